@@ -0,0 +1,387 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var actorKeygenForce bool
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the actor name operations are attributed to",
+	Long: `Show the actor name stash records on operations, where it came from,
+and whether operations by that actor are signed.
+
+The actor is resolved in priority order:
+  1. --actor flag
+  2. $STASH_ACTOR
+  3. The actor configured for the stash with 'stash actor set --stash'
+  4. The actor configured with 'stash actor set'
+  5. git config user.name, or user.email
+  6. $USER
+  7. The OS username
+  8. "unknown"
+
+Sources reported: flag, STASH_ACTOR, stash, config, git, USER, os, fallback.
+
+Examples:
+  stash whoami
+  stash whoami --stash tasks
+  stash whoami --json
+
+Exit Codes:
+  0  Success
+
+JSON Output (--json):
+  {"actor": "alice", "source": "config", "stash": "tasks", "signing_key": true,
+   "fingerprint": "3f9c2a7e51d0b884", "registered": true}`,
+	Args: cobra.NoArgs,
+	RunE: runWhoami,
+}
+
+var actorCmd = &cobra.Command{
+	Use:   "actor",
+	Short: "Manage your actor identity and signing key",
+	Long: `Manage the actor name operations are attributed to and the key used
+to sign them.
+
+Signing makes history attribution verifiable: once an actor has published a
+key, 'stash verify --signatures' reports any operation claiming to be by that
+actor that was not signed with their key, such as one made by passing their
+name to --actor.
+
+Identity and private keys are kept in the user config directory
+(~/.config/stash, or $STASH_CONFIG_DIR). Public keys are published to
+.stash/_keys/ so they can be committed alongside the stash.
+
+Examples:
+  stash actor set alice
+  stash actor keygen
+  stash actor publish`,
+}
+
+var actorSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Set the default actor name",
+	Long: `Set the actor name used when neither --actor nor $STASH_ACTOR is given.
+
+With --stash, the name is used only for operations on that stash, in
+place of the default. Use this to give a stash a dedicated actor, such as
+a bot that owns a queue.
+
+The setting is kept in the user config directory, so it applies to every
+project on this machine but is not shared through git.
+
+Examples:
+  stash actor set alice
+  stash actor set triage-bot --stash inbox
+  stash actor set alice --json
+
+Exit Codes:
+  0  Success
+  2  Validation error (invalid actor name)
+
+JSON Output (--json):
+  {"actor": "alice", "stash": "inbox"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runActorSet,
+}
+
+var actorKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a signing key for the current actor",
+	Long: `Generate an ed25519 signing key for the current actor.
+
+The private key is written to the user config directory with mode 0600.
+When run inside a project with a .stash directory, the public key is also
+published to .stash/_keys/<actor>.pub. From then on every operation by the
+actor is signed.
+
+Replacing an existing key requires --force. Operations signed with the old
+key no longer verify once the new key is published.
+
+Examples:
+  stash actor keygen
+  stash actor keygen --actor ci-bot
+  stash actor keygen --force
+
+Exit Codes:
+  0  Success
+  2  Validation error (invalid actor name, or key exists without --force)
+
+JSON Output (--json):
+  {"actor": "alice", "fingerprint": "3f9c2a7e51d0b884", "published": true}`,
+	Args: cobra.NoArgs,
+	RunE: runActorKeygen,
+}
+
+var actorPublishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish the current actor's public key to this .stash",
+	Long: `Publish the current actor's existing public key to .stash/_keys/, so
+operations they sign in this project can be verified.
+
+Examples:
+  stash actor publish
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found
+  2  The actor has no signing key (run 'stash actor keygen')
+
+JSON Output (--json):
+  {"actor": "alice", "fingerprint": "3f9c2a7e51d0b884", "published": true}`,
+	Args: cobra.NoArgs,
+	RunE: runActorPublish,
+}
+
+func init() {
+	actorKeygenCmd.Flags().BoolVar(&actorKeygenForce, "force", false, "Replace an existing signing key")
+	actorCmd.AddCommand(actorSetCmd)
+	actorCmd.AddCommand(actorKeygenCmd)
+	actorCmd.AddCommand(actorPublishCmd)
+	rootCmd.AddCommand(actorCmd)
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+// validateActorName checks that an actor name can be used as a key file name.
+func validateActorName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("actor name cannot be empty")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid actor name %q", name)
+	}
+	return nil
+}
+
+// signingKeyFor loads the actor's signing key. It is the Signer used by
+// every store the CLI opens.
+func signingKeyFor(actor string) (ed25519.PrivateKey, error) {
+	if validateActorName(actor) != nil {
+		return nil, nil
+	}
+	return context.LoadSigningKey(actor)
+}
+
+// schemaActorFor names the actor changing a stash's schema, for its schema
+// log. It is the actor resolver of every store the CLI opens.
+func schemaActorFor(stash string) string {
+	actor, _ := context.ResolveStashActor(GetActorName(), stash)
+	return actor
+}
+
+// newActorKey builds the public key record for a private key.
+func newActorKey(actor string, key ed25519.PrivateKey) *model.ActorKey {
+	return &model.ActorKey{
+		Actor:     actor,
+		Algorithm: model.SignatureAlgorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Added:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	ctx, err := context.Resolve(GetActorName(), GetStashName())
+	if err != nil {
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+	actor, source := ctx.Actor, ctx.ActorSource
+
+	key, err := signingKeyFor(actor)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key: %w", err)
+	}
+
+	var fingerprint string
+	registered := false
+	if key != nil {
+		pub := newActorKey(actor, key)
+		fingerprint = pub.Fingerprint()
+		if stashDir := context.FindStashDir(); stashDir != "" {
+			store, err := openStore(stashDir)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer store.Close()
+			keys, err := store.ActorKeys()
+			if err != nil {
+				return fmt.Errorf("failed to read actor keys: %w", err)
+			}
+			registered = keys[actor] != nil && keys[actor].PublicKey == pub.PublicKey
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"actor":       actor,
+			"source":      source,
+			"stash":       ctx.Stash,
+			"signing_key": key != nil,
+			"fingerprint": fingerprint,
+			"registered":  registered,
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		fmt.Println(actor)
+		return nil
+	}
+
+	if source == context.ActorSourceStash {
+		fmt.Printf("%s (from stash '%s' config)\n", actor, ctx.Stash)
+	} else {
+		fmt.Printf("%s (from %s)\n", actor, source)
+	}
+	switch {
+	case key == nil:
+		fmt.Println("  Signing: off (run 'stash actor keygen')")
+	case registered:
+		fmt.Printf("  Signing: ed25519 %s, published to this .stash\n", fingerprint)
+	default:
+		fmt.Printf("  Signing: ed25519 %s, not published here (run 'stash actor publish')\n", fingerprint)
+	}
+	return nil
+}
+
+func runActorSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := validateActorName(name); err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"actor": name})
+		return nil
+	}
+
+	identity, err := context.LoadIdentity()
+	if err != nil {
+		return fmt.Errorf("failed to load identity: %w", err)
+	}
+	stash := GetStashName()
+	if stash != "" {
+		if identity.Stashes == nil {
+			identity.Stashes = make(map[string]string)
+		}
+		identity.Stashes[stash] = name
+	} else {
+		identity.Actor = name
+	}
+	if err := context.SaveIdentity(identity); err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{"actor": name}
+		if stash != "" {
+			output["stash"] = stash
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if stash != "" {
+			fmt.Printf("Actor for stash '%s' set to '%s'\n", stash, name)
+		} else {
+			fmt.Printf("Actor set to '%s'\n", name)
+		}
+	}
+	return nil
+}
+
+func runActorKeygen(cmd *cobra.Command, args []string) error {
+	actor, _ := context.ResolveStashActor(GetActorName(), GetStashName())
+	if err := validateActorName(actor); err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"actor": actor})
+		return nil
+	}
+
+	key, err := context.GenerateSigningKey(actor, actorKeygenForce)
+	if err != nil {
+		if errors.Is(err, context.ErrKeyExists) {
+			ExitValidationError(fmt.Sprintf("actor '%s' already has a signing key (use --force to replace it)", actor),
+				map[string]interface{}{"actor": actor})
+			return nil
+		}
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	return publishActorKey(actor, key, false)
+}
+
+func runActorPublish(cmd *cobra.Command, args []string) error {
+	actor, _ := context.ResolveStashActor(GetActorName(), GetStashName())
+	key, err := signingKeyFor(actor)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key: %w", err)
+	}
+	if key == nil {
+		ExitValidationError(fmt.Sprintf("actor '%s' has no signing key (run 'stash actor keygen')", actor),
+			map[string]interface{}{"actor": actor})
+		return nil
+	}
+
+	return publishActorKey(actor, key, true)
+}
+
+// publishActorKey registers the actor's public key in the current .stash
+// directory and reports the result. Without a .stash directory the key is
+// only published when required is set, in which case it is an error.
+func publishActorKey(actor string, key ed25519.PrivateKey, required bool) error {
+	pub := newActorKey(actor, key)
+
+	published := false
+	if stashDir := context.FindStashDir(); stashDir != "" {
+		store, err := openStore(stashDir)
+		if err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		defer store.Close()
+
+		// Keep the original date when republishing the same key, so earlier
+		// unsigned operations are not reported
+		if keys, err := store.ActorKeys(); err == nil {
+			if existing := keys[actor]; existing != nil && existing.PublicKey == pub.PublicKey {
+				pub.Added = existing.Added
+			}
+		}
+		if err := store.PublishKey(pub); err != nil {
+			return fmt.Errorf("failed to publish key: %w", err)
+		}
+		published = true
+	} else if required {
+		ExitNoStashDir()
+		return nil
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"actor":       actor,
+			"fingerprint": pub.Fingerprint(),
+			"published":   published,
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Signing key for '%s': ed25519 %s\n", actor, pub.Fingerprint())
+		if published {
+			fmt.Println("  Published to .stash/_keys/" + actor + ".pub")
+		} else {
+			fmt.Println("  Not published: no .stash directory here (run 'stash actor publish' in a project)")
+		}
+	}
+	return nil
+}
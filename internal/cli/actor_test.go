@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/stash/internal/context"
+)
+
+func TestActor(t *testing.T) {
+	t.Run("actor set changes the default actor", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+		t.Setenv("STASH_ACTOR", "")
+
+		rootCmd.SetArgs([]string{"actor", "set", "alice"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		output := captureSchemaOutput(t, "whoami", "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse whoami output %q: %v", output, err)
+		}
+		if result["actor"] != "alice" || result["source"] != context.ActorSourceConfig {
+			t.Errorf("expected alice from config, got %v", result)
+		}
+		if result["signing_key"] != false {
+			t.Errorf("expected no signing key, got %v", result["signing_key"])
+		}
+	})
+
+	t.Run("actor set --stash overrides the actor for one stash", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+		t.Setenv("STASH_ACTOR", "")
+
+		rootCmd.SetArgs([]string{"actor", "set", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"actor", "set", "triage-bot", "--stash", "inbox"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		output := captureSchemaOutput(t, "whoami", "--stash", "inbox", "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse whoami output %q: %v", output, err)
+		}
+		if result["actor"] != "triage-bot" || result["source"] != context.ActorSourceStash {
+			t.Errorf("expected triage-bot from stash config, got %v", result)
+		}
+
+		output = captureSchemaOutput(t, "whoami", "--stash", "tasks", "--json")
+		result = nil
+		json.Unmarshal([]byte(output), &result)
+		if result["actor"] != "alice" {
+			t.Errorf("expected alice for other stashes, got %v", result)
+		}
+	})
+
+	t.Run("actor set rejects path-like names", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"actor", "set", "../alice"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("keygen refuses to replace a key without --force", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		resetFlags()
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice", "--force"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 with --force, got %d", ExitCode)
+		}
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	t.Run("signed operations verify and survive compaction", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "_keys", "alice.pub")); err != nil {
+			t.Fatalf("expected public key to be published: %v", err)
+		}
+
+		for _, name := range []string{"Laptop", "Mouse"} {
+			rootCmd.SetArgs([]string{"add", name, "--actor", "alice"})
+			rootCmd.Execute()
+			resetFlags()
+		}
+		// Actors without keys are allowed and stay unsigned
+		rootCmd.SetArgs([]string{"add", "Monitor", "--actor", "bob"})
+		rootCmd.Execute()
+		resetFlags()
+
+		output := captureSchemaOutput(t, "verify", "--signatures", "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", ExitCode, output)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse verify output %q: %v", output, err)
+		}
+		if result["valid_signatures"] != float64(2) || result["unsigned"] != float64(1) {
+			t.Errorf("expected 2 valid signatures and 1 unsigned, got %v", result)
+		}
+
+		rootCmd.SetArgs([]string{"sync", "--flush"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"verify", "--signatures"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected signatures to survive compaction, got exit code %d", ExitCode)
+		}
+	})
+
+	t.Run("spoofed actor is reported", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+
+		// Someone without alice's private key claims to be alice
+		keyPath, err := context.SigningKeyPath("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(keyPath); err != nil {
+			t.Fatal(err)
+		}
+
+		rootCmd.SetArgs([]string{"add", "Laptop", "--actor", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"verify", "--signatures"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for spoofed actor, got %d", ExitCode)
+		}
+	})
+
+	t.Run("tampered signed operation is reported", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"actor", "keygen", "--actor", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"add", "Laptop", "--actor", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+
+		logPath := filepath.Join(tempDir, ".stash", "inventory", "records.jsonl")
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tampered := bytes.Replace(data, []byte(`"Name":"Laptop"`), []byte(`"Name":"Tablet"`), 1)
+		if bytes.Equal(data, tampered) {
+			t.Fatal("expected to find the record to tamper with")
+		}
+		if err := os.WriteFile(logPath, tampered, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		rootCmd.SetArgs([]string{"verify", "--signatures"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for tampered record, got %d", ExitCode)
+		}
+	})
+}
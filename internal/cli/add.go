@@ -11,12 +11,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var (
 	addSetFlags  []string
 	addParentID  string
+	addDryRun    bool
 )
 
 var addCmd = &cobra.Command{
@@ -29,14 +29,21 @@ Requires at least one column to be defined first:
 
 The value is assigned to the first (primary) column. Additional fields
 can be set using --set flags. Column names use underscores, not hyphens.
+A --set value of @file reads the value from a file, and @- from stdin;
+use @@ for a value that starts with @.
 
 Records get a unique ID based on the stash prefix (e.g., inv-ex4j).
 Child records can be created with --parent, getting IDs like inv-ex4j.1.
 
+With --dry-run the record is validated and given its ID, and the create
+operation that would be written is printed, but nothing is saved.
+
 Examples:
   stash add "Laptop"
   stash add "Laptop" --set Price=999 --set Category="electronics"
   stash add "Charger" --parent inv-ex4j
+  stash add "Bug report" --set Details=@report.md
+  stash add "Laptop" --set Price=999 --dry-run --json
 
 AI Agent Examples:
   # Capture new record ID for subsequent operations
@@ -53,11 +60,20 @@ AI Agent Examples:
       stash add "$name" --set Price="$price"
   done
 
+Columns declared with --severity warning (see 'stash column add') accept
+values that break their constraints; each violation is printed to stderr
+as a warning, as JSON with --json.
+
 Exit Codes:
-  0  Success - record created
+  0  Success - record created (including with warnings)
   1  Stash or column not found
-  2  Validation error (empty value, invalid field format)
-  4  Parent record not found (with --parent)`,
+  2  Validation error (empty value, invalid field format, unreadable @file)
+  4  Parent record not found (with --parent)
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--dry-run --json):
+  {"dry_run": true, "stash": "inventory",
+   "operations": [{"_id": "inv-ex4j", "_op": "create", "Name": "Laptop", ...}]}`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAdd,
 }
@@ -65,6 +81,7 @@ Exit Codes:
 func init() {
 	addCmd.Flags().StringArrayVar(&addSetFlags, "set", nil, "Set field value (can be repeated): --set Field=Value")
 	addCmd.Flags().StringVar(&addParentID, "parent", "", "Parent record ID for creating child records")
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "Validate and print the record without saving it")
 	rootCmd.AddCommand(addCmd)
 }
 
@@ -92,7 +109,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -120,9 +137,10 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	// Set primary value to first column (AC-07: trimmed)
 	primaryCol := stash.PrimaryColumn()
-	fields[primaryCol.Name] = primaryValue
+	fields[primaryCol.Name] = columnValue(primaryCol, primaryValue)
 
 	// Parse additional --set flags
+	values := &valueReader{stdin: cmd.InOrStdin()}
 	for _, setFlag := range addSetFlags {
 		parts := strings.SplitN(setFlag, "=", 2)
 		if len(parts) != 2 {
@@ -131,32 +149,35 @@ func runAdd(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 		fieldName := strings.TrimSpace(parts[0])
-		fieldValue := strings.TrimSpace(parts[1])
+		fieldValue, err := values.resolve(strings.TrimSpace(parts[1]))
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("cannot read value for %s: %v", fieldName, err),
+				map[string]interface{}{"column": fieldName, "value": parts[1]})
+			return nil
+		}
 
 		// Validate column exists
-		if !stash.Columns.Exists(fieldName) {
-			ExitColumnNotFound(fieldName)
+		col := resolveColumn(stash, fieldName)
+		if col == nil {
 			return nil
 		}
+		if col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set", col.Name),
+				map[string]interface{}{"column": col.Name, "computed": col.Computed})
+			return nil
+		}
+
+		fields[fieldName] = columnValue(col, fieldValue)
+	}
 
-		fields[fieldName] = fieldValue
+	if !checkPermission(stash, ctx.Actor, model.PermCreate, fieldNames(fields)) {
+		return nil
 	}
 
 	// Validate fields against column constraints
+	coerced := coerceEnums(stash, fields)
 	validationResult := ValidateFields(stash, fields)
-	if !validationResult.Valid {
-		// Report first validation error
-		if len(validationResult.Errors) > 0 {
-			err := validationResult.Errors[0]
-			ExitValidationError(err.Message,
-				map[string]interface{}{
-					"column": err.Column,
-					"value":  err.Value,
-					"rule":   err.Rule,
-				})
-			return nil
-		}
-		ExitValidationError("validation failed", nil)
+	if exitViolation(validationResult) {
 		return nil
 	}
 
@@ -184,7 +205,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		parentID = addParentID
 	} else {
 		// Generate new root ID
-		recordID, err = model.GenerateID(stash.Prefix)
+		recordID, err = store.NextRecordID(ctx.Stash)
 		if err != nil {
 			return fmt.Errorf("failed to generate ID: %w", err)
 		}
@@ -201,10 +222,35 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		UpdatedBy: ctx.Actor,
 		Branch:    ctx.Branch,
 		Fields:    fields,
+		Coerced:   coerced,
+	}
+
+	// Run the stash's validation hooks on the complete record
+	hookResult := ValidateHooks(stash, record, nil)
+	if exitViolation(hookResult) {
+		return nil
+	}
+	validationResult.merge(hookResult)
+
+	// Violations of warning-severity columns are accepted, and reported
+	for i := range validationResult.Warnings {
+		validationResult.Warnings[i].RecordID = recordID
+	}
+	printValidationWarnings(validationResult.Warnings)
+
+	if addDryRun {
+		ops, err := previewOperations(store, ctx.Stash, []*model.Record{record}, model.OpCreate)
+		if err != nil {
+			return err
+		}
+		return outputDryRun(ctx.Stash, ops, nil)
 	}
 
 	// Save record
 	if err := store.CreateRecord(ctx.Stash, record); err != nil {
+		if exitRecordTooLarge(ctx.Stash, err) || exitWriteRefused(err) {
+			return nil
+		}
 		return fmt.Errorf("failed to create record: %w", err)
 	}
 
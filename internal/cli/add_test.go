@@ -18,17 +18,48 @@ func resetFlags() {
 	// Reset add command flags
 	addSetFlags = nil
 	addParentID = ""
+	addDryRun = false
 	// Reset set command flags
 	setColFlags = nil
 	setAutoCreate = false
+	setForce = false
+	setDryRun = false
+	upsertSetFlags = nil
+	upsertMatch = nil
+	upsertDryRun = false
+	upsertForce = false
+	undoRun = ""
+	undoDryRun = false
+	undoForce = false
 	// Reset column command flags
 	columnDesc = ""
 	columnValidate = ""
 	columnEnum = ""
 	columnRequired = false
+	columnComputed = ""
+	columnTransitions = ""
+	columnDue = false
+	columnWarn = false
+	columnSeverity = ""
+	columnList = false
+	columnHook = ""
+	columnCoerce = false
+	columnUnit = ""
+	columnDecimals = -1
+	columnThousands = false
+	openForce = false
+	columnDryRun = false
+	columnDescribeEdit = false
+	columnDescribeEnforce = ""
+	// Reset validate command flags
+	validateReport = ""
+	validateFailOn = "error"
+	validateSince = ""
 	// Reset show command flags
 	showWithFiles = false
 	showHistory = false
+	showProvenance = false
+	showFields = nil
 	// Reset list command flags
 	listAll = false
 	listDeleted = false
@@ -39,28 +70,135 @@ func resetFlags() {
 	listDesc = false
 	listWhere = nil
 	listSearch = ""
+	listSearchMode = "ci"
 	listColumns = ""
+	listArchived = false
+	listAssignedTo = ""
+	listCreatedBy = ""
+	listUpdatedSince = ""
+	listDeletedBy = ""
+	listBranch = ""
+	listRecursive = false
+	listDepth = 0
+	listCSV = false
+	listTSV = false
+	listNoHeaders = false
+	listExplain = false
+	listNoPrefs = false
+	noDefaultFilter = false
+	listSample = 0
+	listSeed = 0
+	listHead = 0
+	listTail = 0
+	// Reset limits command flags
+	limitsMaxRecord = ""
+	limitsMaxField = ""
+	limitsReset = false
+
+	// Reset quotas command flags
+	quotasMaxRecords = ""
+	quotasWritesPerMinute = ""
+	quotasAgent = ""
+	quotasReset = false
+	// Reset normalize command flags
+	normalizeMap = nil
+	normalizeTrim = false
+	normalizeLower = false
+	normalizeUpper = false
+	normalizeWhere = nil
+	normalizeDryRun = false
+	// Reset grep command flags
+	grepRegex = false
+	grepIgnoreCase = false
+	grepAllStashes = false
+	grepFiles = false
+	// Reset changelog command flags
+	changelogSince = ""
+	changelogLimit = 0
+	changelogSchema = false
+	// Reset wait command flags
+	waitWhere = nil
+	waitSince = -1
+	waitTimeout = 60
+	// Reset stats command flags
+	statsHot = false
+	statsCold = false
+	statsLimit = 10
+	statsTrackReads = false
+	statsNoTrackReads = false
+	statsReset = false
+	// Reset fingerprint command flags
+	fingerprintCheck = ""
+	fingerprintAll = false
 	// Reset count command flags
 	countAll = false
 	countDeleted = false
 	countWhere = nil
+	countArchived = false
 	// Reset rm command flags
 	rmCascade = false
+	rmDepth = 0
+	rmDryRun = false
 	rmYes = false
 	// Reset restore command flags
 	restoreCascade = false
+	restoreDepth = 0
+	restoreDryRun = false
+	restoreBefore = ""
+	restoreWhere = nil
+	restoreRevert = false
+	trashDeletedBy = ""
+	trashOlderThan = ""
+	trashRestoreAll = false
+	trashPurgeOlderThan = ""
+	trashDryRun = false
+	trashYes = false
 	// Reset purge command flags
 	purgeID = ""
 	purgeBefore = ""
 	purgeAll = false
 	purgeDryRun = false
 	purgeYes = false
+	purgeExpired = false
+	// Reset retention command flags
+	retentionClear = false
+	retentionAutoPurge = false
+	retentionNoAutoPurge = false
+	// Reset verify command flags
+	verifyEnable = false
+	verifySignatures = false
+	verifyDeep = false
+	// Reset actor command flags
+	actorKeygenForce = false
+	// Reset permissions command flags
+	permissionsOps = ""
+	permissionsColumns = ""
+	// Reset exec command flags
+	execScript = ""
+	execKeepGoing = false
 	// Reset history command flags
 	historyBy = ""
+	historyRun = ""
 	historySince = ""
 	historyLimit = 0
+	historyField = ""
+	historyFormat = ""
+	historyColumn = ""
+	// Reset locks command flags
+	locksPrune = false
+	locksStats = false
+	locksSince = ""
+	locksBefore = ""
 	// Reset attach command flags
 	attachMove = false
+	attachStdin = false
+	attachName = ""
+	// Reset file policy command flags
+	filePolicyMaxSize = ""
+	filePolicyExt = ""
+	filePolicyMime = ""
+	filePolicyScanner = ""
+	filePolicyClear = false
 	// Reset move command flags
 	moveParentID = ""
 	// Reset init-claude command flags
@@ -69,25 +207,107 @@ func resetFlags() {
 	// Reset migrate command flags
 	migrateDryRun = false
 	migrateInspect = false
+	// Reset transitions command flags
+	transitionsSet = ""
+	transitionsClear = false
+	// Reset due/remind command flags
+	dueOverdue = false
+	dueWithin = ""
+	dueColumn = ""
+	remindExec = ""
+	remindWithin = ""
+	remindColumn = ""
+	remindDryRun = false
+	// Reset schema command flags
+	schemaYAML = false
+	schemaDryRun = false
+	// Reset init command flags
+	initPrefix = ""
+	initFromSchema = ""
+	initPreset = ""
+	initIDStrategy = ""
+	initIDTemplate = ""
+	initHashChain = false
+	initRequireDescriptions = false
+	// Reset prefix command flags
+	prefixMigrate = false
 	// Reset query command flags
 	queryCSV = false
 	queryNoHeaders = false
 	queryColumns = ""
+	queryExplain = false
+	querySample = 0
+	querySeed = 0
+	queryHead = 0
+	queryTail = 0
+	templateSchedule = ""
+	templateOwner = ""
+	templateOutput = ""
+	templateListSchedule = ""
+	templateInto = ""
+	replayInto = ""
+	replayStrict = false
+	replayLenient = false
+	templatePrefix = ""
+	templateReplace = false
 	// Reset bulk-set command flags
 	bulkSetWhere = nil
 	bulkSetSet = nil
 	// Reset search command flags
 	searchIn = nil
+	searchMode = "ci"
 	// Reset status command flags
 	statusProcessing = true
 	statusAgent = ""
+	// Reset audit command flags
+	auditSince = ""
+	auditUntil = ""
+	auditOps = nil
+	auditLimit = 0
+	auditCSV = false
+	// Reset distinct command flags
+	distinctWhere = nil
+	distinctArchived = false
+	distinctLimit = 0
+	// Reset tree command flags
+	treeDepth = 0
+	treeColumns = nil
+	// Reset adopt command flags
+	adoptParent = ""
+	adoptToRoot = false
+	benchRecords = 10000
+	sessionEphemeral = false
+	benchProfile = ""
+	benchProfileOutput = ""
+	describeExamples = 3
+	// Reset rule command flags
+	ruleWhen = ""
+	ruleSeverity = ""
+	// Reset hook command flags
+	hookColumn = ""
+	hookRevoke = false
+	// Reset checkout command flags
+	checkoutReset = false
+	// Reset registry command flags
+	registryName = ""
 	// Reset global flags
 	jsonOutput = false
 	stashName = ""
 	actorName = ""
+	sourceName = ""
+	runID = ""
+	txFlag = ""
 	quiet = false
 	verbose = false
+	porcelain = false
+	noColor = false
+	wide = false
+	stashDir = ""
 	noDaemon = false
+	logLevel = ""
+	logFile = ""
+	commandTimeout = 0
+	endTimeout()
 }
 
 // setupTestStashWithColumns creates a test stash with columns for testing
@@ -0,0 +1,139 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	adoptParent string
+	adoptToRoot bool
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <id>",
+	Short: "Re-parent an orphaned record",
+	Long: `Give an orphaned record a new parent, or make it a root record.
+
+A record is orphaned when its parent no longer exists, for example after
+the parent was purged. 'stash doctor' reports orphaned records. Adopting
+one moves it, with its descendants, the same way 'stash move' does: the
+records get new IDs that reflect the new hierarchy.
+
+Options:
+  --parent ID   Adopt the record under this parent
+  --to-root     Make the record a root record
+
+Examples:
+  stash adopt inv-ex4j.2 --parent inv-ab12
+  stash adopt inv-ex4j.2 --to-root
+
+AI Agent Examples:
+  # Move every orphan reported by doctor to the root
+  stash doctor --json | jq -r '.checks[] | select(.check == "tasks/orphans") | .details' \
+    | sed 's/, /\n/g' | xargs -n1 stash adopt --stash tasks --to-root
+
+Exit Codes:
+  0  Success
+  1  Stash or record not found
+  2  Validation error (record is not orphaned, neither or both of --parent and --to-root)
+  4  New parent not found or deleted
+
+JSON Output (--json):
+  {"old_id": "inv-ex4j.2", "new_id": "inv-ab12.1", "parent_id": "inv-ab12", "moved": 1}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdopt,
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptParent, "parent", "", "New parent record ID")
+	adoptCmd.Flags().BoolVar(&adoptToRoot, "to-root", false, "Make the record a root record")
+	rootCmd.AddCommand(adoptCmd)
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	recordID := args[0]
+	if (adoptParent == "") == !adoptToRoot {
+		ExitValidationError("specify exactly one of --parent or --to-root", nil)
+		return nil
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, nil) {
+		return nil
+	}
+
+	record, err := store.GetRecord(ctx.Stash, recordID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			ExitRecordNotFound(recordID)
+			return nil
+		}
+		if errors.Is(err, model.ErrRecordDeleted) {
+			ExitRecordDeleted(recordID)
+			return nil
+		}
+		return fmt.Errorf("failed to get record: %w", err)
+	}
+
+	// Only orphans are adopted; attached records are moved with 'stash move'
+	if record.ParentID == "" {
+		ExitValidationError(fmt.Sprintf("record '%s' is a root record, not an orphan", recordID),
+			map[string]interface{}{"record_id": recordID})
+		return nil
+	}
+	if _, err := store.GetRecordIncludeDeleted(ctx.Stash, record.ParentID); err == nil {
+		ExitValidationError(fmt.Sprintf("record '%s' is not orphaned: its parent '%s' exists (use 'stash move')", recordID, record.ParentID),
+			map[string]interface{}{"record_id": recordID, "parent_id": record.ParentID})
+		return nil
+	} else if !errors.Is(err, model.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get parent record: %w", err)
+	}
+
+	if adoptParent != "" {
+		if _, err := store.GetRecord(ctx.Stash, adoptParent); err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) || errors.Is(err, model.ErrRecordDeleted) {
+				ExitReferenceError(fmt.Sprintf("parent record '%s' not found", adoptParent),
+					map[string]interface{}{"parent_id": adoptParent})
+				return nil
+			}
+			return fmt.Errorf("failed to get parent record: %w", err)
+		}
+		if adoptParent == recordID || model.IsDescendantOf(adoptParent, recordID) {
+			ExitValidationError("cannot adopt a record under itself or its own descendant", nil)
+			return nil
+		}
+	}
+
+	newRecordID, _, movedRecords, err := moveRecord(store, ctx, record, adoptParent)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"old_id":    recordID,
+			"new_id":    newRecordID,
+			"parent_id": adoptParent,
+			"moved":     len(movedRecords),
+		})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("%s -> %s\n", recordID, newRecordID)
+		if len(movedRecords) > 1 {
+			fmt.Printf("Moved %d record(s)\n", len(movedRecords))
+		}
+	}
+	return nil
+}
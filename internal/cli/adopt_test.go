@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestAdopt(t *testing.T) {
+	// setup creates a root record and an orphan whose parent inv-gone was purged
+	setup := func(t *testing.T) (string, func()) {
+		t.Helper()
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		var root map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &root)
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		now := time.Now()
+		for _, id := range []string{"inv-gone.1", "inv-gone.1.1"} {
+			if err := store.CreateRecord("inventory", &model.Record{
+				ID: id, ParentID: model.GetParentID(id), CreatedAt: now, CreatedBy: "test",
+				UpdatedAt: now, UpdatedBy: "test", Fields: map[string]interface{}{"Name": id},
+			}); err != nil {
+				t.Fatalf("failed to create record: %v", err)
+			}
+		}
+		store.Close()
+		ExitCode = 0
+		return root["_id"].(string), cleanup
+	}
+
+	t.Run("doctor reports orphans", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		defer rootCmd.SetOut(nil)
+		rootCmd.SetArgs([]string{"doctor", "--json"})
+		rootCmd.Execute()
+		resetFlags()
+
+		var report DoctorOutput
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("failed to parse doctor output: %v\n%s", err, out.String())
+		}
+		for _, check := range report.Checks {
+			if check.Check == "inventory/orphans" {
+				if check.Status != "warning" || check.Details != "inv-gone.1" {
+					t.Errorf("expected inv-gone.1 reported, got %+v", check)
+				}
+				return
+			}
+		}
+		t.Error("expected an orphans check")
+	})
+
+	t.Run("adopt under a new parent moves descendants", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		output := captureSchemaOutput(t, "adopt", "inv-gone.1", "--parent", rootID, "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["new_id"] != rootID+".1" || result["moved"] != float64(2) {
+			t.Errorf("expected adoption under %s, got %v", rootID, result)
+		}
+		captureSchemaOutput(t, "show", rootID+".1.1")
+		if ExitCode != 0 {
+			t.Errorf("expected the orphan's child to move too, got exit code %d", ExitCode)
+		}
+	})
+
+	t.Run("adopt to root", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		output := captureSchemaOutput(t, "adopt", "inv-gone.1", "--to-root", "--json")
+		var result map[string]interface{}
+		json.Unmarshal([]byte(output), &result)
+		newID, _ := result["new_id"].(string)
+		if newID == "" || strings.Contains(newID, ".") {
+			t.Errorf("expected a root ID, got %v", result)
+		}
+	})
+
+	t.Run("rejects records that are not orphaned", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "adopt", "inv-gone.1.1", "--to-root")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for an attached record, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "adopt", rootID, "--to-root")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a root record, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "adopt", "inv-gone.1")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 without --parent or --to-root, got %d", ExitCode)
+		}
+	})
+
+	t.Run("purge refuses a parent with live children", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Battery", "--parent", rootID)
+		captureSchemaOutput(t, "add", "Cell", "--parent", rootID+".1")
+		// Deletes the root and Battery, leaving Cell live
+		captureSchemaOutput(t, "rm", rootID, "--cascade", "--depth", "1", "--yes")
+		ExitCode = 0
+		captureSchemaOutput(t, "purge", "--id", rootID+".1", "--yes")
+		if ExitCode != 4 {
+			t.Errorf("expected exit code 4, got %d", ExitCode)
+		}
+	})
+}
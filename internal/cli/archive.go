@@ -0,0 +1,196 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <id> [id...]",
+	Short: "Archive records without deleting them",
+	Long: `Archive one or more records by setting _archived_at and _archived_by.
+
+Archiving is for "done but keep around" records. Archived records:
+  - Are hidden from default 'stash list' and 'stash count' output
+  - Remain visible with 'stash list --archived', 'stash show', and 'stash query'
+  - Are skipped by 'stash validate'
+  - Keep their full history (the archive is recorded as an operation)
+
+Use 'stash unarchive' to return records to the active set.
+
+Examples:
+  stash archive inv-ex4j
+  stash archive inv-ex4j inv-8t5n
+  stash list --archived
+
+AI Agent Examples:
+  # Archive everything marked done
+  stash list --where "status=done" --json | jq -r '.[]._id' | xargs stash archive
+
+Exit Codes:
+  0  Success
+  1  Record not found, or already archived
+  3  Record is deleted
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--json):
+  {"archived": 2, "ids": ["inv-ex4j", "inv-8t5n"]}`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runArchive,
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive <id> [id...]",
+	Short: "Return archived records to the active set",
+	Long: `Unarchive one or more records by clearing _archived_at and _archived_by.
+
+Examples:
+  stash unarchive inv-ex4j
+  stash unarchive inv-ex4j --json
+
+Exit Codes:
+  0  Success
+  1  Record not found, or not archived
+  3  Record is deleted
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--json):
+  {"unarchived": 1, "ids": ["inv-ex4j"]}`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runUnarchive,
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	return runArchiveOp(args, true)
+}
+
+func runUnarchive(cmd *cobra.Command, args []string) error {
+	return runArchiveOp(args, false)
+}
+
+// runArchiveOp archives (or unarchives) each record in ids.
+// All records are checked before any are changed.
+func runArchiveOp(ids []string, archive bool) error {
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	if !checkPermission(stash, ctx.Actor, model.PermArchive, nil) {
+		return nil
+	}
+
+	// Verify every record before changing any
+	for _, id := range ids {
+		record, err := store.GetRecord(ctx.Stash, id)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				ExitRecordNotFound(id)
+				return nil
+			}
+			if errors.Is(err, model.ErrRecordDeleted) {
+				ExitRecordDeleted(id)
+				return nil
+			}
+			return fmt.Errorf("failed to get record: %w", err)
+		}
+
+		if archive && record.IsArchived() {
+			ExitWithError(1, ErrCodeConflict, fmt.Sprintf("record '%s' is already archived", id),
+				map[string]interface{}{"record_id": id})
+			return nil
+		}
+		if !archive && !record.IsArchived() {
+			ExitWithError(1, ErrCodeConflict, fmt.Sprintf("record '%s' is not archived", id),
+				map[string]interface{}{"record_id": id})
+			return nil
+		}
+
+		lock, err := CheckLock(ctx.StashDir, ctx.Stash, id, ctx.Actor)
+		if err != nil {
+			return fmt.Errorf("failed to check lock: %w", err)
+		}
+		if lock != nil {
+			ExitRecordLocked(id, lock)
+			return nil
+		}
+	}
+
+	for _, id := range ids {
+		if archive {
+			err = store.ArchiveRecord(ctx.Stash, id, ctx.Actor)
+		} else {
+			err = store.UnarchiveRecord(ctx.Stash, id, ctx.Actor)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update record %s: %w", id, err)
+		}
+	}
+
+	// Output result
+	verb := "Archived"
+	key := "archived"
+	if !archive {
+		verb = "Unarchived"
+		key = "unarchived"
+	}
+
+	if GetJSONOutput() {
+		result := map[string]interface{}{
+			key:   len(ids),
+			"ids": ids,
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if len(ids) == 1 {
+			fmt.Printf("%s %s\n", verb, ids[0])
+		} else {
+			fmt.Printf("%s %d record(s)\n", verb, len(ids))
+		}
+	}
+
+	return nil
+}
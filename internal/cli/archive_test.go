@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// setupArchiveStash creates a stash with one record and returns its ID.
+func setupArchiveStash(t *testing.T) (tempDir string, recordID string, cleanup func()) {
+	t.Helper()
+	tempDir, cleanup = setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+
+	rootCmd.SetArgs([]string{"add", "Task"})
+	rootCmd.Execute()
+
+	store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	records, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	ExitCode = 0
+	resetFlags()
+	return tempDir, records[0].ID, cleanup
+}
+
+func TestArchive(t *testing.T) {
+	t.Run("archive hides record from default list", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupArchiveStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+
+		active, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*", ExcludeArchived: true})
+		if len(active) != 0 {
+			t.Errorf("expected 0 active records, got %d", len(active))
+		}
+		archived, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*", ArchivedOnly: true})
+		if len(archived) != 1 {
+			t.Fatalf("expected 1 archived record, got %d", len(archived))
+		}
+		if archived[0].ArchivedBy == "" || archived[0].ArchivedAt == nil {
+			t.Error("expected _archived_at and _archived_by to be set")
+		}
+
+		history, _ := store.GetRecordHistory("tasks", recordID)
+		if len(history) == 0 || history[len(history)-1].Operation != model.OpArchive {
+			t.Error("expected archive operation in history")
+		}
+	})
+
+	t.Run("list --archived shows archived records", func(t *testing.T) {
+		_, recordID, cleanup := setupArchiveStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		resetFlags()
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		rootCmd.SetArgs([]string{"list", "--archived", "--json"})
+		rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+
+		if !strings.Contains(buf.String(), recordID) {
+			t.Errorf("expected %s in archived list, got: %s", recordID, buf.String())
+		}
+	})
+
+	t.Run("unarchive returns record to active set", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupArchiveStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"unarchive", recordID})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+
+		active, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*", ExcludeArchived: true})
+		if len(active) != 1 {
+			t.Errorf("expected 1 active record, got %d", len(active))
+		}
+	})
+
+	t.Run("archiving twice fails", func(t *testing.T) {
+		_, recordID, cleanup := setupArchiveStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+
+	t.Run("validate skips archived records", func(t *testing.T) {
+		_, recordID, cleanup := setupArchiveStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "add", "Owner", "--required"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"validate"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Fatalf("expected exit code 2 before archiving, got %d", ExitCode)
+		}
+		ExitCode = 0
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"archive", recordID})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"validate"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 after archiving, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var assignCmd = &cobra.Command{
+	Use:   "assign <id> <agent>",
+	Short: "Assign a record to an agent",
+	Long: `Assign a record to an agent by setting _assigned_to.
+
+Assignment records who is meant to work on a record. It is long-lived
+intent, kept until the record is reassigned or unassigned, and does not
+stop anyone else from changing the record. Use 'stash lock' for short-lived
+exclusive access while an agent is making changes.
+
+Assigning a record again replaces the earlier assignee. Each assignment is
+recorded as an operation, so 'stash history' shows who assigned what to
+whom, and when. Use "me" for the current actor.
+
+Find assigned records with 'stash list --assigned-to <agent>', or
+--assigned-to me.
+
+Examples:
+  stash assign inv-ex4j alice
+  stash assign inv-ex4j me
+  stash list --assigned-to alice
+
+AI Agent Examples:
+  # Claim the next unassigned record and work on it
+  ID=$(stash list --where "_assigned_to IS NULL" --limit 1 --json | jq -r '.[0]._id')
+  stash assign "$ID" me
+
+Exit Codes:
+  0  Success (including a record already assigned to the agent)
+  1  Record not found
+  2  Validation error (empty agent)
+  3  Record is deleted
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--json):
+  {"record_id": "inv-ex4j", "assigned_to": "alice", "previous": "bob"}`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAssign,
+}
+
+var unassignCmd = &cobra.Command{
+	Use:   "unassign <id>",
+	Short: "Clear a record's assignment",
+	Long: `Clear a record's assignment by removing _assigned_to.
+
+The unassignment is recorded as an operation in the record's history.
+
+Examples:
+  stash unassign inv-ex4j
+  stash unassign inv-ex4j --json
+
+Exit Codes:
+  0  Success
+  1  Record not found, or not assigned
+  3  Record is deleted
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--json):
+  {"record_id": "inv-ex4j", "assigned_to": null, "previous": "alice"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUnassign,
+}
+
+func init() {
+	rootCmd.AddCommand(assignCmd)
+	rootCmd.AddCommand(unassignCmd)
+}
+
+// resolveAssignee returns the agent an assignment argument names: the
+// actor for "me", otherwise the argument itself.
+func resolveAssignee(agent, actor string) string {
+	agent = strings.TrimSpace(agent)
+	if strings.EqualFold(agent, "me") {
+		return actor
+	}
+	return agent
+}
+
+func runAssign(cmd *cobra.Command, args []string) error {
+	return runAssignOp(args[0], args[1], true)
+}
+
+func runUnassign(cmd *cobra.Command, args []string) error {
+	return runAssignOp(args[0], "", false)
+}
+
+// runAssignOp assigns a record to agent, or unassigns it.
+func runAssignOp(id, agent string, assign bool) error {
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	if assign {
+		agent = resolveAssignee(agent, ctx.Actor)
+		if agent == "" {
+			ExitValidationError("agent must not be empty", nil)
+			return nil
+		}
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, nil) {
+		return nil
+	}
+
+	record, err := store.GetRecord(ctx.Stash, id)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			ExitRecordNotFound(id)
+			return nil
+		}
+		if errors.Is(err, model.ErrRecordDeleted) {
+			ExitRecordDeleted(id)
+			return nil
+		}
+		return fmt.Errorf("failed to get record: %w", err)
+	}
+	previous := record.AssignedTo
+
+	if !assign && previous == "" {
+		ExitWithError(1, ErrCodeConflict, fmt.Sprintf("record '%s' is not assigned", id),
+			map[string]interface{}{"record_id": id})
+		return nil
+	}
+
+	lock, err := CheckLock(ctx.StashDir, ctx.Stash, id, ctx.Actor)
+	if err != nil {
+		return fmt.Errorf("failed to check lock: %w", err)
+	}
+	if lock != nil {
+		ExitRecordLocked(id, lock)
+		return nil
+	}
+
+	// Reassigning to the current assignee changes nothing
+	if !assign {
+		err = store.UnassignRecord(ctx.Stash, id, ctx.Actor)
+	} else if agent != previous {
+		err = store.AssignRecord(ctx.Stash, id, agent, ctx.Actor)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update record %s: %w", id, err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		result := map[string]interface{}{
+			"record_id":   id,
+			"assigned_to": nil,
+			"previous":    nil,
+		}
+		if agent != "" {
+			result["assigned_to"] = agent
+		}
+		if previous != "" {
+			result["previous"] = previous
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if assign {
+			fmt.Printf("Assigned %s to %s\n", id, agent)
+		} else {
+			fmt.Printf("Unassigned %s (was %s)\n", id, previous)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAssign(t *testing.T) {
+	// addTask adds a record and returns its ID
+	addTask := func(t *testing.T, name string, args ...string) string {
+		t.Helper()
+		var rec map[string]interface{}
+		output := captureSchemaOutput(t, append([]string{"add", name, "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &rec); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return rec["_id"].(string)
+	}
+
+	// listAssigned returns the IDs list --assigned-to prints
+	listAssigned := func(t *testing.T, who string, args ...string) []string {
+		t.Helper()
+		var records []map[string]interface{}
+		output := captureSchemaOutput(t, append([]string{"list", "--assigned-to", who, "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		ids := make([]string, len(records))
+		for i, rec := range records {
+			ids[i] = rec["_id"].(string)
+		}
+		return ids
+	}
+
+	t.Run("assign sets _assigned_to and list filters by it", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+		first := addTask(t, "Write docs")
+		second := addTask(t, "Fix bug")
+		child := addTask(t, "Subtask", "--parent", second)
+
+		ExitCode = 0
+		var result map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "assign", first, "alice", "--json")), &result)
+		if ExitCode != 0 || result["assigned_to"] != "alice" || result["previous"] != nil {
+			t.Errorf("unexpected result (exit %d): %v", ExitCode, result)
+		}
+		captureSchemaOutput(t, "assign", child, "me", "--actor", "bob")
+
+		var shown map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "show", first, "--json")), &shown)
+		if shown["_assigned_to"] != "alice" {
+			t.Errorf("expected _assigned_to alice, got %v", shown["_assigned_to"])
+		}
+
+		if ids := listAssigned(t, "alice"); len(ids) != 1 || ids[0] != first {
+			t.Errorf("expected alice to have %s, got %v", first, ids)
+		}
+		if ids := listAssigned(t, "me", "--actor", "bob"); len(ids) != 1 || ids[0] != child {
+			t.Errorf("expected bob to have child %s, got %v", child, ids)
+		}
+		if ids := listAssigned(t, "carol"); len(ids) != 0 {
+			t.Errorf("expected carol to have nothing, got %v", ids)
+		}
+	})
+
+	t.Run("reassign and unassign are recorded in history", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+		id := addTask(t, "Write docs")
+
+		captureSchemaOutput(t, "assign", id, "alice")
+		var result map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "assign", id, "bob", "--json")), &result)
+		if result["previous"] != "alice" {
+			t.Errorf("expected previous alice, got %v", result["previous"])
+		}
+		// Assigning to the current assignee records nothing
+		captureSchemaOutput(t, "assign", id, "bob")
+		json.Unmarshal([]byte(captureSchemaOutput(t, "unassign", id, "--json")), &result)
+		if result["assigned_to"] != nil || result["previous"] != "bob" {
+			t.Errorf("unexpected unassign result: %v", result)
+		}
+
+		var history []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "history", id, "--json")), &history)
+		var ops []string
+		for _, entry := range history {
+			ops = append(ops, entry["_op"].(string))
+		}
+		if got := strings.Join(ops, ","); got != "unassign,assign,assign,create" {
+			t.Errorf("expected unassign,assign,assign,create, got %s", got)
+		}
+
+		if ids := listAssigned(t, "bob"); len(ids) != 0 {
+			t.Errorf("expected no records assigned to bob, got %v", ids)
+		}
+	})
+
+	t.Run("assignment survives updates and is separate from locks", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+		id := addTask(t, "Write docs")
+
+		captureSchemaOutput(t, "assign", id, "alice")
+		captureSchemaOutput(t, "set", id, "Name=Write more docs", "--actor", "bob")
+		if ids := listAssigned(t, "alice"); len(ids) != 1 {
+			t.Errorf("expected the assignment to survive an update, got %v", ids)
+		}
+
+		var locks []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "locks", "--json")), &locks)
+		if len(locks) != 0 {
+			t.Errorf("expected assignment not to lock the record, got %v", locks)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+		id := addTask(t, "Write docs")
+
+		for _, tc := range []struct {
+			args []string
+			code int
+		}{
+			{[]string{"unassign", id}, 1},
+			{[]string{"assign", "tk-zzzz", "alice"}, 1},
+			{[]string{"assign", id, " "}, 2},
+		} {
+			ExitCode = 0
+			captureSchemaOutput(t, tc.args...)
+			if ExitCode != tc.code {
+				t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.code, ExitCode)
+			}
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-1")
+		resetLockFlags()
+		captureSchemaOutput(t, "assign", id, "alice", "--actor", "agent-2")
+		if ExitCode != 5 {
+			t.Errorf("expected exit code 5 for a locked record, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
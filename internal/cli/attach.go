@@ -15,48 +15,94 @@ import (
 )
 
 var (
-	attachMove bool
+	attachMove  bool
+	attachStdin bool
+	attachName  string
 )
 
 var attachCmd = &cobra.Command{
-	Use:   "attach <record-id> <file>",
+	Use:   "attach <record-id> [file]",
 	Short: "Attach a file to a record",
 	Long: `Attach a file to a record in the current stash.
 
 The file is copied to .stash/<stash>/files/<record-id>/<filename>.
-Use --move to move the file instead of copying.
+Use --move to move the file instead of copying, and --name to store it
+under another name.
+
+With --stdin, the attachment is read from standard input instead of a
+file, and --name is required. Nothing is attached if the input fails part
+way. Read attachments back with 'stash file cat'.
 
 File metadata (name, size, hash, attached_at, attached_by) is tracked.
+Files the stash's attachment policy rejects are not attached (see
+'stash file policy').
 
 Examples:
   stash attach inv-ex4j document.pdf
   stash attach inv-ex4j image.png --move
-  stash attach inv-ex4j ./docs/spec.md --json`,
-	Args: cobra.ExactArgs(2),
+  stash attach inv-ex4j ./docs/spec.md --json
+  stash attach inv-ex4j draft.md --name spec.md
+  make test 2>&1 | stash attach inv-ex4j --stdin --name test.log`,
+	Args: cobra.RangeArgs(1, 2),
 	RunE: runAttach,
 }
 
 func init() {
-	attachCmd.Flags().BoolVar(&attachMove, "move", false, "Move file instead of copying")
+	addAttachFlags(attachCmd)
 	rootCmd.AddCommand(attachCmd)
 }
 
+// addAttachFlags adds the flags of attach, shared with 'file attach'.
+func addAttachFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&attachMove, "move", false, "Move file instead of copying")
+	cmd.Flags().BoolVar(&attachStdin, "stdin", false, "Read the attachment from standard input (requires --name)")
+	cmd.Flags().StringVar(&attachName, "name", "", "Attachment name (default: the file's name)")
+}
+
 func runAttach(cmd *cobra.Command, args []string) error {
 	recordID := args[0]
-	filePath := args[1]
 
-	// Check if source file exists
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid file path: %s\n", filePath)
-		Exit(2)
+	// The content comes from a file, or from stdin
+	filePath, absPath := "", ""
+	switch {
+	case attachStdin && len(args) > 1:
+		ExitValidationError("--stdin cannot be combined with a file argument", nil)
+		return nil
+	case attachStdin && attachMove:
+		ExitValidationError("--move cannot be used with --stdin", nil)
 		return nil
+	case attachStdin && attachName == "":
+		ExitValidationError("--stdin requires --name", nil)
+		return nil
+	case !attachStdin && len(args) < 2:
+		ExitValidationError("give a file to attach, or --stdin with --name", nil)
+		return nil
+	}
+	if attachName != "" {
+		if err := model.ValidateAttachmentName(attachName); err != nil {
+			ExitValidationError(fmt.Sprintf("invalid attachment name '%s': must be a file name without a path", attachName),
+				map[string]interface{}{"name": attachName})
+			return nil
+		}
 	}
 
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", filePath)
-		Exit(2)
-		return nil
+	if !attachStdin {
+		filePath = args[1]
+
+		// Check if source file exists
+		var err error
+		absPath, err = filepath.Abs(filePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid file path: %s\n", filePath)
+			Exit(2)
+			return nil
+		}
+
+		if _, err := os.Stat(absPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", filePath)
+			Exit(2)
+			return nil
+		}
 	}
 
 	// Resolve context
@@ -76,14 +122,14 @@ func runAttach(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Verify stash exists
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -93,8 +139,12 @@ func runAttach(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermAttach, nil) {
+		return nil
+	}
+
 	// Attach the file
-	attachment, err := store.AttachFile(ctx.Stash, recordID, absPath, attachMove, ctx.Actor)
+	attachment, err := attachContent(cmd, store, ctx.Stash, recordID, absPath, ctx.Actor)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: record '%s' not found\n", recordID)
@@ -111,8 +161,17 @@ func runAttach(cmd *cobra.Command, args []string) error {
 			Exit(2)
 			return nil
 		}
+		var policyErr *model.AttachmentPolicyError
+		if errors.As(err, &policyErr) {
+			ExitAttachmentRejected(ctx.Stash, recordID, policyErr)
+			return nil
+		}
 		if errors.Is(err, model.ErrAttachmentExists) {
-			fmt.Fprintf(os.Stderr, "Error: attachment '%s' already exists for record '%s'\n", filepath.Base(absPath), recordID)
+			name := attachName
+			if name == "" {
+				name = filepath.Base(absPath)
+			}
+			fmt.Fprintf(os.Stderr, "Error: attachment '%s' already exists for record '%s'\n", name, recordID)
 			Exit(1)
 			return nil
 		}
@@ -138,6 +197,8 @@ func runAttach(cmd *cobra.Command, args []string) error {
 		action := "Copied"
 		if attachMove {
 			action = "Moved"
+		} else if attachStdin {
+			action = "Saved stdin as"
 		}
 		fmt.Printf("%s '%s' to record %s\n", action, attachment.Name, recordID)
 		if IsVerbose() {
@@ -149,3 +210,29 @@ func runAttach(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// attachContent attaches the file at path, or stdin with --stdin, under
+// --name if given.
+func attachContent(cmd *cobra.Command, store *storage.Store, stashName, recordID, path, actor string) (*model.Attachment, error) {
+	if attachStdin {
+		return store.AttachReader(stashName, recordID, attachName, cmd.InOrStdin(), actor)
+	}
+	if attachName == "" || attachName == filepath.Base(path) {
+		return store.AttachFile(stashName, recordID, path, attachMove, actor)
+	}
+
+	// Renamed on the way in, so copy the content
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, model.ErrFileNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+	attachment, err := store.AttachReader(stashName, recordID, attachName, f, actor)
+	if err == nil && attachMove {
+		os.Remove(path)
+	}
+	return attachment, err
+}
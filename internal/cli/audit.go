@@ -0,0 +1,282 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	auditSince string
+	auditUntil string
+	auditOps   []string
+	auditLimit int
+	auditCSV   bool
+)
+
+// auditOperations lists the operation types recorded in the JSONL logs.
+var auditOperations = []string{
+	model.OpCreate, model.OpUpdate, model.OpDelete,
+	model.OpRestore, model.OpArchive, model.OpUnarchive,
+	model.OpAssign, model.OpUnassign,
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report operations across all stashes",
+	Long: `Report every logged operation across all stashes, filtered by actor,
+operation type, and time range.
+
+Where 'stash history' follows one stash or record, audit gives the
+cross-cutting view: who did what, where, and when. It reads the JSONL
+operation logs, so it includes records that were later deleted or moved.
+Purged records are no longer in the log and do not appear.
+
+Here --actor selects the actor to report on instead of setting the acting
+actor. Use --stash to limit the report to one stash.
+
+Options:
+  --actor <name>   Only operations by this actor
+  --op <type>      Only these operation types (repeatable, or comma-separated):
+                   create, update, delete, restore, archive, unarchive,
+                   assign, unassign
+  --since <when>   Only operations at or after a duration ago (24h, 7d, 1w)
+                   or a date (2025-01-31, 2025-01-31T09:00:00Z)
+  --until <when>   Only operations before a duration ago or a date
+  --limit <n>      Only the N most recent operations
+  --csv            Output as CSV with headers
+
+Examples:
+  stash audit
+  stash audit --actor alice --since 7d
+  stash audit --op delete --since 2025-01-01
+  stash audit --stash inventory --op delete,restore
+  stash audit --csv > audit.csv
+
+AI Agent Examples:
+  # Review everything another agent deleted today
+  stash audit --actor cleanup-agent --op delete --since 24h --json
+
+Exit Codes:
+  0  Success (including no matching operations)
+  1  No .stash directory, or stash not found (with --stash)
+  2  Validation error (unknown operation, invalid time)
+
+JSON Output (--json):
+  [{"timestamp": "2025-01-31T09:12:44Z", "stash": "inventory", "id": "inv-ex4j",
+    "op": "delete", "actor": "alice", "branch": "main", "signed": false}]`,
+	Args: cobra.NoArgs,
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only operations since a duration ago or a date")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "Only operations before a duration ago or a date")
+	auditCmd.Flags().StringSliceVar(&auditOps, "op", nil, "Only these operation types")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", 0, "Limit to the N most recent operations (0 = no limit)")
+	auditCmd.Flags().BoolVar(&auditCSV, "csv", false, "Output as CSV")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// AuditEntry is one logged operation in an audit report.
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Stash     string    `json:"stash"`
+	ID        string    `json:"id"`
+	Op        string    `json:"op"`
+	Actor     string    `json:"actor"`
+	Branch    string    `json:"branch,omitempty"`
+	Signed    bool      `json:"signed"`
+}
+
+// parseTimeFilter parses a --since or --until value: a duration before now
+// (24h, 7d, 1w) or a date.
+func parseTimeFilter(value string) (time.Time, error) {
+	if d, err := parseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, _, err := parseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: '%s' (expected a duration like 24h or 7d, or a date like 2006-01-02)", value)
+	}
+	return t, nil
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	if auditCSV && GetJSONOutput() {
+		ExitValidationError("--csv and --json cannot be used together", nil)
+		return nil
+	}
+
+	// Validate filters before touching the store
+	ops := make(map[string]bool)
+	for _, op := range auditOps {
+		op = strings.ToLower(strings.TrimSpace(op))
+		if !slices.Contains(auditOperations, op) {
+			ExitValidationError(fmt.Sprintf("unknown operation '%s' (valid: %s)", op, strings.Join(auditOperations, ", ")),
+				map[string]interface{}{"op": op})
+			return nil
+		}
+		ops[op] = true
+	}
+
+	var since, until time.Time
+	var err error
+	if auditSince != "" {
+		if since, err = parseTimeFilter(auditSince); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"since": auditSince})
+			return nil
+		}
+	}
+	if auditUntil != "" {
+		if until, err = parseTimeFilter(auditUntil); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"until": auditUntil})
+			return nil
+		}
+	}
+
+	// Resolve context; the stash is optional here
+	stashDir := context.FindStashDir()
+	if stashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+
+	// Create storage
+	store, err := openStore(stashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	var stashes []*model.Stash
+	if name := GetStashName(); name != "" {
+		stash, err := store.GetStash(name)
+		if err != nil {
+			if errors.Is(err, model.ErrStashNotFound) {
+				ExitStashNotFound(name)
+				return nil
+			}
+			return fmt.Errorf("failed to get stash: %w", err)
+		}
+		stashes = append(stashes, stash)
+	} else {
+		stashes, err = store.ListStashes()
+		if err != nil {
+			return fmt.Errorf("failed to list stashes: %w", err)
+		}
+	}
+
+	// Collect matching operations from every stash's log
+	actor := GetActorName()
+	entries := make([]AuditEntry, 0)
+	for _, stash := range stashes {
+		history, err := store.GetAllHistory(stash.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read history for %s: %w", stash.Name, err)
+		}
+		for _, rec := range history {
+			if actor != "" && rec.UpdatedBy != actor {
+				continue
+			}
+			if len(ops) > 0 && !ops[rec.Operation] {
+				continue
+			}
+			if !since.IsZero() && rec.UpdatedAt.Before(since) {
+				continue
+			}
+			if !until.IsZero() && !rec.UpdatedAt.Before(until) {
+				continue
+			}
+			entries = append(entries, AuditEntry{
+				Timestamp: rec.UpdatedAt,
+				Stash:     stash.Name,
+				ID:        rec.ID,
+				Op:        rec.Operation,
+				Actor:     rec.UpdatedBy,
+				Branch:    rec.Branch,
+				Signed:    rec.Signature != "",
+			})
+		}
+	}
+
+	// Most recent first, as in history
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if auditLimit > 0 && len(entries) > auditLimit {
+		entries = entries[:auditLimit]
+	}
+
+	// Output result
+	switch {
+	case GetJSONOutput():
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case auditCSV:
+		return writeAuditCSV(entries)
+	default:
+		printAuditTable(entries)
+	}
+	return nil
+}
+
+func writeAuditCSV(entries []AuditEntry) error {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write([]string{"timestamp", "stash", "id", "op", "actor", "branch", "signed"})
+	for _, e := range entries {
+		writer.Write([]string{
+			e.Timestamp.UTC().Format(time.RFC3339),
+			e.Stash,
+			e.ID,
+			e.Op,
+			e.Actor,
+			e.Branch,
+			strconv.FormatBool(e.Signed),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func printAuditTable(entries []AuditEntry) {
+	if len(entries) == 0 {
+		Infof("No operations found.\n")
+		return
+	}
+
+	fmt.Printf("%-19s  %-15s  %-9s  %-20s  %s\n", "Timestamp", "Stash", "Op", "ID", "Actor")
+	fmt.Printf("%s  %s  %s  %s  %s\n",
+		strings.Repeat("-", 19),
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 20),
+		strings.Repeat("-", 15),
+	)
+	for _, e := range entries {
+		fmt.Printf("%-19s  %-15s  %-9s  %-20s  %s\n",
+			e.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			truncate(e.Stash, 15),
+			e.Op,
+			truncate(e.ID, 20),
+			e.Actor,
+		)
+	}
+
+	Infof("\n%d operation(s)\n", len(entries))
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	// setupAudit creates two stashes with operations by two actors and
+	// returns the ID of the record bob deleted.
+	setupAudit := func(t *testing.T) string {
+		t.Helper()
+		rootCmd.SetArgs([]string{"add", "Laptop", "--actor", "alice"})
+		rootCmd.Execute()
+		resetFlags()
+
+		var created map[string]interface{}
+		output := captureSchemaOutput(t, "add", "Mouse", "--actor", "alice", "--json")
+		if err := json.Unmarshal([]byte(output), &created); err != nil {
+			t.Fatalf("failed to parse add output %q: %v", output, err)
+		}
+		id := created["_id"].(string)
+
+		rootCmd.SetArgs([]string{"rm", id, "--actor", "bob", "--yes"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "tasks", "--prefix", "tk-"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"column", "add", "Title", "--stash", "tasks"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"add", "Write report", "--stash", "tasks", "--actor", "bob"})
+		rootCmd.Execute()
+		resetFlags()
+		return id
+	}
+
+	auditJSON := func(t *testing.T, args ...string) []AuditEntry {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"audit", "--json"}, args...)...)
+		var entries []AuditEntry
+		if err := json.Unmarshal([]byte(output), &entries); err != nil {
+			t.Fatalf("failed to parse audit output %q: %v", output, err)
+		}
+		return entries
+	}
+
+	t.Run("reports operations across stashes", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		setupAudit(t)
+
+		entries := auditJSON(t)
+		if len(entries) != 4 {
+			t.Fatalf("expected 4 operations, got %d: %+v", len(entries), entries)
+		}
+		stashes := map[string]bool{}
+		for _, e := range entries {
+			stashes[e.Stash] = true
+		}
+		if !stashes["inventory"] || !stashes["tasks"] {
+			t.Errorf("expected operations from both stashes, got %v", stashes)
+		}
+	})
+
+	t.Run("filters by actor and operation", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		deleted := setupAudit(t)
+
+		entries := auditJSON(t, "--actor", "bob", "--op", "delete")
+		if len(entries) != 1 || entries[0].ID != deleted || entries[0].Op != "delete" {
+			t.Errorf("expected bob's delete of %s, got %+v", deleted, entries)
+		}
+
+		entries = auditJSON(t, "--actor", "bob", "--stash", "tasks")
+		if len(entries) != 1 || entries[0].Stash != "tasks" {
+			t.Errorf("expected one tasks operation, got %+v", entries)
+		}
+	})
+
+	t.Run("filters by time range", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		setupAudit(t)
+
+		if entries := auditJSON(t, "--since", "1h"); len(entries) != 4 {
+			t.Errorf("expected 4 recent operations, got %d", len(entries))
+		}
+		if entries := auditJSON(t, "--until", "2000-01-01"); len(entries) != 0 {
+			t.Errorf("expected no operations before 2000, got %d", len(entries))
+		}
+	})
+
+	t.Run("csv output", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		setupAudit(t)
+
+		output := captureSchemaOutput(t, "audit", "--csv", "--op", "create")
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if lines[0] != "timestamp,stash,id,op,actor,branch,signed" {
+			t.Errorf("unexpected CSV header: %q", lines[0])
+		}
+		if len(lines) != 4 {
+			t.Errorf("expected header and 3 rows, got %d lines", len(lines))
+		}
+	})
+
+	t.Run("rejects unknown operations", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"audit", "--op", "explode"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
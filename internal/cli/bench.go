@@ -0,0 +1,286 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	benchRecords       int
+	benchProfile       string
+	benchProfileOutput string
+)
+
+// benchQueries is the number of filtered queries the query benchmark runs.
+const benchQueries = 100
+
+// benchCategories is the number of distinct Category values in the
+// synthetic stash; each query matches about 1/benchCategories of it.
+const benchCategories = 10
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure stash performance on this machine",
+	Long: `Generate a synthetic stash in a temporary directory and measure how
+fast stash adds, lists, queries, and rebuilds records, then print a report.
+
+The benchmarks run against the storage layer directly, without the daemon,
+so the numbers reflect this machine's disk and CPU. The current stash is
+never touched, and the temporary directory is removed afterwards.
+
+Benchmarks:
+  add      Create each record (JSONL append and cache update)
+  list     List every record from the cache
+  query    Run 100 filtered, sorted queries against the cache
+  rebuild  Rebuild the cache from the JSONL file
+
+Options:
+  --records N              Number of records to generate (default 10000)
+  --profile cpu|mem        Write a pprof profile of the run
+  --profile-output FILE    Profile file (default: stash-bench.<kind>.pprof)
+
+Examples:
+  stash bench
+  stash bench --records 100000
+  stash bench --profile cpu
+  go tool pprof stash-bench.cpu.pprof
+
+AI Agent Examples:
+  # Compare add throughput before and after an upgrade
+  stash bench --records 5000 --json | jq '.results[] | select(.name == "add") | .per_second'
+
+Exit Codes:
+  0  Success
+  2  Invalid --records or --profile
+
+JSON Output (--json):
+  {"records": 10000, "os": "linux", "arch": "amd64", "go_version": "go1.22.2",
+   "results": [{"name": "add", "ops": 10000, "unit": "records",
+                "seconds": 4.2, "per_second": 2380.9}, ...],
+   "profile": "stash-bench.cpu.pprof"}`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchRecords, "records", 10000, "Number of records to generate")
+	benchCmd.Flags().StringVar(&benchProfile, "profile", "", "Write a pprof profile: cpu or mem")
+	benchCmd.Flags().StringVar(&benchProfileOutput, "profile-output", "", "Profile file (default: stash-bench.<kind>.pprof)")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchResult is the outcome of one benchmark.
+type benchResult struct {
+	Name      string  `json:"name"`
+	Ops       int     `json:"ops"`
+	Unit      string  `json:"unit"`
+	Seconds   float64 `json:"seconds"`
+	PerSecond float64 `json:"per_second"`
+}
+
+// benchReport is the full output of 'stash bench'.
+type benchReport struct {
+	Records   int           `json:"records"`
+	OS        string        `json:"os"`
+	Arch      string        `json:"arch"`
+	GoVersion string        `json:"go_version"`
+	Results   []benchResult `json:"results"`
+	Profile   string        `json:"profile,omitempty"`
+}
+
+// timeBench runs fn and records how long it took to complete ops units.
+func timeBench(name string, ops int, unit string, fn func() error) (benchResult, error) {
+	start := time.Now()
+	if err := fn(); err != nil {
+		return benchResult{}, fmt.Errorf("%s benchmark failed: %w", name, err)
+	}
+	elapsed := time.Since(start)
+	result := benchResult{Name: name, Ops: ops, Unit: unit, Seconds: elapsed.Seconds()}
+	if elapsed > 0 {
+		result.PerSecond = float64(ops) / elapsed.Seconds()
+	}
+	return result, nil
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchRecords <= 0 {
+		ExitValidationError("--records must be positive", map[string]interface{}{"records": benchRecords})
+		return nil
+	}
+	if benchProfile != "" && benchProfile != "cpu" && benchProfile != "mem" {
+		ExitValidationError(fmt.Sprintf("invalid --profile '%s': must be cpu or mem", benchProfile),
+			map[string]interface{}{"profile": benchProfile})
+		return nil
+	}
+
+	// Set up the synthetic stash
+	dir, err := os.MkdirTemp("", "stash-bench-")
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := storage.NewStore(filepath.Join(dir, ".stash"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	const stashName = "bench"
+	now := time.Now()
+	stash := &model.Stash{
+		Name:      stashName,
+		Prefix:    "bn-",
+		Created:   now,
+		CreatedBy: "bench",
+		Columns: model.ColumnList{
+			{Name: "Name", Added: now, AddedBy: "bench"},
+			{Name: "Category", Added: now, AddedBy: "bench"},
+			{Name: "Price", Added: now, AddedBy: "bench", Validate: "number"},
+		},
+	}
+	if err := store.CreateStash(stashName, stash.Prefix, stash); err != nil {
+		return fmt.Errorf("failed to create stash: %w", err)
+	}
+
+	report := benchReport{
+		Records:   benchRecords,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+	}
+
+	// Start profiling
+	if benchProfile != "" {
+		report.Profile = benchProfileOutput
+		if report.Profile == "" {
+			report.Profile = fmt.Sprintf("stash-bench.%s.pprof", benchProfile)
+		}
+	}
+	if benchProfile == "cpu" {
+		f, err := os.Create(report.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to create profile: %w", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	benches := []struct {
+		name string
+		ops  int
+		unit string
+		fn   func() error
+	}{
+		{"add", benchRecords, "records", func() error {
+			for i := 0; i < benchRecords; i++ {
+				id, err := store.NextRecordID(stashName)
+				if err != nil {
+					return err
+				}
+				created := time.Now()
+				record := &model.Record{
+					ID:        id,
+					CreatedAt: created,
+					CreatedBy: "bench",
+					UpdatedAt: created,
+					UpdatedBy: "bench",
+					Fields: map[string]interface{}{
+						"Name":     fmt.Sprintf("Item %d", i),
+						"Category": fmt.Sprintf("category-%d", i%benchCategories),
+						"Price":    float64(i % 1000),
+					},
+				}
+				if err := store.CreateRecord(stashName, record); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"list", benchRecords, "records", func() error {
+			_, err := store.ListRecords(stashName, storage.ListOptions{ParentID: "*"})
+			return err
+		}},
+		{"query", benchQueries, "queries", func() error {
+			for i := 0; i < benchQueries; i++ {
+				_, err := store.ListRecords(stashName, storage.ListOptions{
+					ParentID: "*",
+					Where: []storage.WhereCondition{
+						{Field: "Category", Operator: "=", Value: fmt.Sprintf("category-%d", i%benchCategories)},
+					},
+					OrderBy: []storage.OrderKey{{Field: "Price", Desc: true}},
+					Limit:   50,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"rebuild", benchRecords, "records", func() error {
+			return store.RebuildCache(stashName)
+		}},
+	}
+	for _, b := range benches {
+		result, err := timeBench(b.name, b.ops, b.unit, b.fn)
+		if err != nil {
+			return err
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if benchProfile == "mem" {
+		f, err := os.Create(report.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to create profile: %w", err)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	Infof("Benchmark: %d records (%s/%s, %s)\n\n", report.Records, report.OS, report.Arch, report.GoVersion)
+	tbl := newTable(
+		tableColumn{Header: "Benchmark"},
+		tableColumn{Header: "Ops"},
+		tableColumn{Header: "Time"},
+		tableColumn{Header: "Rate"},
+	)
+	for _, r := range report.Results {
+		elapsed := time.Duration(r.Seconds * float64(time.Second))
+		tbl.addRow(r.Name,
+			fmt.Sprintf("%d %s", r.Ops, r.Unit),
+			elapsed.Round(time.Millisecond).String(),
+			fmt.Sprintf("%.0f %s/s", r.PerSecond, r.Unit))
+	}
+	tbl.print()
+	if report.Profile != "" {
+		Infof("\n%s profile written to %s\n", benchProfile, report.Profile)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	t.Run("reports each benchmark", func(t *testing.T) {
+		ExitCode = 0
+		out := captureSchemaOutput(t, "bench", "--records", "20", "--json")
+
+		var report benchReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("expected JSON report: %v\n%s", err, out)
+		}
+		if report.Records != 20 || len(report.Results) != 4 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		for i, name := range []string{"add", "list", "query", "rebuild"} {
+			result := report.Results[i]
+			if result.Name != name || result.Ops == 0 || result.Seconds <= 0 {
+				t.Errorf("unexpected %s result: %+v", name, result)
+			}
+		}
+	})
+
+	t.Run("writes a profile", func(t *testing.T) {
+		ExitCode = 0
+		path := filepath.Join(t.TempDir(), "cpu.pprof")
+		captureSchemaOutput(t, "bench", "--records", "5", "--profile", "cpu", "--profile-output", path)
+
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("expected a CPU profile at %s: %v", path, err)
+		}
+	})
+
+	t.Run("rejects an invalid profile", func(t *testing.T) {
+		// The helper's stash is not used, but it stubs out os.Exit
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "bench", "--profile", "disk")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,411 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var checkoutReset bool
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout [branch]",
+	Short: "Set the branch that commands write records on",
+	Long: `Check out a branch for this .stash directory.
+
+Records carry the branch they were created on (_branch), normally the
+current git branch. After 'stash checkout', commands use the checked out
+branch instead, so an agent can work on a named branch without switching
+git branches. list and show say which branch is checked out, and 'stash
+branch diff' reviews what changed before merging.
+
+The checkout is kept in .stash/state.json. It describes this working copy
+rather than the data, so leave it out of git commits. Use --reset to go
+back to the git branch.
+
+Examples:
+  stash checkout agent/import-contacts
+  stash checkout --reset
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found
+  2  Validation error (missing or invalid branch name)
+
+JSON Output (--json):
+  {"branch": "agent/import-contacts", "checked_out": true}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCheckout,
+}
+
+var branchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "Show the active branch",
+	Long: `Show the branch commands write records on: the branch checked out with
+'stash checkout', or else the current git branch.
+
+Examples:
+  stash branch
+  stash branch diff main
+
+Exit Codes:
+  0  Success
+
+JSON Output (--json):
+  {"branch": "agent/import-contacts", "checked_out": true, "git_branch": "main"}`,
+	Args: cobra.NoArgs,
+	RunE: runBranch,
+}
+
+var branchDiffCmd = &cobra.Command{
+	Use:   "diff <base>",
+	Short: "Compare a stash's records with another git branch",
+	Long: `Compare the records of a stash with their state on another git branch,
+usually the branch you will merge into.
+
+The records on the base branch are read from its committed records.jsonl
+(with 'git show'), and compared with the records here, including changes
+not yet committed. Records are reported as added, modified (with each
+changed field), or deleted. Archiving and assignment show as changes to
+_archived and _assigned_to.
+
+Examples:
+  stash branch diff main
+  stash branch diff origin/main --stash contacts --json
+
+AI Agent Examples:
+  # Review an agent's changes before merging its branch
+  stash branch diff main --json | jq '.modified[] | {id, changes}'
+
+Exit Codes:
+  0  Success
+  1  Stash not found, not in a git repository, or unknown base branch
+
+JSON Output (--json):
+  {"stash": "contacts", "base": "main", "branch": "agent/import",
+   "added": [{"id": "ct-ex4j", "fields": {"Name": "Alice"}}],
+   "modified": [{"id": "ct-8k2m", "changes": [{"field": "Email", "from": "a@old.com", "to": "a@new.com"}]}],
+   "deleted": [{"id": "ct-q9w1", "fields": {"Name": "Bob"}}]}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBranchDiff,
+}
+
+func init() {
+	checkoutCmd.Flags().BoolVar(&checkoutReset, "reset", false, "Go back to the current git branch")
+	branchCmd.AddCommand(branchDiffCmd)
+	rootCmd.AddCommand(checkoutCmd)
+	rootCmd.AddCommand(branchCmd)
+}
+
+func runCheckout(cmd *cobra.Command, args []string) error {
+	if checkoutReset == (len(args) == 1) {
+		ExitValidationError("give a branch to check out, or --reset", nil)
+		return nil
+	}
+	branch := ""
+	if len(args) == 1 {
+		branch = strings.TrimSpace(args[0])
+		if branch == "" || strings.ContainsAny(branch, " \t\n") {
+			ExitValidationError(fmt.Sprintf("invalid branch name '%s'", args[0]),
+				map[string]interface{}{"branch": args[0]})
+			return nil
+		}
+	}
+
+	stashDir := context.FindStashDir()
+	if stashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+	state, err := context.LoadState(stashDir)
+	if err != nil {
+		return err
+	}
+	state.Branch = branch
+	if err := context.SaveState(stashDir, state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	checkedOut := branch != ""
+	if !checkedOut {
+		branch = context.DetectBranch()
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"branch": branch, "checked_out": checkedOut})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if checkedOut {
+			fmt.Printf("Checked out branch '%s'\n", branch)
+		} else if branch != "" {
+			fmt.Printf("Using git branch '%s'\n", branch)
+		} else {
+			fmt.Println("No branch checked out")
+		}
+	}
+	return nil
+}
+
+func runBranch(cmd *cobra.Command, args []string) error {
+	gitBranch := context.DetectBranch()
+	branch := context.CheckedOutBranch(context.FindStashDir())
+	checkedOut := branch != ""
+	if !checkedOut {
+		branch = gitBranch
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"branch":      branch,
+			"checked_out": checkedOut,
+			"git_branch":  gitBranch,
+		})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	switch {
+	case checkedOut:
+		fmt.Printf("%s (checked out; git branch: %s)\n", branch, valueOr(gitBranch, "none"))
+	case branch != "":
+		fmt.Println(branch)
+	default:
+		Infof("No branch (not in a git repository, and nothing checked out)\n")
+	}
+	return nil
+}
+
+// valueOr returns s, or def if s is empty.
+func valueOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// BranchDiffRecord is a record added or deleted relative to the base branch.
+type BranchDiffRecord struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// BranchDiffChange is one changed field of a modified record.
+type BranchDiffChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// BranchDiffModified is a record whose fields differ from the base branch.
+type BranchDiffModified struct {
+	ID      string             `json:"id"`
+	Changes []BranchDiffChange `json:"changes"`
+}
+
+// BranchDiffOutput is the JSON output of 'stash branch diff'.
+type BranchDiffOutput struct {
+	Stash    string               `json:"stash"`
+	Base     string               `json:"base"`
+	Branch   string               `json:"branch,omitempty"`
+	Added    []BranchDiffRecord   `json:"added"`
+	Modified []BranchDiffModified `json:"modified"`
+	Deleted  []BranchDiffRecord   `json:"deleted"`
+}
+
+func runBranchDiff(cmd *cobra.Command, args []string) error {
+	base := args[0]
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	baseRecords, err := readBranchRecords(ctx.StashDir, stash.Name, base)
+	if err != nil {
+		ExitWithError(1, ErrCodeReferenceError, err.Error(), map[string]interface{}{"base": base})
+		return nil
+	}
+	history, err := store.GetAllHistory(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read records: %w", err)
+	}
+
+	output := diffRecordStates(storage.ReplayRecords(baseRecords), storage.ReplayRecords(history))
+	output.Stash, output.Base, output.Branch = stash.Name, base, ctx.Branch
+
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+	if len(output.Added)+len(output.Modified)+len(output.Deleted) == 0 {
+		fmt.Printf("No changes to stash '%s' relative to %s\n", stash.Name, base)
+		return nil
+	}
+
+	primary := ""
+	if col := stash.PrimaryColumn(); col != nil {
+		primary = col.Name
+	}
+	fmt.Printf("Changes to stash '%s' relative to %s:\n", stash.Name, base)
+	for _, rec := range output.Added {
+		fmt.Printf("  + %s  %s\n", rec.ID, valueText(rec.Fields[primary]))
+	}
+	for _, rec := range output.Modified {
+		fmt.Printf("  ~ %s\n", rec.ID)
+		for _, change := range rec.Changes {
+			fmt.Printf("      %s: %s -> %s\n", change.Field, diffValueText(change.From), diffValueText(change.To))
+		}
+	}
+	for _, rec := range output.Deleted {
+		fmt.Printf("  - %s  %s\n", rec.ID, valueText(rec.Fields[primary]))
+	}
+	fmt.Printf("%d added, %d modified, %d deleted\n", len(output.Added), len(output.Modified), len(output.Deleted))
+	return nil
+}
+
+// diffValueText formats a changed value, showing unset values as (unset).
+func diffValueText(value interface{}) string {
+	if value == nil {
+		return "(unset)"
+	}
+	return valueText(value)
+}
+
+// readBranchRecords reads a stash's JSONL log as committed on a git
+// branch. A branch without the stash has no records.
+func readBranchRecords(stashDir, stashName, branch string) ([]*model.Record, error) {
+	dir, err := filepath.Abs(stashDir)
+	if err != nil {
+		return nil, err
+	}
+	git := func(args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, errors.New(msg)
+			}
+			return nil, err
+		}
+		return out, nil
+	}
+
+	if _, err := git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	if _, err := git("rev-parse", "--verify", "--quiet", branch+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown branch '%s'", branch)
+	}
+
+	path := branch + ":./" + stashName + "/records.jsonl"
+	if _, err := git("cat-file", "-e", path); err != nil {
+		return nil, nil
+	}
+	data, err := git("show", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return storage.ReadRecords(bytes.NewReader(data))
+}
+
+// diffRecordStates compares the record states of a base branch with the
+// current ones.
+func diffRecordStates(base, current map[string]*model.Record) BranchDiffOutput {
+	output := BranchDiffOutput{
+		Added:    []BranchDiffRecord{},
+		Modified: []BranchDiffModified{},
+		Deleted:  []BranchDiffRecord{},
+	}
+	live := func(rec *model.Record) bool {
+		return rec != nil && !rec.IsDeleted()
+	}
+
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	for id := range base {
+		if _, ok := current[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		was, now := base[id], current[id]
+		switch {
+		case !live(was) && live(now):
+			output.Added = append(output.Added, BranchDiffRecord{ID: id, Fields: now.Fields})
+		case live(was) && !live(now):
+			output.Deleted = append(output.Deleted, BranchDiffRecord{ID: id, Fields: was.Fields})
+		case live(was) && live(now):
+			if changes := diffRecordFields(was, now); len(changes) > 0 {
+				output.Modified = append(output.Modified, BranchDiffModified{ID: id, Changes: changes})
+			}
+		}
+	}
+	return output
+}
+
+// diffRecordFields returns the fields that differ between two versions of
+// a record, in field name order, followed by archive and assignment
+// changes.
+func diffRecordFields(was, now *model.Record) []BranchDiffChange {
+	names := make([]string, 0, len(now.Fields))
+	for name := range now.Fields {
+		names = append(names, name)
+	}
+	for name := range was.Fields {
+		if _, ok := now.Fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []BranchDiffChange
+	for _, name := range names {
+		from, to := was.Fields[name], now.Fields[name]
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, BranchDiffChange{Field: name, From: from, To: to})
+		}
+	}
+	if was.IsArchived() != now.IsArchived() {
+		changes = append(changes, BranchDiffChange{Field: "_archived", From: was.IsArchived(), To: now.IsArchived()})
+	}
+	if was.AssignedTo != now.AssignedTo {
+		changes = append(changes, BranchDiffChange{Field: "_assigned_to", From: nilIfEmpty(was.AssignedTo), To: nilIfEmpty(now.AssignedTo)})
+	}
+	return changes
+}
+
+// nilIfEmpty returns nil for an empty string, so it reads as unset.
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckout(t *testing.T) {
+	t.Run("checked out branch tags new records", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "checkout", "agent/import")
+		if ExitCode != 0 {
+			t.Fatalf("checkout failed, exit %d", ExitCode)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "state.json")); err != nil {
+			t.Fatalf("expected state.json to be written: %v", err)
+		}
+
+		var branch map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "branch", "--json")), &branch)
+		if branch["branch"] != "agent/import" || branch["checked_out"] != true {
+			t.Errorf("unexpected branch output: %v", branch)
+		}
+
+		var rec map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Write docs", "--json")), &rec)
+		if rec["_branch"] != "agent/import" {
+			t.Errorf("expected the record on the checked out branch, got %v", rec["_branch"])
+		}
+
+		if output := captureSchemaOutput(t, "list"); !strings.Contains(output, "On branch agent/import") {
+			t.Errorf("expected list to show the checked out branch, got: %s", output)
+		}
+		if output := captureSchemaOutput(t, "show", rec["_id"].(string)); !strings.Contains(output, "**Checked out**: agent/import") {
+			t.Errorf("expected show to show the checked out branch, got: %s", output)
+		}
+
+		captureSchemaOutput(t, "checkout", "--reset")
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "state.json")); !os.IsNotExist(err) {
+			t.Errorf("expected --reset to remove state.json, got %v", err)
+		}
+		if output := captureSchemaOutput(t, "list"); strings.Contains(output, "On branch") {
+			t.Errorf("expected no checked out branch after --reset, got: %s", output)
+		}
+	})
+
+	t.Run("requires a branch or --reset", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "checkout")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "checkout", "main", "--reset")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestBranchDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status"})
+	defer cleanup()
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	kept := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--set", "Status=open"))
+	removed := strings.TrimSpace(captureSchemaOutput(t, "add", "Old task"))
+	git("init", "-q", "-b", "main")
+	git("add", ".stash")
+	git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "records")
+
+	captureSchemaOutput(t, "checkout", "agent/work")
+	added := strings.TrimSpace(captureSchemaOutput(t, "add", "New task"))
+	captureSchemaOutput(t, "set", kept, "Status=done")
+	captureSchemaOutput(t, "rm", removed, "--yes")
+
+	var diff BranchDiffOutput
+	output := captureSchemaOutput(t, "branch", "diff", "main", "--json")
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("failed to parse output %q: %v", output, err)
+	}
+	if diff.Branch != "agent/work" {
+		t.Errorf("expected branch agent/work, got %q", diff.Branch)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].ID != added {
+		t.Errorf("expected %s added, got %+v", added, diff.Added)
+	}
+	if len(diff.Deleted) != 1 || diff.Deleted[0].ID != removed {
+		t.Errorf("expected %s deleted, got %+v", removed, diff.Deleted)
+	}
+	if len(diff.Modified) != 1 || len(diff.Modified[0].Changes) != 1 ||
+		diff.Modified[0].Changes[0] != (BranchDiffChange{Field: "Status", From: "open", To: "done"}) {
+		t.Errorf("expected Status open -> done on %s, got %+v", kept, diff.Modified)
+	}
+
+	ExitCode = 0
+	captureSchemaOutput(t, "branch", "diff", "no-such-branch")
+	if ExitCode != 1 {
+		t.Errorf("expected exit code 1 for an unknown branch, got %d", ExitCode)
+	}
+	ExitCode = 0
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -43,9 +42,11 @@ WHERE clause format (same as 'stash list --where'):
   field IS NOT NULL  Field has a value
   field IS EMPTY     Field is null or empty string
   field IS NOT EMPTY Field has a non-empty value
+  field CONTAINS val List column has the element
 
 Multiple --where flags are ANDed together.
-Multiple --set flags update multiple fields.
+Multiple --set flags update multiple fields. On list columns,
+--set Field+=Value adds an element and --set Field-=Value removes one.
 
 Examples:
   stash bulk-set --where "Category=electronics" --set Priority=high
@@ -76,7 +77,8 @@ AI Agent Examples:
 Exit Codes:
   0  Success (includes 0 records matched)
   1  Stash or column not found
-  2  Validation error (missing flags, invalid format)`,
+  2  Validation error (missing flags, invalid format)
+  6  Permission denied (see 'stash permissions')`,
 	Args: cobra.NoArgs,
 	RunE: runBulkSet,
 }
@@ -101,24 +103,28 @@ func runBulkSet(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Parse SET clauses
+	// Parse SET clauses; list edits (Field+=Value, Field-=Value) are
+	// applied after plain assignments
 	updates := make(map[string]interface{})
+	var listEdits []fieldAssignment
 	for _, setClause := range bulkSetSet {
-		parts := strings.SplitN(setClause, "=", 2)
-		if len(parts) != 2 {
+		a, ok := parseAssignment(setClause)
+		if !ok {
 			fmt.Fprintf(os.Stderr, "Error: invalid --set format: %s (expected Field=Value)\n", setClause)
 			Exit(2)
 			return nil
 		}
-		fieldName := strings.TrimSpace(parts[0])
-		fieldValue := strings.TrimSpace(parts[1])
-		updates[fieldName] = fieldValue
+		if a.Op == assignSet {
+			updates[a.Field] = a.Value
+		} else {
+			listEdits = append(listEdits, a)
+		}
 	}
 
 	// Parse WHERE clauses
 	var whereConditions []storage.WhereCondition
 	for _, clause := range bulkSetWhere {
-		cond, err := parseWhereClause(clause)
+		cond, err := storage.ParseWhereClause(clause)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			Exit(2)
@@ -144,7 +150,7 @@ func runBulkSet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -162,13 +168,39 @@ func runBulkSet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate all columns exist before making changes
-	for fieldName := range updates {
-		if !stash.Columns.Exists(fieldName) {
-			fmt.Fprintf(os.Stderr, "Error: column '%s' not found\n", fieldName)
-			Exit(1)
+	if !checkWhereColumns(stash, whereConditions) {
+		return nil
+	}
+	touched := fieldNames(updates)
+	for _, edit := range listEdits {
+		touched = append(touched, edit.Field)
+	}
+	for _, fieldName := range touched {
+		col := resolveColumn(stash, fieldName)
+		if col == nil {
+			return nil
+		}
+		if col.IsComputed() {
+			fmt.Fprintf(os.Stderr, "Error: column '%s' is computed and cannot be set\n", col.Name)
+			Exit(2)
 			return nil
 		}
 	}
+	for _, edit := range listEdits {
+		if col := stash.Columns.Find(edit.Field); !col.List {
+			fmt.Fprintf(os.Stderr, "Error: column '%s' is not a list column (%s needs 'stash column add %s --list')\n", col.Name, edit.Op, col.Name)
+			Exit(2)
+			return nil
+		}
+	}
+	for fieldName, fieldValue := range updates {
+		updates[fieldName] = columnValue(stash.Columns.Find(fieldName), fieldValue.(string))
+	}
+	coerced := coerceListEdits(stash, listEdits, coerceEnums(stash, updates))
+
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, touched) {
+		return nil
+	}
 
 	// Query matching records (non-deleted only)
 	opts := storage.ListOptions{
@@ -193,13 +225,22 @@ func runBulkSet(cmd *cobra.Command, args []string) error {
 				record.SetField(col.Name, fieldValue)
 			}
 		}
+		for _, edit := range listEdits {
+			col := stash.Columns.Find(edit.Field)
+			current, _ := record.GetField(col.Name)
+			record.SetField(col.Name, editList(current, edit.Op, edit.Value))
+		}
 
 		// Update audit trail
 		record.UpdatedAt = time.Now()
 		record.UpdatedBy = ctx.Actor
+		record.Coerced = coerced
 
 		// Save record
 		if err := store.UpdateRecord(ctx.Stash, record); err != nil {
+			if exitRecordTooLarge(ctx.Stash, err) || exitWriteRefused(err) {
+				return nil
+			}
 			return fmt.Errorf("failed to update record %s: %w", record.ID, err)
 		}
 
@@ -0,0 +1,325 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	changelogSince  string
+	changelogLimit  int
+	changelogSchema bool
+)
+
+// Kinds of changelog entries.
+const (
+	changelogKindSchema = "schema"
+	changelogKindData   = "data"
+)
+
+// Data milestones of a changelog.
+const (
+	milestoneFirstRecord = "first_record"
+	milestoneRecordCount = "record_count"
+	milestoneBulk        = "bulk"
+)
+
+// A bulk change is a run of at least bulkMinOps operations by one actor,
+// each within bulkGap of the one before.
+const (
+	bulkMinOps = 25
+	bulkGap    = time.Minute
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Show how a stash's schema and data evolved",
+	Long: `Show the evolution of the current stash, oldest first: its schema
+changes interleaved with notable milestones of its data.
+
+Schema changes are recorded in the stash's schema log (schema.jsonl) as
+they are made: the stash's creation, columns added, changed (description,
+validation, ...), or dropped, and changes to stash settings such as the
+prefix, rules, or limits. Each names who made it and what changed. A stash
+created before schema changes were logged starts its log with its
+creation and columns as recorded in its config.
+
+Data milestones are derived from the JSONL history:
+  first_record   The first record was created
+  record_count   The stash reached 10, 100, 1000, ... active records
+  bulk           An actor made 25 or more operations in a row, each
+                 within a minute of the one before (an import, a
+                 normalize, an agent's batch)
+
+Options:
+  --since <dur>    Only entries in this period (e.g., 24h, 7d, 1w)
+  --limit <n>      Only the N most recent entries
+  --schema         Only schema changes
+
+Examples:
+  stash changelog
+  stash changelog --since 30d
+  stash changelog --schema --json
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Invalid duration
+
+Output:
+  2025-01-08 10:30  alice  Created stash (prefix: "inv-")
+  2025-01-08 10:31  alice  Added column Price (validate: "number")
+  2025-01-09 09:05  agent  First record
+  2025-01-09 09:06  agent  Bulk change: 240 operations (240 create)
+  2025-01-09 09:07  agent  Reached 100 records
+  2025-01-12 14:00  bob    Changed column Price: desc: unset -> "Unit price"
+
+JSON Output (--json):
+  [{"timestamp": "2025-01-08T10:31:00Z", "kind": "schema",
+    "op": "column_add", "actor": "alice", "column": "Price",
+    "fields": [{"field": "validate", "to": "number"}],
+    "summary": "Added column Price (validate: \"number\")"},
+   {"timestamp": "2025-01-09T09:07:00Z", "kind": "data",
+    "op": "record_count", "actor": "agent", "records": 100,
+    "summary": "Reached 100 records"}]`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "Only entries in this period (e.g., 24h, 7d)")
+	changelogCmd.Flags().IntVar(&changelogLimit, "limit", 0, "Only the N most recent entries (0 = no limit)")
+	changelogCmd.Flags().BoolVar(&changelogSchema, "schema", false, "Only schema changes")
+	rootCmd.AddCommand(changelogCmd)
+}
+
+// ChangelogEntry is one schema change or data milestone of a stash.
+type ChangelogEntry struct {
+	Timestamp time.Time           `json:"timestamp"`
+	Kind      string              `json:"kind"`
+	Op        string              `json:"op"`
+	Actor     string              `json:"actor,omitempty"`
+	Column    string              `json:"column,omitempty"`
+	Fields    []model.FieldChange `json:"fields,omitempty"`
+	Records   int                 `json:"records,omitempty"` // Active records reached, or operations of a bulk change
+	Summary   string              `json:"summary"`
+}
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	var cutoff time.Time
+	if changelogSince != "" {
+		duration, err := parseDuration(changelogSince)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("invalid duration: %s", changelogSince), map[string]interface{}{"since": changelogSince})
+			return nil
+		}
+		cutoff = time.Now().Add(-duration)
+	}
+
+	ctx, store, _, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	changes, err := store.SchemaLog(ctx.Stash)
+	if err != nil {
+		return fmt.Errorf("failed to read schema log: %w", err)
+	}
+	entries := make([]ChangelogEntry, 0, len(changes))
+	for _, change := range changes {
+		entries = append(entries, schemaEntry(change))
+	}
+	if !changelogSchema {
+		history, err := store.GetAllHistory(ctx.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to get history: %w", err)
+		}
+		entries = append(entries, dataMilestones(history)...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+
+	if !cutoff.IsZero() {
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if entry.Timestamp.After(cutoff) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	if changelogLimit > 0 && len(entries) > changelogLimit {
+		entries = entries[len(entries)-changelogLimit:]
+	}
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsPorcelain() {
+		for _, entry := range entries {
+			printPorcelain(porcelainTime(entry.Timestamp), entry.Kind, entry.Op, entry.Actor, entry.Summary)
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		Infof("No changes found.\n")
+		return nil
+	}
+	width := 0
+	for _, entry := range entries {
+		width = max(width, len(entry.Actor))
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s  %-*s  %s\n", entry.Timestamp.Local().Format("2006-01-02 15:04"), width, entry.Actor, entry.Summary)
+	}
+	return nil
+}
+
+// schemaEntry describes a schema change for the changelog.
+func schemaEntry(change model.SchemaChange) ChangelogEntry {
+	entry := ChangelogEntry{
+		Timestamp: change.Timestamp,
+		Kind:      changelogKindSchema,
+		Op:        change.Op,
+		Actor:     change.Actor,
+		Column:    change.Column,
+		Fields:    change.Fields,
+	}
+	switch change.Op {
+	case model.SchemaStashCreate:
+		entry.Summary = "Created stash" + fieldSettings(change.Fields)
+	case model.SchemaColumnAdd:
+		entry.Summary = "Added column " + change.Column + fieldSettings(change.Fields)
+	case model.SchemaColumnChange:
+		entry.Summary = "Changed column " + change.Column + ": " + fieldChanges(change.Fields)
+	case model.SchemaColumnDrop:
+		entry.Summary = "Dropped column " + change.Column
+	case model.SchemaSettings:
+		entry.Summary = "Changed settings: " + fieldChanges(change.Fields)
+	default:
+		entry.Summary = change.Op
+	}
+	return entry
+}
+
+// fieldSettings formats the settings of something new, in parentheses,
+// or nothing if it has none.
+func fieldSettings(fields []model.FieldChange) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field + ": " + settingValue(f.To)
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// fieldChanges formats changed settings as "field: from -> to" pairs.
+func fieldChanges(fields []model.FieldChange) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fmt.Sprintf("%s: %s -> %s", f.Field, settingValue(f.From), settingValue(f.To))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// settingValue formats a setting's value as JSON, or "unset".
+func settingValue(v interface{}) string {
+	if v == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// dataMilestones returns the notable points of a stash's history, given
+// its operations in log order: the first record, each power of ten of
+// active records reached, and bulk changes.
+func dataMilestones(history []*model.Record) []ChangelogEntry {
+	var entries []ChangelogEntry
+	active := make(map[string]bool)
+	next := 10
+	for _, op := range history {
+		switch op.Operation {
+		case model.OpCreate:
+			if len(entries) == 0 {
+				entries = append(entries, ChangelogEntry{
+					Timestamp: op.UpdatedAt, Kind: changelogKindData, Op: milestoneFirstRecord,
+					Actor: op.UpdatedBy, Summary: "First record",
+				})
+			}
+			active[op.ID] = true
+		case model.OpRestore:
+			active[op.ID] = true
+		case model.OpDelete:
+			delete(active, op.ID)
+		}
+		if len(active) >= next {
+			entries = append(entries, ChangelogEntry{
+				Timestamp: op.UpdatedAt, Kind: changelogKindData, Op: milestoneRecordCount,
+				Actor: op.UpdatedBy, Records: next, Summary: fmt.Sprintf("Reached %d records", next),
+			})
+			next *= 10
+		}
+	}
+	return append(entries, bulkChanges(history)...)
+}
+
+// bulkChanges returns the runs of operations that make a bulk change.
+func bulkChanges(history []*model.Record) []ChangelogEntry {
+	var entries []ChangelogEntry
+	flush := func(run []*model.Record) {
+		if len(run) < bulkMinOps {
+			return
+		}
+		counts := make(map[string]int)
+		var ops []string
+		for _, op := range run {
+			if counts[op.Operation] == 0 {
+				ops = append(ops, op.Operation)
+			}
+			counts[op.Operation]++
+		}
+		parts := make([]string, len(ops))
+		for i, op := range ops {
+			parts[i] = fmt.Sprintf("%d %s", counts[op], op)
+		}
+		entries = append(entries, ChangelogEntry{
+			Timestamp: run[0].UpdatedAt, Kind: changelogKindData, Op: milestoneBulk,
+			Actor: run[0].UpdatedBy, Records: len(run),
+			Summary: fmt.Sprintf("Bulk change: %d operations (%s)", len(run), strings.Join(parts, ", ")),
+		})
+	}
+
+	var run []*model.Record
+	for _, op := range history {
+		if len(run) > 0 {
+			last := run[len(run)-1]
+			if op.UpdatedBy != last.UpdatedBy || op.UpdatedAt.Sub(last.UpdatedAt) > bulkGap {
+				flush(run)
+				run = nil
+			}
+		}
+		run = append(run, op)
+	}
+	flush(run)
+	return entries
+}
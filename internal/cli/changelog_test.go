@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+func TestChangelog(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+	defer cleanup()
+
+	captureSchemaOutput(t, "column", "describe", "Price", "Unit price")
+	for i := 0; i < 12; i++ {
+		captureSchemaOutput(t, "add", "item", "--set", "Price=1")
+	}
+	captureSchemaOutput(t, "collation", "nocase")
+
+	out := captureSchemaOutput(t, "changelog", "--json")
+	var entries []ChangelogEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("failed to parse JSON %q: %v", out, err)
+	}
+	var ops []string
+	for _, entry := range entries {
+		ops = append(ops, entry.Op)
+	}
+	want := []string{
+		model.SchemaStashCreate, model.SchemaColumnAdd, model.SchemaColumnAdd, model.SchemaColumnChange,
+		milestoneFirstRecord, milestoneRecordCount, model.SchemaSettings,
+	}
+	if strings.Join(ops, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected entries %v, got %v", want, ops)
+	}
+	if entries[1].Column != "Name" || entries[1].Actor != "test" {
+		t.Errorf("expected Name added by test, got %+v", entries[1])
+	}
+	if entries[5].Records != 10 {
+		t.Errorf("expected 10 records reached, got %d", entries[5].Records)
+	}
+	if !strings.Contains(entries[6].Summary, `collation: unset -> "nocase"`) {
+		t.Errorf("unexpected settings summary %q", entries[6].Summary)
+	}
+
+	out = captureSchemaOutput(t, "changelog", "--schema", "--limit", "2")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `Changed column Price: desc: unset -> "Unit price"`) {
+		t.Errorf("expected the last two schema changes, got %q", out)
+	}
+
+	captureSchemaOutput(t, "changelog", "--since", "soon")
+	if ExitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid duration, got %d", ExitCode)
+	}
+	ExitCode = 0
+}
+
+func TestBulkChanges(t *testing.T) {
+	start := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	var history []*model.Record
+	for i := 0; i < bulkMinOps; i++ {
+		history = append(history, &model.Record{ID: "inv-1", Operation: model.OpUpdate, UpdatedBy: "agent", UpdatedAt: start.Add(time.Duration(i) * time.Second)})
+	}
+	// A pause ends the run, and a short run is not a bulk change
+	history = append(history, &model.Record{ID: "inv-1", Operation: model.OpUpdate, UpdatedBy: "agent", UpdatedAt: start.Add(time.Hour)})
+
+	entries := bulkChanges(history)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 bulk change, got %d", len(entries))
+	}
+	if entries[0].Records != bulkMinOps || entries[0].Actor != "agent" || !entries[0].Timestamp.Equal(start) {
+		t.Errorf("unexpected bulk change %+v", entries[0])
+	}
+}
@@ -6,12 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var childrenCmd = &cobra.Command{
@@ -54,7 +52,7 @@ func runChildren(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -108,74 +106,34 @@ func runChildren(cmd *cobra.Command, args []string) error {
 
 	// Human-readable output
 	if len(children) == 0 {
-		fmt.Println("No children.")
+		Infof("No children.\n")
 		return nil
 	}
 
 	// Get primary column for display
 	primaryCol := stash.PrimaryColumn()
 
-	// Calculate column widths
-	idWidth := 4 // "ID" header
-	nameWidth := 12
-	if primaryCol != nil {
-		nameWidth = len(primaryCol.Name)
-	}
-
-	for _, child := range children {
-		if len(child.ID) > idWidth {
-			idWidth = len(child.ID)
-		}
-		if primaryCol != nil {
-			if val, ok := child.Fields[primaryCol.Name]; ok {
-				s := fmt.Sprintf("%v", val)
-				if len(s) > nameWidth {
-					nameWidth = len(s)
-				}
-			}
-		}
-	}
-
-	// Cap widths
-	if idWidth > 20 {
-		idWidth = 20
-	}
-	if nameWidth > 40 {
-		nameWidth = 40
-	}
-
-	// Print header
+	// Show the primary column, headed by its name
 	headerName := "Value"
 	if primaryCol != nil {
 		headerName = primaryCol.Name
 	}
 
-	fmt.Printf("%-*s  %-*s  %s\n", idWidth, "ID", nameWidth, headerName, "Updated")
-	fmt.Printf("%s  %s  %s\n", strings.Repeat("-", idWidth), strings.Repeat("-", nameWidth), strings.Repeat("-", 19))
-
-	// Print children
+	tbl := newTable(
+		tableColumn{Header: "ID", Max: 20, System: true},
+		tableColumn{Header: headerName, Max: 40},
+		tableColumn{Header: "Updated", System: true},
+	)
 	for _, child := range children {
-		id := child.ID
-		if len(id) > idWidth {
-			id = id[:idWidth-3] + "..."
-		}
-
 		name := ""
 		if primaryCol != nil {
-			if val, ok := child.Fields[primaryCol.Name]; ok {
-				name = fmt.Sprintf("%v", val)
-				if len(name) > nameWidth {
-					name = name[:nameWidth-3] + "..."
-				}
-			}
+			name = valueText(child.Fields[primaryCol.Name])
 		}
-
-		updated := child.UpdatedAt.Format("2006-01-02 15:04:05")
-
-		fmt.Printf("%-*s  %-*s  %s\n", idWidth, id, nameWidth, name, updated)
+		tbl.addRow(child.ID, name, child.UpdatedAt.Format("2006-01-02 15:04:05"))
 	}
+	tbl.print()
 
-	fmt.Printf("\nTotal: %d child(ren)\n", len(children))
+	Infof("\nTotal: %d child(ren)\n", len(children))
 
 	return nil
 }
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollation(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	out := captureSchemaOutput(t, "collation")
+	if !strings.Contains(out, "binary") {
+		t.Errorf("expected binary collation by default, got %q", out)
+	}
+
+	captureSchemaOutput(t, "add", "apple")
+	captureSchemaOutput(t, "add", "Apple")
+	captureSchemaOutput(t, "add", "banana")
+
+	out = captureSchemaOutput(t, "count", "--where", "Name=APPLE")
+	if strings.TrimSpace(out) != "0" {
+		t.Errorf("expected no binary match, got %q", out)
+	}
+
+	captureSchemaOutput(t, "collation", "nocase")
+	if ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", ExitCode)
+	}
+	out = captureSchemaOutput(t, "count", "--where", "Name=APPLE")
+	if strings.TrimSpace(out) != "2" {
+		t.Errorf("expected both apples to match ignoring case, got %q", out)
+	}
+
+	out = captureSchemaOutput(t, "collation", "--json")
+	if !strings.Contains(out, `"collation":"nocase"`) {
+		t.Errorf("expected nocase in JSON, got %q", out)
+	}
+
+	captureSchemaOutput(t, "collation", "no such thing")
+	if ExitCode != 2 {
+		t.Errorf("expected exit code 2 for an unknown collation, got %d", ExitCode)
+	}
+	ExitCode = 0
+}
@@ -2,6 +2,7 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,13 +14,29 @@ import (
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
 	"github.com/user/stash/internal/storage"
+	"gopkg.in/yaml.v3"
 )
 
 var (
-	columnDesc     string
-	columnValidate string
-	columnEnum     string
-	columnRequired bool
+	columnDesc        string
+	columnValidate    string
+	columnEnum        string
+	columnRequired    bool
+	columnComputed    string
+	columnTransitions string
+	columnDue         bool
+	columnWarn        bool
+	columnSeverity    string
+	columnList        bool
+	columnHook        string
+	columnCoerce      bool
+	columnUnit        string
+	columnDecimals    int
+	columnThousands   bool
+	columnDryRun      bool
+
+	columnDescribeEdit    bool
+	columnDescribeEnforce string
 )
 
 var columnCmd = &cobra.Command{
@@ -37,7 +54,8 @@ Examples:
   stash column add Price --desc "Price in USD"
   stash column list
   stash column list --json
-  stash column describe Price "Price in USD"`,
+  stash column describe Price "Price in USD"
+  stash column describe --edit`,
 }
 
 var columnAddCmd = &cobra.Command{
@@ -58,6 +76,51 @@ Validation Options:
   --validate TYPE  Validate format: email, url, number, date
   --enum VALUES    Comma-separated list of allowed values
   --required       Field must have a non-empty value
+  --transitions    Allowed workflow moves between enum values,
+                   e.g. "pending>active>closed" (requires --enum)
+  --coerce         Accept enum values in any case and write the allowed
+                   value they match, so "Pending" is stored as "pending"
+                   (requires --enum). The value as given is recorded on
+                   the operation as _coerced. Without it, enum values
+                   must match exactly.
+  --due            Track this date column as a due date (see 'stash due')
+  --hook CMD       Validate values with a shell command, which reads the
+                   value as JSON on stdin and exits non-zero to reject it
+                   (see 'stash hook')
+  --severity SEV   error (default) rejects writes that break a constraint.
+                   warning accepts them: add and set print a warning, and
+                   'stash validate' reports them with severity "warning".
+                   Use it for columns holding legacy data that does not
+                   yet conform. Change it later with 'stash schema apply'.
+  --warn           Shorthand for --severity warning
+
+Display Options:
+  --unit UNIT      Unit shown after numbers in table and markdown output
+                   (list, show, search), e.g. "USD" or "kg"
+  --decimals N     Digits shown after the decimal point
+  --thousands-sep  Separate thousands with commas
+                   JSON and CSV output keep values as stored. Together,
+                   "--unit USD --decimals 2 --thousands-sep" shows 1299
+                   as "1,299.00 USD". Change them later with 'stash schema
+                   apply'.
+
+List Columns:
+  --list           Values are lists, stored as JSON arrays. --validate and
+                   --enum apply to each element, and --required means the
+                   list must not be empty. Set with tags=a,b, add and remove
+                   elements with 'stash set <id> tags+=a' and 'tags-=a', and
+                   filter with --where "tags CONTAINS a".
+
+When the stash requires column descriptions (see 'stash column describe
+--enforce'), --desc is required and columns are added one at a time.
+
+--dry-run checks the columns, including computed expressions, and prints
+them without adding them.
+
+Computed Columns:
+  --computed EXPR  Derive the value from a SQL expression over other
+                   columns. Computed values are evaluated at read time
+                   (list, show, query) and cannot be set directly.
 
 Examples:
   stash column add Name
@@ -66,6 +129,16 @@ Examples:
   stash column add email --validate email
   stash column add status --enum "pending,active,closed"
   stash column add priority --required
+  stash column add status --enum "pending,active,closed" --transitions "pending>active>closed"
+  stash column add status --enum "pending,active,closed" --coerce
+  stash column add total --computed "Price * Quantity"
+  stash column add due_on --due
+  stash column add owner --required --warn
+  stash column add sku --hook "./scripts/check-sku.sh"
+  stash column add contact --validate email --severity warning
+  stash column add tags --list --desc "Free-form labels"
+  stash column add Price --validate number --unit USD --decimals 2 --thousands-sep
+  stash column add total --computed "Price * Quantity" --dry-run
 
 AI Agent Examples:
   # Add email column with validation
@@ -74,16 +147,24 @@ AI Agent Examples:
   # Add status column with enum constraint
   stash column add status --enum "pending,active,closed" --required
 
+  # Derive a value instead of keeping it in sync manually
+  stash column add total --computed "Price * Quantity" --desc "Line total"
+
   # Check column constraints
   stash column list --json | jq '.[] | select(.validate != null)'
 
 Exit Codes:
   0  Success - column added
   1  Stash not found, column already exists
-  2  Validation error (invalid column name, invalid validation type)
+  2  Validation error (invalid column name, invalid validation type,
+     invalid computed expression, missing required description)
 
 JSON Output (--json):
   [{"name": "email", "validate": "email", "required": false}]
+
+JSON Output (--dry-run --json):
+  {"dry_run": true, "stash": "contacts",
+   "columns": [{"name": "email", "validate": "email", "required": false}]}
 `,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runColumnAdd,
@@ -103,17 +184,54 @@ Examples:
 }
 
 var columnDescribeCmd = &cobra.Command{
-	Use:   "describe <name> <description>",
-	Short: "Set or update a column description",
+	Use:   "describe <name> <description> | describe --edit | describe --enforce on|off",
+	Short: "Set or update column descriptions",
 	Long: `Set or update the description for a column.
 
 Descriptions help agents understand the purpose and format
 of each column.
 
+With --edit, every column description opens in $VISUAL or $EDITOR
+(default vi; notepad on Windows) as YAML, one "Column: description" line
+per column. Save and quit to apply the changes; columns cannot be added,
+removed, or renamed this way.
+
+With --enforce on, the stash requires a description on every new column:
+'stash column add' needs --desc, schema files must describe the columns
+they add, and descriptions cannot be cleared. Existing columns are not
+checked; 'stash doctor' lists those still without a description.
+
+Options:
+  --edit           Edit all column descriptions in $EDITOR
+  --enforce on|off Require descriptions on new columns
+
 Examples:
   stash column describe Price "Price in USD"
-  stash column describe Name "Product display name"`,
-	Args: cobra.ExactArgs(2),
+  stash column describe Name "Product display name"
+  stash column describe --edit
+  EDITOR="code --wait" stash column describe --edit
+  stash column describe --enforce on
+
+AI Agent Examples:
+  # Describe every undocumented column reported by doctor
+  stash doctor --json | jq -r '.checks[] | select(.check | endswith("/column_descriptions")) | .details'
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found, or the editor failed
+  2  Validation error (unknown column in the edited file, invalid YAML,
+     empty description while descriptions are required, invalid --enforce)
+
+JSON Output (--json):
+  describe:  {"name": "Price", "desc": "Price in USD"}
+  --edit:    {"stash": "inventory", "updated": ["Price", "Name"]}
+  --enforce: {"stash": "inventory", "require_descriptions": true, "undescribed": ["SKU"]}`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if columnDescribeEdit || columnDescribeEnforce != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	RunE: runColumnDescribe,
 }
 
@@ -122,6 +240,21 @@ func init() {
 	columnAddCmd.Flags().StringVar(&columnValidate, "validate", "", "Validation type: email, url, number, date")
 	columnAddCmd.Flags().StringVar(&columnEnum, "enum", "", "Comma-separated list of allowed values")
 	columnAddCmd.Flags().BoolVar(&columnRequired, "required", false, "Field is required (non-empty)")
+	columnAddCmd.Flags().StringVar(&columnTransitions, "transitions", "", "Allowed enum transitions (e.g., \"pending>active,active>closed\")")
+	columnAddCmd.Flags().BoolVar(&columnDue, "due", false, "Track this column as a due date (implies --validate date)")
+	columnAddCmd.Flags().StringVar(&columnSeverity, "severity", "", "Constraint violation severity: error (reject writes, default) or warning")
+	columnAddCmd.Flags().BoolVar(&columnWarn, "warn", false, "Shorthand for --severity warning")
+	columnAddCmd.Flags().StringVar(&columnComputed, "computed", "", "SQL expression to compute the value from other columns")
+	columnAddCmd.Flags().BoolVar(&columnList, "list", false, "Values are lists (add and remove elements with += and -=)")
+	columnAddCmd.Flags().StringVar(&columnHook, "hook", "", "Shell command that validates values (see 'stash hook')")
+	columnAddCmd.Flags().BoolVar(&columnCoerce, "coerce", false, "Accept enum values in any case, writing the allowed value (requires --enum)")
+	columnAddCmd.Flags().StringVar(&columnUnit, "unit", "", "Unit shown after numbers in table and markdown output")
+	columnAddCmd.Flags().IntVar(&columnDecimals, "decimals", -1, "Digits shown after the decimal point in table and markdown output")
+	columnAddCmd.Flags().BoolVar(&columnThousands, "thousands-sep", false, "Separate thousands with commas in table and markdown output")
+	columnAddCmd.Flags().BoolVar(&columnDryRun, "dry-run", false, "Check and print the columns without adding them")
+
+	columnDescribeCmd.Flags().BoolVar(&columnDescribeEdit, "edit", false, "Edit all column descriptions in $EDITOR")
+	columnDescribeCmd.Flags().StringVar(&columnDescribeEnforce, "enforce", "", "Require descriptions on new columns: on or off")
 
 	columnCmd.AddCommand(columnAddCmd)
 	columnCmd.AddCommand(columnListCmd)
@@ -147,7 +280,7 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -168,10 +301,65 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 	var addedColumns []model.Column
 	now := time.Now()
 
+	// --warn is shorthand for --severity warning
+	severity := model.SeverityError
+	if columnSeverity != "" {
+		var ok bool
+		if severity, ok = model.ParseSeverity(columnSeverity); !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid severity '%s' (valid: error, warning)\n", columnSeverity)
+			Exit(2)
+			return nil
+		}
+		if columnWarn && severity == model.SeverityError {
+			fmt.Fprintln(os.Stderr, "Error: --warn cannot be combined with --severity error")
+			Exit(2)
+			return nil
+		}
+	}
+	if columnWarn {
+		severity = model.SeverityWarning
+	}
+	warn := severity == model.SeverityWarning
+
 	// If any constraint flags are provided, only one column name is allowed
-	hasConstraints := columnDesc != "" || columnValidate != "" || columnEnum != "" || columnRequired
+	hasConstraints := columnDesc != "" || columnValidate != "" || columnEnum != "" || columnRequired || columnComputed != "" || columnTransitions != "" || columnDue || columnSeverity != "" || columnWarn || columnList || columnHook != "" || columnCoerce
 	if hasConstraints && len(args) > 1 {
-		fmt.Fprintln(os.Stderr, "Error: --desc, --validate, --enum, and --required can only be used when adding a single column")
+		fmt.Fprintln(os.Stderr, "Error: --desc, --validate, --enum, --required, --transitions, --computed, --list, --hook, and --coerce can only be used when adding a single column")
+		Exit(2)
+		return nil
+	}
+
+	if stash.RequireDescriptions && strings.TrimSpace(columnDesc) == "" {
+		fmt.Fprintf(os.Stderr, "Error: stash '%s' requires column descriptions (use --desc)\n", ctx.Stash)
+		Exit(2)
+		return nil
+	}
+
+	// List elements have no single current value to move between
+	if columnList && (columnComputed != "" || columnTransitions != "" || columnDue) {
+		fmt.Fprintln(os.Stderr, "Error: --list cannot be combined with --computed, --transitions, or --due")
+		Exit(2)
+		return nil
+	}
+
+	// Computed columns are read-only, so value constraints don't apply
+	if columnComputed != "" {
+		if columnValidate != "" || columnEnum != "" || columnRequired || columnTransitions != "" || columnHook != "" {
+			fmt.Fprintln(os.Stderr, "Error: --computed cannot be combined with --validate, --enum, --required, --transitions, or --hook")
+			Exit(2)
+			return nil
+		}
+		// The primary column receives the value passed to 'stash add'
+		if !stash.HasColumns() {
+			fmt.Fprintln(os.Stderr, "Error: the first column cannot be computed (it holds the primary value)")
+			Exit(2)
+			return nil
+		}
+	}
+
+	// Warning severity only applies to value constraints
+	if warn && columnValidate == "" && columnEnum == "" && !columnRequired && !columnDue && columnHook == "" {
+		fmt.Fprintln(os.Stderr, "Error: --severity warning requires --validate, --enum, --required, --due, or --hook")
 		Exit(2)
 		return nil
 	}
@@ -184,6 +372,17 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Due date columns hold dates
+	if columnDue {
+		if columnValidate == "" {
+			columnValidate = string(ValidationDate)
+		} else if columnValidate != string(ValidationDate) {
+			fmt.Fprintln(os.Stderr, "Error: --due requires date validation (omit --validate or use --validate date)")
+			Exit(2)
+			return nil
+		}
+	}
+
 	// Parse enum values
 	var enumValues []string
 	if columnEnum != "" {
@@ -200,6 +399,35 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if columnDecimals < -1 {
+		fmt.Fprintf(os.Stderr, "Error: invalid --decimals %d (must not be negative)\n", columnDecimals)
+		Exit(2)
+		return nil
+	}
+	var decimals *int
+	if columnDecimals >= 0 {
+		n := columnDecimals
+		decimals = &n
+	}
+
+	// Coercion maps values onto the enum's allowed values
+	if columnCoerce && len(enumValues) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --coerce requires --enum")
+		Exit(2)
+		return nil
+	}
+
+	// Parse workflow transitions between enum values
+	var transitions map[string][]string
+	if columnTransitions != "" {
+		transitions, err = parseTransitions(columnTransitions, enumValues)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(2)
+			return nil
+		}
+	}
+
 	// Add each column
 	for _, name := range args {
 		// Validate column name first (for better error messages)
@@ -227,16 +455,32 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 		}
 
 		col := model.Column{
-			Name:     name,
-			Desc:     columnDesc,
-			Added:    now,
-			AddedBy:  ctx.Actor,
-			Validate: columnValidate,
-			Enum:     enumValues,
-			Required: columnRequired,
+			Name:         name,
+			Desc:         columnDesc,
+			Added:        now,
+			AddedBy:      ctx.Actor,
+			Validate:     columnValidate,
+			Enum:         enumValues,
+			Required:     columnRequired,
+			Computed:     strings.TrimSpace(columnComputed),
+			Transitions:  transitions,
+			Due:          columnDue,
+			List:         columnList,
+			Hook:         strings.TrimSpace(columnHook),
+			Coerce:       columnCoerce,
+			Unit:         strings.TrimSpace(columnUnit),
+			Decimals:     decimals,
+			ThousandsSep: columnThousands,
+		}
+		if warn {
+			col.Severity = model.SeverityWarning
 		}
 
-		if err := store.AddColumn(ctx.Stash, col); err != nil {
+		addColumn := store.AddColumn
+		if columnDryRun {
+			addColumn = store.CheckColumn
+		}
+		if err := addColumn(ctx.Stash, col); err != nil {
 			if errors.Is(err, model.ErrColumnExists) {
 				// Find the existing column name to show original case
 				existing := stash.Columns.Find(name)
@@ -248,6 +492,11 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 				Exit(1)
 				return nil
 			}
+			if col.IsComputed() {
+				fmt.Fprintf(os.Stderr, "Error: invalid computed expression '%s': %v\n", col.Computed, err)
+				Exit(2)
+				return nil
+			}
 			return fmt.Errorf("failed to add column '%s': %w", name, err)
 		}
 
@@ -255,23 +504,51 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 		// Update local stash reference to track added columns for subsequent checks
 		stash.Columns = append(stash.Columns, col)
 	}
+	if columnHook != "" && !columnDryRun {
+		if err := trustHooks(ctx.StashDir, strings.TrimSpace(columnHook)); err != nil {
+			return err
+		}
+	}
 
 	// Output result
 	if GetJSONOutput() {
 		output := make([]map[string]interface{}, len(addedColumns))
 		for i, col := range addedColumns {
 			output[i] = map[string]interface{}{
-				"name":     col.Name,
-				"desc":     col.Desc,
-				"added":    col.Added.Format(time.RFC3339),
-				"added_by": col.AddedBy,
-				"validate": col.Validate,
-				"enum":     col.Enum,
-				"required": col.Required,
+				"name":          col.Name,
+				"desc":          col.Desc,
+				"added":         col.Added.Format(time.RFC3339),
+				"added_by":      col.AddedBy,
+				"validate":      col.Validate,
+				"enum":          col.Enum,
+				"required":      col.Required,
+				"computed":      col.Computed,
+				"transitions":   col.Transitions,
+				"due":           col.Due,
+				"list":          col.List,
+				"hook":          col.Hook,
+				"coerce":        col.Coerce,
+				"unit":          col.Unit,
+				"decimals":      col.Decimals,
+				"thousands_sep": col.ThousandsSep,
+				"severity":      col.ViolationSeverity(),
 			}
 		}
-		data, _ := json.Marshal(output)
+		var data []byte
+		if columnDryRun {
+			data, _ = json.Marshal(map[string]interface{}{
+				"dry_run": true,
+				"stash":   ctx.Stash,
+				"columns": output,
+			})
+		} else {
+			data, _ = json.Marshal(output)
+		}
 		fmt.Println(string(data))
+	} else if columnDryRun && !IsQuiet() {
+		for _, col := range addedColumns {
+			fmt.Printf("Would add column '%s' to stash '%s'\n", col.Name, ctx.Stash)
+		}
 	} else if !IsQuiet() {
 		if len(addedColumns) == 1 {
 			fmt.Printf("Added column '%s' to stash '%s'\n", addedColumns[0].Name, ctx.Stash)
@@ -294,19 +571,49 @@ func runColumnAdd(cmd *cobra.Command, args []string) error {
 	columnValidate = ""
 	columnEnum = ""
 	columnRequired = false
+	columnComputed = ""
+	columnTransitions = ""
+	columnDue = false
+	columnList = false
+	columnCoerce = false
+	columnUnit = ""
+	columnDecimals = -1
+	columnThousands = false
+	columnDryRun = false
 
 	return nil
 }
 
+// resolveColumn returns the stash column with the given name
+// (case-insensitive). If there is none it reports the error, with a
+// suggestion for a likely typo, and returns nil.
+func resolveColumn(stash *model.Stash, name string) *model.Column {
+	col := stash.Columns.Find(name)
+	if col == nil {
+		ExitColumnNotFound(name, stash.Columns)
+	}
+	return col
+}
+
 // ColumnInfo represents column information for list output
 type ColumnInfo struct {
-	Name      string   `json:"name"`
-	Desc      string   `json:"desc"`
-	Validate  string   `json:"validate,omitempty"`
-	Enum      []string `json:"enum,omitempty"`
-	Required  bool     `json:"required,omitempty"`
-	Populated int      `json:"populated"`
-	Empty     int      `json:"empty"`
+	Name        string              `json:"name"`
+	Desc        string              `json:"desc"`
+	Validate    string              `json:"validate,omitempty"`
+	Enum        []string            `json:"enum,omitempty"`
+	Required    bool                `json:"required,omitempty"`
+	Computed    string              `json:"computed,omitempty"`
+	Transitions map[string][]string `json:"transitions,omitempty"`
+	Due         bool                `json:"due,omitempty"`
+	List        bool                `json:"list,omitempty"`
+	Severity    string              `json:"severity,omitempty"`
+	Hook        string              `json:"hook,omitempty"`
+	Coerce      bool                `json:"coerce,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Decimals    *int                `json:"decimals,omitempty"`
+	Thousands   bool                `json:"thousands_sep,omitempty"`
+	Populated   int                 `json:"populated"`
+	Empty       int                 `json:"empty"`
 }
 
 func runColumnList(cmd *cobra.Command, args []string) error {
@@ -327,7 +634,7 @@ func runColumnList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -357,11 +664,21 @@ func runColumnList(cmd *cobra.Command, args []string) error {
 	columnInfos := make([]ColumnInfo, len(stash.Columns))
 	for i, col := range stash.Columns {
 		columnInfos[i] = ColumnInfo{
-			Name:     col.Name,
-			Desc:     col.Desc,
-			Validate: col.Validate,
-			Enum:     col.Enum,
-			Required: col.Required,
+			Name:        col.Name,
+			Desc:        col.Desc,
+			Validate:    col.Validate,
+			Enum:        col.Enum,
+			Required:    col.Required,
+			Computed:    col.Computed,
+			Transitions: col.Transitions,
+			Due:         col.Due,
+			List:        col.List,
+			Severity:    col.Severity,
+			Hook:        col.Hook,
+			Coerce:      col.Coerce,
+			Unit:        col.Unit,
+			Decimals:    col.Decimals,
+			Thousands:   col.ThousandsSep,
 		}
 
 		// Count populated and empty
@@ -394,9 +711,33 @@ func runColumnList(cmd *cobra.Command, args []string) error {
 				if len(info.Enum) > 0 {
 					fmt.Printf("    Enum: %s\n", strings.Join(info.Enum, ", "))
 				}
+				if info.Coerce {
+					fmt.Printf("    Coerce: yes\n")
+				}
 				if info.Required {
 					fmt.Printf("    Required: yes\n")
 				}
+				if info.Computed != "" {
+					fmt.Printf("    Computed: %s\n", info.Computed)
+				}
+				if info.Due {
+					fmt.Printf("    Due date: yes\n")
+				}
+				if info.List {
+					fmt.Printf("    List: yes\n")
+				}
+				if info.Unit != "" {
+					fmt.Printf("    Unit: %s\n", info.Unit)
+				}
+				if info.Decimals != nil {
+					fmt.Printf("    Decimals: %d\n", *info.Decimals)
+				}
+				if info.Thousands {
+					fmt.Printf("    Thousands separator: yes\n")
+				}
+				if len(info.Transitions) > 0 {
+					fmt.Printf("    Transitions: see 'stash transitions %s'\n", info.Name)
+				}
 				if len(records) > 0 {
 					fmt.Printf("    Populated: %d, Empty: %d\n", info.Populated, info.Empty)
 				}
@@ -408,8 +749,16 @@ func runColumnList(cmd *cobra.Command, args []string) error {
 }
 
 func runColumnDescribe(cmd *cobra.Command, args []string) error {
-	columnName := args[0]
-	description := args[1]
+	if columnDescribeEdit && columnDescribeEnforce != "" {
+		fmt.Fprintln(os.Stderr, "Error: --edit and --enforce cannot be used together")
+		Exit(2)
+		return nil
+	}
+	if columnDescribeEnforce != "" && columnDescribeEnforce != "on" && columnDescribeEnforce != "off" {
+		fmt.Fprintf(os.Stderr, "Error: invalid --enforce value '%s' (use on or off)\n", columnDescribeEnforce)
+		Exit(2)
+		return nil
+	}
 
 	// Resolve context - stash is required
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -428,7 +777,7 @@ func runColumnDescribe(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -445,11 +794,25 @@ func runColumnDescribe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if columnDescribeEnforce != "" {
+		return enforceColumnDescriptions(store, stash, columnDescribeEnforce == "on")
+	}
+	if columnDescribeEdit {
+		return editColumnDescriptions(store, stash)
+	}
+
+	columnName := args[0]
+	description := args[1]
+
 	// Find column (case-insensitive)
-	col := stash.Columns.Find(columnName)
+	col := resolveColumn(stash, columnName)
 	if col == nil {
-		fmt.Fprintf(os.Stderr, "Error: column '%s' not found\n", columnName)
-		Exit(1)
+		return nil
+	}
+
+	if stash.RequireDescriptions && strings.TrimSpace(description) == "" {
+		fmt.Fprintf(os.Stderr, "Error: stash '%s' requires column descriptions; '%s' cannot be cleared\n", stash.Name, col.Name)
+		Exit(2)
 		return nil
 	}
 
@@ -475,3 +838,145 @@ func runColumnDescribe(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// undescribedColumns returns the names of the stash's columns that have no
+// description.
+func undescribedColumns(stash *model.Stash) []string {
+	names := []string{}
+	for _, col := range stash.Columns {
+		if strings.TrimSpace(col.Desc) == "" {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// enforceColumnDescriptions turns the stash's description requirement on
+// or off.
+func enforceColumnDescriptions(store *storage.Store, stash *model.Stash, require bool) error {
+	stash.RequireDescriptions = require
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update stash: %w", err)
+	}
+
+	undescribed := undescribedColumns(stash)
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"stash":                stash.Name,
+			"require_descriptions": require,
+			"undescribed":          undescribed,
+		})
+		fmt.Println(string(data))
+		return nil
+	}
+	if IsQuiet() {
+		return nil
+	}
+	if !require {
+		fmt.Printf("Column descriptions are no longer required in stash '%s'\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Column descriptions are now required in stash '%s'\n", stash.Name)
+	if len(undescribed) > 0 {
+		fmt.Printf("  %d existing column(s) without a description: %s\n", len(undescribed), strings.Join(undescribed, ", "))
+		fmt.Println("  Add them with 'stash column describe --edit'")
+	}
+	return nil
+}
+
+// editColumnDescriptions opens every column description in the user's
+// editor as YAML and applies the changes.
+func editColumnDescriptions(store *storage.Store, stash *model.Stash) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, col := range stash.Columns {
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: col.Name},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: col.Desc, Style: describeValueStyle(col.Desc)})
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Column descriptions for stash '%s'.\n", stash.Name)
+	buf.WriteString("# Edit the descriptions, then save and quit. Columns cannot be added,\n")
+	buf.WriteString("# removed, or renamed here. Lines starting with '#' are ignored.\n")
+	if len(stash.Columns) > 0 {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode descriptions: %w", err)
+		}
+		enc.Close()
+	}
+
+	edited, err := editInEditor("stash-columns-*.yaml", buf.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		Exit(1)
+		return nil
+	}
+
+	var descs map[string]string
+	if err := yaml.Unmarshal(edited, &descs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid descriptions file: %v\n", err)
+		Exit(2)
+		return nil
+	}
+
+	// Resolve every edited name before changing anything
+	updated := []string{}
+	changes := make(map[*model.Column]string)
+	for name, desc := range descs {
+		col := stash.Columns.Find(name)
+		if col == nil {
+			fmt.Fprintf(os.Stderr, "Error: column '%s' not found (columns cannot be added here)\n", name)
+			Exit(2)
+			return nil
+		}
+		desc = strings.TrimSpace(desc)
+		if desc == col.Desc {
+			continue
+		}
+		if stash.RequireDescriptions && desc == "" {
+			fmt.Fprintf(os.Stderr, "Error: stash '%s' requires column descriptions; '%s' cannot be cleared\n", stash.Name, col.Name)
+			Exit(2)
+			return nil
+		}
+		changes[col] = desc
+	}
+	for i := range stash.Columns {
+		col := &stash.Columns[i]
+		if desc, ok := changes[col]; ok {
+			col.Desc = desc
+			updated = append(updated, col.Name)
+		}
+	}
+
+	if len(updated) > 0 {
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update column descriptions: %w", err)
+		}
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"stash":   stash.Name,
+			"updated": updated,
+		})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if len(updated) == 0 {
+			fmt.Println("No description changes")
+		} else {
+			fmt.Printf("Updated %d column description(s) in stash '%s': %s\n", len(updated), stash.Name, strings.Join(updated, ", "))
+		}
+	}
+	return nil
+}
+
+// describeValueStyle quotes empty descriptions so the editor shows an
+// obvious place to type.
+func describeValueStyle(desc string) yaml.Style {
+	if desc == "" {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
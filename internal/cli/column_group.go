@@ -0,0 +1,234 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var columnGroupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Show or manage named column groups",
+	Long: `Show the column groups of a stash.
+
+A column group names a slice of the columns, so a wide stash can be
+viewed a few relevant columns at a time. Wherever columns are listed,
+"@<group>" stands for the group's columns:
+
+  stash list --columns @pricing
+  stash list --columns Name,@pricing
+  stash show inv-ex4j --fields @pricing
+
+Groups are stored in the stash's config.json and shared with everyone
+using the stash.
+
+Examples:
+  stash column group
+  stash column group set pricing Price,Cost,Margin
+  stash column group remove pricing
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "inventory", "groups": [{"name": "pricing", "columns": ["Price", "Cost", "Margin"]}]}`,
+	Args: cobra.NoArgs,
+	RunE: runColumnGroup,
+}
+
+var columnGroupSetCmd = &cobra.Command{
+	Use:   "set <name> <column>[,<column>...] [column...]",
+	Short: "Define a column group",
+	Long: `Add or replace a column group. The columns are given comma-separated,
+as separate arguments, or both, in the order they should be shown.
+
+Examples:
+  stash column group set pricing Price,Cost,Margin
+  stash column group set contact Name Email Phone
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (invalid group name)
+
+JSON Output (--json):
+  {"stash": "inventory", "group": {"name": "pricing", "columns": ["Price", "Cost", "Margin"]}}`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runColumnGroupSet,
+}
+
+var columnGroupRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a column group",
+	Long: `Remove a column group. The columns themselves are not changed.
+
+Examples:
+  stash column group remove pricing
+
+Exit Codes:
+  0  Success
+  1  Stash or group not found
+
+JSON Output (--json):
+  {"stash": "inventory", "removed": "pricing"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runColumnGroupRemove,
+}
+
+func init() {
+	columnGroupCmd.AddCommand(columnGroupSetCmd)
+	columnGroupCmd.AddCommand(columnGroupRemoveCmd)
+	columnCmd.AddCommand(columnGroupCmd)
+}
+
+// expandColumnGroups replaces each "@group" among names with the group's
+// columns, leaving other names as they are and dropping repeats. Reports
+// an unknown group and returns false.
+func expandColumnGroups(stash *model.Stash, names []string) ([]string, bool) {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			expanded = append(expanded, name)
+		}
+	}
+	for _, name := range names {
+		groupName, isGroup := model.GroupName(name)
+		if !isGroup {
+			add(name)
+			continue
+		}
+		group := stash.Group(groupName)
+		if group == nil {
+			groupNames := make([]string, len(stash.Groups))
+			for i, g := range stash.Groups {
+				groupNames[i] = g.Name
+			}
+			ExitWithError(1, ErrCodeColumnNotFound, fmt.Sprintf("column group '%s' not found", groupName),
+				map[string]interface{}{"group": groupName, "groups": groupNames})
+			return nil, false
+		}
+		for _, col := range group.Columns {
+			add(col)
+		}
+	}
+	return expanded, true
+}
+
+func runColumnGroup(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Output result
+	if GetJSONOutput() {
+		groups := stash.Groups
+		if groups == nil {
+			groups = []model.ColumnGroup{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "groups": groups})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsPorcelain() {
+		for _, group := range stash.Groups {
+			printPorcelain(group.Name, strings.Join(group.Columns, ","))
+		}
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(stash.Groups) == 0 {
+		fmt.Printf("Stash '%s' has no column groups\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Column groups of stash '%s':\n", stash.Name)
+	for _, group := range stash.Groups {
+		fmt.Printf("  @%s: %s\n", group.Name, strings.Join(group.Columns, ", "))
+	}
+	return nil
+}
+
+func runColumnGroupSet(cmd *cobra.Command, args []string) error {
+	name := strings.TrimPrefix(args[0], model.GroupPrefix)
+	if err := model.ValidateGroupName(name); err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"group": name})
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Columns must exist; store them with their actual names
+	group := model.ColumnGroup{Name: name}
+	for _, arg := range args[1:] {
+		for _, colName := range splitPermissionList(arg) {
+			col := resolveColumn(stash, colName)
+			if col == nil {
+				return nil
+			}
+			group.Columns = append(group.Columns, col.Name)
+		}
+	}
+	if len(group.Columns) == 0 {
+		ExitValidationError("a column group needs at least one column", map[string]interface{}{"group": name})
+		return nil
+	}
+
+	stash.SetGroup(group)
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update column groups: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "group": group})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Set column group @%s in stash '%s': %s\n", group.Name, stash.Name, strings.Join(group.Columns, ", "))
+	}
+	return nil
+}
+
+func runColumnGroupRemove(cmd *cobra.Command, args []string) error {
+	name := strings.TrimPrefix(args[0], model.GroupPrefix)
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !stash.RemoveGroup(name) {
+		ExitWithError(1, ErrCodeColumnNotFound, fmt.Sprintf("column group '%s' not found", name),
+			map[string]interface{}{"group": name})
+		return nil
+	}
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update column groups: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "removed": name})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed column group @%s from stash '%s'\n", name, stash.Name)
+	}
+	return nil
+}
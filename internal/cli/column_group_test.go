@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestColumnGroup(t *testing.T) {
+	t.Run("groups expand in list and show", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Cost", "Notes"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "group", "set", "pricing", "price,Cost"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		var created map[string]interface{}
+		output := captureSchemaOutput(t, "add", "Laptop", "--set", "Price=10", "--set", "Cost=7", "--set", "Notes=x", "--json")
+		if err := json.Unmarshal([]byte(output), &created); err != nil {
+			t.Fatalf("failed to parse add output %q: %v", output, err)
+		}
+		id, _ := created["_id"].(string)
+
+		output = captureSchemaOutput(t, "list", "--columns", "Name,@pricing,Price")
+		header := strings.Fields(strings.SplitN(output, "\n", 2)[0])
+		if strings.Join(header[:4], " ") != "ID Name Price Cost" {
+			t.Errorf("expected columns ID Name Price Cost, got %v", header)
+		}
+		if strings.Contains(output, "Notes") {
+			t.Errorf("expected Notes to be left out:\n%s", output)
+		}
+
+		var shown map[string]interface{}
+		output = captureSchemaOutput(t, "show", id, "--fields", "@pricing", "--json")
+		if err := json.Unmarshal([]byte(output), &shown); err != nil {
+			t.Fatalf("failed to parse show output %q: %v", output, err)
+		}
+		if _, ok := shown["Price"]; !ok {
+			t.Errorf("expected Price in %v", shown)
+		}
+		if _, ok := shown["Notes"]; ok {
+			t.Errorf("expected no Notes in %v", shown)
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		output := captureSchemaOutput(t, "list", "--columns", "@pricing", "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["code"] != ErrCodeColumnNotFound {
+			t.Errorf("expected %s, got %v", ErrCodeColumnNotFound, result["code"])
+		}
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+
+	t.Run("set rejects unknown columns and remove drops the group", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "group", "set", "pricing", "Price,Margin"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for unknown column, got %d", ExitCode)
+		}
+		resetFlags()
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"column", "group", "set", "pricing", "Price"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"column", "group", "remove", "@pricing"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		var result struct {
+			Groups []interface{} `json:"groups"`
+		}
+		output := captureSchemaOutput(t, "column", "group", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if len(result.Groups) != 0 {
+			t.Errorf("expected no groups, got %v", result.Groups)
+		}
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/user/stash/internal/storage"
 )
 
 // TestUC_COL_001_AddColumn tests UC-COL-001: Add Column
@@ -558,3 +561,329 @@ func TestColumnAlias(t *testing.T) {
 		}
 	})
 }
+
+func TestColumnComputed(t *testing.T) {
+	t.Run("computed column is evaluated at read time", func(t *testing.T) {
+		// Given: Stash with Name, Price and Quantity columns
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Quantity"})
+		defer cleanup()
+
+		// When: User adds a computed column and a record
+		rootCmd.SetArgs([]string{"column", "add", "total", "--computed", "Price * Quantity"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0 adding computed column, got %d", ExitCode)
+		}
+
+		rootCmd.SetArgs([]string{"add", "Widget", "--set", "Price=2.5", "--set", "Quantity=4"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0 adding record, got %d", ExitCode)
+		}
+
+		// Then: The computed value is derived from the stored fields
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+
+		records, err := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+		if records[0].Fields["total"] != float64(10) {
+			t.Errorf("expected total 10, got %v", records[0].Fields["total"])
+		}
+	})
+
+	t.Run("must not allow setting a computed column", func(t *testing.T) {
+		// Given: Stash with a computed column and a record
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Quantity"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "add", "total", "--computed", "Price * Quantity"})
+		rootCmd.Execute()
+		rootCmd.SetArgs([]string{"add", "Widget"})
+		rootCmd.Execute()
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.Close()
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+
+		// When: User tries to set the computed column
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", records[0].ID, "total=5"})
+		rootCmd.Execute()
+
+		// Then: Exit code is 2
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("must not allow invalid expressions", func(t *testing.T) {
+		// Given: Stash with a Name column
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		// When: User adds a computed column referencing an unknown column
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"column", "add", "total", "--computed", "Missing * 2"})
+		rootCmd.Execute()
+
+		// Then: Exit code is 2
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestColumnSuggestions(t *testing.T) {
+	addRecord := func(t *testing.T) string {
+		t.Helper()
+		output := captureSchemaOutput(t, "add", "Laptop", "--set", "Price=999", "--json")
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &rec); err != nil {
+			t.Fatalf("failed to parse add output: %v", err)
+		}
+		return rec["_id"].(string)
+	}
+
+	parseError := func(t *testing.T, output string) JSONError {
+		t.Helper()
+		var jsonErr JSONError
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &jsonErr); err != nil {
+			t.Fatalf("failed to parse error output %q: %v", output, err)
+		}
+		return jsonErr
+	}
+
+	t.Run("set suggests the closest column", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+		id := addRecord(t)
+
+		ExitCode = 0
+		jsonErr := parseError(t, captureSchemaOutput(t, "set", id, "Prce=10", "--json"))
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		if jsonErr.Code != ErrCodeColumnNotFound {
+			t.Errorf("expected code %s, got %s", ErrCodeColumnNotFound, jsonErr.Code)
+		}
+		if !strings.Contains(jsonErr.Message, "did you mean 'Price'?") {
+			t.Errorf("expected suggestion in message, got %q", jsonErr.Message)
+		}
+		if jsonErr.Details["suggestion"] != "Price" {
+			t.Errorf("expected suggestion detail 'Price', got %v", jsonErr.Details["suggestion"])
+		}
+	})
+
+	t.Run("no suggestion when nothing is close", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+		id := addRecord(t)
+
+		ExitCode = 0
+		jsonErr := parseError(t, captureSchemaOutput(t, "set", id, "Warehouse=A", "--json"))
+		if _, ok := jsonErr.Details["suggestion"]; ok {
+			t.Errorf("expected no suggestion, got %v", jsonErr.Details["suggestion"])
+		}
+	})
+
+	t.Run("list where suggests the closest column", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+		addRecord(t)
+
+		ExitCode = 0
+		jsonErr := parseError(t, captureSchemaOutput(t, "list", "--where", "price>100", "--where", "Nmae=Laptop", "--json"))
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		if jsonErr.Details["column"] != "Nmae" || jsonErr.Details["suggestion"] != "Name" {
+			t.Errorf("unexpected details: %v", jsonErr.Details)
+		}
+	})
+
+	t.Run("list where accepts system columns", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+		id := addRecord(t)
+
+		ExitCode = 0
+		output := captureSchemaOutput(t, "list", "--where", "id="+id, "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", ExitCode, output)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse list output: %v", err)
+		}
+		if len(records) != 1 {
+			t.Errorf("expected 1 record, got %d", len(records))
+		}
+	})
+}
+
+// TestColumnDescribeEdit tests bulk editing descriptions in $EDITOR
+func TestColumnDescribeEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell script")
+	}
+
+	// fakeEditor installs an editor that saves the file it was given to
+	// seen and replaces it with content.
+	fakeEditor := func(t *testing.T, content string) (seen string) {
+		dir := t.TempDir()
+		seen = filepath.Join(dir, "seen.yaml")
+		replacement := filepath.Join(dir, "replacement.yaml")
+		os.WriteFile(replacement, []byte(content), 0644)
+		script := filepath.Join(dir, "editor.sh")
+		os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" '"+seen+"'\ncp '"+replacement+"' \"$1\"\n"), 0755)
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", script)
+		return seen
+	}
+
+	t.Run("applies edited descriptions", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		seen := fakeEditor(t, "Name: Product name\nprice: Price in USD\n")
+
+		ExitCode = 0
+		out := captureSchemaOutput(t, "column", "describe", "--edit", "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		data, _ := os.ReadFile(seen)
+		if !strings.Contains(string(data), "Name: \"\"") || !strings.Contains(string(data), "Price: \"\"") {
+			t.Errorf("expected every column in the edited file, got:\n%s", data)
+		}
+
+		var result map[string]interface{}
+		json.Unmarshal([]byte(out), &result)
+		if updated, _ := result["updated"].([]interface{}); len(updated) != 2 {
+			t.Errorf("expected 2 updated columns, got %s", out)
+		}
+
+		out = captureSchemaOutput(t, "column", "list", "--json")
+		var cols []ColumnInfo
+		json.Unmarshal([]byte(out), &cols)
+		if len(cols) != 2 || cols[0].Desc != "Product name" || cols[1].Desc != "Price in USD" {
+			t.Errorf("unexpected descriptions: %+v", cols)
+		}
+	})
+
+	t.Run("rejects unknown columns", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		fakeEditor(t, "Name: Product name\nColour: Paint colour\n")
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "describe", "--edit")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+// TestColumnDescribeEnforce tests requiring descriptions on new columns
+func TestColumnDescribeEnforce(t *testing.T) {
+	t.Run("column add requires --desc", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		ExitCode = 0
+		out := captureSchemaOutput(t, "column", "describe", "--enforce", "on", "--json")
+		if !strings.Contains(out, `"undescribed":["Name"]`) {
+			t.Errorf("expected Name reported as undescribed, got %s", out)
+		}
+
+		captureSchemaOutput(t, "column", "add", "Price")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 without --desc, got %d", ExitCode)
+		}
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "add", "Price", "--desc", "Price in USD")
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 with --desc, got %d", ExitCode)
+		}
+
+		captureSchemaOutput(t, "column", "describe", "Price", "")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 clearing a description, got %d", ExitCode)
+		}
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "describe", "--enforce", "off")
+		captureSchemaOutput(t, "column", "add", "SKU")
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 once enforcement is off, got %d", ExitCode)
+		}
+	})
+
+	t.Run("schema apply requires descriptions", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "column", "describe", "--enforce", "on")
+
+		schemaPath := filepath.Join(tempDir, "schema.yaml")
+		os.WriteFile(schemaPath, []byte("columns:\n  - name: Name\n  - name: Price\n"), 0644)
+
+		ExitCode = 0
+		captureSchemaOutput(t, "schema", "apply", schemaPath)
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("init checks schema columns", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		schemaPath := filepath.Join(tempDir, "schema.yaml")
+		os.WriteFile(schemaPath, []byte("prefix: sh-\ncolumns:\n  - name: Name\n"), 0644)
+
+		ExitCode = 0
+		captureSchemaOutput(t, "init", "shared", "--from-schema", schemaPath, "--require-descriptions")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+
+		ExitCode = 0
+		captureSchemaOutput(t, "init", "todo", "--preset", "tasks", "--require-descriptions")
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 for a described preset, got %d", ExitCode)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "describe", "--enforce", "maybe")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
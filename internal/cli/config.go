@@ -0,0 +1,334 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// preferenceKeys lists the settings 'stash config' accepts.
+var preferenceKeys = []string{"list.columns", "list.order-by", "list.limit"}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or manage your saved preferences for a stash",
+	Long: `Show your saved preferences for the current stash.
+
+Preferences are default flags that commands apply when you leave the flag
+out, so every invocation doesn't need the same long flag string. A flag
+given on the command line always wins, and 'stash list --no-prefs' ignores
+preferences altogether.
+
+Preferences are per user: they are kept in the user config directory
+(~/.config/stash, or $STASH_CONFIG_DIR), not in .stash, and apply to every
+stash with the same name on this machine.
+
+Keys:
+  list.columns   Default for list --columns (e.g., Name,Price)
+  list.order-by  Default for list --order-by (e.g., "Price desc")
+  list.limit     Default for list --limit
+
+Examples:
+  stash config
+  stash config set list.columns Name,Price
+  stash config set list.order-by "Price desc"
+  stash config get list.columns
+  stash config unset list.limit
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "inventory", "preferences": {"list.columns": "Name,Price"}}`,
+	Args: cobra.NoArgs,
+	RunE: runConfig,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Save a preference",
+	Long: `Save a preference for the current stash. See 'stash config' for keys.
+
+Examples:
+  stash config set list.columns Name,Price
+  stash config set list.order-by "Category,Price desc"
+  stash config set list.limit 20
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (unknown key, invalid value)
+
+JSON Output (--json):
+  {"stash": "inventory", "key": "list.columns", "value": "Name,Price"}`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show a preference",
+	Long: `Show a preference for the current stash. Nothing is printed if it is
+not set.
+
+Examples:
+  stash config get list.columns
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (unknown key)
+
+JSON Output (--json):
+  {"stash": "inventory", "key": "list.columns", "value": "Name,Price"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a preference",
+	Long: `Remove a preference for the current stash, so the command's own default
+applies again.
+
+Examples:
+  stash config unset list.columns
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or the preference is not set
+  2  Validation error (unknown key)
+
+JSON Output (--json):
+  {"stash": "inventory", "key": "list.columns", "removed": "Name,Price"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUnset,
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	store.Close()
+
+	prefs, err := context.LoadPreferences()
+	if err != nil {
+		return fmt.Errorf("failed to load preferences: %w", err)
+	}
+	settings := prefs.Stashes[stash.Name]
+	if settings == nil {
+		settings = map[string]string{}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "preferences": settings})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(settings) == 0 {
+		fmt.Printf("No preferences saved for stash '%s'\n", stash.Name)
+		return nil
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Printf("Preferences for stash '%s':\n", stash.Name)
+	for _, key := range keys {
+		fmt.Printf("  %s = %s\n", key, settings[key])
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], strings.TrimSpace(args[1])
+	if !checkPreferenceKey(key) {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	store.Close()
+
+	value, ok = normalizePreference(stash, key, value)
+	if !ok {
+		return nil
+	}
+
+	prefs, err := context.LoadPreferences()
+	if err != nil {
+		return fmt.Errorf("failed to load preferences: %w", err)
+	}
+	prefs.Set(stash.Name, key, value)
+	if err := context.SavePreferences(prefs); err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "key": key, "value": value})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Set %s = %s for stash '%s'\n", key, value, stash.Name)
+	}
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if !checkPreferenceKey(key) {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	store.Close()
+
+	prefs, err := context.LoadPreferences()
+	if err != nil {
+		return fmt.Errorf("failed to load preferences: %w", err)
+	}
+	value := prefs.Get(stash.Name, key)
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "key": key, "value": value})
+		fmt.Println(string(data))
+	} else if value != "" {
+		fmt.Println(value)
+	}
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if !checkPreferenceKey(key) {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	store.Close()
+
+	prefs, err := context.LoadPreferences()
+	if err != nil {
+		return fmt.Errorf("failed to load preferences: %w", err)
+	}
+	removed := prefs.Get(stash.Name, key)
+	if !prefs.Unset(stash.Name, key) {
+		ExitWithError(1, ErrCodeValidation, fmt.Sprintf("preference '%s' is not set", key),
+			map[string]interface{}{"stash": stash.Name, "key": key})
+		return nil
+	}
+	if err := context.SavePreferences(prefs); err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "key": key, "removed": removed})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed %s for stash '%s'\n", key, stash.Name)
+	}
+	return nil
+}
+
+// checkPreferenceKey exits with a validation error unless key is a known
+// preference.
+func checkPreferenceKey(key string) bool {
+	for _, known := range preferenceKeys {
+		if key == known {
+			return true
+		}
+	}
+	ExitValidationError(fmt.Sprintf("unknown preference '%s' (valid: %s)", key, strings.Join(preferenceKeys, ", ")),
+		map[string]interface{}{"key": key})
+	return false
+}
+
+// normalizePreference checks a preference value against the stash, the way
+// the flag it stands in for would, and returns it in canonical form.
+func normalizePreference(stash *model.Stash, key, value string) (string, bool) {
+	details := map[string]interface{}{"key": key, "value": value}
+	switch key {
+	case "list.columns":
+		var names []string
+		for _, name := range splitPermissionList(value) {
+			if !strings.HasPrefix(name, "_") {
+				col := resolveColumn(stash, name)
+				if col == nil {
+					return "", false
+				}
+				name = col.Name
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			ExitValidationError("list.columns needs at least one column", details)
+			return "", false
+		}
+		return strings.Join(names, ","), true
+	case "list.order-by":
+		if _, err := storage.ParseOrderBy(value, false); err != nil || value == "" {
+			message := "list.order-by cannot be empty"
+			if err != nil {
+				message = err.Error()
+			}
+			ExitValidationError(message, details)
+			return "", false
+		}
+		return value, true
+	case "list.limit":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			ExitValidationError("list.limit must be a positive number", details)
+			return "", false
+		}
+		return strconv.Itoa(n), true
+	}
+	return value, true
+}
+
+// listPreferences returns the user's saved list settings for a stash,
+// or none if --no-prefs was given or they cannot be read.
+func listPreferences(stashName string) map[string]string {
+	if listNoPrefs {
+		return nil
+	}
+	prefs, err := context.LoadPreferences()
+	if err != nil {
+		return nil
+	}
+	return prefs.Stashes[stashName]
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigPreferences(t *testing.T) {
+	t.Run("list applies saved defaults unless overridden", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Category"})
+		defer cleanup()
+		t.Setenv("STASH_CONFIG_DIR", filepath.Join(tempDir, ".config"))
+
+		for _, item := range [][]string{{"Laptop", "999"}, {"Mouse", "25"}, {"Monitor", "300"}} {
+			captureSchemaOutput(t, "add", item[0], "--set", "Price="+item[1], "--set", "Category=tech")
+		}
+
+		captureSchemaOutput(t, "config", "set", "list.columns", "name, price")
+		captureSchemaOutput(t, "config", "set", "list.order-by", "Price:numeric desc")
+		captureSchemaOutput(t, "config", "set", "list.limit", "2")
+		if ExitCode != 0 {
+			t.Fatalf("config set failed, exit %d", ExitCode)
+		}
+		if value := strings.TrimSpace(captureSchemaOutput(t, "config", "get", "list.columns")); value != "Name,Price" {
+			t.Errorf("expected canonical column names, got %q", value)
+		}
+
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--json")), &records)
+		if len(records) != 2 || records[0]["Name"] != "Laptop" || records[1]["Name"] != "Monitor" {
+			t.Fatalf("expected the two most expensive records, got %v", records)
+		}
+		if output := captureSchemaOutput(t, "list", "--csv"); !strings.HasPrefix(output, "_id,Name,Price\n") {
+			t.Errorf("expected only the saved columns, got: %s", output)
+		}
+
+		records = nil
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--limit", "1", "--order-by", "Name", "--json")), &records)
+		if len(records) != 1 || records[0]["Name"] != "Laptop" {
+			t.Errorf("expected flags to override saved defaults, got %v", records)
+		}
+
+		records = nil
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--no-prefs", "--json")), &records)
+		if len(records) != 3 {
+			t.Errorf("expected --no-prefs to ignore the saved limit, got %v", records)
+		}
+		if output := captureSchemaOutput(t, "list", "--csv", "--no-prefs"); !strings.HasPrefix(output, "_id,Name,Price,Category\n") {
+			t.Errorf("expected --no-prefs to ignore the saved columns, got: %s", output)
+		}
+
+		captureSchemaOutput(t, "config", "unset", "list.limit")
+		var config struct {
+			Preferences map[string]string `json:"preferences"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "config", "--json")), &config)
+		if _, ok := config.Preferences["list.limit"]; ok || len(config.Preferences) != 2 {
+			t.Errorf("expected list.limit to be removed, got %v", config.Preferences)
+		}
+	})
+
+	t.Run("rejects unknown keys and invalid values", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		t.Setenv("STASH_CONFIG_DIR", filepath.Join(tempDir, ".config"))
+
+		cases := []struct {
+			args []string
+			code int
+		}{
+			{[]string{"config", "set", "list.colour", "red"}, 2},
+			{[]string{"config", "set", "list.limit", "0"}, 2},
+			{[]string{"config", "set", "list.order-by", "Name sideways"}, 2},
+			{[]string{"config", "set", "list.columns", "Nope"}, 1},
+			{[]string{"config", "unset", "list.limit"}, 1},
+		}
+		for _, tc := range cases {
+			ExitCode = 0
+			captureStderr(t, func() {
+				captureSchemaOutput(t, tc.args...)
+			})
+			if ExitCode != tc.code {
+				t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.code, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
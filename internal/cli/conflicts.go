@@ -0,0 +1,342 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var conflictsAll bool
+
+// Kinds of conflict reported by 'stash conflicts'.
+const (
+	conflictBothChanged = "both_changed" // both operations set the field, to different values
+	conflictReverted    = "reverted"     // the later operation put back the value the earlier one changed
+)
+
+// ConflictChange is one side of a conflict: an operation and the value it
+// left the field with.
+type ConflictChange struct {
+	Actor string      `json:"actor"`
+	At    time.Time   `json:"at"`
+	Op    string      `json:"op"`
+	Hash  string      `json:"hash"`
+	Value interface{} `json:"value"`
+}
+
+// Conflict is a field that two operations by different actors changed
+// without either seeing the other's change. The later operation in the log
+// won; the earlier one's change was overwritten.
+type Conflict struct {
+	ID          string         `json:"id"`
+	Field       string         `json:"field"`
+	Kind        string         `json:"kind"`
+	BaseHash    string         `json:"base_hash"`
+	Base        interface{}    `json:"base"`
+	Overwritten ConflictChange `json:"overwritten"`
+	By          ConflictChange `json:"by"`
+	Current     interface{}    `json:"current"`
+	Resolved    bool           `json:"resolved,omitempty"`
+}
+
+// ConflictsOutput is the JSON output of 'stash conflicts'.
+type ConflictsOutput struct {
+	Stash     string     `json:"stash"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts [id]",
+	Short: "List concurrent changes to the same field after a merge",
+	Long: `List fields that two actors changed at the same time without seeing
+each other's change, such as after a git merge of records.jsonl.
+
+Every operation in records.jsonl stores the full state of its record, that
+state's hash, and the hash of the state it was made from. conflicts replays
+the log and, for each operation, finds that base state. Operations logged
+between the base state and the operation were not seen by it. When one of
+them was made by another actor and changed a field, the later operation
+overwrote that change. Two kinds of conflict are reported:
+
+  both_changed  Both operations changed the field, to different values
+  reverted      Only the earlier operation changed the field; the later
+                one silently put back the value from the base state
+
+The later operation's value is the current one. Review each conflict and
+set the field to the right value with 'stash set'. A conflict counts as
+resolved once the record is changed again by an operation made after it;
+use --all to include resolved conflicts.
+
+Operations written before base hashes were recorded are matched to the
+state they differ from least among those that existed when they were
+made. That finds reverted changes reliably, but two changes to the same
+field made minutes apart can look like one following the other.
+
+Examples:
+  stash conflicts                  # Unresolved conflicts in the stash
+  stash conflicts inv-ex4j         # Only for one record
+  stash conflicts --all            # Include resolved conflicts
+  stash conflicts --json
+
+AI Agent Examples:
+  # Check for lost changes after pulling
+  git pull && stash conflicts --quiet || stash conflicts --json
+
+Exit Codes:
+  0  No unresolved conflicts
+  1  Stash not found, or unresolved conflicts found
+
+JSON Output (--json):
+  {"stash": "inventory", "conflicts": [{"id": "inv-ex4j", "field": "Status",
+    "kind": "both_changed", "base_hash": "a1b2c3d4e5f6", "base": "open",
+    "overwritten": {"actor": "alice", "at": "...", "op": "update", "hash": "...", "value": "done"},
+    "by": {"actor": "bob", "at": "...", "op": "update", "hash": "...", "value": "blocked"},
+    "current": "blocked"}]}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConflicts,
+}
+
+func init() {
+	conflictsCmd.Flags().BoolVar(&conflictsAll, "all", false, "Include conflicts that have been resolved")
+	rootCmd.AddCommand(conflictsCmd)
+}
+
+func runConflicts(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	var history []*model.Record
+	if len(args) > 0 {
+		history, err = store.GetRecordHistory(stash.Name, args[0])
+		if err == nil && len(history) == 0 {
+			ExitRecordNotFound(args[0])
+			return nil
+		}
+	} else {
+		history, err = store.GetAllHistory(stash.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	conflicts := []Conflict{}
+	unresolved := 0
+	for _, c := range findConflicts(history) {
+		if !c.Resolved {
+			unresolved++
+		}
+		if conflictsAll || !c.Resolved {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(ConflictsOutput{Stash: stash.Name, Conflicts: conflicts})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		printConflicts(stash.Name, conflicts)
+	}
+
+	if unresolved > 0 {
+		Exit(1)
+	}
+	return nil
+}
+
+// printConflicts prints conflicts for a person to resolve.
+func printConflicts(stashName string, conflicts []Conflict) {
+	if len(conflicts) == 0 {
+		fmt.Printf("No conflicts in stash '%s'\n", stashName)
+		return
+	}
+	fmt.Printf("%d conflict(s) in stash '%s':\n", len(conflicts), stashName)
+	for _, c := range conflicts {
+		kind := "both changed"
+		if c.Kind == conflictReverted {
+			kind = "change reverted"
+		}
+		status := ""
+		if c.Resolved {
+			status = ", resolved"
+		}
+		fmt.Printf("\n%s  %s  (%s%s)\n", c.ID, c.Field, kind, status)
+		fmt.Printf("  base     %-16s\n", formatConflictValue(c.Base))
+		for _, change := range []ConflictChange{c.Overwritten, c.By} {
+			fmt.Printf("  %-8s %-16s %s\n", change.Actor, formatConflictValue(change.Value), change.At.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("  current  %s\n", formatConflictValue(c.Current))
+	}
+}
+
+// formatConflictValue formats a field value, showing unset values.
+func formatConflictValue(v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	return fmt.Sprint(v)
+}
+
+// findConflicts replays a JSONL log and returns the conflicts in it,
+// ordered by record and then by log position.
+func findConflicts(log []*model.Record) []Conflict {
+	// The operations that set a record's state, in log order; deletes only
+	// stamp metadata on the state before them
+	ops := make(map[string][]*model.Record)
+	for _, rec := range log {
+		if model.IsStateOp(rec.Operation) {
+			ops[rec.ID] = append(ops[rec.ID], rec)
+		}
+	}
+	ids := make([]string, 0, len(ops))
+	for id := range ops {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var conflicts []Conflict
+	for _, id := range ids {
+		conflicts = append(conflicts, recordConflicts(ops[id])...)
+	}
+	return conflicts
+}
+
+// recordConflicts returns the conflicts among the operations of one record.
+func recordConflicts(ops []*model.Record) []Conflict {
+	type found struct {
+		conflict Conflict
+		at       int // index of the overwriting operation
+	}
+	var all []found
+	bases := make([]int, len(ops))
+	current := ops[len(ops)-1]
+
+	for n, b := range ops {
+		bases[n] = n - 1
+		if n == 0 || b.Operation == model.OpCreate {
+			continue
+		}
+
+		base := findBase(ops, n)
+		bases[n] = base
+		if base == n-1 {
+			continue
+		}
+
+		changed := make(map[string]bool)
+		for _, f := range changedFields(ops[base].Fields, b.Fields) {
+			changed[f] = true
+		}
+		reported := make(map[string]bool)
+		// Latest unseen change first, so each field is reported once
+		for j := n - 1; j > base; j-- {
+			a := ops[j]
+			if a.UpdatedBy == b.UpdatedBy {
+				continue
+			}
+			for _, f := range changedFields(ops[j-1].Fields, a.Fields) {
+				if reported[f] || reflect.DeepEqual(a.Fields[f], b.Fields[f]) {
+					continue
+				}
+				reported[f] = true
+				kind := conflictReverted
+				if changed[f] {
+					kind = conflictBothChanged
+				}
+				all = append(all, found{at: n, conflict: Conflict{
+					ID:          b.ID,
+					Field:       f,
+					Kind:        kind,
+					BaseHash:    ops[base].Hash,
+					Base:        ops[base].Fields[f],
+					Overwritten: conflictChange(a, f),
+					By:          conflictChange(b, f),
+					Current:     current.Fields[f],
+				}})
+			}
+		}
+	}
+
+	// A later operation made from the overwriting one's state or after
+	// resolves the conflict
+	conflicts := make([]Conflict, 0, len(all))
+	for _, f := range all {
+		for m := f.at + 1; m < len(ops); m++ {
+			if ops[m].Operation != model.OpCreate && bases[m] >= f.at {
+				f.conflict.Resolved = true
+				break
+			}
+		}
+		conflicts = append(conflicts, f.conflict)
+	}
+	return conflicts
+}
+
+// findBase returns the index of the state the operation at n was made
+// from. Operations note the hash of that state; for older operations
+// without one, the base is the state the operation differs from least
+// among those that existed when it was made, taking the latest on a tie as
+// the operation has seen more.
+func findBase(ops []*model.Record, n int) int {
+	b := ops[n]
+	if b.BaseHash != "" {
+		for k := n - 1; k >= 0; k-- {
+			if ops[k].Hash == b.BaseHash {
+				return k
+			}
+			if ops[k].Operation == model.OpCreate {
+				break
+			}
+		}
+	}
+
+	base, fewest := n-1, -1
+	for k := n - 1; k >= 0; k-- {
+		if !ops[k].UpdatedAt.After(b.UpdatedAt) {
+			if d := len(changedFields(ops[k].Fields, b.Fields)); fewest < 0 || d < fewest {
+				base, fewest = k, d
+			}
+		}
+		if ops[k].Operation == model.OpCreate {
+			break
+		}
+	}
+	return base
+}
+
+// conflictChange describes an operation's side of a conflict on a field.
+func conflictChange(op *model.Record, field string) ConflictChange {
+	return ConflictChange{
+		Actor: op.UpdatedBy,
+		At:    op.UpdatedAt,
+		Op:    op.Operation,
+		Hash:  op.Hash,
+		Value: op.Fields[field],
+	}
+}
+
+// changedFields returns the names of the fields that differ between two
+// versions of a record's fields, sorted.
+func changedFields(was, now map[string]interface{}) []string {
+	var names []string
+	for name, to := range now {
+		if !reflect.DeepEqual(was[name], to) {
+			names = append(names, name)
+		}
+	}
+	for name := range was {
+		if _, ok := now[name]; !ok && was[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestConflicts(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status", "Priority"})
+	defer cleanup()
+	logPath := filepath.Join(tempDir, ".stash", "tasks", "records.jsonl")
+
+	id := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--set", "Status=open", "--set", "Priority=low", "--actor", "alice"))
+	captureSchemaOutput(t, "set", id, "Status=done", "Priority=high", "--actor", "alice")
+
+	// Bob changed the record on another clone, from the state alice created;
+	// a git merge appended his operation after hers
+	file, _ := os.Open(logPath)
+	log, err := storage.ReadRecords(file)
+	file.Close()
+	if err != nil || len(log) != 2 {
+		t.Fatalf("expected 2 operations, got %d (%v)", len(log), err)
+	}
+	base := log[0]
+	bob := *base
+	bob.Fields = map[string]interface{}{"Name": "Write docs", "Status": "blocked", "Priority": "low"}
+	bob.Operation = model.OpUpdate
+	bob.Hash = bob.CalculateHash()
+	bob.BaseHash = base.Hash
+	bob.UpdatedBy = "bob"
+	bob.UpdatedAt = log[1].UpdatedAt.Add(-time.Minute)
+	line, _ := json.Marshal(&bob)
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write(append(line, '\n'))
+	f.Close()
+
+	var output ConflictsOutput
+	out := captureSchemaOutput(t, "conflicts", "--json")
+	if err := json.Unmarshal([]byte(out), &output); err != nil {
+		t.Fatalf("failed to parse output %q: %v", out, err)
+	}
+	if ExitCode != 1 {
+		t.Errorf("expected exit code 1 with conflicts, got %d", ExitCode)
+	}
+	ExitCode = 0
+	if len(output.Conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", output.Conflicts)
+	}
+	priority, status := output.Conflicts[0], output.Conflicts[1]
+	if status.Field != "Status" || status.Kind != conflictBothChanged ||
+		status.Overwritten.Actor != "alice" || status.Overwritten.Value != "done" ||
+		status.By.Actor != "bob" || status.Current != "blocked" || status.Base != "open" {
+		t.Errorf("unexpected Status conflict: %+v", status)
+	}
+	if priority.Field != "Priority" || priority.Kind != conflictReverted || priority.Overwritten.Value != "high" || priority.Current != "low" {
+		t.Errorf("unexpected Priority conflict: %+v", priority)
+	}
+
+	// Resolving with a fresh write clears the conflicts
+	captureSchemaOutput(t, "sync", "--rebuild")
+	captureSchemaOutput(t, "set", id, "Status=done", "Priority=high", "--actor", "carol")
+	if out := captureSchemaOutput(t, "conflicts"); ExitCode != 0 || !strings.Contains(out, "No conflicts") {
+		t.Errorf("expected no unresolved conflicts, got exit %d: %s", ExitCode, out)
+	}
+	if out := captureSchemaOutput(t, "conflicts", id, "--all"); !strings.Contains(out, "resolved") {
+		t.Errorf("expected --all to list resolved conflicts, got: %s", out)
+	}
+	ExitCode = 0
+}
+
+func TestFindConflictsWithoutBaseHashes(t *testing.T) {
+	at := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	op := func(name, actor string, minute int, fields map[string]interface{}) *model.Record {
+		r := &model.Record{ID: "tk-aaaa", Operation: name, UpdatedBy: actor, UpdatedAt: at.Add(time.Duration(minute) * time.Minute), Fields: fields}
+		r.Hash = r.CalculateHash()
+		return r
+	}
+
+	t.Run("sequential changes do not conflict", func(t *testing.T) {
+		log := []*model.Record{
+			op(model.OpCreate, "alice", 0, map[string]interface{}{"Status": "open", "Owner": "x"}),
+			op(model.OpUpdate, "alice", 1, map[string]interface{}{"Status": "done", "Owner": "x"}),
+			op(model.OpUpdate, "bob", 2, map[string]interface{}{"Status": "done", "Owner": "y"}),
+		}
+		if conflicts := findConflicts(log); len(conflicts) != 0 {
+			t.Errorf("expected no conflicts, got %+v", conflicts)
+		}
+	})
+
+	t.Run("an operation older than the one before it did not see it", func(t *testing.T) {
+		log := []*model.Record{
+			op(model.OpCreate, "alice", 0, map[string]interface{}{"Status": "open"}),
+			op(model.OpUpdate, "alice", 5, map[string]interface{}{"Status": "done"}),
+			op(model.OpUpdate, "bob", 3, map[string]interface{}{"Status": "blocked"}),
+		}
+		conflicts := findConflicts(log)
+		if len(conflicts) != 1 || conflicts[0].Kind != conflictBothChanged || conflicts[0].Overwritten.Value != "done" {
+			t.Errorf("expected a both_changed conflict, got %+v", conflicts)
+		}
+	})
+}
@@ -14,9 +14,10 @@ import (
 )
 
 var (
-	countAll     bool
-	countDeleted bool
-	countWhere   []string
+	countAll      bool
+	countDeleted  bool
+	countWhere    []string
+	countArchived bool
 )
 
 var countCmd = &cobra.Command{
@@ -24,11 +25,14 @@ var countCmd = &cobra.Command{
 	Short: "Count records",
 	Long: `Count records in the current stash.
 
-By default, counts root-level non-deleted records. Use flags to filter:
+By default, counts root-level records that are not deleted or archived.
+Use flags to filter:
 
   --all              Count all records including children
   --deleted          Include soft-deleted records
+  --archived         Count only archived records
   --where CONDITION  Filter by field value (can be repeated)
+  --no-default-filter  Skip the stash's default filter (see 'stash filter')
 
 WHERE clause format:
   field=value        Equals
@@ -42,6 +46,7 @@ WHERE clause format:
   field IS NOT NULL  Field has a value
   field IS EMPTY     Field is null or empty string
   field IS NOT EMPTY Field has a non-empty value
+  field CONTAINS val List column has the element
 
 Examples:
   stash count
@@ -56,7 +61,9 @@ Examples:
 func init() {
 	countCmd.Flags().BoolVar(&countAll, "all", false, "Count all records including children")
 	countCmd.Flags().BoolVar(&countDeleted, "deleted", false, "Include soft-deleted records")
+	countCmd.Flags().BoolVar(&countArchived, "archived", false, "Count only archived records")
 	countCmd.Flags().StringArrayVar(&countWhere, "where", nil, "Filter by field value (can be repeated)")
+	countCmd.Flags().BoolVar(&noDefaultFilter, "no-default-filter", false, "Count records the stash's default filter hides")
 	rootCmd.AddCommand(countCmd)
 }
 
@@ -78,14 +85,14 @@ func runCount(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Verify stash exists
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -98,7 +105,7 @@ func runCount(cmd *cobra.Command, args []string) error {
 	// Parse WHERE clauses
 	var whereConditions []storage.WhereCondition
 	for _, clause := range countWhere {
-		cond, err := parseWhereClause(clause)
+		cond, err := storage.ParseWhereClause(clause)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			Exit(1)
@@ -106,11 +113,21 @@ func runCount(cmd *cobra.Command, args []string) error {
 		}
 		whereConditions = append(whereConditions, cond)
 	}
+	if !checkWhereColumns(stash, whereConditions) {
+		return nil
+	}
+	filter, ok := defaultFilter(stash)
+	if !ok {
+		return nil
+	}
+	whereConditions = append(filter, whereConditions...)
 
 	// Build list options
 	opts := storage.ListOptions{
-		IncludeDeleted: countDeleted,
-		Where:          whereConditions,
+		IncludeDeleted:  countDeleted,
+		ExcludeArchived: !countArchived,
+		ArchivedOnly:    countArchived,
+		Where:           whereConditions,
 	}
 
 	// Handle parent filtering
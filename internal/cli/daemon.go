@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
+	stashcontext "github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/daemon"
 )
 
@@ -20,8 +20,11 @@ const (
 )
 
 var (
-	logLines int
-	follow   bool
+	logLines           int
+	follow             bool
+	metricsAddr        string
+	changesAddr        string
+	changesAllowRemote bool
 )
 
 // daemonCmd represents the daemon command group.
@@ -31,7 +34,33 @@ var daemonCmd = &cobra.Command{
 	Long: `Manage the background sync daemon that watches for changes
 and keeps the SQLite cache synchronized with JSONL files.
 
-The daemon runs in the background and periodically syncs changes.`,
+The daemon runs in the background and periodically syncs changes.
+
+With --metrics-addr, the daemon also serves Prometheus metrics at
+http://<addr>/metrics, so the stashes can be monitored and alerted on:
+
+  stash_records{stash}                    Active records
+  stash_deleted_records{stash}            Soft-deleted records
+  stash_last_operation_timestamp_seconds  Time of the latest operation
+  stash_jsonl_bytes{stash}                Size of records.jsonl
+  stash_operations_total{stash,op}        Operations in records.jsonl
+  stash_lock_conflicts_total{stash}       Locks refused to another agent
+  stash_cache_rebuild_duration_seconds    Histogram of cache rebuilds
+  stash_cache_rebuild_failures_total      Cache rebuilds that failed
+  stash_daemon_start_time_seconds         When the daemon started
+
+With --changes-addr, the daemon serves each stash's changes, for agents
+waiting on each other's writes (see 'stash wait'):
+
+  GET /stashes/<name>/changes?since=SEQ&where=COND&timeout=SECONDS
+
+The changes feed returns whole records without authentication, so it is
+only served on a loopback address (127.0.0.1, ::1, localhost) unless
+--changes-allow-remote is given.
+
+Examples:
+  stash daemon start --metrics-addr 127.0.0.1:9464
+  stash daemon start --changes-addr 127.0.0.1:9465`,
 }
 
 // daemonStartCmd starts the daemon.
@@ -54,8 +83,10 @@ var daemonStopCmd = &cobra.Command{
 var daemonRestartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Restart the background daemon",
-	Long:  `Stop and start the background sync daemon.`,
-	RunE:  runDaemonRestart,
+	Long: `Stop and start the background sync daemon. The metrics endpoint
+and changes feed keep their addresses unless --metrics-addr or
+--changes-addr gives a new one.`,
+	RunE: runDaemonRestart,
 }
 
 // daemonStatusCmd shows daemon status.
@@ -94,37 +125,32 @@ func init() {
 	// Flags for logs command
 	daemonLogsCmd.Flags().IntVarP(&logLines, "lines", "n", DefaultLogLines, "Number of lines to show")
 	daemonLogsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow log output (not implemented)")
-}
 
-// getStashDir returns the .stash directory path.
-func getStashDir() string {
-	// Try to find .stash in current directory or parents
-	dir, err := os.Getwd()
-	if err != nil {
-		return DefaultStashDir
-	}
-
-	for {
-		stashDir := filepath.Join(dir, DefaultStashDir)
-		if _, err := os.Stat(stashDir); err == nil {
-			return stashDir
-		}
-
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
-		}
-		dir = parent
+	// Flags for the metrics endpoint
+	for _, cmd := range []*cobra.Command{daemonStartCmd, daemonRestartCmd, daemonRunCmd} {
+		cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address (e.g., 127.0.0.1:9464)")
+		cmd.Flags().StringVar(&changesAddr, "changes-addr", "", "Serve the changes feed on this address (e.g., 127.0.0.1:9465)")
+		cmd.Flags().BoolVar(&changesAllowRemote, "changes-allow-remote", false, "Allow --changes-addr to be a non-loopback address")
 	}
+}
 
-	// Default to current directory
-	return DefaultStashDir
+// getStashDir returns the .stash directory path: the nearest .stash in
+// the current directory or its parents, or the one in --dir.
+func getStashDir() string {
+	return stashcontext.TargetStashDir()
 }
 
 // runDaemonStart handles the daemon start command.
 func runDaemonStart(cmd *cobra.Command, args []string) error {
 	stashDir := getStashDir()
 	d := daemon.New(stashDir)
+	d.SetMetricsAddr(metricsAddr)
+	if changesAddr != "" {
+		if err := daemon.CheckChangesAddr(changesAddr, changesAllowRemote); err != nil {
+			return err
+		}
+	}
+	d.SetChangesAddr(changesAddr, changesAllowRemote)
 
 	// Check if already running
 	running, pid := d.IsRunning()
@@ -215,6 +241,25 @@ func runDaemonRestart(cmd *cobra.Command, args []string) error {
 	d := daemon.New(stashDir)
 
 	oldRunning, oldPID := d.IsRunning()
+	addr := metricsAddr
+	changes, remote := changesAddr, changesAllowRemote
+	if oldRunning {
+		if status, err := d.GetStatus(); err == nil {
+			if addr == "" {
+				addr = status.MetricsAddr
+			}
+			if changes == "" {
+				changes, remote = status.ChangesAddr, status.ChangesRemote
+			}
+		}
+	}
+	if changes != "" {
+		if err := daemon.CheckChangesAddr(changes, remote); err != nil {
+			return err
+		}
+	}
+	d.SetMetricsAddr(addr)
+	d.SetChangesAddr(changes, remote)
 
 	if err := d.Restart(); err != nil {
 		return fmt.Errorf("restarting daemon: %w", err)
@@ -288,6 +333,14 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Memory: %.1f MB\n", status.MemoryMB)
 	}
 
+	if status.MetricsAddr != "" {
+		fmt.Printf("  Metrics: http://%s%s\n", status.MetricsAddr, daemon.MetricsPath)
+	}
+
+	if status.ChangesAddr != "" {
+		fmt.Printf("  Changes: http://%s/stashes/<name>/changes\n", status.ChangesAddr)
+	}
+
 	return nil
 }
 
@@ -342,6 +395,8 @@ func runDaemonLogs(cmd *cobra.Command, args []string) error {
 func runDaemonRun(cmd *cobra.Command, args []string) error {
 	stashDir := getStashDir()
 	proc := daemon.NewProcess(stashDir)
+	proc.SetMetricsAddr(metricsAddr)
+	proc.SetChangesAddr(changesAddr, changesAllowRemote)
 
 	ctx := context.Background()
 	return proc.Run(ctx)
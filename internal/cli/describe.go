@@ -0,0 +1,275 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var describeExamples int
+
+// StashDescription is a machine-readable description of a stash, meant to
+// be given to an agent so it knows how to use the stash.
+type StashDescription struct {
+	Stash       string            `json:"stash"`
+	Prefix      string            `json:"prefix"`
+	IDFormat    string            `json:"id_format"`
+	RecordCount int               `json:"record_count"`
+	Columns     []DescribedColumn `json:"columns"`
+	Examples    []*model.Record   `json:"examples"`
+	Commands    map[string]string `json:"commands"`
+}
+
+// DescribedColumn is a column as reported by 'stash describe'.
+type DescribedColumn struct {
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Description string              `json:"description,omitempty"`
+	Required    bool                `json:"required"`
+	Enum        []string            `json:"enum,omitempty"`
+	Coerce      bool                `json:"coerce,omitempty"`
+	Transitions map[string][]string `json:"transitions,omitempty"`
+	Computed    string              `json:"computed,omitempty"`
+	Due         bool                `json:"due,omitempty"`
+	List        bool                `json:"list,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Warning     bool                `json:"warning_only,omitempty"`
+}
+
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe a stash for agent prompting",
+	Long: `Describe the current stash: its prefix, record count, columns with their
+types, validation, enums, required flags, and descriptions, plus a few
+example records.
+
+The output is meant to be injected into an LLM prompt so an agent knows
+which fields exist and which values they accept before it writes. Use
+--json for a machine-readable description; the default output is
+Markdown.
+
+Column types:
+  text, number, date, email, url   Values are validated as that type
+  enum                             Values must be one of the enum values
+                                   ("coerce": true accepts them in any case)
+  computed                         Derived from an expression; read-only
+List columns ("list": true) hold arrays whose elements have the type.
+
+Options:
+  --examples N   Number of example records, most recently updated first
+                 (default 3, 0 for none)
+
+Examples:
+  stash describe
+  stash describe --stash tasks --json
+  stash describe --examples 0
+
+AI Agent Examples:
+  # Add the stash description to an agent's system prompt
+  stash describe --stash tasks --json > tasks.context.json
+
+  # List the allowed values of an enum column
+  stash describe --json | jq -r '.columns[] | select(.name == "Status") | .enum[]'
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Invalid --examples
+
+JSON Output (--json):
+  {"stash": "tasks", "prefix": "tk-", "id_format": "tk-xxxx (children: tk-xxxx.1)",
+   "record_count": 42,
+   "columns": [{"name": "Title", "type": "text", "description": "Task title",
+                "required": true},
+               {"name": "Status", "type": "enum", "required": false,
+                "enum": ["open", "done"]}],
+   "examples": [{"_id": "tk-ab12", "Title": "Write docs", "Status": "open", ...}],
+   "commands": {"add": "stash add <Title> --set Column=value", ...}}`,
+	Args: cobra.NoArgs,
+	RunE: runDescribe,
+}
+
+func init() {
+	describeCmd.Flags().IntVar(&describeExamples, "examples", 3, "Number of example records (0 for none)")
+	rootCmd.AddCommand(describeCmd)
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	if describeExamples < 0 {
+		ExitValidationError("--examples must not be negative", map[string]interface{}{"examples": describeExamples})
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	desc, err := describeStash(store, stash, describeExamples)
+	if err != nil {
+		return err
+	}
+
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(desc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printDescription(desc)
+	return nil
+}
+
+// describeStash builds the description of a stash with up to examples
+// example records.
+func describeStash(store *storage.Store, stash *model.Stash, examples int) (*StashDescription, error) {
+	count, err := store.CountRecords(stash.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count records: %w", err)
+	}
+
+	desc := &StashDescription{
+		Stash:       stash.Name,
+		Prefix:      stash.Prefix,
+		IDFormat:    fmt.Sprintf("%sxxxx (children: %sxxxx.1)", stash.Prefix, stash.Prefix),
+		RecordCount: count,
+		Columns:     make([]DescribedColumn, len(stash.Columns)),
+		Examples:    []*model.Record{},
+	}
+	for i, col := range stash.Columns {
+		desc.Columns[i] = describeColumn(col)
+	}
+
+	if examples > 0 {
+		records, err := store.ListRecords(stash.Name, storage.ListOptions{
+			ParentID:   "*",
+			Descending: true,
+			Limit:      examples,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list records: %w", err)
+		}
+		desc.Examples = records
+	}
+
+	first := "<value>"
+	if len(stash.Columns) > 0 {
+		first = "<" + stash.Columns[0].Name + ">"
+	}
+	desc.Commands = map[string]string{
+		"add":   fmt.Sprintf("stash add %s --stash %s --set Column=value", first, stash.Name),
+		"child": fmt.Sprintf("stash add %s --stash %s --parent <id>", first, stash.Name),
+		"set":   fmt.Sprintf("stash set <id> Column=value --stash %s", stash.Name),
+		"list":  fmt.Sprintf("stash list --stash %s --where \"Column=value\" --json", stash.Name),
+		"show":  fmt.Sprintf("stash show <id> --stash %s --json", stash.Name),
+	}
+
+	return desc, nil
+}
+
+// describeColumn reports a column's type and constraints.
+func describeColumn(col model.Column) DescribedColumn {
+	colType := "text"
+	switch {
+	case col.IsComputed():
+		colType = "computed"
+	case len(col.Enum) > 0:
+		colType = "enum"
+	case col.Validate != "":
+		colType = col.Validate
+	case col.Due:
+		colType = string(ValidationDate)
+	}
+
+	return DescribedColumn{
+		Name:        col.Name,
+		Type:        colType,
+		Description: col.Desc,
+		Required:    col.Required,
+		Enum:        col.Enum,
+		Coerce:      col.Coerce,
+		Transitions: col.Transitions,
+		Computed:    col.Computed,
+		Due:         col.Due,
+		List:        col.List,
+		Unit:        col.Unit,
+		Warning:     col.ViolationSeverity() == model.SeverityWarning,
+	}
+}
+
+// printDescription prints a description as Markdown.
+func printDescription(desc *StashDescription) {
+	fmt.Printf("# Stash: %s\n\n", desc.Stash)
+	fmt.Printf("Prefix: %s (IDs look like %s)\n", desc.Prefix, desc.IDFormat)
+	fmt.Printf("Records: %d\n\n", desc.RecordCount)
+
+	fmt.Println("## Columns")
+	fmt.Println()
+	if len(desc.Columns) == 0 {
+		fmt.Println("No columns defined.")
+	}
+	for _, col := range desc.Columns {
+		attrs := []string{col.Type}
+		if col.List {
+			attrs = append(attrs, "list")
+		}
+		if col.Unit != "" {
+			attrs = append(attrs, "in "+col.Unit)
+		}
+		if col.Required {
+			attrs = append(attrs, "required")
+		}
+		if col.Due {
+			attrs = append(attrs, "due")
+		}
+		if col.Warning {
+			attrs = append(attrs, "warnings only")
+		}
+		line := fmt.Sprintf("- %s (%s)", col.Name, strings.Join(attrs, ", "))
+		if col.Description != "" {
+			line += ": " + col.Description
+		}
+		fmt.Println(line)
+		if len(col.Enum) > 0 {
+			allowed := strings.Join(col.Enum, ", ")
+			if col.Coerce {
+				allowed += " (any case)"
+			}
+			fmt.Printf("  Allowed values: %s\n", allowed)
+		}
+		for _, from := range col.Enum {
+			if next, ok := col.Transitions[from]; ok {
+				fmt.Printf("  %s -> %s\n", from, strings.Join(next, ", "))
+			}
+		}
+		if col.Computed != "" {
+			fmt.Printf("  Computed: %s\n", col.Computed)
+		}
+	}
+
+	if len(desc.Examples) > 0 {
+		fmt.Println()
+		fmt.Println("## Example records")
+		fmt.Println()
+		for _, rec := range desc.Examples {
+			data, _ := json.Marshal(rec)
+			fmt.Println(string(data))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("## Commands")
+	fmt.Println()
+	for _, name := range []string{"add", "child", "set", "list", "show"} {
+		fmt.Printf("- %s: %s\n", name, desc.Commands[name])
+	}
+}
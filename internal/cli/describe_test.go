@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Run("describes columns and examples", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Title"})
+		defer cleanup()
+
+		for _, args := range [][]string{
+			{"column", "add", "Status", "--enum", "open,done", "--required"},
+			{"column", "add", "Price", "--validate", "number"},
+			{"column", "describe", "Title", "Task title"},
+			{"add", "First", "--set", "Status=open"},
+			{"add", "Second", "--set", "Status=done"},
+		} {
+			rootCmd.SetArgs(args)
+			rootCmd.Execute()
+			resetFlags()
+		}
+
+		ExitCode = 0
+		out := captureSchemaOutput(t, "describe", "--examples", "1", "--json")
+
+		var desc StashDescription
+		if err := json.Unmarshal([]byte(out), &desc); err != nil {
+			t.Fatalf("expected JSON: %v\n%s", err, out)
+		}
+		if desc.Stash != "tasks" || desc.Prefix != "tk-" || desc.RecordCount != 2 {
+			t.Errorf("unexpected stash details: %+v", desc)
+		}
+		if len(desc.Columns) != 3 {
+			t.Fatalf("expected 3 columns, got %+v", desc.Columns)
+		}
+		if c := desc.Columns[0]; c.Name != "Title" || c.Type != "text" || c.Description != "Task title" {
+			t.Errorf("unexpected Title column: %+v", c)
+		}
+		if c := desc.Columns[1]; c.Type != "enum" || !c.Required || strings.Join(c.Enum, ",") != "open,done" {
+			t.Errorf("unexpected Status column: %+v", c)
+		}
+		if c := desc.Columns[2]; c.Type != "number" || c.Required {
+			t.Errorf("unexpected Price column: %+v", c)
+		}
+		if len(desc.Examples) != 1 || desc.Examples[0].Fields["Title"] == nil {
+			t.Errorf("expected one example record, got %+v", desc.Examples)
+		}
+		if !strings.Contains(desc.Commands["add"], "stash add <Title> --stash tasks") {
+			t.Errorf("unexpected add command: %q", desc.Commands["add"])
+		}
+	})
+
+	t.Run("prints Markdown", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Title"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "add", "Status", "--enum", "open,done"})
+		rootCmd.Execute()
+		resetFlags()
+
+		out := captureSchemaOutput(t, "describe", "--examples", "0")
+		for _, want := range []string{"# Stash: tasks", "- Title (text)", "- Status (enum)", "Allowed values: open, done"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in output:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "## Example records") {
+			t.Errorf("expected no examples with --examples 0:\n%s", out)
+		}
+	})
+
+	t.Run("rejects negative examples", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Title"})
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "describe", "--examples", "-1")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var detachCmd = &cobra.Command{
@@ -52,14 +51,14 @@ func runDetach(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Verify stash exists
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -69,6 +68,10 @@ func runDetach(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermAttach, nil) {
+		return nil
+	}
+
 	// Detach the file
 	err = store.DetachFile(ctx.Stash, recordID, filename)
 	if err != nil {
@@ -0,0 +1,156 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	distinctWhere    []string
+	distinctArchived bool
+	distinctLimit    int
+)
+
+var distinctCmd = &cobra.Command{
+	Use:   "distinct <column>",
+	Short: "List the distinct values of a column with counts",
+	Long: `List each distinct value of a column and how many records hold it,
+most common first.
+
+Counts cover every record, including children, that is not deleted or
+archived. Records where the column is unset are counted as (unset); an
+empty string is counted separately as (empty). System columns such as
+_created_by can be used as well as stash columns.
+
+Useful before adding an enum constraint, or when cleaning up values that
+differ only in spelling.
+
+Options:
+  --where CONDITION  Only count records matching a condition (can be repeated;
+                     same format as 'stash list --where')
+  --archived         Count archived records instead
+  --limit N          Show only the N most common values
+  --no-default-filter  Skip the stash's default filter (see 'stash filter')
+
+Examples:
+  stash distinct Category
+  stash distinct Status --where "Priority=high"
+  stash distinct _created_by
+  stash distinct Category --limit 5 --json
+
+AI Agent Examples:
+  # Propose an enum constraint from the values already in use
+  stash distinct Status --json | jq -r '[.[].value | select(. != null)] | join(",")'
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Invalid --where condition
+
+JSON Output (--json):
+  [{"value": "electronics", "count": 12}, {"value": null, "count": 3}]`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDistinct,
+}
+
+func init() {
+	distinctCmd.Flags().StringArrayVar(&distinctWhere, "where", nil, "Filter by field value (can be repeated)")
+	distinctCmd.Flags().BoolVar(&noDefaultFilter, "no-default-filter", false, "Count records the stash's default filter hides")
+	distinctCmd.Flags().BoolVar(&distinctArchived, "archived", false, "Count archived records instead")
+	distinctCmd.Flags().IntVar(&distinctLimit, "limit", 0, "Show only the N most common values (0 = all)")
+	rootCmd.AddCommand(distinctCmd)
+}
+
+func runDistinct(cmd *cobra.Command, args []string) error {
+	column := args[0]
+
+	// Parse WHERE clauses before touching the store
+	var whereConditions []storage.WhereCondition
+	for _, clause := range distinctWhere {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"where": clause})
+			return nil
+		}
+		whereConditions = append(whereConditions, cond)
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !storage.IsBaseColumn(column) && resolveColumn(stash, column) == nil {
+		return nil
+	}
+	if !checkWhereColumns(stash, whereConditions) {
+		return nil
+	}
+	filter, ok := defaultFilter(stash)
+	if !ok {
+		return nil
+	}
+	whereConditions = append(filter, whereConditions...)
+
+	values, err := store.DistinctValues(stash.Name, column, storage.ListOptions{
+		ParentID:        "*",
+		ExcludeArchived: !distinctArchived,
+		ArchivedOnly:    distinctArchived,
+		Where:           whereConditions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list distinct values: %w", err)
+	}
+	if distinctLimit > 0 && len(values) > distinctLimit {
+		values = values[:distinctLimit]
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(values) == 0 {
+		if !IsQuiet() {
+			fmt.Fprintln(os.Stderr, "No records found.")
+		}
+		return nil
+	}
+
+	width := len("Value")
+	for _, v := range values {
+		width = max(width, len(distinctLabel(v.Value)))
+	}
+	width = min(width, 40)
+
+	fmt.Printf("%-*s  %s\n", width, "Value", "Count")
+	fmt.Printf("%s  %s\n", strings.Repeat("-", width), strings.Repeat("-", 5))
+	for _, v := range values {
+		fmt.Printf("%-*s  %d\n", width, truncate(distinctLabel(v.Value), width), v.Count)
+	}
+	return nil
+}
+
+// distinctLabel formats a distinct value for display.
+func distinctLabel(value interface{}) string {
+	switch value {
+	case nil:
+		return "(unset)"
+	case "":
+		return "(empty)"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestDistinct(t *testing.T) {
+	setup := func(t *testing.T) func() {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Category", "Price"})
+		for _, item := range [][]string{
+			{"Laptop", "--set", "Category=electronics", "--set", "Price=999"},
+			{"Phone", "--set", "Category=electronics", "--set", "Price=500"},
+			{"Desk", "--set", "Category=furniture", "--set", "Price=200"},
+			{"Pen"},
+		} {
+			captureSchemaOutput(t, append([]string{"add"}, item...)...)
+		}
+		ExitCode = 0
+		return cleanup
+	}
+
+	distinct := func(t *testing.T, args ...string) []storage.ValueCount {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"distinct", "--json"}, args...)...)
+		var values []storage.ValueCount
+		if err := json.Unmarshal([]byte(output), &values); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return values
+	}
+
+	t.Run("counts values most common first", func(t *testing.T) {
+		defer setup(t)()
+		values := distinct(t, "category")
+		if len(values) != 3 {
+			t.Fatalf("expected 3 distinct values, got %v", values)
+		}
+		if values[0].Value != "electronics" || values[0].Count != 2 {
+			t.Errorf("expected electronics x2 first, got %v", values[0])
+		}
+		unset := false
+		for _, v := range values {
+			if v.Value == nil && v.Count == 1 {
+				unset = true
+			}
+		}
+		if !unset {
+			t.Errorf("expected one record with no category, got %v", values)
+		}
+	})
+
+	t.Run("where and limit", func(t *testing.T) {
+		defer setup(t)()
+		values := distinct(t, "Category", "--where", "Price>300")
+		if len(values) != 1 || values[0].Value != "electronics" || values[0].Count != 2 {
+			t.Errorf("expected only electronics x2, got %v", values)
+		}
+		if values := distinct(t, "Category", "--limit", "1"); len(values) != 1 {
+			t.Errorf("expected 1 value with --limit 1, got %v", values)
+		}
+	})
+
+	t.Run("system columns", func(t *testing.T) {
+		defer setup(t)()
+		values := distinct(t, "_created_by")
+		if len(values) != 1 || values[0].Count != 4 {
+			t.Errorf("expected one actor with 4 records, got %v", values)
+		}
+	})
+
+	t.Run("table output", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "distinct", "Category")
+		if !strings.Contains(output, "electronics") || !strings.Contains(output, "(unset)") {
+			t.Errorf("expected values in table output, got %q", output)
+		}
+	})
+
+	t.Run("unknown column suggests a name", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "distinct", "Categry", "--json")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		if !strings.Contains(output, "did you mean 'Category'?") {
+			t.Errorf("expected suggestion, got %q", output)
+		}
+	})
+}
@@ -3,13 +3,16 @@ package cli
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/daemon"
 	"github.com/user/stash/internal/model"
 	"github.com/user/stash/internal/storage"
 )
@@ -20,13 +23,24 @@ var doctorCmd = &cobra.Command{
 	Long: `Check stash health and report any issues found.
 
 The doctor command performs various health checks on your stash:
-  - JSONL file integrity (valid JSON lines)
+  - JSONL file integrity (valid JSON lines); lines sealed by the last
+    compaction are verified by their checksum instead of being parsed
+  - Torn writes: incomplete last lines of records.jsonl, left by a crash
+    mid-write, that were moved aside to records.jsonl.torn-* files
   - SQLite cache consistency
+  - SQLite cache schema version (fix runs pending migrations, or rebuilds
+    a cache left by a newer version of stash)
   - Orphaned files in files/ directory
   - Missing files referenced by records
   - Config.json validity
   - Duplicate record IDs
+  - Orphaned records whose parent no longer exists (fix with 'stash adopt')
+  - Deleted records awaiting purge (retention policy)
+  - Log bloat: more than 50 operations per record, or a records.jsonl
+    over 100 MB, with the space compaction would reclaim (fix compacts
+    the log, as 'stash sync --flush' does, dropping the history)
   - Hash verification (with --deep)
+  - Operation log hash chain, for stashes in hash chain mode (with --deep)
 
 Flags:
   --fix       Attempt to fix issues (requires confirmation)
@@ -107,7 +121,7 @@ func runHealthChecks(cmd *cobra.Command, ctx *context.Context) []CheckResult {
 	var results []CheckResult
 
 	// Open store for checks
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		results = append(results, CheckResult{
 			Check:   "store_open",
@@ -156,24 +170,45 @@ func runHealthChecks(cmd *cobra.Command, ctx *context.Context) []CheckResult {
 		// Check JSONL integrity
 		results = append(results, checkJSONLIntegrity(ctx, stash.Name))
 
+		// Check for torn writes moved out of the JSONL log
+		results = append(results, checkTornWrites(store, stash.Name))
+
 		// Check for duplicate record IDs
 		results = append(results, checkDuplicateIDs(ctx, stash.Name))
 
 		// Check JSONL/SQLite consistency
 		results = append(results, checkCacheConsistency(ctx, store, stash.Name))
 
+		// Check the cache layout is at the current schema version
+		results = append(results, checkCacheSchema(store, stash.Name))
+
 		// Check for orphaned files
 		results = append(results, checkOrphanedFiles(ctx, stash.Name))
 
 		// Check for missing files
 		results = append(results, checkMissingFiles(ctx, store, stash.Name))
 
+		// Check for records whose parent no longer exists
+		results = append(results, checkOrphans(store, stash.Name))
+
+		// Check for records over the stash's size limits
+		results = append(results, checkRecordSizes(store, stash))
+
 		// Check column descriptions (warning if missing)
 		results = append(results, checkColumnDescriptions(stash))
 
+		// Check for accumulated purgeable records
+		results = append(results, checkRetention(store, stash))
+
+		// Check the log has not outgrown the records it holds
+		results = append(results, checkLogBloat(ctx, stash.Name))
+
 		// Deep check: hash verification
 		if doctorDeep {
 			results = append(results, checkRecordHashes(ctx, store, stash.Name))
+			if stash.HashChain {
+				results = append(results, checkHashChain(store, stash))
+			}
 		}
 	}
 
@@ -181,52 +216,38 @@ func runHealthChecks(cmd *cobra.Command, ctx *context.Context) []CheckResult {
 }
 
 func checkDaemonStatus(ctx *context.Context) CheckResult {
-	pidFile := filepath.Join(ctx.StashDir, "daemon.pid")
-	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		return CheckResult{
-			Check:   "daemon_status",
-			Status:  "warning",
-			Message: "Daemon not running",
-			Details: "PID file not found",
-		}
-	}
-
-	// Read PID and check if process is running
-	data, err := os.ReadFile(pidFile)
+	pid, err := daemon.ReadPID(filepath.Join(ctx.StashDir, daemon.DefaultPIDFile))
 	if err != nil {
-		return CheckResult{
+		result := CheckResult{
 			Check:   "daemon_status",
 			Status:  "warning",
 			Message: "Could not read daemon PID file",
 			Details: err.Error(),
 		}
-	}
-
-	// Try to verify process exists (basic check)
-	pid := strings.TrimSpace(string(data))
-	if pid == "" {
-		return CheckResult{
-			Check:   "daemon_status",
-			Status:  "warning",
-			Message: "Daemon PID file is empty",
+		if errors.Is(err, daemon.ErrPIDFileNotFound) {
+			result.Message = "Daemon not running"
+			result.Details = "PID file not found"
+		} else if errors.Is(err, daemon.ErrInvalidPID) {
+			result.Message = "Daemon PID file is empty or invalid"
+			result.Details = ""
 		}
+		return result
 	}
 
-	// Check if process exists via /proc on Linux
-	procPath := filepath.Join("/proc", pid)
-	if _, err := os.Stat(procPath); os.IsNotExist(err) {
+	// Check the process exists (portable across Linux, macOS, and Windows)
+	if !daemon.IsProcessRunning(pid) {
 		return CheckResult{
 			Check:   "daemon_status",
 			Status:  "warning",
 			Message: "Daemon process not found",
-			Details: fmt.Sprintf("PID %s does not exist", pid),
+			Details: fmt.Sprintf("PID %d does not exist", pid),
 		}
 	}
 
 	return CheckResult{
 		Check:   "daemon_status",
 		Status:  "ok",
-		Message: fmt.Sprintf("Daemon running (PID %s)", pid),
+		Message: fmt.Sprintf("Daemon running (PID %d)", pid),
 	}
 }
 
@@ -282,8 +303,7 @@ func checkConfig(ctx *context.Context, stashName string) CheckResult {
 func checkJSONLIntegrity(ctx *context.Context, stashName string) CheckResult {
 	jsonlPath := filepath.Join(ctx.StashDir, stashName, "records.jsonl")
 
-	file, err := os.Open(jsonlPath)
-	if err != nil {
+	if _, err := os.Stat(jsonlPath); err != nil {
 		if os.IsNotExist(err) {
 			return CheckResult{
 				Check:   fmt.Sprintf("%s/jsonl", stashName),
@@ -298,33 +318,42 @@ func checkJSONLIntegrity(ctx *context.Context, stashName string) CheckResult {
 			Details: err.Error(),
 		}
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-	validLines := 0
-	var parseErrors []string
+	// Lines in the sealed segment written by the last compaction are
+	// checked by its checksum; only the lines after it are parsed
+	jsonl := storage.NewJSONLStore(ctx.StashDir)
+	var skip int64
+	sealed, note := 0, ""
+	report, err := jsonl.VerifySegment(stashName)
+	if err != nil {
+		note = fmt.Sprintf("; cannot check sealed segment: %v", err)
+	} else if report.Valid {
+		skip, sealed = report.Segment.Bytes, report.Segment.Records
+		note = fmt.Sprintf("; %d in sealed segment verified by checksum", sealed)
+	} else if report.Sealed {
+		note = fmt.Sprintf("; checked in full, as %s since it was sealed", report.Problem)
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Bytes()
+	validLines := sealed
+	var parseErrors []string
+	err = jsonl.ReadTail(stashName, skip, sealed+1, func(lineNum int, line []byte) error {
 		if len(line) == 0 {
-			continue // Skip empty lines
+			return nil // Skip empty lines
 		}
-
 		var record model.Record
 		if err := json.Unmarshal(line, &record); err != nil {
 			parseErrors = append(parseErrors, fmt.Sprintf("line %d: %v", lineNum, err))
 			if len(parseErrors) >= 5 {
 				parseErrors = append(parseErrors, "... (more errors)")
-				break
+				return errStopScan
 			}
-			continue
+			return nil
 		}
 		validLines++
-	}
+		return nil
+	})
 
-	if err := scanner.Err(); err != nil {
+	if err != nil && !errors.Is(err, errStopScan) {
 		return CheckResult{
 			Check:   fmt.Sprintf("%s/jsonl", stashName),
 			Status:  "error",
@@ -345,7 +374,41 @@ func checkJSONLIntegrity(ctx *context.Context, stashName string) CheckResult {
 	return CheckResult{
 		Check:   fmt.Sprintf("%s/jsonl", stashName),
 		Status:  "ok",
-		Message: fmt.Sprintf("JSONL valid (%d records)", validLines),
+		Message: fmt.Sprintf("JSONL valid (%d records%s)", validLines, note),
+	}
+}
+
+// errStopScan ends a scan of records.jsonl early.
+var errStopScan = errors.New("stop scan")
+
+func checkTornWrites(store *storage.Store, stashName string) CheckResult {
+	quarantined, err := store.QuarantinedWrites(stashName)
+	if err != nil {
+		return CheckResult{
+			Check:   fmt.Sprintf("%s/torn_writes", stashName),
+			Status:  "error",
+			Message: "Cannot list torn writes",
+			Details: err.Error(),
+		}
+	}
+
+	if len(quarantined) > 0 {
+		names := make([]string, len(quarantined))
+		for i, path := range quarantined {
+			names[i] = filepath.Base(path)
+		}
+		return CheckResult{
+			Check:   fmt.Sprintf("%s/torn_writes", stashName),
+			Status:  "warning",
+			Message: fmt.Sprintf("%d interrupted write(s) moved out of records.jsonl; redo the lost operations, then delete the files", len(quarantined)),
+			Details: strings.Join(names, ", "),
+		}
+	}
+
+	return CheckResult{
+		Check:   fmt.Sprintf("%s/torn_writes", stashName),
+		Status:  "ok",
+		Message: "No torn writes",
 	}
 }
 
@@ -423,10 +486,10 @@ func checkCacheConsistency(ctx *context.Context, store *storage.Store, stashName
 	// Build current state from JSONL operations
 	state := make(map[string]*model.Record)
 	for _, record := range records {
-		switch record.Operation {
-		case model.OpCreate, model.OpUpdate, model.OpRestore:
+		switch {
+		case model.IsStateOp(record.Operation):
 			state[record.ID] = record
-		case model.OpDelete:
+		case record.Operation == model.OpDelete:
 			if existing, ok := state[record.ID]; ok {
 				existing.DeletedAt = record.DeletedAt
 				existing.DeletedBy = record.DeletedBy
@@ -469,6 +532,61 @@ func checkCacheConsistency(ctx *context.Context, store *storage.Store, stashName
 	}
 }
 
+func checkCacheSchema(store *storage.Store, stashName string) CheckResult {
+	check := fmt.Sprintf("%s/cache_schema", stashName)
+
+	version, err := store.CacheSchemaVersion(stashName)
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: "Cannot read cache schema version",
+			Details: err.Error(),
+		}
+	}
+
+	if version > storage.CacheSchemaVersion {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: fmt.Sprintf("Cache schema version %d is newer than this version of stash supports (%d)", version, storage.CacheSchemaVersion),
+			Details: "Upgrade stash, or run 'stash doctor --fix' to rebuild the cache",
+		}
+	}
+
+	pending, err := store.PendingCacheMigrations(stashName)
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: "Cannot read pending cache migrations",
+			Details: err.Error(),
+		}
+	}
+	if len(pending) > 0 {
+		descs := make([]string, len(pending))
+		for i, m := range pending {
+			descs[i] = fmt.Sprintf("%d: %s", m.Version, m.Description)
+		}
+		details := "Pending: " + strings.Join(descs, "; ")
+		if err := store.CacheMigrationError(stashName); err != nil {
+			details += fmt.Sprintf(" (%v)", err)
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: fmt.Sprintf("Cache schema version %d, %d migration(s) pending", version, len(pending)),
+			Details: details,
+		}
+	}
+
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("Cache schema up to date (version %d)", version),
+	}
+}
+
 func checkOrphanedFiles(ctx *context.Context, stashName string) CheckResult {
 	filesDir := filepath.Join(ctx.StashDir, stashName, "files")
 
@@ -558,10 +676,10 @@ func checkMissingFiles(ctx *context.Context, store *storage.Store, stashName str
 	// Build current state
 	state := make(map[string]*model.Record)
 	for _, record := range records {
-		switch record.Operation {
-		case model.OpCreate, model.OpUpdate, model.OpRestore:
+		switch {
+		case model.IsStateOp(record.Operation):
 			state[record.ID] = record
-		case model.OpDelete:
+		case record.Operation == model.OpDelete:
 			delete(state, record.ID)
 		}
 	}
@@ -599,6 +717,73 @@ func checkMissingFiles(ctx *context.Context, store *storage.Store, stashName str
 	}
 }
 
+func checkOrphans(store *storage.Store, stashName string) CheckResult {
+	orphans, err := store.FindOrphans(stashName)
+	if err != nil {
+		return CheckResult{
+			Check:   fmt.Sprintf("%s/orphans", stashName),
+			Status:  "error",
+			Message: "Cannot list records",
+			Details: err.Error(),
+		}
+	}
+
+	if len(orphans) > 0 {
+		return CheckResult{
+			Check:   fmt.Sprintf("%s/orphans", stashName),
+			Status:  "warning",
+			Message: fmt.Sprintf("%d orphaned record(s); re-parent with 'stash adopt <id> --parent <id>' or '--to-root'", len(orphans)),
+			Details: strings.Join(getRecordIDs(orphans), ", "),
+		}
+	}
+
+	return CheckResult{
+		Check:   fmt.Sprintf("%s/orphans", stashName),
+		Status:  "ok",
+		Message: "No orphaned records",
+	}
+}
+
+func checkRecordSizes(store *storage.Store, stash *model.Stash) CheckResult {
+	check := fmt.Sprintf("%s/record_sizes", stash.Name)
+
+	var oversized []string
+	err := store.IterateRecords(stash.Name, storage.ListOptions{ParentID: "*"}, func(rec *model.Record) error {
+		var sizeErr *model.SizeLimitError
+		if errors.As(stash.CheckSize(rec, nil), &sizeErr) {
+			what := "fields total"
+			if sizeErr.Field != "" {
+				what = sizeErr.Field
+			}
+			oversized = append(oversized, fmt.Sprintf("%s (%s: %s)", rec.ID, what, model.FormatSize(sizeErr.Size)))
+		}
+		return nil
+	})
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot list records",
+			Details: err.Error(),
+		}
+	}
+
+	if len(oversized) > 0 {
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: fmt.Sprintf("%d record(s) over the size limits; move large values into attachments with 'stash attach' (see 'stash limits')", len(oversized)),
+			Details: strings.Join(oversized, ", "),
+		}
+	}
+
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: "No records over the size limits",
+	}
+}
+
 func checkColumnDescriptions(stash *model.Stash) CheckResult {
 	var missing []string
 	for _, col := range stash.Columns {
@@ -631,6 +816,129 @@ func checkColumnDescriptions(stash *model.Stash) CheckResult {
 	}
 }
 
+// purgeableWarnThreshold is the number of purgeable deleted records at which
+// doctor warns.
+const purgeableWarnThreshold = 50
+
+func checkRetention(store *storage.Store, stash *model.Stash) CheckResult {
+	check := fmt.Sprintf("%s/retention", stash.Name)
+
+	// With a policy, expired records are purgeable; without one, all deleted records are
+	var purgeable []*model.Record
+	var err error
+	if _, ok := stash.RetentionPeriod(); ok {
+		purgeable, err = store.ListExpiredRecords(stash.Name, time.Now())
+	} else {
+		purgeable, err = store.ListDeletedRecords(stash.Name, nil)
+	}
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot list deleted records",
+			Details: err.Error(),
+		}
+	}
+
+	if len(purgeable) >= purgeableWarnThreshold {
+		if stash.Retention == "" {
+			return CheckResult{
+				Check:   check,
+				Status:  "warning",
+				Message: fmt.Sprintf("%d deleted record(s) and no retention policy", len(purgeable)),
+				Details: "Set one with 'stash retention 30d' or purge with 'stash purge --before 30d'",
+			}
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: fmt.Sprintf("%d deleted record(s) past retention (%s)", len(purgeable), stash.Retention),
+			Details: "Run 'stash purge --expired' or enable 'stash retention --auto-purge'",
+		}
+	}
+
+	if stash.Retention == "" {
+		return CheckResult{
+			Check:   check,
+			Status:  "ok",
+			Message: "No retention policy",
+		}
+	}
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("%d deleted record(s) past retention (%s)", len(purgeable), stash.Retention),
+	}
+}
+
+// Log sizes at which doctor advises compaction: operations per record,
+// and bytes of records.jsonl.
+const (
+	opsPerRecordWarnThreshold = 50
+	logSizeWarnThreshold      = 100 << 20
+)
+
+func checkLogBloat(ctx *context.Context, stashName string) CheckResult {
+	check := fmt.Sprintf("%s/history", stashName)
+
+	info, err := os.Stat(filepath.Join(ctx.StashDir, stashName, "records.jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return CheckResult{Check: check, Status: "ok", Message: "No records file (empty stash)"}
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot read records.jsonl",
+			Details: err.Error(),
+		}
+	}
+
+	// Compaction keeps one line per record: its last operation
+	ops := 0
+	last := make(map[string]int64)
+	err = storage.NewJSONLStore(ctx.StashDir).ReadTail(stashName, 0, 1, func(lineNum int, line []byte) error {
+		var op struct {
+			ID string `json:"_id"`
+		}
+		if len(line) == 0 || json.Unmarshal(line, &op) != nil {
+			return nil // Reported by the jsonl check
+		}
+		ops++
+		last[op.ID] = int64(len(line)) + 1
+		return nil
+	})
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Error reading records.jsonl",
+			Details: err.Error(),
+		}
+	}
+	if len(last) == 0 {
+		return CheckResult{Check: check, Status: "ok", Message: "No records"}
+	}
+
+	var compacted int64
+	for _, size := range last {
+		compacted += size
+	}
+	ratio := float64(ops) / float64(len(last))
+	summary := fmt.Sprintf("%d operation(s) on %d record(s) (%.1f per record), log %s",
+		ops, len(last), ratio, model.FormatSize(info.Size()))
+	if ratio > opsPerRecordWarnThreshold || info.Size() > logSizeWarnThreshold {
+		return CheckResult{
+			Check:   check,
+			Status:  "warning",
+			Message: summary,
+			Details: fmt.Sprintf("Compacting would reclaim about %s; run 'stash sync --flush' or 'stash doctor --fix' (the operation history is dropped)",
+				model.FormatSize(max(info.Size()-compacted, 0))),
+		}
+	}
+	return CheckResult{Check: check, Status: "ok", Message: summary}
+}
+
 func checkRecordHashes(ctx *context.Context, store *storage.Store, stashName string) CheckResult {
 	stash, err := store.GetStash(stashName)
 	if err != nil {
@@ -681,8 +989,54 @@ func checkRecordHashes(ctx *context.Context, store *storage.Store, stashName str
 	}
 }
 
+func checkHashChain(store *storage.Store, stash *model.Stash) CheckResult {
+	check := fmt.Sprintf("%s/hash_chain", stash.Name)
+
+	report, err := store.VerifyChain(stash.Name)
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot verify hash chain",
+			Details: err.Error(),
+		}
+	}
+
+	if len(report.Breaks) > 0 || report.Unchained > 0 {
+		var details []string
+		if report.Unchained > 0 {
+			details = append(details, fmt.Sprintf("%d line(s) carry no chain hash", report.Unchained))
+		}
+		for i, b := range report.Breaks {
+			if i >= 5 {
+				details = append(details, "... (more breaks)")
+				break
+			}
+			details = append(details, fmt.Sprintf("line %d (%s)", b.Line, b.ID))
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: fmt.Sprintf("Hash chain broken (%d break(s))", len(report.Breaks)),
+			Details: strings.Join(details, "; ") + "; run 'stash verify' for details",
+		}
+	}
+
+	result := CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("Hash chain intact (%d operations)", report.Lines),
+	}
+	if n := len(report.Rechains); n > 0 {
+		last := report.Rechains[n-1]
+		result.Message += fmt.Sprintf("; rewritten %d time(s), last by %s at %s", n, last.Reason, last.At.Format(time.RFC3339))
+		result.Details = fmt.Sprintf("continues from %s, the head before the last rewrite", last.PrevHead)
+	}
+	return result
+}
+
 func attemptFixes(cmd *cobra.Command, ctx *context.Context, results []CheckResult) []CheckResult {
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		fmt.Fprintf(cmd.OutOrStdout(), "Cannot open store for repairs: %v\n", err)
 		return results
@@ -713,38 +1067,93 @@ func attemptFixes(cmd *cobra.Command, ctx *context.Context, results []CheckResul
 			}
 		}
 
+		// Compact a bloated log
+		if strings.HasSuffix(r.Check, "/history") && r.Status == "warning" {
+			stashName := strings.TrimSuffix(r.Check, "/history")
+			if !quiet {
+				fmt.Fprintf(cmd.OutOrStdout(), "Fixing: Compacting the log of %s...\n", stashName)
+			}
+			if err := store.FlushToJSONL(stashName); err != nil {
+				r.Details = fmt.Sprintf("Fix failed: %v", err)
+			} else {
+				r.Status = "ok"
+				r.Message = "Log compacted"
+				r.Details = ""
+			}
+		}
+
+		// Migrate an old cache schema, or rebuild one newer than this build
+		if strings.HasSuffix(r.Check, "/cache_schema") {
+			stashName := strings.TrimSuffix(r.Check, "/cache_schema")
+			if err := fixCacheSchema(cmd, store, stashName); err != nil {
+				r.Details = fmt.Sprintf("Fix failed: %v", err)
+			} else {
+				r.Status = "ok"
+				r.Message = fmt.Sprintf("Cache schema up to date (version %d)", storage.CacheSchemaVersion)
+				r.Details = ""
+			}
+		}
+
 		newResults = append(newResults, r)
 	}
 
 	return newResults
 }
 
-func outputDoctorResults(cmd *cobra.Command, results []CheckResult) error {
-	// Calculate summary
-	var okCount, warnCount, errCount int
+// fixCacheSchema runs a stash's pending cache migrations. A cache that
+// cannot be migrated, because it is newer than this build or its version
+// is unreadable, is rebuilt from JSONL instead.
+func fixCacheSchema(cmd *cobra.Command, store *storage.Store, stashName string) error {
+	version, err := store.CacheSchemaVersion(stashName)
+	if err == nil && version <= storage.CacheSchemaVersion {
+		if !quiet {
+			fmt.Fprintf(cmd.OutOrStdout(), "Fixing: Migrating cache schema for %s...\n", stashName)
+		}
+		return store.MigrateCache(stashName)
+	}
+
+	if !quiet {
+		fmt.Fprintf(cmd.OutOrStdout(), "Fixing: Rebuilding cache for %s...\n", stashName)
+	}
+	return store.ResetCache(stashName)
+}
+
+// summarizeChecks counts check results by status. The output is healthy
+// when no check is an error.
+func summarizeChecks(results []CheckResult) DoctorOutput {
+	output := DoctorOutput{Checks: results}
 	for _, r := range results {
 		switch r.Status {
 		case "ok":
-			okCount++
+			output.Summary.OK++
 		case "warning":
-			warnCount++
+			output.Summary.Warnings++
 		case "error":
-			errCount++
+			output.Summary.Errors++
 		}
 	}
+	output.Summary.Total = len(results)
+	output.Healthy = output.Summary.Errors == 0
+	return output
+}
 
-	healthy := errCount == 0
+// checkStatusIcon returns the text output marker for a check status.
+func checkStatusIcon(status string) string {
+	switch status {
+	case "ok":
+		return "[OK]"
+	case "warning":
+		return "[WARN]"
+	case "error":
+		return "[ERROR]"
+	}
+	return ""
+}
 
-	if jsonOutput {
-		output := DoctorOutput{
-			Healthy: healthy,
-			Checks:  results,
-		}
-		output.Summary.Total = len(results)
-		output.Summary.OK = okCount
-		output.Summary.Warnings = warnCount
-		output.Summary.Errors = errCount
+func outputDoctorResults(cmd *cobra.Command, results []CheckResult) error {
+	output := summarizeChecks(results)
 
+	if jsonOutput {
 		data, err := json.MarshalIndent(output, "", "  ")
 		if err != nil {
 			return err
@@ -760,17 +1169,7 @@ func outputDoctorResults(cmd *cobra.Command, results []CheckResult) error {
 	fmt.Fprintln(out)
 
 	for _, r := range results {
-		var statusIcon string
-		switch r.Status {
-		case "ok":
-			statusIcon = "[OK]"
-		case "warning":
-			statusIcon = "[WARN]"
-		case "error":
-			statusIcon = "[ERROR]"
-		}
-
-		fmt.Fprintf(out, "%-8s %s: %s\n", statusIcon, r.Check, r.Message)
+		fmt.Fprintf(out, "%-8s %s: %s\n", checkStatusIcon(r.Status), r.Check, r.Message)
 		if r.Details != "" {
 			fmt.Fprintf(out, "         %s\n", r.Details)
 		}
@@ -778,9 +1177,9 @@ func outputDoctorResults(cmd *cobra.Command, results []CheckResult) error {
 
 	fmt.Fprintln(out)
 	fmt.Fprintf(out, "Summary: %d checks, %d ok, %d warnings, %d errors\n",
-		len(results), okCount, warnCount, errCount)
+		output.Summary.Total, output.Summary.OK, output.Summary.Warnings, output.Summary.Errors)
 
-	if healthy {
+	if output.Healthy {
 		fmt.Fprintln(out, "Status: Healthy")
 	} else {
 		fmt.Fprintln(out, "Status: Issues found")
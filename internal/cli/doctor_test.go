@@ -2,13 +2,16 @@ package cli
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
 	"github.com/user/stash/internal/storage"
 )
@@ -388,6 +391,208 @@ func TestUC_SYN_002_Doctor_Checks(t *testing.T) {
 			t.Errorf("expected warning about missing description, got: %s", output)
 		}
 	})
+
+	t.Run("reports daemon status from the PID file", func(t *testing.T) {
+		// Given: a PID file naming this process, then one naming no process
+		stashDir := t.TempDir()
+		ctx := &context.Context{StashDir: stashDir}
+		pidFile := filepath.Join(stashDir, "daemon.pid")
+
+		if result := checkDaemonStatus(ctx); result.Message != "Daemon not running" {
+			t.Errorf("expected 'Daemon not running' without a PID file, got %q", result.Message)
+		}
+
+		os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+		if result := checkDaemonStatus(ctx); result.Status != "ok" {
+			t.Errorf("expected ok for a running PID, got %s: %s", result.Status, result.Message)
+		}
+
+		os.WriteFile(pidFile, []byte("999999999\n"), 0644)
+		if result := checkDaemonStatus(ctx); result.Message != "Daemon process not found" {
+			t.Errorf("expected 'Daemon process not found' for a stale PID, got %q", result.Message)
+		}
+
+		os.WriteFile(pidFile, []byte("\n"), 0644)
+		if result := checkDaemonStatus(ctx); result.Status != "warning" {
+			t.Errorf("expected warning for an empty PID file, got %s", result.Status)
+		}
+	})
+
+	t.Run("warns about a log bloated with operations until compacted", func(t *testing.T) {
+		// Given: one record updated more times than the threshold
+		stashDir := filepath.Join(t.TempDir(), ".stash")
+		store, err := storage.NewStore(stashDir)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		defer store.Close()
+		stash := &model.Stash{
+			Name:      "bloat",
+			Prefix:    "bl-",
+			Created:   time.Now(),
+			CreatedBy: "test",
+			Columns: model.ColumnList{
+				{Name: "n", Desc: "Counter", Added: time.Now(), AddedBy: "test"},
+			},
+		}
+		store.CreateStash(stash.Name, stash.Prefix, stash)
+		record := &model.Record{
+			ID:        "bl-001",
+			Fields:    map[string]interface{}{"n": "0"},
+			CreatedAt: time.Now(),
+			CreatedBy: "test",
+			UpdatedAt: time.Now(),
+			UpdatedBy: "test",
+		}
+		store.CreateRecord(stash.Name, record)
+		ctx := &context.Context{StashDir: stashDir}
+		if result := checkLogBloat(ctx, stash.Name); result.Status != "ok" {
+			t.Errorf("expected ok for a fresh log, got %s: %s", result.Status, result.Message)
+		}
+
+		for i := 1; i <= opsPerRecordWarnThreshold; i++ {
+			record.Fields["n"] = fmt.Sprint(i)
+			store.UpdateRecord(stash.Name, record)
+		}
+		result := checkLogBloat(ctx, stash.Name)
+		if result.Status != "warning" || !strings.Contains(result.Details, "reclaim") {
+			t.Errorf("expected a warning with the space to reclaim, got %s: %s (%s)", result.Status, result.Message, result.Details)
+		}
+
+		// When: the log is compacted, as --fix does
+		if err := store.FlushToJSONL(stash.Name); err != nil {
+			t.Fatalf("failed to compact: %v", err)
+		}
+		if result := checkLogBloat(ctx, stash.Name); result.Status != "ok" {
+			t.Errorf("expected ok after compaction, got %s: %s", result.Status, result.Message)
+		}
+	})
+
+	t.Run("detects cache schema newer than this build and fix rebuilds it", func(t *testing.T) {
+		// Given: The cache was last written by a newer version of stash
+		tmpDir := t.TempDir()
+		stashDir := filepath.Join(tmpDir, ".stash")
+
+		store, err := storage.NewStore(stashDir)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		stash := &model.Stash{
+			Name:      "schematest",
+			Prefix:    "sch-",
+			Created:   time.Now(),
+			CreatedBy: "test",
+		}
+		store.CreateStash(stash.Name, stash.Prefix, stash)
+		store.Close()
+
+		// The storage package registers its SQLite driver as sqlite3_stash
+		db, err := sql.Open("sqlite3_stash", filepath.Join(stashDir, "cache.db"))
+		if err != nil {
+			t.Fatalf("failed to open cache: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE _stash_meta SET schema_version = ?`, storage.CacheSchemaVersion+1); err != nil {
+			t.Fatalf("failed to set schema version: %v", err)
+		}
+		db.Close()
+
+		oldCwd, _ := os.Getwd()
+		os.Chdir(tmpDir)
+		defer os.Chdir(oldCwd)
+
+		// When: User runs `stash doctor`
+		resetDoctorFlags()
+		var stdout bytes.Buffer
+		rootCmd.SetOut(&stdout)
+		rootCmd.SetArgs([]string{"doctor"})
+		rootCmd.Execute()
+
+		// Then: The version mismatch is an error
+		output := stdout.String()
+		if !strings.Contains(output, "[ERROR]  schematest/cache_schema: Cache schema version") {
+			t.Errorf("expected cache schema error, got: %s", output)
+		}
+
+		// When: User runs `stash doctor --fix --yes`
+		resetDoctorFlags()
+		stdout.Reset()
+		rootCmd.SetArgs([]string{"doctor", "--fix", "--yes"})
+		rootCmd.Execute()
+
+		// Then: The cache is rebuilt at the current version
+		output = stdout.String()
+		if !strings.Contains(output, "[OK]     schematest/cache_schema: Cache schema up to date") {
+			t.Errorf("expected fixed cache schema, got: %s", output)
+		}
+		store, err = storage.NewStore(stashDir)
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+		if version, err := store.CacheSchemaVersion("schematest"); err != nil || version != storage.CacheSchemaVersion {
+			t.Errorf("expected schema version %d, got %d (%v)", storage.CacheSchemaVersion, version, err)
+		}
+	})
+
+	t.Run("checks the sealed segment by checksum", func(t *testing.T) {
+		// Given: A compacted stash with one operation appended since
+		tmpDir := t.TempDir()
+		stashDir := filepath.Join(tmpDir, ".stash")
+
+		store, err := storage.NewStore(stashDir)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		stash := &model.Stash{
+			Name:      "sealtest",
+			Prefix:    "sel-",
+			Created:   time.Now(),
+			CreatedBy: "test",
+			Columns: model.ColumnList{
+				{Name: "name", Desc: "Name", Added: time.Now(), AddedBy: "test"},
+			},
+		}
+		store.CreateStash(stash.Name, stash.Prefix, stash)
+		for _, id := range []string{"sel-001", "sel-002"} {
+			store.CreateRecord(stash.Name, &model.Record{
+				ID: id, Fields: map[string]interface{}{"name": id},
+				CreatedAt: time.Now(), CreatedBy: "test", UpdatedAt: time.Now(), UpdatedBy: "test",
+			})
+		}
+		store.FlushToJSONL(stash.Name)
+		store.CreateRecord(stash.Name, &model.Record{
+			ID: "sel-003", Fields: map[string]interface{}{"name": "sel-003"},
+			CreatedAt: time.Now(), CreatedBy: "test", UpdatedAt: time.Now(), UpdatedBy: "test",
+		})
+		store.Close()
+
+		oldCwd, _ := os.Getwd()
+		os.Chdir(tmpDir)
+		defer os.Chdir(oldCwd)
+		runDoctor := func() string {
+			resetDoctorFlags()
+			var stdout bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetArgs([]string{"doctor"})
+			rootCmd.Execute()
+			return stdout.String()
+		}
+
+		// Then: Only the appended line is parsed
+		output := runDoctor()
+		if !strings.Contains(output, "JSONL valid (3 records; 2 in sealed segment verified by checksum)") {
+			t.Errorf("expected the sealed segment to be verified by checksum, got: %s", output)
+		}
+
+		// And: A log changed outside stash is checked in full
+		jsonlPath := filepath.Join(stashDir, "sealtest", "records.jsonl")
+		data, _ := os.ReadFile(jsonlPath)
+		os.WriteFile(jsonlPath, bytes.Replace(data, []byte(`"sel-001"`), []byte(`"sel-009"`), 1), 0644)
+		output = runDoctor()
+		if !strings.Contains(output, "JSONL valid (3 records; checked in full, as records.jsonl changed within its sealed segment since it was sealed)") {
+			t.Errorf("expected a full check of the changed log, got: %s", output)
+		}
+	})
 }
 
 // TestUC_SYN_002_Doctor_MustNot tests anti-requirements
@@ -11,7 +11,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var dropYes bool
@@ -42,17 +41,11 @@ func init() {
 func runDrop(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	// Resolve context to find stash directory
-	ctx, _ := context.Resolve(GetActorName(), "")
-
-	// Determine base directory
-	baseDir := ".stash"
-	if ctx.StashDir != "" {
-		baseDir = ctx.StashDir
-	}
+	// Find the stash directory
+	baseDir := context.TargetStashDir()
 
 	// Create storage
-	store, err := storage.NewStore(baseDir)
+	store, err := openStore(baseDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -0,0 +1,93 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// dryRunVerbs describe each operation type in dry-run output.
+var dryRunVerbs = map[string]string{
+	model.OpCreate:    "create",
+	model.OpUpdate:    "update",
+	model.OpDelete:    "delete",
+	model.OpRestore:   "restore",
+	model.OpArchive:   "archive",
+	model.OpUnarchive: "unarchive",
+	model.OpAssign:    "assign",
+	model.OpUnassign:  "unassign",
+}
+
+// previewOperations returns the log operations that writing each record
+// with op would append, without writing them.
+func previewOperations(store *storage.Store, stashName string, records []*model.Record, op string) ([]*model.Record, error) {
+	ops := make([]*model.Record, len(records))
+	for i, record := range records {
+		preview, err := store.PreviewRecord(stashName, record, op)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview %s: %w", record.ID, err)
+		}
+		ops[i] = preview
+	}
+	return ops, nil
+}
+
+// mixedOperations reports whether ops are not all of one operation type.
+func mixedOperations(ops []*model.Record) bool {
+	for _, op := range ops {
+		if op.Operation != ops[0].Operation {
+			return true
+		}
+	}
+	return false
+}
+
+// outputDryRun reports what a command run with --dry-run would have
+// written. JSON output has "dry_run": true, the operations that would be
+// appended to the stash's log, and the fields in extra; text output lists
+// the records the operations would change, with each operation when they
+// differ.
+func outputDryRun(stashName string, ops []*model.Record, extra map[string]interface{}) error {
+	if GetJSONOutput() {
+		result := map[string]interface{}{
+			"dry_run":    true,
+			"stash":      stashName,
+			"operations": ops,
+		}
+		for key, value := range extra {
+			result[key] = value
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() || len(ops) == 0 {
+		return nil
+	}
+	if mixedOperations(ops) {
+		fmt.Printf("Would write %d operation(s):\n", len(ops))
+		for _, op := range ops {
+			fmt.Printf("  - %s: %s\n", op.ID, dryRunVerbs[op.Operation])
+		}
+	} else {
+		fmt.Printf("Would %s %d record(s):\n", dryRunVerbs[ops[0].Operation], len(ops))
+		printRecordIDList(ops)
+	}
+	if IsVerbose() {
+		for _, op := range ops {
+			data, err := json.Marshal(op)
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Printf("  %s\n", data)
+		}
+	}
+	return nil
+}
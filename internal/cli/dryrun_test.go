@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDryRun tests --dry-run on the commands that write records and columns
+func TestDryRun(t *testing.T) {
+	// logSize returns the size of the stash's operation log
+	logSize := func(t *testing.T, tempDir string) int64 {
+		t.Helper()
+		info, err := os.Stat(filepath.Join(tempDir, ".stash", "inventory", "records.jsonl"))
+		if err != nil {
+			t.Fatalf("failed to stat log: %v", err)
+		}
+		return info.Size()
+	}
+
+	// dryRun runs a command with --dry-run --json and parses its output
+	dryRun := func(t *testing.T, args ...string) map[string]interface{} {
+		t.Helper()
+		output := captureSchemaOutput(t, append(args, "--dry-run", "--json")...)
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["dry_run"] != true {
+			t.Errorf("expected dry_run true, got %v", result)
+		}
+		return result
+	}
+
+	// operation returns the only operation in a dry-run result
+	operation := func(t *testing.T, result map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		ops, _ := result["operations"].([]interface{})
+		if len(ops) != 1 {
+			t.Fatalf("expected 1 operation, got %v", result["operations"])
+		}
+		return ops[0].(map[string]interface{})
+	}
+
+	t.Run("add prints the create without saving it", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+		before := logSize(t, tempDir)
+
+		op := operation(t, dryRun(t, "add", "Laptop", "--set", "Price=999"))
+		if op["_op"] != "create" || op["Name"] != "Laptop" || op["Price"] != "999" || op["_hash"] == "" {
+			t.Errorf("unexpected operation: %v", op)
+		}
+		if id, _ := op["_id"].(string); len(id) <= len("inv-") {
+			t.Errorf("expected a resolved ID, got %v", op["_id"])
+		}
+
+		if logSize(t, tempDir) != before {
+			t.Error("expected dry run to leave the log unchanged")
+		}
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--json")), &records)
+		if len(records) != 0 {
+			t.Errorf("expected no records, got %d", len(records))
+		}
+	})
+
+	t.Run("add validates", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		ExitCode = 0
+
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Missing=1", "--dry-run")
+		if ExitCode == 0 {
+			t.Error("expected an unknown column to fail the dry run")
+		}
+		ExitCode = 0
+	})
+
+	t.Run("set prints the update and new columns without saving", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Stock"})
+		defer cleanup()
+		var rec map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--set", "Stock=5", "--json")), &rec)
+		id := rec["_id"].(string)
+		before := logSize(t, tempDir)
+
+		result := dryRun(t, "set", id, "Stock=4", "Color=red", "--auto-create")
+		op := operation(t, result)
+		if op["_op"] != "update" || op["Stock"] != "4" || op["Color"] != "red" || op["_hash"] == rec["_hash"] {
+			t.Errorf("unexpected operation: %v", op)
+		}
+		if cols, _ := result["new_columns"].([]interface{}); len(cols) != 1 || cols[0] != "Color" {
+			t.Errorf("expected new column Color, got %v", result["new_columns"])
+		}
+
+		if logSize(t, tempDir) != before {
+			t.Error("expected dry run to leave the log unchanged")
+		}
+		var cols []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "column", "list", "--json")), &cols)
+		if len(cols) != 2 {
+			t.Errorf("expected no column to be created, got %d columns", len(cols))
+		}
+		var shown map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "show", id, "--json")), &shown)
+		if fmt.Sprint(shown["Stock"]) != "5" {
+			t.Errorf("expected Stock to stay 5, got %v", shown["Stock"])
+		}
+	})
+
+	t.Run("rm and restore print their operations and check locks", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		var rec map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &rec)
+		id := rec["_id"].(string)
+		before := logSize(t, tempDir)
+
+		op := operation(t, dryRun(t, "rm", id))
+		if op["_op"] != "delete" || op["_deleted_at"] == nil {
+			t.Errorf("unexpected operation: %v", op)
+		}
+		if logSize(t, tempDir) != before {
+			t.Error("expected dry run to leave the log unchanged")
+		}
+
+		captureSchemaOutput(t, "rm", id, "--yes")
+		op = operation(t, dryRun(t, "restore", id))
+		if op["_op"] != "restore" || op["_deleted_at"] != nil {
+			t.Errorf("unexpected operation: %v", op)
+		}
+
+		captureSchemaOutput(t, "restore", id)
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-1")
+		resetLockFlags()
+		ExitCode = 0
+		captureSchemaOutput(t, "rm", id, "--dry-run", "--actor", "agent-2")
+		if ExitCode != 5 {
+			t.Errorf("expected exit code 5 for a locked record, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("column add checks columns without adding them", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		result := dryRun(t, "column", "add", "total", "--computed", "Price * 2")
+		if cols, _ := result["columns"].([]interface{}); len(cols) != 1 {
+			t.Errorf("expected 1 column, got %v", result["columns"])
+		}
+		var cols []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "column", "list", "--json")), &cols)
+		if len(cols) != 2 {
+			t.Errorf("expected no column to be added, got %d columns", len(cols))
+		}
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "add", "bad", "--computed", "Price +", "--dry-run")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for an invalid expression, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,261 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	dueOverdue bool
+	dueWithin  string
+	dueColumn  string
+)
+
+// Due statuses reported by 'stash due'
+const (
+	DueStatusOverdue  = "overdue"
+	DueStatusUpcoming = "upcoming"
+)
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List records by due date",
+	Long: `List records by the value of their due date column(s).
+
+Due date columns are date columns added with 'stash column add <name> --due'.
+Records are sorted by due date, earliest first. Date-only values are due at
+the end of that day.
+
+Filters:
+  --overdue        Only records whose due date has passed
+  --within <dur>   Only records due within the duration (e.g., 24h, 7d, 2w)
+  --column <name>  Use a specific date column instead of all due columns
+
+Combining --overdue and --within lists both overdue records and records
+due within the window.
+
+Examples:
+  stash due
+  stash due --overdue
+  stash due --within 7d
+  stash due --overdue --within 2d --json
+
+AI Agent Examples:
+  # Pick up overdue work first
+  stash due --overdue --json | jq -r '.[].id' | while read id; do
+      stash set "$id" priority="high"
+  done
+
+Exit Codes:
+  0  Success (includes no matches)
+  1  Stash or column not found
+  2  Validation error (invalid duration, no due date column)
+
+JSON Output (--json):
+  [{"id": "tk-a1b2", "column": "due_on", "due": "2024-01-15T23:59:59Z",
+    "status": "overdue", "record": {...}}]`,
+	Args: cobra.NoArgs,
+	RunE: runDue,
+}
+
+func init() {
+	dueCmd.Flags().BoolVar(&dueOverdue, "overdue", false, "Only show overdue records")
+	dueCmd.Flags().StringVar(&dueWithin, "within", "", "Only show records due within duration (e.g., 7d)")
+	dueCmd.Flags().StringVar(&dueColumn, "column", "", "Date column to use (default: all due columns)")
+	rootCmd.AddCommand(dueCmd)
+}
+
+// DueItem is a record with a due date in a specific column.
+type DueItem struct {
+	ID     string        `json:"id"`
+	Column string        `json:"column"`
+	Due    time.Time     `json:"due"`
+	Status string        `json:"status"`
+	Record *model.Record `json:"record"`
+}
+
+func runDue(cmd *cobra.Command, args []string) error {
+	// Parse --within duration
+	var within time.Duration
+	if dueWithin != "" {
+		d, err := parseDuration(dueWithin)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("invalid duration '%s'", dueWithin),
+				map[string]interface{}{"within": dueWithin})
+			return nil
+		}
+		within = d
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	columns, ok := resolveDueColumns(stash, dueColumn)
+	if !ok {
+		return nil
+	}
+
+	records, err := store.ListRecords(ctx.Stash, storage.ListOptions{ParentID: "*"})
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	now := time.Now()
+	items := filterDueItems(collectDueItems(records, columns, now), dueOverdue, within, now)
+
+	// JSON output
+	if GetJSONOutput() {
+		if items == nil {
+			items = []DueItem{}
+		}
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// Human-readable output
+	if len(items) == 0 {
+		Infof("No records due.\n")
+		return nil
+	}
+
+	primaryCol := stash.PrimaryColumn()
+	for _, item := range items {
+		name := ""
+		if primaryCol != nil {
+			if v, ok := item.Record.Fields[primaryCol.Name]; ok && v != nil {
+				name = fmt.Sprintf("%v", v)
+			}
+		}
+		fmt.Printf("%-12s  %-8s  %s  %-10s  %s\n",
+			item.ID, item.Status, item.Due.Format("2006-01-02 15:04"), item.Column, name)
+	}
+	Infof("\nTotal: %d record(s)\n", len(items))
+
+	return nil
+}
+
+// resolveDueColumns returns the due date columns to inspect. When name is
+// set, that column is used regardless of its due flag. Reports an error and
+// returns false if no suitable column exists.
+func resolveDueColumns(stash *model.Stash, name string) ([]*model.Column, bool) {
+	if name != "" {
+		col := resolveColumn(stash, name)
+		if col == nil {
+			return nil, false
+		}
+		return []*model.Column{col}, true
+	}
+
+	var columns []*model.Column
+	for i := range stash.Columns {
+		if stash.Columns[i].Due {
+			columns = append(columns, &stash.Columns[i])
+		}
+	}
+	if len(columns) == 0 {
+		ExitValidationError("no due date column (add one with 'stash column add <name> --due')", nil)
+		return nil, false
+	}
+	return columns, true
+}
+
+// collectDueItems builds due items for every record with a parseable date
+// in one of the given columns, sorted by due date.
+func collectDueItems(records []*model.Record, columns []*model.Column, now time.Time) []DueItem {
+	var items []DueItem
+	for _, rec := range records {
+		for _, col := range columns {
+			v, ok := rec.GetField(col.Name)
+			if !ok || v == nil {
+				continue
+			}
+			due, dateOnly, err := parseDate(fmt.Sprintf("%v", v))
+			if err != nil {
+				continue
+			}
+			if dateOnly {
+				// A date-only value is due by the end of that day
+				due = due.Add(24*time.Hour - time.Second)
+			}
+
+			status := DueStatusUpcoming
+			if due.Before(now) {
+				status = DueStatusOverdue
+			}
+			items = append(items, DueItem{
+				ID:     rec.ID,
+				Column: col.Name,
+				Due:    due,
+				Status: status,
+				Record: rec,
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Due.Before(items[j].Due)
+	})
+	return items
+}
+
+// filterDueItems applies the --overdue and --within filters. With neither
+// filter set, all items are returned.
+func filterDueItems(items []DueItem, overdue bool, within time.Duration, now time.Time) []DueItem {
+	if !overdue && within == 0 {
+		return items
+	}
+
+	var filtered []DueItem
+	for _, item := range items {
+		isOverdue := item.Status == DueStatusOverdue
+		if overdue && isOverdue {
+			filtered = append(filtered, item)
+			continue
+		}
+		if within > 0 && !isOverdue && item.Due.Before(now.Add(within)) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+func TestCollectDueItems(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+	col := &model.Column{Name: "due_on", Validate: "date", Due: true}
+	records := []*model.Record{
+		{ID: "tk-late", Fields: map[string]interface{}{"due_on": "2024-06-10"}},
+		{ID: "tk-today", Fields: map[string]interface{}{"due_on": "2024-06-15"}},
+		{ID: "tk-soon", Fields: map[string]interface{}{"due_on": "2024-06-18T09:00:00"}},
+		{ID: "tk-later", Fields: map[string]interface{}{"due_on": "2024-08-01"}},
+		{ID: "tk-none", Fields: map[string]interface{}{}},
+		{ID: "tk-bad", Fields: map[string]interface{}{"due_on": "someday"}},
+	}
+
+	items := collectDueItems(records, []*model.Column{col}, now)
+
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if got := strings.Join(ids, ","); got != "tk-late,tk-today,tk-soon,tk-later" {
+		t.Fatalf("unexpected items/order: %s", got)
+	}
+
+	if items[0].Status != DueStatusOverdue {
+		t.Errorf("expected tk-late to be overdue, got %s", items[0].Status)
+	}
+	// A date-only value is not overdue until the day is over
+	if items[1].Status != DueStatusUpcoming {
+		t.Errorf("expected tk-today to be upcoming, got %s", items[1].Status)
+	}
+
+	overdue := filterDueItems(items, true, 0, now)
+	if len(overdue) != 1 || overdue[0].ID != "tk-late" {
+		t.Errorf("expected only tk-late overdue, got %v", overdue)
+	}
+
+	soon := filterDueItems(items, false, 7*24*time.Hour, now)
+	if len(soon) != 2 || soon[0].ID != "tk-today" || soon[1].ID != "tk-soon" {
+		t.Errorf("expected tk-today and tk-soon within 7d, got %v", soon)
+	}
+
+	both := filterDueItems(items, true, 7*24*time.Hour, now)
+	if len(both) != 3 {
+		t.Errorf("expected 3 items for --overdue --within 7d, got %d", len(both))
+	}
+}
+
+func TestDueCommand(t *testing.T) {
+	setupDueStash := func(t *testing.T) (string, func()) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+
+		rootCmd.SetArgs([]string{"column", "add", "due_on", "--due"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("failed to add due column, exit code %d", ExitCode)
+		}
+
+		past := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+		future := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+		rootCmd.SetArgs([]string{"add", "Late task", "--set", "due_on=" + past})
+		rootCmd.Execute()
+		rootCmd.SetArgs([]string{"add", "Future task", "--set", "due_on=" + future})
+		rootCmd.Execute()
+
+		ExitCode = 0
+		resetFlags()
+		return tempDir, cleanup
+	}
+
+	t.Run("lists overdue records as JSON", func(t *testing.T) {
+		_, cleanup := setupDueStash(t)
+		defer cleanup()
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		rootCmd.SetArgs([]string{"due", "--overdue", "--json"})
+		rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+
+		var items []DueItem
+		if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+			t.Fatalf("failed to parse JSON: %v\n%s", err, buf.String())
+		}
+		if len(items) != 1 {
+			t.Fatalf("expected 1 overdue item, got %d", len(items))
+		}
+		if items[0].Record.Fields["Name"] != "Late task" {
+			t.Errorf("expected 'Late task', got %v", items[0].Record.Fields["Name"])
+		}
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+	})
+
+	t.Run("remind runs command for overdue records", func(t *testing.T) {
+		tempDir, cleanup := setupDueStash(t)
+		defer cleanup()
+
+		outFile := filepath.Join(tempDir, "fired.txt")
+		rootCmd.SetArgs([]string{"remind", "--exec", "echo \"$STASH_ID $STASH_STATUS\" >> " + outFile, "--quiet"})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+		data, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("expected hook output file: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 1 || !strings.HasSuffix(lines[0], " overdue") {
+			t.Errorf("expected one overdue reminder, got %q", string(data))
+		}
+	})
+
+	t.Run("must not run without a due column", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"due"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var durabilityCmd = &cobra.Command{
+	Use:   "durability [normal|full]",
+	Short: "Show or set how writes to records.jsonl reach the disk",
+	Long: `Show or set the durability of writes to the stash's records.jsonl.
+
+Every write replaces records.jsonl with a new copy that is synced to disk
+first, so the log is never left half-written by stash. The modes differ
+in whether the replacement itself is synced:
+
+  normal  The default. After a power loss, the last operations may be
+          missing, but the log is intact.
+  full    The stash directory is synced after every write too, so an
+          operation survives power loss once the command that made it has
+          returned. Writes take longer, most on network file systems.
+
+The mode is stored in the stash's config.json.
+
+If records.jsonl does end in an incomplete line, for example after a
+crash while another program wrote it, stash moves that line to a
+records.jsonl.torn-<time> file the next time the stash is opened, warns,
+and carries on. 'stash doctor' lists such files until they are deleted.
+
+Examples:
+  stash durability          # Show the current mode
+  stash durability full     # Sync every write through to the disk
+  stash durability normal   # Back to the default
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (unknown mode)
+
+JSON Output (--json):
+  {"stash": "inventory", "durability": "full"}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDurability,
+}
+
+func init() {
+	rootCmd.AddCommand(durabilityCmd)
+}
+
+func runDurability(cmd *cobra.Command, args []string) error {
+	var mode string
+	if len(args) > 0 {
+		mode = args[0]
+		if err := model.ValidateDurability(mode); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"durability": mode})
+			return nil
+		}
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if mode != "" {
+		stash.Durability = mode
+		if mode == model.DurabilityNormal {
+			stash.Durability = ""
+		}
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update durability: %w", err)
+		}
+	}
+
+	current := model.DurabilityNormal
+	if stash.FullDurability() {
+		current = model.DurabilityFull
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "durability": current})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if mode != "" {
+			fmt.Printf("Set durability of stash '%s' to %s\n", stash.Name, current)
+		} else {
+			fmt.Printf("Durability of stash '%s': %s\n", stash.Name, current)
+		}
+	}
+	return nil
+}
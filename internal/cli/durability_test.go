@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestDurability(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	out := captureSchemaOutput(t, "durability")
+	if !strings.Contains(out, "normal") {
+		t.Errorf("expected normal durability by default, got %q", out)
+	}
+
+	captureSchemaOutput(t, "durability", "full")
+	if ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", ExitCode)
+	}
+	store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	stash, _ := store.GetStash("inventory")
+	store.Close()
+	if stash.Durability != model.DurabilityFull {
+		t.Errorf("expected full durability in config, got %q", stash.Durability)
+	}
+
+	// Writes still work with every write synced through
+	captureSchemaOutput(t, "add", "Laptop")
+	if ExitCode != 0 {
+		t.Fatalf("expected add to succeed, got exit code %d", ExitCode)
+	}
+
+	out = captureSchemaOutput(t, "durability", "--json")
+	if !strings.Contains(out, `"durability":"full"`) {
+		t.Errorf("expected full durability in JSON, got %q", out)
+	}
+
+	captureSchemaOutput(t, "durability", "paranoid")
+	if ExitCode != 2 {
+		t.Errorf("expected exit code 2 for an unknown mode, got %d", ExitCode)
+	}
+	ExitCode = 0
+}
+
+func TestTornWriteRecovery(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+	captureSchemaOutput(t, "add", "Laptop")
+
+	logPath := filepath.Join(tempDir, ".stash", "inventory", "records.jsonl")
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString(`{"id":"inv-zzzz","_op":"upd`)
+	f.Close()
+
+	var out string
+	stderr := captureStderr(t, func() {
+		out = captureSchemaOutput(t, "add", "Monitor")
+	})
+	if ExitCode != 0 {
+		t.Fatalf("expected add to succeed after recovery, got exit code %d: %s", ExitCode, out)
+	}
+	if !strings.Contains(stderr, "interrupted write") || !strings.Contains(stderr, "records.jsonl.torn-") {
+		t.Errorf("expected a warning about the torn write, got %q", stderr)
+	}
+
+	var doctorOut bytes.Buffer
+	rootCmd.SetOut(&doctorOut)
+	captureSchemaOutput(t, "doctor")
+	rootCmd.SetOut(nil)
+	if !strings.Contains(doctorOut.String(), "1 interrupted write(s) moved out of records.jsonl") {
+		t.Errorf("expected doctor to list the quarantined write, got:\n%s", doctorOut.String())
+	}
+	ExitCode = 0
+}
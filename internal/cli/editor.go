@@ -0,0 +1,76 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the user's editor command line: $VISUAL, then
+// $EDITOR, then the platform default.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// openerCommand returns the command that opens a file in its default
+// application: $STASH_OPENER, then the platform opener.
+func openerCommand() []string {
+	if fields := strings.Fields(os.Getenv("STASH_OPENER")); len(fields) > 0 {
+		return fields
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		// start's first quoted argument is the window title
+		return []string{"cmd", "/c", "start", ""}
+	}
+	return []string{"xdg-open"}
+}
+
+// editInEditor writes content to a temporary file named after pattern,
+// opens it in the user's editor, and returns the edited contents once the
+// editor exits.
+func editInEditor(pattern string, content []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("editor '%s' exited with status %d", editor[0], exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("failed to run editor '%s': %w", editor[0], err)
+	}
+
+	return os.ReadFile(path)
+}
@@ -3,8 +3,13 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
 )
 
 // Error codes for structured error responses
@@ -19,6 +24,18 @@ const (
 	ErrCodeNoStashDir      = "NO_STASH_DIR"
 	ErrCodeInvalidSQL      = "INVALID_SQL"
 	ErrCodePermissionError = "PERMISSION_ERROR"
+	ErrCodeRootNotFound    = "ROOT_NOT_FOUND"
+	ErrCodeAttachRejected  = "ATTACHMENT_REJECTED"
+	ErrCodeRecordTooLarge  = "RECORD_TOO_LARGE"
+	ErrCodeThrottled       = "THROTTLED"
+	ErrCodeQuotaExceeded   = "QUOTA_EXCEEDED"
+	ErrCodeTimeout         = "TIMEOUT"
+	ErrCodeQueryTooLarge   = "QUERY_TOO_LARGE"
+	ErrCodeTxNotFound      = "TX_NOT_FOUND"
+
+	ErrCodeValidationWarning = "VALIDATION_WARNING"
+	ErrCodeTornWrite         = "TORN_WRITE"
+	ErrCodeEnumCoerced       = "ENUM_COERCED"
 )
 
 // JSONError represents a structured error response for --json output
@@ -48,6 +65,32 @@ func ExitWithError(code int, errCode, message string, details map[string]interfa
 	Exit(code)
 }
 
+// JSONWarning represents a structured warning for --json output. Warnings
+// do not stop a command, so they are written to stderr and stdout keeps
+// only the command's result.
+type JSONWarning struct {
+	Warning bool                   `json:"warning"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// PrintWarning outputs a warning to stderr: structured JSON if --json flag
+// is set, otherwise plain text unless --quiet is set.
+func PrintWarning(errCode, message string, details map[string]interface{}) {
+	if GetJSONOutput() {
+		data, _ := json.Marshal(JSONWarning{
+			Warning: true,
+			Code:    errCode,
+			Message: message,
+			Details: details,
+		})
+		fmt.Fprintln(os.Stderr, string(data))
+	} else if !IsQuiet() {
+		fmt.Fprintln(os.Stderr, "Warning:", message)
+	}
+}
+
 // ExitRecordNotFound outputs a record not found error
 func ExitRecordNotFound(recordID string) {
 	ExitWithError(1, ErrCodeRecordNotFound,
@@ -62,11 +105,16 @@ func ExitStashNotFound(stashName string) {
 		map[string]interface{}{"stash": stashName})
 }
 
-// ExitColumnNotFound outputs a column not found error
-func ExitColumnNotFound(columnName string) {
-	ExitWithError(1, ErrCodeColumnNotFound,
-		fmt.Sprintf("column '%s' not found", columnName),
-		map[string]interface{}{"column": columnName})
+// ExitColumnNotFound outputs a column not found error, suggesting the
+// closest of the stash's columns when one is near enough to be a typo
+func ExitColumnNotFound(columnName string, columns model.ColumnList) {
+	message := fmt.Sprintf("column '%s' not found", columnName)
+	details := map[string]interface{}{"column": columnName}
+	if suggestion := columns.Suggest(columnName); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+		details["suggestion"] = suggestion
+	}
+	ExitWithError(1, ErrCodeColumnNotFound, message, details)
 }
 
 // ExitValidationError outputs a validation error
@@ -98,3 +146,89 @@ func ExitInvalidSQL(message string, query string) {
 	ExitWithError(2, ErrCodeInvalidSQL, message,
 		map[string]interface{}{"query": query})
 }
+
+// ExitPermissionDenied outputs an error when the actor's permission rule
+// forbids a write
+func ExitPermissionDenied(stashName string, err *model.PermissionError) {
+	details := map[string]interface{}{
+		"stash":     stashName,
+		"actor":     err.Actor,
+		"operation": err.Operation,
+	}
+	if err.Column != "" {
+		details["column"] = err.Column
+	}
+	ExitWithError(6, ErrCodePermissionError, err.Error(), details)
+}
+
+// ExitAttachmentRejected outputs an error when the stash's attachment
+// policy rejects a file
+func ExitAttachmentRejected(stashName, recordID string, err *model.AttachmentPolicyError) {
+	ExitWithError(2, ErrCodeAttachRejected, err.Error(), map[string]interface{}{
+		"stash":     stashName,
+		"record_id": recordID,
+		"name":      err.Name,
+		"rule":      err.Rule,
+	})
+}
+
+// exitRecordTooLarge outputs an error if err is a record rejected by the
+// stash's size limits. Returns true if it was.
+func exitRecordTooLarge(stashName string, err error) bool {
+	var sizeErr *model.SizeLimitError
+	if !errors.As(err, &sizeErr) {
+		return false
+	}
+	details := map[string]interface{}{
+		"stash": stashName,
+		"size":  sizeErr.Size,
+		"limit": sizeErr.Limit,
+	}
+	if sizeErr.RecordID != "" {
+		details["record_id"] = sizeErr.RecordID
+	}
+	if sizeErr.Field != "" {
+		details["field"] = sizeErr.Field
+	}
+	ExitWithError(2, ErrCodeRecordTooLarge,
+		sizeErr.Error()+" (store large content as a file with 'stash attach', or see 'stash limits')", details)
+	return true
+}
+
+// exitWriteRefused outputs an error if err is a write refused by the
+// stash's quotas (see 'stash quotas'), and reports whether it did. Both
+// exit with code 7, so agents can tell them from failures and back off.
+func exitWriteRefused(err error) bool {
+	var throttleErr *model.ThrottleError
+	if errors.As(err, &throttleErr) {
+		ExitWithError(7, ErrCodeThrottled, throttleErr.Error(), map[string]interface{}{
+			"stash":               throttleErr.Stash,
+			"agent":               throttleErr.Agent,
+			"writes_per_minute":   throttleErr.Limit,
+			"retry_after_seconds": math.Ceil(throttleErr.RetryAfter.Seconds()),
+		})
+		return true
+	}
+	var quotaErr *model.QuotaError
+	if errors.As(err, &quotaErr) {
+		ExitWithError(7, ErrCodeQuotaExceeded, quotaErr.Error()+" (see 'stash quotas')", map[string]interface{}{
+			"stash":       quotaErr.Stash,
+			"records":     quotaErr.Records,
+			"max_records": quotaErr.Limit,
+		})
+		return true
+	}
+	return false
+}
+
+// exitQueryTooLarge reports a listing too large for SQLite to run and
+// exits 2. Returns true if err is such a listing's error.
+func exitQueryTooLarge(err error) bool {
+	var tooLarge *storage.QueryTooLargeError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	ExitWithError(2, ErrCodeQueryTooLarge, tooLarge.Error()+"; use fewer or shorter --where conditions",
+		map[string]interface{}{"reason": tooLarge.Reason})
+	return true
+}
@@ -0,0 +1,73 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/user/stash/internal/storage"
+)
+
+// explainOutput is the --explain JSON output: the query plan and any hints
+// drawn from it.
+type explainOutput struct {
+	*storage.QueryPlan
+	Hints []string `json:"hints,omitempty"`
+}
+
+// planHints explains the parts of a plan that commonly make a query slow.
+// filtered says whether the query selects some rows rather than all, as
+// reading every row is only a cost when most of them are thrown away.
+func planHints(plan *storage.QueryPlan, filtered bool) []string {
+	var hints []string
+	if filtered {
+		for _, table := range plan.FullScans() {
+			hints = append(hints, fmt.Sprintf("SQLite reads every row of %s to apply the filter, as no index covers it. Only the parent, branch, assignee, hash, and update, delete and archive times are indexed; a filter on one of those reads just the matching rows", table))
+		}
+	}
+	if plan.TempSorts() {
+		hints = append(hints, "Matching rows are sorted after they are read, as no index returns them in order; with a --limit, every match is still read and sorted first")
+	}
+	return hints
+}
+
+// writeQueryPlan writes the SQL of a query, the arguments it binds, and
+// SQLite's plan for it, as JSON with --json or as an indented tree.
+func writeQueryPlan(w io.Writer, plan *storage.QueryPlan, filtered bool) error {
+	hints := planHints(plan, filtered)
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(explainOutput{QueryPlan: plan, Hints: hints}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintln(w, "SQL:")
+	fmt.Fprintf(w, "  %s\n", plan.SQL)
+	if len(plan.Args) > 0 {
+		args := make([]string, len(plan.Args))
+		for i, arg := range plan.Args {
+			args[i] = fmt.Sprintf("%q", fmt.Sprint(arg))
+			if _, ok := arg.(string); !ok {
+				args[i] = fmt.Sprint(arg)
+			}
+		}
+		fmt.Fprintf(w, "Args: %s\n", strings.Join(args, ", "))
+	}
+
+	fmt.Fprintln(w, "\nQuery plan:")
+	depth := map[int]int{}
+	for _, step := range plan.Steps {
+		depth[step.ID] = depth[step.Parent] + 1
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth[step.ID]), step.Detail)
+	}
+
+	for _, hint := range hints {
+		fmt.Fprintf(w, "\nHint: %s\n", hint)
+	}
+	return nil
+}
@@ -2,10 +2,12 @@
 package cli
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -22,15 +24,19 @@ var (
 	exportIncludeDeleted bool
 	exportForce          bool
 	exportColumns        string
+	exportFull           bool
+	exportSQLite         bool
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export [file]",
 	Short: "Export records to a file",
-	Long: `Export records from the current stash to CSV, JSON, or JSONL format.
+	Long: `Export records from the current stash to CSV, JSON, or JSONL format, or
+to a standalone SQLite database.
 
 By default, exports to CSV format. Use --format to specify the output format.
-If no file is specified, writes to stdout.
+If no file is specified, writes to stdout. Records are streamed from the
+cache as they are written, so memory use stays constant for large stashes.
 
 Examples:
   stash export                              # Export all to stdout (CSV)
@@ -39,18 +45,35 @@ Examples:
   stash export --format jsonl               # Export all to stdout (JSONL)
   stash export --where "Category=electronics"  # Export filtered records
   stash export --columns "Name,Price"       # Export only specific columns
-  stash export --include-deleted            # Include soft-deleted records`,
+  stash export --include-deleted            # Include soft-deleted records
+  stash export backup.json --full           # Full-fidelity copy for 'stash import --full'
+  stash export inventory.db --sqlite        # SQLite database for DB tools
+
+SQLite exports (--sqlite or --format sqlite) need an output file. They
+hold one table named after the stash, with the system fields as columns
+(_id, _parent, _created_at, ...) and one column per stash column: number
+columns are REAL, dates ISO-8601 TEXT, and list columns TEXT holding a
+JSON array. _parent, _updated_at, _deleted_at, and _assigned_to are
+indexed. The _stash_columns table describes the schema, and _stash_export
+the export. 'stash import --sqlite' reads the table back.
+
+Full exports (--full) hold the schema, every line of the operations log,
+and the contents of all attachments, along with the stash's fingerprint.
+'stash import --full' recreates the stash from them with the same IDs and
+hashes. --where, --columns, and --format do not apply to full exports.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runExport,
 }
 
 func init() {
-	exportCmd.Flags().StringVar(&exportFormat, "format", "csv", "Output format: csv, json, jsonl")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "csv", "Output format: csv, json, jsonl, sqlite")
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: stdout)")
 	exportCmd.Flags().StringArrayVar(&exportWhere, "where", nil, "Filter by field value (can be repeated)")
 	exportCmd.Flags().BoolVar(&exportIncludeDeleted, "include-deleted", false, "Include soft-deleted records")
 	exportCmd.Flags().BoolVarP(&exportForce, "force", "f", false, "Overwrite existing file without warning")
 	exportCmd.Flags().StringVar(&exportColumns, "columns", "", "Select specific columns to export (comma-separated)")
+	exportCmd.Flags().BoolVar(&exportFull, "full", false, "Export history, attachments, and schema for 'stash import --full'")
+	exportCmd.Flags().BoolVar(&exportSQLite, "sqlite", false, "Write a standalone SQLite database (same as --format sqlite)")
 	rootCmd.AddCommand(exportCmd)
 }
 
@@ -72,7 +95,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -89,10 +112,17 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if exportFull {
+		return runExportFull(store, ctx, args)
+	}
+
 	// Validate format
 	format := strings.ToLower(exportFormat)
-	if format != "csv" && format != "json" && format != "jsonl" {
-		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (must be csv, json, or jsonl)\n", exportFormat)
+	if exportSQLite {
+		format = "sqlite"
+	}
+	if format != "csv" && format != "json" && format != "jsonl" && format != "sqlite" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (must be csv, json, jsonl, or sqlite)\n", exportFormat)
 		Exit(1)
 		return nil
 	}
@@ -102,6 +132,11 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		outputFile = args[0]
 	}
+	if format == "sqlite" && outputFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: SQLite exports need an output file")
+		Exit(1)
+		return nil
+	}
 
 	// Check if output file exists (unless --force)
 	if outputFile != "" && !exportForce {
@@ -115,7 +150,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 	// Parse WHERE clauses
 	var whereConditions []storage.WhereCondition
 	for _, clause := range exportWhere {
-		cond, err := parseWhereClause(clause)
+		cond, err := storage.ParseWhereClause(clause)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			Exit(1)
@@ -123,6 +158,9 @@ func runExport(cmd *cobra.Command, args []string) error {
 		}
 		whereConditions = append(whereConditions, cond)
 	}
+	if !checkWhereColumns(stash, whereConditions) {
+		return nil
+	}
 
 	// Build list options
 	opts := storage.ListOptions{
@@ -131,10 +169,35 @@ func runExport(cmd *cobra.Command, args []string) error {
 		Where:          whereConditions,
 	}
 
-	// List records
-	records, err := store.ListRecords(ctx.Stash, opts)
-	if err != nil {
-		return fmt.Errorf("failed to list records: %w", err)
+	// Get column names - use selected columns or all columns
+	var columnNames []string
+	if exportColumns != "" {
+		for _, col := range strings.Split(exportColumns, ",") {
+			col = strings.TrimSpace(col)
+			if col != "" {
+				columnNames = append(columnNames, col)
+			}
+		}
+		expanded, ok := expandColumnGroups(stash, columnNames)
+		if !ok {
+			return nil
+		}
+		columnNames = expanded
+	} else {
+		columnNames = stash.Columns.Names()
+	}
+
+	if format == "sqlite" {
+		count, err := store.ExportSQLite(ctx.Stash, outputFile, ctx.Actor, opts, columnNames)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(1)
+			return nil
+		}
+		if !IsQuiet() {
+			fmt.Fprintf(os.Stderr, "Exported %d record(s) to %s\n", count, outputFile)
+		}
+		return nil
 	}
 
 	// Determine output writer
@@ -149,27 +212,21 @@ func runExport(cmd *cobra.Command, args []string) error {
 		defer writer.Close()
 	}
 
-	// Get column names - use selected columns or all columns
-	var columnNames []string
-	if exportColumns != "" {
-		for _, col := range strings.Split(exportColumns, ",") {
-			col = strings.TrimSpace(col)
-			if col != "" {
-				columnNames = append(columnNames, col)
-			}
-		}
-	} else {
-		columnNames = stash.Columns.Names()
+	// Stream records straight from the cache so large stashes export in
+	// constant memory
+	records := func(fn func(*model.Record) error) error {
+		return store.IterateRecords(ctx.Stash, opts, fn)
 	}
 
 	// Export based on format
+	var count int
 	switch format {
 	case "csv":
-		err = exportCSV(writer, records, columnNames)
+		count, err = exportCSV(writer, records, columnNames)
 	case "json":
-		err = exportJSON(writer, records, columnNames)
+		count, err = exportJSON(writer, records, columnNames)
 	case "jsonl":
-		err = exportJSONL(writer, records, columnNames)
+		count, err = exportJSONL(writer, records, columnNames)
 	}
 
 	if err != nil {
@@ -180,75 +237,165 @@ func runExport(cmd *cobra.Command, args []string) error {
 
 	// Success message (unless writing to stdout)
 	if outputFile != "" && !IsQuiet() {
-		fmt.Fprintf(os.Stderr, "Exported %d record(s) to %s\n", len(records), outputFile)
+		fmt.Fprintf(os.Stderr, "Exported %d record(s) to %s\n", count, outputFile)
+	}
+
+	return nil
+}
+
+// runExportFull writes a full-fidelity export of the current stash.
+func runExportFull(store *storage.Store, ctx *context.Context, args []string) error {
+	if len(exportWhere) > 0 || exportColumns != "" {
+		ExitValidationError("--where and --columns cannot be used with --full", nil)
+		return nil
+	}
+
+	outputFile := exportOutput
+	if len(args) > 0 {
+		outputFile = args[0]
+	}
+	if outputFile != "" && !exportForce {
+		if _, err := os.Stat(outputFile); err == nil {
+			fmt.Fprintf(os.Stderr, "Error: file '%s' already exists (use --force to overwrite)\n", outputFile)
+			Exit(1)
+			return nil
+		}
+	}
+
+	export, err := store.ExportFull(ctx.Stash, ctx.Actor)
+	if err != nil {
+		return fmt.Errorf("failed to export stash: %w", err)
 	}
 
+	writer := os.Stdout
+	if outputFile != "" {
+		writer, err = os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer writer.Close()
+	}
+	if err := storage.WriteFullExport(writer, export); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if outputFile != "" && !IsQuiet() {
+		fmt.Fprintf(os.Stderr, "Exported %d operation(s) and %d attachment(s) to %s\n", len(export.Operations), len(export.Attachments), outputFile)
+		fmt.Fprintf(os.Stderr, "Fingerprint: %s\n", export.Fingerprint)
+	}
 	return nil
 }
 
-// exportCSV writes records in CSV format.
-func exportCSV(w *os.File, records []*model.Record, columnNames []string) error {
+// recordSource passes records to fn one at a time.
+type recordSource func(fn func(*model.Record) error) error
+
+// exportFields returns the selected fields of a record.
+func exportFields(rec *model.Record, columnNames []string) map[string]interface{} {
+	filtered := make(map[string]interface{})
+	for _, col := range columnNames {
+		if val, ok := rec.Fields[col]; ok {
+			filtered[col] = val
+		}
+	}
+	return filtered
+}
+
+// exportCSV writes records in CSV format and returns the number written.
+func exportCSV(w *os.File, records recordSource, columnNames []string) (int, error) {
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
 	// Write header
 	if err := writer.Write(columnNames); err != nil {
-		return fmt.Errorf("failed to write CSV header: %w", err)
+		return 0, fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
 	// Write records
-	for _, rec := range records {
+	count := 0
+	err := records(func(rec *model.Record) error {
 		row := make([]string, len(columnNames))
 		for i, col := range columnNames {
 			if val, ok := rec.Fields[col]; ok {
-				row[i] = fmt.Sprintf("%v", val)
+				row[i] = valueText(val)
 			}
 		}
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write CSV row: %w", err)
 		}
-	}
+		count++
+		return nil
+	})
 
-	return nil
+	return count, err
 }
 
-// exportJSON writes records as a JSON array.
-func exportJSON(w *os.File, records []*model.Record, columnNames []string) error {
-	// Build output structure (only selected fields)
-	output := make([]map[string]interface{}, len(records))
-	for i, rec := range records {
-		filtered := make(map[string]interface{})
-		for _, col := range columnNames {
-			if val, ok := rec.Fields[col]; ok {
-				filtered[col] = val
-			}
+// exportJSON writes records as a JSON array and returns the number written.
+func exportJSON(w *os.File, records recordSource, columnNames []string) (int, error) {
+	return writeJSONArray(w, records, func(rec *model.Record) interface{} {
+		return exportFields(rec, columnNames)
+	})
+}
+
+// writeJSONArray writes an indented JSON array one element at a time, so the
+// full result set is never held in memory. The output matches
+// json.MarshalIndent(values, "", "  ").
+func writeJSONArray(w io.Writer, records recordSource, value func(*model.Record) interface{}) (int, error) {
+	buf := bufio.NewWriter(w)
+
+	count := 0
+	err := records(func(rec *model.Record) error {
+		data, err := json.MarshalIndent(value(rec), "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		sep := ",\n  "
+		if count == 0 {
+			sep = "[\n  "
+		}
+		buf.WriteString(sep)
+		if _, err := buf.Write(data); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
 		}
-		output[i] = filtered
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
 	}
 
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(output); err != nil {
-		return fmt.Errorf("failed to write JSON: %w", err)
+	closing := "\n]\n"
+	if count == 0 {
+		closing = "[]\n"
+	}
+	if _, err := buf.WriteString(closing); err != nil {
+		return count, fmt.Errorf("failed to write JSON: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write JSON: %w", err)
 	}
 
-	return nil
+	return count, nil
 }
 
-// exportJSONL writes records as newline-delimited JSON.
-func exportJSONL(w *os.File, records []*model.Record, columnNames []string) error {
-	encoder := json.NewEncoder(w)
-	for _, rec := range records {
-		filtered := make(map[string]interface{})
-		for _, col := range columnNames {
-			if val, ok := rec.Fields[col]; ok {
-				filtered[col] = val
-			}
-		}
-		if err := encoder.Encode(filtered); err != nil {
+// exportJSONL writes records as newline-delimited JSON and returns the number written.
+func exportJSONL(w *os.File, records recordSource, columnNames []string) (int, error) {
+	buf := bufio.NewWriter(w)
+
+	encoder := json.NewEncoder(buf)
+	count := 0
+	err := records(func(rec *model.Record) error {
+		if err := encoder.Encode(exportFields(rec, columnNames)); err != nil {
 			return fmt.Errorf("failed to write JSONL: %w", err)
 		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := buf.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write JSONL: %w", err)
 	}
 
-	return nil
+	return count, nil
 }
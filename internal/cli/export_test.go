@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
 )
 
 // resetExportFlags resets export command flags
@@ -17,6 +20,8 @@ func resetExportFlags() {
 	exportIncludeDeleted = false
 	exportForce = false
 	exportColumns = ""
+	exportFull = false
+	exportSQLite = false
 }
 
 // TestUC_IMP_002_ExportToFile tests UC-IMP-002: Export to File
@@ -558,3 +563,120 @@ func TestUC_IMP_002_ExportToFile(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteJSONArray(t *testing.T) {
+	records := []*model.Record{
+		{ID: "inv-aaa1", Fields: map[string]interface{}{"Name": "Laptop"}},
+		{ID: "inv-aaa2", Fields: map[string]interface{}{"Name": "Mouse"}},
+	}
+
+	for _, n := range []int{0, 1, 2} {
+		source := func(fn func(*model.Record) error) error {
+			for _, rec := range records[:n] {
+				if err := fn(rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var buf strings.Builder
+		count, err := writeJSONArray(&buf, source, func(rec *model.Record) interface{} {
+			return rec.Fields
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != n {
+			t.Errorf("expected count %d, got %d", n, count)
+		}
+
+		values := make([]map[string]interface{}, n)
+		for i, rec := range records[:n] {
+			values[i] = rec.Fields
+		}
+		want, _ := json.MarshalIndent(values, "", "  ")
+		if buf.String() != string(want)+"\n" {
+			t.Errorf("streamed output differs for %d record(s):\ngot:  %q\nwant: %q", n, buf.String(), string(want)+"\n")
+		}
+	}
+}
+
+func TestExportSQLite(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"add", "Laptop", "--set", "Price=999"},
+		{"add", "Mouse", "--set", "Price=50"},
+		{"init", "copy", "--prefix", "cp-"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		ExitCode = 0
+		resetFlags()
+	}
+	resetExportFlags()
+	resetImportFlags()
+
+	dbFile := filepath.Join(tempDir, "inventory.db")
+
+	t.Run("needs an output file", func(t *testing.T) {
+		defer resetExportFlags()
+		rootCmd.SetArgs([]string{"export", "--stash", "inventory", "--sqlite"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("round trip through import", func(t *testing.T) {
+		defer resetExportFlags()
+		defer resetImportFlags()
+		rootCmd.SetArgs([]string{"export", dbFile, "--stash", "inventory", "--sqlite"})
+		if err := rootCmd.Execute(); err != nil || ExitCode != 0 {
+			t.Fatalf("export failed: %v (exit %d)", err, ExitCode)
+		}
+
+		rootCmd.SetArgs([]string{"import", dbFile, "--stash", "copy", "--confirm"})
+		if err := rootCmd.Execute(); err != nil || ExitCode != 0 {
+			t.Fatalf("import failed: %v (exit %d)", err, ExitCode)
+		}
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+		records, err := store.ListRecords("copy", storage.ListOptions{ParentID: "*"})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		for _, rec := range records {
+			if !strings.HasPrefix(rec.ID, "cp-") {
+				t.Errorf("expected a new ID in the copy, got %s", rec.ID)
+			}
+			if _, ok := rec.Fields["_id"]; ok {
+				t.Errorf("system column imported as a field: %v", rec.Fields)
+			}
+		}
+	})
+
+	t.Run("table only for SQLite", func(t *testing.T) {
+		defer resetImportFlags()
+		csvFile := filepath.Join(tempDir, "products.csv")
+		os.WriteFile(csvFile, []byte("Name\nDesk\n"), 0644)
+		rootCmd.SetArgs([]string{"import", csvFile, "--table", "t", "--confirm"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
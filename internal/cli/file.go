@@ -0,0 +1,301 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var fileCmd = &cobra.Command{
+	Use:   "file",
+	Short: "Stream attachments in and out of records",
+	Long: `Stream attachments in and out of records.
+
+'file cat' writes an attachment to stdout and 'file attach --stdin' reads
+one from stdin, so attachments can be piped without temporary files.
+
+Examples:
+  stash file cat inv-ex4j spec.md
+  stash file cat inv-ex4j build.log | grep FAIL
+  make test 2>&1 | stash file attach inv-ex4j --stdin --name test.log
+  stash file policy --max-size 10MB --ext pdf,png,md`,
+}
+
+var fileCatCmd = &cobra.Command{
+	Use:   "cat <record-id> <name>",
+	Short: "Write an attachment to stdout",
+	Long: `Write the content of an attachment to stdout, byte for byte.
+
+Examples:
+  stash file cat inv-ex4j spec.md
+  stash file cat inv-ex4j image.png > image.png
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Invalid attachment name
+  4  Record or attachment not found`,
+	Args: cobra.ExactArgs(2),
+	RunE: runFileCat,
+}
+
+var fileAttachCmd = &cobra.Command{
+	Use:   "attach <record-id> [file]",
+	Short: "Attach a file, or stdin, to a record",
+	Long: `Attach a file to a record, or read the attachment from stdin with
+--stdin and --name. This is the same as 'stash attach'.
+
+Examples:
+  stash file attach inv-ex4j document.pdf
+  make test 2>&1 | stash file attach inv-ex4j --stdin --name test.log
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or attachment already exists
+  2  Validation error (missing --name, invalid name, file not found),
+     or rejected by the attachment policy
+  4  Record not found`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runAttach,
+}
+
+var (
+	filePolicyMaxSize string
+	filePolicyExt     string
+	filePolicyMime    string
+	filePolicyScanner string
+	filePolicyClear   bool
+)
+
+var filePolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show or set what files may be attached",
+	Long: `Show or set the attachment policy of the current stash.
+
+The policy is stored in the stash's config.json and checked whenever a
+file is attached, so a shared stash does not collect arbitrary binaries.
+A file must pass every limit that is set:
+
+  --max-size  Largest file, e.g. 512KB, 10MB, 1GB
+  --ext       Allowed extensions, comma-separated
+  --mime      Allowed content types, detected from the file's first bytes;
+              image/* allows every image type
+  --scanner   Shell command run with the file's path as its last argument
+              (also in STASH_FILE); a non-zero exit rejects the file, and
+              the first line of its output becomes the message
+
+Set a flag to "none" to remove that limit, or use --clear to remove them
+all.
+Files already attached are not checked.
+
+Examples:
+  stash file policy
+  stash file policy --max-size 10MB --ext pdf,png,md
+  stash file policy --mime "image/*,application/pdf"
+  stash file policy --scanner "clamscan --no-summary"
+  stash file policy --ext none
+  stash file policy --clear
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (invalid size, --clear with other flags)
+
+JSON Output (--json):
+  {"stash": "inventory", "policy": {"max_size": 10485760,
+   "extensions": ["pdf", "png"], "scanner": "clamscan --no-summary"}}`,
+	Args: cobra.NoArgs,
+	RunE: runFilePolicy,
+}
+
+func init() {
+	addAttachFlags(fileAttachCmd)
+	filePolicyCmd.Flags().StringVar(&filePolicyMaxSize, "max-size", "", "Largest file that may be attached (e.g., 10MB)")
+	filePolicyCmd.Flags().StringVar(&filePolicyExt, "ext", "", "Allowed file extensions, comma-separated")
+	filePolicyCmd.Flags().StringVar(&filePolicyMime, "mime", "", "Allowed content types, comma-separated (e.g., image/*)")
+	filePolicyCmd.Flags().StringVar(&filePolicyScanner, "scanner", "", "Command that scans files before they are attached")
+	filePolicyCmd.Flags().BoolVar(&filePolicyClear, "clear", false, "Remove every limit")
+	fileCmd.AddCommand(fileCatCmd)
+	fileCmd.AddCommand(fileAttachCmd)
+	fileCmd.AddCommand(filePolicyCmd)
+	rootCmd.AddCommand(fileCmd)
+}
+
+func runFileCat(cmd *cobra.Command, args []string) error {
+	recordID := args[0]
+	name := args[1]
+
+	if err := model.ValidateAttachmentName(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid attachment name '%s'\n", name)
+		Exit(2)
+		return nil
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			fmt.Fprintln(os.Stderr, "Error: no .stash directory found")
+			Exit(1)
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			fmt.Fprintln(os.Stderr, "Error: no stash specified and multiple stashes exist (use --stash)")
+			Exit(1)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Verify stash exists
+	if _, err := store.GetStash(ctx.Stash); err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
+			Exit(1)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	f, err := store.OpenAttachment(ctx.Stash, recordID, name)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: record '%s' not found\n", recordID)
+			Exit(4)
+			return nil
+		}
+		if errors.Is(err, model.ErrRecordDeleted) {
+			fmt.Fprintf(os.Stderr, "Error: record '%s' is deleted\n", recordID)
+			Exit(4)
+			return nil
+		}
+		if errors.Is(err, model.ErrAttachmentNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: attachment '%s' not found for record '%s'\n", name, recordID)
+			Exit(4)
+			return nil
+		}
+		return fmt.Errorf("failed to open attachment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+	return nil
+}
+
+func runFilePolicy(cmd *cobra.Command, args []string) error {
+	update := filePolicyMaxSize != "" || filePolicyExt != "" || filePolicyMime != "" || filePolicyScanner != ""
+	if filePolicyClear && update {
+		ExitValidationError("--clear cannot be combined with other policy flags", nil)
+		return nil
+	}
+
+	var maxSize int64
+	if filePolicyMaxSize != "" && !isPolicyNone(filePolicyMaxSize) && filePolicyMaxSize != "0" {
+		var err error
+		if maxSize, err = model.ParseSize(filePolicyMaxSize); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"max_size": filePolicyMaxSize})
+			return nil
+		}
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Update the policy if requested
+	if update || filePolicyClear {
+		policy := &model.AttachmentPolicy{}
+		if stash.AttachPolicy != nil {
+			*policy = *stash.AttachPolicy
+		}
+		if filePolicyMaxSize != "" {
+			policy.MaxSize = maxSize
+		}
+		if filePolicyExt != "" {
+			policy.Extensions = nil
+			if !isPolicyNone(filePolicyExt) {
+				for _, ext := range splitPermissionList(filePolicyExt) {
+					policy.Extensions = append(policy.Extensions, strings.ToLower(strings.TrimPrefix(ext, ".")))
+				}
+			}
+		}
+		if filePolicyMime != "" {
+			policy.MimeTypes = nil
+			if !isPolicyNone(filePolicyMime) {
+				policy.MimeTypes = splitPermissionList(strings.ToLower(filePolicyMime))
+			}
+		}
+		if filePolicyScanner != "" {
+			policy.Scanner = ""
+			if !isPolicyNone(filePolicyScanner) {
+				policy.Scanner = strings.TrimSpace(filePolicyScanner)
+			}
+		}
+		if filePolicyClear || policy.IsEmpty() {
+			policy = nil
+		}
+		stash.AttachPolicy = policy
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update attachment policy: %w", err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{"stash": stash.Name, "policy": stash.AttachPolicy}
+		if stash.AttachPolicy == nil {
+			output["policy"] = struct{}{}
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	policy := stash.AttachPolicy
+	if policy.IsEmpty() {
+		fmt.Printf("Stash '%s' accepts any attachment\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Attachment policy for stash '%s':\n", stash.Name)
+	if policy.MaxSize > 0 {
+		fmt.Printf("  max size:   %s\n", model.FormatSize(policy.MaxSize))
+	}
+	if len(policy.Extensions) > 0 {
+		fmt.Printf("  extensions: %s\n", strings.Join(policy.Extensions, ", "))
+	}
+	if len(policy.MimeTypes) > 0 {
+		fmt.Printf("  mime types: %s\n", strings.Join(policy.MimeTypes, ", "))
+	}
+	if policy.Scanner != "" {
+		fmt.Printf("  scanner:    %s\n", policy.Scanner)
+	}
+	return nil
+}
+
+// isPolicyNone reports whether a policy flag value removes its limit.
+func isPolicyNone(value string) bool {
+	return strings.EqualFold(strings.TrimSpace(value), "none")
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/model"
+)
+
+func TestFileStreaming(t *testing.T) {
+	t.Run("attach from stdin and cat back", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		content := "line one\nline two\x00binary\n"
+
+		rootCmd.SetIn(strings.NewReader(content))
+		captureSchemaOutput(t, "file", "attach", id, "--stdin", "--name", "out.log")
+		rootCmd.SetIn(nil)
+		if ExitCode != 0 {
+			t.Fatalf("attach from stdin failed, exit %d", ExitCode)
+		}
+		data, err := os.ReadFile(filepath.Join(tempDir, ".stash", "inventory", "files", id, "out.log"))
+		if err != nil || string(data) != content {
+			t.Errorf("expected the stdin content to be stored, got %q (%v)", data, err)
+		}
+
+		if output := captureSchemaOutput(t, "file", "cat", id, "out.log"); output != content {
+			t.Errorf("expected cat to write the attachment unchanged, got %q", output)
+		}
+
+		if output := captureSchemaOutput(t, "files", id); strings.Contains(output, ".attach-") {
+			t.Errorf("expected no temporary files to be left, got: %s", output)
+		}
+	})
+
+	t.Run("attach a file under another name", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		src := filepath.Join(tempDir, "draft.md")
+		os.WriteFile(src, []byte("# Spec"), 0644)
+
+		captureSchemaOutput(t, "attach", id, src, "--name", "spec.md")
+		if output := captureSchemaOutput(t, "file", "cat", id, "spec.md"); output != "# Spec" {
+			t.Errorf("expected the renamed attachment, got %q", output)
+		}
+	})
+
+	t.Run("rejects invalid usage", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		cases := []struct {
+			args []string
+			code int
+		}{
+			{[]string{"attach", id, "--stdin"}, 2},
+			{[]string{"attach", id, "--stdin", "--name", "../escape"}, 2},
+			{[]string{"attach", id, "--stdin", "--name", "a.log", "--move"}, 2},
+			{[]string{"attach", id}, 2},
+			{[]string{"file", "cat", id, "missing.log"}, 4},
+			{[]string{"file", "cat", "inv-none", "out.log"}, 4},
+		}
+		for _, tc := range cases {
+			ExitCode = 0
+			captureStderr(t, func() {
+				captureSchemaOutput(t, tc.args...)
+			})
+			if ExitCode != tc.code {
+				t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.code, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
+
+func TestFilePolicy(t *testing.T) {
+	t.Run("rejects files outside the policy", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		captureSchemaOutput(t, "file", "policy", "--max-size", "1KB", "--ext", "txt,.PNG", "--mime", "text/*")
+		if ExitCode != 0 {
+			t.Fatalf("failed to set policy, exit %d", ExitCode)
+		}
+
+		var policy struct {
+			Policy map[string]interface{} `json:"policy"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "file", "policy", "--json")), &policy)
+		if policy.Policy["max_size"] != float64(1024) || fmt.Sprint(policy.Policy["extensions"]) != "[txt png]" {
+			t.Errorf("unexpected policy: %v", policy.Policy)
+		}
+
+		write := func(name string, data []byte) string {
+			path := filepath.Join(tempDir, name)
+			os.WriteFile(path, data, 0644)
+			return path
+		}
+		cases := []struct {
+			path string
+			rule string
+		}{
+			{write("big.txt", []byte(strings.Repeat("x", 2048))), model.PolicyMaxSize},
+			{write("tool.exe", []byte("hello")), model.PolicyExtension},
+			{write("fake.png", []byte("%PDF-1.4 not an image")), model.PolicyMimeType},
+		}
+		for _, tc := range cases {
+			ExitCode = 0
+			var result JSONError
+			json.Unmarshal([]byte(captureSchemaOutput(t, "attach", id, tc.path, "--json")), &result)
+			if ExitCode != 2 || result.Code != ErrCodeAttachRejected || result.Details["rule"] != tc.rule {
+				t.Errorf("%s: expected rejection by %s, got exit %d: %+v", filepath.Base(tc.path), tc.rule, ExitCode, result)
+			}
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "attach", id, write("notes.txt", []byte("hello")))
+		if ExitCode != 0 {
+			t.Errorf("expected an allowed file to attach, got exit %d", ExitCode)
+		}
+
+		// Streamed input is limited too, and leaves nothing behind
+		rootCmd.SetIn(strings.NewReader(strings.Repeat("x", 4096)))
+		captureStderr(t, func() {
+			captureSchemaOutput(t, "attach", id, "--stdin", "--name", "stream.txt")
+		})
+		rootCmd.SetIn(nil)
+		if ExitCode != 2 {
+			t.Errorf("expected an oversized stream to be rejected, got exit %d", ExitCode)
+		}
+		ExitCode = 0
+		entries, _ := os.ReadDir(filepath.Join(tempDir, ".stash", "inventory", "files", id))
+		if len(entries) != 1 {
+			t.Errorf("expected only notes.txt to be attached, got %d files", len(entries))
+		}
+
+		captureSchemaOutput(t, "file", "policy", "--clear")
+		captureSchemaOutput(t, "attach", id, filepath.Join(tempDir, "tool.exe"))
+		if ExitCode != 0 {
+			t.Errorf("expected any file to attach after --clear, got exit %d", ExitCode)
+		}
+	})
+
+	t.Run("scanner rejects files", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		script := writeHookScript(t, tempDir, "scan.sh", "if grep -q EICAR \"$1\"; then echo \"$1: Eicar FOUND\"; exit 1; fi\n")
+		captureSchemaOutput(t, "file", "policy", "--scanner", script)
+
+		infected := filepath.Join(tempDir, "infected.txt")
+		os.WriteFile(infected, []byte("X5O EICAR test"), 0644)
+		ExitCode = 0
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "attach", id, infected)
+		})
+		if ExitCode != 2 || !strings.Contains(stderr, "Eicar FOUND") {
+			t.Errorf("expected the scanner to reject, got exit %d: %s", ExitCode, stderr)
+		}
+		ExitCode = 0
+
+		clean := filepath.Join(tempDir, "clean.txt")
+		os.WriteFile(clean, []byte("hello"), 0644)
+		captureSchemaOutput(t, "attach", id, clean)
+		if ExitCode != 0 {
+			t.Errorf("expected a clean file to attach, got exit %d", ExitCode)
+		}
+
+		captureSchemaOutput(t, "file", "policy", "--scanner", "none")
+		if output := captureSchemaOutput(t, "file", "policy"); !strings.Contains(output, "accepts any attachment") {
+			t.Errorf("expected the policy to be removed, got: %s", output)
+		}
+	})
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var filesCmd = &cobra.Command{
@@ -51,7 +50,7 @@ func runFiles(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -117,20 +116,5 @@ func runFiles(cmd *cobra.Command, args []string) error {
 
 // formatSize formats a file size in human-readable format.
 func formatSize(bytes int64) string {
-	const (
-		KB = 1024
-		MB = KB * 1024
-		GB = MB * 1024
-	)
-
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.1f GB", float64(bytes)/GB)
-	case bytes >= MB:
-		return fmt.Sprintf("%.1f MB", float64(bytes)/MB)
-	case bytes >= KB:
-		return fmt.Sprintf("%.1f KB", float64(bytes)/KB)
-	default:
-		return fmt.Sprintf("%d B", bytes)
-	}
+	return model.FormatSize(bytes)
 }
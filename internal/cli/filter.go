@@ -0,0 +1,229 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// noDefaultFilter is --no-default-filter, shared by the commands that
+// apply a stash's default filter.
+var noDefaultFilter bool
+
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Show or manage the stash's default filter",
+	Long: `Show the default filter of the current stash.
+
+A default filter is a set of --where conditions that list, search, count,
+and distinct apply on every call, so records nobody wants to see (e.g.,
+Status = 'archived') don't need the same --where repeated each time. The
+conditions are combined with any --where given, and --no-default-filter
+skips them for one call. Records are never hidden from show, set, export,
+or query, which name records or columns explicitly. As with --where,
+"Status != 'archived'" does not match records with no Status at all.
+
+The filter is stored in the stash's config.json and applies to everyone
+using the stash; for defaults of your own, see 'stash config'.
+
+Examples:
+  stash filter
+  stash filter set "Status != 'archived'"
+  stash filter set "Status != 'archived'" "Owner IS NOT EMPTY"
+  stash filter clear
+  stash list --no-default-filter
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "tasks", "default_filter": ["Status != 'archived'"]}`,
+	Args: cobra.NoArgs,
+	RunE: runFilter,
+}
+
+var filterSetCmd = &cobra.Command{
+	Use:   "set <condition> [condition...]",
+	Short: "Set the default filter",
+	Long: `Replace the default filter with the given conditions, in the format of
+'stash list --where'. A record is listed only if it matches all of them.
+
+Examples:
+  stash filter set "Status != 'archived'"
+  stash filter set "Status != 'archived'" "Priority IS NOT EMPTY"
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (invalid condition)
+
+JSON Output (--json):
+  {"stash": "tasks", "default_filter": ["Status != 'archived'"]}`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runFilterSet,
+}
+
+var filterClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the default filter",
+	Long: `Remove the default filter, so listings show every record again.
+
+Examples:
+  stash filter clear
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "tasks", "removed": ["Status != 'archived'"]}`,
+	Args: cobra.NoArgs,
+	RunE: runFilterClear,
+}
+
+func init() {
+	filterCmd.AddCommand(filterSetCmd)
+	filterCmd.AddCommand(filterClearCmd)
+	rootCmd.AddCommand(filterCmd)
+}
+
+// defaultFilter returns the conditions of the stash's default filter, or
+// none if --no-default-filter was given. Reports a saved condition that no
+// longer parses or names a column that no longer exists, and returns false.
+func defaultFilter(stash *model.Stash) ([]storage.WhereCondition, bool) {
+	if noDefaultFilter {
+		return nil, true
+	}
+	var conditions []storage.WhereCondition
+	for _, clause := range stash.DefaultFilter {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("saved default filter: %v (see 'stash filter')", err), map[string]interface{}{"where": clause})
+			return nil, false
+		}
+		conditions = append(conditions, cond)
+	}
+	if !checkWhereColumns(stash, conditions) {
+		return nil, false
+	}
+	return conditions, true
+}
+
+// noteDefaultFilter tells the user a listing left out the records the
+// stash's default filter hides.
+func noteDefaultFilter(stash *model.Stash) {
+	if noDefaultFilter || len(stash.DefaultFilter) == 0 {
+		return
+	}
+	Infof("Default filter: %s (--no-default-filter to show all)\n", strings.Join(stash.DefaultFilter, " AND "))
+}
+
+func runFilter(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Output result
+	if GetJSONOutput() {
+		filter := stash.DefaultFilter
+		if filter == nil {
+			filter = []string{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "default_filter": filter})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsPorcelain() {
+		for _, clause := range stash.DefaultFilter {
+			printPorcelain(clause)
+		}
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(stash.DefaultFilter) == 0 {
+		fmt.Printf("Stash '%s' has no default filter\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Default filter of stash '%s':\n", stash.Name)
+	for _, clause := range stash.DefaultFilter {
+		fmt.Printf("  %s\n", clause)
+	}
+	return nil
+}
+
+func runFilterSet(cmd *cobra.Command, args []string) error {
+	var conditions []storage.WhereCondition
+	for _, clause := range args {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"where": clause})
+			return nil
+		}
+		conditions = append(conditions, cond)
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !checkWhereColumns(stash, conditions) {
+		return nil
+	}
+
+	stash.DefaultFilter = args
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update default filter: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "default_filter": stash.DefaultFilter})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Set default filter of stash '%s': %s\n", stash.Name, strings.Join(stash.DefaultFilter, " AND "))
+	}
+	return nil
+}
+
+func runFilterClear(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	removed := stash.DefaultFilter
+	if removed == nil {
+		removed = []string{}
+	}
+	if len(removed) > 0 {
+		stash.DefaultFilter = nil
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update default filter: %w", err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "removed": removed})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Cleared default filter of stash '%s'\n", stash.Name)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFilter(t *testing.T) {
+	setup := func(t *testing.T) func() {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status"})
+		for _, status := range []string{"open", "open", "archived"} {
+			rootCmd.SetArgs([]string{"add", "Task", "--set", "Status=" + status})
+			rootCmd.Execute()
+			resetFlags()
+		}
+		rootCmd.SetArgs([]string{"filter", "set", "Status != 'archived'"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+		return cleanup
+	}
+
+	count := func(t *testing.T, args ...string) int {
+		t.Helper()
+		var records []map[string]interface{}
+		output := captureSchemaOutput(t, append(args, "--json")...)
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return len(records)
+	}
+
+	t.Run("listings apply the filter", func(t *testing.T) {
+		cleanup := setup(t)
+		defer cleanup()
+
+		if n := count(t, "list"); n != 2 {
+			t.Errorf("expected 2 records listed, got %d", n)
+		}
+		if n := count(t, "search", "Task"); n != 2 {
+			t.Errorf("expected 2 records found, got %d", n)
+		}
+		if n := count(t, "list", "--where", "Status=archived"); n != 0 {
+			t.Errorf("expected --where to combine with the filter, got %d", n)
+		}
+		output := captureSchemaOutput(t, "count")
+		if strings.TrimSpace(output) != "2" {
+			t.Errorf("expected count 2, got %q", output)
+		}
+	})
+
+	t.Run("--no-default-filter skips it", func(t *testing.T) {
+		cleanup := setup(t)
+		defer cleanup()
+
+		if n := count(t, "list", "--no-default-filter"); n != 3 {
+			t.Errorf("expected 3 records listed, got %d", n)
+		}
+		output := captureSchemaOutput(t, "count", "--no-default-filter")
+		if strings.TrimSpace(output) != "3" {
+			t.Errorf("expected count 3, got %q", output)
+		}
+	})
+
+	t.Run("clear removes it", func(t *testing.T) {
+		cleanup := setup(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"filter", "clear"})
+		rootCmd.Execute()
+		resetFlags()
+		if n := count(t, "list"); n != 3 {
+			t.Errorf("expected 3 records listed, got %d", n)
+		}
+	})
+
+	t.Run("set rejects unknown columns", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"filter", "set", "Status != 'archived'"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+}
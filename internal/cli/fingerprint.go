@@ -0,0 +1,168 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	fingerprintCheck string
+	fingerprintAll   bool
+)
+
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "Hash the logical state of a stash",
+	Long: `Print a fingerprint of the current stash: a SHA-256 hash of its
+schema, the current state of every record (including deleted ones), and
+the contents of its attachments.
+
+The fingerprint is computed from records.jsonl and the attached files,
+never the cache, so two copies of a stash have the same fingerprint exactly
+when they hold the same data. Compare fingerprints to confirm that two
+machines are in sync after a git pull or 'stash sync', or that
+'stash import --full' reproduced a 'stash export --full' faithfully.
+
+With --verbose, the hashes of the schema, records, and attachments are
+shown too, so a mismatch can be traced to one of them.
+
+Examples:
+  stash fingerprint                         # Print the fingerprint
+  stash fingerprint --stash inventory       # Of a specific stash
+  stash fingerprint --all                   # Of every stash
+  stash fingerprint --verbose               # With the hash of each part
+  stash fingerprint --check sha256:9f2c...  # Exit 1 unless it matches
+
+AI Agent Examples:
+  # Compare with another clone
+  [ "$(stash fingerprint)" = "$(ssh build 'cd repo && stash fingerprint')" ] && echo in sync
+
+Exit Codes:
+  0  Success (and the fingerprint matches --check)
+  1  Stash not found, or the fingerprint does not match --check
+  2  Validation error (--check with --all)
+
+JSON Output (--json):
+  {"stash": "inventory", "fingerprint": "sha256:9f2c...", "schema": "sha256:...",
+   "records": "sha256:...", "attachments": "sha256:...", "record_count": 12,
+   "attachment_count": 3, "matches": true}
+  "matches" is only present with --check. --all outputs an array.`,
+	Args: cobra.NoArgs,
+	RunE: runFingerprint,
+}
+
+func init() {
+	fingerprintCmd.Flags().StringVar(&fingerprintCheck, "check", "", "Expected fingerprint; exit 1 if it differs")
+	fingerprintCmd.Flags().BoolVar(&fingerprintAll, "all", false, "Fingerprint every stash")
+	rootCmd.AddCommand(fingerprintCmd)
+}
+
+func runFingerprint(cmd *cobra.Command, args []string) error {
+	if fingerprintAll {
+		return runFingerprintAll()
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	fp, err := store.FingerprintDetail(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to fingerprint stash: %w", err)
+	}
+	matches := fingerprintCheck == "" || fingerprintCheck == fp.Hash
+
+	if GetJSONOutput() {
+		output := FingerprintOutput{StashFingerprint: fp}
+		if fingerprintCheck != "" {
+			output.Matches = &matches
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else {
+		if matches {
+			fmt.Println(fp.Hash)
+		} else {
+			fmt.Printf("%s (expected %s)\n", fp.Hash, fingerprintCheck)
+		}
+		if IsVerbose() {
+			printFingerprintParts(fp)
+		}
+	}
+
+	if !matches {
+		Exit(1)
+	}
+	return nil
+}
+
+// FingerprintOutput is the JSON output of 'stash fingerprint'.
+type FingerprintOutput struct {
+	*storage.StashFingerprint
+	Matches *bool `json:"matches,omitempty"`
+}
+
+// runFingerprintAll prints the fingerprint of every stash.
+func runFingerprintAll() error {
+	if fingerprintCheck != "" {
+		ExitValidationError("--check cannot be used with --all", nil)
+		return nil
+	}
+
+	ctx, err := context.Resolve(GetActorName(), GetStashName())
+	if err != nil {
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+	if ctx.StashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	stashes, err := store.ListStashes()
+	if err != nil {
+		return fmt.Errorf("failed to list stashes: %w", err)
+	}
+	sort.Slice(stashes, func(i, j int) bool { return stashes[i].Name < stashes[j].Name })
+
+	fingerprints := make([]*storage.StashFingerprint, 0, len(stashes))
+	for _, stash := range stashes {
+		fp, err := store.FingerprintDetail(stash.Name)
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint stash '%s': %w", stash.Name, err)
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(fingerprints)
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, fp := range fingerprints {
+		fmt.Printf("%s  %s\n", fp.Hash, fp.Stash)
+		if IsVerbose() {
+			printFingerprintParts(fp)
+		}
+	}
+	return nil
+}
+
+// printFingerprintParts prints the hashes a fingerprint is made of.
+func printFingerprintParts(fp *storage.StashFingerprint) {
+	fmt.Printf("  schema       %s\n", fp.Schema)
+	fmt.Printf("  records      %s (%d)\n", fp.Records, fp.RecordCount)
+	fmt.Printf("  attachments  %s (%d)\n", fp.Attachments, fp.FileCount)
+}
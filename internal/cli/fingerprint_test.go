@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFullExportRoundTrip(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status"})
+	defer cleanup()
+	resetExportFlags()
+	resetImportFlags()
+	defer resetExportFlags()
+	defer resetImportFlags()
+
+	kept := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--set", "Status=open"))
+	removed := strings.TrimSpace(captureSchemaOutput(t, "add", "Old task"))
+	captureSchemaOutput(t, "set", kept, "Status=done")
+	captureSchemaOutput(t, "rm", removed, "--yes")
+	notes := filepath.Join(tempDir, "notes.txt")
+	os.WriteFile(notes, []byte("remember the milk"), 0644)
+	captureSchemaOutput(t, "attach", kept, notes)
+
+	fingerprint := strings.TrimSpace(captureSchemaOutput(t, "fingerprint"))
+	if !strings.HasPrefix(fingerprint, "sha256:") {
+		t.Fatalf("expected a sha256 fingerprint, got %q", fingerprint)
+	}
+
+	exportFile := filepath.Join(tempDir, "tasks.json")
+	captureSchemaOutput(t, "export", exportFile, "--full")
+	resetExportFlags()
+	if ExitCode != 0 {
+		t.Fatalf("export --full failed, exit %d", ExitCode)
+	}
+	original, _ := os.ReadFile(filepath.Join(tempDir, ".stash", "tasks", "records.jsonl"))
+
+	// Import on another "machine"
+	otherDir, otherCleanup := setupTestStashWithColumns(t, "other", "ot-", []string{"Name"})
+	defer otherCleanup()
+
+	captureSchemaOutput(t, "import", exportFile, "--full")
+	resetImportFlags()
+	if ExitCode != 0 {
+		t.Fatalf("import --full failed, exit %d", ExitCode)
+	}
+
+	copied, _ := os.ReadFile(filepath.Join(otherDir, ".stash", "tasks", "records.jsonl"))
+	if !bytes.Equal(original, copied) {
+		t.Errorf("expected records.jsonl to be copied byte for byte")
+	}
+	if got := strings.TrimSpace(captureSchemaOutput(t, "fingerprint", "--stash", "tasks")); got != fingerprint {
+		t.Errorf("expected fingerprint %s after import, got %s", fingerprint, got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(otherDir, ".stash", "tasks", "files", kept, "notes.txt")); string(data) != "remember the milk" {
+		t.Errorf("expected the attachment to be imported, got %q", data)
+	}
+
+	var rec map[string]interface{}
+	json.Unmarshal([]byte(captureSchemaOutput(t, "show", kept, "--stash", "tasks", "--json")), &rec)
+	if rec["Status"] != "done" {
+		t.Errorf("expected the imported record to keep its state, got %v", rec)
+	}
+
+	t.Run("check reports a mismatch", func(t *testing.T) {
+		captureSchemaOutput(t, "fingerprint", "--stash", "tasks", "--check", fingerprint)
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 for a matching fingerprint, got %d", ExitCode)
+		}
+		captureSchemaOutput(t, "set", kept, "Status=open", "--stash", "tasks")
+		captureSchemaOutput(t, "fingerprint", "--stash", "tasks", "--check", fingerprint)
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 after a change, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("refuses an existing stash", func(t *testing.T) {
+		captureSchemaOutput(t, "import", exportFile, "--full")
+		resetImportFlags()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status"})
+	defer cleanup()
+
+	id := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--set", "Status=open"))
+	parts := func() FingerprintOutput {
+		t.Helper()
+		var fp FingerprintOutput
+		output := captureSchemaOutput(t, "fingerprint", "--json")
+		if err := json.Unmarshal([]byte(output), &fp); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return fp
+	}
+	before := parts()
+	if before.RecordCount != 1 || before.Stash != "tasks" {
+		t.Errorf("unexpected fingerprint: %+v", before.StashFingerprint)
+	}
+
+	t.Run("a clone without the cache has the same fingerprint", func(t *testing.T) {
+		cloneDir, cloneCleanup := setupTestEnv(t)
+		defer cloneCleanup()
+		for _, name := range []string{"config.json", "records.jsonl"} {
+			data, _ := os.ReadFile(filepath.Join(tempDir, ".stash", "tasks", name))
+			os.MkdirAll(filepath.Join(cloneDir, ".stash", "tasks"), 0755)
+			os.WriteFile(filepath.Join(cloneDir, ".stash", "tasks", name), data, 0644)
+		}
+		if got := strings.TrimSpace(captureSchemaOutput(t, "fingerprint")); got != before.Hash {
+			t.Errorf("expected %s in the clone, got %s", before.Hash, got)
+		}
+	})
+
+	t.Run("changes show in the part they touch", func(t *testing.T) {
+		captureSchemaOutput(t, "set", id, "Status=done")
+		after := parts()
+		if after.Hash == before.Hash || after.Records == before.Records {
+			t.Errorf("expected a record change to change the records hash")
+		}
+		if after.Schema != before.Schema || after.Attachments != before.Attachments {
+			t.Errorf("expected only the records hash to change")
+		}
+
+		captureSchemaOutput(t, "column", "add", "Owner")
+		if parts().Schema == after.Schema {
+			t.Errorf("expected a new column to change the schema hash")
+		}
+	})
+
+	t.Run("all lists every stash", func(t *testing.T) {
+		captureSchemaOutput(t, "init", "notes", "--prefix", "nt-")
+		output := captureSchemaOutput(t, "fingerprint", "--all")
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if len(lines) != 2 || !strings.HasSuffix(lines[0], "  notes") || !strings.HasSuffix(lines[1], "  tasks") {
+			t.Errorf("expected a line per stash, got: %s", output)
+		}
+
+		ExitCode = 0
+		captureSchemaOutput(t, "fingerprint", "--all", "--check", before.Hash)
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,259 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	grepRegex      bool
+	grepIgnoreCase bool
+	grepAllStashes bool
+	grepFiles      bool
+)
+
+// grepBinarySniff is how much of an attachment is checked for NUL bytes
+// to decide whether it is text.
+const grepBinarySniff = 8000
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Find a pattern in every field of a stash, or of every stash",
+	Long: `Search every column of every record for a pattern and report each
+matching line as stash, record, field, and line number: the "where did I
+put that?" search.
+
+The pattern matches as plain text unless --regex is given, in which case
+it is a Go regular expression (RE2 syntax). Matching is case-sensitive
+unless --ignore-case is given. Multi-line values are matched line by line;
+list values are matched element by element.
+
+By default only the current stash is searched. --all-stashes searches
+every stash in the .stash directory. --files also searches the text of
+the records' attachments; binary attachments are skipped. Deleted
+records are not searched.
+
+Examples:
+  stash grep "invoice 4471"                  # Current stash
+  stash grep "invoice 4471" --all-stashes    # Every stash
+  stash grep -i "tokyo" --all-stashes --files
+  stash grep --regex "INV-[0-9]{4}" --all-stashes
+
+AI Agent Examples:
+  # Which stashes mention a customer?
+  stash grep "$CUSTOMER" --all-stashes --json | jq -r '.[].stash' | sort -u
+
+Exit Codes:
+  0  At least one match
+  1  No matches, or stash not found
+  2  Validation error (invalid regular expression)
+
+Output:
+  inventory:inv-ab12:Notes:2: ordered with invoice 4471
+  inventory:inv-ab12:files/receipt.txt:14: Invoice 4471
+
+JSON Output (--json):
+  [{"stash": "inventory", "record": "inv-ab12", "field": "Notes", "line": 2,
+    "text": "ordered with invoice 4471"},
+   {"stash": "inventory", "record": "inv-ab12", "file": "receipt.txt",
+    "line": 14, "text": "Invoice 4471"}]`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGrep,
+}
+
+func init() {
+	grepCmd.Flags().BoolVar(&grepRegex, "regex", false, "Treat the pattern as a regular expression")
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match regardless of case")
+	grepCmd.Flags().BoolVar(&grepAllStashes, "all-stashes", false, "Search every stash")
+	grepCmd.Flags().BoolVar(&grepFiles, "files", false, "Also search the text of attachments")
+	rootCmd.AddCommand(grepCmd)
+}
+
+// GrepHit is one line that matched 'stash grep'. Exactly one of Field and
+// File is set.
+type GrepHit struct {
+	Stash  string `json:"stash"`
+	Record string `json:"record"`
+	Field  string `json:"field,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	pattern := args[0]
+	if !grepRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if grepIgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		ExitValidationError(fmt.Sprintf("invalid regular expression: %v", err), map[string]interface{}{"pattern": args[0]})
+		return nil
+	}
+
+	var store *storage.Store
+	var stashes []*model.Stash
+	if grepAllStashes {
+		ctx, err := context.Resolve(GetActorName(), GetStashName())
+		if err != nil {
+			return fmt.Errorf("failed to resolve context: %w", err)
+		}
+		if ctx.StashDir == "" {
+			ExitNoStashDir()
+			return nil
+		}
+		store, err = openStore(ctx.StashDir)
+		if err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		stashes, err = store.ListStashes()
+		if err != nil {
+			store.Close()
+			return fmt.Errorf("failed to list stashes: %w", err)
+		}
+		sort.Slice(stashes, func(i, j int) bool { return stashes[i].Name < stashes[j].Name })
+	} else {
+		var stash *model.Stash
+		var ok bool
+		_, store, stash, ok, err = openSchemaStash()
+		if !ok {
+			return err
+		}
+		stashes = []*model.Stash{stash}
+	}
+	defer store.Close()
+
+	hits := []GrepHit{}
+	for _, stash := range stashes {
+		found, err := grepStash(store, stash, re, grepFiles)
+		if err != nil {
+			return err
+		}
+		hits = append(hits, found...)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(hits)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		for _, hit := range hits {
+			where := hit.Field
+			if hit.File != "" {
+				where = "files/" + hit.File
+			}
+			fmt.Printf("%s:%s:%s:%d: %s\n", hit.Stash, hit.Record, where, hit.Line, hit.Text)
+		}
+	}
+
+	if len(hits) == 0 {
+		Exit(1)
+	}
+	return nil
+}
+
+// grepStash returns the lines of a stash's active records, and optionally
+// of their attachments, that match re. Records are searched in ID order,
+// their fields in column order.
+func grepStash(store *storage.Store, stash *model.Stash, re *regexp.Regexp, files bool) ([]GrepHit, error) {
+	records, err := store.ListRecords(stash.Name, storage.ListOptions{ParentID: "*"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records of stash '%s': %w", stash.Name, err)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	var hits []GrepHit
+	active := make(map[string]bool, len(records))
+	for _, record := range records {
+		active[record.ID] = true
+		for _, col := range stash.Columns {
+			value, ok := record.GetField(col.Name)
+			if !ok {
+				continue
+			}
+			line := 0
+			for _, elem := range listElements(value) {
+				for _, text := range strings.Split(elem, "\n") {
+					line++
+					if re.MatchString(text) {
+						hits = append(hits, GrepHit{Stash: stash.Name, Record: record.ID, Field: col.Name, Line: line, Text: text})
+					}
+				}
+			}
+		}
+	}
+	if !files {
+		return hits, nil
+	}
+
+	err = store.WalkAttachments(stash.Name, func(recordID, name, path string) error {
+		if !active[recordID] {
+			return nil
+		}
+		found, err := grepFile(path, re)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s of %s: %w", name, recordID, err)
+		}
+		for _, hit := range found {
+			hit.Stash, hit.Record, hit.File = stash.Name, recordID, name
+			hits = append(hits, hit)
+		}
+		return nil
+	})
+	return hits, err
+}
+
+// grepFile returns the numbered lines of a text file that match re, or
+// nothing if the file looks binary.
+func grepFile(path string, re *regexp.Regexp) ([]GrepHit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, grepBinarySniff)
+	head, err := r.Peek(grepBinarySniff)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var hits []GrepHit
+	for line := 1; ; line++ {
+		text, err := r.ReadString('\n')
+		if text != "" {
+			text = strings.TrimRight(text, "\r\n")
+			if re.MatchString(text) {
+				hits = append(hits, GrepHit{Line: line, Text: text})
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return hits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	t.Run("finds fields and attachments across stashes", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Notes=bought in Tokyo\nreceipt in drawer", "--stash", "inventory")
+		captureSchemaOutput(t, "add", "Desk", "--stash", "inventory")
+		captureSchemaOutput(t, "init", "trips", "--prefix", "tr-")
+		captureSchemaOutput(t, "column", "add", "Name", "--stash", "trips")
+		out := captureSchemaOutput(t, "add", "Tokyo trip", "--stash", "trips", "--json")
+		var trip struct {
+			ID string `json:"_id"`
+		}
+		if err := json.Unmarshal([]byte(out), &trip); err != nil || trip.ID == "" {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		note := filepath.Join(tempDir, "itinerary.txt")
+		os.WriteFile(note, []byte("day 1\nfly to tokyo\n"), 0644)
+		captureSchemaOutput(t, "attach", trip.ID, note, "--stash", "trips")
+		binary := filepath.Join(tempDir, "photo.bin")
+		os.WriteFile(binary, []byte("tokyo\x00\x01"), 0644)
+		captureSchemaOutput(t, "attach", trip.ID, binary, "--stash", "trips")
+
+		out = captureSchemaOutput(t, "grep", "Tokyo", "--stash", "inventory")
+		if ExitCode != 0 || !strings.Contains(out, "inventory:inv-") || !strings.Contains(out, ":Notes:1: bought in Tokyo") {
+			t.Errorf("expected a Notes hit, got %q (exit %d)", out, ExitCode)
+		}
+
+		out = captureSchemaOutput(t, "grep", "-i", "tokyo", "--all-stashes", "--files", "--json")
+		var hits []GrepHit
+		if err := json.Unmarshal([]byte(out), &hits); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		if len(hits) != 3 {
+			t.Fatalf("expected 3 hits, got %+v", hits)
+		}
+		file := hits[2]
+		if file.Stash != "trips" || file.Record != trip.ID || file.File != "itinerary.txt" || file.Line != 2 || file.Text != "fly to tokyo" {
+			t.Errorf("unexpected attachment hit %+v", file)
+		}
+		if hits[1].Stash != "trips" || hits[1].Field != "Name" {
+			t.Errorf("expected the trip name to match, got %+v", hits[1])
+		}
+
+		out = captureSchemaOutput(t, "grep", "--regex", `receipt in \w+`, "--all-stashes")
+		if !strings.Contains(out, ":Notes:2: receipt in drawer") {
+			t.Errorf("expected the second line of Notes, got %q", out)
+		}
+	})
+
+	t.Run("no match exits 1", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop")
+		out := captureSchemaOutput(t, "grep", "Tokyo", "--json")
+		if ExitCode != 1 || strings.TrimSpace(out) != "[]" {
+			t.Errorf("expected exit 1 and no hits, got %q (exit %d)", out, ExitCode)
+		}
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "grep", "--regex", "(")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -33,6 +33,13 @@ All records include these system fields (prefixed with underscore):
   _deleted     true if record is soft-deleted
   _deleted_at  ISO 8601 timestamp of deletion
   _deleted_by  Actor who deleted the record
+  _archived_at ISO 8601 timestamp of archival (hidden from default list)
+  _archived_by Actor who archived the record
+  _assigned_to Agent the record is assigned to (see 'stash assign')
+  _sig         Signature by _updated_by's key (if the actor has a signing key)
+  _source      Where the operation's values came from (see --source)
+  _run         Agent run that made the operation (see --run-id)
+  _coerced     Enum values as given, where a coercing column rewrote them
 
 RECORD JSON FORMAT
 ──────────────────
@@ -107,6 +114,8 @@ EXIT CODES
   2  Validation error (invalid input)
   3  Conflict (duplicate, constraint violation)
   4  Reference error (invalid parent ID)
+  6  Permission denied (actor's permission rule forbids the write)
+  8  Timed out (--timeout or $STASH_TIMEOUT passed)
 
 ERROR RESPONSES
 ───────────────
@@ -230,6 +239,11 @@ Exit codes:
   2 - Validation error
   3 - Conflict
   4 - Reference error
+  6 - Permission denied
+  8 - Timed out
+
+Set $STASH_TIMEOUT (e.g. 30s) so a slow query or stuck disk cannot hang a
+pipeline; the command exits 8 instead.
 
 PERFORMANCE TIPS
 ────────────────
@@ -258,7 +272,157 @@ Related Topics:
 	},
 }
 
+var helpPorcelainCmd = &cobra.Command{
+	Use:   "porcelain",
+	Short: "Porcelain output format and compatibility contract",
+	Long: `Porcelain Output Format
+
+The global --porcelain flag gives list, show, history, and locks a plain
+tab-separated output meant for scripts. Unlike the human-readable output,
+the porcelain format is a compatibility contract: it will not change in a
+way that breaks existing parsers. New fields may only be appended to the
+end of a line, or added as new keys in 'show'.
+
+RULES
+─────
+  - One line per item, fields separated by a single tab.
+  - Backslash, tab, newline, and carriage return in values are escaped
+    as \\, \t, \n, and \r, so every line parses with a plain split.
+  - Timestamps are RFC 3339 in UTC (2026-01-12T10:30:00Z).
+  - Unset values are empty fields.
+  - No headers, counts, colors, or informational lines ("No records
+    found."); an empty result prints nothing.
+  - --json takes precedence over --porcelain.
+
+FORMATS
+───────
+  stash list --porcelain
+    <id> <status> <updated_at> <value>...
+    status is active, archived, or deleted; the values are the --columns
+    in the order given, or the primary column.
+
+  stash show <id>... --porcelain
+    <key> <value>
+    _id, _parent_id, _status, _hash, _created_at, _created_by,
+    _updated_at, _updated_by, then each set field sorted by name, then
+    one _child line per child. Several records are separated by an
+    empty line.
+
+  stash history --porcelain
+    <timestamp> <op> <id> <actor> <branch>
+
+  stash locks --porcelain
+    <id> <agent> <locked_at> <expires_at>
+
+EXAMPLE
+───────
+  stash list --columns Name --porcelain | while IFS=$'\t' read -r id status updated name; do
+      echo "$id: $name"
+  done`,
+}
+
+var helpLoggingCmd = &cobra.Command{
+	Use:   "logging",
+	Short: "Diagnostic logging with --log-level and --log-file",
+	Long: `Diagnostic Logging
+
+To find out why a command misbehaved, run it with --log-level. stash then
+logs structured events about what it did, one per line, to stderr:
+
+  stash set inv-ex4j Status=done --log-level debug
+
+LEVELS
+──────
+  off    No events (the default)
+  error  Failures only
+  warn   Warnings and failures
+  info   Notable events
+  debug  Store operations and decisions: the store and cache opened,
+         each JSONL append or rewrite, cache rebuilds, and record lock
+         checks (unlocked, owned, blocked by another agent, or expired)
+  trace  Everything in debug, plus every SQL statement run against the
+         cache with its arguments and duration, and every file lock
+         taken and released
+
+OUTPUT
+──────
+Events go to stderr as key=value text. With --log-file <path>, they are
+appended to the file as JSON lines instead, at debug level unless
+--log-level says otherwise, so they can be kept and filtered with jq:
+
+  stash list --log-file /tmp/stash.log --log-level trace
+  jq 'select(.msg == "sql") | .query' /tmp/stash.log
+
+$STASH_LOG_LEVEL and $STASH_LOG_FILE set the same options for every
+command, such as those run by an agent or a git hook.
+
+Trace output includes the values of the fields being written. Take care
+when sharing logs of stashes that hold sensitive data.`,
+}
+
+var helpTelemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "OpenTelemetry traces and metrics",
+	Long: `OpenTelemetry Traces and Metrics
+
+stash can report what its commands spend time on to an OpenTelemetry
+collector, so the latency it adds to an agent's work shows up in the same
+observability stack as everything else. It is off unless configured with
+the standard OTEL_* environment variables; nothing is sent otherwise.
+
+  export OTEL_EXPORTER_OTLP_ENDPOINT=http://collector:4318
+  stash set inv-ex4j Status=done
+
+The OTLP exporters are only in builds made with the otlp tag, which keeps
+gRPC and protobuf out of the default binary:
+
+  go build -tags otlp ./cmd/stash
+
+Other builds report an error if OTLP is configured. The console exporter
+is in every build.
+
+TRACES
+──────
+Each command is one trace. Its root span is named after the command
+("stash set") and notes the exit code. Within it:
+
+  Store.<Operation>  Store operations, such as Store.CreateRecord or
+                     Store.ListRecords, with the stash and record ID
+  jsonl.append       An operation appended to records.jsonl
+  jsonl.rewrite      records.jsonl rewritten in full, as by a purge
+  jsonl.read         records.jsonl read in full, as by a cache rebuild
+  sqlite.query       A statement run against the cache, with its SQL but
+                     not its arguments
+
+METRICS
+───────
+  stash.records.written  Operations appended to records.jsonl, by stash
+                         and operation (create, update, delete, ...)
+  stash.cache.rebuilds   Rebuilds of the cache from records.jsonl, by stash
+
+CONFIGURATION
+─────────────
+  OTEL_EXPORTER_OTLP_ENDPOINT    Send traces and metrics over OTLP
+  OTEL_EXPORTER_OTLP_PROTOCOL    http/protobuf (the default) or grpc
+  OTEL_TRACES_EXPORTER           otlp, console, or none
+  OTEL_METRICS_EXPORTER          otlp, console, or none
+  OTEL_SERVICE_NAME              Service name (default: stash)
+  OTEL_RESOURCE_ATTRIBUTES       Extra resource attributes, such as the agent
+  OTEL_TRACES_SAMPLER            Sampling, as for any OpenTelemetry SDK
+  OTEL_SDK_DISABLED=true         Turn telemetry off
+
+The signal-specific variables (OTEL_EXPORTER_OTLP_TRACES_ENDPOINT,
+OTEL_EXPORTER_OTLP_HEADERS, and so on) are honored too. The console
+exporter writes to stderr, leaving stdout to the command.
+
+Telemetry is flushed when the command exits, waiting at most 5 seconds for
+the collector.`,
+}
+
 func init() {
+	helpTopicsCmd.AddCommand(helpLoggingCmd)
+	helpTopicsCmd.AddCommand(helpTelemetryCmd)
+	helpTopicsCmd.AddCommand(helpPorcelainCmd)
 	helpTopicsCmd.AddCommand(helpJSONCmd)
 	helpTopicsCmd.AddCommand(helpAgentsCmd)
 	rootCmd.AddCommand(helpTopicsCmd)
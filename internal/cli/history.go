@@ -2,26 +2,60 @@
 package cli
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var (
-	historyBy    string
-	historySince string
-	historyLimit int
+	historyBy     string
+	historyRun    string
+	historySince  string
+	historyLimit  int
+	historyField  string
+	historyFormat string
+	historyColumn string
 )
 
+// Formats for the value series of 'stash history <id> --field'.
+const (
+	historyFormatTable     = "table"
+	historyFormatCSV       = "csv"
+	historyFormatSparkline = "sparkline"
+)
+
+// sparkBlocks are the bar heights used to draw a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// fieldChange is one value of a field in a record's history.
+type fieldChange struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Value     interface{} `json:"value"`
+	Actor     string      `json:"actor"`
+	Op        string      `json:"op"`
+}
+
+// columnChange is an operation that changed a column's value, from the
+// value before it to the value after.
+type columnChange struct {
+	Timestamp time.Time   `json:"timestamp"`
+	ID        string      `json:"id"`
+	Op        string      `json:"op"`
+	Actor     string      `json:"actor"`
+	Before    interface{} `json:"before"`
+	After     interface{} `json:"after"`
+}
+
 var historyCmd = &cobra.Command{
 	Use:   "history [id]",
 	Short: "Show change history",
@@ -32,24 +66,75 @@ for that specific record.
 
 Options:
   --by <actor>     Filter by actor (who made the change)
+  --run <id>       Filter by agent run (see --run-id)
   --since <dur>    Filter by time (e.g., 24h, 7d, 1w)
   --limit <n>      Limit to N most recent changes
+  --field <name>   With an ID, show how one field's value changed over time
+  --format <fmt>   Format for --field: table (default), csv, or sparkline
+  --column <name>  Show only the changes to one column, with the values
+                   before and after
+
+Field history:
+  --field lists each operation that changed the field's value, oldest
+  first: its timestamp, the new value, and who changed it. An unset value
+  is empty. The sparkline format draws numeric values as bars scaled from
+  the lowest to the highest value, followed by the range; it needs every
+  set value to be a number.
+
+Column history:
+  --column lists the operations that changed a column's value, in the
+  stash or, with an ID, in one record, most recent first: the record, the
+  operation, who made it, and the value before and after. Operations that
+  leave the column as it was are left out, so a field's lifecycle can be
+  audited without unrelated updates. A record's create is a change from
+  unset when it sets the column. --by, --run, --since, and --limit apply
+  to the changes; the value before is the record's value at the time,
+  whoever set it. A dropped column's changes can still be listed.
 
 Examples:
   stash history                    # All recent changes
   stash history inv-ex4j           # Changes for specific record
   stash history --by alice         # Changes by alice
+  stash history --run run-42       # Everything agent run run-42 did
   stash history --since 24h        # Changes in last 24 hours
   stash history --limit 50         # Last 50 changes
-  stash history --json             # JSON output`,
+  stash history --json             # JSON output
+  stash history inv-ex4j --field Price                    # Price changes
+  stash history inv-ex4j --field Price --format sparkline # ▁▃▂▅█ 10 .. 42
+  stash history inv-ex4j --field Price --format csv > price.csv
+  stash history --column Status                 # Every Status change
+  stash history --column Status --since 7d --by alice
+
+AI Agent Examples:
+  # Get a field's values over time without a jq pipeline
+  stash history inv-ex4j --field Stock --json | jq '.[].value'
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Invalid duration, format, or --field without an ID; non-numeric
+     values for a sparkline; --column with --field
+  4  Record not found
+
+JSON Output (--field --json):
+  [{"timestamp": "2025-01-08T10:30:00Z", "value": "10", "actor": "alice",
+    "op": "create"}]
+
+JSON Output (--column --json):
+  [{"timestamp": "2025-01-08T10:30:00Z", "id": "inv-ex4j", "op": "update",
+    "actor": "alice", "before": "open", "after": "done"}]`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runHistory,
 }
 
 func init() {
 	historyCmd.Flags().StringVar(&historyBy, "by", "", "Filter by actor")
+	historyCmd.Flags().StringVar(&historyRun, "run", "", "Filter by agent run (see --run-id)")
 	historyCmd.Flags().StringVar(&historySince, "since", "", "Filter by time (e.g., 24h, 7d)")
 	historyCmd.Flags().IntVar(&historyLimit, "limit", 0, "Limit results (0 = no limit)")
+	historyCmd.Flags().StringVar(&historyField, "field", "", "Show the values of one field over time (requires an ID)")
+	historyCmd.Flags().StringVar(&historyFormat, "format", "", "Format for --field: table, csv, or sparkline")
+	historyCmd.Flags().StringVar(&historyColumn, "column", "", "Show only changes to one column, with before and after values")
 	rootCmd.AddCommand(historyCmd)
 }
 
@@ -85,6 +170,30 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		recordID = args[0]
 	}
 
+	if historyColumn != "" && historyField != "" {
+		ExitValidationError("--column and --field cannot be used together", nil)
+		return nil
+	}
+	if historyFormat != "" && historyField == "" {
+		ExitValidationError("--format requires --field", nil)
+		return nil
+	}
+	if historyField != "" {
+		if recordID == "" {
+			ExitValidationError("--field requires a record ID", nil)
+			return nil
+		}
+		switch historyFormat {
+		case "":
+			historyFormat = historyFormatTable
+		case historyFormatTable, historyFormatCSV, historyFormatSparkline:
+		default:
+			ExitValidationError(fmt.Sprintf("invalid --format '%s' (valid formats: table, csv, sparkline)", historyFormat),
+				map[string]interface{}{"format": historyFormat})
+			return nil
+		}
+	}
+
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
 	if err != nil {
@@ -102,14 +211,14 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Verify stash exists
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -140,6 +249,9 @@ func runHistory(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to get history: %w", err)
 		}
 	}
+	// Changes to a column are found in the whole history, so the value
+	// before a change is known when the operation before it is filtered out
+	all := history
 
 	// AC-03: Filter by actor
 	if historyBy != "" {
@@ -152,6 +264,11 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		history = filtered
 	}
 
+	// Filter by run
+	if historyRun != "" {
+		history = runOperations(history, historyRun)
+	}
+
 	// AC-04: Filter by time
 	if historySince != "" {
 		duration, err := parseDuration(historySince)
@@ -170,6 +287,29 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		history = filtered
 	}
 
+	if historyField != "" {
+		// Use the column's name case; a dropped column's values can still
+		// be found in the log
+		field := historyField
+		if col := stash.Columns.Find(field); col != nil {
+			field = col.Name
+		} else if !historyHasField(history, field) {
+			ExitColumnNotFound(field, stash.Columns)
+			return nil
+		}
+		return outputFieldHistory(field, fieldHistory(history, field))
+	}
+	if historyColumn != "" {
+		column := historyColumn
+		if col := stash.Columns.Find(column); col != nil {
+			column = col.Name
+		} else if !historyHasField(all, column) {
+			ExitColumnNotFound(column, stash.Columns)
+			return nil
+		}
+		return outputColumnHistory(column, columnHistory(all, history, column))
+	}
+
 	// Sort by timestamp (most recent first)
 	sort.Slice(history, func(i, j int) bool {
 		return history[i].UpdatedAt.After(history[j].UpdatedAt)
@@ -195,6 +335,15 @@ func runHistory(cmd *cobra.Command, args []string) error {
 			if rec.Branch != "" {
 				entry["_branch"] = rec.Branch
 			}
+			if rec.AssignedTo != "" {
+				entry["_assigned_to"] = rec.AssignedTo
+			}
+			if rec.RunID != "" {
+				entry["_run"] = rec.RunID
+			}
+			if len(rec.Coerced) > 0 {
+				entry["_coerced"] = rec.Coerced
+			}
 			// Include primary field if available
 			for k, v := range rec.Fields {
 				entry[k] = v
@@ -209,9 +358,16 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if IsPorcelain() {
+		for _, rec := range history {
+			printPorcelain(porcelainTime(rec.UpdatedAt), rec.Operation, rec.ID, rec.UpdatedBy, rec.Branch)
+		}
+		return nil
+	}
+
 	// Human-readable output
 	if len(history) == 0 {
-		fmt.Println("No history found.")
+		Infof("No history found.\n")
 		return nil
 	}
 
@@ -247,7 +403,242 @@ func runHistory(cmd *cobra.Command, args []string) error {
 			timestamp, op, id, actor, branch)
 	}
 
-	fmt.Printf("\n%d change(s)\n", len(history))
+	Infof("\n%d change(s)\n", len(history))
+
+	return nil
+}
+
+// runOperations returns the operations in history made by the agent run.
+func runOperations(history []*model.Record, run string) []*model.Record {
+	filtered := make([]*model.Record, 0)
+	for _, rec := range history {
+		if rec.RunID == run {
+			filtered = append(filtered, rec)
+		}
+	}
+	return filtered
+}
+
+// historyHasField returns true if any operation in the history sets the
+// field.
+func historyHasField(history []*model.Record, field string) bool {
+	for _, rec := range history {
+		if _, ok := rec.Fields[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldHistory returns the changes to a field's value in a record's
+// history, oldest first. Operations that leave the value as it was are
+// skipped. With --limit only the most recent changes are kept.
+func fieldHistory(history []*model.Record, field string) []fieldChange {
+	ops := make([]*model.Record, len(history))
+	copy(ops, history)
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].UpdatedAt.Before(ops[j].UpdatedAt)
+	})
+
+	changes := []fieldChange{}
+	var last string
+	for i, rec := range ops {
+		value := rec.Fields[field]
+		text := valueText(value)
+		if i > 0 && text == last {
+			continue
+		}
+		last = text
+		changes = append(changes, fieldChange{
+			Timestamp: rec.UpdatedAt,
+			Value:     value,
+			Actor:     rec.UpdatedBy,
+			Op:        rec.Operation,
+		})
+	}
+
+	if historyLimit > 0 && len(changes) > historyLimit {
+		changes = changes[len(changes)-historyLimit:]
+	}
+	return changes
+}
+
+// columnHistory returns the operations in history that changed a column's
+// value, most recent first, with the values before and after. Changes are
+// found in all, each record's operations in order, and only the
+// operations in history are kept. With --limit only the most recent
+// changes are kept.
+func columnHistory(all, history []*model.Record, column string) []columnChange {
+	keep := make(map[*model.Record]bool, len(history))
+	for _, rec := range history {
+		keep[rec] = true
+	}
+	ops := make([]*model.Record, len(all))
+	copy(ops, all)
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].UpdatedAt.Before(ops[j].UpdatedAt)
+	})
+
+	changes := []columnChange{}
+	last := make(map[string]interface{})
+	for _, rec := range ops {
+		before := last[rec.ID]
+		after := rec.Fields[column]
+		last[rec.ID] = after
+		if valueText(before) == valueText(after) || !keep[rec] {
+			continue
+		}
+		changes = append(changes, columnChange{
+			Timestamp: rec.UpdatedAt,
+			ID:        rec.ID,
+			Op:        rec.Operation,
+			Actor:     rec.UpdatedBy,
+			Before:    before,
+			After:     after,
+		})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Timestamp.After(changes[j].Timestamp)
+	})
+	if historyLimit > 0 && len(changes) > historyLimit {
+		changes = changes[:historyLimit]
+	}
+	return changes
+}
 
+// outputColumnHistory prints the changes to a column.
+func outputColumnHistory(column string, changes []columnChange) error {
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if IsPorcelain() {
+		for _, c := range changes {
+			printPorcelain(porcelainTime(c.Timestamp), c.Op, c.ID, c.Actor, valueText(c.Before), valueText(c.After))
+		}
+		return nil
+	}
+
+	if len(changes) == 0 {
+		Infof("No changes to %s found.\n", column)
+		return nil
+	}
+	t := newTable(
+		tableColumn{Header: "Timestamp", System: true},
+		tableColumn{Header: "ID", System: true},
+		tableColumn{Header: "Op", System: true},
+		tableColumn{Header: "Actor", Max: 20},
+		tableColumn{Header: "Before"},
+		tableColumn{Header: "After"},
+	)
+	for _, c := range changes {
+		t.addRow(c.Timestamp.Format("2006-01-02 15:04:05"), c.ID, c.Op, c.Actor, valueText(c.Before), valueText(c.After))
+	}
+	t.print()
+	Infof("\n%d change(s) to %s\n", len(changes), column)
 	return nil
 }
+
+// outputFieldHistory prints the changes to a field in the --format.
+func outputFieldHistory(field string, changes []fieldChange) error {
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	switch historyFormat {
+	case historyFormatCSV:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"timestamp", "value", "actor", "op"})
+		for _, c := range changes {
+			w.Write([]string{c.Timestamp.Format(time.RFC3339), valueText(c.Value), c.Actor, c.Op})
+		}
+		w.Flush()
+		return w.Error()
+
+	case historyFormatSparkline:
+		values := make([]*float64, len(changes))
+		for i, c := range changes {
+			text := strings.TrimSpace(valueText(c.Value))
+			if text == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				ExitValidationError(fmt.Sprintf("cannot draw a sparkline: '%s' is not a number", text),
+					map[string]interface{}{"column": field, "value": c.Value, "timestamp": c.Timestamp})
+				return nil
+			}
+			values[i] = &n
+		}
+		line, low, high, ok := sparkline(values)
+		if !ok {
+			Infof("No numeric values for %s.\n", field)
+			return nil
+		}
+		fmt.Printf("%s  %s  %s .. %s (%d change(s))\n", field, line,
+			strconv.FormatFloat(low, 'f', -1, 64), strconv.FormatFloat(high, 'f', -1, 64), len(changes))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		Infof("No history found.\n")
+		return nil
+	}
+	t := newTable(
+		tableColumn{Header: "Timestamp", System: true},
+		tableColumn{Header: field},
+		tableColumn{Header: "Actor", Max: 20},
+		tableColumn{Header: "Op", System: true},
+	)
+	for _, c := range changes {
+		t.addRow(c.Timestamp.Format("2006-01-02 15:04:05"), valueText(c.Value), c.Actor, c.Op)
+	}
+	t.print()
+	Infof("\n%d change(s)\n", len(changes))
+	return nil
+}
+
+// sparkline draws values as bars scaled between the lowest and highest
+// value, with a space for each nil value. Returns false if no value is
+// set.
+func sparkline(values []*float64) (line string, low, high float64, ok bool) {
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if !ok || *v < low {
+			low = *v
+		}
+		if !ok || *v > high {
+			high = *v
+		}
+		ok = true
+	}
+	if !ok {
+		return "", 0, 0, false
+	}
+
+	var b strings.Builder
+	top := len(sparkBlocks) - 1
+	for _, v := range values {
+		switch {
+		case v == nil:
+			b.WriteRune(' ')
+		case high == low:
+			b.WriteRune(sparkBlocks[top/2])
+		default:
+			b.WriteRune(sparkBlocks[int((*v-low)/(high-low)*float64(top)+0.5)])
+		}
+	}
+	return b.String(), low, high, true
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -362,3 +363,189 @@ func TestUC_QRY_004_ViewChangeHistory_MustNot(t *testing.T) {
 		}
 	})
 }
+
+func TestHistoryField(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Notes"})
+	defer cleanup()
+
+	var rec map[string]interface{}
+	json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--set", "Price=10", "--json")), &rec)
+	id := rec["_id"].(string)
+	for _, args := range [][]string{
+		{"set", id, "Price=30"},
+		{"set", id, "Notes=unrelated"}, // leaves Price as it was
+		{"set", id, "Price=20"},
+		{"set", id, "price=40"},
+	} {
+		captureSchemaOutput(t, args...)
+	}
+
+	t.Run("json lists each change oldest first", func(t *testing.T) {
+		var changes []map[string]interface{}
+		output := captureSchemaOutput(t, "history", id, "--field", "Price", "--json")
+		if err := json.Unmarshal([]byte(output), &changes); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		var values []interface{}
+		for _, c := range changes {
+			values = append(values, c["value"])
+		}
+		if fmt.Sprint(values) != "[10 30 20 40]" {
+			t.Errorf("expected values [10 30 20 40], got %v", values)
+		}
+		if changes[0]["op"] != "create" {
+			t.Errorf("expected first change to be the create, got %v", changes[0])
+		}
+	})
+
+	t.Run("limit keeps the most recent changes", func(t *testing.T) {
+		var changes []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "history", id, "--field", "Price", "--limit", "2", "--json")), &changes)
+		if len(changes) != 2 || changes[0]["value"] != "20" || changes[1]["value"] != "40" {
+			t.Errorf("expected the last two changes, got %v", changes)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		output := captureSchemaOutput(t, "history", id, "--field", "Price", "--format", "csv")
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if len(lines) != 5 || lines[0] != "timestamp,value,actor,op" || !strings.Contains(lines[1], ",10,") {
+			t.Errorf("unexpected CSV:\n%s", output)
+		}
+	})
+
+	t.Run("sparkline", func(t *testing.T) {
+		output := captureSchemaOutput(t, "history", id, "--field", "Price", "--format", "sparkline")
+		if !strings.Contains(output, "▁▆▃█") || !strings.Contains(output, "10 .. 40") {
+			t.Errorf("unexpected sparkline: %q", output)
+		}
+	})
+
+	t.Run("sparkline rejects text values", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "history", id, "--field", "Name", "--format", "sparkline")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("invalid usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"history", "--field", "Price"},
+			{"history", id, "--format", "csv"},
+			{"history", id, "--field", "Price", "--format", "svg"},
+		} {
+			ExitCode = 0
+			captureSchemaOutput(t, args...)
+			if ExitCode != 2 {
+				t.Errorf("%v: expected exit code 2, got %d", args, ExitCode)
+			}
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "history", id, "--field", "Missing")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for an unknown column, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestHistoryColumn(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status", "Notes"})
+	defer cleanup()
+
+	add := func(name, status string) string {
+		var rec map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", name, "--set", "Status="+status, "--json")), &rec)
+		return rec["_id"].(string)
+	}
+	first := add("Write spec", "open")
+	second := add("Review", "open")
+	for _, args := range [][]string{
+		{"set", first, "Notes=draft"}, // leaves Status as it was
+		{"set", first, "Status=doing"},
+		{"set", second, "Notes=later"},
+		{"set", first, "Status=done", "--actor", "bob"},
+	} {
+		captureSchemaOutput(t, args...)
+	}
+
+	changes := func(t *testing.T, args ...string) []columnChange {
+		t.Helper()
+		var got []columnChange
+		output := captureSchemaOutput(t, append([]string{"history", "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return got
+	}
+	describe := func(changes []columnChange) string {
+		var parts []string
+		for _, c := range changes {
+			parts = append(parts, fmt.Sprintf("%s:%v->%v", c.Op, c.Before, c.After))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	t.Run("lists only changes to the column, most recent first", func(t *testing.T) {
+		got := changes(t, "--column", "status")
+		if d := describe(got); d != "update:doing->done update:open->doing create:<nil>->open create:<nil>->open" {
+			t.Errorf("unexpected changes %s", d)
+		}
+		if got[0].ID != first || got[0].Actor != "bob" {
+			t.Errorf("expected bob's change to %s first, got %+v", first, got[0])
+		}
+	})
+
+	t.Run("with an ID", func(t *testing.T) {
+		if d := describe(changes(t, second, "--column", "Status")); d != "create:<nil>->open" {
+			t.Errorf("unexpected changes %s", d)
+		}
+	})
+
+	t.Run("filters keep the value before", func(t *testing.T) {
+		if d := describe(changes(t, "--column", "Status", "--by", "bob")); d != "update:doing->done" {
+			t.Errorf("unexpected changes %s", d)
+		}
+		if d := describe(changes(t, "--column", "Status", "--limit", "1")); d != "update:doing->done" {
+			t.Errorf("unexpected changes %s", d)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		output := captureSchemaOutput(t, "history", "--column", "Status")
+		if !strings.Contains(output, "Before") || !strings.Contains(output, "doing") || strings.Contains(output, "draft") {
+			t.Errorf("unexpected output:\n%s", output)
+		}
+	})
+
+	t.Run("invalid usage", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "history", first, "--column", "Status", "--field", "Status")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 with --field, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "history", "--column", "Missing")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for an unknown column, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestSparkline(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	line, low, high, ok := sparkline([]*float64{f(1), nil, f(5), f(3)})
+	if !ok || line != "▁ █▅" || low != 1 || high != 5 {
+		t.Errorf("sparkline = %q, %v, %v, %v", line, low, high, ok)
+	}
+	if line, _, _, _ := sparkline([]*float64{f(2), f(2)}); line != "▄▄" {
+		t.Errorf("expected flat sparkline, got %q", line)
+	}
+	if _, _, _, ok := sparkline([]*float64{nil}); ok {
+		t.Error("expected no sparkline without values")
+	}
+}
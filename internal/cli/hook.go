@@ -0,0 +1,564 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bytes"
+	gocontext "context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	hookColumn string
+	hookRevoke bool
+)
+
+// hookTimeout bounds how long a validation hook may run before the value
+// is rejected.
+var hookTimeout = 30 * time.Second
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Show or manage validation hook commands",
+	Long: `Show the validation hooks of a stash.
+
+A validation hook is a shell command that decides whether a write is
+allowed, for rules stash cannot express itself. The command reads the
+candidate as JSON on stdin and exits non-zero to reject it; the first line
+of its stderr (or stdout) becomes the error message.
+
+Column hooks judge one column's value. They run when the column is set,
+and their result is cached per distinct value for the rest of the
+command, so a bulk import calls the hook once per value rather than once
+per record. A column hook must therefore decide on the value alone.
+
+The stash hook judges whole records and runs on every write.
+
+Hooks run for add, set, import, and 'stash validate'. A column hook
+follows the column's severity: with --severity warning its rejections are
+reported as warnings. Set STASH_NO_HOOKS=1 to skip hooks.
+
+Hooks only run once trusted on this machine. Hooks are stored in
+config.json and travel with the .stash directory, so a clone or a pull
+could otherwise run any command it brings. The hooks you set with 'stash
+hook set' or 'stash column add --hook' are trusted for you; others are
+skipped with a warning until you review them and run 'stash hook trust'.
+Trust is kept per .stash directory and command in the user config
+directory (~/.config/stash, or $STASH_CONFIG_DIR), like git keeps its
+hooks out of the repository. A script a trusted command runs is not
+checked, so review changes to hook scripts as you would any code.
+
+Hook input (stdin):
+  {"stash": "contacts", "column": "Email", "value": "a@example.com",
+   "record": {"_id": "ct-ex4j", "Name": "Alice", "Email": "a@example.com", ...}}
+
+Environment:
+  STASH_STASH, STASH_ID, and STASH_COLUMN (column hooks only)
+
+Examples:
+  stash hook
+  stash hook set "./scripts/check-email.sh" --column Email
+  stash hook set "python3 scripts/check_order.py"
+  stash hook trust
+  stash hook remove --column Email
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "contacts", "validate_hook": "python3 scripts/check_order.py",
+   "columns": [{"column": "Email", "hook": "./scripts/check-email.sh"}],
+   "untrusted": ["python3 scripts/check_order.py"]}`,
+	Args: cobra.NoArgs,
+	RunE: runHook,
+}
+
+var hookSetCmd = &cobra.Command{
+	Use:   "set <command>",
+	Short: "Set a validation hook command",
+	Long: `Set the validation hook of a column (--column) or of the stash.
+
+The command runs with 'sh -c' in the current directory, and is trusted
+on this machine (see 'stash hook'). Existing records are not checked when
+a hook is set; run 'stash validate' to find the ones it rejects.
+
+Examples:
+  stash hook set "./scripts/check-email.sh" --column Email
+  stash hook set "python3 scripts/check_order.py"
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (empty command, computed column)
+
+JSON Output (--json):
+  {"stash": "contacts", "column": "Email", "hook": "./scripts/check-email.sh"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHookSet,
+}
+
+var hookRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a validation hook command",
+	Long: `Remove the validation hook of a column (--column) or of the stash.
+
+Examples:
+  stash hook remove --column Email
+  stash hook remove
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found, or no hook is set
+
+JSON Output (--json):
+  {"stash": "contacts", "column": "Email", "removed": "./scripts/check-email.sh"}`,
+	Args: cobra.NoArgs,
+	RunE: runHookRemove,
+}
+
+var hookTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Allow a stash's validation hooks to run on this machine",
+	Long: `Trust the validation hooks a stash has now, so they run on this machine.
+
+Review the commands first ('stash hook') and the scripts they run: a
+trusted hook runs with your permissions on every write. Hooks changed or
+added later, for example by a pull, need trusting again. With --revoke,
+stop the stash's hooks from running.
+
+Examples:
+  stash hook trust
+  stash hook trust --revoke
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or the stash has no hooks
+
+JSON Output (--json):
+  {"stash": "contacts", "trusted": ["./scripts/check-email.sh"]}
+  {"stash": "contacts", "revoked": ["./scripts/check-email.sh"]}`,
+	Args: cobra.NoArgs,
+	RunE: runHookTrust,
+}
+
+func init() {
+	hookTrustCmd.Flags().BoolVar(&hookRevoke, "revoke", false, "Stop the stash's hooks from running")
+	hookCmd.AddCommand(hookTrustCmd)
+	hookSetCmd.Flags().StringVar(&hookColumn, "column", "", "Column whose values the hook validates (default: the whole record)")
+	hookRemoveCmd.Flags().StringVar(&hookColumn, "column", "", "Column whose hook to remove (default: the stash hook)")
+	hookCmd.AddCommand(hookSetCmd)
+	hookCmd.AddCommand(hookRemoveCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+func runHook(cmd *cobra.Command, args []string) error {
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	type columnHook struct {
+		Column string `json:"column"`
+		Hook   string `json:"hook"`
+	}
+	columns := []columnHook{}
+	for _, col := range stash.Columns {
+		if col.Hook != "" {
+			columns = append(columns, columnHook{col.Name, col.Hook})
+		}
+	}
+
+	trust, err := context.LoadHookTrust()
+	if err != nil {
+		return fmt.Errorf("failed to read trusted hooks: %w", err)
+	}
+	untrusted := []string{}
+	for _, command := range stashHooks(stash) {
+		if !trust.Trusted(ctx.StashDir, command) {
+			untrusted = append(untrusted, command)
+		}
+	}
+	mark := func(command string) string {
+		if !trust.Trusted(ctx.StashDir, command) {
+			return command + "  (not trusted)"
+		}
+		return command
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{"stash": stash.Name, "columns": columns, "untrusted": untrusted}
+		if stash.ValidateHook != "" {
+			output["validate_hook"] = stash.ValidateHook
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if stash.ValidateHook == "" && len(columns) == 0 {
+		fmt.Printf("Stash '%s' has no validation hooks\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Validation hooks for stash '%s':\n", stash.Name)
+	if stash.ValidateHook != "" {
+		fmt.Printf("  (record): %s\n", mark(stash.ValidateHook))
+	}
+	for _, c := range columns {
+		fmt.Printf("  %s: %s\n", c.Column, mark(c.Hook))
+	}
+	if len(untrusted) > 0 {
+		fmt.Println("Hooks not trusted on this machine do not run; review them, then run 'stash hook trust'")
+	}
+	return nil
+}
+
+func runHookSet(cmd *cobra.Command, args []string) error {
+	command := strings.TrimSpace(args[0])
+	if command == "" {
+		ExitValidationError("hook command cannot be empty", nil)
+		return nil
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	output := map[string]interface{}{"stash": stash.Name, "hook": command}
+	if hookColumn != "" {
+		col := resolveColumn(stash, hookColumn)
+		if col == nil {
+			return nil
+		}
+		if col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set, so it has nothing to validate", col.Name),
+				map[string]interface{}{"column": col.Name})
+			return nil
+		}
+		col.Hook = command
+		output["column"] = col.Name
+	} else {
+		stash.ValidateHook = command
+	}
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update hooks: %w", err)
+	}
+	if err := trustHooks(ctx.StashDir, command); err != nil {
+		return err
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if column, ok := output["column"]; ok {
+			fmt.Printf("Set validation hook for column '%s'\n", column)
+		} else {
+			fmt.Printf("Set validation hook for stash '%s'\n", stash.Name)
+		}
+	}
+	return nil
+}
+
+func runHookRemove(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	output := map[string]interface{}{"stash": stash.Name}
+	var removed string
+	if hookColumn != "" {
+		col := resolveColumn(stash, hookColumn)
+		if col == nil {
+			return nil
+		}
+		removed, col.Hook = col.Hook, ""
+		output["column"] = col.Name
+	} else {
+		removed, stash.ValidateHook = stash.ValidateHook, ""
+	}
+	if removed == "" {
+		ExitWithError(1, ErrCodeValidation, "no validation hook is set", output)
+		return nil
+	}
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update hooks: %w", err)
+	}
+	output["removed"] = removed
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed validation hook: %s\n", removed)
+	}
+	return nil
+}
+
+func runHookTrust(cmd *cobra.Command, args []string) error {
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	commands := stashHooks(stash)
+	if len(commands) == 0 {
+		ExitWithError(1, ErrCodeValidation, fmt.Sprintf("stash '%s' has no validation hooks", stash.Name), nil)
+		return nil
+	}
+
+	trust, err := context.LoadHookTrust()
+	if err != nil {
+		return fmt.Errorf("failed to read trusted hooks: %w", err)
+	}
+	key, verb := "trusted", "Trusted"
+	if hookRevoke {
+		trust.Revoke(ctx.StashDir, commands...)
+		key, verb = "revoked", "Revoked trust in"
+	} else {
+		trust.Trust(ctx.StashDir, commands...)
+	}
+	if err := context.SaveHookTrust(trust); err != nil {
+		return fmt.Errorf("failed to save trusted hooks: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, key: commands})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("%s %d validation hook(s) of stash '%s'\n", verb, len(commands), stash.Name)
+	}
+	return nil
+}
+
+// stashHooks returns the distinct hook commands of a stash.
+func stashHooks(stash *model.Stash) []string {
+	var commands []string
+	add := func(command string) {
+		if command == "" {
+			return
+		}
+		for _, c := range commands {
+			if c == command {
+				return
+			}
+		}
+		commands = append(commands, command)
+	}
+	add(stash.ValidateHook)
+	for _, col := range stash.Columns {
+		add(col.Hook)
+	}
+	return commands
+}
+
+// trustHooks trusts hook commands for a .stash directory, for hooks the
+// user sets here.
+func trustHooks(stashDir string, commands ...string) error {
+	trust, err := context.LoadHookTrust()
+	if err != nil {
+		return fmt.Errorf("failed to read trusted hooks: %w", err)
+	}
+	trust.Trust(stashDir, commands...)
+	if err := context.SaveHookTrust(trust); err != nil {
+		return fmt.Errorf("failed to save trusted hooks: %w", err)
+	}
+	return nil
+}
+
+// hookInput is the JSON a validation hook reads on stdin.
+type hookInput struct {
+	Stash  string        `json:"stash"`
+	Column string        `json:"column,omitempty"`
+	Value  interface{}   `json:"value,omitempty"`
+	Record *model.Record `json:"record"`
+}
+
+// The hook state of the running command, forgotten by resetHookState.
+var (
+	// hookCache holds hook results, keyed by hookCacheKey. An empty
+	// result means the hook accepted.
+	hookCache = make(map[string]string)
+	// hookTrust and hookTrustDir are the trusted hooks and the .stash
+	// directory they are checked for, loaded on first use.
+	hookTrust    *context.HookTrust
+	hookTrustDir string
+	// hookWarned holds the stashes whose untrusted hooks were reported.
+	hookWarned = make(map[string]bool)
+)
+
+// resetHookState forgets the hook results and trust of the previous
+// command, so a hook or script changed between the commands of a session
+// is run and trusted afresh.
+func resetHookState() {
+	hookCache = make(map[string]string)
+	hookTrust, hookTrustDir = nil, ""
+	hookWarned = make(map[string]bool)
+}
+
+// hookTrusted reports whether a hook command is trusted to run for the
+// .stash directory the command works in.
+func hookTrusted(command string) bool {
+	if hookTrust == nil {
+		trust, err := context.LoadHookTrust()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; validation hooks were not run\n", err)
+			trust = &context.HookTrust{}
+		}
+		hookTrust = trust
+		if ctx, _ := context.Resolve(GetActorName(), GetStashName()); ctx != nil {
+			hookTrustDir = ctx.StashDir
+		}
+	}
+	return hookTrustDir != "" && hookTrust.Trusted(hookTrustDir, command)
+}
+
+// warnUntrustedHooks reports, once per command, that a stash's hooks were
+// skipped because they are not trusted.
+func warnUntrustedHooks(stash *model.Stash) {
+	if hookWarned[stash.Name] {
+		return
+	}
+	hookWarned[stash.Name] = true
+	fmt.Fprintf(os.Stderr, "Warning: validation hooks of stash '%s' are not trusted on this machine and were not run; review them with 'stash hook', then run 'stash hook trust'\n", stash.Name)
+}
+
+// hookCacheKey identifies a hook call by its command and the hash of the
+// input it decides on.
+func hookCacheKey(command string, parts ...interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(command))
+	for _, part := range parts {
+		data, _ := json.Marshal(part)
+		h.Write([]byte{0})
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hooksDisabled reports whether STASH_NO_HOOKS turns hooks off.
+func hooksDisabled() bool {
+	return os.Getenv("STASH_NO_HOOKS") != ""
+}
+
+// runValidationHook runs a hook command and returns its rejection
+// message, or "" if it accepted the input.
+func runValidationHook(command string, input hookInput) string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal hook input: %v", err)
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), hookTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	hook := exec.CommandContext(ctx, "sh", "-c", command)
+	hook.Stdin = bytes.NewReader(data)
+	hook.Stdout = &stdout
+	hook.Stderr = &stderr
+	hook.Env = append(os.Environ(), "STASH_STASH="+input.Stash, "STASH_COLUMN="+input.Column)
+	if input.Record != nil {
+		hook.Env = append(hook.Env, "STASH_ID="+input.Record.ID)
+	}
+
+	err = hook.Run()
+	if err == nil {
+		return ""
+	}
+	if errors.Is(ctx.Err(), gocontext.DeadlineExceeded) {
+		return fmt.Sprintf("validation hook timed out after %s", hookTimeout)
+	}
+	for _, out := range []string{stderr.String(), stdout.String()} {
+		if line, _, _ := strings.Cut(strings.TrimSpace(out), "\n"); line != "" {
+			return line
+		}
+	}
+	return fmt.Sprintf("rejected by validation hook (%v)", err)
+}
+
+// ValidateHooks runs the stash's validation hooks on a record. With
+// columns given, only the hooks of those columns run, along with the
+// stash hook.
+func ValidateHooks(stash *model.Stash, record *model.Record, columns []string) *ValidationResult {
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	if hooksDisabled() {
+		return result
+	}
+
+	report := func(v ValidationError) {
+		v.RecordID = record.ID
+		if v.Severity == model.SeverityWarning {
+			result.Warnings = append(result.Warnings, v)
+			return
+		}
+		result.Valid = false
+		result.Errors = append(result.Errors, v)
+	}
+
+	for i := range stash.Columns {
+		col := &stash.Columns[i]
+		if col.Hook == "" || (columns != nil && !model.ContainsFold(columns, col.Name)) {
+			continue
+		}
+		if !hookTrusted(col.Hook) {
+			warnUntrustedHooks(stash)
+			continue
+		}
+		value := record.Fields[col.Name]
+		key := hookCacheKey(col.Hook, col.Name, value)
+		message, cached := hookCache[key]
+		if !cached {
+			message = runValidationHook(col.Hook, hookInput{Stash: stash.Name, Column: col.Name, Value: value, Record: record})
+			hookCache[key] = message
+		}
+		if message != "" {
+			report(ValidationError{
+				Column:   col.Name,
+				Value:    fmt.Sprintf("%v", value),
+				Rule:     "hook",
+				Message:  fmt.Sprintf("column '%s' rejected by hook: %s", col.Name, message),
+				Severity: col.ViolationSeverity(),
+			})
+		}
+	}
+
+	if stash.ValidateHook != "" && !hookTrusted(stash.ValidateHook) {
+		warnUntrustedHooks(stash)
+	} else if stash.ValidateHook != "" {
+		if message := runValidationHook(stash.ValidateHook, hookInput{Stash: stash.Name, Record: record}); message != "" {
+			report(ValidationError{
+				Rule:     "hook",
+				Message:  fmt.Sprintf("record rejected by hook: %s", message),
+				Severity: model.SeverityError,
+			})
+		}
+	}
+
+	return result
+}
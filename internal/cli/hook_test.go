@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+// writeHookScript writes an executable shell script for use as a hook.
+func writeHookScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatalf("failed to write hook script: %v", err)
+	}
+	return path
+}
+
+func TestHook(t *testing.T) {
+	t.Run("column hook rejects values", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name", "Email"})
+		defer cleanup()
+
+		script := writeHookScript(t, tempDir, "check-email.sh",
+			"grep -q '\"value\":\"[^\"]*@' || { echo 'not an email address' >&2; exit 1; }\n")
+		captureSchemaOutput(t, "hook", "set", script, "--column", "email")
+		if ExitCode != 0 {
+			t.Fatalf("failed to set hook, exit %d", ExitCode)
+		}
+
+		var hooks struct {
+			Columns []map[string]string `json:"columns"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "hook", "--json")), &hooks)
+		if len(hooks.Columns) != 1 || hooks.Columns[0]["column"] != "Email" {
+			t.Errorf("unexpected hooks: %v", hooks.Columns)
+		}
+
+		ExitCode = 0
+		output := captureSchemaOutput(t, "add", "Alice", "--set", "Email=alice", "--json")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a rejected value, got %d: %s", ExitCode, output)
+		}
+		ExitCode = 0
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Alice", "--set", "Email=alice@example.com"))
+		if ExitCode != 0 || id == "" {
+			t.Fatalf("expected accepted value to be added, got exit %d", ExitCode)
+		}
+
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "set", id, "Email=bob")
+		})
+		if ExitCode != 2 || !strings.Contains(stderr, "not an email address") {
+			t.Errorf("expected the hook's message, got exit %d: %s", ExitCode, stderr)
+		}
+		ExitCode = 0
+
+		// Sets of other columns do not run the column hook
+		captureSchemaOutput(t, "set", id, "Name=Alicia")
+		if ExitCode != 0 {
+			t.Errorf("expected an unrelated set to succeed, got exit %d", ExitCode)
+		}
+
+		captureSchemaOutput(t, "hook", "remove", "--column", "Email")
+		captureSchemaOutput(t, "set", id, "Email=bob")
+		if ExitCode != 0 {
+			t.Errorf("expected set to succeed after removing the hook, got exit %d", ExitCode)
+		}
+	})
+
+	t.Run("stash hook sees the whole record", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "orders", "ord-", []string{"Name", "Qty"})
+		defer cleanup()
+
+		script := writeHookScript(t, tempDir, "check-order.sh",
+			"if grep -q '\"Qty\":\"0\"'; then echo \"order $STASH_ID has no quantity\"; exit 1; fi\n")
+		captureSchemaOutput(t, "hook", "set", script)
+
+		ExitCode = 0
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Order 1", "--set", "Qty=0")
+		})
+		if ExitCode != 2 || !strings.Contains(stderr, "has no quantity") {
+			t.Errorf("expected the stash hook to reject, got exit %d: %s", ExitCode, stderr)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("import calls the hook once per value", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Category"})
+		defer cleanup()
+
+		counter := filepath.Join(tempDir, "calls")
+		script := writeHookScript(t, tempDir, "check-category.sh",
+			"echo x >> '"+counter+"'\nif grep -q '\"value\":\"bogus\"'; then echo 'unknown category' >&2; exit 1; fi\n")
+		captureSchemaOutput(t, "hook", "set", script, "--column", "Category")
+
+		csvFile := filepath.Join(tempDir, "products.csv")
+		os.WriteFile(csvFile, []byte("Name,Category\nLaptop,tech\nMouse,tech\nCable,tech\nGadget,bogus\n"), 0644)
+		resetImportFlags()
+		captureStderr(t, func() {
+			captureSchemaOutput(t, "import", csvFile, "--confirm")
+		})
+		resetImportFlags()
+
+		calls, _ := os.ReadFile(counter)
+		if n := strings.Count(string(calls), "x"); n != 2 {
+			t.Errorf("expected 2 hook calls for 2 distinct values, got %d", n)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		if len(records) != 3 {
+			t.Errorf("expected the rejected record to be skipped, got %d records", len(records))
+		}
+	})
+
+	t.Run("warning severity hooks do not block", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name"})
+		defer cleanup()
+
+		script := writeHookScript(t, tempDir, "reject.sh", "echo 'looks wrong' >&2; exit 1\n")
+		captureSchemaOutput(t, "column", "add", "Phone", "--hook", script, "--severity", "warning")
+
+		ExitCode = 0
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Alice", "--set", "Phone=123")
+		})
+		if ExitCode != 0 || !strings.Contains(stderr, "looks wrong") {
+			t.Errorf("expected a warning and success, got exit %d: %s", ExitCode, stderr)
+		}
+	})
+
+	t.Run("STASH_NO_HOOKS skips hooks", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name"})
+		defer cleanup()
+
+		script := writeHookScript(t, tempDir, "reject.sh", "exit 1\n")
+		captureSchemaOutput(t, "hook", "set", script)
+		t.Setenv("STASH_NO_HOOKS", "1")
+
+		ExitCode = 0
+		captureSchemaOutput(t, "add", "Alice")
+		if ExitCode != 0 {
+			t.Errorf("expected hooks to be skipped, got exit %d", ExitCode)
+		}
+	})
+
+	t.Run("untrusted hooks do not run", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name"})
+		defer cleanup()
+
+		script := writeHookScript(t, tempDir, "reject.sh", "echo 'rejected' >&2; exit 1\n")
+		captureSchemaOutput(t, "hook", "set", script)
+		captureSchemaOutput(t, "hook", "trust", "--revoke")
+
+		var hooks struct {
+			Untrusted []string `json:"untrusted"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "hook", "--json")), &hooks)
+		if len(hooks.Untrusted) != 1 || hooks.Untrusted[0] != script {
+			t.Errorf("expected the hook to be listed as untrusted, got %v", hooks.Untrusted)
+		}
+
+		ExitCode = 0
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Alice")
+		})
+		if ExitCode != 0 || !strings.Contains(stderr, "not trusted") {
+			t.Errorf("expected the untrusted hook to be skipped with a warning, got exit %d: %s", ExitCode, stderr)
+		}
+
+		captureSchemaOutput(t, "hook", "trust")
+		captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Bob")
+		})
+		if ExitCode != 2 {
+			t.Errorf("expected the trusted hook to reject, got exit %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("each command runs hooks afresh", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name", "Email"})
+		defer cleanup()
+
+		deny := filepath.Join(tempDir, "deny")
+		script := writeHookScript(t, tempDir, "check.sh", "if [ -e '"+deny+"' ]; then echo 'denied' >&2; exit 1; fi\n")
+		captureSchemaOutput(t, "hook", "set", script, "--column", "Email")
+
+		captureSchemaOutput(t, "add", "Alice", "--set", "Email=a@example.com")
+		if ExitCode != 0 {
+			t.Fatalf("expected the first add to succeed, got exit %d", ExitCode)
+		}
+		os.WriteFile(deny, nil, 0644)
+		captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Alice", "--set", "Email=a@example.com")
+		})
+		if ExitCode != 2 {
+			t.Errorf("expected the hook to run again and reject, got exit %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,27 +25,109 @@ var (
 	importDryRun  bool
 	importColumn  string
 	importFormat  string
+	importSQLite  bool
+	importTable   string
+
+	importAnalyze      bool
+	importCreateSchema bool
+	importSample       int
+	importFull         bool
+
+	importStrict  bool
+	importLenient bool
+
+	importSync  bool
+	importKey   string
+	importForce bool
 )
 
 var importCmd = &cobra.Command{
-	Use:   "import <file>",
-	Short: "Import records from a file",
-	Long: `Import records from a CSV, JSON, or JSONL file.
+	Use:   "import <file|url>",
+	Short: "Import records from a file or URL",
+	Long: `Import records from a CSV, JSON, or JSONL file, or a table of a SQLite
+database. The file may be an http:// or https:// URL.
 
 The file format is auto-detected from the extension, or can be specified
 with --format. CSV is the default.
 
+For SQLite databases (.db, .sqlite, .sqlite3, or --sqlite):
+- --table names the table or view to read; it may be left out when the
+  database has only one
+- NULL values are left unset, and list columns take JSON arrays
+- Databases written by 'stash export --sqlite' import their stash
+  columns; the system columns (_id, _created_at, ...) are skipped
+
 For CSV files:
 - The first row must be column headers
 - Missing columns will be created automatically
 - The first column (or --column) is used as the primary value
 
+With --analyze, the file is sampled and a schema is proposed instead of
+importing: a validation type for each column whose values are all numbers,
+dates, emails, or URLs, and an enum for text columns with a few repeated
+values. --create-schema creates the proposed columns (only those the stash
+does not have); without --analyze, the records are then imported into them.
+
 Examples:
   stash import products.csv                 # Interactive import
   stash import products.csv --confirm       # Skip confirmation
   stash import products.csv --dry-run       # Preview changes
   stash import products.csv --column Name   # Use Name as primary column
-  stash import products.json --format json  # Import JSON array`,
+  stash import products.json --format json  # Import JSON array
+  stash import shop.db --table products     # Import a SQLite table
+  stash import products.csv --analyze       # Propose a typed schema
+  stash import products.csv --analyze --create-schema  # Create it only
+  stash import products.csv --create-schema --confirm  # Create it and import
+  stash import backup.json --full           # Recreate a stash from 'stash export --full'
+  stash import feed.jsonl --strict --confirm   # All lines valid, or nothing
+  stash import feed.jsonl --lenient --confirm  # Skip bad lines into feed.jsonl.rejects
+  stash import https://example.com/products.csv --confirm
+  stash import products.csv --sync --key SKU --confirm  # Update by SKU, add new SKUs
+
+JSONL files from elsewhere can be checked line by line before importing:
+each line must be a JSON object whose values pass their columns'
+validation and the stash's validation hooks.
+  --strict    Import nothing if any line fails; every failure is reported
+  --lenient   Skip the failed lines and write them, each with its line
+              number and reason, to <file>.rejects
+Without either, a line that is not JSON fails the whole import, and
+records rejected by a hook are skipped with an error message.
+
+A URL is downloaded into .stash/_remote/, and its format is taken from the
+response's content type, then the URL. A Google Sheet's edit link is
+read as its CSV export, so the sheet must be shared with anyone who has
+the link; a "publish to the web" CSV link works as is. Once an import of
+a URL succeeds, its ETag and Last-Modified date are kept for the stash,
+and the next import into it asks the server for changes only.
+
+With --sync, the file updates the stash instead of adding to it: records
+are matched to its rows by the column named by --key. A row whose key
+matches a record updates the fields that differ; a row whose key matches
+none adds a record. Records whose key is not in the file are left alone.
+Rows without a key, whose key several records share, or whose record
+fails validation, makes an illegal status transition (unless --force), or
+is locked are skipped with an error message. Syncing
+a URL that has not changed since it was last synced into the stash, with
+no rows skipped, does nothing.
+  stash import https://docs.google.com/spreadsheets/d/<id>/edit#gid=0 \
+    --sync --key SKU --confirm
+
+With --full, the file must be a full export. A new stash is created under
+the exported name with the same records, IDs, hashes, history, and
+attachments, and its fingerprint is checked against the export's (see
+'stash fingerprint'). The stash must not already exist.
+
+Exit Codes:
+  0  Success
+  1  File or stash not found, the URL cannot be downloaded or is larger
+     than 256 MB, the file cannot be parsed, the table is missing, the
+     stash to create with --full exists, or its fingerprint does not match
+  2  Validation error (negative --sample, --table without a SQLite
+     database, --strict or --lenient without a JSONL file, --strict and
+     --lenient together, --strict found an invalid line, --sync without
+     --key or with --analyze, --key not a column of the file, or --full
+     with a URL)
+  7  A write quota stopped the import (records before it stay imported)`,
 	Args: cobra.ExactArgs(1),
 	RunE: runImport,
 }
@@ -53,19 +136,54 @@ func init() {
 	importCmd.Flags().BoolVar(&importConfirm, "confirm", false, "Skip confirmation prompt")
 	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview what would be imported")
 	importCmd.Flags().StringVar(&importColumn, "column", "", "Specify primary column name")
-	importCmd.Flags().StringVar(&importFormat, "format", "", "File format: csv, json, jsonl (default: auto-detect)")
+	importCmd.Flags().StringVar(&importFormat, "format", "", "File format: csv, json, jsonl, sqlite (default: auto-detect)")
+	importCmd.Flags().BoolVar(&importSQLite, "sqlite", false, "Read a table of a SQLite database (same as --format sqlite)")
+	importCmd.Flags().StringVar(&importTable, "table", "", "SQLite table to import (default: the only table)")
+	importCmd.Flags().BoolVar(&importAnalyze, "analyze", false, "Propose a schema from the file instead of importing")
+	importCmd.Flags().BoolVar(&importCreateSchema, "create-schema", false, "Create new columns with inferred types and enums")
+	importCmd.Flags().IntVar(&importSample, "sample", 1000, "Records to sample for --analyze and --create-schema (0 = all)")
+	importCmd.Flags().BoolVar(&importFull, "full", false, "Recreate a stash from a 'stash export --full' file")
+	importCmd.Flags().BoolVar(&importStrict, "strict", false, "Import nothing if any JSONL line is invalid")
+	importCmd.Flags().BoolVar(&importLenient, "lenient", false, "Skip invalid JSONL lines into <file>.rejects")
+	importCmd.Flags().BoolVar(&importSync, "sync", false, "Update records matched by --key and add the rest")
+	importCmd.Flags().StringVar(&importKey, "key", "", "Column that matches rows to records with --sync")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Allow illegal status transitions with --sync")
 	rootCmd.AddCommand(importCmd)
 }
 
 func runImport(cmd *cobra.Command, args []string) error {
 	filename := args[0]
+	if importSample < 0 {
+		ExitValidationError("--sample must not be negative", map[string]interface{}{"sample": importSample})
+		return nil
+	}
+	if !checkJSONLMode(importStrict, importLenient) {
+		return nil
+	}
+	checkLines := importStrict || importLenient
+	if importSync != (importKey != "") {
+		ExitValidationError("--sync and --key must be used together", map[string]interface{}{"sync": importSync, "key": importKey})
+		return nil
+	}
+	if importSync && importAnalyze {
+		ExitValidationError("--sync cannot be used with --analyze", nil)
+		return nil
+	}
 
-	// Check file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
+	// Check file exists; a URL is downloaded once the stash is known
+	remote := isRemoteImport(filename)
+	if remote && importFull {
+		ExitValidationError("--full imports a file, not a URL", map[string]interface{}{"url": filename})
+		return nil
+	}
+	if _, err := os.Stat(filename); !remote && os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: file '%s' not found\n", filename)
 		Exit(1)
 		return nil
 	}
+	if importFull {
+		return runImportFull(filename)
+	}
 
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -84,7 +202,7 @@ func runImport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -101,8 +219,36 @@ func runImport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	// Download a URL, and read the copy
+	source := filename
+	var fetched *remoteImport
+	if remote {
+		fetched, err = fetchRemoteImport(ctx.StashDir, stash.Name, sheetsCSVURL(filename))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(1)
+			return nil
+		}
+		if fetched.NotModified && fetched.Synced() && importSync {
+			if GetJSONOutput() {
+				data, _ := json.MarshalIndent(map[string]interface{}{"up_to_date": true, "source": source}, "", "  ")
+				fmt.Println(string(data))
+			} else if !IsQuiet() {
+				fmt.Printf("%s has not changed since the last sync\n", source)
+			}
+			return nil
+		}
+		filename = fetched.Path
+	}
+
 	// Detect format
 	format := importFormat
+	if importSQLite {
+		format = "sqlite"
+	}
+	if format == "" && fetched != nil {
+		format = fetched.Format
+	}
 	if format == "" {
 		ext := strings.ToLower(filepath.Ext(filename))
 		switch ext {
@@ -112,15 +258,26 @@ func runImport(cmd *cobra.Command, args []string) error {
 			format = "json"
 		case ".jsonl":
 			format = "jsonl"
+		case ".db", ".sqlite", ".sqlite3":
+			format = "sqlite"
 		default:
 			format = "csv" // Default to CSV
 		}
 	}
 	format = strings.ToLower(format)
+	if importTable != "" && format != "sqlite" {
+		ExitValidationError("--table applies only to SQLite databases", map[string]interface{}{"table": importTable, "format": format})
+		return nil
+	}
+	if checkLines && format != "jsonl" {
+		ExitValidationError("--strict and --lenient apply only to JSONL files", map[string]interface{}{"format": format})
+		return nil
+	}
 
 	// Parse file
 	var columns []string
 	var records []map[string]interface{}
+	var jsonl *jsonlRecords
 
 	switch format {
 	case "csv":
@@ -128,9 +285,17 @@ func runImport(cmd *cobra.Command, args []string) error {
 	case "json":
 		columns, records, err = parseJSON(filename)
 	case "jsonl":
-		columns, records, err = parseJSONL(filename)
+		if checkLines {
+			if jsonl, err = readJSONL(filename); err == nil {
+				columns, records = jsonl.Columns, jsonl.Records
+			}
+		} else {
+			columns, records, err = parseJSONL(filename)
+		}
+	case "sqlite":
+		columns, records, err = parseSQLite(filename, importTable)
 	default:
-		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (must be csv, json, or jsonl)\n", format)
+		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (must be csv, json, jsonl, or sqlite)\n", format)
 		Exit(1)
 		return nil
 	}
@@ -141,12 +306,59 @@ func runImport(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// With --strict or --lenient, check every line before importing any
+	var rejected []lineSkip
+	rejectsFile := ""
+	if jsonl != nil {
+		records, rejected = checkImportLines(stash, jsonl)
+		if len(rejected) > 0 {
+			if importStrict {
+				exitStrictRejects(filename, rejected)
+				return nil
+			}
+			if !importDryRun && !importAnalyze {
+				rejectsFile = rejectsPath(filename)
+				if err := writeImportRejects(filename, rejectsFile, rejected); err != nil {
+					return err
+				}
+			}
+			if !GetJSONOutput() && !IsQuiet() {
+				fmt.Fprintf(os.Stderr, "Skipping %d invalid line(s):\n", len(rejected))
+				printLineSkips(os.Stderr, rejected)
+				if rejectsFile != "" {
+					fmt.Fprintf(os.Stderr, "  Wrote them to %s\n", rejectsFile)
+				}
+			}
+		}
+	}
+
 	if len(records) == 0 {
 		fmt.Fprintln(os.Stderr, "No records to import")
 		Exit(0)
 		return nil
 	}
 
+	// Infer a schema from the data
+	var analysis *ImportAnalysis
+	if importAnalyze || importCreateSchema {
+		analysis = analyzeImport(stash, columns, records, importSample)
+		analysis.File, analysis.Format = source, format
+	}
+	if importAnalyze {
+		if importCreateSchema {
+			if !checkPermission(stash, ctx.Actor, model.PermCreate, columns) {
+				return nil
+			}
+			if err := createImportSchema(store, ctx.Stash, ctx.Actor, analysis); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				Exit(1)
+				return nil
+			}
+		}
+		printImportAnalysis(analysis)
+		return nil
+	}
+
 	// Determine primary column
 	primaryColumn := importColumn
 	if primaryColumn == "" {
@@ -155,6 +367,22 @@ func runImport(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Find the key column among the file's
+	keyColumn := ""
+	if importSync {
+		for _, col := range columns {
+			if strings.EqualFold(col, importKey) {
+				keyColumn = col
+				break
+			}
+		}
+		if keyColumn == "" {
+			ExitValidationError(fmt.Sprintf("key column '%s' is not a column of the file", importKey),
+				map[string]interface{}{"key": importKey, "columns": columns})
+			return nil
+		}
+	}
+
 	// Check which columns need to be created
 	var missingColumns []string
 	for _, col := range columns {
@@ -167,11 +395,14 @@ func runImport(cmd *cobra.Command, args []string) error {
 	if !importConfirm && !GetJSONOutput() {
 		fmt.Println("Import Preview")
 		fmt.Println("==============")
-		fmt.Printf("File: %s\n", filename)
+		fmt.Printf("File: %s\n", source)
 		fmt.Printf("Format: %s\n", format)
 		fmt.Printf("Records: %d\n", len(records))
 		fmt.Printf("Columns: %s\n", strings.Join(columns, ", "))
 		fmt.Printf("Primary column: %s\n", primaryColumn)
+		if importSync {
+			fmt.Printf("Sync key: %s\n", keyColumn)
+		}
 
 		if len(missingColumns) > 0 {
 			fmt.Printf("New columns to create: %s\n", strings.Join(missingColumns, ", "))
@@ -199,6 +430,13 @@ func runImport(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermCreate, columns) {
+		return nil
+	}
+	if importSync && !checkPermission(stash, ctx.Actor, model.PermUpdate, columns) {
+		return nil
+	}
+
 	// Dry run mode
 	if importDryRun {
 		if GetJSONOutput() {
@@ -209,6 +447,12 @@ func runImport(cmd *cobra.Command, args []string) error {
 				"new_columns":     missingColumns,
 				"primary_column":  primaryColumn,
 			}
+			if jsonl != nil {
+				output["rejected"] = rejected
+			}
+			if importSync {
+				output["sync_key"] = keyColumn
+			}
 			data, _ := json.MarshalIndent(output, "", "  ")
 			fmt.Println(string(data))
 		} else {
@@ -230,13 +474,16 @@ func runImport(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Create missing columns
+	// Create missing columns, typed as inferred with --create-schema
 	for _, colName := range missingColumns {
 		col := model.Column{
 			Name:    colName,
 			Added:   time.Now(),
 			AddedBy: ctx.Actor,
 		}
+		if proposal := analysis.Proposal(colName); proposal != nil {
+			col.Validate, col.Enum = proposal.Validate, proposal.Enum
+		}
 		if err := store.AddColumn(ctx.Stash, col); err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating column '%s': %v\n", colName, err)
 			Exit(1)
@@ -250,6 +497,39 @@ func runImport(cmd *cobra.Command, args []string) error {
 	// Refresh stash to get updated columns
 	stash, _ = store.GetStash(ctx.Stash)
 
+	if importSync {
+		result, err := syncImportRecords(ctx, store, stash, columns, records, keyColumn)
+		if err != nil {
+			if exitWriteRefused(err) {
+				return nil
+			}
+			return err
+		}
+		if fetched != nil {
+			// A sync that skipped rows is not done with this download
+			if err := fetched.save(result.Skipped == 0); err != nil {
+				return err
+			}
+		}
+		if GetJSONOutput() {
+			output := map[string]interface{}{
+				"added":       result.Added,
+				"updated":     result.Updated,
+				"unchanged":   result.Unchanged,
+				"skipped":     result.Skipped,
+				"total":       len(records),
+				"new_columns": len(missingColumns),
+				"key":         keyColumn,
+			}
+			data, _ := json.MarshalIndent(output, "", "  ")
+			fmt.Println(string(data))
+		} else if !IsQuiet() {
+			fmt.Printf("Synced %d record(s) by %s: %d added, %d updated, %d unchanged\n",
+				len(records), keyColumn, result.Added, result.Updated, result.Unchanged)
+		}
+		return nil
+	}
+
 	// Import records
 	imported := 0
 	for i, rec := range records {
@@ -261,35 +541,51 @@ func runImport(cmd *cobra.Command, args []string) error {
 
 		// Create record
 		now := time.Now()
-		recordID, err := model.GenerateID(stash.Prefix)
+		recordID, err := store.NextRecordID(ctx.Stash)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating ID for record %d: %v\n", i+1, err)
 			continue
 		}
+		fields, coerced := importFields(stash, columns, rec)
 		record := &model.Record{
 			ID:        recordID,
 			CreatedAt: now,
 			CreatedBy: ctx.Actor,
 			UpdatedAt: now,
 			UpdatedBy: ctx.Actor,
-			Fields:    make(map[string]interface{}),
+			Fields:    fields,
+			Coerced:   coerced,
 		}
 
-		// Set fields
-		for _, col := range columns {
-			if val, ok := rec[col]; ok {
-				record.Fields[col] = val
-			}
+		// Run the stash's validation hooks; rejected records are skipped
+		hookResult := ValidateHooks(stash, record, nil)
+		if !hookResult.Valid {
+			fmt.Fprintf(os.Stderr, "Error importing record %d (%s): %s\n", i+1, primaryVal, hookResult.Errors[0].Message)
+			continue
 		}
+		printValidationWarnings(hookResult.Warnings)
 
 		// Create the record
 		if err := store.CreateRecord(ctx.Stash, record); err != nil {
+			// A quota stops the import; the records before it stay imported
+			if errors.Is(err, model.ErrThrottled) || errors.Is(err, model.ErrQuotaExceeded) {
+				if !GetJSONOutput() {
+					fmt.Fprintf(os.Stderr, "Imported %d of %d record(s) before stopping\n", imported, len(records))
+				}
+				exitWriteRefused(err)
+				return nil
+			}
 			fmt.Fprintf(os.Stderr, "Error importing record %d (%s): %v\n", i+1, primaryVal, err)
 			// Continue with other records
 			continue
 		}
 		imported++
 	}
+	if fetched != nil {
+		if err := fetched.save(false); err != nil {
+			return err
+		}
+	}
 
 	// Output result
 	if GetJSONOutput() {
@@ -298,6 +594,12 @@ func runImport(cmd *cobra.Command, args []string) error {
 			"total":        len(records),
 			"new_columns":  len(missingColumns),
 		}
+		if jsonl != nil {
+			output["rejected"] = len(rejected)
+			if rejectsFile != "" {
+				output["rejects_file"] = rejectsFile
+			}
+		}
 		data, _ := json.MarshalIndent(output, "", "  ")
 		fmt.Println(string(data))
 	} else if !IsQuiet() {
@@ -387,29 +689,56 @@ func parseJSON(filename string) ([]string, []map[string]interface{}, error) {
 	return columns, records, nil
 }
 
+// parseSQLite reads a table of a SQLite database and returns columns and
+// records.
+func parseSQLite(filename, table string) ([]string, []map[string]interface{}, error) {
+	data, err := storage.ReadSQLiteTable(filename, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data.Columns, data.Rows, nil
+}
+
 // parseJSONL reads a JSONL file (newline-delimited JSON) and returns columns and records.
 func parseJSONL(filename string) ([]string, []map[string]interface{}, error) {
-	file, err := os.Open(filename)
+	data, err := readJSONL(filename)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer file.Close()
+	if len(data.Skipped) > 0 {
+		return nil, nil, fmt.Errorf("line %d: %s", data.Skipped[0].Line, data.Skipped[0].Reason)
+	}
+	return data.Columns, data.Records, nil
+}
 
-	var records []map[string]interface{}
-	columnSet := make(map[string]bool)
+// jsonlRecords is a JSONL file of records, read line by line.
+type jsonlRecords struct {
+	Columns []string
+	Records []map[string]interface{}
+	Lines   []int      // the line number of each record
+	Skipped []lineSkip // lines that are not JSON objects
+}
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+// readJSONL reads a JSONL file, skipping the lines that are not JSON
+// objects.
+func readJSONL(filename string) (*jsonlRecords, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
+	data := &jsonlRecords{}
+	columnSet := make(map[string]bool)
+	err = scanJSONL(file, func(lineNum int, line []byte) error {
 		var rec map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &rec); err != nil {
-			return nil, nil, fmt.Errorf("failed to parse JSON at line %d: %w", lineNum, err)
+		if err := json.Unmarshal(line, &rec); err != nil {
+			data.Skipped = append(data.Skipped, lineSkip{Line: lineNum, Reason: fmt.Sprintf("invalid JSON: %v", err)})
+			return nil
+		}
+		if rec == nil {
+			data.Skipped = append(data.Skipped, lineSkip{Line: lineNum, Reason: "not a JSON object"})
+			return nil
 		}
 
 		// Collect columns
@@ -418,19 +747,175 @@ func parseJSONL(filename string) ([]string, []map[string]interface{}, error) {
 				columnSet[key] = true
 			}
 		}
+		data.Records = append(data.Records, rec)
+		data.Lines = append(data.Lines, lineNum)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		records = append(records, rec)
+	for col := range columnSet {
+		data.Columns = append(data.Columns, col)
 	}
+	return data, nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, nil, fmt.Errorf("error reading file: %w", err)
+// importFields returns the fields of the record imported from rec, and
+// the values coercing enum columns were given where they were coerced.
+// CSV cells of list columns hold comma-separated elements. Coercions are
+// not noted one by one, as an import may make thousands.
+func importFields(stash *model.Stash, columns []string, rec map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	fields := make(map[string]interface{})
+	var coerced map[string]interface{}
+	for _, name := range columns {
+		val, ok := rec[name]
+		if !ok {
+			continue
+		}
+		col := stash.Columns.Find(name)
+		if str, isString := val.(string); isString {
+			val = columnValue(col, str)
+		}
+		if col != nil {
+			if canonical, ok := coerceEnumValue(col, val); ok {
+				if coerced == nil {
+					coerced = make(map[string]interface{})
+				}
+				coerced[col.Name] = val
+				val = canonical
+			}
+		}
+		fields[name] = val
 	}
+	return fields, coerced
+}
 
-	var columns []string
-	for col := range columnSet {
-		columns = append(columns, col)
+// checkImportLines returns the records of a JSONL file that pass
+// validation, and the lines that do not, in line order: lines that are
+// not JSON objects, values that break their column's rules, and records
+// a validation hook rejects.
+func checkImportLines(stash *model.Stash, data *jsonlRecords) ([]map[string]interface{}, []lineSkip) {
+	var valid []map[string]interface{}
+	rejected := append([]lineSkip{}, data.Skipped...)
+	for i, rec := range data.Records {
+		if reason := importProblem(stash, data.Columns, rec); reason != "" {
+			rejected = append(rejected, lineSkip{Line: data.Lines[i], Reason: reason})
+			continue
+		}
+		valid = append(valid, rec)
 	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Line < rejected[j].Line })
+	return valid, rejected
+}
 
-	return columns, records, nil
+// importProblem returns why an imported record fails validation, or empty
+// string if it passes.
+func importProblem(stash *model.Stash, columns []string, rec map[string]interface{}) string {
+	fields, _ := importFields(stash, columns, rec)
+	record := &model.Record{Fields: fields}
+	for _, name := range fieldNames(record.Fields) {
+		col := stash.Columns.Find(name)
+		if col == nil || col.IsComputed() {
+			continue
+		}
+		if result := ValidateValue(col, record.Fields[name]); !result.Valid {
+			return result.Errors[0].Message
+		}
+	}
+	if result := ValidateHooks(stash, record, nil); !result.Valid {
+		return result.Errors[0].Message
+	}
+	return ""
 }
 
+// writeImportRejects writes the rejected lines of an import file to
+// rejectsFile.
+func writeImportRejects(filename, rejectsFile string, rejected []lineSkip) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	want := make(map[int]bool, len(rejected))
+	for _, r := range rejected {
+		want[r.Line] = true
+	}
+	lines := make(map[int][]byte, len(rejected))
+	err = scanJSONL(file, func(lineNum int, line []byte) error {
+		if want[lineNum] {
+			lines[lineNum] = append([]byte(nil), line...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeRejects(rejectsFile, rejected, lines)
+}
+
+
+// runImportFull creates a stash from a full-fidelity export.
+func runImportFull(filename string) error {
+	ctx, err := context.Resolve(GetActorName(), GetStashName())
+	if err != nil {
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+	if ctx.StashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	export, err := storage.ReadFullExport(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		Exit(1)
+		return nil
+	}
+
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.ImportFull(export); err != nil {
+		name := ""
+		if export.Stash != nil {
+			name = export.Stash.Name
+		}
+		switch {
+		case errors.Is(err, model.ErrStashExists):
+			ExitWithError(1, ErrCodeConflict, fmt.Sprintf("stash '%s' already exists", name), map[string]interface{}{"stash": name})
+		case errors.Is(err, storage.ErrFingerprintMismatch):
+			ExitWithError(1, ErrCodeConflict, err.Error(), map[string]interface{}{"stash": name, "fingerprint": export.Fingerprint})
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(1)
+		}
+		return nil
+	}
+
+	if GetJSONOutput() {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"stash":       export.Stash.Name,
+			"operations":  len(export.Operations),
+			"attachments": len(export.Attachments),
+			"fingerprint": export.Fingerprint,
+		})
+	}
+	if !IsQuiet() {
+		fmt.Printf("Imported stash '%s' with %d operation(s) and %d attachment(s)\n", export.Stash.Name, len(export.Operations), len(export.Attachments))
+		fmt.Printf("Fingerprint: %s\n", export.Fingerprint)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// Enum inference limits: a text column is proposed as an enum when it has
+// at most maxEnumValues distinct values, each seen minEnumRepeats times on
+// average in the sample.
+const (
+	maxEnumValues  = 12
+	minEnumRepeats = 2
+)
+
+// ImportColumnProposal is the schema proposed for one column of an import
+// file.
+type ImportColumnProposal struct {
+	Name     string   `json:"name"`
+	Validate string   `json:"validate,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Exists   bool     `json:"exists"`   // Already a column of the stash; left as is
+	Filled   int      `json:"filled"`   // Sampled rows with a value
+	Distinct int      `json:"distinct"` // Distinct sampled values
+	Example  string   `json:"example,omitempty"`
+}
+
+// ImportAnalysis is the schema proposed for an import file.
+type ImportAnalysis struct {
+	File    string                 `json:"file"`
+	Format  string                 `json:"format"`
+	Rows    int                    `json:"rows"`
+	Sampled int                    `json:"sampled"`
+	Columns []ImportColumnProposal `json:"columns"`
+	Created []string               `json:"created,omitempty"`
+}
+
+// Proposal returns the proposal for a column, or nil.
+func (a *ImportAnalysis) Proposal(name string) *ImportColumnProposal {
+	if a == nil {
+		return nil
+	}
+	for i := range a.Columns {
+		if strings.EqualFold(a.Columns[i].Name, name) {
+			return &a.Columns[i]
+		}
+	}
+	return nil
+}
+
+// analyzeImport samples up to sample records (all if sample is 0) and
+// proposes a validation type and enum for each column.
+func analyzeImport(stash *model.Stash, columns []string, records []map[string]interface{}, sample int) *ImportAnalysis {
+	if sample <= 0 || sample > len(records) {
+		sample = len(records)
+	}
+	analysis := &ImportAnalysis{Rows: len(records), Sampled: sample, Columns: []ImportColumnProposal{}}
+
+	for _, name := range columns {
+		proposal := ImportColumnProposal{Name: name, Exists: stash.Columns.Exists(name)}
+		var values []string
+		for _, rec := range records[:sample] {
+			if val, ok := rec[name]; ok && val != nil {
+				if str := strings.TrimSpace(fmt.Sprintf("%v", val)); str != "" {
+					values = append(values, str)
+				}
+			}
+		}
+		proposal.Filled = len(values)
+		if len(values) > 0 {
+			proposal.Example = values[0]
+		}
+
+		counts := make(map[string]int)
+		for _, v := range values {
+			counts[v]++
+		}
+		proposal.Distinct = len(counts)
+
+		proposal.Validate = inferValidation(values)
+		if proposal.Validate == "" && len(counts) > 1 && len(counts) <= maxEnumValues &&
+			len(values) >= minEnumRepeats*len(counts) {
+			for v := range counts {
+				proposal.Enum = append(proposal.Enum, v)
+			}
+			sort.Strings(proposal.Enum)
+		}
+		analysis.Columns = append(analysis.Columns, proposal)
+	}
+	return analysis
+}
+
+// inferValidation returns the validation type every value passes, or ""
+// for text. Numbers with leading zeros, such as postal codes, stay text.
+func inferValidation(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	checks := []struct {
+		name  ValidationType
+		valid func(string) bool
+	}{
+		{ValidationNumber, func(v string) bool {
+			digits := strings.TrimLeft(v, "+-")
+			return validateNumber(v) == nil && !(len(digits) > 1 && digits[0] == '0' && digits[1] != '.')
+		}},
+		{ValidationDate, func(v string) bool { return validateDate(v) == nil }},
+		{ValidationEmail, func(v string) bool { return validateEmail(v) == nil }},
+		{ValidationURL, func(v string) bool { return validateURL(v) == nil }},
+	}
+	for _, check := range checks {
+		all := true
+		for _, v := range values {
+			if !check.valid(v) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return string(check.name)
+		}
+	}
+	return ""
+}
+
+// createImportSchema adds the proposed columns the stash does not have
+// yet, recording their names in the analysis.
+func createImportSchema(store *storage.Store, stashName, actor string, analysis *ImportAnalysis) error {
+	for _, proposal := range analysis.Columns {
+		if proposal.Exists {
+			continue
+		}
+		col := model.Column{
+			Name:     proposal.Name,
+			Added:    time.Now(),
+			AddedBy:  actor,
+			Validate: proposal.Validate,
+			Enum:     proposal.Enum,
+		}
+		if err := store.AddColumn(stashName, col); err != nil {
+			return fmt.Errorf("failed to create column '%s': %w", proposal.Name, err)
+		}
+		analysis.Created = append(analysis.Created, proposal.Name)
+	}
+	return nil
+}
+
+// printImportAnalysis prints the proposed schema.
+func printImportAnalysis(analysis *ImportAnalysis) {
+	if GetJSONOutput() {
+		data, _ := json.MarshalIndent(analysis, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if IsQuiet() {
+		return
+	}
+
+	fmt.Printf("# Proposed schema for %s\n\n", analysis.File)
+	fmt.Printf("Sampled %d of %d record(s)\n\n", analysis.Sampled, analysis.Rows)
+	fmt.Println("| Column | Type | Enum | Filled | Example |")
+	fmt.Println("|--------|------|------|--------|---------|")
+	for _, p := range analysis.Columns {
+		kind := p.Validate
+		if kind == "" {
+			kind = "text"
+		}
+		if p.Exists {
+			kind += " (exists)"
+		}
+		filled := 0
+		if analysis.Sampled > 0 {
+			filled = p.Filled * 100 / analysis.Sampled
+		}
+		fmt.Printf("| %s | %s | %s | %d%% | %s |\n", p.Name, kind, strings.Join(p.Enum, ", "), filled, p.Example)
+	}
+
+	if len(analysis.Created) > 0 {
+		fmt.Printf("\nCreated %d column(s): %s\n", len(analysis.Created), strings.Join(analysis.Created, ", "))
+	} else {
+		fmt.Printf("\nCreate this schema with: stash import %s --analyze --create-schema\n", analysis.File)
+	}
+}
@@ -0,0 +1,383 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// remoteImportTimeout bounds a download of a remote import.
+const remoteImportTimeout = 60 * time.Second
+
+// maxRemoteImportSize bounds the body of a remote import.
+var maxRemoteImportSize int64 = 256 << 20
+
+// remoteImport is a downloaded import: the cached copy of its body, and
+// the format its response or URL indicates ("" if neither does).
+type remoteImport struct {
+	Path        string
+	Format      string
+	NotModified bool // the server reported no change since the last download
+
+	meta     remoteImportMeta
+	metaPath string
+}
+
+// Synced returns true if the download was last synced into the stash
+// with --sync, so a sync of an unchanged download has nothing to do.
+func (r *remoteImport) Synced() bool {
+	return r.meta.Synced
+}
+
+// save records the download's ETag and Last-Modified date, so the next
+// import asks for changes only, and whether it was synced. Call it once
+// the import has succeeded, so a failed import is retried in full.
+func (r *remoteImport) save(synced bool) error {
+	if r.NotModified && (!synced || r.meta.Synced) {
+		return nil
+	}
+	r.meta.Synced = synced
+	data, _ := json.MarshalIndent(r.meta, "", "  ")
+	if err := os.WriteFile(r.metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write download cache: %w", err)
+	}
+	return nil
+}
+
+// remoteImportMeta is what is kept about a remote import's last download,
+// to ask the server for changes only.
+type remoteImportMeta struct {
+	URL          string    `json:"url"`
+	Stash        string    `json:"stash"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Format       string    `json:"format,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Synced       bool      `json:"synced,omitempty"` // imported with --sync
+}
+
+// isRemoteImport returns true if an import argument is a URL.
+func isRemoteImport(arg string) bool {
+	return strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://")
+}
+
+// sheetsEditURL matches the address of a Google Sheet as it is edited.
+var sheetsEditURL = regexp.MustCompile(`^https://docs\.google\.com/spreadsheets/d/([A-Za-z0-9_-]+)/(edit|view)`)
+
+// sheetsCSVURL returns the CSV export address of a Google Sheet's edit
+// link, keeping the sheet (gid) it points at. Other URLs, including
+// "publish to the web" CSV links, are returned unchanged.
+func sheetsCSVURL(raw string) string {
+	m := sheetsEditURL.FindStringSubmatch(raw)
+	if m == nil {
+		return raw
+	}
+	export := "https://docs.google.com/spreadsheets/d/" + m[1] + "/export?format=csv"
+	if u, err := url.Parse(raw); err == nil {
+		gid := u.Query().Get("gid")
+		if gid == "" {
+			if frag, err := url.ParseQuery(u.Fragment); err == nil {
+				gid = frag.Get("gid")
+			}
+		}
+		if gid != "" {
+			export += "&gid=" + url.QueryEscape(gid)
+		}
+	}
+	return export
+}
+
+// remoteImportDir returns where a URL's last download into a stash is
+// cached. Each stash keeps its own, so importing a URL into one stash
+// does not make it look up to date in another.
+func remoteImportDir(stashDir, stashName, rawURL string) string {
+	sum := sha256.Sum256([]byte(stashName + "\n" + rawURL))
+	return filepath.Join(stashDir, "_remote", hex.EncodeToString(sum[:8]))
+}
+
+// fetchRemoteImport downloads a URL for a stash into the stash
+// directory's cache.
+// When an earlier download is cached, the request carries its ETag and
+// Last-Modified date, and a 304 Not Modified response reuses the cached
+// body. The new download's validators are kept by save.
+func fetchRemoteImport(stashDir, stashName, rawURL string) (*remoteImport, error) {
+	dir := remoteImportDir(stashDir, stashName, rawURL)
+	bodyPath := filepath.Join(dir, "body")
+	metaPath := filepath.Join(dir, "meta.json")
+
+	var cached *remoteImportMeta
+	if data, err := os.ReadFile(metaPath); err == nil {
+		var meta remoteImportMeta
+		if json.Unmarshal(data, &meta) == nil && meta.URL == rawURL && meta.Stash == stashName {
+			if _, err := os.Stat(bodyPath); err == nil {
+				cached = &meta
+			}
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("User-Agent", "stash-import")
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: remoteImportTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return &remoteImport{Path: bodyPath, Format: cached.Format, NotModified: true, meta: *cached, metaPath: metaPath}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download: %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create download cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".body-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download cache: %w", err)
+	}
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, maxRemoteImportSize+1))
+	if err == nil && n > maxRemoteImportSize {
+		err = fmt.Errorf("response is larger than %d MB", maxRemoteImportSize>>20)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), bodyPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+
+	// Until the import succeeds, the cached validators describe an older
+	// body, so a changed server answers in full next time
+	os.Remove(metaPath)
+	meta := remoteImportMeta{
+		URL:          rawURL,
+		Stash:        stashName,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Format:       remoteImportFormat(resp.Request.URL, resp.Header.Get("Content-Type")),
+		FetchedAt:    time.Now().UTC(),
+	}
+	return &remoteImport{Path: bodyPath, Format: meta.Format, meta: meta, metaPath: metaPath}, nil
+}
+
+// remoteImportFormat returns the format of a download from its content
+// type, then its URL: the path's extension, or a format or output query
+// parameter as Google Sheets export links have. Returns "" if neither
+// tells.
+func remoteImportFormat(u *url.URL, contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/json":
+		return "json"
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return "jsonl"
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	case ".jsonl":
+		return "jsonl"
+	}
+	for _, param := range []string{"format", "output"} {
+		switch strings.ToLower(u.Query().Get(param)) {
+		case "csv":
+			return "csv"
+		case "json":
+			return "json"
+		}
+	}
+	return ""
+}
+
+// importSyncResult counts what an import with --sync did.
+type importSyncResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// syncImportRecords upserts imported records by a key column, so a stash
+// can mirror a spreadsheet: a row whose key matches a record updates the
+// fields that differ, and a row whose key matches none is added. Rows
+// without a key, whose key matches several records, that fail validation,
+// or whose record is locked by another agent are skipped with an error
+// message. Records whose key is not in the file are left alone. A write
+// refused by a quota stops the sync and is returned.
+func syncImportRecords(ctx *context.Context, store *storage.Store, stash *model.Stash, columns []string, records []map[string]interface{}, key string) (*importSyncResult, error) {
+	keyCol := stash.Columns.Find(key)
+	existing, err := store.ListRecords(stash.Name, storage.ListOptions{ParentID: "*"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+	byKey := make(map[string][]*model.Record)
+	for _, r := range existing {
+		if v, _ := r.GetField(keyCol.Name); valueText(v) != "" {
+			byKey[valueText(v)] = append(byKey[valueText(v)], r)
+		}
+	}
+
+	result := &importSyncResult{}
+	skip := func(i int, keyValue, reason string) {
+		fmt.Fprintf(os.Stderr, "Error syncing record %d (%s): %s\n", i+1, keyValue, reason)
+		result.Skipped++
+	}
+	refused := func(err error) bool {
+		return errors.Is(err, model.ErrThrottled) || errors.Is(err, model.ErrQuotaExceeded)
+	}
+	for i, rec := range records {
+		fields, coerced := importFields(stash, columns, rec)
+		keyValue := strings.TrimSpace(valueText(fields[key]))
+		if keyValue == "" {
+			skip(i, keyValue, fmt.Sprintf("no value for key column '%s'", keyCol.Name))
+			continue
+		}
+		matches := byKey[keyValue]
+		if len(matches) > 1 {
+			skip(i, keyValue, fmt.Sprintf("%d records have this %s", len(matches), keyCol.Name))
+			continue
+		}
+
+		now := time.Now()
+		if len(matches) == 0 {
+			if reason := importProblem(stash, columns, rec); reason != "" {
+				skip(i, keyValue, reason)
+				continue
+			}
+			recordID, err := store.NextRecordID(stash.Name)
+			if err != nil {
+				return result, fmt.Errorf("failed to generate ID: %w", err)
+			}
+			record := &model.Record{
+				ID:        recordID,
+				CreatedAt: now,
+				CreatedBy: ctx.Actor,
+				UpdatedAt: now,
+				UpdatedBy: ctx.Actor,
+				Fields:    fields,
+				Coerced:   coerced,
+			}
+			if err := store.CreateRecord(stash.Name, record); err != nil {
+				if refused(err) {
+					return result, err
+				}
+				skip(i, keyValue, err.Error())
+				continue
+			}
+			byKey[keyValue] = []*model.Record{record}
+			result.Added++
+			continue
+		}
+
+		// Change a copy, so a skipped row leaves the record as it was
+		current := matches[0]
+		record := *current
+		record.Fields = make(map[string]interface{}, len(current.Fields))
+		for name, value := range current.Fields {
+			record.Fields[name] = value
+		}
+		var touched []string
+		for name, value := range fields {
+			col := stash.Columns.Find(name)
+			if col == nil || col.IsComputed() {
+				continue
+			}
+			if have, _ := current.GetField(col.Name); valueText(have) != valueText(value) {
+				touched = append(touched, col.Name)
+				record.SetField(col.Name, value)
+			}
+		}
+		if len(touched) == 0 {
+			result.Unchanged++
+			continue
+		}
+		sort.Strings(touched)
+
+		lock, err := CheckLock(ctx.StashDir, stash.Name, record.ID, ctx.Actor)
+		if err != nil {
+			return result, fmt.Errorf("failed to check lock: %w", err)
+		}
+		if lock != nil {
+			skip(i, keyValue, fmt.Sprintf("record '%s' is locked by agent '%s'", record.ID, lock.Agent))
+			continue
+		}
+		reason := ""
+		for _, name := range touched {
+			col := stash.Columns.Find(name)
+			value, _ := record.GetField(name)
+			if !importForce {
+				if reason, _ = transitionProblem(col, current, value); reason != "" {
+					break
+				}
+			}
+			if valResult := ValidateValue(col, value); !valResult.Valid {
+				reason = valResult.Errors[0].Message
+				break
+			}
+		}
+		if reason == "" {
+			if hookResult := ValidateHooks(stash, &record, touched); !hookResult.Valid {
+				reason = hookResult.Errors[0].Message
+			}
+		}
+		if reason != "" {
+			skip(i, keyValue, reason)
+			continue
+		}
+
+		record.UpdatedAt = now
+		record.UpdatedBy = ctx.Actor
+		record.Coerced = coerced
+		if err := store.UpdateRecord(stash.Name, &record); err != nil {
+			if refused(err) {
+				return result, err
+			}
+			skip(i, keyValue, err.Error())
+			continue
+		}
+		byKey[keyValue] = []*model.Record{&record}
+		result.Updated++
+	}
+	return result, nil
+}
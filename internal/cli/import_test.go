@@ -2,8 +2,12 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -16,6 +20,17 @@ func resetImportFlags() {
 	importDryRun = false
 	importColumn = ""
 	importFormat = ""
+	importAnalyze = false
+	importCreateSchema = false
+	importSample = 1000
+	importFull = false
+	importSQLite = false
+	importTable = ""
+	importStrict = false
+	importLenient = false
+	importSync = false
+	importKey = ""
+	importForce = false
 }
 
 // TestUC_IMP_001_ImportFromCSV tests UC-IMP-001: Import from CSV
@@ -433,3 +448,338 @@ func TestUC_IMP_001_ImportFromCSV(t *testing.T) {
 		}
 	})
 }
+
+func TestImportAnalyze(t *testing.T) {
+	csvData := "Name,Price,Released,Contact,Category,Zip\n" +
+		"Laptop,999.99,2024-01-15,sales@example.com,electronics,02134\n" +
+		"Mouse,25,2024-02-01,sales@example.com,electronics,10001\n" +
+		"Desk,300,2023-11-20,office@example.com,furniture,02134\n" +
+		"Chair,150,2023-12-05,office@example.com,furniture,\n" +
+		"Monitor,249.5,2024-03-10,sales@example.com,electronics,94105\n"
+
+	t.Run("proposes types and enums without importing", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "products", "prd-", []string{"Name"})
+		defer cleanup()
+		csvFile := filepath.Join(tempDir, "products.csv")
+		os.WriteFile(csvFile, []byte(csvData), 0644)
+
+		resetImportFlags()
+		output := captureSchemaOutput(t, "import", csvFile, "--analyze", "--json")
+		resetImportFlags()
+
+		var analysis ImportAnalysis
+		if err := json.Unmarshal([]byte(output), &analysis); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if analysis.Rows != 5 || analysis.Sampled != 5 {
+			t.Errorf("expected 5 rows sampled, got %d of %d", analysis.Sampled, analysis.Rows)
+		}
+		expected := map[string]string{"Name": "", "Price": "number", "Released": "date", "Contact": "email", "Category": "", "Zip": ""}
+		for name, validate := range expected {
+			p := analysis.Proposal(name)
+			if p == nil || p.Validate != validate {
+				t.Errorf("%s: expected validate %q, got %+v", name, validate, p)
+			}
+		}
+		if p := analysis.Proposal("Category"); len(p.Enum) != 2 || p.Enum[0] != "electronics" || p.Enum[1] != "furniture" {
+			t.Errorf("expected Category to be an enum, got %v", p.Enum)
+		}
+		if p := analysis.Proposal("Name"); len(p.Enum) != 0 || !p.Exists {
+			t.Errorf("expected Name to be an existing text column, got %+v", p)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("products", storage.ListOptions{ParentID: "*"})
+		stash, _ := store.GetStash("products")
+		if len(records) != 0 || len(stash.Columns) != 1 {
+			t.Errorf("expected --analyze to change nothing, got %d records and %d columns", len(records), len(stash.Columns))
+		}
+	})
+
+	t.Run("create-schema creates typed columns and imports", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "products", "prd-", []string{"Name"})
+		defer cleanup()
+		csvFile := filepath.Join(tempDir, "products.csv")
+		os.WriteFile(csvFile, []byte(csvData), 0644)
+
+		resetImportFlags()
+		captureStderr(t, func() {
+			captureSchemaOutput(t, "import", csvFile, "--create-schema", "--confirm")
+		})
+		resetImportFlags()
+		if ExitCode != 0 {
+			t.Fatalf("import failed, exit %d", ExitCode)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		stash, _ := store.GetStash("products")
+		if col := stash.Columns.Find("Price"); col == nil || col.Validate != "number" {
+			t.Errorf("expected a number column Price, got %+v", col)
+		}
+		if col := stash.Columns.Find("Category"); col == nil || len(col.Enum) != 2 {
+			t.Errorf("expected an enum column Category, got %+v", col)
+		}
+		records, _ := store.ListRecords("products", storage.ListOptions{ParentID: "*"})
+		if len(records) != 5 {
+			t.Errorf("expected 5 imported records, got %d", len(records))
+		}
+	})
+}
+
+func TestImportJSONLModes(t *testing.T) {
+	lines := strings.Join([]string{
+		`{"Name": "Laptop", "Price": "999"}`,
+		`{"Name": "Mouse", "Price": "cheap"}`,
+		`not json`,
+		`{"Name": "Desk", "Price": "250"}`,
+	}, "\n")
+	setup := func(t *testing.T) (string, func()) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		captureSchemaOutput(t, "column", "add", "Price", "--validate", "number")
+		path := filepath.Join(tempDir, "feed.jsonl")
+		os.WriteFile(path, []byte(lines), 0644)
+		resetImportFlags()
+		return path, cleanup
+	}
+	count := func(t *testing.T) string {
+		t.Helper()
+		return strings.TrimSpace(captureSchemaOutput(t, "count"))
+	}
+
+	t.Run("strict imports nothing", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "import", path, "--strict", "--confirm", "--json")
+		resetImportFlags()
+		if ExitCode != 2 {
+			t.Fatalf("expected exit code 2, got %d: %s", ExitCode, out)
+		}
+		if !strings.Contains(out, `"line":2`) || !strings.Contains(out, `"line":3`) {
+			t.Errorf("expected both invalid lines reported, got %s", out)
+		}
+		ExitCode = 0
+		if got := count(t); got != "0" {
+			t.Errorf("expected no records, got %s", got)
+		}
+	})
+
+	t.Run("lenient skips into a rejects file", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "import", path, "--lenient", "--confirm", "--json")
+		resetImportFlags()
+		var result struct {
+			Imported    int    `json:"imported"`
+			Rejected    int    `json:"rejected"`
+			RejectsFile string `json:"rejects_file"`
+		}
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		if result.Imported != 2 || result.Rejected != 2 || result.RejectsFile != path+".rejects" {
+			t.Errorf("unexpected result %+v", result)
+		}
+
+		data, err := os.ReadFile(path + ".rejects")
+		if err != nil {
+			t.Fatalf("expected a rejects file: %v", err)
+		}
+		rejects := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(rejects) != 2 {
+			t.Fatalf("expected 2 rejected lines, got %q", data)
+		}
+		var first lineReject
+		json.Unmarshal([]byte(rejects[0]), &first)
+		if first.Line != 2 || !strings.Contains(string(first.Record), "cheap") || first.Reason == "" {
+			t.Errorf("unexpected reject %+v", first)
+		}
+		var second lineReject
+		json.Unmarshal([]byte(rejects[1]), &second)
+		if second.Line != 3 || second.Text != "not json" {
+			t.Errorf("unexpected reject %+v", second)
+		}
+	})
+
+	t.Run("modes apply only to JSONL", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		csvPath := strings.TrimSuffix(path, ".jsonl") + ".csv"
+		os.WriteFile(csvPath, []byte("Name\nLaptop\n"), 0644)
+		captureSchemaOutput(t, "import", csvPath, "--strict", "--confirm")
+		resetImportFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestImportRemoteSync(t *testing.T) {
+	sheet := "SKU,Name,Price\nA1,Laptop,999\nB2,Mouse,50\n"
+	etag := `"v1"`
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(sheet))
+	}))
+	defer server.Close()
+	sheetURL := server.URL + "/export"
+
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"SKU"})
+	defer cleanup()
+	resetImportFlags()
+
+	type syncResult struct {
+		Added, Updated, Unchanged, Skipped int
+		UpToDate                           bool `json:"up_to_date"`
+	}
+	sync := func(t *testing.T, args ...string) syncResult {
+		t.Helper()
+		out := captureSchemaOutput(t, append([]string{"import", sheetURL, "--sync", "--key", "sku", "--confirm", "--json"}, args...)...)
+		resetImportFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", ExitCode, out)
+		}
+		var result syncResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		return result
+	}
+	names := func(t *testing.T) string {
+		t.Helper()
+		out := captureSchemaOutput(t, "list", "--json")
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(out), &records)
+		var got []string
+		for _, r := range records {
+			got = append(got, fmt.Sprintf("%v=%v/%v", r["SKU"], r["Name"], r["Price"]))
+		}
+		sort.Strings(got)
+		return strings.Join(got, " ")
+	}
+
+	if got := sync(t); got.Added != 2 || got.Updated != 0 {
+		t.Fatalf("expected 2 added, got %+v", got)
+	}
+
+	// Unchanged since the last sync
+	if got := sync(t); !got.UpToDate || notModified != 1 {
+		t.Errorf("expected up to date from a 304, got %+v (%d not modified)", got, notModified)
+	}
+
+	// The sheet changes: one row edited, one added
+	sheet = "SKU,Name,Price\nA1,Laptop Pro,999\nB2,Mouse,50\nC3,Desk,250\n"
+	etag = `"v2"`
+	if got := sync(t); got.Added != 1 || got.Updated != 1 || got.Unchanged != 1 {
+		t.Errorf("expected 1 added, 1 updated, 1 unchanged, got %+v", got)
+	}
+	if got := names(t); got != "A1=Laptop Pro/999 B2=Mouse/50 C3=Desk/250" {
+		t.Errorf("unexpected records %s", got)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 downloads, got %d", requests)
+	}
+
+	t.Run("each stash keeps its own cache", func(t *testing.T) {
+		captureSchemaOutput(t, "init", "mirror", "--prefix", "mi-")
+		if got := sync(t, "--stash", "mirror"); got.UpToDate || got.Added != 3 {
+			t.Errorf("expected 3 added to a second stash, got %+v", got)
+		}
+		if got := sync(t, "--stash", "mirror"); !got.UpToDate {
+			t.Errorf("expected up to date once synced, got %+v", got)
+		}
+	})
+
+	t.Run("a plain import is not a sync", func(t *testing.T) {
+		captureSchemaOutput(t, "init", "plain", "--prefix", "pl-")
+		captureSchemaOutput(t, "import", sheetURL, "--confirm", "--stash", "plain")
+		resetImportFlags()
+		if got := sync(t, "--stash", "plain"); got.UpToDate || got.Unchanged != 3 {
+			t.Errorf("expected the sync to run after a plain import, got %+v", got)
+		}
+	})
+
+	t.Run("sync needs a key in the file", func(t *testing.T) {
+		captureSchemaOutput(t, "import", sheetURL, "--sync", "--confirm", "--stash", "inventory")
+		resetImportFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 without --key, got %d", ExitCode)
+		}
+		ExitCode = 0
+		etag = `"v3"`
+		captureSchemaOutput(t, "import", sheetURL, "--sync", "--key", "Barcode", "--confirm", "--stash", "inventory")
+		resetImportFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a key not in the file, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("illegal transitions are skipped unless forced", func(t *testing.T) {
+		captureSchemaOutput(t, "init", "flow", "--prefix", "fl-")
+		captureSchemaOutput(t, "column", "add", "Status", "--enum", "pending,active,closed", "--transitions", "pending>active>closed", "--stash", "flow")
+		sheet, etag = "SKU,Status\nA1,pending\n", `"v4"`
+		if got := sync(t, "--stash", "flow"); got.Added != 1 {
+			t.Fatalf("expected 1 added, got %+v", got)
+		}
+
+		sheet, etag = "SKU,Status\nA1,closed\n", `"v5"`
+		if got := sync(t, "--stash", "flow"); got.Skipped != 1 || got.Updated != 0 {
+			t.Errorf("expected pending -> closed to be skipped, got %+v", got)
+		}
+		if got := sync(t, "--stash", "flow", "--force"); got.Updated != 1 {
+			t.Errorf("expected --force to allow pending -> closed, got %+v", got)
+		}
+	})
+
+	t.Run("download too large", func(t *testing.T) {
+		defer func(max int64) { maxRemoteImportSize = max }(maxRemoteImportSize)
+		maxRemoteImportSize = 16
+		etag = `"v6"`
+		captureSchemaOutput(t, "import", sheetURL, "--confirm", "--stash", "inventory")
+		resetImportFlags()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("download failure", func(t *testing.T) {
+		missing := httptest.NewServer(http.NotFoundHandler())
+		defer missing.Close()
+		captureSchemaOutput(t, "import", missing.URL+"/products.csv", "--confirm", "--stash", "inventory")
+		resetImportFlags()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestSheetsCSVURL(t *testing.T) {
+	tests := map[string]string{
+		"https://docs.google.com/spreadsheets/d/abc_123/edit#gid=42":      "https://docs.google.com/spreadsheets/d/abc_123/export?format=csv&gid=42",
+		"https://docs.google.com/spreadsheets/d/abc_123/edit?gid=7#gid=7": "https://docs.google.com/spreadsheets/d/abc_123/export?format=csv&gid=7",
+		"https://docs.google.com/spreadsheets/d/abc_123/view":             "https://docs.google.com/spreadsheets/d/abc_123/export?format=csv",
+		"https://docs.google.com/spreadsheets/d/e/2PACX/pub?output=csv":   "https://docs.google.com/spreadsheets/d/e/2PACX/pub?output=csv",
+		"https://example.com/products.csv":                                "https://example.com/products.csv",
+	}
+	for in, want := range tests {
+		if got := sheetsCSVURL(in); got != want {
+			t.Errorf("sheetsCSVURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -3,12 +3,13 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
-	"github.com/user/stash/internal/storage"
 )
 
 var infoCmd = &cobra.Command{
@@ -16,12 +17,34 @@ var infoCmd = &cobra.Command{
 	Short: "Show stash information and status",
 	Long: `Show information about all stashes.
 
-Displays record counts, deleted record counts, file counts,
-daemon status, and current actor/branch context.
+Displays, for each stash, record and deleted record counts, file
+counts, when a record last changed, the disk usage of the operation log
+(records.jsonl) and attached files, and whether the cache is in sync with
+the log. Also shows daemon status and current actor/branch context.
+
+Counts come from the cache and sizes from file metadata, so info stays
+fast on large stashes. The cache status compares only the last operation
+in the log with the cache:
+  in_sync   The cache has the log's last operation
+  stale     The log has changes the cache lacks (run 'stash sync')
+  unknown   The log or cache could not be read
 
 Examples:
   stash info
-  stash info --json`,
+  stash info --verbose
+  stash info --json
+
+AI Agent Examples:
+  # Find stashes whose cache needs a sync
+  stash info --json | jq -r '.stashes[] | select(.cache_status != "in_sync") | .name'
+
+JSON Output (--json):
+  {"stashes": [{"name": "inventory", "prefix": "inv-", "columns": 3,
+                "records": 42, "deleted": 2, "files": 1,
+                "last_modified": "2025-01-08T10:30:00Z",
+                "jsonl_bytes": 18342, "files_bytes": 40960,
+                "cache_status": "in_sync", ...}],
+   "context": {...}, "daemon": {...}}`,
 	Args: cobra.NoArgs,
 	RunE: runInfo,
 }
@@ -40,8 +63,19 @@ type StashInfo struct {
 	Files        int    `json:"files"`
 	CreatedBy    string `json:"created_by"`
 	CreatedAt    string `json:"created_at"`
+	LastModified string `json:"last_modified,omitempty"`
+	JSONLBytes   int64  `json:"jsonl_bytes"`
+	FilesBytes   int64  `json:"files_bytes"`
+	CacheStatus  string `json:"cache_status"`
 }
 
+// Cache statuses reported by 'stash info'.
+const (
+	cacheInSync  = "in_sync"
+	cacheStale   = "stale"
+	cacheUnknown = "unknown"
+)
+
 // InfoOutput represents the full info output
 type InfoOutput struct {
 	Stashes []StashInfo `json:"stashes"`
@@ -81,7 +115,7 @@ func runInfo(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -104,29 +138,30 @@ func runInfo(cmd *cobra.Command, args []string) error {
 			CreatedAt: stash.Created.Format("2006-01-02 15:04:05"),
 		}
 
-		// Count records
-		records, err := store.ListRecords(stash.Name, storage.ListOptions{
-			ParentID:       "*", // All records
-			IncludeDeleted: false,
-		})
-		if err == nil {
-			info.Records = len(records)
+		// Count records from the cache
+		if stats, err := store.StashStats(stash.Name); err == nil {
+			info.Records = stats.Records
+			info.Deleted = stats.Deleted
+			if !stats.LastModified.IsZero() {
+				info.LastModified = stats.LastModified.UTC().Format(time.RFC3339)
+			}
 		}
 
-		// Count deleted records
-		allRecords, err := store.ListRecords(stash.Name, storage.ListOptions{
-			ParentID:       "*", // All records
-			IncludeDeleted: true,
-		})
-		if err == nil {
-			info.Deleted = len(allRecords) - info.Records
+		// Size the log
+		stashPath := filepath.Join(ctx.StashDir, stash.Name)
+		if fi, err := os.Stat(filepath.Join(stashPath, "records.jsonl")); err == nil {
+			info.JSONLBytes = fi.Size()
 		}
 
-		// Count files
-		filesDir := filepath.Join(ctx.StashDir, stash.Name, "files")
-		files, err := os.ReadDir(filesDir)
-		if err == nil {
-			info.Files = len(files)
+		// Count and size files
+		info.Files, info.FilesBytes = dirUsage(filepath.Join(stashPath, "files"))
+
+		info.CacheStatus = cacheUnknown
+		if inSync, err := store.CacheInSync(stash.Name); err == nil {
+			info.CacheStatus = cacheStale
+			if inSync {
+				info.CacheStatus = cacheInSync
+			}
 		}
 
 		stashInfos = append(stashInfos, info)
@@ -152,18 +187,35 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		if len(stashInfos) == 0 {
 			fmt.Println("No stashes found.")
 		} else {
-			fmt.Println("Stashes:")
+			t := newTable(
+				tableColumn{Header: "Stash"},
+				tableColumn{Header: "Prefix"},
+				tableColumn{Header: "Records"},
+				tableColumn{Header: "Deleted"},
+				tableColumn{Header: "Files"},
+				tableColumn{Header: "Log"},
+				tableColumn{Header: "Files Size"},
+				tableColumn{Header: "Modified", System: true},
+				tableColumn{Header: "Cache"},
+			)
 			for _, info := range stashInfos {
-				fmt.Printf("\n  %s (prefix: %s)\n", info.Name, info.Prefix)
-				fmt.Printf("    Columns: %d\n", info.Columns)
-				fmt.Printf("    Records: %d", info.Records)
-				if info.Deleted > 0 {
-					fmt.Printf(" (%d deleted)", info.Deleted)
+				modified := "-"
+				if info.LastModified != "" {
+					modified = info.LastModified
 				}
-				fmt.Println()
-				fmt.Printf("    Files:   %d\n", info.Files)
-				if IsVerbose() {
-					fmt.Printf("    Created: %s by %s\n", info.CreatedAt, info.CreatedBy)
+				t.addRow(info.Name, info.Prefix,
+					fmt.Sprintf("%d", info.Records),
+					fmt.Sprintf("%d", info.Deleted),
+					fmt.Sprintf("%d", info.Files),
+					formatBytes(info.JSONLBytes),
+					formatBytes(info.FilesBytes),
+					modified,
+					info.CacheStatus)
+			}
+			t.print()
+			if IsVerbose() {
+				for _, info := range stashInfos {
+					fmt.Printf("\n  %s: %d columns, created %s by %s\n", info.Name, info.Columns, info.CreatedAt, info.CreatedBy)
 				}
 			}
 		}
@@ -178,3 +230,25 @@ func runInfo(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// dirUsage returns the number of entries directly in dir and the total
+// size of the regular files under it. A missing directory is empty.
+func dirUsage(dir string) (int, int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	return len(entries), size
+}
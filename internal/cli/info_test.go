@@ -160,6 +160,54 @@ func TestUC_ST_003_ShowInfo(t *testing.T) {
 	})
 }
 
+// TestInfoStashStats tests the per-stash counts, sizes, and cache status
+func TestInfoStashStats(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	var rec map[string]interface{}
+	json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &rec)
+	captureSchemaOutput(t, "add", "Mouse")
+	captureSchemaOutput(t, "rm", rec["_id"].(string), "--yes")
+
+	filesDir := filepath.Join(tempDir, ".stash", "inventory", "files")
+	os.MkdirAll(filesDir, 0755)
+	os.WriteFile(filepath.Join(filesDir, "manual.txt"), []byte("0123456789"), 0644)
+
+	var result InfoOutput
+	output := captureSchemaOutput(t, "info", "--json")
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("failed to parse output %q: %v", output, err)
+	}
+	if len(result.Stashes) != 1 {
+		t.Fatalf("expected 1 stash, got %d", len(result.Stashes))
+	}
+	info := result.Stashes[0]
+	if info.Records != 1 || info.Deleted != 1 {
+		t.Errorf("expected 1 record and 1 deleted, got %d and %d", info.Records, info.Deleted)
+	}
+	if info.Files != 1 || info.FilesBytes != 10 {
+		t.Errorf("expected 1 file of 10 bytes, got %d of %d", info.Files, info.FilesBytes)
+	}
+	logInfo, _ := os.Stat(filepath.Join(tempDir, ".stash", "inventory", "records.jsonl"))
+	if logInfo == nil || info.JSONLBytes != logInfo.Size() {
+		t.Errorf("expected jsonl_bytes to be the log size, got %d", info.JSONLBytes)
+	}
+	if info.LastModified == "" {
+		t.Error("expected last_modified to be set")
+	}
+	if info.CacheStatus != cacheInSync {
+		t.Errorf("expected cache in sync, got %q", info.CacheStatus)
+	}
+
+	output = captureSchemaOutput(t, "info")
+	for _, want := range []string{"Stash", "Records", "inventory", cacheInSync} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected table output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
 // TestUC_ST_003_ShowInfo_MustNot tests anti-requirements
 func TestUC_ST_003_ShowInfo_MustNot(t *testing.T) {
 	t.Run("must not show deleted records in main count", func(t *testing.T) {
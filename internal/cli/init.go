@@ -7,15 +7,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/cli/templates"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
 	"github.com/user/stash/internal/storage"
 )
 
-var initPrefix string
+var (
+	initPrefix     string
+	initFromSchema string
+	initPreset     string
+	initIDStrategy string
+	initIDTemplate string
+	initHashChain  bool
+
+	initRequireDescriptions bool
+)
 
 var initCmd = &cobra.Command{
 	Use:   "init <name>",
@@ -30,16 +41,68 @@ Prefix requirements:
   - 2-4 lowercase letters followed by a dash
   - Examples: ab-, inv-, abcd-
 
+The stash is created in the nearest .stash directory in the current
+directory or its parents, or in a new ./.stash if there is none. Use --dir
+to create it in another path instead; the .stash directory is created
+there if needed.
+
+Columns can be created up front from a schema file (see 'stash schema
+export') or from a built-in preset. The prefix defaults to the one in the
+schema or preset when --prefix is omitted.
+
+ID strategies (--id-strategy):
+  random      Random base36 suffix (default): inv-ex4j
+  sequential  Zero-padded counter: inv-0001, inv-0002
+  ulid        Time-sortable ULID: inv-01j9x3k2v8q0r5m7n4c6t1w9yz
+  template    Custom --id-template with placeholders:
+                {seq} {date} {year} {month} {day} {rand}
+              e.g. "{date}-{seq}" -> inv-20240115-0001
+
+Child records always use <parent-id>.<n>, whatever the strategy.
+Sequence numbers are never given out twice, even to concurrent writers;
+one used by a dry run or a rejected record is skipped.
+
+With --hash-chain, every operation written to records.jsonl includes the
+hash of the line before it, so 'stash verify' can prove the log was not
+edited after the fact.
+
+With --require-descriptions, every column must have a description: a
+schema or preset must describe all of its columns, and later columns need
+'stash column add --desc' (see 'stash column describe --enforce').
+
+Presets:
+  tasks      Title, Status (todo/doing/done workflow), Priority, Owner, Due
+  inventory  Name, SKU, Category, Quantity, Price, Location
+  contacts   Name, Email, Phone, Company, Website, Notes
+
 Examples:
   stash init inventory --prefix inv-
-  stash init contacts --prefix ct- --no-daemon`,
+  stash init contacts --prefix ct- --no-daemon
+  stash init todo --preset tasks
+  stash init bugs --prefix bug- --from-schema bugs.schema.yaml
+  stash init orders --prefix ord- --id-strategy sequential
+  stash init events --prefix ev- --id-strategy template --id-template "{date}-{seq}"
+  stash init audit --prefix aud- --hash-chain
+  stash init shared --prefix sh- --from-schema shared.yaml --require-descriptions
+  stash init notes --prefix nt- --dir ~/notes
+
+Exit Codes:
+  0  Success
+  1  Stash already exists, or schema file cannot be read
+  2  Validation error (invalid name, prefix, schema, or unknown preset, or
+     undescribed schema columns with --require-descriptions)`,
 	Args: cobra.ExactArgs(1),
 	RunE: runInit,
 }
 
 func init() {
-	initCmd.Flags().StringVar(&initPrefix, "prefix", "", "Record ID prefix (required, e.g., inv-)")
-	initCmd.MarkFlagRequired("prefix")
+	initCmd.Flags().StringVar(&initPrefix, "prefix", "", "Record ID prefix (e.g., inv-; required unless set by schema or preset)")
+	initCmd.Flags().StringVar(&initFromSchema, "from-schema", "", "Create columns from a schema file (JSON or YAML)")
+	initCmd.Flags().StringVar(&initPreset, "preset", "", "Create columns from a built-in preset (tasks, inventory, contacts)")
+	initCmd.Flags().StringVar(&initIDStrategy, "id-strategy", "", "Record ID strategy: random, sequential, ulid, template (default: random)")
+	initCmd.Flags().StringVar(&initIDTemplate, "id-template", "", "ID template for --id-strategy template (e.g., \"{date}-{seq}\")")
+	initCmd.Flags().BoolVar(&initHashChain, "hash-chain", false, "Link each logged operation to the previous one for tamper evidence")
+	initCmd.Flags().BoolVar(&initRequireDescriptions, "require-descriptions", false, "Require a description on every column")
 	rootCmd.AddCommand(initCmd)
 }
 
@@ -53,8 +116,67 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return nil // Won't reach in normal execution
 	}
 
+	// Validate ID strategy; a template on its own implies the template strategy
+	idStrategy := initIDStrategy
+	if idStrategy == "" && initIDTemplate != "" {
+		idStrategy = model.IDStrategyTemplate
+	}
+	if err := model.ValidateIDStrategy(idStrategy, initIDTemplate); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		Exit(2)
+		return nil
+	}
+	if idStrategy == model.IDStrategyRandom {
+		idStrategy = ""
+	}
+
+	// Load the initial schema, if any
+	if initFromSchema != "" && initPreset != "" {
+		fmt.Fprintln(os.Stderr, "Error: --from-schema and --preset cannot be used together")
+		Exit(2)
+		return nil
+	}
+	var schema *Schema
+	if initFromSchema != "" {
+		var ok bool
+		if schema, ok = loadSchemaFile(initFromSchema); !ok {
+			return nil
+		}
+	} else if initPreset != "" {
+		var err error
+		if schema, err = loadPreset(initPreset); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(2)
+			return nil
+		}
+	}
+
+	if initRequireDescriptions && schema != nil {
+		var undescribed []string
+		for _, col := range schema.Columns {
+			if strings.TrimSpace(col.Desc) == "" {
+				undescribed = append(undescribed, col.Name)
+			}
+		}
+		if len(undescribed) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --require-descriptions: schema columns without a description: %s\n", strings.Join(undescribed, ", "))
+			Exit(2)
+			return nil
+		}
+	}
+
+	prefix := initPrefix
+	if prefix == "" && schema != nil {
+		prefix = schema.Prefix
+	}
+	if prefix == "" {
+		fmt.Fprintln(os.Stderr, "Error: --prefix is required")
+		Exit(2)
+		return nil
+	}
+
 	// Validate prefix
-	if err := model.ValidatePrefix(initPrefix); err != nil {
+	if err := model.ValidatePrefix(prefix); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		Exit(2)
 		return nil // Won't reach in normal execution
@@ -63,14 +185,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// Resolve context
 	ctx, _ := context.Resolve(GetActorName(), "")
 
-	// Determine base directory - use current directory
-	baseDir := ".stash"
-	if ctx.StashDir != "" {
-		baseDir = ctx.StashDir
-	}
+	// Use the nearest .stash, or create one here (or in --dir)
+	baseDir := context.TargetStashDir()
 
 	// Create storage
-	store, err := storage.NewStore(baseDir)
+	store, err := openStore(baseDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -79,15 +198,20 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// Create stash configuration
 	now := time.Now()
 	stash := &model.Stash{
-		Name:      name,
-		Prefix:    initPrefix,
-		Created:   now,
-		CreatedBy: ctx.Actor,
-		Columns:   model.ColumnList{},
+		Name:       name,
+		Prefix:     prefix,
+		Created:    now,
+		CreatedBy:  ctx.Actor,
+		Columns:    model.ColumnList{},
+		IDStrategy: idStrategy,
+		IDTemplate: initIDTemplate,
+		HashChain:  initHashChain,
+
+		RequireDescriptions: initRequireDescriptions,
 	}
 
 	// Create stash
-	if err := store.CreateStash(name, initPrefix, stash); err != nil {
+	if err := store.CreateStash(name, prefix, stash); err != nil {
 		if errors.Is(err, model.ErrStashExists) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' already exists\n", name)
 			Exit(1)
@@ -96,37 +220,56 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create stash: %w", err)
 	}
 
-	// Create empty records.jsonl file
+	// Create empty records.jsonl file and files/ subdirectory
 	stashDir := filepath.Join(baseDir, name)
-	recordsPath := filepath.Join(stashDir, "records.jsonl")
-	if _, err := os.Stat(recordsPath); os.IsNotExist(err) {
-		f, err := os.Create(recordsPath)
-		if err != nil {
-			return fmt.Errorf("failed to create records.jsonl: %w", err)
-		}
-		f.Close()
+	if err := createStashFiles(store, stashDir); err != nil {
+		return err
 	}
 
-	// Create files/ subdirectory
-	filesDir := filepath.Join(stashDir, "files")
-	if err := os.MkdirAll(filesDir, 0755); err != nil {
-		return fmt.Errorf("failed to create files directory: %w", err)
+	// Create columns from the schema
+	var columnNames []string
+	if schema != nil {
+		diff, err := diffSchema(stash, schema)
+		if err == nil {
+			err = applySchema(store, stash, schema, diff, ctx.Actor)
+		}
+		if err != nil {
+			store.DropStash(name)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			Exit(2)
+			return nil
+		}
+		for _, col := range schema.Columns {
+			columnNames = append(columnNames, col.Name)
+		}
 	}
 
 	// Output result
 	if GetJSONOutput() {
 		output := map[string]interface{}{
 			"name":       name,
-			"prefix":     initPrefix,
+			"prefix":     prefix,
 			"created_at": now.Format(time.RFC3339),
 			"created_by": ctx.Actor,
 			"path":       stashDir,
 			"daemon":     !NoDaemon(),
 		}
+		if idStrategy != "" {
+			output["id_strategy"] = idStrategy
+		}
+		if initRequireDescriptions {
+			output["require_descriptions"] = true
+		}
+		if schema != nil {
+			output["columns"] = columnNames
+		}
 		data, _ := json.Marshal(output)
 		fmt.Println(string(data))
 	} else if !IsQuiet() {
-		fmt.Printf("Created stash '%s' with prefix '%s'\n", name, initPrefix)
+		fmt.Printf("Created stash '%s' with prefix '%s'\n", name, prefix)
+		if len(columnNames) > 0 {
+			fmt.Printf("  columns: %s\n", strings.Join(columnNames, ", "))
+		}
 		if IsVerbose() {
 			fmt.Printf("  path: %s\n", stashDir)
 			fmt.Printf("  actor: %s\n", ctx.Actor)
@@ -139,3 +282,49 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// createStashFiles creates the empty records.jsonl file and files/
+// subdirectory of a new stash. An in-memory store has neither.
+func createStashFiles(store *storage.Store, stashDir string) error {
+	if store.IsMemory() {
+		return nil
+	}
+
+	recordsPath := filepath.Join(stashDir, "records.jsonl")
+	if _, err := os.Stat(recordsPath); os.IsNotExist(err) {
+		f, err := os.Create(recordsPath)
+		if err != nil {
+			return fmt.Errorf("failed to create records.jsonl: %w", err)
+		}
+		f.Close()
+	}
+
+	filesDir := filepath.Join(stashDir, "files")
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create files directory: %w", err)
+	}
+	return nil
+}
+
+// loadPreset returns the built-in schema preset with the given name.
+func loadPreset(name string) (*Schema, error) {
+	data, err := templates.Presets.ReadFile("presets/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset '%s' (available: %s)", name, strings.Join(presetNames(), ", "))
+	}
+	schema, err := parseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset '%s': %w", name, err)
+	}
+	return schema, nil
+}
+
+// presetNames returns the names of the built-in schema presets, sorted.
+func presetNames() []string {
+	entries, _ := templates.Presets.ReadDir("presets")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	return names
+}
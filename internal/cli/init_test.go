@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/user/stash/internal/storage"
 )
 
 // setupTestEnv sets up the test environment and returns a cleanup function
@@ -14,6 +16,9 @@ func setupTestEnv(t *testing.T) (tempDir string, cleanup func()) {
 	origDir, _ := os.Getwd()
 	os.Chdir(tempDir)
 
+	// Keep the user's identity config and signing keys out of tests
+	t.Setenv("STASH_CONFIG_DIR", filepath.Join(tempDir, ".config"))
+
 	// Mock the exit function to capture exit code instead of exiting
 	origExitFunc := ExitFunc
 	ExitFunc = func(code int) {
@@ -282,3 +287,189 @@ func TestUC_ST_001_InitStash_MustNot(t *testing.T) {
 		}
 	})
 }
+
+func TestInitFromSchema(t *testing.T) {
+	t.Run("preset creates columns and default prefix", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "people", "--preset", "contacts"})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		configPath := filepath.Join(tempDir, ".stash", "people", "config.json")
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatalf("failed to read config.json: %v", err)
+		}
+		var config struct {
+			Prefix  string `json:"prefix"`
+			Columns []struct {
+				Name     string `json:"name"`
+				Validate string `json:"validate"`
+			} `json:"columns"`
+		}
+		json.Unmarshal(data, &config)
+
+		if config.Prefix != "ct-" {
+			t.Errorf("expected preset prefix 'ct-', got %q", config.Prefix)
+		}
+		if len(config.Columns) != 6 || config.Columns[1].Name != "Email" || config.Columns[1].Validate != "email" {
+			t.Errorf("unexpected columns: %+v", config.Columns)
+		}
+	})
+
+	t.Run("schema file with prefix override", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		schemaPath := filepath.Join(tempDir, "bugs.yaml")
+		schema := "prefix: bg-\ncolumns:\n  - name: Summary\n  - name: Severity\n    enum: [low, high]\n"
+		os.WriteFile(schemaPath, []byte(schema), 0644)
+
+		rootCmd.SetArgs([]string{"init", "bugs", "--prefix", "bug-", "--from-schema", schemaPath})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(tempDir, ".stash", "bugs", "config.json"))
+		var config map[string]interface{}
+		json.Unmarshal(data, &config)
+		if config["prefix"] != "bug-" {
+			t.Errorf("expected prefix 'bug-', got %v", config["prefix"])
+		}
+		if columns, _ := config["columns"].([]interface{}); len(columns) != 2 {
+			t.Errorf("expected 2 columns, got %v", config["columns"])
+		}
+	})
+
+	t.Run("must not accept unknown preset", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "things", "--preset", "nope"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "things")); err == nil {
+			t.Error("expected .stash/things/ NOT to exist for unknown preset")
+		}
+	})
+
+	t.Run("must require a prefix without schema", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "things"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestInitIDStrategy(t *testing.T) {
+	// addRecordIDs adds root records and returns all record IDs in creation order
+	addRecordIDs := func(t *testing.T, tempDir, stashName string, names ...string) []string {
+		t.Helper()
+		for _, name := range names {
+			rootCmd.SetArgs([]string{"add", name, "--stash", stashName})
+			rootCmd.Execute()
+			resetFlags()
+		}
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords(stashName, storage.ListOptions{ParentID: "*", OrderBy: []storage.OrderKey{{Field: "_id"}}})
+		ids := make([]string, len(records))
+		for i, rec := range records {
+			ids[i] = rec.ID
+		}
+		return ids
+	}
+
+	t.Run("sequential IDs", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "orders", "--prefix", "ord-", "--id-strategy", "sequential"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"column", "add", "Name"})
+		rootCmd.Execute()
+		resetFlags()
+
+		ids := addRecordIDs(t, tempDir, "orders", "a", "b")
+		if len(ids) != 2 || ids[0] != "ord-0001" || ids[1] != "ord-0002" {
+			t.Errorf("expected ord-0001, ord-0002, got %v", ids)
+		}
+
+		rootCmd.SetArgs([]string{"add", "c", "--parent", "ord-0002"})
+		rootCmd.Execute()
+		resetFlags()
+		ids = addRecordIDs(t, tempDir, "orders")
+		if len(ids) != 3 || ids[2] != "ord-0002.1" {
+			t.Errorf("expected child ord-0002.1, got %v", ids)
+		}
+	})
+
+	t.Run("template IDs", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "events", "--prefix", "ev-", "--id-template", "x_{seq}"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+		rootCmd.SetArgs([]string{"column", "add", "Name"})
+		rootCmd.Execute()
+		resetFlags()
+
+		ids := addRecordIDs(t, tempDir, "events", "a", "b")
+		if len(ids) != 2 || ids[0] != "ev-x_0001" || ids[1] != "ev-x_0002" {
+			t.Errorf("expected ev-x_0001, ev-x_0002, got %v", ids)
+		}
+
+		// '_' must not act as a wildcard when numbering children
+		for i := 0; i < 2; i++ {
+			rootCmd.SetArgs([]string{"add", "child", "--parent", "ev-x_0001"})
+			rootCmd.Execute()
+			resetFlags()
+		}
+		ids = addRecordIDs(t, tempDir, "events")
+		if len(ids) != 4 || ids[2] != "ev-x_0001.2" {
+			t.Errorf("expected second child ev-x_0001.2, got %v", ids)
+		}
+	})
+
+	t.Run("must reject unknown strategy", func(t *testing.T) {
+		tempDir, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "things", "--prefix", "th-", "--id-strategy", "uuid"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "things")); err == nil {
+			t.Error("expected .stash/things/ NOT to exist for unknown strategy")
+		}
+	})
+}
@@ -0,0 +1,164 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	limitsMaxRecord string
+	limitsMaxField  string
+	limitsReset     bool
+)
+
+var limitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Show or set the size limits of records",
+	Long: `Show or set how large the records of the current stash may be.
+
+Every record is carried whole through the SQLite cache and records.jsonl,
+so a few very large values slow down every listing, query, and sync.
+Writes that would make a record larger than the limits are rejected with
+a RECORD_TOO_LARGE error (exit code 2). Store large content, such as logs,
+transcripts, or generated files, as an attachment with 'stash attach' and
+keep a summary in the record.
+
+  --max-field  Largest single field value (default: 256 KB)
+  --max-record Largest record, measured as the JSON of its fields
+               (default: 1 MB)
+
+Sizes take a unit: 512KB, 2MB (powers of 1024). 'none' turns a limit off,
+and 'default' goes back to the default. The limits are stored in the
+stash's config.json.
+
+Records written before a limit was lowered can still be edited, as long
+as the oversized fields are left as they are and the record does not
+grow. 'stash doctor' lists records over the limits.
+
+Examples:
+  stash limits                         # Show the current limits
+  stash limits --max-field 64KB        # Tighten the field limit
+  stash limits --max-record 4MB --max-field 1MB
+  stash limits --max-field none        # No limit on single fields
+  stash limits --reset                 # Back to the defaults
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (invalid size, --reset with other flags)
+
+JSON Output (--json):
+  {"stash": "inventory", "max_record": 1048576, "max_field": 262144}
+  A limit that is off is reported as 0.`,
+	Args: cobra.NoArgs,
+	RunE: runLimits,
+}
+
+func init() {
+	limitsCmd.Flags().StringVar(&limitsMaxRecord, "max-record", "", "Largest record (e.g., 1MB), 'none', or 'default'")
+	limitsCmd.Flags().StringVar(&limitsMaxField, "max-field", "", "Largest field value (e.g., 256KB), 'none', or 'default'")
+	limitsCmd.Flags().BoolVar(&limitsReset, "reset", false, "Go back to the default limits")
+	rootCmd.AddCommand(limitsCmd)
+}
+
+// parseSizeLimit parses a --max-record or --max-field value: a size,
+// "none" for no limit, or "default".
+func parseSizeLimit(flag, value string) (int64, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "none":
+		return model.NoSizeLimit, true
+	case "default":
+		return 0, true
+	}
+	size, err := model.ParseSize(value)
+	if err != nil {
+		ExitValidationError(fmt.Sprintf("--%s: %v", flag, err), map[string]interface{}{flag: value})
+		return 0, false
+	}
+	return size, true
+}
+
+func runLimits(cmd *cobra.Command, args []string) error {
+	update := limitsMaxRecord != "" || limitsMaxField != ""
+	if limitsReset && update {
+		ExitValidationError("--reset cannot be combined with --max-record or --max-field", nil)
+		return nil
+	}
+
+	limits := &model.SizeLimits{}
+	ok := true
+	if limitsMaxRecord != "" {
+		limits.MaxRecord, ok = parseSizeLimit("max-record", limitsMaxRecord)
+	}
+	if ok && limitsMaxField != "" {
+		limits.MaxField, ok = parseSizeLimit("max-field", limitsMaxField)
+	}
+	if !ok {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if update || limitsReset {
+		if update && stash.Limits != nil {
+			if limitsMaxRecord == "" {
+				limits.MaxRecord = stash.Limits.MaxRecord
+			}
+			if limitsMaxField == "" {
+				limits.MaxField = stash.Limits.MaxField
+			}
+		}
+		if limitsReset || limits.IsEmpty() {
+			limits = nil
+		}
+		stash.Limits = limits
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update size limits: %w", err)
+		}
+	}
+
+	maxRecord, maxField := stash.RecordSizeLimits()
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"stash":      stash.Name,
+			"max_record": max(maxRecord, 0),
+			"max_field":  max(maxField, 0),
+		})
+		fmt.Println(string(data))
+		return nil
+	}
+	if IsQuiet() {
+		return nil
+	}
+
+	if update || limitsReset {
+		fmt.Printf("Set size limits of stash '%s'\n", stash.Name)
+	} else {
+		fmt.Printf("Size limits of stash '%s':\n", stash.Name)
+	}
+	fmt.Printf("  record: %s\n", describeSizeLimit(maxRecord, model.DefaultMaxRecordSize))
+	fmt.Printf("  field:  %s\n", describeSizeLimit(maxField, model.DefaultMaxFieldSize))
+	return nil
+}
+
+// describeSizeLimit formats a limit for display, noting when it is off or
+// the default.
+func describeSizeLimit(limit, defaultLimit int64) string {
+	switch {
+	case limit <= 0:
+		return "none"
+	case limit == defaultLimit:
+		return model.FormatSize(limit) + " (default)"
+	default:
+		return model.FormatSize(limit)
+	}
+}
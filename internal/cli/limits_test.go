@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	t.Run("shows the defaults", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "limits")
+		if !strings.Contains(out, "record: 1.0 MB (default)") || !strings.Contains(out, "field:  256.0 KB (default)") {
+			t.Errorf("expected the default limits, got %q", out)
+		}
+	})
+
+	t.Run("rejects writes over the limits", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "limits", "--max-field", "1KB")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		out := captureSchemaOutput(t, "add", "Laptop", "--set", "Notes="+strings.Repeat("x", 2048), "--json")
+		if ExitCode != 2 {
+			t.Fatalf("expected exit code 2, got %d: %s", ExitCode, out)
+		}
+		var result JSONError
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected a JSON error, got %q", out)
+		}
+		if result.Code != ErrCodeRecordTooLarge || result.Details["field"] != "Notes" {
+			t.Errorf("unexpected error %+v", result)
+		}
+		if !strings.Contains(result.Message, "stash attach") {
+			t.Errorf("expected guidance in the message, got %q", result.Message)
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "limits", "--max-field", "none")
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Notes="+strings.Repeat("x", 2048))
+		if ExitCode != 0 {
+			t.Errorf("expected the write to pass with no field limit, got exit code %d", ExitCode)
+		}
+	})
+
+	t.Run("doctor lists oversized records", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Notes="+strings.Repeat("x", 2048))
+		id := strings.TrimSpace(captureSchemaOutput(t, "list", "--porcelain"))
+		id, _, _ = strings.Cut(id, "\t")
+		captureSchemaOutput(t, "limits", "--max-field", "1KB")
+
+		// The oversized field is kept as it is, so the record can be edited
+		captureSchemaOutput(t, "set", id, "Name=Big laptop")
+		if ExitCode != 0 {
+			t.Fatalf("expected editing another field to pass, got exit code %d", ExitCode)
+		}
+
+		var doctorOut bytes.Buffer
+		rootCmd.SetOut(&doctorOut)
+		captureSchemaOutput(t, "doctor")
+		rootCmd.SetOut(nil)
+		if !strings.Contains(doctorOut.String(), "1 record(s) over the size limits") || !strings.Contains(doctorOut.String(), id+" (Notes: 2.0 KB)") {
+			t.Errorf("expected doctor to list the oversized record, got:\n%s", doctorOut.String())
+		}
+		ExitCode = 0
+	})
+
+	t.Run("validates sizes", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "limits", "--max-record", "lots")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "limits", "--reset", "--max-field", "1KB")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "limits", "--max-record", "4MB")
+		out := captureSchemaOutput(t, "limits", "--json")
+		if !strings.Contains(out, `"max_record":4194304`) || !strings.Contains(out, `"max_field":262144`) {
+			t.Errorf("unexpected limits %q", out)
+		}
+		captureSchemaOutput(t, "limits", "--reset")
+		out = captureSchemaOutput(t, "limits", "--json")
+		if !strings.Contains(out, `"max_record":1048576`) {
+			t.Errorf("expected the default record limit after --reset, got %q", out)
+		}
+	})
+}
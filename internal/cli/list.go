@@ -6,8 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
@@ -16,16 +17,34 @@ import (
 )
 
 var (
-	listAll      bool
-	listDeleted  bool
-	listParent   string
-	listLimit    int
-	listOffset   int
-	listOrderBy  string
-	listDesc     bool
-	listWhere    []string
-	listSearch   string
-	listColumns  string
+	listAll          bool
+	listDeleted      bool
+	listParent       string
+	listLimit        int
+	listOffset       int
+	listOrderBy      string
+	listDesc         bool
+	listWhere        []string
+	listSearch       string
+	listSearchMode   string
+	listColumns      string
+	listNoPrefs      bool
+	listArchived     bool
+	listAssignedTo   string
+	listCreatedBy    string
+	listUpdatedSince string
+	listDeletedBy    string
+	listBranch       string
+	listRecursive    bool
+	listDepth        int
+	listCSV          bool
+	listTSV          bool
+	listNoHeaders    bool
+	listExplain      bool
+	listSample       int
+	listSeed         int64
+	listHead         int
+	listTail         int
 )
 
 var listCmd = &cobra.Command{
@@ -33,24 +52,68 @@ var listCmd = &cobra.Command{
 	Short: "List all records",
 	Long: `List records in the current stash.
 
-By default, shows root-level records (not deleted). Use flags to filter:
+By default, shows root-level records (not deleted or archived). Use flags to filter:
 
   --all              Show all records including children
   --deleted          Include soft-deleted records
+  --archived         Show only archived records
+  --assigned-to WHO  Show only records assigned to an agent, at any depth
+                     ("me" is the current actor; see 'stash assign')
+  --created-by WHO   Show only records created by an actor ("me" is the
+                     current actor)
+  --updated-since T  Show only records updated since a duration ago (24h,
+                     7d) or a date
+  --deleted-by WHO   Show only records deleted by an actor (implies --deleted)
+  --branch NAME      Show only records created on a git branch
   --parent ID        Show only children of the specified parent
+  --recursive        With --parent, include grandchildren and deeper descendants
+  --depth N          With --parent, include descendants up to N levels down
+                     (implies --recursive)
   --limit N          Limit results to N records
   --offset N         Skip first N records
-  --order-by FIELD   Sort by field (default: _updated_at)
-  --desc             Sort descending
+  --head N           Show the first N records (like --limit N)
+  --tail N           Show the last N records, still in the sort order
+  --sample N         Show N records picked at random, still in the sort
+                     order; --seed S picks the same records every time
+  --order-by KEYS    Sort by comma-separated fields (default: _updated_at),
+                     each optionally followed by asc or desc; append
+                     :numeric, :date, or :text to override a column's type
+  --desc             Sort descending (keys without asc or desc)
   --where CONDITION  Filter by field value (can be repeated)
   --search TERM      Search across all fields
+  --search-mode MODE How --search matches: ci (default, ignores case and
+                     accents), exact (case-sensitive), fuzzy (tolerates typos)
   --columns COLS     Select specific columns (comma-separated)
+  --csv              Output as CSV: _id, then the selected columns or all columns
+  --tsv              Output as tab-separated values, like --csv
+  --no-headers       Omit the header row in CSV/TSV output
+  --no-prefs         Ignore the defaults saved with 'stash config'
+  --no-default-filter  Show records the stash's default filter hides
+  --explain          Show the SQL and SQLite query plan instead of records
+
+Defaults for --columns, --order-by, and --limit can be saved per stash
+with 'stash config set' (e.g., list.columns Name,Price). They apply when
+the flag is left out; scripts that must see every record can pass
+--no-prefs.
+
+A stash can also have a default filter (see 'stash filter'), --where
+conditions applied on every list for everyone; --no-default-filter
+skips it.
+
+With read tracking on (see 'stash stats'), --order-by _last_read and
+_read_count sort by when and how often records were read with 'stash
+show'.
+
+Number and date columns (--validate number/date) sort by value, so 999
+sorts before 1000 and dates sort chronologically. Other columns sort as
+text unless the --order-by suffix says otherwise. Records that tie on
+every key are ordered by ID, so output order is deterministic.
 
 WHERE clause format:
   field=value        Equals
   field!=value       Not equals
-  field>value        Greater than (numeric)
-  field<value        Less than (numeric)
+  field>value        Greater than (numeric, or by date for date columns)
+  field<value        Less than (numeric, or by date for date columns)
   field>=value       Greater than or equal
   field<=value       Less than or equal
   field LIKE pattern Pattern match (use % for wildcard)
@@ -58,23 +121,70 @@ WHERE clause format:
   field IS NOT NULL  Field has a value
   field IS EMPTY     Field is null or empty string
   field IS NOT EMPTY Field has a non-empty value
+  field CONTAINS val List column has the element
+
+Comparisons (<, >, <=, >=) accept relative dates, resolved against the
+current time: now, today (start of the day), a signed offset from now
+such as -7d or +2w, or now/today with an offset such as now+2w or
+today-1d. Offset units are y, mo, w, d, h, m, and s (combinable, as in
+1d12h), and ISO-8601 durations such as P7D or PT12H are accepted too.
+
+Like --assigned-to, the audit filters --created-by, --updated-since,
+--deleted-by, and --branch match records at any depth, and can be combined
+with each other and with --where.
+
+With --recursive or --depth, each record in the JSON output carries
+"_depth": its level below the parent (1 for direct children).
+
+JSON, CSV, and TSV output is streamed record by record, so piping a very large
+stash into another tool does not load it all into memory.
+
+--explain prints the SQL the filters become and the plan SQLite would
+follow to run it (see 'stash query --explain'), with hints when the
+filters make it read every record or sort the results, to help tell why a
+listing is slow. No records are listed.
 
 Examples:
   stash list
   stash list --json
   stash list --all
   stash list --parent inv-ex4j
+  stash list --parent inv-ex4j --recursive
+  stash list --parent inv-ex4j --depth 2 --json
   stash list --limit 10 --order-by Name
+  stash list --order-by Price:numeric --desc
+  stash list --order-by "Category,Price desc"
+  stash list --order-by _last_read --limit 20
   stash list --deleted
+  stash list --archived
+  stash list --assigned-to me
+  stash list --created-by alice --updated-since 7d
+  stash list --deleted-by me --branch feature/import
   stash list --where "Category=electronics"
   stash list --where "Price>100" --where "Category=electronics"
+  stash list --where "_created_at > -7d"
+  stash list --where "due < now+2w"
   stash list --search "laptop"
+  stash list --search "laptp" --search-mode fuzzy
   stash list --columns "Name,Price"
+  stash list --where "Category=electronics" --csv > electronics.csv
+  stash list --columns "Name,Price" --tsv --no-headers
+  stash list --no-prefs
+  stash list --where "Price>100" --order-by Name --explain
+  stash list --sample 20                  # Eyeball a huge stash
+  stash list --sample 20 --seed 7         # The same 20 records every time
+  stash list --tail 5                     # The 5 most recently updated
 
 AI Agent Examples:
   # Get all record IDs for batch processing
   stash list --json | jq -r '.[]._id'
 
+  # Work through the records assigned to this agent
+  stash list --assigned-to me --json | jq -r '.[]._id'
+
+  # Review what an agent changed in the last day
+  stash list --created-by agent-1 --updated-since 24h --json
+
   # Find unprocessed records
   stash list --where "status IS NULL" --json | jq -r '.[]._id'
 
@@ -97,7 +207,10 @@ AI Agent Examples:
 
 Exit Codes:
   0  Success
-  1  Stash not found`,
+  1  Stash not found
+  2  Invalid --search-mode, --order-by, --depth, or --updated-since,
+     --recursive without --parent, more than one of --json, --csv,
+     and --tsv, or more than one of --head, --tail, and --sample`,
 	Args: cobra.NoArgs,
 	RunE: runList,
 }
@@ -105,83 +218,148 @@ Exit Codes:
 func init() {
 	listCmd.Flags().BoolVar(&listAll, "all", false, "Show all records including children")
 	listCmd.Flags().BoolVar(&listDeleted, "deleted", false, "Include soft-deleted records")
+	listCmd.Flags().BoolVar(&listArchived, "archived", false, "Show only archived records")
+	listCmd.Flags().StringVar(&listAssignedTo, "assigned-to", "", "Show only records assigned to an agent (\"me\" for the current actor)")
+	listCmd.Flags().StringVar(&listCreatedBy, "created-by", "", "Show only records created by an actor (\"me\" for the current actor)")
+	listCmd.Flags().StringVar(&listUpdatedSince, "updated-since", "", "Show only records updated since a duration ago (24h, 7d) or a date")
+	listCmd.Flags().StringVar(&listDeletedBy, "deleted-by", "", "Show only records deleted by an actor (implies --deleted)")
+	listCmd.Flags().StringVar(&listBranch, "branch", "", "Show only records created on a git branch")
 	listCmd.Flags().StringVar(&listParent, "parent", "", "Show only children of the specified parent")
+	listCmd.Flags().BoolVar(&listRecursive, "recursive", false, "With --parent, include all descendants")
+	listCmd.Flags().IntVar(&listDepth, "depth", 0, "With --parent, include descendants up to N levels down")
 	listCmd.Flags().IntVar(&listLimit, "limit", 0, "Limit results to N records (0 = no limit)")
 	listCmd.Flags().IntVar(&listOffset, "offset", 0, "Skip first N records")
-	listCmd.Flags().StringVar(&listOrderBy, "order-by", "", "Sort by field (default: _updated_at)")
+	listCmd.Flags().IntVar(&listHead, "head", 0, "Show the first N records")
+	listCmd.Flags().IntVar(&listTail, "tail", 0, "Show the last N records")
+	listCmd.Flags().IntVar(&listSample, "sample", 0, "Show N records picked at random")
+	listCmd.Flags().Int64Var(&listSeed, "seed", 0, "With --sample, pick the same records for the same seed")
+	listCmd.Flags().StringVar(&listOrderBy, "order-by", "", "Sort keys, e.g. \"Category,Price desc\" (default: _updated_at)")
 	listCmd.Flags().BoolVar(&listDesc, "desc", false, "Sort descending")
 	listCmd.Flags().StringArrayVar(&listWhere, "where", nil, "Filter by field value (can be repeated)")
 	listCmd.Flags().StringVar(&listSearch, "search", "", "Search across all fields")
-	listCmd.Flags().StringVar(&listColumns, "columns", "", "Select specific columns (comma-separated)")
+	listCmd.Flags().StringVar(&listSearchMode, "search-mode", storage.SearchCI, "Search matching: exact, ci, fuzzy")
+	listCmd.Flags().StringVar(&listColumns, "columns", "", "Select specific columns (comma-separated; @group for a column group)")
+	listCmd.Flags().BoolVar(&listNoPrefs, "no-prefs", false, "Ignore preferences saved with 'stash config'")
+	listCmd.Flags().BoolVar(&noDefaultFilter, "no-default-filter", false, "Show records the stash's default filter hides")
+	listCmd.Flags().BoolVar(&listCSV, "csv", false, "Output as CSV")
+	listCmd.Flags().BoolVar(&listTSV, "tsv", false, "Output as tab-separated values")
+	listCmd.Flags().BoolVar(&listNoHeaders, "no-headers", false, "Omit header row in CSV/TSV output")
+	listCmd.Flags().BoolVar(&listExplain, "explain", false, "Show the SQL and query plan instead of listing records")
 	rootCmd.AddCommand(listCmd)
 }
 
-// parseWhereClause parses a WHERE clause string into a WhereCondition.
-// Supported formats:
-//   - field=value
-//   - field!=value
-//   - field>value, field<value, field>=value, field<=value
-//   - field LIKE pattern
-//   - field IS NULL, field IS NOT NULL
-//   - field IS EMPTY, field IS NOT EMPTY
-func parseWhereClause(clause string) (storage.WhereCondition, error) {
-	clause = strings.TrimSpace(clause)
-
-	// Check for IS NULL / IS NOT NULL / IS EMPTY / IS NOT EMPTY (case-insensitive)
-	isNullRegex := regexp.MustCompile(`(?i)^(\S+)\s+IS\s+(NOT\s+)?(NULL|EMPTY)$`)
-	if matches := isNullRegex.FindStringSubmatch(clause); len(matches) == 4 {
-		operator := strings.ToUpper(matches[3]) // NULL or EMPTY
-		if matches[2] != "" {
-			operator = "IS NOT " + operator
-		} else {
-			operator = "IS " + operator
+// listAuditFilter reports whether any of the audit filter flags is set.
+func listAuditFilter() bool {
+	return listCreatedBy != "" || listUpdatedSince != "" || listDeletedBy != "" || listBranch != ""
+}
+
+// checkWhereColumns reports the first condition naming neither a stash
+// column nor a system column. Returns true if every condition is valid.
+func checkWhereColumns(stash *model.Stash, conditions []storage.WhereCondition) bool {
+	for _, cond := range conditions {
+		if !storage.IsBaseColumn(cond.Field) && resolveColumn(stash, cond.Field) == nil {
+			return false
 		}
-		return storage.WhereCondition{
-			Field:    matches[1],
-			Operator: operator,
-			Value:    "",
-		}, nil
-	}
-
-	// Check for LIKE operator (case-insensitive)
-	likeRegex := regexp.MustCompile(`(?i)^(\S+)\s+LIKE\s+(.+)$`)
-	if matches := likeRegex.FindStringSubmatch(clause); len(matches) == 3 {
-		return storage.WhereCondition{
-			Field:    matches[1],
-			Operator: "LIKE",
-			Value:    stripQuotes(matches[2]),
-		}, nil
-	}
-
-	// Check for comparison operators (order matters: >= before >, <= before <, != before =)
-	operators := []string{"!=", ">=", "<=", "<>", ">", "<", "="}
-	for _, op := range operators {
-		if idx := strings.Index(clause, op); idx > 0 {
-			field := strings.TrimSpace(clause[:idx])
-			value := strings.TrimSpace(clause[idx+len(op):])
-			return storage.WhereCondition{
-				Field:    field,
-				Operator: op,
-				Value:    stripQuotes(value),
-			}, nil
+	}
+	return true
+}
+
+// checkRowPicks reports a negative --head, --tail, or --sample, more than
+// one of them, or --seed without --sample. Returns true if they are valid.
+func checkRowPicks(head, tail, sample int, seed int64) bool {
+	picks := 0
+	for _, pick := range []struct {
+		name string
+		n    int
+	}{{"head", head}, {"tail", tail}, {"sample", sample}} {
+		if pick.n < 0 {
+			ExitValidationError(fmt.Sprintf("--%s must not be negative", pick.name), map[string]interface{}{pick.name: pick.n})
+			return false
+		}
+		if pick.n > 0 {
+			picks++
 		}
 	}
+	if picks > 1 {
+		ExitValidationError("only one of --head, --tail, and --sample can be used", nil)
+		return false
+	}
+	if seed != 0 && sample == 0 {
+		ExitValidationError("--seed requires --sample", nil)
+		return false
+	}
+	return true
+}
 
-	return storage.WhereCondition{}, fmt.Errorf("invalid WHERE clause: %s (expected format: field=value, field>value, field LIKE pattern, or field IS NULL/EMPTY)", clause)
+// recordWithDepth returns a record's JSON object with "_depth" added.
+func recordWithDepth(rec *model.Record, depth int) interface{} {
+	output := make(map[string]interface{})
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return rec
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return rec
+	}
+	output["_depth"] = depth
+	return output
 }
 
-// stripQuotes removes surrounding quotes from a string.
-func stripQuotes(s string) string {
-	s = strings.TrimSpace(s)
-	if len(s) >= 2 {
-		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
-			return s[1 : len(s)-1]
-		}
+// recordStatus returns a record's lifecycle status: active, archived, or
+// deleted.
+func recordStatus(rec *model.Record) string {
+	if rec.IsDeleted() {
+		return "deleted"
+	}
+	if rec.IsArchived() {
+		return "archived"
 	}
-	return s
+	return "active"
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	if !checkSearchMode(listSearchMode) {
+		return nil
+	}
+	orderKeys, err := storage.ParseOrderBy(listOrderBy, listDesc)
+	if err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"order_by": listOrderBy})
+		return nil
+	}
+	if listDepth < 0 {
+		ExitValidationError("--depth must not be negative", map[string]interface{}{"depth": listDepth})
+		return nil
+	}
+	if !checkRowPicks(listHead, listTail, listSample, listSeed) {
+		return nil
+	}
+	if listHead > 0 && listLimit > 0 {
+		ExitValidationError("--head and --limit cannot be combined", nil)
+		return nil
+	}
+	formats := 0
+	for _, set := range []bool{GetJSONOutput(), listCSV, listTSV} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		ExitValidationError("only one of --json, --csv, and --tsv can be used", nil)
+		return nil
+	}
+	recursive := listRecursive || listDepth > 0
+	if recursive && listParent == "" {
+		ExitValidationError("--recursive and --depth require --parent", nil)
+		return nil
+	}
+	var updatedSince time.Time
+	if listUpdatedSince != "" {
+		if updatedSince, err = parseTimeFilter(listUpdatedSince); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"updated_since": listUpdatedSince})
+			return nil
+		}
+	}
+
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
 	if err != nil {
@@ -199,7 +377,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -216,10 +394,29 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	// Fill in flags left out from the user's saved preferences
+	columnList, limit := listColumns, listLimit
+	if listHead > 0 {
+		limit = listHead
+	}
+	prefs := listPreferences(stash.Name)
+	if columnList == "" {
+		columnList = prefs["list.columns"]
+	}
+	if limit == 0 {
+		limit, _ = strconv.Atoi(prefs["list.limit"])
+	}
+	if listOrderBy == "" && !listDesc && prefs["list.order-by"] != "" {
+		if orderKeys, err = storage.ParseOrderBy(prefs["list.order-by"], false); err != nil {
+			ExitValidationError(fmt.Sprintf("saved list.order-by: %v", err), map[string]interface{}{"order_by": prefs["list.order-by"]})
+			return nil
+		}
+	}
+
 	// Parse WHERE clauses
 	var whereConditions []storage.WhereCondition
 	for _, clause := range listWhere {
-		cond, err := parseWhereClause(clause)
+		cond, err := storage.ParseWhereClause(clause)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			Exit(1)
@@ -227,58 +424,128 @@ func runList(cmd *cobra.Command, args []string) error {
 		}
 		whereConditions = append(whereConditions, cond)
 	}
+	if !checkWhereColumns(stash, whereConditions) {
+		return nil
+	}
+	filter, ok := defaultFilter(stash)
+	if !ok {
+		return nil
+	}
+	whereConditions = append(filter, whereConditions...)
 
 	// Parse columns selection
 	var selectedColumns []string
-	if listColumns != "" {
-		for _, col := range strings.Split(listColumns, ",") {
+	if columnList != "" {
+		for _, col := range strings.Split(columnList, ",") {
 			col = strings.TrimSpace(col)
 			if col != "" {
 				selectedColumns = append(selectedColumns, col)
 			}
 		}
+		expanded, ok := expandColumnGroups(stash, selectedColumns)
+		if !ok {
+			return nil
+		}
+		selectedColumns = expanded
 	}
 
 	// Build list options
 	opts := storage.ListOptions{
-		IncludeDeleted: listDeleted,
-		Limit:          listLimit,
-		Offset:         listOffset,
-		OrderBy:        listOrderBy,
-		Descending:     listDesc,
-		Where:          whereConditions,
-		Search:         listSearch,
-		Columns:        selectedColumns,
+		IncludeDeleted:  listDeleted || listDeletedBy != "",
+		ExcludeArchived: !listArchived,
+		ArchivedOnly:    listArchived,
+		Limit:           limit,
+		Offset:          listOffset,
+		Sample:          listSample,
+		SampleSeed:      listSeed,
+		Tail:            listTail,
+		OrderBy:         orderKeys,
+		Descending:      listDesc && len(orderKeys) == 0,
+		Where:           whereConditions,
+		Search:          listSearch,
+		SearchMode:      listSearchMode,
+		Columns:         selectedColumns,
+		UpdatedSince:    updatedSince,
+		Branch:          listBranch,
+	}
+	if listAssignedTo != "" {
+		opts.AssignedTo = resolveAssignee(listAssignedTo, ctx.Actor)
+	}
+	if listCreatedBy != "" {
+		opts.CreatedBy = resolveAssignee(listCreatedBy, ctx.Actor)
+	}
+	if listDeletedBy != "" {
+		opts.DeletedBy = resolveAssignee(listDeletedBy, ctx.Actor)
 	}
 
 	// Handle parent filtering
 	if listParent != "" {
 		opts.ParentID = listParent
-	} else if listAll {
+		opts.Recursive = recursive
+		opts.MaxDepth = listDepth
+	} else if listAll || opts.AssignedTo != "" || listAuditFilter() {
 		opts.ParentID = "*" // All records
 	} else {
 		opts.ParentID = "" // Root records only
 	}
 
-	// List records
-	records, err := store.ListRecords(ctx.Stash, opts)
-	if err != nil {
-		return fmt.Errorf("failed to list records: %w", err)
+	if listExplain {
+		plan, err := store.ExplainList(ctx.Stash, opts)
+		if err != nil {
+			return fmt.Errorf("failed to explain list: %w", err)
+		}
+		filtered := len(opts.Where) > 0 || opts.Search != "" || opts.AssignedTo != "" ||
+			listAuditFilter() || (opts.ParentID != "" && opts.ParentID != "*")
+		return writeQueryPlan(os.Stdout, plan, filtered)
 	}
 
-	// JSON output
+	// JSON output is streamed straight from the cache so piping a large
+	// stash into another tool uses constant memory
 	if GetJSONOutput() {
-		data, err := json.MarshalIndent(records, "", "  ")
+		records := func(fn func(*model.Record) error) error {
+			return store.IterateRecords(ctx.Stash, opts, fn)
+		}
+		parentDepth := model.GetDepth(listParent)
+		_, err := writeJSONArray(os.Stdout, records, func(rec *model.Record) interface{} {
+			if recursive {
+				return recordWithDepth(rec, model.GetDepth(rec.ID)-parentDepth)
+			}
+			return rec
+		})
 		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+			return fmt.Errorf("failed to list records: %w", err)
 		}
-		fmt.Println(string(data))
 		return nil
 	}
 
-	// Human-readable output
-	if len(records) == 0 {
-		fmt.Println("No records found.")
+	if listCSV || listTSV {
+		comma := ','
+		if listTSV {
+			comma = '\t'
+		}
+		columns := stash.Columns.Names()
+		if len(selectedColumns) > 0 {
+			columns = make([]string, len(selectedColumns))
+			for i, name := range selectedColumns {
+				columns[i] = name
+				if col := stash.Columns.Find(name); col != nil {
+					columns[i] = col.Name
+				}
+			}
+		}
+		err := writeDelimited(os.Stdout, append([]string{"_id"}, columns...), comma, !listNoHeaders,
+			func(write func(map[string]interface{}) error) error {
+				return store.IterateRecords(ctx.Stash, opts, func(rec *model.Record) error {
+					row := map[string]interface{}{"_id": rec.ID}
+					for name, value := range rec.Fields {
+						row[name] = value
+					}
+					return write(row)
+				})
+			})
+		if err != nil {
+			return fmt.Errorf("failed to list records: %w", err)
+		}
 		return nil
 	}
 
@@ -295,91 +562,66 @@ func runList(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Calculate column widths
-	idWidth := 4 // "ID" header
-	colWidths := make(map[string]int)
-	for _, col := range displayColumns {
-		colWidths[col] = len(col)
-	}
-	statusWidth := 6 // "Status" header
-
-	for _, rec := range records {
-		if len(rec.ID) > idWidth {
-			idWidth = len(rec.ID)
-		}
-		for _, col := range displayColumns {
-			if val, ok := rec.Fields[col]; ok {
-				s := fmt.Sprintf("%v", val)
-				if len(s) > colWidths[col] {
-					colWidths[col] = len(s)
-				}
+	if IsPorcelain() {
+		err := store.IterateRecords(ctx.Stash, opts, func(rec *model.Record) error {
+			fields := []string{rec.ID, recordStatus(rec), porcelainTime(rec.UpdatedAt)}
+			for _, col := range displayColumns {
+				fields = append(fields, valueText(rec.Fields[col]))
 			}
+			printPorcelain(fields...)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list records: %w", err)
 		}
+		return nil
 	}
 
-	// Cap column widths for readability
-	if idWidth > 20 {
-		idWidth = 20
-	}
-	for col := range colWidths {
-		if colWidths[col] > 40 {
-			colWidths[col] = 40
-		}
+	// List records
+	records, err := store.ListRecords(ctx.Stash, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
 	}
 
-	// Print header
-	headerParts := []string{fmt.Sprintf("%-*s", idWidth, "ID")}
-	separatorParts := []string{strings.Repeat("-", idWidth)}
-	for _, col := range displayColumns {
-		headerParts = append(headerParts, fmt.Sprintf("%-*s", colWidths[col], col))
-		separatorParts = append(separatorParts, strings.Repeat("-", colWidths[col]))
+	// Human-readable output
+	if ctx.CheckedOut {
+		Infof("On branch %s (checked out)\n", ctx.Branch)
+	}
+	if len(records) == 0 {
+		Infof("No records found.\n")
+		noteDefaultFilter(stash)
+		return nil
 	}
-	headerParts = append(headerParts, fmt.Sprintf("%-*s", statusWidth, "Status"))
-	separatorParts = append(separatorParts, strings.Repeat("-", statusWidth))
-	headerParts = append(headerParts, "Updated")
-	separatorParts = append(separatorParts, strings.Repeat("-", 19))
 
-	fmt.Println(strings.Join(headerParts, "  "))
-	fmt.Println(strings.Join(separatorParts, "  "))
+	// Active locks show as a "locked" status
+	locked := lockedRecordIDs(ctx.StashDir, ctx.Stash)
 
-	// Print records
+	columns := []tableColumn{{Header: "ID", Max: 20, System: true}}
+	for _, col := range displayColumns {
+		columns = append(columns, tableColumn{Header: col, Max: 40})
+	}
+	columns = append(columns,
+		tableColumn{Header: "Status", Style: statusStyle},
+		tableColumn{Header: "Updated", System: true},
+	)
+	tbl := newTable(columns...)
 	for _, rec := range records {
-		// Format ID (truncate if needed)
-		id := rec.ID
-		if len(id) > idWidth {
-			id = id[:idWidth-3] + "..."
-		}
-
-		rowParts := []string{fmt.Sprintf("%-*s", idWidth, id)}
-
-		// Format column values
+		row := []string{rec.ID}
 		for _, col := range displayColumns {
-			val := ""
-			if v, ok := rec.Fields[col]; ok {
-				val = fmt.Sprintf("%v", v)
-				if len(val) > colWidths[col] {
-					val = val[:colWidths[col]-3] + "..."
-				}
-			}
-			rowParts = append(rowParts, fmt.Sprintf("%-*s", colWidths[col], val))
+			row = append(row, displayValue(stash.Columns.Find(col), rec.Fields[col]))
 		}
-
-		// Format status
-		status := "active"
-		if rec.IsDeleted() {
-			status = "deleted"
+		status := recordStatus(rec)
+		if status == "active" && locked[rec.ID] {
+			status = "locked"
 		}
-		rowParts = append(rowParts, fmt.Sprintf("%-*s", statusWidth, status))
-
-		// Format updated time
-		updated := rec.UpdatedAt.Format("2006-01-02 15:04:05")
-		rowParts = append(rowParts, updated)
-
-		fmt.Println(strings.Join(rowParts, "  "))
+		row = append(row, status, rec.UpdatedAt.Format("2006-01-02 15:04:05"))
+		tbl.addRow(row...)
 	}
+	tbl.print()
 
 	// Print count
-	fmt.Printf("\nTotal: %d record(s)\n", len(records))
+	Infof("\nTotal: %d record(s)\n", len(records))
+	noteDefaultFilter(stash)
 
 	return nil
 }
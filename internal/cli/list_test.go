@@ -2,8 +2,10 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -1163,69 +1165,6 @@ func TestUC_QRY_001_FilterRecords(t *testing.T) {
 	})
 }
 
-// TestParseWhereClause tests the WHERE clause parser
-func TestParseWhereClause(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected storage.WhereCondition
-		wantErr  bool
-	}{
-		// Existing operators
-		{"field=value", storage.WhereCondition{Field: "field", Operator: "=", Value: "value"}, false},
-		{"field!=value", storage.WhereCondition{Field: "field", Operator: "!=", Value: "value"}, false},
-		{"field>100", storage.WhereCondition{Field: "field", Operator: ">", Value: "100"}, false},
-		{"field<100", storage.WhereCondition{Field: "field", Operator: "<", Value: "100"}, false},
-		{"field>=100", storage.WhereCondition{Field: "field", Operator: ">=", Value: "100"}, false},
-		{"field<=100", storage.WhereCondition{Field: "field", Operator: "<=", Value: "100"}, false},
-		{"field LIKE %pattern%", storage.WhereCondition{Field: "field", Operator: "LIKE", Value: "%pattern%"}, false},
-
-		// IS NULL / IS NOT NULL
-		{"field IS NULL", storage.WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
-		{"field IS NOT NULL", storage.WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
-
-		// IS EMPTY / IS NOT EMPTY
-		{"field IS EMPTY", storage.WhereCondition{Field: "field", Operator: "IS EMPTY", Value: ""}, false},
-		{"field IS NOT EMPTY", storage.WhereCondition{Field: "field", Operator: "IS NOT EMPTY", Value: ""}, false},
-
-		// Case insensitivity
-		{"field is null", storage.WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
-		{"field is not null", storage.WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
-		{"field is empty", storage.WhereCondition{Field: "field", Operator: "IS EMPTY", Value: ""}, false},
-		{"field is not empty", storage.WhereCondition{Field: "field", Operator: "IS NOT EMPTY", Value: ""}, false},
-		{"Field Is Empty", storage.WhereCondition{Field: "Field", Operator: "IS EMPTY", Value: ""}, false},
-		{"Field IS Not NULL", storage.WhereCondition{Field: "Field", Operator: "IS NOT NULL", Value: ""}, false},
-
-		// Whitespace handling
-		{"  field IS NULL  ", storage.WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
-		{"field   IS   NOT   NULL", storage.WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
-
-		// Invalid
-		{"invalid", storage.WhereCondition{}, true},
-		{"", storage.WhereCondition{}, true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got, err := parseWhereClause(tt.input)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseWhereClause(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
-				return
-			}
-			if !tt.wantErr {
-				if got.Field != tt.expected.Field {
-					t.Errorf("parseWhereClause(%q) Field = %q, want %q", tt.input, got.Field, tt.expected.Field)
-				}
-				if got.Operator != tt.expected.Operator {
-					t.Errorf("parseWhereClause(%q) Operator = %q, want %q", tt.input, got.Operator, tt.expected.Operator)
-				}
-				if got.Value != tt.expected.Value {
-					t.Errorf("parseWhereClause(%q) Value = %q, want %q", tt.input, got.Value, tt.expected.Value)
-				}
-			}
-		})
-	}
-}
-
 // TestListRecordsStatus tests that status column shows correctly
 func TestListRecordsStatus(t *testing.T) {
 	t.Run("shows deleted status for deleted records", func(t *testing.T) {
@@ -1266,3 +1205,333 @@ func TestListRecordsStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestListTypedOrderBy(t *testing.T) {
+	listNames := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"list", "--json"}, args...)...)
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		var names []string
+		for _, rec := range records {
+			names = append(names, rec["Name"].(string))
+		}
+		return names
+	}
+
+	setup := func(t *testing.T, priceFlags ...string) func() {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		captureSchemaOutput(t, append([]string{"column", "add", "Price"}, priceFlags...)...)
+		for _, item := range [][2]string{{"Laptop", "1000"}, {"Phone", "999"}, {"Cable", "50"}} {
+			captureSchemaOutput(t, "add", item[0], "--set", "Price="+item[1])
+		}
+		ExitCode = 0
+		return cleanup
+	}
+
+	t.Run("number columns sort numerically", func(t *testing.T) {
+		defer setup(t, "--validate", "number")()
+		names := listNames(t, "--order-by", "Price")
+		if strings.Join(names, ",") != "Cable,Phone,Laptop" {
+			t.Errorf("expected numeric order, got %v", names)
+		}
+	})
+
+	t.Run("numeric suffix overrides untyped columns", func(t *testing.T) {
+		defer setup(t)()
+		if names := listNames(t, "--order-by", "Price"); strings.Join(names, ",") != "Laptop,Cable,Phone" {
+			t.Errorf("expected text order for untyped column, got %v", names)
+		}
+		if names := listNames(t, "--order-by", "Price:numeric"); strings.Join(names, ",") != "Cable,Phone,Laptop" {
+			t.Errorf("expected numeric order, got %v", names)
+		}
+	})
+
+	t.Run("multiple keys with directions", func(t *testing.T) {
+		defer setup(t, "--validate", "number")()
+		captureSchemaOutput(t, "add", "Adapter", "--set", "Price=999")
+		names := listNames(t, "--order-by", "Price desc,Name")
+		if strings.Join(names, ",") != "Laptop,Adapter,Phone,Cable" {
+			t.Errorf("expected Price desc then Name, got %v", names)
+		}
+		names = listNames(t, "--order-by", "Price,Name desc", "--desc")
+		if strings.Join(names, ",") != "Laptop,Phone,Adapter,Cable" {
+			t.Errorf("expected --desc to apply to keys without a direction, got %v", names)
+		}
+	})
+
+	t.Run("invalid sort type", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "list", "--order-by", "Price:money")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "list", "--order-by", "Price sideways")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestListRecursive(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		var root map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &root)
+		rootID := root["_id"].(string)
+		captureSchemaOutput(t, "add", "Battery", "--parent", rootID)
+		captureSchemaOutput(t, "add", "Cell", "--parent", rootID+".1")
+		captureSchemaOutput(t, "add", "Charger", "--parent", rootID)
+		ExitCode = 0
+		return rootID, cleanup
+	}
+
+	listDepths := func(t *testing.T, args ...string) map[string]float64 {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"list", "--json"}, args...)...)
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		depths := make(map[string]float64)
+		for _, rec := range records {
+			depth, _ := rec["_depth"].(float64)
+			depths[rec["Name"].(string)] = depth
+		}
+		return depths
+	}
+
+	t.Run("recursive includes grandchildren with depth", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		depths := listDepths(t, "--parent", rootID, "--recursive")
+		if len(depths) != 3 || depths["Battery"] != 1 || depths["Charger"] != 1 || depths["Cell"] != 2 {
+			t.Errorf("expected all descendants with depths, got %v", depths)
+		}
+	})
+
+	t.Run("depth limits levels", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		depths := listDepths(t, "--parent", rootID, "--depth", "1")
+		if len(depths) != 2 || depths["Cell"] != 0 {
+			t.Errorf("expected only direct children, got %v", depths)
+		}
+	})
+
+	t.Run("requires --parent", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "list", "--recursive")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestListDelimitedOutput(t *testing.T) {
+	setup := func(t *testing.T) func() {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		captureSchemaOutput(t, "add", "Laptop, 15 inch", "--set", "Price=999")
+		ExitCode = 0
+		return cleanup
+	}
+
+	t.Run("csv with headers and all columns", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "list", "--csv")
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if len(lines) != 2 || lines[0] != "_id,Name,Price" {
+			t.Fatalf("expected header and one row, got %q", output)
+		}
+		if !strings.HasPrefix(lines[1], "inv-") || !strings.HasSuffix(lines[1], `,"Laptop, 15 inch",999`) {
+			t.Errorf("unexpected row %q", lines[1])
+		}
+	})
+
+	t.Run("tsv with selected columns and no headers", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "list", "--tsv", "--columns", "price", "--no-headers")
+		fields := strings.Split(strings.TrimSpace(output), "\t")
+		if len(fields) != 2 || fields[1] != "999" {
+			t.Errorf("expected _id and Price only, got %q", output)
+		}
+	})
+
+	t.Run("formats are exclusive", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "list", "--csv", "--tsv")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestListAuditFilters(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+	defer cleanup()
+
+	listIDs := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		var records []map[string]interface{}
+		output := captureSchemaOutput(t, append([]string{"list", "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		ids := make([]string, len(records))
+		for i, rec := range records {
+			ids[i] = rec["_id"].(string)
+		}
+		return ids
+	}
+
+	alice := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--actor", "alice"))
+	bob := strings.TrimSpace(captureSchemaOutput(t, "add", "Fix bug", "--actor", "bob"))
+	child := strings.TrimSpace(captureSchemaOutput(t, "add", "Subtask", "--parent", bob, "--actor", "alice"))
+	removed := strings.TrimSpace(captureSchemaOutput(t, "add", "Old task", "--actor", "alice"))
+	captureSchemaOutput(t, "rm", removed, "--actor", "bob", "--yes")
+
+	if ids := listIDs(t, "--created-by", "alice"); len(ids) != 2 || ids[0] == removed || ids[1] == removed {
+		t.Errorf("expected alice's live records at any depth (%s, %s), got %v", alice, child, ids)
+	}
+	if ids := listIDs(t, "--created-by", "me", "--actor", "bob"); len(ids) != 1 || ids[0] != bob {
+		t.Errorf("expected --created-by me to match bob's record, got %v", ids)
+	}
+	if ids := listIDs(t, "--deleted-by", "bob"); len(ids) != 1 || ids[0] != removed {
+		t.Errorf("expected --deleted-by to find the deleted record, got %v", ids)
+	}
+	if ids := listIDs(t, "--updated-since", "1h"); len(ids) != 3 {
+		t.Errorf("expected 3 records updated in the last hour, got %v", ids)
+	}
+	if ids := listIDs(t, "--updated-since", "2099-01-01"); len(ids) != 0 {
+		t.Errorf("expected no records updated in the future, got %v", ids)
+	}
+
+	ExitCode = 0
+	captureSchemaOutput(t, "list", "--updated-since", "yesterday")
+	if ExitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid --updated-since, got %d", ExitCode)
+	}
+	ExitCode = 0
+}
+
+func TestListExplain(t *testing.T) {
+	setup := func(t *testing.T) func() {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Price=999")
+		ExitCode = 0
+		return cleanup
+	}
+
+	t.Run("shows the SQL and plan instead of records", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "list", "--where", "Price>100", "--order-by", "Name", "--explain")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		for _, want := range []string{"SQL:", `FROM "inventory"`, "Query plan:", "Hint: SQLite reads every row of inventory"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "Laptop") {
+			t.Errorf("expected no records to be listed, got:\n%s", output)
+		}
+	})
+
+	t.Run("an indexed filter has no scan hint", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "list", "--assigned-to", "alice", "--explain")
+		if !strings.Contains(output, "USING INDEX") {
+			t.Errorf("expected the assignee index to be used, got:\n%s", output)
+		}
+		if strings.Contains(output, "reads every row") {
+			t.Errorf("expected no scan hint, got:\n%s", output)
+		}
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		defer setup(t)()
+		output := captureSchemaOutput(t, "list", "--where", "Name=Laptop", "--limit", "5", "--explain", "--json")
+		var plan struct {
+			SQL  string        `json:"sql"`
+			Args []interface{} `json:"args"`
+			Plan []struct {
+				Detail string `json:"detail"`
+			} `json:"plan"`
+			Hints []string `json:"hints"`
+		}
+		if err := json.Unmarshal([]byte(output), &plan); err != nil {
+			t.Fatalf("expected JSON output, got %q: %v", output, err)
+		}
+		if !strings.Contains(plan.SQL, "LIMIT ?") || len(plan.Args) != 2 || plan.Args[0] != "Laptop" {
+			t.Errorf("unexpected SQL %q with args %v", plan.SQL, plan.Args)
+		}
+		if len(plan.Plan) == 0 {
+			t.Error("expected plan steps")
+		}
+	})
+}
+
+func TestListRowPicks(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "N"})
+	defer cleanup()
+	for i := 1; i <= 8; i++ {
+		captureSchemaOutput(t, "add", fmt.Sprintf("Item %d", i), "--set", fmt.Sprintf("N=%d", i))
+	}
+	names := func(args ...string) []string {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"list", "--order-by", "Name", "--json"}, args...)...)
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("expected JSON, got %q", output)
+		}
+		var names []string
+		for _, r := range records {
+			names = append(names, r["Name"].(string))
+		}
+		return names
+	}
+
+	t.Run("head and tail", func(t *testing.T) {
+		if got := strings.Join(names("--head", "2"), ","); got != "Item 1,Item 2" {
+			t.Errorf("unexpected head %q", got)
+		}
+		if got := strings.Join(names("--tail", "2"), ","); got != "Item 7,Item 8" {
+			t.Errorf("unexpected tail %q", got)
+		}
+	})
+
+	t.Run("seeded sample", func(t *testing.T) {
+		first := names("--sample", "3", "--seed", "11")
+		if len(first) != 3 || !sort.StringsAreSorted(first) {
+			t.Errorf("expected 3 sorted records, got %v", first)
+		}
+		if again := names("--sample", "3", "--seed", "11"); strings.Join(again, ",") != strings.Join(first, ",") {
+			t.Errorf("expected the same sample, got %v and %v", first, again)
+		}
+	})
+
+	t.Run("picks are exclusive", func(t *testing.T) {
+		captureSchemaOutput(t, "list", "--head", "2", "--tail", "2")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "list", "--seed", "3")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for --seed without --sample, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,223 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/user/stash/internal/model"
+)
+
+// Assignment operators accepted by set and bulk-set. += and -= add and
+// remove one element of a list column, or add to and subtract from a
+// number column.
+const (
+	assignSet    = "="
+	assignAdd    = "+="
+	assignRemove = "-="
+)
+
+// fieldAssignment is one Field=Value, Field+=Value, or Field-=Value
+// argument.
+type fieldAssignment struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// parseAssignment splits an assignment argument at its operator. Returns
+// false if the argument has no '='.
+func parseAssignment(arg string) (fieldAssignment, bool) {
+	idx := strings.Index(arg, "=")
+	if idx < 0 {
+		return fieldAssignment{}, false
+	}
+	a := fieldAssignment{
+		Field: arg[:idx],
+		Op:    assignSet,
+		Value: strings.TrimSpace(arg[idx+1:]),
+	}
+	// Column names are letters, digits, and underscores, so a trailing
+	// + or - belongs to the operator
+	if strings.HasSuffix(a.Field, "+") {
+		a.Field, a.Op = strings.TrimSuffix(a.Field, "+"), assignAdd
+	} else if strings.HasSuffix(a.Field, "-") {
+		a.Field, a.Op = strings.TrimSuffix(a.Field, "-"), assignRemove
+	}
+	a.Field = strings.TrimSpace(a.Field)
+	return a, true
+}
+
+// valueReader reads values given as @file or @- (stdin), like curl, so
+// large or multi-line text needs no shell quoting. A value starting with
+// @@ is the literal value with one @ removed.
+type valueReader struct {
+	stdin     io.Reader
+	stdinRead bool
+}
+
+// resolve returns the value an argument stands for. File and stdin
+// contents are returned exactly as read.
+func (r *valueReader) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "@@"):
+		return value[1:], nil
+	case value == "@-":
+		if r.stdinRead {
+			return "", errors.New("stdin (@-) can only be read once")
+		}
+		r.stdinRead = true
+		data, err := io.ReadAll(r.stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return string(data), nil
+	case strings.HasPrefix(value, "@") && len(value) > 1:
+		data, err := os.ReadFile(value[1:])
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", fmt.Errorf("file '%s' not found (use @@ for a value starting with @)", value[1:])
+			}
+			return "", fmt.Errorf("failed to read '%s': %w", value[1:], err)
+		}
+		return string(data), nil
+	}
+	return value, nil
+}
+
+// parseListValue parses a list given on the command line: a JSON array,
+// or comma-separated elements. An empty value is an empty list.
+func parseListValue(value string) []interface{} {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		var elems []interface{}
+		if err := json.Unmarshal([]byte(value), &elems); err == nil {
+			return listOf(listElements(elems))
+		}
+	}
+
+	var elems []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			elems = append(elems, part)
+		}
+	}
+	return listOf(elems)
+}
+
+// columnValue converts a value given on the command line to the form
+// stored for the column: a list for list columns, the string otherwise.
+// Empty lists are stored as no value.
+func columnValue(col *model.Column, value string) interface{} {
+	if col == nil || !col.List {
+		return value
+	}
+	if list := parseListValue(value); len(list) > 0 {
+		return list
+	}
+	return nil
+}
+
+// listElements returns the elements of a stored value as strings. A
+// scalar is a one-element list and an unset or empty value has none.
+func listElements(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, e := range v {
+			if e != nil {
+				elems = append(elems, fmt.Sprintf("%v", e))
+			}
+		}
+		return elems
+	case []string:
+		return v
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
+
+// listOf converts elements to the []interface{} form that list values
+// take after a JSON round trip.
+func listOf(elems []string) []interface{} {
+	list := make([]interface{}, len(elems))
+	for i, e := range elems {
+		list[i] = e
+	}
+	return list
+}
+
+// editList applies a += or -= assignment to a list value. Adding an
+// element already present and removing one that is absent change nothing.
+// Returns nil when the list ends up empty.
+func editList(current interface{}, op, element string) interface{} {
+	elems := listElements(current)
+	switch op {
+	case assignAdd:
+		for _, e := range elems {
+			if e == element {
+				return listOf(elems)
+			}
+		}
+		elems = append(elems, element)
+	case assignRemove:
+		kept := elems[:0]
+		for _, e := range elems {
+			if e != element {
+				kept = append(kept, e)
+			}
+		}
+		elems = kept
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+	return listOf(elems)
+}
+
+// editNumber applies a += or -= assignment to a number value. An unset
+// value counts as zero. The result keeps the stored form of the current
+// value: a string stays a string and a JSON number stays a number.
+func editNumber(current interface{}, op, amount string) (interface{}, error) {
+	delta, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a number", amount)
+	}
+
+	var value float64
+	switch v := current.(type) {
+	case nil:
+	case float64:
+		value = v
+	case string:
+		if strings.TrimSpace(v) != "" {
+			if value, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				return nil, fmt.Errorf("current value '%s' is not a number", v)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("current value '%v' is not a number", v)
+	}
+
+	if op == assignRemove {
+		delta = -delta
+	}
+	value += delta
+
+	if _, ok := current.(float64); ok {
+		return value, nil
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want fieldAssignment
+		ok   bool
+	}{
+		{"Tags=a,b", fieldAssignment{"Tags", assignSet, "a,b"}, true},
+		{"Tags+=urgent", fieldAssignment{"Tags", assignAdd, "urgent"}, true},
+		{"Tags-=urgent", fieldAssignment{"Tags", assignRemove, "urgent"}, true},
+		{"Note=x=y", fieldAssignment{"Note", assignSet, "x=y"}, true},
+		{"Tags", fieldAssignment{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseAssignment(tt.arg)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseAssignment(%q) = %+v, %v; want %+v, %v", tt.arg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseListValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []interface{}
+	}{
+		{"a, b,,c", []interface{}{"a", "b", "c"}},
+		{`["a,b", "c"]`, []interface{}{"a,b", "c"}},
+		{"", []interface{}{}},
+	}
+	for _, tt := range tests {
+		if got := parseListValue(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseListValue(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEditList(t *testing.T) {
+	tests := []struct {
+		name    string
+		current interface{}
+		op      string
+		element string
+		want    interface{}
+	}{
+		{"add to unset", nil, assignAdd, "a", []interface{}{"a"}},
+		{"add new", []interface{}{"a"}, assignAdd, "b", []interface{}{"a", "b"}},
+		{"add present", []interface{}{"a", "b"}, assignAdd, "a", []interface{}{"a", "b"}},
+		{"add to scalar", "a", assignAdd, "b", []interface{}{"a", "b"}},
+		{"remove", []interface{}{"a", "b", "a"}, assignRemove, "a", []interface{}{"b"}},
+		{"remove absent", []interface{}{"a"}, assignRemove, "b", []interface{}{"a"}},
+		{"remove last", []interface{}{"a"}, assignRemove, "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editList(tt.current, tt.op, tt.element); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("editList(%v, %s, %s) = %v, want %v", tt.current, tt.op, tt.element, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		current interface{}
+		op      string
+		amount  string
+		want    interface{}
+		wantErr bool
+	}{
+		{"increment unset", nil, assignAdd, "5", "5", false},
+		{"increment string", "10", assignAdd, "5", "15", false},
+		{"decrement string", "10", assignRemove, "1", "9", false},
+		{"keeps JSON numbers", float64(2), assignAdd, "0.5", 2.5, false},
+		{"below zero", "0", assignRemove, "3", "-3", false},
+		{"bad amount", "10", assignAdd, "five", nil, true},
+		{"bad current", "ten", assignAdd, "1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := editNumber(tt.current, tt.op, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("editNumber(%v, %s, %s) error = %v, wantErr %v", tt.current, tt.op, tt.amount, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("editNumber(%v, %s, %s) = %#v, want %#v", tt.current, tt.op, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	content := "# Notes\n\nLine with \"quotes\", 'apostrophes', and $VARS\n\ttabbed ünïcode\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &valueReader{stdin: strings.NewReader("from stdin\n")}
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "plain", false},
+		{"@" + path, content, false},
+		{"@@alice", "@alice", false},
+		{"@", "@", false},
+		{"@-", "from stdin\n", false},
+		{"@-", "", true}, // stdin was already read
+		{"@" + path + ".missing", "", true},
+	}
+	for _, tt := range tests {
+		got, err := r.resolve(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolve(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
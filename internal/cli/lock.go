@@ -11,8 +11,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/logging"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
+	"github.com/user/stash/internal/platform"
 )
 
 // Lock represents a record lock for multi-agent coordination
@@ -39,9 +40,17 @@ const (
 // Default lock timeout in seconds
 const DefaultLockTimeout = 300
 
+// lockAuditRetention is how long locks --prune keeps lock audit events
+// by default.
+const lockAuditRetention = 90 * 24 * time.Hour
+
 var (
 	lockAgent   string
 	lockTimeout int
+	locksPrune  bool
+	locksStats  bool
+	locksSince  string
+	locksBefore string
 )
 
 var lockCmd = &cobra.Command{
@@ -55,6 +64,10 @@ from updating it until the lock is released or expires.
 The lock is associated with an agent name (defaults to current actor).
 Locks auto-expire after a timeout (default 300 seconds / 5 minutes).
 
+Each stash keeps its locks in .stash/<stash>/locks.json. Lock commands
+from parallel agents take turns updating the file, so exactly one agent
+wins a contested record and no lock is lost.
+
 Examples:
   stash lock inv-ex4j                           # Lock with default timeout
   stash lock inv-ex4j --agent worker-1          # Lock as specific agent
@@ -104,12 +117,51 @@ var locksCmd = &cobra.Command{
 
 Shows which records are locked, by which agent, and when the lock expires.
 
+Expired locks are removed whenever lock, unlock, or locks runs. --prune
+removes them and reports what was removed, instead of listing locks. It
+also drops lock audit events older than 90 days, or than --before, so the
+trail does not grow without bound; the lock event of a lock still held
+is kept.
+
+Every lock, refresh, unlock, expiry, and rejected lock attempt is recorded
+in the stash's lock audit trail (.stash/<stash>/lock-audit.jsonl). --stats
+summarizes it per agent: how often each agent locks, how many of its locks
+expired rather than being released, how often it was refused a lock, and
+how long it held locks. Agents that held locks longest come first.
+
+Options:
+  --prune          Remove expired locks and old audit events, and report them
+  --before <when>  With --prune, drop audit events before a duration ago
+                   (30d, 1w) or a date (2025-01-31) instead of 90 days ago
+  --stats          Show lock activity per agent from the lock audit trail
+  --since <when>   With --stats, only activity since a duration ago (24h,
+                   7d, 1w) or a date (2025-01-31)
+
 Examples:
   stash locks
   stash locks --json
+  stash locks --prune
+  stash locks --prune --before 30d
+  stash locks --stats
+  stash locks --stats --since 24h --json
+
+AI Agent Examples:
+  # Find agents that let locks expire instead of releasing them
+  stash locks --stats --json | jq -r '.[] | select(.expired > 0) | .agent'
 
 Exit Codes:
-  0  Success`,
+  0  Success
+  1  Stash not found
+  2  Validation error (--prune with --stats, --since without --stats,
+     --before without --prune)
+
+JSON Output (--json):
+  Default: [{"record_id": "inv-ex4j", "agent": "worker-1", ...}]
+  --prune: {"pruned": 1, "locks": [{"record_id": "inv-ex4j", ...}],
+            "events_dropped": 120}
+  --stats: [{"agent": "worker-1", "locks": 12, "refreshes": 3, "unlocks": 10,
+             "expired": 2, "conflicts": 1, "held": 0,
+             "avg_held_seconds": 41.5, "max_held_seconds": 300}]`,
 	Args: cobra.NoArgs,
 	RunE: runLocks,
 }
@@ -117,6 +169,10 @@ Exit Codes:
 func init() {
 	lockCmd.Flags().StringVar(&lockAgent, "agent", "", "Agent name for the lock (default: current actor)")
 	lockCmd.Flags().IntVar(&lockTimeout, "timeout", DefaultLockTimeout, "Lock timeout in seconds (default 300)")
+	locksCmd.Flags().BoolVar(&locksPrune, "prune", false, "Remove expired locks and report them")
+	locksCmd.Flags().BoolVar(&locksStats, "stats", false, "Show lock activity per agent")
+	locksCmd.Flags().StringVar(&locksSince, "since", "", "With --stats, only activity since a duration ago or a date")
+	locksCmd.Flags().StringVar(&locksBefore, "before", "", "With --prune, drop audit events before a duration ago or a date (default 90d)")
 	rootCmd.AddCommand(lockCmd)
 	rootCmd.AddCommand(unlockCmd)
 	rootCmd.AddCommand(locksCmd)
@@ -140,7 +196,7 @@ func runLock(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -176,29 +232,42 @@ func runLock(cmd *cobra.Command, args []string) error {
 		agent = ctx.Actor
 	}
 
+	// Hold the locks file while reading and rewriting it, so concurrent
+	// agents cannot both take the same lock
+	fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+	if err != nil {
+		return fmt.Errorf("failed to lock locks file: %w", err)
+	}
+	defer fileLock.Unlock()
+
 	// Check for existing lock
-	locks, err := loadLocks(ctx.StashDir)
+	locks, err := loadLocks(ctx.StashDir, ctx.Stash)
 	if err != nil {
 		return fmt.Errorf("failed to load locks: %w", err)
 	}
 
 	// Clean up expired locks while checking
-	locks = cleanExpiredLocks(locks)
+	locks, _, events := pruneExpiredLocks(locks)
 
 	// Check if record is already locked
 	for _, lock := range locks {
-		if lock.Stash == ctx.Stash && lock.RecordID == recordID {
+		if lock.RecordID == recordID {
 			if lock.Agent == agent {
 				// Same agent - refresh the lock
 				lock.LockedAt = time.Now()
 				lock.ExpiresAt = time.Now().Add(time.Duration(lockTimeout) * time.Second)
-				if err := saveLocks(ctx.StashDir, locks); err != nil {
-					return fmt.Errorf("failed to save locks: %w", err)
+				events = append(events, lockEvent{Time: lock.LockedAt, Event: lockEventRefresh, RecordID: recordID, Agent: agent})
+				if err := commitLocks(ctx.StashDir, ctx.Stash, locks, events); err != nil {
+					return err
 				}
 				outputLock(lock)
 				return nil
 			}
 			// Different agent - lock conflict
+			events = append(events, lockEvent{Time: time.Now(), Event: lockEventConflict, RecordID: recordID, Agent: agent})
+			if err := commitLocks(ctx.StashDir, ctx.Stash, locks, events); err != nil {
+				return err
+			}
 			ExitWithError(5, ErrCodeRecordLocked,
 				fmt.Sprintf("record '%s' is locked by agent '%s' (expires %s)",
 					recordID, lock.Agent, lock.ExpiresAt.Format(time.RFC3339)),
@@ -222,10 +291,11 @@ func runLock(cmd *cobra.Command, args []string) error {
 		Stash:     ctx.Stash,
 	}
 	locks = append(locks, lock)
+	events = append(events, lockEvent{Time: now, Event: lockEventLock, RecordID: recordID, Agent: agent})
 
 	// Save locks
-	if err := saveLocks(ctx.StashDir, locks); err != nil {
-		return fmt.Errorf("failed to save locks: %w", err)
+	if err := commitLocks(ctx.StashDir, ctx.Stash, locks, events); err != nil {
+		return err
 	}
 
 	outputLock(lock)
@@ -249,23 +319,44 @@ func runUnlock(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to resolve context: %w", err)
 	}
 
+	// Each stash keeps its locks in its own directory
+	if _, err := os.Stat(ctx.StashPath()); os.IsNotExist(err) {
+		ExitStashNotFound(ctx.Stash)
+		return nil
+	}
+
+	fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+	if err != nil {
+		return fmt.Errorf("failed to lock locks file: %w", err)
+	}
+	defer fileLock.Unlock()
+
 	// Load locks
-	locks, err := loadLocks(ctx.StashDir)
+	locks, err := loadLocks(ctx.StashDir, ctx.Stash)
 	if err != nil {
 		return fmt.Errorf("failed to load locks: %w", err)
 	}
 
-	// Find and remove the lock
+	// Find and remove the lock. A lock that expired before it was
+	// released is recorded as expired.
 	found := false
 	var newLocks []*Lock
+	var events []lockEvent
 	for _, lock := range locks {
-		if lock.Stash == ctx.Stash && lock.RecordID == recordID {
+		if lock.RecordID == recordID {
 			found = true
-			continue // Remove this lock
+			if !lock.IsExpired() {
+				events = append(events, lockEvent{Time: time.Now(), Event: lockEventUnlock, RecordID: recordID, Agent: lock.Agent})
+				continue // Remove this lock
+			}
 		}
 		newLocks = append(newLocks, lock)
 	}
 
+	// Clean up expired locks
+	newLocks, _, expireEvents := pruneExpiredLocks(newLocks)
+	events = append(events, expireEvents...)
+
 	if !found {
 		ExitWithError(1, ErrCodeLockNotFound,
 			fmt.Sprintf("no lock found for record '%s'", recordID),
@@ -274,8 +365,8 @@ func runUnlock(cmd *cobra.Command, args []string) error {
 	}
 
 	// Save updated locks
-	if err := saveLocks(ctx.StashDir, newLocks); err != nil {
-		return fmt.Errorf("failed to save locks: %w", err)
+	if err := commitLocks(ctx.StashDir, ctx.Stash, newLocks, events); err != nil {
+		return err
 	}
 
 	// Output result
@@ -294,6 +385,35 @@ func runUnlock(cmd *cobra.Command, args []string) error {
 }
 
 func runLocks(cmd *cobra.Command, args []string) error {
+	if locksPrune && locksStats {
+		ExitValidationError("--prune and --stats cannot be used together", nil)
+		return nil
+	}
+	if locksSince != "" && !locksStats {
+		ExitValidationError("--since requires --stats", nil)
+		return nil
+	}
+	if locksBefore != "" && !locksPrune {
+		ExitValidationError("--before requires --prune", nil)
+		return nil
+	}
+	before := time.Now().Add(-lockAuditRetention)
+	if locksBefore != "" {
+		var err error
+		if before, err = parseTimeFilter(locksBefore); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"before": locksBefore})
+			return nil
+		}
+	}
+	var since time.Time
+	if locksSince != "" {
+		var err error
+		if since, err = parseTimeFilter(locksSince); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"since": locksSince})
+			return nil
+		}
+	}
+
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
 	if err != nil {
@@ -308,25 +428,43 @@ func runLocks(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to resolve context: %w", err)
 	}
 
+	// Each stash keeps its locks in its own directory
+	if _, err := os.Stat(ctx.StashPath()); os.IsNotExist(err) {
+		ExitStashNotFound(ctx.Stash)
+		return nil
+	}
+
+	fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+	if err != nil {
+		return fmt.Errorf("failed to lock locks file: %w", err)
+	}
+	defer fileLock.Unlock()
+
 	// Load locks
-	locks, err := loadLocks(ctx.StashDir)
+	locks, err := loadLocks(ctx.StashDir, ctx.Stash)
 	if err != nil {
 		return fmt.Errorf("failed to load locks: %w", err)
 	}
 
 	// Clean up expired locks
-	locks = cleanExpiredLocks(locks)
-	if err := saveLocks(ctx.StashDir, locks); err != nil {
-		return fmt.Errorf("failed to save locks: %w", err)
+	locks, expired, events := pruneExpiredLocks(locks)
+	if err := commitLocks(ctx.StashDir, ctx.Stash, locks, events); err != nil {
+		return err
 	}
 
-	// Filter to current stash
-	var stashLocks []*Lock
-	for _, lock := range locks {
-		if lock.Stash == ctx.Stash {
-			stashLocks = append(stashLocks, lock)
+	if locksPrune {
+		dropped, err := compactLockEvents(ctx.StashDir, ctx.Stash, locks, before)
+		if err != nil {
+			return err
 		}
+		outputPrunedLocks(expired, dropped)
+		return nil
 	}
+	if locksStats {
+		return outputLockStats(ctx.StashDir, ctx.Stash, locks, since)
+	}
+
+	stashLocks := locks
 
 	// Output result
 	if GetJSONOutput() {
@@ -335,6 +473,10 @@ func runLocks(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
 		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		for _, lock := range stashLocks {
+			printPorcelain(lock.RecordID, lock.Agent, porcelainTime(lock.LockedAt), porcelainTime(lock.ExpiresAt))
+		}
 	} else if !IsQuiet() {
 		if len(stashLocks) == 0 {
 			fmt.Println("No active locks")
@@ -350,6 +492,84 @@ func runLocks(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// outputPrunedLocks reports the expired locks and the number of audit
+// events removed by --prune
+func outputPrunedLocks(expired []*Lock, dropped int) {
+	if GetJSONOutput() {
+		if expired == nil {
+			expired = []*Lock{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"pruned": len(expired), "locks": expired, "events_dropped": dropped})
+		fmt.Println(string(data))
+		return
+	}
+	if IsQuiet() {
+		return
+	}
+	fmt.Printf("Pruned %d expired lock(s)\n", len(expired))
+	if dropped > 0 {
+		fmt.Printf("Dropped %d old lock audit event(s)\n", dropped)
+	}
+	if IsVerbose() {
+		for _, lock := range expired {
+			fmt.Printf("  %s  locked by %s  expired %s\n", lock.RecordID, lock.Agent, lock.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+}
+
+// outputLockStats reports lock activity per agent from the audit trail
+func outputLockStats(stashDir, stashName string, active []*Lock, since time.Time) error {
+	events, err := loadLockEvents(stashDir, stashName)
+	if err != nil {
+		return fmt.Errorf("failed to read lock audit trail: %w", err)
+	}
+	stats := lockStats(events, active, since)
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if IsQuiet() {
+		return nil
+	}
+	if len(stats) == 0 {
+		fmt.Println("No lock activity")
+		return nil
+	}
+
+	held := func(seconds float64) string {
+		return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+	}
+	t := newTable(
+		tableColumn{Header: "Agent"},
+		tableColumn{Header: "Locks"},
+		tableColumn{Header: "Refreshes"},
+		tableColumn{Header: "Unlocks"},
+		tableColumn{Header: "Expired"},
+		tableColumn{Header: "Conflicts"},
+		tableColumn{Header: "Held"},
+		tableColumn{Header: "Avg Held"},
+		tableColumn{Header: "Max Held"},
+	)
+	for _, s := range stats {
+		t.addRow(s.Agent,
+			fmt.Sprintf("%d", s.Locks),
+			fmt.Sprintf("%d", s.Refreshes),
+			fmt.Sprintf("%d", s.Unlocks),
+			fmt.Sprintf("%d", s.Expired),
+			fmt.Sprintf("%d", s.Conflicts),
+			fmt.Sprintf("%d", s.Held),
+			held(s.AvgHeldSeconds),
+			held(s.MaxHeldSeconds))
+	}
+	t.print()
+	return nil
+}
+
 // outputLock outputs lock information in the appropriate format
 func outputLock(lock *Lock) {
 	if GetJSONOutput() {
@@ -364,15 +584,34 @@ func outputLock(lock *Lock) {
 	}
 }
 
-// locksFilePath returns the path to the locks file
-func locksFilePath(stashDir string) string {
+// locksFilePath returns the path to a stash's locks file
+func locksFilePath(stashDir, stashName string) string {
+	return filepath.Join(stashDir, stashName, "locks.json")
+}
+
+// legacyLocksFilePath returns the path to the locks file shared by all
+// stashes, used before each stash had its own.
+func legacyLocksFilePath(stashDir string) string {
 	return filepath.Join(stashDir, "locks.json")
 }
 
-// loadLocks loads all locks from the locks file
-func loadLocks(stashDir string) ([]*Lock, error) {
-	path := locksFilePath(stashDir)
-	data, err := os.ReadFile(path)
+// lockLocksFile takes the advisory lock that serializes changes to a
+// stash's locks file between stash processes. It is held on a separate
+// locks.json.lock file, since Windows locks block reads of the locked file.
+func lockLocksFile(stashDir, stashName string) (*platform.FileLock, error) {
+	return platform.LockFile(locksFilePath(stashDir, stashName) + ".lock")
+}
+
+// loadLocks loads a stash's locks. A stash that has not saved locks since
+// they moved to per-stash files reads its entries from the legacy shared
+// file; they move to the stash's own file the next time it saves.
+func loadLocks(stashDir, stashName string) ([]*Lock, error) {
+	data, err := os.ReadFile(locksFilePath(stashDir, stashName))
+	legacy := false
+	if os.IsNotExist(err) {
+		data, err = os.ReadFile(legacyLocksFilePath(stashDir))
+		legacy = true
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []*Lock{}, nil
@@ -384,17 +623,50 @@ func loadLocks(stashDir string) ([]*Lock, error) {
 	if err := json.Unmarshal(data, &locks); err != nil {
 		return nil, err
 	}
+	if legacy {
+		var stashLocks []*Lock
+		for _, lock := range locks {
+			if lock.Stash == stashName {
+				stashLocks = append(stashLocks, lock)
+			}
+		}
+		locks = stashLocks
+	}
 	return locks, nil
 }
 
-// saveLocks saves all locks to the locks file
-func saveLocks(stashDir string, locks []*Lock) error {
-	path := locksFilePath(stashDir)
+// saveLocks replaces a stash's locks file. The new file is written beside
+// it and renamed into place, so readers never see a partial file. Callers
+// hold lockLocksFile across the load and save.
+func saveLocks(stashDir, stashName string, locks []*Lock) error {
+	if locks == nil {
+		locks = []*Lock{}
+	}
 	data, err := json.MarshalIndent(locks, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+
+	path := locksFilePath(stashDir, stashName)
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "locks-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
 
 // cleanExpiredLocks removes expired locks from the list
@@ -411,26 +683,47 @@ func cleanExpiredLocks(locks []*Lock) []*Lock {
 // CheckLock checks if a record is locked by another agent.
 // Returns the lock if found and not owned by the given agent, nil otherwise.
 func CheckLock(stashDir, stashName, recordID, agent string) (*Lock, error) {
-	locks, err := loadLocks(stashDir)
+	locks, err := loadLocks(stashDir, stashName)
 	if err != nil {
 		return nil, err
 	}
 
+	decision := "unlocked"
 	for _, lock := range locks {
-		if lock.Stash == stashName && lock.RecordID == recordID {
+		if lock.RecordID == recordID {
 			// Skip expired locks
 			if lock.IsExpired() {
+				logging.Debug("lock check", "stash", stashName, "id", recordID, "actor", agent, "decision", "expired", "locked_by", lock.Agent, "expires_at", lock.ExpiresAt)
 				continue
 			}
 			// If locked by a different agent, return the lock
 			if lock.Agent != agent {
+				logging.Debug("lock check", "stash", stashName, "id", recordID, "actor", agent, "decision", "blocked", "locked_by", lock.Agent, "expires_at", lock.ExpiresAt)
 				return lock, nil
 			}
+			decision = "owned"
 		}
 	}
+	logging.Debug("lock check", "stash", stashName, "id", recordID, "actor", agent, "decision", decision)
 	return nil, nil
 }
 
+// checkRecordLocks reports the first of the records locked by another
+// agent. Returns true if none is.
+func checkRecordLocks(ctx *context.Context, records []*model.Record) (bool, error) {
+	for _, rec := range records {
+		lock, err := CheckLock(ctx.StashDir, ctx.Stash, rec.ID, ctx.Actor)
+		if err != nil {
+			return false, fmt.Errorf("failed to check lock: %w", err)
+		}
+		if lock != nil {
+			ExitRecordLocked(rec.ID, lock)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // ExitRecordLocked outputs an error when a record is locked by another agent
 func ExitRecordLocked(recordID string, lock *Lock) {
 	ExitWithError(5, ErrCodeRecordLocked,
@@ -442,3 +735,17 @@ func ExitRecordLocked(recordID string, lock *Lock) {
 			"expires_at": lock.ExpiresAt,
 		})
 }
+
+// lockedRecordIDs returns the IDs of the records in a stash that hold an
+// active lock. Errors reading the locks file are treated as no locks.
+func lockedRecordIDs(stashDir, stashName string) map[string]bool {
+	ids := make(map[string]bool)
+	locks, err := loadLocks(stashDir, stashName)
+	if err != nil {
+		return ids
+	}
+	for _, lock := range cleanExpiredLocks(locks) {
+		ids[lock.RecordID] = true
+	}
+	return ids
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,7 +45,7 @@ func TestLock_AcquireLock(t *testing.T) {
 		}
 
 		// Verify lock exists
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		found := false
 		for _, lock := range locks {
 			if lock.RecordID == recordID && lock.Agent == "agent-1" {
@@ -78,7 +79,7 @@ func TestLock_AcquireLock(t *testing.T) {
 		rootCmd.Execute()
 
 		// Then: Lock has default timeout (300 seconds)
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		for _, lock := range locks {
 			if lock.RecordID == recordID {
 				expectedExpiry := lock.LockedAt.Add(300 * time.Second)
@@ -112,7 +113,7 @@ func TestLock_AcquireLock(t *testing.T) {
 		rootCmd.Execute()
 
 		// Then: Lock expires in 600 seconds
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		for _, lock := range locks {
 			if lock.RecordID == recordID {
 				expectedExpiry := lock.LockedAt.Add(600 * time.Second)
@@ -197,7 +198,7 @@ func TestLock_LockConflict(t *testing.T) {
 		rootCmd.Execute()
 
 		// Get original lock time
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		var origLockTime time.Time
 		for _, lock := range locks {
 			if lock.RecordID == recordID {
@@ -224,7 +225,7 @@ func TestLock_LockConflict(t *testing.T) {
 		}
 
 		// Verify lock time was updated
-		locks, _ = loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ = loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		for _, lock := range locks {
 			if lock.RecordID == recordID {
 				if !lock.LockedAt.After(origLockTime) && lock.LockedAt != origLockTime {
@@ -271,7 +272,7 @@ func TestUnlock(t *testing.T) {
 		}
 
 		// Verify lock is removed
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		for _, lock := range locks {
 			if lock.RecordID == recordID {
 				t.Error("expected lock to be removed")
@@ -525,7 +526,7 @@ func TestLock_Expiration(t *testing.T) {
 			ExpiresAt: time.Now().Add(-5 * time.Minute), // Expired 5 minutes ago
 			Stash:     "inventory",
 		}
-		saveLocks(filepath.Join(tempDir, ".stash"), []*Lock{expiredLock})
+		saveLocks(filepath.Join(tempDir, ".stash"), "inventory", []*Lock{expiredLock})
 
 		// When: agent-2 tries to lock
 		ExitCode = 0
@@ -542,7 +543,7 @@ func TestLock_Expiration(t *testing.T) {
 		}
 
 		// Verify new lock exists
-		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"))
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
 		found := false
 		for _, lock := range locks {
 			if lock.RecordID == recordID && lock.Agent == "agent-2" {
@@ -576,7 +577,7 @@ func TestLock_Expiration(t *testing.T) {
 			ExpiresAt: time.Now().Add(-5 * time.Minute),
 			Stash:     "inventory",
 		}
-		saveLocks(filepath.Join(tempDir, ".stash"), []*Lock{expiredLock})
+		saveLocks(filepath.Join(tempDir, ".stash"), "inventory", []*Lock{expiredLock})
 
 		// When: agent-2 tries to update
 		ExitCode = 0
@@ -594,6 +595,187 @@ func TestLock_Expiration(t *testing.T) {
 	})
 }
 
+// TestLock_LegacyLocksFile tests reading locks saved in the shared locks
+// file used before each stash had its own
+func TestLock_LegacyLocksFile(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+	stashDir := filepath.Join(tempDir, ".stash")
+
+	legacy := []*Lock{
+		{RecordID: "inv-aaaa", Agent: "agent-1", LockedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour), Stash: "inventory"},
+		{RecordID: "ct-bbbb", Agent: "agent-1", LockedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour), Stash: "contacts"},
+	}
+	data, _ := json.Marshal(legacy)
+	os.WriteFile(filepath.Join(stashDir, "locks.json"), data, 0644)
+
+	locks, err := loadLocks(stashDir, "inventory")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(locks) != 1 || locks[0].RecordID != "inv-aaaa" {
+		t.Fatalf("expected the stash's legacy lock, got %v", locks)
+	}
+
+	// Saving moves the stash's locks to its own file
+	if err := saveLocks(stashDir, "inventory", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stashDir, "inventory", "locks.json")); err != nil {
+		t.Errorf("expected per-stash locks file: %v", err)
+	}
+	if locks, _ := loadLocks(stashDir, "inventory"); len(locks) != 0 {
+		t.Errorf("expected the per-stash file to replace the legacy one, got %v", locks)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(stashDir, "inventory", "locks-*.tmp")); len(matches) != 0 {
+		t.Errorf("expected no temp files left, got %v", matches)
+	}
+}
+
+// TestLocks_PruneAndStats tests pruning expired locks and the lock audit trail
+func TestLocks_PruneAndStats(t *testing.T) {
+	t.Run("prune removes expired locks", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		stashDir := filepath.Join(tempDir, ".stash")
+
+		saveLocks(stashDir, "inventory", []*Lock{
+			{RecordID: "inv-aaaa", Agent: "agent-1", LockedAt: time.Now().Add(-10 * time.Minute), ExpiresAt: time.Now().Add(-5 * time.Minute), Stash: "inventory"},
+			{RecordID: "inv-bbbb", Agent: "agent-2", LockedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour), Stash: "inventory"},
+		})
+
+		var result struct {
+			Pruned int     `json:"pruned"`
+			Locks  []*Lock `json:"locks"`
+		}
+		output := captureSchemaOutput(t, "locks", "--prune", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result.Pruned != 1 || len(result.Locks) != 1 || result.Locks[0].RecordID != "inv-aaaa" {
+			t.Errorf("expected inv-aaaa to be pruned, got %+v", result)
+		}
+
+		locks, _ := loadLocks(stashDir, "inventory")
+		if len(locks) != 1 || locks[0].RecordID != "inv-bbbb" {
+			t.Errorf("expected only inv-bbbb to remain, got %v", locks)
+		}
+		events, _ := loadLockEvents(stashDir, "inventory")
+		if len(events) != 1 || events[0].Event != lockEventExpire || events[0].Agent != "agent-1" {
+			t.Errorf("expected an expire event for agent-1, got %v", events)
+		}
+	})
+
+	t.Run("prune drops old audit events but not those of held locks", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		stashDir := filepath.Join(tempDir, ".stash")
+
+		old := time.Now().Add(-100 * 24 * time.Hour)
+		saveLocks(stashDir, "inventory", []*Lock{
+			{RecordID: "inv-bbbb", Agent: "agent-2", LockedAt: old, ExpiresAt: time.Now().Add(time.Hour), Stash: "inventory"},
+		})
+		appendLockEvents(stashDir, "inventory", []lockEvent{
+			{Time: old, Event: lockEventLock, RecordID: "inv-aaaa", Agent: "agent-1"},
+			{Time: old, Event: lockEventUnlock, RecordID: "inv-aaaa", Agent: "agent-1"},
+			{Time: old, Event: lockEventLock, RecordID: "inv-bbbb", Agent: "agent-2"},
+			{Time: time.Now(), Event: lockEventConflict, RecordID: "inv-bbbb", Agent: "agent-1"},
+		})
+
+		var result struct {
+			EventsDropped int `json:"events_dropped"`
+		}
+		output := captureSchemaOutput(t, "locks", "--prune", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result.EventsDropped != 2 {
+			t.Errorf("expected 2 events dropped, got %d", result.EventsDropped)
+		}
+		events, _ := loadLockEvents(stashDir, "inventory")
+		if len(events) != 2 || events[0].RecordID != "inv-bbbb" || events[1].Event != lockEventConflict {
+			t.Errorf("expected the held lock and the recent conflict to remain, got %v", events)
+		}
+
+		captureSchemaOutput(t, "locks", "--before", "30d")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for --before without --prune, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("stats summarize lock activity per agent", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		var rec map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &rec)
+		id := rec["_id"].(string)
+
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-1")
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-1")
+		ExitCode = 0
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-2")
+		if ExitCode != 5 {
+			t.Errorf("expected exit code 5 for a conflict, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "unlock", id)
+		captureSchemaOutput(t, "lock", id, "--agent", "agent-2")
+
+		var stats []lockAgentStats
+		output := captureSchemaOutput(t, "locks", "--stats", "--json")
+		if err := json.Unmarshal([]byte(output), &stats); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		byAgent := make(map[string]lockAgentStats)
+		for _, s := range stats {
+			byAgent[s.Agent] = s
+		}
+		if s := byAgent["agent-1"]; s.Locks != 1 || s.Refreshes != 1 || s.Unlocks != 1 || s.Held != 0 {
+			t.Errorf("unexpected stats for agent-1: %+v", s)
+		}
+		if s := byAgent["agent-2"]; s.Locks != 1 || s.Conflicts != 1 || s.Held != 1 {
+			t.Errorf("unexpected stats for agent-2: %+v", s)
+		}
+
+		output = captureSchemaOutput(t, "locks", "--stats", "--since", "2000-01-01")
+		if !strings.Contains(output, "agent-1") || !strings.Contains(output, "Conflicts") {
+			t.Errorf("expected a stats table, got:\n%s", output)
+		}
+	})
+
+	t.Run("stats measure how long locks were held", func(t *testing.T) {
+		start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) time.Time { return start.Add(d) }
+		events := []lockEvent{
+			{Time: at(0), Event: lockEventLock, RecordID: "inv-a", Agent: "fast"},
+			{Time: at(10 * time.Second), Event: lockEventUnlock, RecordID: "inv-a", Agent: "fast"},
+			{Time: at(0), Event: lockEventLock, RecordID: "inv-b", Agent: "slow"},
+			{Time: at(time.Minute), Event: lockEventRefresh, RecordID: "inv-b", Agent: "slow"},
+			{Time: at(6 * time.Minute), Event: lockEventExpire, RecordID: "inv-b", Agent: "slow"},
+			{Time: at(7 * time.Minute), Event: lockEventLock, RecordID: "inv-a", Agent: "fast"},
+			{Time: at(7*time.Minute + 30*time.Second), Event: lockEventUnlock, RecordID: "inv-a", Agent: "fast"},
+		}
+
+		stats := lockStats(events, nil, time.Time{})
+		if len(stats) != 2 || stats[0].Agent != "slow" {
+			t.Fatalf("expected slow first, got %+v", stats)
+		}
+		if stats[0].Expired != 1 || stats[0].MaxHeldSeconds != 360 {
+			t.Errorf("unexpected stats for slow: %+v", stats[0])
+		}
+		if stats[1].Unlocks != 2 || stats[1].AvgHeldSeconds != 20 || stats[1].MaxHeldSeconds != 30 {
+			t.Errorf("unexpected stats for fast: %+v", stats[1])
+		}
+
+		// Only the later lock falls in the window
+		stats = lockStats(events, nil, at(7*time.Minute))
+		if len(stats) != 1 || stats[0].Agent != "fast" || stats[0].Locks != 1 || stats[0].MaxHeldSeconds != 30 {
+			t.Errorf("unexpected windowed stats: %+v", stats)
+		}
+	})
+}
+
 // TestLock_JSONOutput tests JSON output for lock commands
 func TestLock_JSONOutput(t *testing.T) {
 	t.Run("AC-01: lock command JSON output", func(t *testing.T) {
@@ -697,10 +879,15 @@ func TestLock_JSONOutput(t *testing.T) {
 func resetLockFlags() {
 	lockAgent = ""
 	lockTimeout = DefaultLockTimeout
+	locksPrune = false
+	locksStats = false
+	locksSince = ""
+	locksBefore = ""
 	// Also reset global flags
 	jsonOutput = false
 	stashName = ""
 	actorName = ""
 	quiet = false
 	verbose = false
+	porcelain = false
 }
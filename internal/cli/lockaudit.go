@@ -0,0 +1,260 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Lock events recorded in a stash's lock audit trail.
+const (
+	lockEventLock     = "lock"
+	lockEventRefresh  = "refresh"
+	lockEventUnlock   = "unlock"
+	lockEventExpire   = "expire"
+	lockEventConflict = "conflict"
+)
+
+// lockEvent is one line of a stash's lock audit trail. For expire events
+// the time is when the lock expired, not when it was pruned.
+type lockEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	RecordID string    `json:"record_id"`
+	Agent    string    `json:"agent"`
+}
+
+// lockAuditPath returns the path to a stash's lock audit trail.
+func lockAuditPath(stashDir, stashName string) string {
+	return filepath.Join(stashDir, stashName, "lock-audit.jsonl")
+}
+
+// appendLockEvents adds events to a stash's lock audit trail. Callers
+// hold lockLocksFile, which also serializes writes to the trail.
+func appendLockEvents(stashDir, stashName string, events []lockEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(lockAuditPath(stashDir, stashName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock audit trail: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write lock audit trail: %w", err)
+	}
+	return nil
+}
+
+// loadLockEvents reads a stash's lock audit trail, oldest first. Lines
+// that cannot be parsed are skipped.
+func loadLockEvents(stashDir, stashName string) ([]lockEvent, error) {
+	f, err := os.Open(lockAuditPath(stashDir, stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []lockEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e lockEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil && e.Event != "" {
+			events = append(events, e)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// compactLockEvents drops the events of a stash's lock audit trail from
+// before a time, except the lock event of each lock still held, which its
+// hold time is measured from. It returns the number of events dropped.
+// Callers hold lockLocksFile.
+func compactLockEvents(stashDir, stashName string, active []*Lock, before time.Time) (int, error) {
+	events, err := loadLockEvents(stashDir, stashName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read lock audit trail: %w", err)
+	}
+
+	// The last lock event of each held lock is kept, however old
+	type holder struct{ agent, recordID string }
+	held := make(map[holder]int)
+	for _, lock := range active {
+		held[holder{lock.Agent, lock.RecordID}] = -1
+	}
+	for i, e := range events {
+		key := holder{e.Agent, e.RecordID}
+		if _, ok := held[key]; ok && e.Event == lockEventLock {
+			held[key] = i
+		}
+	}
+	keep := make(map[int]bool, len(held))
+	for _, i := range held {
+		keep[i] = true
+	}
+
+	kept := events[:0]
+	for i, e := range events {
+		if !e.Time.Before(before) || keep[i] {
+			kept = append(kept, e)
+		}
+	}
+	dropped := len(events) - len(kept)
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	for _, e := range kept {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	path := lockAuditPath(stashDir, stashName)
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, buf.Bytes(), 0644)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to compact lock audit trail: %w", err)
+	}
+	return dropped, nil
+}
+
+// pruneExpiredLocks splits locks into those still active and those that
+// have expired, with an expire event for each expired lock.
+func pruneExpiredLocks(locks []*Lock) (active, expired []*Lock, events []lockEvent) {
+	for _, lock := range locks {
+		if lock.IsExpired() {
+			expired = append(expired, lock)
+			events = append(events, lockEvent{Time: lock.ExpiresAt, Event: lockEventExpire, RecordID: lock.RecordID, Agent: lock.Agent})
+		} else {
+			active = append(active, lock)
+		}
+	}
+	return active, expired, events
+}
+
+// commitLocks saves a stash's locks and then records the events that
+// changed them. Callers hold lockLocksFile.
+func commitLocks(stashDir, stashName string, locks []*Lock, events []lockEvent) error {
+	if err := saveLocks(stashDir, stashName, locks); err != nil {
+		return fmt.Errorf("failed to save locks: %w", err)
+	}
+	return appendLockEvents(stashDir, stashName, events)
+}
+
+// lockAgentStats summarizes one agent's lock activity.
+type lockAgentStats struct {
+	Agent     string `json:"agent"`
+	Locks     int    `json:"locks"`
+	Refreshes int    `json:"refreshes"`
+	Unlocks   int    `json:"unlocks"`
+	Expired   int    `json:"expired"`
+	Conflicts int    `json:"conflicts"`
+	// Held is the number of locks the agent holds now.
+	Held int `json:"held"`
+	// AvgHeldSeconds and MaxHeldSeconds cover locks that were released or
+	// expired, from when they were taken.
+	AvgHeldSeconds float64 `json:"avg_held_seconds"`
+	MaxHeldSeconds float64 `json:"max_held_seconds"`
+
+	released  int
+	totalHeld time.Duration
+}
+
+// lockStats summarizes lock events at or after since, per agent, with the
+// agents that held locks longest first. active are the locks held now.
+func lockStats(events []lockEvent, active []*Lock, since time.Time) []*lockAgentStats {
+	byAgent := make(map[string]*lockAgentStats)
+	agentStats := func(agent string) *lockAgentStats {
+		s, ok := byAgent[agent]
+		if !ok {
+			s = &lockAgentStats{Agent: agent}
+			byAgent[agent] = s
+		}
+		return s
+	}
+
+	// taken maps agent and record to when the agent took the lock
+	type holder struct{ agent, recordID string }
+	taken := make(map[holder]time.Time)
+	for _, e := range events {
+		key := holder{e.Agent, e.RecordID}
+		counted := !e.Time.Before(since)
+		switch e.Event {
+		case lockEventLock:
+			taken[key] = e.Time
+		case lockEventUnlock, lockEventExpire:
+			start, ok := taken[key]
+			delete(taken, key)
+			if !counted || !ok {
+				break
+			}
+			s := agentStats(e.Agent)
+			held := e.Time.Sub(start)
+			s.released++
+			s.totalHeld += held
+			s.MaxHeldSeconds = max(s.MaxHeldSeconds, held.Seconds())
+		}
+		if !counted {
+			continue
+		}
+
+		s := agentStats(e.Agent)
+		switch e.Event {
+		case lockEventLock:
+			s.Locks++
+		case lockEventRefresh:
+			s.Refreshes++
+		case lockEventUnlock:
+			s.Unlocks++
+		case lockEventExpire:
+			s.Expired++
+		case lockEventConflict:
+			s.Conflicts++
+		}
+	}
+	for _, lock := range active {
+		agentStats(lock.Agent).Held++
+	}
+
+	stats := make([]*lockAgentStats, 0, len(byAgent))
+	for _, s := range byAgent {
+		if s.released > 0 {
+			s.AvgHeldSeconds = s.totalHeld.Seconds() / float64(s.released)
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].MaxHeldSeconds != stats[j].MaxHeldSeconds {
+			return stats[i].MaxHeldSeconds > stats[j].MaxHeldSeconds
+		}
+		return stats[i].Agent < stats[j].Agent
+	})
+	return stats
+}
@@ -173,7 +173,7 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run migrations
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to open storage: %w", err)
 	}
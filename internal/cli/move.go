@@ -63,13 +63,13 @@ func runMove(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
-	// Get stash configuration
+	// Verify stash exists
 	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
@@ -80,6 +80,10 @@ func runMove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, nil) {
+		return nil
+	}
+
 	// Get record to move
 	record, err := store.GetRecord(ctx.Stash, recordID)
 	if err != nil {
@@ -125,11 +129,57 @@ func runMove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	newRecordID, idMapping, movedRecords, err := moveRecord(store, ctx, record, newParentID)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		result := map[string]interface{}{
+			"old_id":    recordID,
+			"new_id":    newRecordID,
+			"parent_id": newParentID,
+			"moved":     len(movedRecords),
+		}
+
+		// Include all ID mappings in verbose mode
+		if IsVerbose() {
+			result["id_mapping"] = idMapping
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("%s -> %s\n", recordID, newRecordID)
+		if len(movedRecords) > 1 {
+			fmt.Printf("Moved %d record(s)\n", len(movedRecords))
+		}
+		if IsVerbose() {
+			for oldID, newID := range idMapping {
+				if oldID != recordID {
+					fmt.Printf("  %s -> %s\n", oldID, newID)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// moveRecord re-creates a record and its descendants under a new parent
+// (empty for root) with IDs that reflect the new hierarchy, soft-deleting
+// the originals. Returns the record's new ID, the old-to-new ID mapping,
+// and the moved records.
+func moveRecord(store *storage.Store, ctx *context.Context, record *model.Record, newParentID string) (string, map[string]string, []*model.Record, error) {
 	// Get all descendants
 	allRecords := []*model.Record{record}
-	descendants, err := collectAllDescendants(store, ctx.Stash, recordID)
+	descendants, err := collectAllDescendants(store, ctx.Stash, record.ID)
 	if err != nil {
-		return fmt.Errorf("failed to collect descendants: %w", err)
+		return "", nil, nil, fmt.Errorf("failed to collect descendants: %w", err)
 	}
 	allRecords = append(allRecords, descendants...)
 
@@ -137,29 +187,29 @@ func runMove(cmd *cobra.Command, args []string) error {
 	var newRecordID string
 	if newParentID == "" {
 		// Moving to root - generate new root ID
-		newRecordID, err = model.GenerateID(stash.Prefix)
+		newRecordID, err = store.NextRecordID(ctx.Stash)
 		if err != nil {
-			return fmt.Errorf("failed to generate ID: %w", err)
+			return "", nil, nil, fmt.Errorf("failed to generate ID: %w", err)
 		}
 	} else {
 		// Moving to new parent - get next child sequence
 		nextSeq, err := store.GetNextChildSeq(ctx.Stash, newParentID)
 		if err != nil {
-			return fmt.Errorf("failed to get next child sequence: %w", err)
+			return "", nil, nil, fmt.Errorf("failed to get next child sequence: %w", err)
 		}
 		newRecordID = model.GenerateChildID(newParentID, nextSeq)
 	}
 
 	// Build ID mapping (old -> new)
 	idMapping := make(map[string]string)
-	idMapping[recordID] = newRecordID
+	idMapping[record.ID] = newRecordID
 
 	// Map descendant IDs
 	for _, desc := range descendants {
 		// Replace the old prefix with new prefix
 		// e.g., if moving inv-ex4j.1 to inv-ab12.1
 		// then inv-ex4j.1.2 becomes inv-ab12.1.2
-		suffix := strings.TrimPrefix(desc.ID, recordID)
+		suffix := strings.TrimPrefix(desc.ID, record.ID)
 		newDescID := newRecordID + suffix
 		idMapping[desc.ID] = newDescID
 	}
@@ -173,7 +223,7 @@ func runMove(cmd *cobra.Command, args []string) error {
 
 		// Determine new parent ID
 		var newParent string
-		if oldRec.ID == recordID {
+		if oldRec.ID == record.ID {
 			newParent = newParentID
 		} else {
 			// For descendants, map their parent ID
@@ -195,7 +245,7 @@ func runMove(cmd *cobra.Command, args []string) error {
 
 		// Create the new record
 		if err := store.CreateRecord(ctx.Stash, newRec); err != nil {
-			return fmt.Errorf("failed to create moved record %s: %w", newID, err)
+			return "", nil, nil, fmt.Errorf("failed to create moved record %s: %w", newID, err)
 		}
 
 		movedRecords = append(movedRecords, newRec)
@@ -209,40 +259,7 @@ func runMove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Output result
-	if GetJSONOutput() {
-		result := map[string]interface{}{
-			"old_id":    recordID,
-			"new_id":    newRecordID,
-			"parent_id": newParentID,
-			"moved":     len(movedRecords),
-		}
-
-		// Include all ID mappings in verbose mode
-		if IsVerbose() {
-			result["id_mapping"] = idMapping
-		}
-
-		data, err := json.Marshal(result)
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
-		}
-		fmt.Println(string(data))
-	} else if !IsQuiet() {
-		fmt.Printf("%s -> %s\n", recordID, newRecordID)
-		if len(movedRecords) > 1 {
-			fmt.Printf("Moved %d record(s)\n", len(movedRecords))
-		}
-		if IsVerbose() {
-			for oldID, newID := range idMapping {
-				if oldID != recordID {
-					fmt.Printf("  %s -> %s\n", oldID, newID)
-				}
-			}
-		}
-	}
-
-	return nil
+	return newRecordID, idMapping, movedRecords, nil
 }
 
 // collectAllDescendants recursively collects all descendants of a record.
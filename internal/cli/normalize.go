@@ -0,0 +1,303 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	normalizeMap    []string
+	normalizeTrim   bool
+	normalizeLower  bool
+	normalizeUpper  bool
+	normalizeWhere  []string
+	normalizeDryRun bool
+)
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize <column>",
+	Short: "Rewrite inconsistent values of a column",
+	Long: `Clean up the values of a column across every record, such as
+"Electronics", " electronics" and "ELECTRONICS" written by different agents.
+
+Each value is rewritten in this order:
+  --trim            Remove leading and trailing whitespace
+  --map FROM=TO     Replace the value FROM with TO (exact match, after
+                    --trim; can be repeated)
+  --lower, --upper  Change the value's case
+
+List columns have each element rewritten, dropping elements that become
+duplicates. Each changed record is saved as its own update, so the
+history shows what was rewritten and by whom. Deleted records are left
+alone. The rewritten values must pass the column's validation.
+
+Examples:
+  stash normalize Category --map "Electronics=electronics"
+  stash normalize Category --trim --lower
+  stash normalize Status --map "Done=done" --map "finished=done" --dry-run
+  stash normalize Tags --lower --where "Category=electronics"
+
+Exit Codes:
+  0  Success (includes no values changed)
+  1  Stash or column not found
+  2  Validation error (invalid --map, no rewrite given, computed column,
+     rewritten value fails validation)
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--json):
+  {"stash": "inventory", "column": "Category", "count": 3,
+   "updated": ["inv-ab12", ...],
+   "changes": [{"from": "Electronics", "to": "electronics", "records": 3}]}
+  With --dry-run, the operations that would be written are reported
+  instead of "updated", with "dry_run": true.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNormalize,
+}
+
+func init() {
+	normalizeCmd.Flags().StringArrayVar(&normalizeMap, "map", nil, "Replace a value: FROM=TO (can be repeated)")
+	normalizeCmd.Flags().BoolVar(&normalizeTrim, "trim", false, "Remove leading and trailing whitespace")
+	normalizeCmd.Flags().BoolVar(&normalizeLower, "lower", false, "Lowercase values")
+	normalizeCmd.Flags().BoolVar(&normalizeUpper, "upper", false, "Uppercase values")
+	normalizeCmd.Flags().StringArrayVar(&normalizeWhere, "where", nil, "Only rewrite records matching this condition (can be repeated)")
+	normalizeCmd.Flags().BoolVar(&normalizeDryRun, "dry-run", false, "Show what would change without saving it")
+	rootCmd.AddCommand(normalizeCmd)
+}
+
+// valueRewrite is one distinct value a normalization changed, and how
+// many records held it.
+type valueRewrite struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Records int    `json:"records"`
+}
+
+// normalizer rewrites values by the normalize flags.
+type normalizer struct {
+	mapping map[string]string
+	trim    bool
+	lower   bool
+	upper   bool
+}
+
+// apply returns the rewritten form of a value.
+func (n *normalizer) apply(value string) string {
+	if n.trim {
+		value = strings.TrimSpace(value)
+	}
+	if to, ok := n.mapping[value]; ok {
+		value = to
+	}
+	if n.lower {
+		value = strings.ToLower(value)
+	}
+	if n.upper {
+		value = strings.ToUpper(value)
+	}
+	return value
+}
+
+// rewrite returns the rewritten field value, the values it changed as
+// from/to pairs, and whether the field changed at all. Values that are
+// not text are left alone.
+func (n *normalizer) rewrite(value interface{}) (interface{}, map[[2]string]bool, bool) {
+	changed := make(map[[2]string]bool)
+	switch v := value.(type) {
+	case string:
+		if to := n.apply(v); to != v {
+			changed[[2]string{v, to}] = true
+			return to, changed, true
+		}
+	case []interface{}:
+		var elems []interface{}
+		seen := make(map[string]bool)
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				elems = append(elems, e)
+				continue
+			}
+			to := n.apply(s)
+			if to != s {
+				changed[[2]string{s, to}] = true
+			}
+			if seen[to] {
+				continue
+			}
+			seen[to] = true
+			elems = append(elems, to)
+		}
+		if len(changed) > 0 || len(elems) != len(v) {
+			return elems, changed, true
+		}
+	}
+	return value, changed, false
+}
+
+func runNormalize(cmd *cobra.Command, args []string) error {
+	n := &normalizer{mapping: make(map[string]string), trim: normalizeTrim, lower: normalizeLower, upper: normalizeUpper}
+	for _, m := range normalizeMap {
+		from, to, ok := strings.Cut(m, "=")
+		if !ok {
+			ExitValidationError(fmt.Sprintf("invalid --map format: %s (expected FROM=TO)", m), map[string]interface{}{"map": m})
+			return nil
+		}
+		n.mapping[from] = to
+	}
+	if n.lower && n.upper {
+		ExitValidationError("--lower and --upper cannot be combined", nil)
+		return nil
+	}
+	if len(n.mapping) == 0 && !n.trim && !n.lower && !n.upper {
+		ExitValidationError("nothing to do (use --map, --trim, --lower, or --upper)", nil)
+		return nil
+	}
+	var where []storage.WhereCondition
+	for _, clause := range normalizeWhere {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"where": clause})
+			return nil
+		}
+		where = append(where, cond)
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	col := resolveColumn(stash, args[0])
+	if col == nil || !checkWhereColumns(stash, where) {
+		return nil
+	}
+	if col.IsComputed() {
+		ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set", col.Name), map[string]interface{}{"column": col.Name})
+		return nil
+	}
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, []string{col.Name}) {
+		return nil
+	}
+
+	records, err := store.ListRecords(ctx.Stash, storage.ListOptions{ParentID: "*", Where: where})
+	if err != nil {
+		return fmt.Errorf("failed to query records: %w", err)
+	}
+
+	// Rewrite in memory first, so nothing is saved unless every new value
+	// is valid
+	counts := make(map[[2]string]int)
+	var changedRecords []*model.Record
+	var warnings []ValidationError
+	checked := make(map[string]bool)
+	now := time.Now()
+	for _, record := range records {
+		current, ok := record.GetField(col.Name)
+		if !ok {
+			continue
+		}
+		value, changed, modified := n.rewrite(current)
+		if !modified {
+			continue
+		}
+		for pair := range changed {
+			counts[pair]++
+			if !checked[pair[1]] {
+				checked[pair[1]] = true
+				var check interface{} = pair[1]
+				if col.List {
+					check = []interface{}{pair[1]}
+				}
+				result := ValidateValue(col, check)
+				if exitViolation(result) {
+					return nil
+				}
+				warnings = append(warnings, result.Warnings...)
+			}
+		}
+		record.SetField(col.Name, value)
+		record.UpdatedAt = now
+		record.UpdatedBy = ctx.Actor
+		changedRecords = append(changedRecords, record)
+	}
+	printValidationWarnings(warnings)
+
+	rewrites := make([]valueRewrite, 0, len(counts))
+	for pair, count := range counts {
+		rewrites = append(rewrites, valueRewrite{From: pair[0], To: pair[1], Records: count})
+	}
+	sort.Slice(rewrites, func(i, j int) bool {
+		if rewrites[i].Records != rewrites[j].Records {
+			return rewrites[i].Records > rewrites[j].Records
+		}
+		return rewrites[i].From < rewrites[j].From
+	})
+
+	if normalizeDryRun {
+		ops, err := previewOperations(store, ctx.Stash, changedRecords, model.OpUpdate)
+		if err != nil {
+			return err
+		}
+		if !GetJSONOutput() && !IsQuiet() {
+			printValueRewrites(col.Name, rewrites, len(changedRecords), true)
+		}
+		return outputDryRun(ctx.Stash, ops, map[string]interface{}{
+			"column":  col.Name,
+			"count":   len(changedRecords),
+			"changes": rewrites,
+		})
+	}
+
+	updated := make([]string, 0, len(changedRecords))
+	for _, record := range changedRecords {
+		if err := store.UpdateRecord(ctx.Stash, record); err != nil {
+			if exitRecordTooLarge(ctx.Stash, err) || exitWriteRefused(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to update record %s: %w", record.ID, err)
+		}
+		updated = append(updated, record.ID)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(map[string]interface{}{
+			"stash":   ctx.Stash,
+			"column":  col.Name,
+			"count":   len(updated),
+			"updated": updated,
+			"changes": rewrites,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		printValueRewrites(col.Name, rewrites, len(updated), false)
+	}
+	return nil
+}
+
+// printValueRewrites summarizes the values a normalization changed.
+func printValueRewrites(column string, rewrites []valueRewrite, records int, dryRun bool) {
+	if records == 0 {
+		fmt.Printf("No values of %s need rewriting\n", column)
+		return
+	}
+	verb := "Rewrote"
+	if dryRun {
+		verb = "Would rewrite"
+	}
+	fmt.Printf("%s %s in %d record(s):\n", verb, column, records)
+	for _, r := range rewrites {
+		fmt.Printf("  %q -> %q  (%d)\n", r.From, r.To, r.Records)
+	}
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("rewrites values as individual updates", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Category"})
+		defer cleanup()
+
+		for _, category := range []string{"Electronics", "electronics", "ELECTRONICS", "Furniture", "furniture"} {
+			captureSchemaOutput(t, "add", "Item", "--set", "Category="+category)
+		}
+
+		out := captureSchemaOutput(t, "normalize", "Category", "--trim", "--lower", "--dry-run", "--json")
+		var preview struct {
+			DryRun     bool           `json:"dry_run"`
+			Count      int            `json:"count"`
+			Changes    []valueRewrite `json:"changes"`
+			Operations []interface{}  `json:"operations"`
+		}
+		if err := json.Unmarshal([]byte(out), &preview); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		if !preview.DryRun || preview.Count != 3 || len(preview.Operations) != 3 || len(preview.Changes) != 3 {
+			t.Errorf("unexpected dry run %+v", preview)
+		}
+		out = captureSchemaOutput(t, "count", "--where", "Category=electronics")
+		if strings.TrimSpace(out) != "1" {
+			t.Errorf("expected the dry run to write nothing, got %q", out)
+		}
+
+		out = captureSchemaOutput(t, "normalize", "Category", "--trim", "--lower")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", ExitCode, out)
+		}
+		if !strings.Contains(out, "Rewrote Category in 3 record(s)") || !strings.Contains(out, `"ELECTRONICS" -> "electronics"`) {
+			t.Errorf("expected a summary, got %q", out)
+		}
+		out = captureSchemaOutput(t, "count", "--where", "Category=electronics")
+		if strings.TrimSpace(out) != "3" {
+			t.Errorf("expected 3 electronics, got %q", out)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		history, _ := store.GetAllHistory("inventory")
+		updates := 0
+		for _, op := range history {
+			if op.Operation == model.OpUpdate {
+				updates++
+			}
+		}
+		if updates != 3 {
+			t.Errorf("expected an update in the history per rewritten record, got %d", updates)
+		}
+
+		out = captureSchemaOutput(t, "normalize", "Category", "--lower")
+		if !strings.Contains(out, "No values of Category need rewriting") {
+			t.Errorf("expected nothing left to rewrite, got %q", out)
+		}
+	})
+
+	t.Run("map and list columns", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		captureSchemaOutput(t, "column", "add", "Tags", "--list")
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Tags=Sale,sale,new")
+
+		out := captureSchemaOutput(t, "normalize", "Tags", "--map", "Sale=sale", "--json")
+		var result struct {
+			Count   int            `json:"count"`
+			Changes []valueRewrite `json:"changes"`
+		}
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		if result.Count != 1 || len(result.Changes) != 1 || result.Changes[0] != (valueRewrite{From: "Sale", To: "sale", Records: 1}) {
+			t.Errorf("unexpected result %+v", result)
+		}
+		out = captureSchemaOutput(t, "list", "--json")
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &records); err != nil || len(records) != 1 {
+			t.Fatalf("expected one record, got %q", out)
+		}
+		if tags, _ := json.Marshal(records[0]["Tags"]); string(tags) != `["sale","new"]` {
+			t.Errorf("expected the duplicate element dropped, got %s", tags)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		captureSchemaOutput(t, "column", "add", "Status", "--enum", "open,done")
+		captureSchemaOutput(t, "add", "Task", "--set", "Status=open")
+
+		captureSchemaOutput(t, "normalize", "Status")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 without a rewrite, got %d", ExitCode)
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "normalize", "Status", "--upper")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a value the enum rejects, got %d", ExitCode)
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "normalize", "Nope", "--lower")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for an unknown column, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
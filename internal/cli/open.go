@@ -0,0 +1,363 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Error code for openers that fail
+const ErrCodeOpenFailed = "OPEN_FAILED"
+
+var openForce bool
+
+var openCmd = &cobra.Command{
+	Use:   "open <id> [attachment]",
+	Short: "Open a record in $EDITOR, or an attachment in its default application",
+	Long: `Open a record's attachment in its default application, or the record
+itself in your editor.
+
+With an attachment name, the file is opened with the platform opener:
+xdg-open on Linux and the BSDs, open on macOS, and start on Windows. Set
+STASH_OPENER to use another command; it is given the attachment's path.
+
+Without one, the record's fields open as YAML in $VISUAL or $EDITOR (vi,
+or notepad on Windows, when neither is set). Edit the values, then save and
+quit: the changed fields are written like 'stash set', through the same
+permissions, locks, validation, workflow transitions, and hooks. Nothing
+is written if any check fails. Clear a value with "". List columns are
+YAML sequences. Computed columns are not shown, and columns cannot be
+added or removed here.
+
+If the record changes while it is being edited, nothing is written and
+open exits 1; open it again to edit the new version.
+
+Examples:
+  stash open inv-ex4j
+  stash open inv-ex4j spec.pdf
+  EDITOR="code --wait" stash open inv-ex4j
+  STASH_OPENER=less stash open inv-ex4j build.log
+
+Exit Codes:
+  0  Success (including when nothing was changed)
+  1  Record not found, record changed while editing, opener or editor failed
+  2  Validation error (invalid YAML, unknown or computed column, value
+     breaks a column constraint)
+  3  Record is deleted
+  4  Attachment not found
+
+JSON Output (--json):
+  The updated record, as from 'stash set'; {"id": "inv-ex4j",
+  "updated": []} when nothing changed, and {"id": "inv-ex4j",
+  "attachment": "spec.pdf", "path": "..."} for an attachment.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runOpen,
+}
+
+func init() {
+	openCmd.Flags().BoolVar(&openForce, "force", false, "Bypass workflow transition rules on enum columns")
+	rootCmd.AddCommand(openCmd)
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	recordID := args[0]
+	if len(args) > 1 {
+		if err := model.ValidateAttachmentName(args[1]); err != nil {
+			ExitValidationError(fmt.Sprintf("invalid attachment name '%s'", args[1]),
+				map[string]interface{}{"name": args[1]})
+			return nil
+		}
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	record, err := store.GetRecord(ctx.Stash, recordID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			ExitRecordNotFound(recordID)
+			return nil
+		}
+		if errors.Is(err, model.ErrRecordDeleted) {
+			ExitRecordDeleted(recordID)
+			return nil
+		}
+		return fmt.Errorf("failed to get record: %w", err)
+	}
+
+	if len(args) > 1 {
+		return openAttachment(store, stash, record, args[1])
+	}
+	return editRecord(ctx, store, stash, record)
+}
+
+// openAttachment opens an attachment of a record with the platform opener.
+func openAttachment(store *storage.Store, stash *model.Stash, record *model.Record, name string) error {
+	if _, err := store.GetAttachment(stash.Name, record.ID, name); err != nil {
+		if errors.Is(err, model.ErrAttachmentNotFound) {
+			ExitReferenceError(fmt.Sprintf("attachment '%s' not found for record '%s'", name, record.ID),
+				map[string]interface{}{"record_id": record.ID, "attachment": name})
+			return nil
+		}
+		return fmt.Errorf("failed to get attachment: %w", err)
+	}
+	path := filepath.Join(store.GetFilesDir(stash.Name, record.ID), name)
+
+	opener := openerCommand()
+	c := exec.Command(opener[0], append(opener[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		ExitWithError(1, ErrCodeOpenFailed, fmt.Sprintf("failed to open '%s' with '%s': %v", name, opener[0], err),
+			map[string]interface{}{"record_id": record.ID, "attachment": name, "opener": opener[0]})
+		return nil
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":         record.ID,
+			"attachment": name,
+			"path":       path,
+		})
+		fmt.Println(string(data))
+	} else {
+		Infof("Opened %s of %s\n", name, record.ID)
+	}
+	return nil
+}
+
+// editRecord opens a record's fields in the user's editor as YAML and
+// writes the changed fields, validated like 'stash set'.
+func editRecord(ctx *context.Context, store *storage.Store, stash *model.Stash, record *model.Record) error {
+	content, err := recordYAML(stash, record)
+	if err != nil {
+		return err
+	}
+	edited, err := editInEditor("stash-"+record.ID+"-*.yaml", content)
+	if err != nil {
+		ExitWithError(1, ErrCodeOpenFailed, err.Error(), map[string]interface{}{"record_id": record.ID})
+		return nil
+	}
+
+	updates, ok := parseRecordYAML(stash, record, edited)
+	if !ok {
+		return nil
+	}
+	touched := fieldNames(updates)
+	if len(touched) == 0 {
+		if GetJSONOutput() {
+			data, _ := json.Marshal(map[string]interface{}{"id": record.ID, "updated": []string{}})
+			fmt.Println(string(data))
+		} else {
+			Infof("No changes to %s\n", record.ID)
+		}
+		return nil
+	}
+
+	// The editor may have been open for a while
+	current, err := store.GetRecord(stash.Name, record.ID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordDeleted) {
+			ExitRecordDeleted(record.ID)
+			return nil
+		}
+		return fmt.Errorf("failed to get record: %w", err)
+	}
+	if current.Hash != record.Hash {
+		ExitWithError(1, ErrCodeConflict,
+			fmt.Sprintf("record '%s' changed while it was being edited; nothing was written", record.ID),
+			map[string]interface{}{"record_id": record.ID})
+		return nil
+	}
+	lock, err := CheckLock(ctx.StashDir, stash.Name, record.ID, ctx.Actor)
+	if err != nil {
+		return fmt.Errorf("failed to check lock: %w", err)
+	}
+	if lock != nil {
+		ExitRecordLocked(record.ID, lock)
+		return nil
+	}
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, touched) {
+		return nil
+	}
+
+	coerced := coerceEnums(stash, updates)
+	var warnings []ValidationError
+	for _, name := range touched {
+		col := stash.Columns.Find(name)
+		if !openForce && !checkTransition(col, record, updates[name]) {
+			return nil
+		}
+		valResult := ValidateValue(col, updates[name])
+		if exitViolation(valResult) {
+			return nil
+		}
+		warnings = append(warnings, valResult.Warnings...)
+	}
+	for _, name := range touched {
+		record.SetField(name, updates[name])
+	}
+
+	ruleResult := ValidateRules(stash, record.Fields, touched)
+	if exitViolation(ruleResult) {
+		return nil
+	}
+	warnings = append(warnings, ruleResult.Warnings...)
+	hookResult := ValidateHooks(stash, record, touched)
+	if exitViolation(hookResult) {
+		return nil
+	}
+	warnings = append(warnings, hookResult.Warnings...)
+
+	record.UpdatedAt = time.Now()
+	record.UpdatedBy = ctx.Actor
+	record.Coerced = coerced
+
+	// Violations of warning-severity columns are accepted, and reported
+	for i := range warnings {
+		warnings[i].RecordID = record.ID
+	}
+	printValidationWarnings(warnings)
+
+	if err := store.UpdateRecord(stash.Name, record); err != nil {
+		if exitRecordTooLarge(stash.Name, err) || exitWriteRefused(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to update record: %w", err)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Updated %s: %s\n", record.ID, strings.Join(touched, ", "))
+	}
+	return nil
+}
+
+// recordYAML returns the file a record is edited as: a comment header,
+// then each stored column and its value, in column order.
+func recordYAML(stash *model.Stash, record *model.Record) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, col := range stash.Columns {
+		if col.IsComputed() {
+			continue
+		}
+		value, _ := record.GetField(col.Name)
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: valueText(value)}
+		if col.List {
+			node = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, e := range listElements(value) {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: e})
+			}
+		} else if node.Value == "" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: col.Name}, node)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Record %s in stash '%s'.\n", record.ID, stash.Name)
+	buf.WriteString("# Edit the values, then save and quit. Clear a value with \"\".\n")
+	buf.WriteString("# Columns cannot be added or removed here. Lines starting with '#' are ignored.\n")
+	if len(doc.Content) > 0 {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode record: %w", err)
+		}
+		enc.Close()
+	}
+	return buf.Bytes(), nil
+}
+
+// parseRecordYAML returns the fields of an edited record file whose values
+// differ from the record's, by column name. Values are read as written, so
+// 1.50 stays "1.50". Reports an error and returns false if the file is not
+// a mapping of the stash's stored columns.
+func parseRecordYAML(stash *model.Stash, record *model.Record, data []byte) (map[string]interface{}, bool) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		ExitValidationError(fmt.Sprintf("invalid record file: %v", err), nil)
+		return nil, false
+	}
+	updates := make(map[string]interface{})
+	if len(doc.Content) == 0 {
+		return updates, true
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		ExitValidationError("invalid record file: expected column: value lines", nil)
+		return nil, false
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name, node := mapping.Content[i].Value, mapping.Content[i+1]
+		col := stash.Columns.Find(name)
+		if col == nil {
+			ExitValidationError(fmt.Sprintf("column '%s' not found (columns cannot be added here)", name),
+				map[string]interface{}{"column": name})
+			return nil, false
+		}
+		if col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set", col.Name),
+				map[string]interface{}{"column": col.Name, "computed": col.Computed})
+			return nil, false
+		}
+
+		var value interface{}
+		switch node.Kind {
+		case yaml.ScalarNode:
+			text := node.Value
+			if node.Tag == "!!null" {
+				text = ""
+			}
+			value = columnValue(col, text)
+		case yaml.SequenceNode:
+			var elems []string
+			for _, e := range node.Content {
+				if s := strings.TrimSpace(e.Value); e.Kind == yaml.ScalarNode && s != "" {
+					elems = append(elems, s)
+				}
+			}
+			if !col.List {
+				ExitValidationError(fmt.Sprintf("column '%s' is not a list column", col.Name),
+					map[string]interface{}{"column": col.Name})
+				return nil, false
+			}
+			if len(elems) > 0 {
+				value = listOf(elems)
+			}
+		default:
+			ExitValidationError(fmt.Sprintf("invalid value for column '%s'", col.Name),
+				map[string]interface{}{"column": col.Name})
+			return nil, false
+		}
+
+		current, _ := record.GetField(col.Name)
+		if valueText(current) != valueText(value) {
+			updates[col.Name] = value
+		}
+	}
+	return updates, true
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestOpen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor and opener are shell scripts")
+	}
+
+	// fakeEditor installs an editor that saves the file it was given to
+	// seen and replaces it with content.
+	fakeEditor := func(t *testing.T, content string) (seen string) {
+		dir := t.TempDir()
+		seen = filepath.Join(dir, "seen.yaml")
+		replacement := filepath.Join(dir, "replacement.yaml")
+		os.WriteFile(replacement, []byte(content), 0644)
+		script := filepath.Join(dir, "editor.sh")
+		os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" '"+seen+"'\ncp '"+replacement+"' \"$1\"\n"), 0755)
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", script)
+		return seen
+	}
+
+	// setup creates a stash with one record, and returns its directory
+	// and the record's ID.
+	setup := func(t *testing.T) (string, string, func()) {
+		t.Helper()
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		store.AddColumn("inventory", model.Column{
+			Name: "Status", Enum: []string{"pending", "active"}, Added: time.Now(), AddedBy: "test",
+		})
+		store.Close()
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Price=1.50")
+		store, _ = storage.NewStore(filepath.Join(tempDir, ".stash"))
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.Close()
+		return tempDir, records[0].ID, cleanup
+	}
+
+	t.Run("writes the edited fields", func(t *testing.T) {
+		tempDir, id, cleanup := setup(t)
+		defer cleanup()
+
+		seen := fakeEditor(t, "Name: Laptop Pro\nPrice: 1.50\nStatus: active\n")
+		captureSchemaOutput(t, "open", id)
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		data, _ := os.ReadFile(seen)
+		if !strings.Contains(string(data), "Name: Laptop") || !strings.Contains(string(data), `Status: ""`) {
+			t.Errorf("expected the record's fields in the edited file, got:\n%s", data)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		record, _ := store.GetRecord("inventory", id)
+		if record.Fields["Name"] != "Laptop Pro" || record.Fields["Status"] != "active" {
+			t.Errorf("expected edited fields to be written, got %v", record.Fields)
+		}
+		history, _ := store.GetRecordHistory("inventory", id)
+		if len(history) != 2 {
+			t.Fatalf("expected 2 operations, got %d", len(history))
+		}
+		if _, ok := history[1].Fields["Price"]; !ok || valueText(history[1].Fields["Price"]) != "1.50" {
+			t.Errorf("expected unchanged Price to keep its text, got %v", history[1].Fields["Price"])
+		}
+	})
+
+	t.Run("rejects values that break a constraint", func(t *testing.T) {
+		tempDir, id, cleanup := setup(t)
+		defer cleanup()
+
+		fakeEditor(t, "Name: Laptop Pro\nStatus: closed\n")
+		captureSchemaOutput(t, "open", id)
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		if record, _ := store.GetRecord("inventory", id); record.Fields["Name"] != "Laptop" {
+			t.Errorf("expected nothing written, got %v", record.Fields)
+		}
+	})
+
+	t.Run("rejects unknown columns", func(t *testing.T) {
+		_, id, cleanup := setup(t)
+		defer cleanup()
+
+		fakeEditor(t, "Name: Laptop\nColour: red\n")
+		captureSchemaOutput(t, "open", id)
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("opens an attachment with the opener", func(t *testing.T) {
+		tempDir, id, cleanup := setup(t)
+		defer cleanup()
+
+		file := filepath.Join(t.TempDir(), "spec.md")
+		os.WriteFile(file, []byte("# Spec\n"), 0644)
+		captureSchemaOutput(t, "attach", id, file)
+
+		opened := filepath.Join(t.TempDir(), "opened")
+		script := filepath.Join(t.TempDir(), "opener.sh")
+		os.WriteFile(script, []byte("#!/bin/sh\necho \"$1\" > '"+opened+"'\n"), 0755)
+		t.Setenv("STASH_OPENER", script)
+
+		captureSchemaOutput(t, "open", id, "spec.md")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		data, _ := os.ReadFile(opened)
+		want := filepath.Join(tempDir, ".stash", "inventory", "files", id, "spec.md")
+		if strings.TrimSpace(string(data)) != want {
+			t.Errorf("expected opener to get %s, got %q", want, data)
+		}
+
+		captureSchemaOutput(t, "open", id, "missing.md")
+		if ExitCode != 4 {
+			t.Errorf("expected exit code 4 for a missing attachment, got %d", ExitCode)
+		}
+	})
+}
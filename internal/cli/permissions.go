@@ -0,0 +1,269 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	permissionsOps     string
+	permissionsColumns string
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Show or manage per-actor write restrictions",
+	Long: `Show the write restrictions configured for a stash.
+
+A permission rule limits what one actor may write: which operations they
+may perform and, when adding or setting values, which columns they may set.
+Actors without a rule are unrestricted unless a rule for "*" exists, which
+then applies to every actor without a rule of their own.
+
+Operations:
+  create   add, import
+  update   set, bulk-set, move, assign, unassign
+  delete   rm, purge
+  restore  restore
+  archive  archive, unarchive
+  attach   attach, detach
+
+Rules are stored in the stash's config.json. They guard against agents
+writing outside their role; combine them with signed operations ('stash
+actor keygen') so an actor cannot simply claim another name with --actor.
+
+Examples:
+  stash permissions
+  stash permissions set reviewer --ops update --columns Status
+  stash permissions set "*" --ops create,update
+  stash permissions remove reviewer
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "tasks", "permissions": [{"actor": "reviewer", "operations": ["update"], "columns": ["Status"]}]}`,
+	Args: cobra.NoArgs,
+	RunE: runPermissions,
+}
+
+var permissionsSetCmd = &cobra.Command{
+	Use:   "set <actor>",
+	Short: "Restrict what an actor may write",
+	Long: `Add or replace the permission rule for an actor.
+
+--ops lists the operations the actor may perform; --columns lists the
+columns they may set. Omitting one leaves that axis unrestricted. Use "*"
+as the actor to restrict every actor without a rule of their own.
+
+Writes that break a rule fail with exit code 6.
+
+Examples:
+  stash permissions set reviewer --ops update --columns Status
+  stash permissions set ingest-bot --ops create
+  stash permissions set "*" --ops create,update --columns Name,Notes
+
+AI Agent Examples:
+  # Let a review agent move tasks through the workflow and nothing else
+  stash permissions set review-agent --ops update --columns Status,ReviewNotes
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (unknown operation, no restriction given)
+
+JSON Output (--json):
+  {"stash": "tasks", "permission": {"actor": "reviewer", "operations": ["update"], "columns": ["Status"]}}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPermissionsSet,
+}
+
+var permissionsRemoveCmd = &cobra.Command{
+	Use:   "remove <actor>",
+	Short: "Remove an actor's permission rule",
+	Long: `Remove the permission rule for an actor, lifting its restrictions.
+
+Examples:
+  stash permissions remove reviewer
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or the actor has no rule
+
+JSON Output (--json):
+  {"stash": "tasks", "removed": "reviewer"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPermissionsRemove,
+}
+
+func init() {
+	permissionsSetCmd.Flags().StringVar(&permissionsOps, "ops", "", "Comma-separated operations the actor may perform")
+	permissionsSetCmd.Flags().StringVar(&permissionsColumns, "columns", "", "Comma-separated columns the actor may set")
+	permissionsCmd.AddCommand(permissionsSetCmd)
+	permissionsCmd.AddCommand(permissionsRemoveCmd)
+	rootCmd.AddCommand(permissionsCmd)
+}
+
+// checkPermission reports a write the actor's permission rule forbids, if
+// any. Returns true if the write may proceed.
+func checkPermission(stash *model.Stash, actor, op string, columns []string) bool {
+	var permErr *model.PermissionError
+	if err := stash.CheckPermission(actor, op, columns); errors.As(err, &permErr) {
+		ExitPermissionDenied(stash.Name, permErr)
+		return false
+	}
+	return true
+}
+
+// fieldNames returns the names of the fields being written, sorted so a
+// denied column is reported deterministically.
+func fieldNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitPermissionList splits a comma-separated flag value, dropping blanks.
+func splitPermissionList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func runPermissions(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Output result
+	if GetJSONOutput() {
+		perms := stash.Permissions
+		if perms == nil {
+			perms = []model.Permission{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "permissions": perms})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(stash.Permissions) == 0 {
+		fmt.Printf("Stash '%s' has no write restrictions\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Write restrictions for stash '%s':\n", stash.Name)
+	for _, perm := range stash.Permissions {
+		fmt.Printf("  %s\n", describePermission(perm))
+	}
+	return nil
+}
+
+func runPermissionsSet(cmd *cobra.Command, args []string) error {
+	perm := model.Permission{
+		Actor:      args[0],
+		Operations: splitPermissionList(permissionsOps),
+		Columns:    splitPermissionList(permissionsColumns),
+	}
+	if len(perm.Operations) == 0 && len(perm.Columns) == 0 {
+		ExitValidationError("at least one of --ops or --columns is required", nil)
+		return nil
+	}
+	for i, op := range perm.Operations {
+		op = strings.ToLower(op)
+		if err := model.ValidatePermissionOperation(op); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"operation": op})
+			return nil
+		}
+		perm.Operations[i] = op
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Columns must exist; store them with their actual names
+	for i, name := range perm.Columns {
+		col := resolveColumn(stash, name)
+		if col == nil {
+			return nil
+		}
+		perm.Columns[i] = col.Name
+	}
+
+	stash.SetPermission(perm)
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update permissions: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "permission": perm})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Set permissions in stash '%s': %s\n", stash.Name, describePermission(perm))
+	}
+	return nil
+}
+
+func runPermissionsRemove(cmd *cobra.Command, args []string) error {
+	actor := args[0]
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !stash.RemovePermission(actor) {
+		ExitWithError(1, ErrCodeValidation, fmt.Sprintf("actor '%s' has no permission rule", actor),
+			map[string]interface{}{"actor": actor})
+		return nil
+	}
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update permissions: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "removed": actor})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed permission rule for '%s' from stash '%s'\n", actor, stash.Name)
+	}
+	return nil
+}
+
+// describePermission formats a rule for display.
+func describePermission(perm model.Permission) string {
+	ops := "any operation"
+	if len(perm.Operations) > 0 {
+		ops = strings.Join(perm.Operations, ", ")
+	}
+	cols := "any column"
+	if len(perm.Columns) > 0 {
+		cols = strings.Join(perm.Columns, ", ")
+	}
+	return fmt.Sprintf("%s: %s; %s", perm.Actor, ops, cols)
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	t.Run("column restriction is enforced on set", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status", "Priority"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"permissions", "set", "reviewer", "--ops", "update", "--columns", "status"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		var created map[string]interface{}
+		output := captureSchemaOutput(t, "add", "Write docs", "--json")
+		if err := json.Unmarshal([]byte(output), &created); err != nil {
+			t.Fatalf("failed to parse add output %q: %v", output, err)
+		}
+		id, _ := created["_id"].(string)
+
+		rootCmd.SetArgs([]string{"set", id, "Status=done", "--actor", "reviewer"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected reviewer to set Status, got exit code %d", ExitCode)
+		}
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"set", id, "Priority=high", "--actor", "reviewer"})
+		rootCmd.Execute()
+		if ExitCode != 6 {
+			t.Errorf("expected exit code 6 for denied column, got %d", ExitCode)
+		}
+		resetFlags()
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"rm", id, "--actor", "reviewer", "--yes"})
+		rootCmd.Execute()
+		if ExitCode != 6 {
+			t.Errorf("expected exit code 6 for denied operation, got %d", ExitCode)
+		}
+		resetFlags()
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"add", "Another", "--actor", "reviewer"})
+		rootCmd.Execute()
+		if ExitCode != 6 {
+			t.Errorf("expected exit code 6 for denied create, got %d", ExitCode)
+		}
+		resetFlags()
+
+		// Other actors are unrestricted
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", id, "Priority=high", "--actor", "alice"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected unrestricted actor to succeed, got exit code %d", ExitCode)
+		}
+	})
+
+	t.Run("denied write reports a permission error", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"permissions", "set", "*", "--ops", "update"})
+		rootCmd.Execute()
+		resetFlags()
+
+		output := captureSchemaOutput(t, "add", "Task", "--actor", "bot", "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["code"] != ErrCodePermissionError {
+			t.Errorf("expected %s, got %v", ErrCodePermissionError, result["code"])
+		}
+	})
+
+	t.Run("list and remove rules", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"permissions", "set", "reviewer", "--ops", "update"})
+		rootCmd.Execute()
+		resetFlags()
+
+		output := captureSchemaOutput(t, "permissions", "--json")
+		var result struct {
+			Permissions []struct {
+				Actor      string   `json:"actor"`
+				Operations []string `json:"operations"`
+			} `json:"permissions"`
+		}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if len(result.Permissions) != 1 || result.Permissions[0].Actor != "reviewer" {
+			t.Fatalf("expected reviewer rule, got %+v", result.Permissions)
+		}
+
+		rootCmd.SetArgs([]string{"permissions", "remove", "reviewer"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"permissions", "remove", "reviewer"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for missing rule, got %d", ExitCode)
+		}
+	})
+
+	t.Run("rejects unknown operations and columns", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"permissions", "set", "reviewer", "--ops", "drop"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for unknown operation, got %d", ExitCode)
+		}
+		resetFlags()
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"permissions", "set", "reviewer", "--columns", "Missing"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for unknown column, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// porcelainEscaper escapes the characters that would break a porcelain
+// line or field apart.
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// printPorcelain prints one porcelain line: the fields, escaped, separated
+// by tabs.
+func printPorcelain(fields ...string) {
+	for i, field := range fields {
+		fields[i] = porcelainEscaper.Replace(field)
+	}
+	fmt.Println(strings.Join(fields, "\t"))
+}
+
+// porcelainTime formats a timestamp for porcelain output: RFC 3339 in UTC,
+// or empty for the zero time.
+func porcelainTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// valueText formats a field value as text; unset values are empty and
+// list elements are separated by commas.
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		return strings.Join(listElements(v), ", ")
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// printRecordPorcelain prints a record as "key<TAB>value" lines: the system
+// fields, then the set user fields sorted by name, then one _child line per
+// child.
+func printRecordPorcelain(record *model.Record, children []*model.Record) {
+	printPorcelain("_id", record.ID)
+	printPorcelain("_parent_id", record.ParentID)
+	printPorcelain("_status", recordStatus(record))
+	printPorcelain("_hash", record.Hash)
+	printPorcelain("_created_at", porcelainTime(record.CreatedAt))
+	printPorcelain("_created_by", record.CreatedBy)
+	printPorcelain("_updated_at", porcelainTime(record.UpdatedAt))
+	printPorcelain("_updated_by", record.UpdatedBy)
+
+	names := make([]string, 0, len(record.Fields))
+	for name := range record.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printPorcelain(name, valueText(record.Fields[name]))
+	}
+
+	for _, child := range children {
+		printPorcelain("_child", child.ID)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPorcelain(t *testing.T) {
+	// setup creates Laptop with a child Battery, and returns their IDs
+	setup := func(t *testing.T) (string, string, func()) {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		var laptop, battery map[string]interface{}
+		out := captureSchemaOutput(t, "add", "Laptop", "--set", "Notes=line one\nline\ttwo", "--json")
+		if err := json.Unmarshal([]byte(out), &laptop); err != nil {
+			t.Fatalf("failed to parse add output: %v\n%s", err, out)
+		}
+		out = captureSchemaOutput(t, "add", "Battery", "--parent", laptop["_id"].(string), "--json")
+		if err := json.Unmarshal([]byte(out), &battery); err != nil {
+			t.Fatalf("failed to parse add output: %v\n%s", err, out)
+		}
+		ExitCode = 0
+		return laptop["_id"].(string), battery["_id"].(string), cleanup
+	}
+
+	t.Run("list prints one tab-separated line per record", func(t *testing.T) {
+		laptopID, _, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "list", "--columns", "Name,Notes", "--porcelain")
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 line without header or total, got:\n%s", out)
+		}
+		fields := strings.Split(lines[0], "\t")
+		if len(fields) != 5 {
+			t.Fatalf("expected 5 fields, got %q", fields)
+		}
+		if fields[0] != laptopID || fields[1] != "active" || !strings.HasSuffix(fields[2], "Z") {
+			t.Errorf("unexpected leading fields: %q", fields)
+		}
+		if fields[3] != "Laptop" || fields[4] != `line one\nline\ttwo` {
+			t.Errorf("expected escaped values, got %q", fields[3:])
+		}
+	})
+
+	t.Run("list prints nothing when empty", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		if out := captureSchemaOutput(t, "list", "--porcelain"); out != "" {
+			t.Errorf("expected no output, got %q", out)
+		}
+	})
+
+	t.Run("show prints key/value lines with children", func(t *testing.T) {
+		laptopID, batteryID, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "show", laptopID, "--porcelain")
+		for _, line := range []string{
+			"_id\t" + laptopID,
+			"_parent_id\t",
+			"_status\tactive",
+			"Name\tLaptop",
+			"Notes\tline one\\nline\\ttwo",
+			"_child\t" + batteryID,
+		} {
+			if !strings.Contains(out, line+"\n") {
+				t.Errorf("expected line %q, got:\n%s", line, out)
+			}
+		}
+
+		// Several records are separated by an empty line
+		out = captureSchemaOutput(t, "show", laptopID, batteryID, "--porcelain")
+		if strings.Count(out, "\n\n") != 1 || !strings.Contains(out, "_parent_id\t"+laptopID+"\n") {
+			t.Errorf("expected two records separated by an empty line, got:\n%s", out)
+		}
+	})
+
+	t.Run("history prints one line per change", func(t *testing.T) {
+		laptopID, _, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "history", laptopID, "--porcelain")
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 line without header or count, got:\n%s", out)
+		}
+		fields := strings.Split(lines[0], "\t")
+		if len(fields) != 5 || fields[1] != "create" || fields[2] != laptopID {
+			t.Errorf("unexpected history line: %q", fields)
+		}
+	})
+
+	t.Run("locks prints one line per lock", func(t *testing.T) {
+		laptopID, _, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "lock", laptopID, "--agent", "agent-1")
+		resetLockFlags()
+		out := captureSchemaOutput(t, "locks", "--porcelain")
+		fields := strings.Split(strings.TrimSuffix(out, "\n"), "\t")
+		if len(fields) != 4 || fields[0] != laptopID || fields[1] != "agent-1" {
+			t.Errorf("unexpected locks line: %q", out)
+		}
+	})
+
+	t.Run("quiet suppresses the total", func(t *testing.T) {
+		_, _, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "list", "--quiet")
+		if strings.Contains(out, "Total:") || !strings.Contains(out, "Laptop") {
+			t.Errorf("expected records without a total, got:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var prefixMigrate bool
+
+var prefixCmd = &cobra.Command{
+	Use:   "prefix",
+	Short: "Manage a stash's record ID prefix",
+	Long: `Manage the prefix used to generate record IDs.
+
+Examples:
+  stash prefix set inventory itm-
+  stash prefix set inventory itm- --migrate`,
+}
+
+var prefixSetCmd = &cobra.Command{
+	Use:   "set <stash> <new-prefix>",
+	Short: "Change a stash's record ID prefix",
+	Long: `Change the prefix used for new record IDs.
+
+Without --migrate, only records created from now on use the new prefix;
+existing IDs are unchanged and remain valid.
+
+With --migrate, every record ID using the old prefix is rewritten to the
+new prefix (inv-ex4j -> itm-ex4j, inv-ex4j.1 -> itm-ex4j.1). Parent
+references, attachment directories, and lock entries follow. The JSONL
+log, including history, is rewritten in a single atomic write and the
+cache is rebuilt. If the log cannot be rewritten, the old prefix is
+restored.
+
+Signatures cover record IDs (see 'stash actor'), so the signed operations
+of migrated records are signed again with their actors' keys where this
+machine has them, such as your own. The others lose their signatures;
+the records affected are listed, so their actors can re-sign them or
+'stash verify --signatures' reports can be explained.
+
+Prefix requirements:
+  - 3-5 characters total
+  - 2-4 lowercase letters followed by a dash
+
+Examples:
+  stash prefix set inventory itm-
+  stash prefix set inventory itm- --migrate
+  stash prefix set inventory itm- --migrate --json
+
+Exit Codes:
+  0  Success
+  1  Stash not found, a migrated ID already exists, or the IDs were
+     migrated but the locks on them could not be
+  2  Validation error (invalid prefix)
+
+JSON Output (--json):
+  {"stash": "inventory", "old_prefix": "inv-", "new_prefix": "itm-",
+   "migrated": 2, "id_mapping": {"inv-ex4j": "itm-ex4j", ...},
+   "resigned": ["itm-ex4j"], "unsigned": ["itm-ex4j.1"]}`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPrefixSet,
+}
+
+func init() {
+	prefixSetCmd.Flags().BoolVar(&prefixMigrate, "migrate", false, "Rewrite existing record IDs to the new prefix")
+	prefixCmd.AddCommand(prefixSetCmd)
+	rootCmd.AddCommand(prefixCmd)
+}
+
+func runPrefixSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	newPrefix := args[1]
+
+	if err := model.ValidatePrefix(newPrefix); err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"prefix": newPrefix})
+		return nil
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), name)
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	stash, err := store.GetStash(name)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(name)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+	oldPrefix := stash.Prefix
+
+	change, err := store.ChangePrefix(name, newPrefix, prefixMigrate)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordExists) {
+			ExitWithError(1, ErrCodeConflict, err.Error(), map[string]interface{}{"stash": name})
+			return nil
+		}
+		return fmt.Errorf("failed to change prefix: %w", err)
+	}
+
+	mapping := change.Mapping
+
+	// Point existing locks at the migrated IDs
+	if len(mapping) > 0 {
+		if err := migrateLocks(ctx.StashDir, name, mapping); err != nil {
+			return fmt.Errorf("migrated %d record ID(s) to %s, but failed to migrate their locks, which still name the old IDs: %w", len(mapping), newPrefix, err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"stash":      name,
+			"old_prefix": oldPrefix,
+			"new_prefix": newPrefix,
+			"migrated":   len(mapping),
+			"id_mapping": mapping,
+			"resigned":   change.Resigned,
+			"unsigned":   change.Unsigned,
+		}
+		data, err := json.Marshal(output)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Changed prefix of stash '%s': %s -> %s\n", name, oldPrefix, newPrefix)
+		if prefixMigrate {
+			fmt.Printf("Migrated %d record ID(s)\n", len(mapping))
+		}
+		if len(change.Resigned) > 0 {
+			fmt.Printf("Signed the operations of %d migrated record(s) again\n", len(change.Resigned))
+		}
+		if len(change.Unsigned) > 0 {
+			fmt.Printf("Dropped signatures without a key here to sign again, on: %s\n", strings.Join(change.Unsigned, ", "))
+		}
+		if IsVerbose() {
+			oldIDs := make([]string, 0, len(mapping))
+			for oldID := range mapping {
+				oldIDs = append(oldIDs, oldID)
+			}
+			sort.Strings(oldIDs)
+			for _, oldID := range oldIDs {
+				fmt.Printf("  %s -> %s\n", oldID, mapping[oldID])
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateLocks rewrites lock entries for a stash using an old-to-new ID mapping.
+func migrateLocks(stashDir, stashName string, mapping map[string]string) error {
+	fileLock, err := lockLocksFile(stashDir, stashName)
+	if err != nil {
+		return err
+	}
+	defer fileLock.Unlock()
+
+	locks, err := loadLocks(stashDir, stashName)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, lock := range locks {
+		if newID, ok := mapping[lock.RecordID]; ok {
+			lock.RecordID = newID
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return saveLocks(stashDir, stashName, locks)
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestPrefixSet(t *testing.T) {
+	t.Run("migrate rewrites IDs, parents, files, and locks", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"add", "Laptop"})
+		rootCmd.Execute()
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.Close()
+		parentID := records[0].ID
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"add", "Charger", "--parent", parentID})
+		rootCmd.Execute()
+		resetFlags()
+
+		testFile := filepath.Join(tempDir, "manual.txt")
+		os.WriteFile(testFile, []byte("manual"), 0644)
+		rootCmd.SetArgs([]string{"attach", parentID, testFile})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"lock", parentID})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"prefix", "set", "inventory", "itm-", "--migrate"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		newParentID := "itm-" + strings.TrimPrefix(parentID, "inv-")
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+
+		stash, _ := store.GetStash("inventory")
+		if stash.Prefix != "itm-" {
+			t.Errorf("expected prefix 'itm-', got %q", stash.Prefix)
+		}
+
+		if _, err := store.GetRecord("inventory", parentID); err == nil {
+			t.Errorf("expected old ID %s to be gone", parentID)
+		}
+		children, _ := store.GetChildren("inventory", newParentID)
+		if len(children) != 1 || children[0].ID != newParentID+".1" {
+			t.Errorf("expected child %s.1 under migrated parent, got %v", newParentID, children)
+		}
+
+		history, _ := store.GetRecordHistory("inventory", newParentID)
+		if len(history) == 0 {
+			t.Error("expected history to follow the migrated ID")
+		}
+
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "inventory", "files", newParentID, "manual.txt")); err != nil {
+			t.Errorf("expected attachment under migrated ID: %v", err)
+		}
+
+		locks, _ := loadLocks(filepath.Join(tempDir, ".stash"), "inventory")
+		if len(locks) != 1 || locks[0].RecordID != newParentID {
+			t.Errorf("expected lock on %s, got %+v", newParentID, locks)
+		}
+	})
+
+	t.Run("without --migrate only new records use the new prefix", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"add", "Laptop"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"prefix", "set", "inventory", "itm-"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"add", "Mouse"})
+		rootCmd.Execute()
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*", OrderBy: []storage.OrderKey{{Field: "_id"}}})
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		if !strings.HasPrefix(records[0].ID, "inv-") || !strings.HasPrefix(records[1].ID, "itm-") {
+			t.Errorf("expected inv- then itm- IDs, got %s and %s", records[0].ID, records[1].ID)
+		}
+	})
+
+	t.Run("a failed lock migration fails the command", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"add", "Laptop"})
+		rootCmd.Execute()
+		resetFlags()
+
+		stashDir := filepath.Join(tempDir, ".stash")
+		os.WriteFile(locksFilePath(stashDir, "inventory"), []byte("not json"), 0644)
+
+		rootCmd.SetArgs([]string{"prefix", "set", "inventory", "itm-", "--migrate"})
+		err := rootCmd.Execute()
+		resetFlags()
+		if err == nil || !strings.Contains(err.Error(), "failed to migrate their locks") {
+			t.Errorf("expected the lock migration failure to fail the command, got %v", err)
+		}
+	})
+
+	t.Run("must reject invalid prefix", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"prefix", "set", "inventory", "BAD"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -53,7 +53,7 @@ func runPrime(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -160,7 +160,6 @@ func runPrime(cmd *cobra.Command, args []string) error {
 		recentRecords, err := store.ListRecords(stash.Name, storage.ListOptions{
 			ParentID:   "*",
 			Limit:      5,
-			OrderBy:    "updated_at",
 			Descending: true,
 		})
 		if err == nil && len(recentRecords) > 0 {
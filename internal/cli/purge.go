@@ -13,15 +13,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var (
-	purgeID     string
-	purgeBefore string
-	purgeAll    bool
-	purgeDryRun bool
-	purgeYes    bool
+	purgeID      string
+	purgeBefore  string
+	purgeAll     bool
+	purgeDryRun  bool
+	purgeYes     bool
+	purgeExpired bool
 )
 
 var purgeCmd = &cobra.Command{
@@ -33,11 +33,21 @@ This is irreversible! Records will be removed from JSONL and SQLite.
 Associated files will also be deleted.
 
 Use --dry-run to preview what would be deleted without making changes.
+'stash trash' lists the soft-deleted records, and can restore or purge
+them in bulk.
+
+--expired purges records older than the stash's retention policy
+(see 'stash retention').
+
+A deleted record whose children are still live is never purged, since
+that would leave the children orphaned: purging it by --id fails with
+exit code 4, and bulk purges skip it with a warning.
 
 Examples:
   stash purge --id inv-ex4j --yes           # Purge specific record
   stash purge --before 30d --yes            # Purge records deleted > 30 days ago
   stash purge --all --yes                   # Purge all deleted records
+  stash purge --expired --yes               # Purge records past the retention period
   stash purge --before 7d --dry-run         # Preview what would be purged`,
 	Args: cobra.NoArgs,
 	RunE: runPurge,
@@ -47,6 +57,7 @@ func init() {
 	purgeCmd.Flags().StringVar(&purgeID, "id", "", "Purge specific record by ID")
 	purgeCmd.Flags().StringVar(&purgeBefore, "before", "", "Purge records deleted before duration (e.g., 30d, 7d, 24h)")
 	purgeCmd.Flags().BoolVar(&purgeAll, "all", false, "Purge all deleted records")
+	purgeCmd.Flags().BoolVar(&purgeExpired, "expired", false, "Purge records deleted longer ago than the stash's retention period")
 	purgeCmd.Flags().BoolVar(&purgeDryRun, "dry-run", false, "Preview what would be purged without making changes")
 	purgeCmd.Flags().BoolVarP(&purgeYes, "yes", "y", false, "Skip confirmation prompt")
 	rootCmd.AddCommand(purgeCmd)
@@ -54,8 +65,8 @@ func init() {
 
 func runPurge(cmd *cobra.Command, args []string) error {
 	// Validate flags - need at least one selection criteria
-	if purgeID == "" && purgeBefore == "" && !purgeAll {
-		fmt.Fprintln(os.Stderr, "Error: specify --id, --before, or --all to select records to purge")
+	if purgeID == "" && purgeBefore == "" && !purgeAll && !purgeExpired {
+		fmt.Fprintln(os.Stderr, "Error: specify --id, --before, --all, or --expired to select records to purge")
 		Exit(2)
 		return nil
 	}
@@ -77,14 +88,14 @@ func runPurge(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Get stash configuration
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -94,6 +105,10 @@ func runPurge(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermDelete, nil) {
+		return nil
+	}
+
 	// Build list of records to purge
 	var toPurge []*model.Record
 
@@ -116,6 +131,18 @@ func runPurge(cmd *cobra.Command, args []string) error {
 		}
 
 		toPurge = append(toPurge, record)
+	} else if purgeExpired {
+		if _, ok := stash.RetentionPeriod(); !ok {
+			fmt.Fprintf(os.Stderr, "Error: stash '%s' has no retention policy (set one with 'stash retention 30d')\n", ctx.Stash)
+			Exit(2)
+			return nil
+		}
+
+		expired, err := store.ListExpiredRecords(ctx.Stash, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to list expired records: %w", err)
+		}
+		toPurge = expired
 	} else {
 		// Parse --before duration
 		var beforeTime *time.Time
@@ -138,6 +165,29 @@ func runPurge(cmd *cobra.Command, args []string) error {
 		toPurge = deleted
 	}
 
+	// Purging a parent would orphan its live children: refuse a single
+	// record, skip such records in a bulk purge
+	var kept []*model.Record
+	for _, rec := range toPurge {
+		children, err := store.GetChildren(ctx.Stash, rec.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get children: %w", err)
+		}
+		if len(children) == 0 {
+			kept = append(kept, rec)
+			continue
+		}
+		if purgeID != "" {
+			ExitReferenceError(fmt.Sprintf("record '%s' has %d live child record(s); delete them or re-parent them with 'stash move' first", rec.ID, len(children)),
+				map[string]interface{}{"record_id": rec.ID, "children": getRecordIDs(children)})
+			return nil
+		}
+		if !IsQuiet() {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: it has %d live child record(s)\n", rec.ID, len(children))
+		}
+	}
+	toPurge = kept
+
 	if len(toPurge) == 0 {
 		if !IsQuiet() {
 			fmt.Println("No deleted records found matching criteria.")
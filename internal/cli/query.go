@@ -6,8 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
@@ -19,6 +23,11 @@ var (
 	queryCSV       bool
 	queryNoHeaders bool
 	queryColumns   string
+	queryExplain   bool
+	querySample    int
+	querySeed      int64
+	queryHead      int
+	queryTail      int
 )
 
 var queryCmd = &cobra.Command{
@@ -36,6 +45,13 @@ Output formats:
   --csv          Output as CSV with headers
   --no-headers   Omit header row in CSV output (for scripting)
   --columns      Select specific columns in CSV output (comma-separated)
+  --explain      Show SQLite's query plan instead of running the query
+
+Picking rows:
+  --head N       Show the first N rows
+  --tail N       Show the last N rows
+  --sample N     Show N rows picked at random, in the query's order;
+                 --seed S picks the same rows every time
 
 Examples:
   stash query "SELECT Name, Price FROM inventory WHERE Price > 100"
@@ -45,6 +61,8 @@ Examples:
   stash query "SELECT * FROM inventory" --csv
   stash query "SELECT * FROM inventory" --csv --no-headers
   stash query "SELECT * FROM inventory" --csv --columns "Name,Price"
+  stash query "SELECT * FROM inventory WHERE Price > 100" --explain
+  stash query "SELECT Name, Price FROM inventory" --sample 20 --seed 7
 
 AI Agent Examples:
   # Get pending work queue
@@ -71,10 +89,19 @@ AI Agent Examples:
       done
   done
 
+Explaining a query (--explain):
+  The query is not run. Instead stash prints the SQL and the plan SQLite
+  would follow, from EXPLAIN QUERY PLAN: which tables it reads in full
+  ("SCAN"), which it looks up by index ("SEARCH ... USING INDEX"), and
+  whether it sorts the results afterwards ("USE TEMP B-TREE"). Hints point
+  out full scans under a filter and sorts, the usual causes of a slow
+  query.
+
 Exit Codes:
   0  Success
   1  Stash not found
-  2  Invalid SQL (syntax error, non-SELECT statement)
+  2  Invalid SQL (syntax error, non-SELECT statement), or more than one
+     of --head, --tail, and --sample
 
 Note: This queries the SQLite cache, not the JSONL source. For most use
 cases, the cache is up-to-date, but after manual JSONL edits, run
@@ -87,6 +114,11 @@ func init() {
 	queryCmd.Flags().BoolVar(&queryCSV, "csv", false, "Output as CSV format")
 	queryCmd.Flags().BoolVar(&queryNoHeaders, "no-headers", false, "Omit header row in CSV output")
 	queryCmd.Flags().StringVar(&queryColumns, "columns", "", "Select specific columns in CSV output (comma-separated)")
+	queryCmd.Flags().BoolVar(&queryExplain, "explain", false, "Show the query plan instead of running the query")
+	queryCmd.Flags().IntVar(&queryHead, "head", 0, "Show the first N rows")
+	queryCmd.Flags().IntVar(&queryTail, "tail", 0, "Show the last N rows")
+	queryCmd.Flags().IntVar(&querySample, "sample", 0, "Show N rows picked at random")
+	queryCmd.Flags().Int64Var(&querySeed, "seed", 0, "With --sample, pick the same rows for the same seed")
 	rootCmd.AddCommand(queryCmd)
 }
 
@@ -117,14 +149,123 @@ func isSelectQuery(query string) bool {
 	return true
 }
 
+// querySuggestion returns a "did you mean" hint when a query failed on a
+// column name that is a likely typo of one of the stash's columns.
+func querySuggestion(stash *model.Stash, err error) string {
+	_, name, found := strings.Cut(err.Error(), "no such column: ")
+	if !found {
+		return ""
+	}
+	if suggestion := stash.Columns.Suggest(strings.TrimSpace(name)); suggestion != "" {
+		return fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+	}
+	return ""
+}
+
 func runQuery(cmd *cobra.Command, args []string) error {
-	query := args[0]
+	if !checkRowPicks(queryHead, queryTail, querySample, querySeed) {
+		return nil
+	}
+	if queryExplain {
+		return explainQuery(args[0])
+	}
+
+	rows, columns, ok, err := executeQuery(args[0])
+	if !ok {
+		return err
+	}
+	rows = pickRows(rows, queryHead, queryTail, querySample, querySeed)
+
+	// AC-03: JSON output
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// CSV output
+	if queryCSV {
+		return outputQueryCSV(os.Stdout, rows, columns)
+	}
+
+	return printQueryTable(rows, columns)
+}
+
+// executeQuery runs a read-only SQL query against the current stash. If it
+// returns ok == false, the error has been reported (or is returned in err)
+// and the caller should return err.
+func executeQuery(query string) ([]map[string]interface{}, []string, bool, error) {
+	store, stash, ok, err := openQueryStore(query)
+	if !ok {
+		return nil, nil, false, err
+	}
+	defer store.Close()
+
+	// Execute query
+	rows, columns, err := store.RawQuery(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: query failed: %v%s\n", err, querySuggestion(stash, err))
+		Exit(3)
+		return nil, nil, false, nil
+	}
+	return rows, columns, true, nil
+}
+
+// explainQuery prints the plan of a read-only SQL query without running it.
+func explainQuery(query string) error {
+	store, stash, ok, err := openQueryStore(query)
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	plan, err := store.ExplainQuery(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: query failed: %v%s\n", err, querySuggestion(stash, err))
+		Exit(3)
+		return nil
+	}
+	filtered := strings.Contains(strings.ToUpper(query), "WHERE")
+	return writeQueryPlan(os.Stdout, plan, filtered)
+}
 
+// pickRows returns the first head rows, the last tail rows, or sample rows
+// picked at random (seeded unless seed is 0) in their original order. With
+// none of them set, all rows are returned.
+func pickRows(rows []map[string]interface{}, head, tail, sample int, seed int64) []map[string]interface{} {
+	switch {
+	case head > 0 && head < len(rows):
+		return rows[:head]
+	case tail > 0 && tail < len(rows):
+		return rows[len(rows)-tail:]
+	case sample > 0 && sample < len(rows):
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		picked := rand.New(rand.NewSource(seed)).Perm(len(rows))[:sample]
+		sort.Ints(picked)
+		result := make([]map[string]interface{}, len(picked))
+		for i, idx := range picked {
+			result[i] = rows[idx]
+		}
+		return result
+	}
+	return rows
+}
+
+// openQueryStore checks that query is read-only and opens the store of the
+// current stash to run it against. If it returns ok == false, the error has
+// been reported (or is returned in err); otherwise the caller closes the
+// store.
+func openQueryStore(query string) (*storage.Store, *model.Stash, bool, error) {
 	// AC-02: Reject non-SELECT queries
 	if !isSelectQuery(query) {
 		fmt.Fprintln(os.Stderr, "Error: only SELECT queries are allowed")
 		Exit(2)
-		return nil
+		return nil, nil, false, nil
 	}
 
 	// Resolve context
@@ -133,60 +274,42 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		if errors.Is(err, context.ErrNoStashDir) {
 			fmt.Fprintln(os.Stderr, "Error: no .stash directory found")
 			Exit(1)
-			return nil
+			return nil, nil, false, nil
 		}
 		if errors.Is(err, context.ErrNoStash) {
 			fmt.Fprintln(os.Stderr, "Error: no stash specified and multiple stashes exist (use --stash)")
 			Exit(1)
-			return nil
+			return nil, nil, false, nil
 		}
-		return fmt.Errorf("failed to resolve context: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to resolve context: %w", err)
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to initialize storage: %w", err)
 	}
-	defer store.Close()
 
 	// Verify stash exists
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
+		store.Close()
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
 			Exit(1)
-			return nil
-		}
-		return fmt.Errorf("failed to get stash: %w", err)
-	}
-
-	// Execute query
-	rows, columns, err := store.RawQuery(query)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: query failed: %v\n", err)
-		Exit(3)
-		return nil
-	}
-
-	// AC-03: JSON output
-	if GetJSONOutput() {
-		data, err := json.MarshalIndent(rows, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+			return nil, nil, false, nil
 		}
-		fmt.Println(string(data))
-		return nil
+		return nil, nil, false, fmt.Errorf("failed to get stash: %w", err)
 	}
 
-	// CSV output
-	if queryCSV {
-		return outputQueryCSV(rows, columns)
-	}
+	return store, stash, true, nil
+}
 
+// printQueryTable prints query results as aligned columns.
+func printQueryTable(rows []map[string]interface{}, columns []string) error {
 	// AC-01, AC-04: Human-readable output
 	if len(rows) == 0 {
-		fmt.Println("No results.")
+		Infof("No results.\n")
 		return nil
 	}
 
@@ -234,13 +357,13 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		fmt.Println(strings.Join(rowParts, "  "))
 	}
 
-	fmt.Printf("\n%d row(s)\n", len(rows))
+	Infof("\n%d row(s)\n", len(rows))
 
 	return nil
 }
 
-// outputQueryCSV outputs query results in CSV format.
-func outputQueryCSV(rows []map[string]interface{}, columns []string) error {
+// outputQueryCSV writes query results to w in CSV format.
+func outputQueryCSV(w io.Writer, rows []map[string]interface{}, columns []string) error {
 	// Determine which columns to output
 	outputColumns := columns
 	if queryColumns != "" {
@@ -254,28 +377,49 @@ func outputQueryCSV(rows []map[string]interface{}, columns []string) error {
 		}
 	}
 
-	writer := csv.NewWriter(os.Stdout)
+	return writeDelimited(w, outputColumns, ',', !queryNoHeaders, func(write func(map[string]interface{}) error) error {
+		for _, row := range rows {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeDelimited writes rows as CSV, or TSV when comma is '\t': an optional
+// header line with the column names, then one line per row holding its
+// values for those columns. rows calls write once per row, so records can
+// be streamed without loading them all.
+func writeDelimited(w io.Writer, columns []string, comma rune, headers bool, rows func(write func(map[string]interface{}) error) error) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
 	defer writer.Flush()
 
 	// Write header unless --no-headers is specified
-	if !queryNoHeaders {
-		if err := writer.Write(outputColumns); err != nil {
+	if headers {
+		if err := writer.Write(columns); err != nil {
 			return fmt.Errorf("failed to write CSV header: %w", err)
 		}
 	}
 
 	// Write data rows
-	for _, row := range rows {
-		rowData := make([]string, len(outputColumns))
-		for i, col := range outputColumns {
+	err := rows(func(row map[string]interface{}) error {
+		rowData := make([]string, len(columns))
+		for i, col := range columns {
 			if val, ok := row[col]; ok {
-				rowData[i] = fmt.Sprintf("%v", val)
+				rowData[i] = valueText(val)
 			}
 		}
 		if err := writer.Write(rowData); err != nil {
 			return fmt.Errorf("failed to write CSV row: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,7 @@ func resetQueryFlags() {
 	queryCSV = false
 	queryNoHeaders = false
 	queryColumns = ""
+	queryExplain = false
 }
 
 // TestUC_QRY_003_RawSQLQuery tests UC-QRY-003: Raw SQL Query
@@ -635,3 +637,75 @@ func TestQueryCSVOutput(t *testing.T) {
 		}
 	})
 }
+
+func TestQueryExplain(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+	defer cleanup()
+	captureSchemaOutput(t, "add", "Laptop", "--set", "Price=999")
+	ExitCode = 0
+
+	t.Run("shows the plan without running the query", func(t *testing.T) {
+		output := captureSchemaOutput(t, "query", "SELECT Name FROM inventory WHERE Price > 100 ORDER BY Name", "--explain")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		for _, want := range []string{"SELECT Name FROM inventory WHERE Price > 100", "SCAN inventory", "USE TEMP B-TREE FOR ORDER BY", "reads every row", "sorted after they are read"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "Laptop") {
+			t.Errorf("expected the query not to run, got:\n%s", output)
+		}
+	})
+
+	t.Run("searching by index", func(t *testing.T) {
+		output := captureSchemaOutput(t, "query", "SELECT id FROM inventory WHERE parent_id = 'inv-1'", "--explain")
+		if !strings.Contains(output, "SEARCH inventory USING INDEX idx_inventory_parent") {
+			t.Errorf("expected an index search, got:\n%s", output)
+		}
+		if strings.Contains(output, "Hint:") {
+			t.Errorf("expected no hints, got:\n%s", output)
+		}
+	})
+
+	t.Run("rejects non-SELECT statements", func(t *testing.T) {
+		captureSchemaOutput(t, "query", "DELETE FROM inventory", "--explain")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestQueryRowPicks(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+	for i := 1; i <= 6; i++ {
+		captureSchemaOutput(t, "add", fmt.Sprintf("Item %d", i))
+	}
+	names := func(args ...string) string {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"query", "SELECT Name FROM inventory ORDER BY Name", "--json"}, args...)...)
+		var rows []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &rows); err != nil {
+			t.Fatalf("expected JSON, got %q", output)
+		}
+		var names []string
+		for _, r := range rows {
+			names = append(names, r["Name"].(string))
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := names("--head", "2"); got != "Item 1,Item 2" {
+		t.Errorf("unexpected head %q", got)
+	}
+	if got := names("--tail", "1"); got != "Item 6" {
+		t.Errorf("unexpected tail %q", got)
+	}
+	sample := names("--sample", "3", "--seed", "5")
+	if strings.Count(sample, ",") != 2 || names("--sample", "3", "--seed", "5") != sample {
+		t.Errorf("expected a repeatable sample of 3, got %q", sample)
+	}
+}
@@ -0,0 +1,212 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	quotasMaxRecords      string
+	quotasWritesPerMinute string
+	quotasAgent           string
+	quotasReset           bool
+)
+
+var quotasCmd = &cobra.Command{
+	Use:   "quotas",
+	Short: "Show or set the record quota and write rate limits",
+	Long: `Show or set the quotas of the current stash, so one runaway agent
+cannot flood a shared stash.
+
+  --max-records        Most active (not deleted) records the stash may
+                       hold. Adding or restoring a record beyond it fails.
+  --writes-per-minute  Writes each agent may make a minute: adds, edits,
+                       deletes, restores, archives, and assignments. An
+                       agent may use a minute's writes at once, then
+                       writes as they refill.
+  --agent NAME         With --writes-per-minute, sets the rate of one
+                       agent in place of the stash-wide rate; 'none' lets
+                       it write without limit and 'default' goes back to
+                       the stash-wide rate.
+
+Writes over a quota fail with exit code 7 and a THROTTLED or
+QUOTA_EXCEEDED error; THROTTLED errors say how long until the agent may
+write again (retry_after_seconds with --json). Write rates are shared by
+every process using the .stash directory, through the SQLite cache.
+
+Numbers may be 'none' to remove a quota. The quotas are stored in the
+stash's config.json.
+
+Examples:
+  stash quotas                                   # Show the quotas
+  stash quotas --max-records 10000               # Cap the stash's size
+  stash quotas --writes-per-minute 60            # Limit every agent
+  stash quotas --agent importer --writes-per-minute 600
+  stash quotas --agent admin --writes-per-minute none
+  stash quotas --reset                           # Remove every quota
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (invalid number, --agent without
+     --writes-per-minute, --reset with other flags)
+
+JSON Output (--json):
+  {"stash": "inventory", "records": 120, "max_records": 10000,
+   "writes_per_minute": 60, "agents": {"importer": 600}}
+  A quota that is off is reported as 0.`,
+	Args: cobra.NoArgs,
+	RunE: runQuotas,
+}
+
+func init() {
+	quotasCmd.Flags().StringVar(&quotasMaxRecords, "max-records", "", "Most active records the stash may hold, or 'none'")
+	quotasCmd.Flags().StringVar(&quotasWritesPerMinute, "writes-per-minute", "", "Writes each agent may make a minute, or 'none'")
+	quotasCmd.Flags().StringVar(&quotasAgent, "agent", "", "Set the write rate of this agent only")
+	quotasCmd.Flags().BoolVar(&quotasReset, "reset", false, "Remove every quota")
+	rootCmd.AddCommand(quotasCmd)
+}
+
+// parseQuota parses a --max-records or --writes-per-minute value: a
+// positive number, or "none" for no quota (0).
+func parseQuota(flag, value string) (int, bool) {
+	if strings.EqualFold(strings.TrimSpace(value), "none") {
+		return 0, true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		ExitValidationError(fmt.Sprintf("--%s must be a positive number or 'none'", flag), map[string]interface{}{flag: value})
+		return 0, false
+	}
+	return n, true
+}
+
+func runQuotas(cmd *cobra.Command, args []string) error {
+	update := quotasMaxRecords != "" || quotasWritesPerMinute != ""
+	if quotasReset && (update || quotasAgent != "") {
+		ExitValidationError("--reset cannot be combined with other flags", nil)
+		return nil
+	}
+	if quotasAgent != "" && quotasWritesPerMinute == "" {
+		ExitValidationError("--agent needs --writes-per-minute", map[string]interface{}{"agent": quotasAgent})
+		return nil
+	}
+
+	var maxRecords, writesPerMinute int
+	agentDefault := quotasAgent != "" && strings.EqualFold(quotasWritesPerMinute, "default")
+	ok := true
+	if quotasMaxRecords != "" {
+		maxRecords, ok = parseQuota("max-records", quotasMaxRecords)
+	}
+	if ok && quotasWritesPerMinute != "" && !agentDefault {
+		writesPerMinute, ok = parseQuota("writes-per-minute", quotasWritesPerMinute)
+	}
+	if !ok {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if update || quotasReset {
+		quotas := &model.Quotas{}
+		if stash.Quotas != nil {
+			*quotas = *stash.Quotas
+			quotas.Agents = make(map[string]int, len(stash.Quotas.Agents))
+			for agent, rate := range stash.Quotas.Agents {
+				quotas.Agents[agent] = rate
+			}
+		}
+		if quotasMaxRecords != "" {
+			quotas.MaxRecords = maxRecords
+		}
+		switch {
+		case agentDefault:
+			delete(quotas.Agents, quotasAgent)
+		case quotasAgent != "":
+			if quotas.Agents == nil {
+				quotas.Agents = make(map[string]int)
+			}
+			quotas.Agents[quotasAgent] = writesPerMinute
+		case quotasWritesPerMinute != "":
+			quotas.WritesPerMinute = writesPerMinute
+		}
+		if len(quotas.Agents) == 0 {
+			quotas.Agents = nil
+		}
+		if quotasReset || quotas.IsEmpty() {
+			quotas = nil
+		}
+		stash.Quotas = quotas
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update quotas: %w", err)
+		}
+	}
+
+	stats, err := store.StashStats(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to count records: %w", err)
+	}
+	quotas := stash.Quotas
+	if quotas == nil {
+		quotas = &model.Quotas{}
+	}
+
+	if GetJSONOutput() {
+		agents := quotas.Agents
+		if agents == nil {
+			agents = map[string]int{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"stash":             stash.Name,
+			"records":           stats.Records,
+			"max_records":       quotas.MaxRecords,
+			"writes_per_minute": quotas.WritesPerMinute,
+			"agents":            agents,
+		})
+		fmt.Println(string(data))
+		return nil
+	}
+	if IsQuiet() {
+		return nil
+	}
+
+	if update || quotasReset {
+		fmt.Printf("Set quotas of stash '%s'\n", stash.Name)
+	} else {
+		fmt.Printf("Quotas of stash '%s':\n", stash.Name)
+	}
+	if quotas.MaxRecords > 0 {
+		fmt.Printf("  records: %d of %d\n", stats.Records, quotas.MaxRecords)
+	} else {
+		fmt.Printf("  records: %d (no quota)\n", stats.Records)
+	}
+	fmt.Printf("  writes:  %s per agent\n", describeWriteRate(quotas.WritesPerMinute))
+	agents := make([]string, 0, len(quotas.Agents))
+	for agent := range quotas.Agents {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+	for _, agent := range agents {
+		fmt.Printf("    %s: %s\n", agent, describeWriteRate(quotas.Agents[agent]))
+	}
+	return nil
+}
+
+// describeWriteRate formats a write rate for display.
+func describeWriteRate(perMinute int) string {
+	if perMinute <= 0 {
+		return "no limit"
+	}
+	return fmt.Sprintf("%d a minute", perMinute)
+}
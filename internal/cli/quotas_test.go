@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestQuotas(t *testing.T) {
+	t.Run("record quota", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "quotas", "--max-records", "2")
+		captureSchemaOutput(t, "add", "Laptop")
+		captureSchemaOutput(t, "add", "Mouse")
+		if ExitCode != 0 {
+			t.Fatalf("expected writes under the quota to pass, got exit code %d", ExitCode)
+		}
+
+		out := captureSchemaOutput(t, "add", "Desk", "--json")
+		if ExitCode != 7 {
+			t.Fatalf("expected exit code 7, got %d: %s", ExitCode, out)
+		}
+		var result JSONError
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected a JSON error, got %q", out)
+		}
+		if result.Code != ErrCodeQuotaExceeded || result.Details["max_records"] != float64(2) {
+			t.Errorf("unexpected error %+v", result)
+		}
+		ExitCode = 0
+
+		out = captureSchemaOutput(t, "quotas")
+		if !strings.Contains(out, "records: 2 of 2") {
+			t.Errorf("expected the record count against the quota, got %q", out)
+		}
+
+		captureSchemaOutput(t, "quotas", "--max-records", "none")
+		captureSchemaOutput(t, "add", "Desk")
+		if ExitCode != 0 {
+			t.Errorf("expected the write to pass with no quota, got exit code %d", ExitCode)
+		}
+	})
+
+	t.Run("write rate per agent", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "quotas", "--writes-per-minute", "2")
+		captureSchemaOutput(t, "quotas", "--agent", "importer", "--writes-per-minute", "none")
+		for i := 0; i < 2; i++ {
+			captureSchemaOutput(t, "add", "Item", "--actor", "bot")
+		}
+		if ExitCode != 0 {
+			t.Fatalf("expected writes under the rate to pass, got exit code %d", ExitCode)
+		}
+
+		out := captureSchemaOutput(t, "add", "Item", "--actor", "bot", "--json")
+		if ExitCode != 7 {
+			t.Fatalf("expected exit code 7, got %d: %s", ExitCode, out)
+		}
+		var result JSONError
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("expected a JSON error, got %q", out)
+		}
+		if result.Code != ErrCodeThrottled || result.Details["agent"] != "bot" {
+			t.Errorf("unexpected error %+v", result)
+		}
+		if retry, _ := result.Details["retry_after_seconds"].(float64); retry <= 0 || retry > 30 {
+			t.Errorf("expected a retry time of up to 30s, got %v", result.Details["retry_after_seconds"])
+		}
+		ExitCode = 0
+
+		// Other agents have their own buckets, and exempt agents none
+		captureSchemaOutput(t, "add", "Item", "--actor", "alice")
+		if ExitCode != 0 {
+			t.Errorf("expected another agent to write, got exit code %d", ExitCode)
+		}
+		for i := 0; i < 5; i++ {
+			captureSchemaOutput(t, "add", "Item", "--actor", "importer")
+		}
+		if ExitCode != 0 {
+			t.Errorf("expected the exempt agent to write, got exit code %d", ExitCode)
+		}
+
+		out = captureSchemaOutput(t, "quotas", "--json")
+		var quotas map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &quotas); err != nil {
+			t.Fatalf("expected JSON, got %q", out)
+		}
+		if quotas["writes_per_minute"] != float64(2) || quotas["agents"].(map[string]interface{})["importer"] != float64(0) {
+			t.Errorf("unexpected quotas %v", quotas)
+		}
+
+		captureSchemaOutput(t, "quotas", "--reset")
+		out = captureSchemaOutput(t, "quotas")
+		if !strings.Contains(out, "writes:  no limit per agent") {
+			t.Errorf("expected no quotas after --reset, got %q", out)
+		}
+	})
+
+	t.Run("validates flags", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		for _, args := range [][]string{
+			{"quotas", "--max-records", "0"},
+			{"quotas", "--writes-per-minute", "fast"},
+			{"quotas", "--agent", "bot"},
+			{"quotas", "--reset", "--max-records", "5"},
+		} {
+			captureSchemaOutput(t, args...)
+			if ExitCode != 2 {
+				t.Errorf("%v: expected exit code 2, got %d", args, ExitCode)
+			}
+			ExitCode = 0
+		}
+	})
+}
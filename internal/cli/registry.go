@@ -0,0 +1,369 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+)
+
+var registryName string
+
+// registryNameRegex matches registry root names, which follow the same
+// rules as stash names so root:stash is unambiguous.
+var registryNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Keep track of stash directories across the filesystem",
+	Long: `Keep a user-level index of .stash directories, so stashes in other
+projects can be found and used from anywhere.
+
+Each registered directory has a root name. A stash in it can then be
+addressed as root:stash with --stash, from any directory:
+
+  stash registry add ~/work --name work
+  stash list --stash work:inventory
+
+The registry is kept in the user config directory (~/.config/stash, or
+$STASH_CONFIG_DIR), so it applies to every project on this machine but is
+not shared through git. $STASH_DEFAULT may also name a root:stash.
+
+Examples:
+  stash registry add
+  stash registry add ~/notes --name notes
+  stash registry list
+  stash registry doctor
+  stash registry remove notes`,
+}
+
+var registryAddCmd = &cobra.Command{
+	Use:   "add [path]",
+	Short: "Register a stash directory",
+	Long: `Register a .stash directory under a root name.
+
+The path is a project directory or its .stash directory. Without a path,
+the .stash directory commands use here is registered (see 'stash root').
+The root name defaults to the name of the project directory.
+
+Examples:
+  stash registry add
+  stash registry add ~/work --name work
+  stash registry add ~/notes/.stash --json
+
+Exit Codes:
+  0  Success
+  1  The name or directory is already registered
+  2  Validation error (invalid name, or no .stash directory at the path)
+
+JSON Output (--json):
+  {"name": "work", "path": "/home/me/work/.stash", "added": "2025-01-08T10:30:00Z"}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRegistryAdd,
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered stash directories",
+	Long: `List registered stash directories and the stashes in each.
+
+Directories that no longer exist are shown as missing; remove them with
+'stash registry remove' or check them with 'stash registry doctor'.
+
+Examples:
+  stash registry list
+  stash registry list --json
+
+Exit Codes:
+  0  Success
+
+JSON Output (--json):
+  [{"name": "work", "path": "/home/me/work/.stash", "added": "2025-01-08T10:30:00Z",
+    "exists": true, "stashes": ["inventory", "tasks"]}]`,
+	Args: cobra.NoArgs,
+	RunE: runRegistryList,
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a stash directory",
+	Long: `Remove a root from the registry. The stash directory itself is not
+touched.
+
+Examples:
+  stash registry remove notes
+
+Exit Codes:
+  0  Success
+  1  No root with that name
+
+JSON Output (--json):
+  {"name": "notes", "path": "/home/me/notes/.stash"}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRegistryRemove,
+}
+
+var registryDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the health of every registered stash directory",
+	Long: `Run the 'stash doctor' health checks on every registered stash
+directory, and report directories that no longer exist.
+
+Examples:
+  stash registry doctor
+  stash registry doctor --deep
+  stash registry doctor --json
+
+Exit Codes:
+  0  Success (issues are reported in the output)
+
+JSON Output (--json):
+  {"healthy": false,
+   "roots": [{"name": "work", "path": "/home/me/work/.stash", "healthy": true,
+              "checks": [...], "summary": {"total": 12, "ok": 11, "warnings": 1, "errors": 0}},
+             {"name": "old", "path": "/home/me/old/.stash", "healthy": false,
+              "checks": [{"check": "root_exists", "status": "error", ...}], ...}]}`,
+	Args: cobra.NoArgs,
+	RunE: runRegistryDoctor,
+}
+
+func init() {
+	registryAddCmd.Flags().StringVar(&registryName, "name", "", "Root name (default: the project directory name)")
+	registryDoctorCmd.Flags().BoolVar(&doctorDeep, "deep", false, "Enable deep checks (hash verification)")
+	registryCmd.AddCommand(registryAddCmd)
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryRemoveCmd)
+	registryCmd.AddCommand(registryDoctorCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+func runRegistryAdd(cmd *cobra.Command, args []string) error {
+	dir := context.FindStashDir()
+	if len(args) > 0 {
+		dir = context.StashDirIn(args[0])
+	}
+	if dir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		ExitValidationError(fmt.Sprintf("no .stash directory at %s", path),
+			map[string]interface{}{"path": path})
+		return nil
+	}
+
+	name := registryName
+	if name == "" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	if !registryNameRegex.MatchString(name) {
+		ExitValidationError(fmt.Sprintf("invalid root name '%s': must start with a letter and contain only letters, digits, '-' and '_' (use --name)", name),
+			map[string]interface{}{"name": name})
+		return nil
+	}
+
+	registry, err := context.LoadRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	if existing := registry.Lookup(name); existing != nil {
+		ExitWithError(1, ErrCodeConflict, fmt.Sprintf("root '%s' is already registered for %s", name, existing.Path),
+			map[string]interface{}{"name": name, "path": existing.Path})
+		return nil
+	}
+	if existing := registry.LookupPath(path); existing != nil {
+		ExitWithError(1, ErrCodeConflict, fmt.Sprintf("%s is already registered as '%s'", path, existing.Name),
+			map[string]interface{}{"name": existing.Name, "path": path})
+		return nil
+	}
+
+	entry := context.RegistryEntry{Name: name, Path: path, Added: time.Now().UTC()}
+	registry.Roots = append(registry.Roots, entry)
+	if err := context.SaveRegistry(registry); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Registered '%s' at %s\n", name, path)
+	}
+	return nil
+}
+
+// registryListEntry is a registered root with what is found at its path.
+type registryListEntry struct {
+	context.RegistryEntry
+	Exists  bool     `json:"exists"`
+	Stashes []string `json:"stashes"`
+}
+
+func runRegistryList(cmd *cobra.Command, args []string) error {
+	registry, err := context.LoadRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+
+	entries := make([]registryListEntry, 0, len(registry.Roots))
+	for _, root := range registry.Roots {
+		entry := registryListEntry{RegistryEntry: root, Stashes: []string{}}
+		if info, err := os.Stat(root.Path); err == nil && info.IsDir() {
+			entry.Exists = true
+			if stashes := context.ListStashes(root.Path); stashes != nil {
+				entry.Stashes = stashes
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		Infof("No registered stash directories (add one with 'stash registry add')\n")
+		return nil
+	}
+
+	table := newTable(
+		tableColumn{Header: "Name"},
+		tableColumn{Header: "Path"},
+		tableColumn{Header: "Stashes", Max: 40},
+	)
+	for _, entry := range entries {
+		stashes := "(missing)"
+		if entry.Exists {
+			stashes = strings.Join(entry.Stashes, ", ")
+			if stashes == "" {
+				stashes = "-"
+			}
+		}
+		table.addRow(entry.Name, entry.Path, stashes)
+	}
+	table.print()
+	return nil
+}
+
+func runRegistryRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	registry, err := context.LoadRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	entry := registry.Lookup(name)
+	if entry == nil {
+		ExitWithError(1, ErrCodeRootNotFound, fmt.Sprintf("root '%s' is not registered", name),
+			map[string]interface{}{"name": name})
+		return nil
+	}
+	path := entry.Path
+	registry.Remove(name)
+	if err := context.SaveRegistry(registry); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(map[string]string{"name": name, "path": path})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed '%s' (%s)\n", name, path)
+	}
+	return nil
+}
+
+// registryDoctorRoot is the doctor report for one registered root.
+type registryDoctorRoot struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	DoctorOutput
+}
+
+func runRegistryDoctor(cmd *cobra.Command, args []string) error {
+	registry, err := context.LoadRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+
+	healthy := true
+	roots := make([]registryDoctorRoot, 0, len(registry.Roots))
+	for _, root := range registry.Roots {
+		var results []CheckResult
+		if info, err := os.Stat(root.Path); err != nil || !info.IsDir() {
+			results = []CheckResult{{
+				Check:   "root_exists",
+				Status:  "error",
+				Message: "Stash directory not found",
+				Details: fmt.Sprintf("remove it with 'stash registry remove %s'", root.Name),
+			}}
+		} else {
+			results = runHealthChecks(cmd, &context.Context{StashDir: root.Path})
+		}
+		report := registryDoctorRoot{Name: root.Name, Path: root.Path, DoctorOutput: summarizeChecks(results)}
+		healthy = healthy && report.Healthy
+		roots = append(roots, report)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"healthy": healthy,
+			"roots":   roots,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(roots) == 0 {
+		Infof("No registered stash directories (add one with 'stash registry add')\n")
+		return nil
+	}
+
+	// Text output: a summary per root, with the checks that need attention
+	for _, root := range roots {
+		status := "Healthy"
+		if !root.Healthy {
+			status = "Issues found"
+		}
+		fmt.Printf("%s (%s): %s\n", root.Name, root.Path, status)
+		fmt.Printf("  %d checks, %d ok, %d warnings, %d errors\n",
+			root.Summary.Total, root.Summary.OK, root.Summary.Warnings, root.Summary.Errors)
+		for _, r := range root.Checks {
+			if r.Status == "ok" && !IsVerbose() {
+				continue
+			}
+			fmt.Printf("  %-8s %s: %s\n", checkStatusIcon(r.Status), r.Check, r.Message)
+			if r.Details != "" {
+				fmt.Printf("           %s\n", r.Details)
+			}
+		}
+	}
+	return nil
+}
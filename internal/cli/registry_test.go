@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/context"
+)
+
+func TestRegistry(t *testing.T) {
+	// setupNotes creates a second project with a notes stash and returns
+	// its directory
+	setupNotes := func(t *testing.T, tempDir string) string {
+		t.Helper()
+		dir := filepath.Join(tempDir, "projects", "notes")
+		captureSchemaOutput(t, "init", "notes", "--prefix", "nt-", "--dir", dir)
+		captureSchemaOutput(t, "column", "add", "Name", "--dir", dir)
+		if ExitCode != 0 {
+			t.Fatalf("failed to set up notes project, exit %d", ExitCode)
+		}
+		return dir
+	}
+
+	listRoots := func(t *testing.T) []map[string]interface{} {
+		t.Helper()
+		var roots []map[string]interface{}
+		output := captureSchemaOutput(t, "registry", "list", "--json")
+		if err := json.Unmarshal([]byte(output), &roots); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return roots
+	}
+
+	t.Run("add, list, and remove", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		notesDir := setupNotes(t, tempDir)
+
+		var entry map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "registry", "add", "--name", "work", "--json")), &entry)
+		if entry["name"] != "work" || entry["path"] != filepath.Join(tempDir, ".stash") {
+			t.Errorf("unexpected entry: %v", entry)
+		}
+		// The name defaults to the project directory
+		captureSchemaOutput(t, "registry", "add", notesDir)
+
+		roots := listRoots(t)
+		if len(roots) != 2 || roots[1]["name"] != "notes" || roots[1]["exists"] != true {
+			t.Fatalf("unexpected roots: %v", roots)
+		}
+		if stashes := roots[0]["stashes"].([]interface{}); len(stashes) != 1 || stashes[0] != "inventory" {
+			t.Errorf("expected work to list inventory, got %v", stashes)
+		}
+
+		os.RemoveAll(notesDir)
+		if roots := listRoots(t); roots[1]["exists"] != false {
+			t.Errorf("expected notes to be missing, got %v", roots[1])
+		}
+
+		captureSchemaOutput(t, "registry", "remove", "notes")
+		if roots := listRoots(t); len(roots) != 1 || roots[0]["name"] != "work" {
+			t.Errorf("expected only work after remove, got %v", roots)
+		}
+	})
+
+	t.Run("stashes are addressed as root:stash from anywhere", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		notesDir := setupNotes(t, tempDir)
+		captureSchemaOutput(t, "registry", "add", notesDir, "--name", "personal")
+
+		captureSchemaOutput(t, "add", "Buy milk", "--stash", "personal:notes")
+		if ExitCode != 0 {
+			t.Fatalf("expected add to succeed, got exit %d", ExitCode)
+		}
+
+		// An empty stash name picks the root's only stash
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--stash", "personal:", "--json")), &records)
+		if len(records) != 1 || !strings.HasPrefix(records[0]["_id"].(string), "nt-") {
+			t.Errorf("expected the notes record, got %v", records)
+		}
+
+		// The local stash is untouched
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--json")), &records)
+		if len(records) != 0 {
+			t.Errorf("expected no inventory records, got %v", records)
+		}
+
+		t.Setenv("STASH_DEFAULT", "personal:notes")
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--json")), &records)
+		if len(records) != 1 {
+			t.Errorf("expected STASH_DEFAULT to select the notes stash, got %v", records)
+		}
+	})
+
+	t.Run("unknown root", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"list", "--stash", "nowhere:inventory"})
+		err := rootCmd.Execute()
+		resetFlags()
+		if !errors.Is(err, context.ErrUnknownRoot) {
+			t.Errorf("expected ErrUnknownRoot, got %v", err)
+		}
+	})
+
+	t.Run("doctor checks every root", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		notesDir := setupNotes(t, tempDir)
+		captureSchemaOutput(t, "registry", "add", "--name", "work")
+		captureSchemaOutput(t, "registry", "add", notesDir)
+
+		var report struct {
+			Healthy bool `json:"healthy"`
+			Roots   []struct {
+				Name    string        `json:"name"`
+				Healthy bool          `json:"healthy"`
+				Checks  []CheckResult `json:"checks"`
+			} `json:"roots"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "registry", "doctor", "--json")), &report)
+		if !report.Healthy || len(report.Roots) != 2 || len(report.Roots[1].Checks) < 2 {
+			t.Errorf("expected two healthy roots, got %+v", report)
+		}
+
+		os.RemoveAll(notesDir)
+		json.Unmarshal([]byte(captureSchemaOutput(t, "registry", "doctor", "--json")), &report)
+		if report.Healthy || !report.Roots[0].Healthy || report.Roots[1].Healthy {
+			t.Errorf("expected only notes to be unhealthy, got %+v", report)
+		}
+		if checks := report.Roots[1].Checks; len(checks) != 1 || checks[0].Check != "root_exists" {
+			t.Errorf("expected a root_exists error, got %+v", checks)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		captureSchemaOutput(t, "registry", "add", "--name", "work")
+
+		for _, tc := range []struct {
+			args []string
+			code int
+		}{
+			{[]string{"registry", "add", "--name", "work", filepath.Join(tempDir, "missing")}, 2},
+			{[]string{"registry", "add", "--name", "work"}, 1},
+			{[]string{"registry", "add", "--name", "other"}, 1},
+			{[]string{"registry", "add", "--name", "9lives"}, 2},
+			{[]string{"registry", "remove", "nope"}, 1},
+		} {
+			ExitCode = 0
+			captureSchemaOutput(t, tc.args...)
+			if ExitCode != tc.code {
+				t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.code, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,111 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// lineSkip is a line of a JSONL file that was not imported, and why.
+type lineSkip struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Op     string `json:"op,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// lineReject is a line written to a .rejects file: the skipped line and
+// why it was skipped. Record holds the original line when it is JSON,
+// Text when it is not.
+type lineReject struct {
+	lineSkip
+	Record json.RawMessage `json:"record,omitempty"`
+	Text   string          `json:"text,omitempty"`
+}
+
+// checkJSONLMode reports --strict combined with --lenient. Returns true if
+// at most one is set.
+func checkJSONLMode(strict, lenient bool) bool {
+	if strict && lenient {
+		ExitValidationError("--strict and --lenient cannot be combined", nil)
+		return false
+	}
+	return true
+}
+
+// scanJSONL calls fn with each non-blank line of a JSONL file and its
+// 1-based line number. Errors returned by fn are returned as they are.
+func scanJSONL(r io.Reader, fn func(lineNum int, line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		if err := fn(lineNum, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	return nil
+}
+
+// rejectsPath returns where --lenient writes the lines it skipped from the
+// file at path.
+func rejectsPath(path string) string {
+	return path + ".rejects"
+}
+
+// writeRejects writes the skipped lines of a JSONL file to path, one JSON
+// object per line. lines holds the original text of each line by number.
+func writeRejects(path string, skips []lineSkip, lines map[int][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create rejects file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, skip := range skips {
+		reject := lineReject{lineSkip: skip}
+		if line := lines[skip.Line]; json.Valid(line) {
+			reject.Record = line
+		} else {
+			reject.Text = string(line)
+		}
+		if err := enc.Encode(reject); err != nil {
+			return fmt.Errorf("failed to write rejects file: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// exitStrictRejects reports the invalid lines that made --strict refuse a
+// file, and exits 2.
+func exitStrictRejects(path string, skips []lineSkip) {
+	if !GetJSONOutput() {
+		printLineSkips(os.Stderr, skips)
+	}
+	ExitWithError(2, ErrCodeValidation,
+		fmt.Sprintf("%d invalid line(s) in '%s'; nothing was imported (use --lenient to skip them)", len(skips), path),
+		map[string]interface{}{"path": path, "rejected": skips})
+}
+
+// printLineSkips prints one line per skipped line of a JSONL file.
+func printLineSkips(w io.Writer, skips []lineSkip) {
+	for _, s := range skips {
+		if s.ID != "" {
+			fmt.Fprintf(w, "    line %d (%s %s): %s\n", s.Line, s.Op, s.ID, s.Reason)
+		} else {
+			fmt.Fprintf(w, "    line %d: %s\n", s.Line, s.Reason)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	remindExec   string
+	remindWithin string
+	remindColumn string
+	remindDryRun bool
+)
+
+var remindCmd = &cobra.Command{
+	Use:   "remind --exec <command>",
+	Short: "Run a command for each overdue record",
+	Long: `Run a shell command once for every overdue record.
+
+Intended to be run periodically by cron or the daemon so that overdue
+items trigger actions (notifications, escalation, re-queueing).
+
+The command runs via 'sh -c' with the record JSON on stdin and these
+environment variables set:
+  STASH_ID       Record ID
+  STASH_STASH    Stash name
+  STASH_COLUMN   Due date column
+  STASH_DUE      Due time (RFC 3339)
+  STASH_STATUS   Due status (overdue or upcoming)
+
+Use --within to also fire for records due soon.
+
+Examples:
+  stash remind --exec 'echo "$STASH_ID is overdue"'
+  stash remind --exec './notify.sh' --within 1d
+  stash remind --exec 'jq .Name' --dry-run
+
+  # crontab: check every hour
+  0 * * * * cd /path/to/project && stash remind --exec './escalate.sh'
+
+Exit Codes:
+  0  Success (all commands succeeded, or nothing due)
+  1  Stash or column not found, or one or more commands failed
+  2  Validation error (missing --exec, invalid duration, no due date column)
+
+JSON Output (--json):
+  {"fired": 2, "failed": 0, "ids": ["tk-a1b2", "tk-c3d4"]}`,
+	Args: cobra.NoArgs,
+	RunE: runRemind,
+}
+
+func init() {
+	remindCmd.Flags().StringVar(&remindExec, "exec", "", "Shell command to run for each due record")
+	remindCmd.Flags().StringVar(&remindWithin, "within", "", "Also fire for records due within duration (e.g., 1d)")
+	remindCmd.Flags().StringVar(&remindColumn, "column", "", "Date column to use (default: all due columns)")
+	remindCmd.Flags().BoolVar(&remindDryRun, "dry-run", false, "Show which records would fire without running the command")
+	rootCmd.AddCommand(remindCmd)
+}
+
+func runRemind(cmd *cobra.Command, args []string) error {
+	if remindExec == "" {
+		ExitValidationError("--exec is required", nil)
+		return nil
+	}
+
+	var within time.Duration
+	if remindWithin != "" {
+		d, err := parseDuration(remindWithin)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("invalid duration '%s'", remindWithin),
+				map[string]interface{}{"within": remindWithin})
+			return nil
+		}
+		within = d
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	columns, ok := resolveDueColumns(stash, remindColumn)
+	if !ok {
+		return nil
+	}
+
+	records, err := store.ListRecords(ctx.Stash, storage.ListOptions{ParentID: "*"})
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	now := time.Now()
+	items := filterDueItems(collectDueItems(records, columns, now), true, within, now)
+
+	fired := []string{}
+	failed := 0
+	for _, item := range items {
+		if remindDryRun {
+			if !GetJSONOutput() {
+				fmt.Printf("Would fire for %s (%s, due %s)\n", item.ID, item.Status, item.Due.Format(time.RFC3339))
+			}
+			fired = append(fired, item.ID)
+			continue
+		}
+
+		if err := runRemindHook(remindExec, ctx.Stash, item); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: command failed for %s: %v\n", item.ID, err)
+			failed++
+			continue
+		}
+		fired = append(fired, item.ID)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		result := map[string]interface{}{
+			"fired":  len(fired),
+			"failed": failed,
+			"ids":    fired,
+		}
+		if remindDryRun {
+			result["dry_run"] = true
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if !IsQuiet() && !remindDryRun {
+		fmt.Printf("Fired %d reminder(s)", len(fired))
+		if failed > 0 {
+			fmt.Printf(", %d failed", failed)
+		}
+		fmt.Println()
+	}
+
+	if failed > 0 {
+		Exit(1)
+	}
+	return nil
+}
+
+// runRemindHook runs the reminder command for a single due item.
+// Command output is passed through to the caller, using stderr for both
+// streams in JSON mode so stdout stays parseable.
+func runRemindHook(command, stashName string, item DueItem) error {
+	data, err := json.Marshal(item.Record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	hook := exec.Command("sh", "-c", command)
+	hook.Stdin = bytes.NewReader(data)
+	hook.Stdout = os.Stdout
+	if GetJSONOutput() {
+		hook.Stdout = os.Stderr
+	}
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(),
+		"STASH_ID="+item.ID,
+		"STASH_STASH="+stashName,
+		"STASH_COLUMN="+item.Column,
+		"STASH_DUE="+item.Due.Format(time.RFC3339),
+		"STASH_STATUS="+item.Status,
+	)
+	return hook.Run()
+}
@@ -135,7 +135,7 @@ func runRepair(cmd *cobra.Command, args []string) error {
 func planRepairs(cmd *cobra.Command, ctx *context.Context) []RepairAction {
 	var actions []RepairAction
 
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return actions
 	}
@@ -277,7 +277,7 @@ func findHashMismatches(ctx *context.Context, store *storage.Store, stashName st
 }
 
 func executeRepairs(cmd *cobra.Command, ctx *context.Context, actions []RepairAction) []RepairAction {
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		for i := range actions {
 			actions[i].Status = "failed"
@@ -0,0 +1,491 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	replayInto    string
+	replayStrict  bool
+	replayLenient bool
+)
+
+// replayReport is the outcome of 'stash replay'.
+type replayReport struct {
+	Stash      string            `json:"stash"`
+	Operations int               `json:"operations"`
+	Applied    int               `json:"applied"`
+	Remapped   map[string]string `json:"remapped"`
+	NewColumns []string          `json:"new_columns"`
+	Skipped    []lineSkip        `json:"skipped"`
+	Rejects    string            `json:"rejects_file,omitempty"`
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <records.jsonl>",
+	Short: "Replay another stash's operation log into a stash",
+	Long: `Replay the operations in a records.jsonl file, such as one copied from
+another project or recovered from a backup, into a stash.
+
+Each operation is checked, then written in order with its original
+operation type, timestamps, and actors. The actor replaying the log needs
+permission (see 'stash permissions') for every kind of operation in it,
+and for every column its creates set and its updates change. Columns used by the log that the
+stash lacks are created.
+
+IDs are remapped when a created record's ID is already taken in the stash,
+or does not use the stash's prefix; later operations on that record, and
+its children, follow the new ID. Remapped IDs are reported.
+
+Operations that cannot be replayed are skipped and reported, and the rest
+are still applied:
+  - Lines that are not valid JSON, or lack _id or a known _op
+  - Updates, deletes, and other operations on records the log never created
+  - A second create of the same record
+  - Children whose parent the log never created
+  - Fields that are not valid column names
+
+--strict and --lenient also check each field value against its column's
+validation (see 'stash column add --validate'), and change what happens
+to operations that fail a check:
+  --strict    Replay nothing if any operation fails; every failure is
+              reported
+  --lenient   Skip the failed operations and write them, each with its
+              line number and reason, to <file>.rejects for fixing and
+              replaying later
+
+Options:
+  --into STASH   Stash to replay into (default: the current stash)
+  --strict       Reject the whole log if any operation is invalid
+  --lenient      Skip invalid operations into <file>.rejects
+
+Examples:
+  stash replay ../other-project/.stash/tasks/records.jsonl
+  stash replay recovered.jsonl --into tasks
+  stash replay recovered.jsonl --into tasks --json
+  stash replay foreign.jsonl --into tasks --strict
+  stash replay foreign.jsonl --into tasks --lenient   # writes foreign.jsonl.rejects
+
+AI Agent Examples:
+  # Recover a stash from a raw log and list what could not be replayed
+  stash replay backup/records.jsonl --into tasks --json | jq '.skipped[]'
+
+Exit Codes:
+  0  Success (check "skipped" for operations that were not replayed)
+  1  File or stash not found
+  2  Validation error (--strict found an invalid operation, or --strict
+     with --lenient)
+  6  Permission denied: the actor may not make some operation in the log,
+     or set some column it writes; nothing is replayed
+
+JSON Output (--json):
+  {"stash": "tasks", "operations": 42, "applied": 40,
+   "remapped": {"tk-ab12": "tk-x9k2"}, "new_columns": ["Owner"],
+   "skipped": [{"line": 7, "id": "tk-zz99", "op": "update",
+                "reason": "record was not created earlier in the log"}]}
+  With --lenient, "rejects_file" names the file the skipped operations
+  were written to.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayInto, "into", "", "Stash to replay into (default: the current stash)")
+	replayCmd.Flags().BoolVar(&replayStrict, "strict", false, "Replay nothing if any operation is invalid")
+	replayCmd.Flags().BoolVar(&replayLenient, "lenient", false, "Skip invalid operations into <file>.rejects")
+	rootCmd.AddCommand(replayCmd)
+}
+
+// replayOps are the operation types that can be replayed.
+var replayOps = map[string]bool{
+	model.OpCreate:    true,
+	model.OpUpdate:    true,
+	model.OpDelete:    true,
+	model.OpRestore:   true,
+	model.OpArchive:   true,
+	model.OpUnarchive: true,
+	model.OpAssign:    true,
+	model.OpUnassign:  true,
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	if !checkJSONLMode(replayStrict, replayLenient) {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			ExitWithError(1, "FILE_NOT_FOUND", fmt.Sprintf("file '%s' not found", path),
+				map[string]interface{}{"path": path})
+			return nil
+		}
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer file.Close()
+
+	stashFlag := replayInto
+	if stashFlag == "" {
+		stashFlag = GetStashName()
+	}
+	ctx, err := context.ResolveRequired(GetActorName(), stashFlag)
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --into)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	// With --strict, check the whole log before replaying any of it
+	if replayStrict {
+		checker := newReplayChecker(stash, true)
+		var skips []lineSkip
+		err := scanJSONL(file, func(lineNum int, line []byte) error {
+			if _, skip := checker.check(lineNum, line); skip != nil {
+				skips = append(skips, *skip)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(skips) > 0 {
+			exitStrictRejects(path, skips)
+			return nil
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+
+	// The actor needs permission for every kind of operation in the log,
+	// and every column it writes, before any of it is replayed
+	needed, err := replayPermissionsNeeded(file)
+	if err != nil {
+		return err
+	}
+	for _, perm := range []string{model.PermCreate, model.PermUpdate, model.PermDelete, model.PermRestore, model.PermArchive} {
+		if columns, ok := needed[perm]; ok && !checkPermission(stash, ctx.Actor, perm, fieldNames(columns)) {
+			return nil
+		}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+
+	report := &replayReport{
+		Stash:      stash.Name,
+		Remapped:   map[string]string{},
+		NewColumns: []string{},
+		Skipped:    []lineSkip{},
+	}
+	// ids maps the log's IDs to the stash's, for every record the log created
+	ids := make(map[string]string)
+	checker := newReplayChecker(stash, replayStrict || replayLenient)
+	rejected := make(map[int][]byte)
+
+	err = scanJSONL(file, func(lineNum int, line []byte) error {
+		report.Operations++
+		record, skip := checker.check(lineNum, line)
+		if skip != nil {
+			report.Skipped = append(report.Skipped, *skip)
+			rejected[lineNum] = append([]byte(nil), line...)
+			return nil
+		}
+
+		sourceID := record.ID
+		if record.Operation == model.OpCreate {
+			id, err := replayID(store, stash, record, ids)
+			if err != nil {
+				return err
+			}
+			if id != sourceID {
+				report.Remapped[sourceID] = id
+			}
+			ids[sourceID] = id
+		} else {
+			if record.ParentID != "" {
+				record.ParentID = ids[record.ParentID]
+			}
+			record.ID = ids[sourceID]
+		}
+
+		// Create columns the stash lacks
+		for _, name := range fieldNames(record.Fields) {
+			if stash.Columns.Exists(name) {
+				continue
+			}
+			col := model.Column{Name: name, Added: time.Now(), AddedBy: ctx.Actor}
+			if err := store.AddColumn(stash.Name, col); err != nil {
+				return fmt.Errorf("failed to add column '%s': %w", name, err)
+			}
+			stash.Columns = append(stash.Columns, col)
+			report.NewColumns = append(report.NewColumns, name)
+		}
+
+		if err := store.ReplayRecord(stash.Name, record); err != nil {
+			return fmt.Errorf("failed to replay line %d: %w", lineNum, err)
+		}
+		report.Applied++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if replayLenient && len(report.Skipped) > 0 {
+		report.Rejects = rejectsPath(path)
+		if err := writeRejects(report.Rejects, report.Skipped, rejected); err != nil {
+			return err
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+	fmt.Printf("Replayed %d of %d operation(s) into stash '%s'\n", report.Applied, report.Operations, report.Stash)
+	if len(report.NewColumns) > 0 {
+		fmt.Printf("  Created column(s): %s\n", strings.Join(report.NewColumns, ", "))
+	}
+	if len(report.Remapped) > 0 {
+		fmt.Printf("  Remapped %d ID(s):\n", len(report.Remapped))
+		from := make([]string, 0, len(report.Remapped))
+		for id := range report.Remapped {
+			from = append(from, id)
+		}
+		sort.Strings(from)
+		for _, from := range from {
+			fmt.Printf("    %s -> %s\n", from, report.Remapped[from])
+		}
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("  Skipped %d operation(s):\n", len(report.Skipped))
+		printLineSkips(os.Stdout, report.Skipped)
+	}
+	if report.Rejects != "" {
+		fmt.Printf("  Wrote the skipped operations to %s\n", report.Rejects)
+	}
+	return nil
+}
+
+// replayPermissions maps each operation that can be replayed to the
+// permission it needs.
+var replayPermissions = map[string]string{
+	model.OpCreate:    model.PermCreate,
+	model.OpUpdate:    model.PermUpdate,
+	model.OpAssign:    model.PermUpdate,
+	model.OpUnassign:  model.PermUpdate,
+	model.OpDelete:    model.PermDelete,
+	model.OpRestore:   model.PermRestore,
+	model.OpArchive:   model.PermArchive,
+	model.OpUnarchive: model.PermArchive,
+}
+
+// replayPermissionsNeeded returns the permissions replaying a log needs,
+// each with the columns it writes: every field of a create, and the fields
+// an update changes from the record's previous operation in the log.
+// Lines that cannot be parsed are left to the replay to report.
+func replayPermissionsNeeded(r io.Reader) (map[string]map[string]interface{}, error) {
+	needed := make(map[string]map[string]interface{})
+	last := make(map[string]map[string]interface{})
+	err := scanJSONL(r, func(lineNum int, line []byte) error {
+		var record model.Record
+		if json.Unmarshal(line, &record) != nil {
+			return nil
+		}
+		perm, ok := replayPermissions[record.Operation]
+		if !ok {
+			return nil
+		}
+		if needed[perm] == nil {
+			needed[perm] = make(map[string]interface{})
+		}
+		before := last[record.ID]
+		switch perm {
+		case model.PermCreate:
+			for name := range record.Fields {
+				needed[perm][name] = true
+			}
+		case model.PermUpdate:
+			for name, value := range record.Fields {
+				if old, ok := before[name]; !ok || valueText(old) != valueText(value) {
+					needed[perm][name] = true
+				}
+			}
+			for name := range before {
+				if _, ok := record.Fields[name]; !ok {
+					needed[perm][name] = true
+				}
+			}
+		}
+		last[record.ID] = record.Fields
+		return nil
+	})
+	return needed, err
+}
+
+// replayChecker checks the operations of a log in order, tracking the
+// records the log has created so far.
+type replayChecker struct {
+	stash   *model.Stash
+	created map[string]bool
+	values  bool // also check field values against the columns' validation
+}
+
+func newReplayChecker(stash *model.Stash, values bool) *replayChecker {
+	return &replayChecker{stash: stash, created: make(map[string]bool), values: values}
+}
+
+// check parses a line of the log and returns its operation, or why the
+// operation cannot be replayed.
+func (c *replayChecker) check(lineNum int, line []byte) (*model.Record, *lineSkip) {
+	var record model.Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, &lineSkip{Line: lineNum, Reason: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if reason := c.problem(&record); reason != "" {
+		return nil, &lineSkip{Line: lineNum, ID: record.ID, Op: record.Operation, Reason: reason}
+	}
+	if record.Operation == model.OpCreate {
+		c.created[record.ID] = true
+	}
+	return &record, nil
+}
+
+// problem returns why an operation cannot be replayed, or empty string if
+// it can.
+func (c *replayChecker) problem(record *model.Record) string {
+	if record.ID == "" {
+		return "missing _id"
+	}
+	if !replayOps[record.Operation] {
+		return fmt.Sprintf("unknown operation '%s'", record.Operation)
+	}
+	if name := invalidFieldName(record.Fields); name != "" {
+		return fmt.Sprintf("field '%s' is not a valid column name", name)
+	}
+	if record.Operation == model.OpCreate {
+		if c.created[record.ID] {
+			return "record was already created earlier in the log"
+		}
+	} else if !c.created[record.ID] {
+		return "record was not created earlier in the log"
+	}
+	if record.ParentID != "" && !c.created[record.ParentID] {
+		return fmt.Sprintf("parent '%s' was not created earlier in the log", record.ParentID)
+	}
+	if c.values {
+		for _, name := range fieldNames(record.Fields) {
+			col := c.stash.Columns.Find(name)
+			if col == nil || col.IsComputed() {
+				continue
+			}
+			if result := ValidateValue(col, record.Fields[name]); !result.Valid {
+				return result.Errors[0].Message
+			}
+		}
+	}
+	return ""
+}
+
+// replayID picks the stash ID for a record created by the log, and points
+// its parent at the parent's stash ID. The log's ID is kept unless it is
+// taken, lacks the stash prefix, looks like a child ID on a root record, or
+// the parent's ID changed. The parent must have been created by the log.
+func replayID(store *storage.Store, stash *model.Stash, record *model.Record, ids map[string]string) (string, error) {
+	if record.ParentID != "" {
+		parentID := ids[record.ParentID]
+		keep := parentID == record.ParentID && strings.HasPrefix(record.ID, parentID+".")
+		record.ParentID = parentID
+		if keep {
+			if free, err := replayIDFree(store, stash.Name, record.ID); err != nil || free {
+				return record.ID, err
+			}
+		}
+		seq, err := store.GetNextChildSeq(stash.Name, parentID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get next child sequence: %w", err)
+		}
+		record.ID = model.GenerateChildID(parentID, seq)
+		return record.ID, nil
+	}
+
+	// Any strategy's IDs are kept, so only the prefix and the dot that marks
+	// a child ID are checked
+	if len(record.ID) > len(stash.Prefix) && strings.HasPrefix(record.ID, stash.Prefix) && !strings.Contains(record.ID, ".") {
+		if free, err := replayIDFree(store, stash.Name, record.ID); err != nil || free {
+			return record.ID, err
+		}
+	}
+	id, err := store.NextRecordID(stash.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate record ID: %w", err)
+	}
+	record.ID = id
+	return id, nil
+}
+
+// replayIDFree returns true if no record in the stash, deleted or not, has
+// the ID.
+func replayIDFree(store *storage.Store, stashName, id string) (bool, error) {
+	_, err := store.GetRecordIncludeDeleted(stashName, id)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// invalidFieldName returns the first field name that cannot be a column,
+// or empty string if all can.
+func invalidFieldName(fields map[string]interface{}) string {
+	for _, name := range fieldNames(fields) {
+		if model.ValidateColumnName(name) != nil {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestReplay(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Title", "Status"})
+	defer cleanup()
+
+	run := func(args ...string) {
+		t.Helper()
+		rootCmd.SetArgs(args)
+		rootCmd.Execute()
+		resetFlags()
+	}
+	run("add", "Parent task")
+	store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	records, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+	store.Close()
+	parentID := records[0].ID
+	run("add", "Child task", "--parent", parentID)
+	run("set", parentID, "Status=done")
+	run("add", "Dropped task")
+	store, _ = storage.NewStore(filepath.Join(tempDir, ".stash"))
+	records, _ = store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+	store.Close()
+	var droppedID string
+	for _, rec := range records {
+		if rec.Fields["Title"] == "Dropped task" {
+			droppedID = rec.ID
+		}
+	}
+	run("rm", droppedID, "--yes")
+	run("init", "copy", "--prefix", "cp-")
+	ExitCode = 0
+
+	logPath := filepath.Join(tempDir, ".stash", "tasks", "records.jsonl")
+
+	replay := func(t *testing.T, path string, args ...string) replayReport {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"replay", path, "--json"}, args...)...)
+		var report replayReport
+		if err := json.Unmarshal([]byte(output), &report); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return report
+	}
+
+	t.Run("remaps IDs to the target prefix", func(t *testing.T) {
+		ExitCode = 0
+		report := replay(t, logPath, "--into", "copy")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if report.Applied != report.Operations || len(report.Skipped) != 0 {
+			t.Errorf("expected every operation applied, got %+v", report)
+		}
+
+		newParent := report.Remapped[parentID]
+		if !strings.HasPrefix(newParent, "cp-") {
+			t.Fatalf("expected parent remapped to cp- prefix, got %v", report.Remapped)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		parent, err := store.GetRecord("copy", newParent)
+		if err != nil {
+			t.Fatalf("expected replayed parent: %v", err)
+		}
+		if parent.Fields["Status"] != "done" {
+			t.Errorf("expected update to be replayed, got %v", parent.Fields)
+		}
+		children, _ := store.GetChildren("copy", newParent)
+		if len(children) != 1 || children[0].ID != newParent+".1" {
+			t.Errorf("expected child %s.1, got %v", newParent, children)
+		}
+		if _, err := store.GetRecord("copy", report.Remapped[droppedID]); err == nil {
+			t.Error("expected the deleted record to stay deleted")
+		}
+	})
+
+	t.Run("reports skipped operations", func(t *testing.T) {
+		ExitCode = 0
+		path := filepath.Join(tempDir, "partial.jsonl")
+		lines := strings.Join([]string{
+			`not json`,
+			`{"_id": "tk-zz99", "_op": "update", "Title": "Orphan update"}`,
+			`{"_id": "tk-zz98", "_op": "rename", "Title": "Odd op"}`,
+			`{"_id": "tk-zz97", "_op": "create", "Title": "Kept", "bad-name": "x"}`,
+			`{"_id": "tk-zz96", "_op": "create", "Title": "Kept"}`,
+		}, "\n")
+		os.WriteFile(path, []byte(lines), 0644)
+
+		report := replay(t, path, "--into", "tasks")
+		if report.Operations != 5 || report.Applied != 1 || len(report.Skipped) != 4 {
+			t.Errorf("expected 1 of 5 applied, got %+v", report)
+		}
+		if len(report.Remapped) != 0 {
+			t.Errorf("expected a free ID to be kept, got %v", report.Remapped)
+		}
+	})
+
+	t.Run("keeps IDs of any strategy", func(t *testing.T) {
+		ExitCode = 0
+		path := filepath.Join(tempDir, "strategies.jsonl")
+		lines := strings.Join([]string{
+			`{"_id": "tk-01j9x3k2v8q0r5m7n4c6t1w9yz", "_op": "create", "Title": "ULID"}`,
+			`{"_id": "tk-20240115-0001", "_op": "create", "Title": "Template"}`,
+			`{"_id": "tk-0001", "_op": "create", "Title": "Sequential"}`,
+			`{"_id": "tk-zz95.1", "_op": "create", "Title": "Dotted root"}`,
+		}, "\n")
+		os.WriteFile(path, []byte(lines), 0644)
+
+		report := replay(t, path, "--into", "tasks")
+		if report.Applied != 4 {
+			t.Fatalf("expected 4 applied, got %+v", report)
+		}
+		if len(report.Remapped) != 1 || report.Remapped["tk-zz95.1"] == "" {
+			t.Errorf("expected only the dotted root remapped, got %v", report.Remapped)
+		}
+	})
+
+	t.Run("strict and lenient check values", func(t *testing.T) {
+		ExitCode = 0
+		run("column", "add", "Points", "--validate", "number", "--stash", "tasks")
+		path := filepath.Join(tempDir, "foreign.jsonl")
+		lines := strings.Join([]string{
+			`{"_id": "tk-yy01", "_op": "create", "Title": "Good", "Points": "3"}`,
+			`{"_id": "tk-yy02", "_op": "create", "Title": "Bad", "Points": "lots"}`,
+			`{"_id": "tk-yy02", "_op": "update", "Title": "Bad", "Points": "2"}`,
+		}, "\n")
+		os.WriteFile(path, []byte(lines), 0644)
+
+		output := captureSchemaOutput(t, "replay", path, "--into", "tasks", "--strict", "--json")
+		if ExitCode != 2 || !strings.Contains(output, `"line":2`) || !strings.Contains(output, `"line":3`) {
+			t.Fatalf("expected --strict to reject lines 2 and 3, got exit %d: %s", ExitCode, output)
+		}
+		ExitCode = 0
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		_, err := store.GetRecord("tasks", "tk-yy01")
+		store.Close()
+		if err == nil {
+			t.Fatal("expected --strict to replay nothing")
+		}
+
+		report := replay(t, path, "--into", "tasks", "--lenient")
+		if report.Applied != 1 || len(report.Skipped) != 2 || report.Rejects != path+".rejects" {
+			t.Errorf("expected 1 applied and 2 rejected, got %+v", report)
+		}
+		data, _ := os.ReadFile(path + ".rejects")
+		if strings.Count(string(data), "\n") != 2 || !strings.Contains(string(data), `"lots"`) {
+			t.Errorf("expected the rejected operations in the rejects file, got %q", data)
+		}
+	})
+
+	t.Run("needs permission for every operation", func(t *testing.T) {
+		run("init", "guarded", "--prefix", "gd-")
+		run("permissions", "set", "agent", "--ops", "update", "--stash", "guarded")
+		ExitCode = 0
+		captureSchemaOutput(t, "replay", logPath, "--into", "guarded", "--actor", "agent")
+		if ExitCode != 6 {
+			t.Errorf("expected exit code 6, got %d", ExitCode)
+		}
+		ExitCode = 0
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		if history, _ := store.GetAllHistory("guarded"); len(history) != 0 {
+			t.Errorf("expected nothing replayed, got %d operation(s)", len(history))
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "replay", filepath.Join(tempDir, "nope.jsonl"))
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
@@ -15,30 +17,96 @@ import (
 
 var (
 	restoreCascade bool
+	restoreDepth   int
+	restoreDryRun  bool
+	restoreBefore  string
+	restoreWhere   []string
+	restoreRevert  bool
 )
 
 var restoreCmd = &cobra.Command{
-	Use:   "restore <id>",
+	Use:   "restore <id> | --before <time>",
 	Short: "Restore a soft-deleted record",
 	Long: `Restore a soft-deleted record by clearing _deleted_at and _deleted_by fields.
 
 The record becomes active again and will appear in normal queries.
 
+--cascade also restores the record's deleted descendants. --depth limits
+how many levels below the record the cascade reaches. Records locked by
+another agent (see 'stash lock') are not restored.
+
+--before <time> restores every record deleted at or after the time,
+instead of one record: a recovery after a script or agent deleted more
+than it should. The time is a date (2006-01-02T15:04:05Z) or a duration
+before now (2h, 7d). --where narrows the records, matching their values
+as deleted. --revert also puts back each record's fields, archive state,
+and assignee as they were at the time, from the history; records created
+since are left deleted. Reverted values are written without validation.
+
+--dry-run runs the same checks and prints the restore operations that
+would be written, without restoring anything.
+
 Examples:
   stash restore inv-ex4j
-  stash restore inv-ex4j --cascade  # Restore parent and deleted children
-  stash restore inv-ex4j --json     # Output as JSON`,
-	Args: cobra.ExactArgs(1),
+  stash restore inv-ex4j --cascade             # Restore parent and deleted children
+  stash restore inv-ex4j --cascade --depth 1   # Restore parent and direct children only
+  stash restore inv-ex4j --cascade --dry-run   # Preview what would be restored
+  stash restore inv-ex4j --json                # Output as JSON
+  stash restore --before 2h --dry-run          # Preview restoring the last 2 hours' deletions
+  stash restore --before 2026-01-15T09:00:00Z --where "Category=electronics"
+  stash restore --before 2026-01-15T09:00:00Z --revert
+
+Exit Codes:
+  0  Success (with --before, also when nothing was deleted since)
+  1  Stash not found, record is not deleted
+  2  Validation error (invalid --before or --where, or both an ID and --before)
+  4  Record not found
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--before --json):
+  {"stash": "inventory", "before": "2026-01-15T09:00:00Z", "restored": 2,
+   "ids": ["inv-ex4j", "inv-8t2m"],
+   "operations": [{"id": "inv-ex4j", "op": "restore"}, {"id": "inv-8t2m", "op": "restore"}]}
+
+JSON Output (--dry-run --json):
+  {"dry_run": true, "stash": "inventory", "would_restore": 2,
+   "ids": ["inv-ex4j", "inv-ex4j.1"],
+   "operations": [{"_id": "inv-ex4j", "_op": "restore", ...}, ...]}`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRestore,
 }
 
 func init() {
 	restoreCmd.Flags().BoolVar(&restoreCascade, "cascade", false, "Restore parent and all deleted children")
+	restoreCmd.Flags().IntVar(&restoreDepth, "depth", 0, "With --cascade, restore at most N levels of descendants (0 = all)")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Preview what would be restored without making changes")
+	restoreCmd.Flags().StringVar(&restoreBefore, "before", "", "Restore every record deleted at or after this time (date or duration like 2h)")
+	restoreCmd.Flags().StringArrayVar(&restoreWhere, "where", nil, "With --before, restore only records matching this condition (can be repeated)")
+	restoreCmd.Flags().BoolVar(&restoreRevert, "revert", false, "With --before, also put back the records' values as they were at that time")
 	rootCmd.AddCommand(restoreCmd)
 }
 
 func runRestore(cmd *cobra.Command, args []string) error {
+	if restoreBefore != "" {
+		if len(args) > 0 || restoreCascade {
+			ExitValidationError("--before restores records by time; it cannot be used with a record ID or --cascade", nil)
+			return nil
+		}
+		return runRestoreBefore()
+	}
+	if len(args) == 0 {
+		ExitValidationError("a record ID or --before is required", nil)
+		return nil
+	}
+	if len(restoreWhere) > 0 || restoreRevert {
+		ExitValidationError("--where and --revert can only be used with --before", nil)
+		return nil
+	}
 	recordID := args[0]
+	if !checkCascadeDepth(restoreDepth, restoreCascade) {
+		return nil
+	}
 
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -57,14 +125,14 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Get stash configuration
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -74,6 +142,10 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermRestore, nil) {
+		return nil
+	}
+
 	// Check if record exists (including deleted)
 	record, err := store.GetRecordIncludeDeleted(ctx.Stash, recordID)
 	if err != nil {
@@ -97,21 +169,32 @@ func runRestore(cmd *cobra.Command, args []string) error {
 
 	// AC-02: Cascade restore
 	if restoreCascade {
-		deletedChildren, err := store.GetChildrenIncludeDeleted(ctx.Stash, recordID)
+		toRestore, err = collectDeletedChildren(store, ctx.Stash, toRestore, []*model.Record{record}, restoreDepth)
 		if err != nil {
-			return fmt.Errorf("failed to get children: %w", err)
+			return fmt.Errorf("failed to collect children: %w", err)
 		}
-		// Filter to only deleted children
-		for _, child := range deletedChildren {
-			if child.IsDeleted() {
-				toRestore = append(toRestore, child)
-			}
+	}
+
+	if ok, err := checkRecordLocks(ctx, toRestore); !ok {
+		return err
+	}
+
+	if restoreDryRun {
+		now := time.Now()
+		for _, rec := range toRestore {
+			rec.DeletedAt = nil
+			rec.DeletedBy = ""
+			rec.UpdatedAt = now
+			rec.UpdatedBy = ctx.Actor
 		}
-		// Recursively get nested deleted children
-		toRestore, err = collectDeletedChildren(store, ctx.Stash, toRestore, deletedChildren)
+		ops, err := previewOperations(store, ctx.Stash, toRestore, model.OpRestore)
 		if err != nil {
-			return fmt.Errorf("failed to collect children: %w", err)
+			return err
 		}
+		return outputDryRun(ctx.Stash, ops, map[string]interface{}{
+			"would_restore": len(toRestore),
+			"ids":           getRecordIDs(toRestore),
+		})
 	}
 
 	// Restore records
@@ -153,8 +236,9 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// collectDeletedChildren recursively collects all deleted children of the given records.
-func collectDeletedChildren(store *storage.Store, stashName string, collected []*model.Record, parents []*model.Record) ([]*model.Record, error) {
+// collectDeletedChildren recursively collects all deleted children of the given records,
+// at most levels levels below them (0 = unlimited).
+func collectDeletedChildren(store *storage.Store, stashName string, collected []*model.Record, parents []*model.Record, levels int) ([]*model.Record, error) {
 	for _, parent := range parents {
 		children, err := store.GetChildrenIncludeDeleted(stashName, parent.ID)
 		if err != nil {
@@ -165,8 +249,8 @@ func collectDeletedChildren(store *storage.Store, stashName string, collected []
 				collected = append(collected, child)
 			}
 		}
-		if len(children) > 0 {
-			collected, err = collectDeletedChildren(store, stashName, collected, children)
+		if len(children) > 0 && levels != 1 {
+			collected, err = collectDeletedChildren(store, stashName, collected, children, nextCascadeLevels(levels))
 			if err != nil {
 				return nil, err
 			}
@@ -174,3 +258,180 @@ func collectDeletedChildren(store *storage.Store, stashName string, collected []
 	}
 	return collected, nil
 }
+
+// stateAt returns each record's state at a time, given a stash's
+// operations in log order: its last operation at or before the time.
+// Records created after the time have no state.
+func stateAt(history []*model.Record, at time.Time) map[string]*model.Record {
+	states := make(map[string]*model.Record)
+	for _, op := range history {
+		if !op.UpdatedAt.After(at) {
+			states[op.ID] = op
+		}
+	}
+	return states
+}
+
+// runRestoreBefore restores the records deleted at or after --before.
+func runRestoreBefore() error {
+	before, err := parseTimeFilter(restoreBefore)
+	if err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"before": restoreBefore})
+		return nil
+	}
+	var conditions []storage.WhereCondition
+	for _, clause := range restoreWhere {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"where": clause})
+			return nil
+		}
+		conditions = append(conditions, cond)
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !checkWhereColumns(stash, conditions) {
+		return nil
+	}
+
+	// Hold the locks file from reading the log to the last write, so no
+	// change slips in between
+	if !store.IsMemory() {
+		fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to lock locks file: %w", err)
+		}
+		defer fileLock.Unlock()
+	}
+
+	deleted, err := store.ListRecords(stash.Name, storage.ListOptions{
+		IncludeDeleted: true,
+		DeletedOnly:    true,
+		ParentID:       "*",
+		Where:          conditions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	// The cache keeps times to the second; the log has them exactly
+	history, err := store.GetAllHistory(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+	latest := stateAt(history, time.Now())
+	deletedAt := func(record *model.Record) time.Time {
+		if op := latest[record.ID]; op != nil && op.DeletedAt != nil {
+			return *op.DeletedAt
+		}
+		return *record.DeletedAt
+	}
+	sort.SliceStable(deleted, func(i, j int) bool { return deletedAt(deleted[i]).Before(deletedAt(deleted[j])) })
+	var states map[string]*model.Record
+	if restoreRevert {
+		states = stateAt(history, before)
+	}
+
+	// Work out every operation, and check them all, before writing any
+	type recordRestore struct {
+		current *model.Record
+		before  *model.Record
+		ops     []string
+	}
+	var restores []recordRestore
+	var records []*model.Record
+	for _, record := range deleted {
+		if deletedAt(record).Before(before) {
+			continue
+		}
+		ops, fields := []string{model.OpRestore}, []string(nil)
+		state := record
+		if restoreRevert {
+			state = states[record.ID]
+			ops, fields = undoOps(stash, state, record)
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		for _, op := range ops {
+			var columns []string
+			if op == model.OpUpdate {
+				columns = fields
+			}
+			if !checkPermission(stash, ctx.Actor, undoPermissions[op], columns) {
+				return nil
+			}
+		}
+		restores = append(restores, recordRestore{current: record, before: state, ops: ops})
+		records = append(records, record)
+	}
+	if ok, err := checkRecordLocks(ctx, records); !ok {
+		return err
+	}
+
+	if restoreDryRun {
+		now := time.Now()
+		ops := []*model.Record{}
+		for _, restore := range restores {
+			record := restore.current
+			for _, op := range restore.ops {
+				record = applyUndoOp(record, restore.before, stash, op, ctx.Actor, now)
+				preview, err := store.PreviewRecord(stash.Name, record, op)
+				if err != nil {
+					return fmt.Errorf("failed to preview %s: %w", record.ID, err)
+				}
+				ops = append(ops, preview)
+			}
+		}
+		return outputDryRun(stash.Name, ops, map[string]interface{}{
+			"before":        before,
+			"would_restore": len(restores),
+			"ids":           getRecordIDs(records),
+		})
+	}
+
+	steps := []undoStep{}
+	for _, restore := range restores {
+		for _, op := range restore.ops {
+			if err := writeUndoOp(store, stash, restore.current, restore.before, op, ctx.Actor); err != nil {
+				if exitRecordTooLarge(stash.Name, err) || exitWriteRefused(err) {
+					return nil
+				}
+				return fmt.Errorf("failed to %s %s: %w", op, restore.current.ID, err)
+			}
+			steps = append(steps, undoStep{ID: restore.current.ID, Op: op})
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(map[string]interface{}{
+			"stash":      stash.Name,
+			"before":     before,
+			"restored":   len(restores),
+			"ids":        getRecordIDs(records),
+			"operations": steps,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		for _, step := range steps {
+			printPorcelain(step.ID, step.Op)
+		}
+	} else if !IsQuiet() {
+		fmt.Printf("Restored %d record(s) deleted since %s\n", len(restores), before.Format(time.RFC3339))
+		if IsVerbose() {
+			for _, step := range steps {
+				fmt.Printf("  - %s: %s\n", step.ID, step.Op)
+			}
+		}
+	}
+	return nil
+}
@@ -258,7 +258,7 @@ func runRestoreBackup(cmd *cobra.Command, args []string) error {
 	}
 
 	// Rebuild SQLite cache
-	store, err := storage.NewStore(stashDir)
+	store, err := openStore(stashDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to initialize storage for cache rebuild: %v\n", err)
 	} else {
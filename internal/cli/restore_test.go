@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/user/stash/internal/storage"
 )
@@ -301,3 +302,105 @@ func TestUC_REC_006_RestorePartialCascade(t *testing.T) {
 		}
 	})
 }
+
+// TestRestoreCascadeDepth tests --depth and --dry-run on cascade restores
+func TestRestoreCascadeDepth(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	var root map[string]interface{}
+	json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &root)
+	rootID := root["_id"].(string)
+	captureSchemaOutput(t, "add", "Battery", "--parent", rootID)
+	captureSchemaOutput(t, "add", "Cell", "--parent", rootID+".1")
+	captureSchemaOutput(t, "rm", rootID, "--cascade", "--yes")
+	ExitCode = 0
+
+	restore := func(args ...string) map[string]interface{} {
+		output := captureSchemaOutput(t, append([]string{"restore", "--json"}, args...)...)
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return result
+	}
+
+	result := restore(rootID, "--cascade", "--dry-run")
+	if result["would_restore"] != float64(3) {
+		t.Errorf("expected 3 records in dry run, got %v", result)
+	}
+
+	result = restore(rootID, "--cascade", "--depth", "1")
+	if result["restored"] != float64(2) {
+		t.Errorf("expected record and direct child restored, got %v", result)
+	}
+	captureSchemaOutput(t, "show", rootID+".1.1")
+	if ExitCode != 4 {
+		t.Errorf("expected grandchild to stay deleted, got exit code %d", ExitCode)
+	}
+}
+
+func TestRestoreBefore(t *testing.T) {
+	t.Run("restores records deleted since the time, reverted with --revert", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop")
+		captureSchemaOutput(t, "add", "Mouse")
+		captureSchemaOutput(t, "add", "Monitor")
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		ids := make(map[string]string)
+		for _, rec := range records {
+			ids[rec.Fields["Name"].(string)] = rec.ID
+		}
+		store.DeleteRecord("inventory", ids["Laptop"], "test")
+		time.Sleep(10 * time.Millisecond)
+		before := time.Now().UTC().Format(time.RFC3339Nano)
+		time.Sleep(10 * time.Millisecond)
+		mouse, _ := store.GetRecord("inventory", ids["Mouse"])
+		mouse.Fields["Name"] = "Broken"
+		mouse.UpdatedAt = time.Now()
+		store.UpdateRecord("inventory", mouse)
+		store.DeleteRecord("inventory", ids["Mouse"], "test")
+		store.DeleteRecord("inventory", ids["Monitor"], "test")
+		store.Close()
+
+		var result struct {
+			Restored int      `json:"restored"`
+			IDs      []string `json:"ids"`
+		}
+		output := captureSchemaOutput(t, "restore", "--before", before, "--where", "Name!=Monitor", "--revert", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result.Restored != 1 || len(result.IDs) != 1 || result.IDs[0] != ids["Mouse"] {
+			t.Errorf("expected only %s restored, got %+v", ids["Mouse"], result)
+		}
+
+		store, _ = storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		rec, err := store.GetRecord("inventory", ids["Mouse"])
+		if err != nil {
+			t.Fatalf("expected %s to be restored: %v", ids["Mouse"], err)
+		}
+		if rec.Fields["Name"] != "Mouse" {
+			t.Errorf("expected Name reverted to Mouse, got %v", rec.Fields["Name"])
+		}
+		for _, name := range []string{"Laptop", "Monitor"} {
+			if _, err := store.GetRecord("inventory", ids[name]); err == nil {
+				t.Errorf("expected %s to stay deleted", name)
+			}
+		}
+	})
+
+	t.Run("rejects a record ID with --before", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "restore", "inv-ex4j", "--before", "1h")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
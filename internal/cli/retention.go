@@ -0,0 +1,169 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	retentionClear       bool
+	retentionAutoPurge   bool
+	retentionNoAutoPurge bool
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention [period]",
+	Short: "Show or set the retention policy for deleted records",
+	Long: `Show or set how long soft-deleted records are kept before purging.
+
+The policy is stored in the stash's config.json. Records deleted longer
+ago than the retention period are purged by 'stash purge --expired', or
+automatically by the daemon when --auto-purge is enabled.
+
+Periods use d (days), w (weeks), or Go durations (e.g., 12h).
+
+Examples:
+  stash retention                   # Show current policy
+  stash retention 30d               # Keep deleted records for 30 days
+  stash retention 2w --auto-purge   # Let the daemon purge automatically
+  stash retention --no-auto-purge   # Back to purging manually
+  stash retention --clear           # Keep deleted records forever
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (invalid period)
+
+JSON Output (--json):
+  {"stash": "inventory", "retention": "30d", "auto_purge": false, "expired": 3}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRetention,
+}
+
+func init() {
+	retentionCmd.Flags().BoolVar(&retentionClear, "clear", false, "Remove the retention policy")
+	retentionCmd.Flags().BoolVar(&retentionAutoPurge, "auto-purge", false, "Let the daemon purge expired records automatically")
+	retentionCmd.Flags().BoolVar(&retentionNoAutoPurge, "no-auto-purge", false, "Stop the daemon from purging expired records")
+	rootCmd.AddCommand(retentionCmd)
+}
+
+func runRetention(cmd *cobra.Command, args []string) error {
+	if retentionClear && len(args) > 0 {
+		ExitValidationError("a retention period and --clear cannot be used together", nil)
+		return nil
+	}
+	if retentionAutoPurge && retentionNoAutoPurge {
+		ExitValidationError("--auto-purge and --no-auto-purge cannot be used together", nil)
+		return nil
+	}
+
+	var period string
+	if len(args) > 0 {
+		period = args[0]
+		if _, err := model.ParseRetention(period); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"retention": period})
+			return nil
+		}
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	// Update the policy if requested
+	if period != "" || retentionClear || retentionAutoPurge || retentionNoAutoPurge {
+		if retentionClear {
+			stash.Retention = ""
+			stash.AutoPurge = false
+		}
+		if period != "" {
+			stash.Retention = period
+		}
+		if retentionAutoPurge {
+			if stash.Retention == "" {
+				ExitValidationError("--auto-purge requires a retention period", nil)
+				return nil
+			}
+			stash.AutoPurge = true
+		}
+		if retentionNoAutoPurge {
+			stash.AutoPurge = false
+		}
+
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update retention: %w", err)
+		}
+	}
+
+	expired, err := store.ListExpiredRecords(ctx.Stash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list expired records: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"stash":      stash.Name,
+			"retention":  stash.Retention,
+			"auto_purge": stash.AutoPurge,
+			"expired":    len(expired),
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if stash.Retention == "" {
+		fmt.Printf("Stash '%s' keeps deleted records until purged manually\n", stash.Name)
+		return nil
+	}
+
+	mode := "run 'stash purge --expired' to purge"
+	if stash.AutoPurge {
+		mode = "purged automatically by the daemon"
+	}
+	fmt.Printf("Stash '%s' keeps deleted records for %s (%s)\n", stash.Name, stash.Retention, mode)
+	if len(expired) > 0 {
+		fmt.Printf("  %d deleted record(s) past retention\n", len(expired))
+	}
+
+	return nil
+}
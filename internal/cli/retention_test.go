@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestRetention(t *testing.T) {
+	t.Run("policy is stored in config and purge --expired keeps recent deletes", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"add", "Laptop"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"retention", "30d", "--auto-purge"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		stash, _ := store.GetStash("inventory")
+		if stash.Retention != "30d" || !stash.AutoPurge {
+			t.Errorf("expected retention 30d with auto-purge, got %q/%v", stash.Retention, stash.AutoPurge)
+		}
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.DeleteRecord("inventory", records[0].ID, "test")
+		store.Close()
+
+		rootCmd.SetArgs([]string{"purge", "--expired", "--yes"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, _ = storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		deleted, _ := store.ListDeletedRecords("inventory", nil)
+		if len(deleted) != 1 {
+			t.Errorf("expected recently deleted record to be kept, got %d deleted", len(deleted))
+		}
+	})
+
+	t.Run("clear removes the policy", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"retention", "2w", "--auto-purge"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"retention", "--clear"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		stash, _ := store.GetStash("inventory")
+		if stash.Retention != "" || stash.AutoPurge {
+			t.Errorf("expected no policy, got %q/%v", stash.Retention, stash.AutoPurge)
+		}
+	})
+
+	t.Run("must reject invalid period", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"retention", "soon"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("purge --expired requires a policy", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"purge", "--expired", "--yes"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
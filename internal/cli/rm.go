@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
@@ -15,6 +16,8 @@ import (
 
 var (
 	rmCascade bool
+	rmDepth   int
+	rmDryRun  bool
 	rmYes     bool
 )
 
@@ -26,25 +29,69 @@ var rmCmd = &cobra.Command{
 
 The record remains in the database but is excluded from normal queries.
 Use 'stash restore' to undo a soft-delete.
-Use 'stash purge' to permanently remove soft-deleted records.
+Use 'stash trash' to see and restore deleted records, and 'stash purge' to
+permanently remove them.
+
+A record with children can only be deleted with --cascade, which deletes
+its descendants too. --depth limits how many levels below the record the
+cascade reaches; deeper descendants are left in place. The affected
+records are listed before the confirmation prompt.
+
+Records locked by another agent (see 'stash lock') are not deleted.
+
+--dry-run runs the same checks and prints the delete operations that
+would be written, without deleting anything.
 
 Examples:
   stash rm inv-ex4j
-  stash rm inv-ex4j --yes         # Skip confirmation
-  stash rm inv-ex4j --cascade     # Delete parent and children
-  stash rm inv-ex4j --json        # Output as JSON`,
+  stash rm inv-ex4j --yes                 # Skip confirmation
+  stash rm inv-ex4j --cascade             # Delete parent and children
+  stash rm inv-ex4j --cascade --depth 1   # Delete parent and direct children only
+  stash rm inv-ex4j --cascade --dry-run   # Preview what would be deleted
+  stash rm inv-ex4j --json                # Output as JSON
+
+Exit Codes:
+  0  Success
+  1  Stash not found, record has children (without --cascade), aborted
+  4  Record not found or already deleted
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--dry-run --json):
+  {"dry_run": true, "stash": "inventory", "would_delete": 3,
+   "ids": ["inv-ex4j", "inv-ex4j.1", "inv-ex4j.2"],
+   "operations": [{"_id": "inv-ex4j", "_op": "delete", ...}, ...]}`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRm,
 }
 
 func init() {
 	rmCmd.Flags().BoolVar(&rmCascade, "cascade", false, "Delete parent and all children")
+	rmCmd.Flags().IntVar(&rmDepth, "depth", 0, "With --cascade, delete at most N levels of descendants (0 = all)")
+	rmCmd.Flags().BoolVar(&rmDryRun, "dry-run", false, "Preview what would be deleted without making changes")
 	rmCmd.Flags().BoolVarP(&rmYes, "yes", "y", false, "Skip confirmation prompt")
 	rootCmd.AddCommand(rmCmd)
 }
 
+// checkCascadeDepth reports an invalid --depth for a cascade operation.
+// Returns true if the depth is valid.
+func checkCascadeDepth(depth int, cascade bool) bool {
+	if depth < 0 {
+		ExitValidationError("--depth must not be negative", map[string]interface{}{"depth": depth})
+		return false
+	}
+	if depth > 0 && !cascade {
+		ExitValidationError("--depth requires --cascade", nil)
+		return false
+	}
+	return true
+}
+
 func runRm(cmd *cobra.Command, args []string) error {
 	recordID := args[0]
+	if !checkCascadeDepth(rmDepth, rmCascade) {
+		return nil
+	}
 
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -63,14 +110,14 @@ func runRm(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
 	// Get stash configuration
-	_, err = store.GetStash(ctx.Stash)
+	stash, err := store.GetStash(ctx.Stash)
 	if err != nil {
 		if errors.Is(err, model.ErrStashNotFound) {
 			fmt.Fprintf(os.Stderr, "Error: stash '%s' not found\n", ctx.Stash)
@@ -80,6 +127,10 @@ func runRm(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	if !checkPermission(stash, ctx.Actor, model.PermDelete, nil) {
+		return nil
+	}
+
 	// Get record to verify it exists and is not already deleted
 	record, err := store.GetRecord(ctx.Stash, recordID)
 	if err != nil {
@@ -112,16 +163,40 @@ func runRm(cmd *cobra.Command, args []string) error {
 	// Build list of records to delete
 	toDelete := []*model.Record{record}
 	if rmCascade && len(children) > 0 {
-		toDelete = append(toDelete, children...)
-		// Get nested children recursively
-		toDelete, err = collectAllChildren(store, ctx.Stash, toDelete, children)
+		toDelete, err = collectAllChildren(store, ctx.Stash, toDelete, []*model.Record{record}, rmDepth)
 		if err != nil {
 			return fmt.Errorf("failed to collect children: %w", err)
 		}
 	}
 
+	if ok, err := checkRecordLocks(ctx, toDelete); !ok {
+		return err
+	}
+
+	if rmDryRun {
+		now := time.Now()
+		for _, rec := range toDelete {
+			rec.DeletedAt = &now
+			rec.DeletedBy = ctx.Actor
+			rec.UpdatedAt = now
+			rec.UpdatedBy = ctx.Actor
+		}
+		ops, err := previewOperations(store, ctx.Stash, toDelete, model.OpDelete)
+		if err != nil {
+			return err
+		}
+		return outputDryRun(ctx.Stash, ops, map[string]interface{}{
+			"would_delete": len(toDelete),
+			"ids":          getRecordIDs(toDelete),
+		})
+	}
+
 	// Confirmation (AC-04)
 	if !rmYes && !IsQuiet() {
+		if len(toDelete) > 1 {
+			fmt.Println("Records to delete:")
+			printRecordIDList(toDelete)
+		}
 		fmt.Printf("Delete %d record(s)? [y/N]: ", len(toDelete))
 		var response string
 		fmt.Scanln(&response)
@@ -172,8 +247,9 @@ func runRm(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// collectAllChildren recursively collects all children of the given records.
-func collectAllChildren(store *storage.Store, stashName string, collected []*model.Record, parents []*model.Record) ([]*model.Record, error) {
+// collectAllChildren recursively collects all children of the given records,
+// at most levels levels below them (0 = unlimited).
+func collectAllChildren(store *storage.Store, stashName string, collected []*model.Record, parents []*model.Record, levels int) ([]*model.Record, error) {
 	for _, parent := range parents {
 		children, err := store.GetChildren(stashName, parent.ID)
 		if err != nil {
@@ -181,7 +257,10 @@ func collectAllChildren(store *storage.Store, stashName string, collected []*mod
 		}
 		if len(children) > 0 {
 			collected = append(collected, children...)
-			collected, err = collectAllChildren(store, stashName, collected, children)
+			if levels == 1 {
+				continue
+			}
+			collected, err = collectAllChildren(store, stashName, collected, children, nextCascadeLevels(levels))
 			if err != nil {
 				return nil, err
 			}
@@ -190,6 +269,22 @@ func collectAllChildren(store *storage.Store, stashName string, collected []*mod
 	return collected, nil
 }
 
+// nextCascadeLevels returns the levels left to descend one level further
+// down (0 = unlimited).
+func nextCascadeLevels(levels int) int {
+	if levels > 0 {
+		return levels - 1
+	}
+	return 0
+}
+
+// printRecordIDList prints record IDs as an indented list.
+func printRecordIDList(records []*model.Record) {
+	for _, rec := range records {
+		fmt.Printf("  - %s\n", rec.ID)
+	}
+}
+
 // getRecordIDs extracts IDs from a list of records.
 func getRecordIDs(records []*model.Record) []string {
 	ids := make([]string, len(records))
@@ -368,3 +368,66 @@ func TestUC_REC_005_RejectNonExistent(t *testing.T) {
 		}
 	})
 }
+
+// TestRmCascadeDepth tests --depth and --dry-run on cascade deletes
+func TestRmCascadeDepth(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		var root map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "add", "Laptop", "--json")), &root)
+		rootID := root["_id"].(string)
+		captureSchemaOutput(t, "add", "Battery", "--parent", rootID)
+		captureSchemaOutput(t, "add", "Cell", "--parent", rootID+".1")
+		ExitCode = 0
+		return rootID, cleanup
+	}
+
+	rmResult := func(t *testing.T, args ...string) map[string]interface{} {
+		t.Helper()
+		output := captureSchemaOutput(t, append([]string{"rm", "--json", "--yes"}, args...)...)
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		return result
+	}
+
+	t.Run("dry run lists the affected set without deleting", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		result := rmResult(t, rootID, "--cascade", "--dry-run")
+		if result["would_delete"] != float64(3) || result["dry_run"] != true {
+			t.Errorf("expected 3 records in dry run, got %v", result)
+		}
+
+		output := captureSchemaOutput(t, "list", "--all", "--json")
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(output), &records)
+		if len(records) != 3 {
+			t.Errorf("expected dry run to delete nothing, got %d records left", len(records))
+		}
+	})
+
+	t.Run("depth limits the cascade", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		result := rmResult(t, rootID, "--cascade", "--depth", "1")
+		ids, _ := result["ids"].([]interface{})
+		if len(ids) != 2 || ids[0] != rootID || ids[1] != rootID+".1" {
+			t.Errorf("expected record and direct child deleted, got %v", result)
+		}
+	})
+
+	t.Run("depth requires cascade", func(t *testing.T) {
+		rootID, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "rm", rootID, "--depth", "1", "--yes")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -2,10 +2,17 @@
 package cli
 
 import (
+	gocontext "context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/logging"
+	"github.com/user/stash/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Global flags
@@ -13,11 +20,53 @@ var (
 	jsonOutput bool
 	stashName  string
 	actorName  string
+	sourceName string
+	runID      string
+	txFlag     string
 	quiet      bool
 	verbose    bool
 	noDaemon   bool
+	porcelain  bool
+	noColor    bool
+	wide       bool
+	stashDir   string
+	logLevel   string
+	logFile    string
+
+	commandTimeout time.Duration
+)
+
+// closeLog closes the log file opened for the current command, if any.
+var closeLog = func() error { return nil }
+
+// endTelemetry ends the current command's span with its exit code and
+// flushes telemetry, if it is on.
+var endTelemetry = func(code int) {}
+
+// telemetryFlushTimeout bounds how long a command waits at exit for spans
+// and metrics to reach the collector.
+const telemetryFlushTimeout = 5 * time.Second
+
+// commandCtx is the context of the running command, done once its
+// --timeout (commandLimit) passes. endTimeout releases it when the command
+// returns.
+var (
+	commandCtx   = gocontext.Background()
+	commandLimit time.Duration
+	endTimeout   = func() {}
 )
 
+// timeoutGrace is how long a command may run past its --timeout, finishing
+// a write or reporting the error, before the process is stopped.
+const timeoutGrace = 5 * time.Second
+
+// untimedCommands lists the commands --timeout does not apply to: those
+// that run until stopped, and those with a --timeout of their own. The
+// commands run by shell and exec are timed one by one.
+var untimedCommands = map[string]bool{
+	"daemon": true, "exec": true, "lock": true, "shell": true, "wait": true,
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "stash",
@@ -35,28 +84,197 @@ Features:
   - Hierarchical records: Parent-child relationships with dot notation IDs
   - Dual storage: JSONL source of truth + SQLite cache for queries
   - Full audit trail: Track who created/modified records and when
-  - Agent-native: JSON output, context injection, conversational commands`,
+  - Agent-native: JSON output, context injection, conversational commands
+
+Stash looks for the nearest .stash directory in the current directory or
+its parents, like git looks for .git, so commands work from anywhere in a
+project. Use --dir to pick a directory instead, and 'stash root' to see
+which .stash is in use.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		context.SetDir(stashDir)
+		resetHookState()
+		if err := setupLogging(cmd, args); err != nil {
+			return err
+		}
+		if err := setupTimeout(cmd); err != nil {
+			return err
+		}
+		if err := checkTxCommand(cmd); err != nil {
+			return err
+		}
+		return setupTelemetry(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	endTimeout()
+	if err != nil {
+		if exitWriteRefused(err) || exitTimedOut(err) || exitQueryTooLarge(err) {
+			return
+		}
 		fmt.Fprintln(os.Stderr, err)
+		endTelemetry(1)
 		os.Exit(1)
 	}
+	endTelemetry(0)
 }
 
 func init() {
 	// Global flags available to all commands
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (for agent parsing)")
-	rootCmd.PersistentFlags().StringVar(&stashName, "stash", "", "Target specific stash (default: auto-detect or $STASH_DEFAULT)")
-	rootCmd.PersistentFlags().StringVar(&actorName, "actor", "", "Override actor for audit trail (default: $STASH_ACTOR or $USER)")
+	rootCmd.PersistentFlags().StringVar(&stashName, "stash", "", "Target specific stash, or root:stash for a registered directory (default: auto-detect or $STASH_DEFAULT)")
+	rootCmd.PersistentFlags().StringVar(&stashDir, "dir", "", "Use the .stash directory in this path instead of searching parent directories")
+	rootCmd.PersistentFlags().StringVar(&actorName, "actor", "", "Override actor for audit trail (default: $STASH_ACTOR, configured actor, git user, or OS user)")
+	rootCmd.PersistentFlags().StringVar(&sourceName, "source", "", "Record where written values came from, e.g. a file or URL (also: $STASH_SOURCE)")
+	rootCmd.PersistentFlags().StringVar(&runID, "run-id", "", "Tag written operations with an agent run, to review or undo together (also: $STASH_RUN_ID)")
+	rootCmd.PersistentFlags().StringVar(&txFlag, "tx", "", "Stage writes in a transaction begun with 'stash tx begin' (also: $STASH_TX)")
 	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Suppress non-essential output")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable debug output")
 	rootCmd.PersistentFlags().BoolVar(&noDaemon, "no-daemon", false, "Bypass daemon, direct file access")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also: NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&wide, "wide", false, "Do not truncate table columns to fit the terminal")
+	rootCmd.PersistentFlags().BoolVar(&porcelain, "porcelain", false, "Stable tab-separated output for scripts (see 'stash help-topic porcelain')")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log diagnostic events: error, warn, info, debug, or trace (also: $STASH_LOG_LEVEL)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append diagnostic events to this file as JSON lines (also: $STASH_LOG_FILE)")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Cancel the command if it runs longer than this, e.g. 30s (also: $STASH_TIMEOUT)")
+}
+
+// setupTimeout starts the deadline of a command from --timeout or
+// $STASH_TIMEOUT. Once it passes, the command's reads of the cache and
+// logs fail and no new write is started (see Store.SetContext). A command
+// stuck in IO that cannot be interrupted is stopped timeoutGrace later.
+func setupTimeout(cmd *cobra.Command) error {
+	endTimeout()
+	commandCtx, commandLimit, endTimeout = gocontext.Background(), 0, func() {}
+
+	top := cmd
+	for top.HasParent() && top.Parent().HasParent() {
+		top = top.Parent()
+	}
+	if untimedCommands[top.Name()] {
+		return nil
+	}
+	limit := commandTimeout
+	if env := os.Getenv("STASH_TIMEOUT"); limit == 0 && env != "" {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return fmt.Errorf("invalid STASH_TIMEOUT %q: %w", env, err)
+		}
+		limit = d
+	}
+	if limit < 0 {
+		return fmt.Errorf("invalid timeout %s: must not be negative", limit)
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), limit)
+	stop := time.AfterFunc(limit+timeoutGrace, func() {
+		// Exits even inside a session, which keeps Exit from exiting
+		fmt.Fprintf(os.Stderr, "Error: timed out after %s and did not stop; exiting\n", limit)
+		endTelemetry(exitTimeout)
+		os.Exit(exitTimeout)
+	})
+	commandCtx, commandLimit = ctx, limit
+	endTimeout = func() {
+		stop.Stop()
+		cancel()
+		commandCtx, endTimeout = gocontext.Background(), func() {}
+	}
+	return nil
+}
+
+// commandContext returns the context of the running command, which is
+// done once its --timeout passes.
+func commandContext() gocontext.Context {
+	return commandCtx
+}
+
+// exitTimeout is the exit code of a command stopped by --timeout.
+const exitTimeout = 8
+
+// exitTimedOut reports a command stopped by its --timeout and exits 8.
+// Returns true if err is from the command's deadline passing.
+func exitTimedOut(err error) bool {
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		return false
+	}
+	ExitWithError(exitTimeout, ErrCodeTimeout, fmt.Sprintf("timed out after %s (see --timeout)", commandLimit),
+		map[string]interface{}{"timeout": commandLimit.String(), "error": err.Error()})
+	return true
+}
+
+// setupLogging starts the diagnostic log for a command from --log-level
+// and --log-file, or their environment variables (see 'stash help-topic
+// logging').
+func setupLogging(cmd *cobra.Command, args []string) error {
+	closeLog()
+	closeLog = func() error { return nil }
+
+	name, path := logLevel, logFile
+	if name == "" {
+		name = os.Getenv("STASH_LOG_LEVEL")
+	}
+	if path == "" {
+		path = os.Getenv("STASH_LOG_FILE")
+	}
+	if name == "" && path != "" {
+		name = "debug"
+	}
+	level, err := logging.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	if level == logging.LevelOff {
+		logging.Disable()
+		return nil
+	}
+
+	closeFile, err := logging.SetupFile(level, path)
+	if err != nil {
+		return err
+	}
+	closeLog = closeFile
+	logging.Debug("command", "command", cmd.CommandPath(), "args", args, "version", Version)
+	return nil
+}
+
+// setupTelemetry starts OpenTelemetry tracing and metrics for a command
+// when the OTEL_* environment variables configure them (see 'stash
+// help-topic telemetry'). The command is the root span of its trace.
+func setupTelemetry(cmd *cobra.Command) error {
+	endTelemetry(0)
+	shutdown, err := telemetry.Setup(gocontext.Background(), Version)
+	if err != nil {
+		return err
+	}
+	if !telemetry.Enabled() {
+		return nil
+	}
+
+	span := telemetry.Start(cmd.CommandPath(), attribute.String("stash.command", cmd.CommandPath()))
+	endTelemetry = func(code int) {
+		endTelemetry = func(int) {}
+		var err error
+		if code != 0 {
+			err = fmt.Errorf("exit status %d", code)
+		}
+		span.SetAttributes(attribute.Int("process.exit.code", code))
+		span.End(&err)
+
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), telemetryFlushTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logging.Debug("telemetry flush", "error", err)
+		}
+	}
+	return nil
 }
 
 // ExitCode is used to communicate exit codes for testing
@@ -69,6 +287,7 @@ var ExitFunc = os.Exit
 // Exit sets the exit code and calls the exit function
 func Exit(code int) {
 	ExitCode = code
+	endTelemetry(code)
 	ExitFunc(code)
 }
 
@@ -87,11 +306,52 @@ func GetActorName() string {
 	return actorName
 }
 
+// GetSource returns where the values written by this command came from,
+// from --source or $STASH_SOURCE, or "" if neither is set.
+func GetSource() string {
+	if sourceName != "" {
+		return sourceName
+	}
+	return os.Getenv("STASH_SOURCE")
+}
+
+// GetRunID returns the agent run this command's operations belong to,
+// from --run-id or $STASH_RUN_ID, or "" if neither is set.
+func GetRunID() string {
+	if runID != "" {
+		return runID
+	}
+	return os.Getenv("STASH_RUN_ID")
+}
+
+// GetTx returns the token of the transaction this command's writes are
+// staged in, from --tx or $STASH_TX, or "" if neither is set.
+func GetTx() string {
+	if txFlag != "" {
+		return txFlag
+	}
+	return os.Getenv("STASH_TX")
+}
+
 // IsQuiet returns whether quiet mode is enabled
 func IsQuiet() bool {
 	return quiet
 }
 
+// IsPorcelain returns whether porcelain output is enabled
+func IsPorcelain() bool {
+	return porcelain
+}
+
+// Infof prints an informational line, such as a result count, that --quiet
+// and --porcelain suppress. Data output is printed directly.
+func Infof(format string, args ...interface{}) {
+	if IsQuiet() || IsPorcelain() {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
 // IsVerbose returns whether verbose mode is enabled
 func IsVerbose() bool {
 	return verbose
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogging(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+	defer cleanup()
+	defer setupLogging(rootCmd, nil)
+
+	logPath := filepath.Join(tempDir, "stash.log")
+	id := strings.TrimSpace(captureSchemaOutput(t, "add", "Write docs", "--log-file", logPath))
+	captureSchemaOutput(t, "list", "--log-file", logPath, "--log-level", "trace")
+
+	// Another agent's lock is reported as the reason a write is refused
+	captureSchemaOutput(t, "lock", id, "--actor", "alice")
+	captureSchemaOutput(t, "set", id, "Name=Other", "--actor", "bob", "--log-file", logPath)
+	ExitCode = 0
+	closeLog()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	log := string(data)
+	for _, want := range []string{
+		`"msg":"command","command":"stash add"`,
+		`"msg":"jsonl append","stash":"tasks","id":"` + id + `","op":"create"`,
+		`"level":"TRACE","msg":"sql","query":"SELECT`,
+		`"msg":"lock check","stash":"tasks","id":"` + id + `","actor":"bob","decision":"blocked","locked_by":"alice"`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("expected the log to contain %s, got:\n%s", want, log)
+		}
+	}
+	if strings.Count(log, `"msg":"sql"`) == 0 || strings.Contains(strings.SplitN(log, `"command":"stash list"`, 2)[0], `"msg":"sql"`) {
+		t.Errorf("expected SQL to be traced only at trace level")
+	}
+
+	t.Run("invalid level", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"list", "--log-level", "loud"})
+		err := rootCmd.Execute()
+		resetFlags()
+		if err == nil || !strings.Contains(err.Error(), "invalid log level") {
+			t.Errorf("expected an invalid log level error, got %v", err)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		before, _ := os.Stat(logPath)
+		captureSchemaOutput(t, "list")
+		after, _ := os.Stat(logPath)
+		if after.Size() != before.Size() {
+			t.Errorf("expected nothing logged without --log-file")
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+	defer cleanup()
+	captureSchemaOutput(t, "add", "Write docs")
+
+	run := func(args ...string) error {
+		t.Helper()
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		resetFlags()
+		return err
+	}
+
+	if err := run("list", "--timeout", "1ns"); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Errorf("expected the deadline to stop list, got %v", err)
+	}
+	if err := run("list", "--timeout", "1m"); err != nil {
+		t.Errorf("expected list to finish within its timeout, got %v", err)
+	}
+
+	t.Setenv("STASH_TIMEOUT", "1ns")
+	if err := run("count"); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Errorf("expected $STASH_TIMEOUT to stop count, got %v", err)
+	}
+	if err := run("count", "--timeout", "1m"); err != nil {
+		t.Errorf("expected --timeout to override $STASH_TIMEOUT, got %v", err)
+	}
+	// wait has its own --timeout, in seconds
+	if err := run("wait", "--timeout", "1", "--where", "Name=Write docs"); err != nil {
+		t.Errorf("expected wait to ignore $STASH_TIMEOUT, got %v", err)
+	}
+	t.Setenv("STASH_TIMEOUT", "soon")
+	if err := run("count"); err == nil || !strings.Contains(err.Error(), "invalid STASH_TIMEOUT") {
+		t.Errorf("expected an invalid STASH_TIMEOUT error, got %v", err)
+	}
+
+	t.Run("reported as exit 8", func(t *testing.T) {
+		commandLimit = time.Second
+		exitTimedOut(gocontext.DeadlineExceeded)
+		if ExitCode != exitTimeout {
+			t.Errorf("expected exit code %d, got %d", exitTimeout, ExitCode)
+		}
+		ExitCode = 0
+	})
+}
+
+func TestTelemetry(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+	defer cleanup()
+
+	// The console exporter writes spans and metrics to stderr
+	outPath := filepath.Join(tempDir, "telemetry.json")
+	out, err := os.Create(outPath)
+	if err != nil {
+		t.Fatalf("failed to create output file: %v", err)
+	}
+	oldStderr := os.Stderr
+	os.Stderr = out
+	t.Setenv("OTEL_TRACES_EXPORTER", "console")
+	t.Setenv("OTEL_METRICS_EXPORTER", "console")
+	captureSchemaOutput(t, "add", "Write docs")
+	endTelemetry(0)
+	os.Stderr = oldStderr
+	out.Close()
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	type span struct {
+		Name        string
+		SpanContext struct{ SpanID string }
+		Parent      struct{ SpanID string }
+	}
+	spans := make(map[string]span)
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	for dec.More() {
+		var s span
+		if err := dec.Decode(&s); err != nil {
+			t.Fatalf("failed to parse output: %v", err)
+		}
+		if s.Name != "" {
+			spans[s.Name] = s
+		}
+	}
+
+	root, ok := spans["stash add"]
+	if !ok {
+		t.Fatalf("expected a span for the command, got:\n%s", data)
+	}
+	for _, name := range []string{"Store.CreateRecord", "jsonl.append", "sqlite.query"} {
+		if _, ok := spans[name]; !ok {
+			t.Errorf("expected a %s span", name)
+		}
+	}
+	if spans["Store.CreateRecord"].Parent.SpanID != root.SpanContext.SpanID {
+		t.Errorf("expected Store.CreateRecord within the command span")
+	}
+	if spans["jsonl.append"].Parent.SpanID != spans["Store.CreateRecord"].SpanContext.SpanID {
+		t.Errorf("expected jsonl.append within Store.CreateRecord")
+	}
+	if !strings.Contains(string(data), `"Name":"stash.records.written"`) {
+		t.Errorf("expected the records written counter to be exported")
+	}
+}
@@ -0,0 +1,72 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+)
+
+var rootDirCmd = &cobra.Command{
+	Use:   "root",
+	Short: "Print the .stash directory in use",
+	Long: `Print the absolute path of the .stash directory that commands use.
+
+Stash looks for the nearest .stash in the current directory or its
+parents, so running from a subdirectory of a project uses the project's
+.stash. A project nested in another can keep its own .stash, which wins
+for commands run inside it. With --dir, the .stash directory in that path
+is used and parents are not searched.
+
+Examples:
+  stash root
+  stash root --dir ~/notes
+  cd "$(dirname "$(stash root)")"
+
+AI Agent Examples:
+  # Check which stash directory a script will write to
+  stash root --json | jq -r '.stash_dir'
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found
+
+JSON Output (--json):
+  {"stash_dir": "/home/me/project/.stash", "root": "/home/me/project"}`,
+	Args: cobra.NoArgs,
+	RunE: runRootDir,
+}
+
+func init() {
+	rootCmd.AddCommand(rootDirCmd)
+}
+
+func runRootDir(cmd *cobra.Command, args []string) error {
+	dir := context.FindStashDir()
+	if dir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	if GetJSONOutput() {
+		data, err := json.Marshal(map[string]string{
+			"stash_dir": abs,
+			"root":      filepath.Dir(abs),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println(abs)
+	return nil
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootDir(t *testing.T) {
+	t.Run("finds .stash from a subdirectory", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		subDir := filepath.Join(tempDir, "src", "pkg")
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.Chdir(subDir)
+
+		output := strings.TrimSpace(captureSchemaOutput(t, "root"))
+		if output != filepath.Join(tempDir, ".stash") {
+			t.Errorf("expected %s, got %s", filepath.Join(tempDir, ".stash"), output)
+		}
+
+		// Commands run from the subdirectory use the project's stash
+		captureSchemaOutput(t, "add", "Laptop")
+		if ExitCode != 0 {
+			t.Fatalf("expected add to succeed from a subdirectory, got exit %d", ExitCode)
+		}
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--json")), &records)
+		if len(records) != 1 {
+			t.Errorf("expected 1 record, got %d", len(records))
+		}
+	})
+
+	t.Run("no .stash directory", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "root")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+
+	t.Run("init and use a stash in --dir", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		other := filepath.Join(tempDir, "elsewhere", "notes")
+
+		captureSchemaOutput(t, "init", "notes", "--prefix", "nt-", "--dir", other)
+		if ExitCode != 0 {
+			t.Fatalf("expected init --dir to succeed, got exit %d", ExitCode)
+		}
+		if _, err := os.Stat(filepath.Join(other, ".stash", "notes", "config.json")); err != nil {
+			t.Errorf("expected the stash to be created in --dir: %v", err)
+		}
+
+		var result map[string]string
+		json.Unmarshal([]byte(captureSchemaOutput(t, "root", "--dir", other, "--json")), &result)
+		if result["stash_dir"] != filepath.Join(other, ".stash") || result["root"] != other {
+			t.Errorf("unexpected root output: %v", result)
+		}
+
+		// --dir may also name the .stash directory itself, and only its
+		// stashes are visible
+		var info struct {
+			Stashes []map[string]interface{} `json:"stashes"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "info", "--dir", filepath.Join(other, ".stash"), "--json")), &info)
+		if len(info.Stashes) != 1 || info.Stashes[0]["name"] != "notes" {
+			t.Errorf("expected only the notes stash, got %v", info.Stashes)
+		}
+
+		// Without --dir the project's .stash is used again
+		output := strings.TrimSpace(captureSchemaOutput(t, "root"))
+		if output != filepath.Join(tempDir, ".stash") {
+			t.Errorf("expected %s, got %s", filepath.Join(tempDir, ".stash"), output)
+		}
+	})
+
+	t.Run("--dir without a .stash is not searched upward", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+		empty := filepath.Join(tempDir, "empty")
+		os.Mkdir(empty, 0755)
+
+		captureSchemaOutput(t, "root", "--dir", empty)
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,286 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	ruleWhen     string
+	ruleSeverity string
+)
+
+var ruleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Show or manage conditional validation rules",
+	Long: `Show the conditional validation rules of a stash.
+
+A rule makes a column required in records where a condition holds, such as
+Ship_Date being required when Status=shipped. Column constraints ('stash
+column add --required') apply to every record; rules express invariants
+that depend on another field.
+
+Conditions:
+  Column=value    The column has the value (for a list, any element)
+  Column!=value   The column does not have the value
+  Column=         The column is unset
+  Column!=        The column is set
+
+Rules are checked by add and set, and by 'stash validate'. A set only
+checks the rules involving the columns it changes, so editing an unrelated
+field of a legacy record is not blocked. Rules with --severity warning are
+reported but do not reject writes.
+
+Rules are stored in the stash's config.json.
+
+Examples:
+  stash rule
+  stash rule add Ship_Date --when "Status=shipped"
+  stash rule remove Ship_Date --when "Status=shipped"
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+
+JSON Output (--json):
+  {"stash": "orders", "rules": [{"require": "Ship_Date", "when": "Status=shipped"}]}`,
+	Args: cobra.NoArgs,
+	RunE: runRule,
+}
+
+var ruleAddCmd = &cobra.Command{
+	Use:   "add <column> --when <condition>",
+	Short: "Require a column when a condition holds",
+	Long: `Add a rule requiring a column to have a value in records where the
+--when condition holds. Adding a rule for the same column and condition
+replaces it, so the severity can be changed.
+
+Existing records are not checked when the rule is added; run 'stash
+validate' to find the ones that break it.
+
+Examples:
+  stash rule add Ship_Date --when "Status=shipped"
+  stash rule add Tracking --when "Carrier!=" --severity warning
+  stash rule add Reason --when "Status=cancelled" --json
+
+AI Agent Examples:
+  # Add a rule, then list the records that already break it
+  stash rule add Ship_Date --when "Status=shipped"
+  stash validate --json | jq '.errors[] | select(.rule == "required_when")'
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (invalid condition or severity, value not in the
+     column's enum, computed column)
+
+JSON Output (--json):
+  {"stash": "orders", "rule": {"require": "Ship_Date", "when": "Status=shipped"}}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRuleAdd,
+}
+
+var ruleRemoveCmd = &cobra.Command{
+	Use:   "remove <column> --when <condition>",
+	Short: "Remove a conditional validation rule",
+	Long: `Remove the rule requiring a column under a condition.
+
+Examples:
+  stash rule remove Ship_Date --when "Status=shipped"
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or no such rule
+  2  Validation error (missing --when)
+
+JSON Output (--json):
+  {"stash": "orders", "removed": {"require": "Ship_Date", "when": "Status=shipped"}}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRuleRemove,
+}
+
+func init() {
+	ruleAddCmd.Flags().StringVar(&ruleWhen, "when", "", "Condition: Column=value or Column!=value")
+	ruleAddCmd.Flags().StringVar(&ruleSeverity, "severity", "", "Violation severity: error (reject writes, default) or warning")
+	ruleRemoveCmd.Flags().StringVar(&ruleWhen, "when", "", "Condition of the rule to remove")
+	ruleCmd.AddCommand(ruleAddCmd)
+	ruleCmd.AddCommand(ruleRemoveCmd)
+	rootCmd.AddCommand(ruleCmd)
+}
+
+func runRule(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Output result
+	if GetJSONOutput() {
+		rules := stash.Rules
+		if rules == nil {
+			rules = []model.Rule{}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "rules": rules})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(stash.Rules) == 0 {
+		fmt.Printf("Stash '%s' has no conditional rules\n", stash.Name)
+		return nil
+	}
+	fmt.Printf("Conditional rules for stash '%s':\n", stash.Name)
+	for _, rule := range stash.Rules {
+		fmt.Printf("  %s\n", describeRule(rule))
+	}
+	return nil
+}
+
+// resolveRule checks a rule's columns and condition against the stash and
+// returns it with the columns' actual names. Returns false after reporting
+// an error.
+func resolveRule(stash *model.Stash, require, when string) (model.Rule, bool) {
+	if strings.TrimSpace(when) == "" {
+		ExitValidationError("--when is required (e.g. --when \"Status=shipped\")", nil)
+		return model.Rule{}, false
+	}
+	cond, err := model.ParseCondition(when)
+	if err != nil {
+		ExitValidationError(err.Error(), map[string]interface{}{"when": when})
+		return model.Rule{}, false
+	}
+
+	col := resolveColumn(stash, require)
+	if col == nil {
+		return model.Rule{}, false
+	}
+	condCol := resolveColumn(stash, cond.Column)
+	if condCol == nil {
+		return model.Rule{}, false
+	}
+	cond.Column = condCol.Name
+	return model.Rule{Require: col.Name, When: cond.String()}, true
+}
+
+func runRuleAdd(cmd *cobra.Command, args []string) error {
+	severity := model.SeverityError
+	if ruleSeverity != "" {
+		var ok bool
+		if severity, ok = model.ParseSeverity(ruleSeverity); !ok {
+			ExitValidationError(fmt.Sprintf("invalid severity '%s' (valid: error, warning)", ruleSeverity),
+				map[string]interface{}{"severity": ruleSeverity})
+			return nil
+		}
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	rule, ok := resolveRule(stash, args[0], ruleWhen)
+	if !ok {
+		return nil
+	}
+
+	// Computed values are not stored, so they can neither be required nor
+	// tested by a condition
+	cond, _ := model.ParseCondition(rule.When)
+	for _, name := range []string{rule.Require, cond.Column} {
+		if col := stash.Columns.Find(name); col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be used in a rule", col.Name),
+				map[string]interface{}{"column": col.Name})
+			return nil
+		}
+	}
+	if rule.Require == cond.Column {
+		ExitValidationError("a rule cannot require the column its condition tests",
+			map[string]interface{}{"column": rule.Require})
+		return nil
+	}
+
+	// Catch typos in the condition value of an enum column
+	if col := stash.Columns.Find(cond.Column); len(col.Enum) > 0 && cond.Value != "" && !containsValue(col.Enum, cond.Value) {
+		ExitValidationError(fmt.Sprintf("'%s' is not an allowed value of column '%s' (allowed: %s)",
+			cond.Value, col.Name, strings.Join(col.Enum, ", ")),
+			map[string]interface{}{"column": col.Name, "value": cond.Value, "allowed": col.Enum})
+		return nil
+	}
+
+	if severity == model.SeverityWarning {
+		rule.Severity = model.SeverityWarning
+	}
+	stash.SetRule(rule)
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update rules: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "rule": rule})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Added rule to stash '%s': %s\n", stash.Name, describeRule(rule))
+	}
+	return nil
+}
+
+func runRuleRemove(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	rule, ok := resolveRule(stash, args[0], ruleWhen)
+	if !ok {
+		return nil
+	}
+	if !stash.RemoveRule(rule.Require, rule.When) {
+		ExitWithError(1, ErrCodeValidation, fmt.Sprintf("no rule requires '%s' when %s", rule.Require, rule.When),
+			map[string]interface{}{"require": rule.Require, "when": rule.When})
+		return nil
+	}
+	if err := store.UpdateStashConfig(stash); err != nil {
+		return fmt.Errorf("failed to update rules: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "removed": rule})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Removed rule from stash '%s': %s\n", stash.Name, describeRule(rule))
+	}
+	return nil
+}
+
+// containsValue reports whether values contains v.
+func containsValue(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// describeRule formats a rule for display.
+func describeRule(rule model.Rule) string {
+	desc := fmt.Sprintf("%s required when %s", rule.Require, rule.When)
+	if rule.ViolationSeverity() == model.SeverityWarning {
+		desc += " (warning)"
+	}
+	return desc
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRule(t *testing.T) {
+	setup := func(t *testing.T) func() {
+		_, cleanup := setupTestStashWithColumns(t, "orders", "ord-", []string{"Name", "Ship_Date", "Carrier", "Tracking"})
+		captureSchemaOutput(t, "column", "add", "Status", "--enum", "pending,shipped,cancelled")
+		captureSchemaOutput(t, "rule", "add", "Ship_Date", "--when", "status=shipped")
+		if ExitCode != 0 {
+			t.Fatalf("failed to add rule, exit %d", ExitCode)
+		}
+		return cleanup
+	}
+
+	t.Run("rules are stored with column names", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "rule", "add", "Tracking", "--when", "Carrier!=", "--severity", "warn")
+
+		var result struct {
+			Rules []map[string]interface{} `json:"rules"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "rule", "--json")), &result)
+		if len(result.Rules) != 2 || result.Rules[0]["when"] != "Status=shipped" || result.Rules[1]["severity"] != "warning" {
+			t.Errorf("unexpected rules: %v", result.Rules)
+		}
+
+		captureSchemaOutput(t, "rule", "remove", "Tracking", "--when", "Carrier!=")
+		json.Unmarshal([]byte(captureSchemaOutput(t, "rule", "--json")), &result)
+		if len(result.Rules) != 1 {
+			t.Errorf("expected 1 rule after remove, got %v", result.Rules)
+		}
+	})
+
+	t.Run("add and set enforce rules", func(t *testing.T) {
+		defer setup(t)()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "add", "Order 1", "--set", "Status=shipped")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a shipped order without Ship_Date, got %d", ExitCode)
+		}
+		ExitCode = 0
+
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Order 1", "--set", "Status=pending"))
+		output := captureSchemaOutput(t, "set", id, "Status=shipped", "--json")
+		if ExitCode != 2 || !strings.Contains(output, "required_when") {
+			t.Errorf("expected a required_when error, got exit %d: %s", ExitCode, output)
+		}
+		ExitCode = 0
+
+		captureSchemaOutput(t, "set", id, "Status=shipped", "Ship_Date=2025-01-08")
+		if ExitCode != 0 {
+			t.Errorf("expected set with Ship_Date to succeed, got exit %d", ExitCode)
+		}
+		captureSchemaOutput(t, "set", id, "Ship_Date=")
+		if ExitCode != 2 {
+			t.Errorf("expected clearing Ship_Date to fail, got exit %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("set only checks rules it touches", func(t *testing.T) {
+		defer setup(t)()
+		id := strings.TrimSpace(captureSchemaOutput(t, "add", "Order 1"))
+		// A record that broke the rule before it was added
+		captureSchemaOutput(t, "rule", "remove", "Ship_Date", "--when", "Status=shipped")
+		captureSchemaOutput(t, "set", id, "Status=shipped")
+		captureSchemaOutput(t, "rule", "add", "Ship_Date", "--when", "Status=shipped")
+
+		captureSchemaOutput(t, "set", id, "Carrier=ups")
+		if ExitCode != 0 {
+			t.Errorf("expected an unrelated set to succeed, got exit %d", ExitCode)
+		}
+
+		ExitCode = 0
+		var result ValidateStashOutput
+		json.Unmarshal([]byte(captureSchemaOutput(t, "validate", "--json")), &result)
+		if result.ErrorCount != 1 || result.Errors[0].Rule != "required_when" || result.Errors[0].RecordID != id {
+			t.Errorf("expected validate to report the rule, got %+v", result)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("warning rules accept writes", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "rule", "add", "Tracking", "--when", "Carrier!=", "--severity", "warning")
+
+		ExitCode = 0
+		stderr := captureStderr(t, func() {
+			captureSchemaOutput(t, "add", "Order 1", "--set", "Carrier=ups")
+		})
+		if ExitCode != 0 || !strings.Contains(stderr, "Tracking") {
+			t.Errorf("expected a warning (exit %d): %q", ExitCode, stderr)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "column", "add", "Total", "--computed", "1")
+
+		for _, tc := range []struct {
+			args []string
+			code int
+		}{
+			{[]string{"rule", "add", "Ship_Date"}, 2},
+			{[]string{"rule", "add", "Ship_Date", "--when", "Status"}, 2},
+			{[]string{"rule", "add", "Ship_Date", "--when", "Status=shiped"}, 2},
+			{[]string{"rule", "add", "Ship_Date", "--when", "Ship_Date!="}, 2},
+			{[]string{"rule", "add", "Total", "--when", "Status=shipped"}, 2},
+			{[]string{"rule", "add", "Ship_Date", "--when", "Status=shipped", "--severity", "info"}, 2},
+			{[]string{"rule", "add", "Nope", "--when", "Status=shipped"}, 1},
+			{[]string{"rule", "remove", "Carrier", "--when", "Status=cancelled"}, 1},
+		} {
+			ExitCode = 0
+			captureSchemaOutput(t, tc.args...)
+			if ExitCode != tc.code {
+				t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.code, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
@@ -0,0 +1,673 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	schemaYAML   bool
+	schemaDryRun bool
+)
+
+// Schema is the portable column set of a stash, without any records.
+type Schema struct {
+	Stash   string         `json:"stash" yaml:"stash"`
+	Prefix  string         `json:"prefix" yaml:"prefix"`
+	Columns []SchemaColumn `json:"columns" yaml:"columns"`
+}
+
+// SchemaColumn is a column definition as it appears in a schema file.
+type SchemaColumn struct {
+	Name        string              `json:"name" yaml:"name"`
+	Desc        string              `json:"desc,omitempty" yaml:"desc,omitempty"`
+	Validate    string              `json:"validate,omitempty" yaml:"validate,omitempty"`
+	Enum        []string            `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Required    bool                `json:"required,omitempty" yaml:"required,omitempty"`
+	Computed    string              `json:"computed,omitempty" yaml:"computed,omitempty"`
+	Due         bool                `json:"due,omitempty" yaml:"due,omitempty"`
+	Severity    string              `json:"severity,omitempty" yaml:"severity,omitempty"`
+	List        bool                `json:"list,omitempty" yaml:"list,omitempty"`
+	Coerce      bool                `json:"coerce,omitempty" yaml:"coerce,omitempty"`
+	Transitions map[string][]string `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+
+	Unit         string `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Decimals     *int   `json:"decimals,omitempty" yaml:"decimals,omitempty"`
+	ThousandsSep bool   `json:"thousands_sep,omitempty" yaml:"thousands_sep,omitempty"`
+}
+
+// Schema change actions reported by 'stash schema diff'
+const (
+	SchemaChangeAdd    = "add"
+	SchemaChangeUpdate = "update"
+)
+
+// SchemaChange is a single difference between a stash and a schema file.
+type SchemaChange struct {
+	Action string      `json:"action"`
+	Column string      `json:"column"`
+	Field  string      `json:"field,omitempty"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+// SchemaDiff describes what applying a schema file would change.
+type SchemaDiff struct {
+	Stash   string         `json:"stash"`
+	Changes []SchemaChange `json:"changes"`
+	Extra   []string       `json:"extra"` // Columns in the stash but not in the schema (kept)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Export and apply stash schemas",
+	Long: `Export a stash's column set to a file and apply it elsewhere.
+
+A schema file holds column names, descriptions, validation types, enums,
+required flags, computed expressions, due and list flags, and workflow
+transitions, but no records. Keep it in version control to replicate a stash's shape
+into new stashes or onto other machines.
+
+Examples:
+  stash schema export > tasks.schema.json
+  stash schema export --yaml > tasks.schema.yaml
+  stash schema diff tasks.schema.yaml
+  stash schema apply tasks.schema.yaml
+
+AI Agent Examples:
+  # Replicate a schema into a fresh stash
+  stash schema export --stash tasks > schema.json
+  stash init tasks2 --prefix tk-
+  stash schema apply schema.json --stash tasks2
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or file cannot be read
+  2  Validation error (invalid schema, unsupported change)
+
+JSON Output (--json):
+  schema export: {"stash": "tasks", "prefix": "tk-", "columns": [...]}
+  schema diff:   {"stash": "tasks", "changes": [...], "extra": [...]}
+  schema apply:  {"stash": "tasks", "applied": 2, "changes": [...], "extra": [...]}`,
+}
+
+var schemaExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the stash schema",
+	Long: `Print the stash's column set as JSON (default) or YAML.
+
+Examples:
+  stash schema export
+  stash schema export --yaml > schema.yaml
+
+Exit Codes:
+  0  Success
+  1  Stash not found`,
+	Args: cobra.NoArgs,
+	RunE: runSchemaExport,
+}
+
+var schemaDiffCmd = &cobra.Command{
+	Use:   "diff <file>",
+	Short: "Show what applying a schema file would change",
+	Long: `Compare a schema file (JSON or YAML) against the current stash.
+
+Output lines:
+  + Column               Column will be added
+  ~ Column.field: a -> b Column attribute will change
+  - Column               Column exists only in the stash (kept)
+
+Examples:
+  stash schema diff schema.yaml
+  stash schema diff schema.json --json
+
+Exit Codes:
+  0  Success (includes no changes)
+  1  Stash not found, or file cannot be read
+  2  Validation error (invalid schema, unsupported change)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSchemaDiff,
+}
+
+var schemaApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Apply a schema file to the stash",
+	Long: `Apply a schema file (JSON or YAML) to the current stash.
+
+Missing columns are added and attributes of existing columns are updated
+to match the file. Columns not present in the file are left in place;
+remove them with 'stash column drop'. The stash name and prefix in the
+file are informational and are not applied.
+
+Computed expressions cannot be changed on existing columns.
+
+Examples:
+  stash schema apply schema.yaml
+  stash schema apply schema.json --stash tasks2
+  stash schema apply schema.yaml --dry-run
+
+Exit Codes:
+  0  Success
+  1  Stash not found, or file cannot be read
+  2  Validation error (invalid schema, unsupported change)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSchemaApply,
+}
+
+func init() {
+	schemaExportCmd.Flags().BoolVar(&schemaYAML, "yaml", false, "Output as YAML")
+	schemaApplyCmd.Flags().BoolVar(&schemaDryRun, "dry-run", false, "Show changes without applying them")
+
+	schemaCmd.AddCommand(schemaExportCmd)
+	schemaCmd.AddCommand(schemaDiffCmd)
+	schemaCmd.AddCommand(schemaApplyCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchemaExport(cmd *cobra.Command, args []string) error {
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	schema := schemaFromStash(stash)
+
+	if schemaYAML {
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(schema); err != nil {
+			return fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		return enc.Close()
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	fmt.Println(string(data))
+
+	return nil
+}
+
+func runSchemaDiff(cmd *cobra.Command, args []string) error {
+	schema, ok := loadSchemaFile(args[0])
+	if !ok {
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	diff, err := diffSchema(stash, schema)
+	if err != nil {
+		ExitValidationError(err.Error(), nil)
+		return nil
+	}
+
+	return printSchemaDiff(diff, nil)
+}
+
+func runSchemaApply(cmd *cobra.Command, args []string) error {
+	schema, ok := loadSchemaFile(args[0])
+	if !ok {
+		return nil
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	diff, err := diffSchema(stash, schema)
+	if err != nil {
+		ExitValidationError(err.Error(), nil)
+		return nil
+	}
+
+	if stash.RequireDescriptions {
+		if names := undescribedAdditions(schema, diff); len(names) > 0 {
+			ExitValidationError(fmt.Sprintf("stash '%s' requires column descriptions; the schema adds columns without one: %s",
+				stash.Name, strings.Join(names, ", ")), map[string]interface{}{"columns": names})
+			return nil
+		}
+	}
+
+	if schemaDryRun {
+		return printSchemaDiff(diff, nil)
+	}
+
+	if err := applySchema(store, stash, schema, diff, ctx.Actor); err != nil {
+		return err
+	}
+
+	applied := len(diff.Changes)
+	return printSchemaDiff(diff, &applied)
+}
+
+// openSchemaStash resolves the current stash. Returns ok=false after
+// reporting an error; err is set only for unexpected failures.
+func openSchemaStash() (*context.Context, *storage.Store, *model.Stash, bool, error) {
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil, nil, nil, false, nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil, nil, nil, false, nil
+		}
+		return nil, nil, nil, false, fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		store.Close()
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil, nil, nil, false, nil
+		}
+		return nil, nil, nil, false, fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	return ctx, store, stash, true, nil
+}
+
+// loadSchemaFile reads and validates a JSON or YAML schema file.
+// Reports an error and returns false on failure.
+func loadSchemaFile(path string) (*Schema, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read schema file: %v\n", err)
+		Exit(1)
+		return nil, false
+	}
+
+	schema, err := parseSchema(data)
+	if err != nil {
+		ExitValidationError(fmt.Sprintf("invalid schema file: %v", err), map[string]interface{}{"file": path})
+		return nil, false
+	}
+
+	return schema, true
+}
+
+// parseSchema decodes a schema from JSON or YAML and validates it.
+func parseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	if err := validateSchema(&schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// validateSchema checks a schema with the same rules 'stash column add' applies.
+func validateSchema(schema *Schema) error {
+	seen := make(map[string]bool)
+	for i, col := range schema.Columns {
+		if err := model.ValidateColumnName(col.Name); err != nil {
+			return fmt.Errorf("column '%s': %v", col.Name, err)
+		}
+		key := strings.ToLower(col.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate column '%s'", col.Name)
+		}
+		seen[key] = true
+
+		if col.Validate != "" && !IsValidValidationType(col.Validate) {
+			return fmt.Errorf("column '%s': invalid validation type '%s' (valid types: %s)",
+				col.Name, col.Validate, strings.Join(ValidValidationTypes, ", "))
+		}
+		if col.Severity != "" {
+			severity, ok := model.ParseSeverity(col.Severity)
+			if !ok {
+				return fmt.Errorf("column '%s': invalid severity '%s' (valid: error, warning)", col.Name, col.Severity)
+			}
+			// Error is the default and is stored as no severity
+			if severity == model.SeverityError {
+				severity = ""
+			}
+			schema.Columns[i].Severity = severity
+		}
+		if col.Due && col.Validate != string(ValidationDate) {
+			return fmt.Errorf("column '%s': due columns require date validation", col.Name)
+		}
+		if col.Computed != "" {
+			if i == 0 {
+				return fmt.Errorf("column '%s': the first column cannot be computed", col.Name)
+			}
+			if col.Validate != "" || len(col.Enum) > 0 || col.Required || len(col.Transitions) > 0 {
+				return fmt.Errorf("column '%s': computed columns cannot have validate, enum, required, or transitions", col.Name)
+			}
+		}
+		if col.List && (col.Computed != "" || col.Due || len(col.Transitions) > 0) {
+			return fmt.Errorf("column '%s': list columns cannot be computed, due, or have transitions", col.Name)
+		}
+		if col.Decimals != nil && *col.Decimals < 0 {
+			return fmt.Errorf("column '%s': decimals must not be negative", col.Name)
+		}
+		if col.Coerce && len(col.Enum) == 0 {
+			return fmt.Errorf("column '%s': coerce requires an enum", col.Name)
+		}
+		if len(col.Transitions) > 0 {
+			if len(col.Enum) == 0 {
+				return fmt.Errorf("column '%s': transitions require an enum", col.Name)
+			}
+			allowed := make(map[string]bool, len(col.Enum))
+			for _, v := range col.Enum {
+				allowed[v] = true
+			}
+			for from, next := range col.Transitions {
+				for _, state := range append([]string{from}, next...) {
+					if !allowed[state] {
+						return fmt.Errorf("column '%s': unknown state '%s' in transitions", col.Name, state)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaFromStash builds a schema from a stash's current columns.
+func schemaFromStash(stash *model.Stash) *Schema {
+	schema := &Schema{
+		Stash:   stash.Name,
+		Prefix:  stash.Prefix,
+		Columns: make([]SchemaColumn, len(stash.Columns)),
+	}
+	for i, col := range stash.Columns {
+		schema.Columns[i] = schemaColumnFrom(col)
+	}
+	return schema
+}
+
+func schemaColumnFrom(col model.Column) SchemaColumn {
+	return SchemaColumn{
+		Name:        col.Name,
+		Desc:        col.Desc,
+		Validate:    col.Validate,
+		Enum:        col.Enum,
+		Required:    col.Required,
+		Computed:    col.Computed,
+		Due:         col.Due,
+		Severity:    col.Severity,
+		List:        col.List,
+		Coerce:      col.Coerce,
+		Transitions: col.Transitions,
+
+		Unit:         col.Unit,
+		Decimals:     col.Decimals,
+		ThousandsSep: col.ThousandsSep,
+	}
+}
+
+// diffSchema compares a stash with a schema. Returns an error if the schema
+// requires a change that cannot be applied to an existing column.
+func diffSchema(stash *model.Stash, schema *Schema) (*SchemaDiff, error) {
+	diff := &SchemaDiff{
+		Stash:   stash.Name,
+		Changes: []SchemaChange{},
+		Extra:   []string{},
+	}
+
+	inSchema := make(map[string]bool)
+	for _, want := range schema.Columns {
+		inSchema[strings.ToLower(want.Name)] = true
+
+		existing := stash.Columns.Find(want.Name)
+		if existing == nil {
+			diff.Changes = append(diff.Changes, SchemaChange{Action: SchemaChangeAdd, Column: want.Name})
+			continue
+		}
+
+		have := schemaColumnFrom(*existing)
+		if have.Computed != want.Computed {
+			return nil, fmt.Errorf("cannot change computed expression of existing column '%s'", existing.Name)
+		}
+
+		fields := []struct {
+			name     string
+			from, to interface{}
+			equal    bool
+		}{
+			{"desc", have.Desc, want.Desc, have.Desc == want.Desc},
+			{"validate", have.Validate, want.Validate, have.Validate == want.Validate},
+			{"enum", have.Enum, want.Enum, equalOrEmpty(have.Enum, want.Enum)},
+			{"required", have.Required, want.Required, have.Required == want.Required},
+			{"due", have.Due, want.Due, have.Due == want.Due},
+			{"severity", have.Severity, want.Severity, have.Severity == want.Severity},
+			{"list", have.List, want.List, have.List == want.List},
+			{"coerce", have.Coerce, want.Coerce, have.Coerce == want.Coerce},
+			{"transitions", have.Transitions, want.Transitions, equalOrEmpty(have.Transitions, want.Transitions)},
+			{"unit", have.Unit, want.Unit, have.Unit == want.Unit},
+			{"decimals", decimalsValue(have.Decimals), decimalsValue(want.Decimals), reflect.DeepEqual(have.Decimals, want.Decimals)},
+			{"thousands_sep", have.ThousandsSep, want.ThousandsSep, have.ThousandsSep == want.ThousandsSep},
+		}
+		for _, f := range fields {
+			if !f.equal {
+				diff.Changes = append(diff.Changes, SchemaChange{
+					Action: SchemaChangeUpdate,
+					Column: existing.Name,
+					Field:  f.name,
+					From:   f.from,
+					To:     f.to,
+				})
+			}
+		}
+	}
+
+	for _, col := range stash.Columns {
+		if !inSchema[strings.ToLower(col.Name)] {
+			diff.Extra = append(diff.Extra, col.Name)
+		}
+	}
+
+	return diff, nil
+}
+
+// undescribedAdditions returns the columns a schema diff would add that
+// have no description in the schema.
+func undescribedAdditions(schema *Schema, diff *SchemaDiff) []string {
+	var names []string
+	for _, change := range diff.Changes {
+		if change.Action != SchemaChangeAdd {
+			continue
+		}
+		for _, col := range schema.Columns {
+			if col.Name == change.Column && strings.TrimSpace(col.Desc) == "" {
+				names = append(names, col.Name)
+			}
+		}
+	}
+	return names
+}
+
+// decimalsValue returns a column's decimals for a schema diff, or nil if
+// it has none.
+func decimalsValue(decimals *int) interface{} {
+	if decimals == nil {
+		return nil
+	}
+	return *decimals
+}
+
+// equalOrEmpty compares two slices or maps, treating nil and empty as equal.
+func equalOrEmpty(a, b interface{}) bool {
+	if reflect.ValueOf(a).Len() == 0 && reflect.ValueOf(b).Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// applySchema updates existing columns, then adds missing ones in schema order.
+func applySchema(store *storage.Store, stash *model.Stash, schema *Schema, diff *SchemaDiff, actor string) error {
+	updated := false
+	for _, want := range schema.Columns {
+		existing := stash.Columns.Find(want.Name)
+		if existing == nil {
+			continue
+		}
+		if reflect.DeepEqual(schemaColumnFrom(*existing), want) {
+			continue
+		}
+		existing.Desc = want.Desc
+		existing.Validate = want.Validate
+		existing.Enum = want.Enum
+		existing.Required = want.Required
+		existing.Due = want.Due
+		existing.Severity = want.Severity
+		existing.List = want.List
+		existing.Coerce = want.Coerce
+		existing.Transitions = want.Transitions
+		existing.Unit = want.Unit
+		existing.Decimals = want.Decimals
+		existing.ThousandsSep = want.ThousandsSep
+		updated = true
+	}
+	if updated {
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update columns: %w", err)
+		}
+	}
+
+	now := time.Now()
+	for _, change := range diff.Changes {
+		if change.Action != SchemaChangeAdd {
+			continue
+		}
+		for _, want := range schema.Columns {
+			if want.Name != change.Column {
+				continue
+			}
+			col := model.Column{
+				Name:        want.Name,
+				Desc:        want.Desc,
+				Added:       now,
+				AddedBy:     actor,
+				Validate:    want.Validate,
+				Enum:        want.Enum,
+				Required:    want.Required,
+				Computed:    want.Computed,
+				Due:         want.Due,
+				Severity:    want.Severity,
+				List:        want.List,
+				Coerce:      want.Coerce,
+				Transitions: want.Transitions,
+
+				Unit:         want.Unit,
+				Decimals:     want.Decimals,
+				ThousandsSep: want.ThousandsSep,
+			}
+			if err := store.AddColumn(stash.Name, col); err != nil {
+				return fmt.Errorf("failed to add column '%s': %w", col.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// printSchemaDiff prints a schema diff. When applied is non-nil the
+// changes are reported as already applied.
+func printSchemaDiff(diff *SchemaDiff, applied *int) error {
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"stash":   diff.Stash,
+			"changes": diff.Changes,
+			"extra":   diff.Extra,
+		}
+		if applied != nil {
+			output["applied"] = *applied
+		}
+		data, err := json.Marshal(output)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(diff.Changes) == 0 {
+		fmt.Printf("Stash '%s' matches the schema\n", diff.Stash)
+	} else if applied != nil {
+		fmt.Printf("Applied %d change(s) to stash '%s':\n", *applied, diff.Stash)
+	} else {
+		fmt.Printf("Changes for stash '%s':\n", diff.Stash)
+	}
+
+	for _, change := range diff.Changes {
+		switch change.Action {
+		case SchemaChangeAdd:
+			fmt.Printf("  + %s\n", change.Column)
+		case SchemaChangeUpdate:
+			fmt.Printf("  ~ %s.%s: %s -> %s\n", change.Column, change.Field,
+				formatSchemaValue(change.From), formatSchemaValue(change.To))
+		}
+	}
+	for _, name := range diff.Extra {
+		fmt.Printf("  - %s (not in schema, kept)\n", name)
+	}
+
+	return nil
+}
+
+// formatSchemaValue renders a column attribute for diff output.
+func formatSchemaValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		if val == "" {
+			return "(none)"
+		}
+		return fmt.Sprintf("%q", val)
+	case []string:
+		if len(val) == 0 {
+			return "(none)"
+		}
+		return strings.Join(val, ",")
+	case map[string][]string:
+		if len(val) == 0 {
+			return "(none)"
+		}
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+// captureSchemaOutput runs a command and returns what it wrote to stdout.
+func captureSchemaOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs(args)
+	rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	resetFlags()
+	return buf.String()
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("export round-trips through apply", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"column", "add", "Status", "--enum", "open,done", "--required"})
+		rootCmd.Execute()
+		resetFlags()
+
+		for _, format := range []string{"json", "yaml"} {
+			args := []string{"schema", "export", "--stash", "tasks"}
+			if format == "yaml" {
+				args = append(args, "--yaml")
+			}
+			output := captureSchemaOutput(t, args...)
+			if ExitCode != 0 {
+				t.Fatalf("export exit code %d", ExitCode)
+			}
+
+			schemaPath := filepath.Join(tempDir, "schema."+format)
+			if err := os.WriteFile(schemaPath, []byte(output), 0644); err != nil {
+				t.Fatalf("failed to write schema: %v", err)
+			}
+
+			target := "copy_" + format
+			rootCmd.SetArgs([]string{"init", target, "--prefix", "cp-"})
+			rootCmd.Execute()
+			resetFlags()
+
+			rootCmd.SetArgs([]string{"schema", "apply", schemaPath, "--stash", target})
+			rootCmd.Execute()
+			if ExitCode != 0 {
+				t.Fatalf("apply exit code %d", ExitCode)
+			}
+			resetFlags()
+
+			store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+			if err != nil {
+				t.Fatalf("failed to open store: %v", err)
+			}
+			stash, err := store.GetStash(target)
+			store.Close()
+			if err != nil {
+				t.Fatalf("failed to get stash: %v", err)
+			}
+			if len(stash.Columns) != 2 {
+				t.Fatalf("%s: expected 2 columns, got %d", format, len(stash.Columns))
+			}
+			status := stash.Columns.Find("Status")
+			if status == nil || !status.Required || strings.Join(status.Enum, ",") != "open,done" {
+				t.Errorf("%s: Status column not replicated: %+v", format, status)
+			}
+		}
+	})
+
+	t.Run("diff reports changes without applying them", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Legacy"})
+		defer cleanup()
+
+		schemaPath := filepath.Join(tempDir, "schema.yaml")
+		schema := "columns:\n  - name: Name\n    desc: Task name\n  - name: Owner\n    validate: email\n"
+		if err := os.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+			t.Fatalf("failed to write schema: %v", err)
+		}
+
+		output := captureSchemaOutput(t, "schema", "diff", schemaPath, "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		var diff SchemaDiff
+		if err := json.Unmarshal([]byte(output), &diff); err != nil {
+			t.Fatalf("failed to parse JSON: %v\noutput: %s", err, output)
+		}
+		if len(diff.Changes) != 2 {
+			t.Fatalf("expected 2 changes, got %d: %+v", len(diff.Changes), diff.Changes)
+		}
+		if diff.Changes[0].Action != SchemaChangeUpdate || diff.Changes[0].Field != "desc" {
+			t.Errorf("expected desc update, got %+v", diff.Changes[0])
+		}
+		if diff.Changes[1].Action != SchemaChangeAdd || diff.Changes[1].Column != "Owner" {
+			t.Errorf("expected Owner add, got %+v", diff.Changes[1])
+		}
+		if len(diff.Extra) != 1 || diff.Extra[0] != "Legacy" {
+			t.Errorf("expected Legacy as extra column, got %v", diff.Extra)
+		}
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+		stash, _ := store.GetStash("tasks")
+		if stash.Columns.Find("Owner") != nil {
+			t.Error("diff must not add columns")
+		}
+	})
+
+	t.Run("apply rejects invalid schema", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		schemaPath := filepath.Join(tempDir, "schema.json")
+		schema := `{"columns": [{"name": "Name"}, {"name": "Email", "validate": "phone"}]}`
+		if err := os.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+			t.Fatalf("failed to write schema: %v", err)
+		}
+
+		rootCmd.SetArgs([]string{"schema", "apply", schemaPath})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -15,7 +15,8 @@ import (
 )
 
 var (
-	searchIn []string // Columns to search in
+	searchIn   []string // Columns to search in
+	searchMode string   // How the term matches
 )
 
 var searchCmd = &cobra.Command{
@@ -25,13 +26,23 @@ var searchCmd = &cobra.Command{
 
 By default, searches all text columns. Use --in to limit search to specific columns.
 
-The search is case-insensitive and matches partial strings (contains).
+The search matches partial strings (contains). --search-mode selects how:
+  ci     Ignore case and accents, so "laptop" matches "Laptop" and
+         "láptop" (default)
+  exact  Case-sensitive match
+  fuzzy  Match words similar to the term by trigram similarity,
+         so "laptp" still matches "Laptop"
+
+Records hidden by the stash's default filter (see 'stash filter') are not
+searched unless --no-default-filter is given.
 
 Examples:
   stash search "disney"                    # Search all columns
   stash search "disney" --in company_name  # Search only company_name column
   stash search "disney" --in Name --in Description  # Search multiple columns
   stash search "disney" --json             # Output as JSON
+  stash search "Disney" --search-mode exact  # Case-sensitive
+  stash search "disny" --search-mode fuzzy   # Tolerate typos
 
 AI Agent Examples:
   # Find records mentioning a keyword
@@ -57,18 +68,24 @@ AI Agent Examples:
 
 Exit Codes:
   0  Success (includes 0 matches)
-  1  Stash not found`,
+  1  Stash not found
+  2  Invalid --search-mode`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSearch,
 }
 
 func init() {
 	searchCmd.Flags().StringArrayVar(&searchIn, "in", nil, "Column(s) to search in (can be repeated)")
+	searchCmd.Flags().StringVar(&searchMode, "search-mode", storage.SearchCI, "Search matching: exact, ci, fuzzy")
+	searchCmd.Flags().BoolVar(&noDefaultFilter, "no-default-filter", false, "Search records the stash's default filter hides")
 	rootCmd.AddCommand(searchCmd)
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
 	searchTerm := args[0]
+	if !checkSearchMode(searchMode) {
+		return nil
+	}
 
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -87,7 +104,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -104,10 +121,16 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	filter, ok := defaultFilter(stash)
+	if !ok {
+		return nil
+	}
+
 	// Build list options for search
 	opts := storage.ListOptions{
 		IncludeDeleted: false,
 		ParentID:       "*", // Search all records, not just root
+		Where:          filter,
 	}
 
 	var records []*model.Record
@@ -119,10 +142,11 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to list records: %w", err)
 		}
-		records = filterRecordsBySearch(allRecords, searchTerm, searchIn)
+		records = filterRecordsBySearch(allRecords, searchTerm, searchIn, searchMode)
 	} else {
 		// Search all columns using the built-in Search option
 		opts.Search = searchTerm
+		opts.SearchMode = searchMode
 		var err error
 		records, err = store.ListRecords(ctx.Stash, opts)
 		if err != nil {
@@ -142,7 +166,8 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	// Human-readable output
 	if len(records) == 0 {
-		fmt.Println("No records found.")
+		Infof("No records found.\n")
+		noteDefaultFilter(stash)
 		return nil
 	}
 
@@ -153,106 +178,57 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		displayColumns = []string{primaryCol.Name}
 	}
 
-	// Calculate column widths
-	idWidth := 4 // "ID" header
-	colWidths := make(map[string]int)
+	columns := []tableColumn{{Header: "ID", Max: 20, System: true}}
 	for _, col := range displayColumns {
-		colWidths[col] = len(col)
+		columns = append(columns, tableColumn{Header: col, Max: 40})
 	}
-	statusWidth := 6 // "Status" header
-
+	columns = append(columns,
+		tableColumn{Header: "Status", Style: statusStyle},
+		tableColumn{Header: "Updated", System: true},
+	)
+	tbl := newTable(columns...)
 	for _, rec := range records {
-		if len(rec.ID) > idWidth {
-			idWidth = len(rec.ID)
-		}
+		row := []string{rec.ID}
 		for _, col := range displayColumns {
-			if val, ok := rec.Fields[col]; ok {
-				s := fmt.Sprintf("%v", val)
-				if len(s) > colWidths[col] {
-					colWidths[col] = len(s)
-				}
-			}
+			row = append(row, displayValue(stash.Columns.Find(col), rec.Fields[col]))
 		}
-	}
-
-	// Cap column widths for readability
-	if idWidth > 20 {
-		idWidth = 20
-	}
-	for col := range colWidths {
-		if colWidths[col] > 40 {
-			colWidths[col] = 40
-		}
-	}
-
-	// Print header
-	headerParts := []string{fmt.Sprintf("%-*s", idWidth, "ID")}
-	separatorParts := []string{strings.Repeat("-", idWidth)}
-	for _, col := range displayColumns {
-		headerParts = append(headerParts, fmt.Sprintf("%-*s", colWidths[col], col))
-		separatorParts = append(separatorParts, strings.Repeat("-", colWidths[col]))
-	}
-	headerParts = append(headerParts, fmt.Sprintf("%-*s", statusWidth, "Status"))
-	separatorParts = append(separatorParts, strings.Repeat("-", statusWidth))
-	headerParts = append(headerParts, "Updated")
-	separatorParts = append(separatorParts, strings.Repeat("-", 19))
-
-	fmt.Println(strings.Join(headerParts, "  "))
-	fmt.Println(strings.Join(separatorParts, "  "))
-
-	// Print records
-	for _, rec := range records {
-		// Format ID (truncate if needed)
-		id := rec.ID
-		if len(id) > idWidth {
-			id = id[:idWidth-3] + "..."
-		}
-
-		rowParts := []string{fmt.Sprintf("%-*s", idWidth, id)}
-
-		// Format column values
-		for _, col := range displayColumns {
-			val := ""
-			if v, ok := rec.Fields[col]; ok {
-				val = fmt.Sprintf("%v", v)
-				if len(val) > colWidths[col] {
-					val = val[:colWidths[col]-3] + "..."
-				}
-			}
-			rowParts = append(rowParts, fmt.Sprintf("%-*s", colWidths[col], val))
-		}
-
-		// Format status
 		status := "active"
 		if rec.IsDeleted() {
 			status = "deleted"
 		}
-		rowParts = append(rowParts, fmt.Sprintf("%-*s", statusWidth, status))
-
-		// Format updated time
-		updated := rec.UpdatedAt.Format("2006-01-02 15:04:05")
-		rowParts = append(rowParts, updated)
-
-		fmt.Println(strings.Join(rowParts, "  "))
+		row = append(row, status, rec.UpdatedAt.Format("2006-01-02 15:04:05"))
+		tbl.addRow(row...)
 	}
+	tbl.print()
 
 	// Print count
-	fmt.Printf("\nTotal: %d record(s)\n", len(records))
+	Infof("\nTotal: %d record(s)\n", len(records))
+	noteDefaultFilter(stash)
 
 	return nil
 }
 
+// checkSearchMode reports an invalid --search-mode. Returns true if the
+// mode is valid.
+func checkSearchMode(mode string) bool {
+	if !storage.IsValidSearchMode(mode) {
+		ExitValidationError(fmt.Sprintf("invalid search mode '%s' (valid: %s)", mode, strings.Join(storage.SearchModes, ", ")),
+			map[string]interface{}{"search_mode": mode})
+		return false
+	}
+	return true
+}
+
 // filterRecordsBySearch filters records by search term in specific columns.
 // This performs case-insensitive partial matching (contains).
-func filterRecordsBySearch(records []*model.Record, term string, columns []string) []*model.Record {
-	termLower := strings.ToLower(term)
+func filterRecordsBySearch(records []*model.Record, term string, columns []string, mode string) []*model.Record {
 	var filtered []*model.Record
 
 	for _, rec := range records {
 		for _, col := range columns {
 			if val, ok := rec.Fields[col]; ok {
 				valStr := fmt.Sprintf("%v", val)
-				if strings.Contains(strings.ToLower(valStr), termLower) {
+				if storage.MatchesSearch(valStr, term, mode) {
 					filtered = append(filtered, rec)
 					break // Found match, no need to check other columns
 				}
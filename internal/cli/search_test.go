@@ -467,3 +467,65 @@ func TestSearchCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchModes(t *testing.T) {
+	searchNames := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		output := captureSchemaOutput(t, append(args, "--json")...)
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		var names []string
+		for _, rec := range records {
+			names = append(names, rec["Name"].(string))
+		}
+		return names
+	}
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+		for _, name := range []string{"Laptop", "láptop bag", "Monitor"} {
+			captureSchemaOutput(t, "add", name, "--set", "Notes=stock")
+		}
+		ExitCode = 0
+		return cleanup
+	}
+
+	t.Run("ci ignores case and accents by default", func(t *testing.T) {
+		defer setup(t)()
+		if names := searchNames(t, "search", "laptop"); len(names) != 2 {
+			t.Errorf("expected 2 matches, got %v", names)
+		}
+		if names := searchNames(t, "list", "--search", "LAPTOP"); len(names) != 2 {
+			t.Errorf("expected 2 matches from list, got %v", names)
+		}
+	})
+
+	t.Run("exact is case-sensitive", func(t *testing.T) {
+		defer setup(t)()
+		names := searchNames(t, "search", "Lap", "--search-mode", "exact")
+		if len(names) != 1 || names[0] != "Laptop" {
+			t.Errorf("expected only Laptop, got %v", names)
+		}
+	})
+
+	t.Run("fuzzy tolerates typos", func(t *testing.T) {
+		defer setup(t)()
+		if names := searchNames(t, "search", "laptp", "--search-mode", "fuzzy"); len(names) != 2 {
+			t.Errorf("expected 2 matches, got %v", names)
+		}
+		if names := searchNames(t, "search", "laptp", "--in", "Name", "--search-mode", "fuzzy"); len(names) != 2 {
+			t.Errorf("expected 2 matches with --in, got %v", names)
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		defer setup(t)()
+		captureSchemaOutput(t, "search", "laptop", "--search-mode", "regex")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
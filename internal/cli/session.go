@@ -0,0 +1,464 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	execScript       string
+	execKeepGoing    bool
+	sessionEphemeral bool
+)
+
+// ephemeralUnsupported lists the commands that need a .stash directory on
+// disk, and so cannot run in an ephemeral session.
+var ephemeralUnsupported = map[string]bool{
+	"attach": true, "audit": true, "backup": true, "daemon": true,
+	"detach": true, "doctor": true, "files": true, "lock": true,
+	"locks": true, "migrate": true, "repair": true, "restore-backup": true,
+	"sync": true, "template": true, "tx": true, "unlock": true, "upgrade": true,
+}
+
+// activeSession is set while 'stash shell' or 'stash exec' is running.
+var activeSession *session
+
+// session runs many commands in one process, sharing one open Store per
+// .stash directory instead of reopening the cache for every command.
+type session struct {
+	stores     map[string]*storage.Store
+	globalArgs []string
+	memory     *storage.Store // in-memory store of an ephemeral session
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Run stash commands interactively in one session",
+	Long: `Start an interactive session that runs stash commands one per line.
+
+The store is opened once and kept open for the whole session, so many
+commands run much faster than separate invocations. Each command behaves
+exactly as it would on the command line, including --json output. Global
+flags given to 'stash shell' (e.g. --stash, --json, --actor) apply to every
+command in the session.
+
+Lines are split like a shell command line: quote values containing spaces.
+A leading "stash" is optional. Blank lines and lines starting with # are
+ignored. Type exit or quit (or send EOF) to leave.
+
+Commands that prompt for confirmation read from the same input; pass --yes
+where available.
+
+With --ephemeral the session works on an empty in-memory store instead of
+the .stash directory: stashes created in it vanish when the session ends,
+and nothing is written to disk. Commands that need files on disk (attach,
+lock, backup, sync, template, daemon, ...) are not available.
+
+Examples:
+  stash shell
+  stash shell --ephemeral
+  stash> add "Laptop" --set Price=999
+  stash> list --where "Price > 500"
+  stash> exit
+
+Exit Codes:
+  0  Session ended`,
+	Args: cobra.NoArgs,
+	RunE: runShell,
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec",
+	Short: "Run a script of stash commands in one session",
+	Long: `Run stash commands from a script file, one per line, in one session.
+
+The store is opened once and shared by every command in the script, which
+makes large scripted workloads dramatically faster than calling stash once
+per command. Each command produces the same output it would on the command
+line. Global flags given to 'stash exec' apply to every command.
+
+Script lines are split like a shell command line: quote values containing
+spaces. A leading "stash" is optional. Blank lines and lines starting with #
+are ignored. Use --script - to read the script from stdin.
+
+By default the script stops at the first failing command. Use --keep-going
+to run every line and exit with the last failure.
+
+With --ephemeral the script runs against an empty in-memory store, as with
+'stash shell --ephemeral': nothing is written to disk.
+
+Examples:
+  stash exec --script seed.stash
+  stash exec --script seed.stash --json
+  generate-commands | stash exec --script -
+
+AI Agent Examples:
+  # Add many records without reopening the store each time
+  printf 'add "Laptop"\nadd "Mouse"\nadd "Monitor"\n' | stash exec --script - --json
+
+  # Use a throwaway stash as scratch space
+  printf 'init scratch --prefix sc-\ncolumn add Url\nadd "https://example.com"\nlist --json\n' \
+    | stash exec --script - --ephemeral
+
+Exit Codes:
+  0  All commands succeeded
+  n  Exit code of the failing command (or of the last failure with --keep-going)
+  2  Script file missing or a line could not be parsed`,
+	Args: cobra.NoArgs,
+	RunE: runExec,
+}
+
+func init() {
+	execCmd.Flags().StringVar(&execScript, "script", "", "Script file to run (- for stdin)")
+	execCmd.Flags().BoolVar(&execKeepGoing, "keep-going", false, "Continue after a command fails")
+	execCmd.Flags().BoolVar(&sessionEphemeral, "ephemeral", false, "Use an in-memory store that is discarded when the script ends")
+	shellCmd.Flags().BoolVar(&sessionEphemeral, "ephemeral", false, "Use an in-memory store that is discarded when the session ends")
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(execCmd)
+}
+
+// openStore opens the store for stashDir. Inside a session the session's
+// store is shared; callers Close it as usual. With --tx it is the
+// transaction's view, which stages writes. Operations are signed with
+// the acting actor's key when they have one and note the command's --source
+// and --run-id.
+func openStore(stashDir string) (store *storage.Store, err error) {
+	if token := GetTx(); token != "" {
+		store, err = openTxStore(stashDir, token)
+	} else if activeSession != nil {
+		store, err = activeSession.store(stashDir)
+	} else {
+		store, err = newSignedStore(stashDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	store.SetContext(commandContext())
+	store.SetSource(GetSource())
+	store.SetRunID(GetRunID())
+	return store, nil
+}
+
+// newSignedStore creates a store that signs operations with actors' keys.
+func newSignedStore(stashDir string) (*storage.Store, error) {
+	store, err := storage.NewStore(stashDir)
+	if err != nil {
+		return nil, err
+	}
+	store.SetSigner(signingKeyFor)
+	store.SetActor(schemaActorFor)
+	warnTornWrites(store)
+	return store, nil
+}
+
+// warnTornWrites reports the interrupted writes recovered when a store was
+// opened, so the operation lost with them can be redone.
+func warnTornWrites(store *storage.Store) {
+	for _, torn := range store.TornWrites() {
+		PrintWarning(ErrCodeTornWrite,
+			fmt.Sprintf("records.jsonl of stash '%s' ended in an incomplete line from an interrupted write; moved it (%d bytes) to %s", torn.Stash, torn.Bytes, torn.Quarantine),
+			map[string]interface{}{"stash": torn.Stash, "bytes": torn.Bytes, "quarantine": torn.Quarantine})
+	}
+}
+
+// store returns a reference to the session's store for stashDir, opening
+// it on first use.
+func (s *session) store(stashDir string) (*storage.Store, error) {
+	if s.memory != nil {
+		return s.memory.Retain(), nil
+	}
+	if store, ok := s.stores[stashDir]; ok {
+		return store.Retain(), nil
+	}
+	store, err := newSignedStore(stashDir)
+	if err != nil {
+		return nil, err
+	}
+	s.stores[stashDir] = store
+	return store.Retain(), nil
+}
+
+// close releases the stores held by the session.
+func (s *session) close() {
+	for _, store := range s.stores {
+		store.Close()
+	}
+	if s.memory != nil {
+		s.memory.Close()
+	}
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	if activeSession != nil {
+		ExitValidationError("shell cannot be started inside a session", nil)
+		return nil
+	}
+
+	interactive := isTerminal(os.Stdin)
+	runSession(os.Stdin, true, sessionEphemeral, func() {
+		if interactive {
+			fmt.Fprint(os.Stderr, "stash> ")
+		}
+	})
+	if interactive {
+		fmt.Fprintln(os.Stderr)
+	}
+	return nil
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	if activeSession != nil {
+		ExitValidationError("exec cannot be run inside a session", nil)
+		return nil
+	}
+	if execScript == "" {
+		ExitValidationError("--script is required", nil)
+		return nil
+	}
+
+	var input io.Reader = os.Stdin
+	if execScript != "-" {
+		file, err := os.Open(execScript)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("cannot open script: %v", err), map[string]interface{}{"script": execScript})
+			return nil
+		}
+		defer file.Close()
+		input = file
+	}
+
+	if code := runSession(input, execKeepGoing, sessionEphemeral, nil); code != 0 {
+		Exit(code)
+	}
+	return nil
+}
+
+// runSession executes commands read from input, one per line, sharing one
+// store per .stash directory. It stops at the first failure unless
+// keepGoing is set, and returns the exit code of the last failing command.
+// An ephemeral session uses a single in-memory store in place of .stash.
+// prompt, if non-nil, is called before each line is read.
+func runSession(input io.Reader, keepGoing, ephemeral bool, prompt func()) int {
+	sess := &session{
+		stores:     make(map[string]*storage.Store),
+		globalArgs: sessionGlobalArgs(),
+	}
+	if ephemeral {
+		memory, err := storage.NewMemoryStore()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to create in-memory store: %v\n", err)
+			return 1
+		}
+		memory.SetSigner(signingKeyFor)
+		memory.SetActor(schemaActorFor)
+		sess.memory = memory
+		context.SetStashDirOverride(storage.MemoryDir, func() []string {
+			var names []string
+			stashes, _ := memory.ListStashes()
+			for _, stash := range stashes {
+				names = append(names, stash.Name)
+			}
+			return names
+		})
+	}
+	activeSession = sess
+	origExitFunc := ExitFunc
+	ExitFunc = func(code int) {}
+	defer func() {
+		ExitFunc = origExitFunc
+		activeSession = nil
+		if ephemeral {
+			context.SetStashDirOverride("", nil)
+		}
+		sess.close()
+	}()
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	failed := 0
+	for lineNum := 1; ; lineNum++ {
+		if prompt != nil {
+			prompt()
+		}
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			break
+		}
+
+		code := sess.run(line, lineNum)
+		if code != 0 {
+			failed = code
+			if !keepGoing {
+				break
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read commands: %v\n", err)
+		return 1
+	}
+	return failed
+}
+
+// run executes a single command line and returns its exit code.
+func (s *session) run(line string, lineNum int) int {
+	args, err := splitCommandLine(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: line %d: %v\n", lineNum, err)
+		return 2
+	}
+	if len(args) > 0 && args[0] == "stash" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return 0
+	}
+	if args[0] == "shell" || args[0] == "exec" {
+		fmt.Fprintf(os.Stderr, "Error: line %d: %s cannot be run inside a session\n", lineNum, args[0])
+		return 2
+	}
+	if s.memory != nil && ephemeralUnsupported[args[0]] {
+		fmt.Fprintf(os.Stderr, "Error: line %d: %s needs a .stash directory and cannot be run in an ephemeral session\n", lineNum, args[0])
+		return 2
+	}
+
+	// Start every command from default flag values, then apply the
+	// session's global flags ahead of the command's own
+	resetCommandFlags(rootCmd)
+	ExitCode = 0
+	rootCmd.SetArgs(append(append([]string{}, s.globalArgs...), args...))
+
+	err = rootCmd.Execute()
+	endTimeout()
+	if err != nil {
+		if exitTimedOut(err) || exitQueryTooLarge(err) {
+			return ExitCode
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return ExitCode
+}
+
+// sessionGlobalArgs returns the global flags set for the session itself so
+// they can be applied to each command run within it. Every flag given on
+// the command line is forwarded, so a new global flag needs no change here.
+func sessionGlobalArgs() []string {
+	var args []string
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			for _, value := range slice.GetSlice() {
+				args = append(args, "--"+f.Name+"="+value)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// resetCommandFlags restores every flag of cmd and its subcommands to its
+// default value, so one command's flags do not leak into the next.
+func resetCommandFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetCommandFlags(sub)
+	}
+}
+
+// splitCommandLine splits a line into arguments, honouring single quotes,
+// double quotes, and backslash escapes the way a shell would.
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			}
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{`add Laptop`, []string{"add", "Laptop"}, false},
+		{`add "Big Laptop" --set Price=999`, []string{"add", "Big Laptop", "--set", "Price=999"}, false},
+		{`list --where 'Name = "x"'`, []string{"list", "--where", `Name = "x"`}, false},
+		{`add Big\ Laptop`, []string{"add", "Big Laptop"}, false},
+		{`add "say \"hi\""`, []string{"add", `say "hi"`}, false},
+		{`add ""`, []string{"add", ""}, false},
+		{`add "unterminated`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := splitCommandLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSessionGlobalArgs(t *testing.T) {
+	resetCommandFlags(rootCmd)
+	defer resetCommandFlags(rootCmd)
+	for name, value := range map[string]string{"no-color": "true", "wide": "true", "log-level": "debug", "timeout": "30s"} {
+		if err := rootCmd.PersistentFlags().Set(name, value); err != nil {
+			t.Fatalf("failed to set --%s: %v", name, err)
+		}
+	}
+	got := strings.Join(sessionGlobalArgs(), " ")
+	for _, flag := range []string{"--no-color=true", "--wide=true", "--log-level=debug", "--timeout=30s"} {
+		if !strings.Contains(got, flag) {
+			t.Errorf("expected %s to be forwarded, got %q", flag, got)
+		}
+	}
+	if strings.Contains(got, "--json") {
+		t.Errorf("expected flags not given to be left out, got %q", got)
+	}
+}
+
+func TestExec(t *testing.T) {
+	t.Run("runs every command against one store", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "seed.stash")
+		content := "# seed data\nadd \"Big Laptop\" --set Price=999\n\nstash add Mouse --set Price=5\n"
+		if err := os.WriteFile(script, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+
+		captureSchemaOutput(t, "exec", "--script", script)
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		defer store.Close()
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*", OrderBy: []storage.OrderKey{{Field: "Name"}}})
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		if records[0].Fields["Name"] != "Big Laptop" {
+			t.Errorf("expected 'Big Laptop', got %v", records[0].Fields["Name"])
+		}
+		if _, ok := records[1].Fields["Price"]; !ok {
+			t.Error("expected flags to be applied per command")
+		}
+	})
+
+	t.Run("stops at the first failing command", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "seed.stash")
+		content := "add Laptop\nshow inv-none\nadd Mouse\n"
+		if err := os.WriteFile(script, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+
+		captureSchemaOutput(t, "exec", "--script", script)
+		if ExitCode == 0 {
+			t.Fatal("expected non-zero exit code")
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		if len(records) != 1 {
+			t.Errorf("expected 1 record before the failure, got %d", len(records))
+		}
+	})
+
+	t.Run("applies --dir to every command", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "seed.stash")
+		if err := os.WriteFile(script, []byte("add Laptop\nadd Mouse\n"), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+		os.Chdir(t.TempDir())
+		defer os.Chdir(tempDir)
+
+		captureSchemaOutput(t, "--dir", tempDir, "exec", "--script", script)
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		if len(records) != 2 {
+			t.Errorf("expected 2 records, got %d", len(records))
+		}
+	})
+
+	t.Run("applies --porcelain to every command", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "list.stash")
+		if err := os.WriteFile(script, []byte("add Laptop\nlist\n"), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+		out := captureSchemaOutput(t, "--porcelain", "exec", "--script", script)
+		if !strings.Contains(out, "\tLaptop") || strings.Contains(out, "Name") {
+			t.Errorf("expected tab-separated rows without a header, got:\n%s", out)
+		}
+	})
+
+	t.Run("logs every command", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "seed.stash")
+		if err := os.WriteFile(script, []byte("add Laptop\nlist\n"), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+		logPath := filepath.Join(tempDir, "stash.log")
+		captureSchemaOutput(t, "--log-file", logPath, "exec", "--script", script)
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("expected a log file: %v", err)
+		}
+		if !strings.Contains(string(data), "stash add") || !strings.Contains(string(data), "stash list") {
+			t.Errorf("expected each session command logged, got:\n%s", data)
+		}
+	})
+
+	t.Run("must reject missing script", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"exec", "--script", "missing.stash"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("ephemeral session keeps everything in memory", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		script := filepath.Join(tempDir, "scratch.stash")
+		content := "init scratch --prefix sc-\ncolumn add Url\nadd https://example.com\nlist --json\nlock sc-none\n"
+		if err := os.WriteFile(script, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+
+		out := captureSchemaOutput(t, "exec", "--script", script, "--ephemeral", "--keep-going")
+		if !strings.Contains(out, `"Url": "https://example.com"`) {
+			t.Errorf("expected the scratch record to be listed, got:\n%s", out)
+		}
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for lock, got %d", ExitCode)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".stash", "scratch")); !os.IsNotExist(err) {
+			t.Errorf("expected nothing written to .stash, got %v", err)
+		}
+		if context.FindStashDir() == storage.MemoryDir {
+			t.Error("expected the .stash search to be restored after the session")
+		}
+	})
+}
@@ -5,17 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
 	"github.com/user/stash/internal/model"
-	"github.com/user/stash/internal/storage"
 )
 
 var setColFlags []string
 var setAutoCreate bool
+var setForce bool
+var setDryRun bool
 
 var setCmd = &cobra.Command{
 	Use:   "set <id> <field>=<value> | set <id> --col <field> <value> [--col <field> <value>...]",
@@ -31,6 +33,36 @@ Multiple field update:
 Auto-create columns:
   stash set inv-ex4j NewField=value --auto-create
 
+Values from files or stdin (like curl):
+  stash set inv-ex4j Notes=@notes.md       # Read the value from a file
+  build.sh 2>&1 | stash set inv-ex4j Log=@-   # Read the value from stdin
+  stash set inv-ex4j Owner=@@alice         # Literal value "@alice"
+
+  The content is stored exactly as read, including newlines.
+
+List columns (see 'stash column add --list'):
+  stash set inv-ex4j tags=urgent,backend   # Replace the list
+  stash set inv-ex4j tags+=urgent          # Add an element if absent
+  stash set inv-ex4j tags-=urgent          # Remove an element
+
+Number columns (see 'stash column add --validate number'):
+  stash set inv-ex4j Stock+=5              # Increment (unset counts as 0)
+  stash set inv-ex4j Stock-=1              # Decrement
+
+  += and -= read and rewrite the record while holding the stash's lock
+  file, so concurrent agents incrementing the same counter never lose an
+  update. With --auto-create, a missing column is created as a number
+  column if the value is a number, and as a list column otherwise.
+
+Workflow transitions:
+  Enum columns with transitions (see 'stash transitions') only accept
+  values reachable from the current value. Use --force to override.
+
+Dry run:
+  --dry-run runs every check a real update does (columns, validation,
+  transitions, locks, permissions) and prints the update operation that
+  would be written, without saving it or auto-creating columns.
+
 Note: Cannot update deleted records. Use 'stash restore' first.
 
 Examples:
@@ -38,6 +70,10 @@ Examples:
   stash set inv-ex4j --col Price 1299 --col Stock 50
   stash set inv-ex4j Notes=""  # Clear a field
   stash set inv-ex4j Category=Electronics --auto-create  # Create column if needed
+  stash set inv-ex4j Status=pending --force  # Skip workflow transition check
+  stash set inv-ex4j tags+=urgent tags-=triage
+  stash set inv-ex4j Stock-=1
+  stash set inv-ex4j Status=done --dry-run --json
 
 AI Agent Examples:
   # Update with processing results
@@ -54,17 +90,31 @@ AI Agent Examples:
           stash set "$id" status="complete"
       done
 
+  # Take one item from stock without a show+set round trip
+  stash set "$RECORD_ID" Stock-=1 --json | jq '.Stock'
+
   # Error handling with status tracking
   if ! process_record "$id"; then
       stash set "$id" status="error" error_msg="Processing failed"
   fi
 
+Values that break the constraints of a column declared with --severity
+warning are accepted and reported on stderr as warnings.
+
 Exit Codes:
-  0  Success - record updated
+  0  Success - record updated (including with warnings)
   1  Record or column not found
-  2  Validation error (invalid format, reserved column name)
+  2  Validation error (invalid format, reserved column name, illegal transition,
+     += or -= on a column that is not a list or number, non-numeric amount,
+     unreadable @file)
   3  Record is deleted (use 'stash restore' first)
-  5  Record is locked by another agent`,
+  5  Record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+JSON Output (--dry-run --json):
+  {"dry_run": true, "stash": "inventory",
+   "operations": [{"_id": "inv-ex4j", "_op": "update", "Price": "1299", ...}],
+   "new_columns": ["Category"]}`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runSet,
 }
@@ -72,28 +122,47 @@ Exit Codes:
 func init() {
 	setCmd.Flags().StringArrayVar(&setColFlags, "col", nil, "Set field value: --col Field Value (can be repeated)")
 	setCmd.Flags().BoolVar(&setAutoCreate, "auto-create", false, "Automatically create columns that don't exist")
+	setCmd.Flags().BoolVar(&setForce, "force", false, "Override workflow transition restrictions")
+	setCmd.Flags().BoolVar(&setDryRun, "dry-run", false, "Check and print the update without saving it")
 	rootCmd.AddCommand(setCmd)
 }
 
 func runSet(cmd *cobra.Command, args []string) error {
 	recordID := args[0]
 
-	// Parse field updates
+	// Parse field updates; edits (Field+=Value, Field-=Value) are applied
+	// after plain assignments, in order
 	updates := make(map[string]interface{})
+	var edits []fieldAssignment
+	values := &valueReader{stdin: cmd.InOrStdin()}
+	assign := func(a fieldAssignment) bool {
+		if a.Op != assignSet {
+			edits = append(edits, a)
+			return true
+		}
+		value, err := values.resolve(a.Value)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("cannot read value for %s: %v", a.Field, err),
+				map[string]interface{}{"column": a.Field, "value": a.Value})
+			return false
+		}
+		updates[a.Field] = value
+		return true
+	}
 
 	// Parse from positional args (Field=Value format)
 	if len(args) > 1 && len(setColFlags) == 0 {
 		// Single field update: stash set inv-ex4j Field=Value
 		for i := 1; i < len(args); i++ {
-			parts := strings.SplitN(args[i], "=", 2)
-			if len(parts) != 2 {
+			a, ok := parseAssignment(args[i])
+			if !ok {
 				ExitValidationError(fmt.Sprintf("invalid format: %s (expected Field=Value)", args[i]),
 					map[string]interface{}{"input": args[i]})
 				return nil
 			}
-			fieldName := strings.TrimSpace(parts[0])
-			fieldValue := strings.TrimSpace(parts[1])
-			updates[fieldName] = fieldValue
+			if !assign(a) {
+				return nil
+			}
 		}
 	}
 
@@ -108,22 +177,23 @@ func runSet(cmd *cobra.Command, args []string) error {
 
 	// Let's use a simpler approach: --col Field=Value
 	for _, colFlag := range setColFlags {
-		parts := strings.SplitN(colFlag, "=", 2)
-		if len(parts) != 2 {
+		a, ok := parseAssignment(colFlag)
+		if !ok {
 			// Try space-separated format: "Field Value"
-			parts = strings.SplitN(colFlag, " ", 2)
+			parts := strings.SplitN(colFlag, " ", 2)
 			if len(parts) != 2 {
 				ExitValidationError(fmt.Sprintf("invalid --col format: %s (expected Field=Value or 'Field Value')", colFlag),
 					map[string]interface{}{"input": colFlag})
 				return nil
 			}
+			a = fieldAssignment{Field: strings.TrimSpace(parts[0]), Op: assignSet, Value: strings.TrimSpace(parts[1])}
+		}
+		if !assign(a) {
+			return nil
 		}
-		fieldName := strings.TrimSpace(parts[0])
-		fieldValue := strings.TrimSpace(parts[1])
-		updates[fieldName] = fieldValue
 	}
 
-	if len(updates) == 0 {
+	if len(updates) == 0 && len(edits) == 0 {
 		ExitValidationError("no field updates specified", nil)
 		return nil
 	}
@@ -143,7 +213,7 @@ func runSet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -159,8 +229,25 @@ func runSet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
+	// Every field touched, by assignment or edit
+	touched := fieldNames(updates)
+	editFields := make(map[string]fieldAssignment)
+	for _, edit := range edits {
+		if _, ok := updates[edit.Field]; !ok {
+			if _, seen := editFields[edit.Field]; !seen {
+				touched = append(touched, edit.Field)
+			}
+		}
+		editFields[edit.Field] = edit
+	}
+
+	if !checkPermission(stash, ctx.Actor, model.PermUpdate, touched) {
+		return nil
+	}
+
 	// AC-04: Validate all columns exist before making changes, or auto-create if flag is set
-	for fieldName := range updates {
+	var newColumns []string
+	for _, fieldName := range touched {
 		if !stash.Columns.Exists(fieldName) {
 			if setAutoCreate {
 				// Validate column name before auto-creating
@@ -175,47 +262,117 @@ func runSet(cmd *cobra.Command, args []string) error {
 					return nil
 				}
 
-				// Auto-create the column
+				// Auto-create the column; edits create a number column
+				// when given a number, and a list column otherwise
 				col := model.Column{
 					Name:    fieldName,
 					Added:   time.Now(),
 					AddedBy: ctx.Actor,
 				}
-				if err := store.AddColumn(ctx.Stash, col); err != nil {
+				if edit, ok := editFields[fieldName]; ok {
+					if _, err := strconv.ParseFloat(edit.Value, 64); err == nil {
+						col.Validate = string(ValidationNumber)
+					} else {
+						col.List = true
+					}
+				}
+				if setDryRun {
+					err = store.CheckColumn(ctx.Stash, col)
+				} else {
+					err = store.AddColumn(ctx.Stash, col)
+				}
+				if err != nil {
 					return fmt.Errorf("failed to auto-create column '%s': %w", fieldName, err)
 				}
 
 				// Update local stash reference
 				stash.Columns = append(stash.Columns, col)
+				newColumns = append(newColumns, col.Name)
 
-				if IsVerbose() && !IsQuiet() {
+				if IsVerbose() && !IsQuiet() && !setDryRun {
 					fmt.Printf("Auto-created column '%s'\n", fieldName)
 				}
 			} else {
-				ExitColumnNotFound(fieldName)
+				ExitColumnNotFound(fieldName, stash.Columns)
 				return nil
 			}
 		}
 	}
 
-	// Validate the updates against column constraints (before getting record)
+	// Computed columns are derived at read time and cannot be set
+	for _, fieldName := range touched {
+		if col := stash.Columns.Find(fieldName); col != nil && col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set", col.Name),
+				map[string]interface{}{"column": col.Name, "computed": col.Computed})
+			return nil
+		}
+	}
+
+	// += and -= only apply to list and number columns; list assignments
+	// are parsed into their elements
+	for _, edit := range edits {
+		if edit.Value == "" {
+			ExitValidationError(fmt.Sprintf("%s%s needs a value", edit.Field, edit.Op),
+				map[string]interface{}{"column": edit.Field, "operator": edit.Op})
+			return nil
+		}
+		col := stash.Columns.Find(edit.Field)
+		switch {
+		case col.List:
+		case isNumberColumn(col):
+			if _, err := strconv.ParseFloat(edit.Value, 64); err != nil {
+				ExitValidationError(fmt.Sprintf("%s%s needs a number, got '%s'", col.Name, edit.Op, edit.Value),
+					map[string]interface{}{"column": col.Name, "operator": edit.Op, "value": edit.Value})
+				return nil
+			}
+		default:
+			ExitValidationError(fmt.Sprintf("column '%s' is not a list or number column (%s needs 'stash column add %s --list' or '--validate number')", col.Name, edit.Op, col.Name),
+				map[string]interface{}{"column": col.Name, "operator": edit.Op})
+			return nil
+		}
+	}
 	for fieldName, fieldValue := range updates {
-		col := stash.Columns.Find(fieldName)
-		if col != nil {
-			valResult := ValidateValue(col, fieldValue)
-			if !valResult.Valid && len(valResult.Errors) > 0 {
-				validErr := valResult.Errors[0]
-				ExitValidationError(validErr.Message,
-					map[string]interface{}{
-						"column": validErr.Column,
-						"value":  validErr.Value,
-						"rule":   validErr.Rule,
-					})
+		updates[fieldName] = columnValue(stash.Columns.Find(fieldName), fieldValue.(string))
+	}
+	coerced := coerceListEdits(stash, edits, coerceEnums(stash, updates))
+
+	// Validate the updates, and the elements being added to lists, against
+	// column constraints (before getting record)
+	type check struct {
+		col   *model.Column
+		value interface{}
+	}
+	var checks []check
+	for fieldName, fieldValue := range updates {
+		checks = append(checks, check{stash.Columns.Find(fieldName), fieldValue})
+	}
+	for _, edit := range edits {
+		if col := stash.Columns.Find(edit.Field); col.List && edit.Op == assignAdd {
+			checks = append(checks, check{col, []interface{}{edit.Value}})
+		}
+	}
+	var warnings []ValidationError
+	for _, c := range checks {
+		if col := c.col; col != nil {
+			valResult := ValidateValue(col, c.value)
+			if exitViolation(valResult) {
 				return nil
 			}
+			warnings = append(warnings, valResult.Warnings...)
 		}
 	}
 
+	// Edits read the current value, so hold the locks file until the
+	// record is saved: a concurrent edit of the same record waits rather
+	// than overwriting this one
+	if len(edits) > 0 && !store.IsMemory() {
+		fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to lock locks file: %w", err)
+		}
+		defer fileLock.Unlock()
+	}
+
 	// AC-03: Get existing record
 	record, err := store.GetRecord(ctx.Stash, recordID)
 	if err != nil {
@@ -241,6 +398,16 @@ func runSet(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Enforce workflow transitions on enum columns
+	if !setForce {
+		for fieldName, fieldValue := range updates {
+			col := stash.Columns.Find(fieldName)
+			if col != nil && !checkTransition(col, record, fieldValue) {
+				return nil
+			}
+		}
+	}
+
 	// Apply updates to fields
 	for fieldName, fieldValue := range updates {
 		// Use the column's actual name case
@@ -249,13 +416,73 @@ func runSet(cmd *cobra.Command, args []string) error {
 			record.SetField(col.Name, fieldValue)
 		}
 	}
+	for _, edit := range edits {
+		col := stash.Columns.Find(edit.Field)
+		current, _ := record.GetField(col.Name)
+		if col.List {
+			record.SetField(col.Name, editList(current, edit.Op, edit.Value))
+			continue
+		}
+
+		value, err := editNumber(current, edit.Op, edit.Value)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("cannot apply %s%s%s: %v", col.Name, edit.Op, edit.Value, err),
+				map[string]interface{}{"column": col.Name, "operator": edit.Op, "value": current})
+			return nil
+		}
+		valResult := ValidateValue(col, value)
+		if exitViolation(valResult) {
+			return nil
+		}
+		warnings = append(warnings, valResult.Warnings...)
+		record.SetField(col.Name, value)
+	}
+
+	// Check the conditional rules that involve the columns being set
+	ruleResult := ValidateRules(stash, record.Fields, touched)
+	if exitViolation(ruleResult) {
+		return nil
+	}
+	warnings = append(warnings, ruleResult.Warnings...)
+
+	// Run the validation hooks of the columns being set
+	hookResult := ValidateHooks(stash, record, touched)
+	if exitViolation(hookResult) {
+		return nil
+	}
+	warnings = append(warnings, hookResult.Warnings...)
 
 	// Update audit trail
 	record.UpdatedAt = time.Now()
 	record.UpdatedBy = ctx.Actor
+	record.Coerced = coerced
+
+	// Violations of warning-severity columns are accepted, and reported
+	for i := range warnings {
+		warnings[i].RecordID = recordID
+	}
+	printValidationWarnings(warnings)
+
+	if setDryRun {
+		ops, err := previewOperations(store, ctx.Stash, []*model.Record{record}, model.OpUpdate)
+		if err != nil {
+			return err
+		}
+		var extra map[string]interface{}
+		if len(newColumns) > 0 {
+			extra = map[string]interface{}{"new_columns": newColumns}
+			if !GetJSONOutput() && !IsQuiet() {
+				fmt.Printf("Would create column(s): %s\n", strings.Join(newColumns, ", "))
+			}
+		}
+		return outputDryRun(ctx.Stash, ops, extra)
+	}
 
 	// Save record
 	if err := store.UpdateRecord(ctx.Stash, record); err != nil {
+		if exitRecordTooLarge(ctx.Stash, err) || exitWriteRefused(err) {
+			return nil
+		}
 		return fmt.Errorf("failed to update record: %w", err)
 	}
 
@@ -276,3 +503,8 @@ func runSet(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// isNumberColumn returns true if the column only accepts numbers.
+func isNumberColumn(col *model.Column) bool {
+	return col != nil && ValidationType(col.Validate) == ValidationNumber
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/user/stash/internal/storage"
@@ -584,3 +585,248 @@ func TestUC_REC_002_UpdateRecord_AutoCreate(t *testing.T) {
 		}
 	})
 }
+
+func TestSetListColumn(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Title"})
+	defer cleanup()
+
+	rootCmd.SetArgs([]string{"column", "add", "Tags", "--list"})
+	if err := rootCmd.Execute(); err != nil || ExitCode != 0 {
+		t.Fatalf("column add --list failed: err=%v exit=%d", err, ExitCode)
+	}
+	resetFlags()
+
+	rootCmd.SetArgs([]string{"add", "Fix bug", "--set", "Tags=urgent,backend"})
+	rootCmd.Execute()
+	resetFlags()
+	rootCmd.SetArgs([]string{"add", "Write docs"})
+	rootCmd.Execute()
+	resetFlags()
+
+	store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	records, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+	store.Close()
+	ids := map[string]string{}
+	for _, rec := range records {
+		ids[fmt.Sprintf("%v", rec.Fields["Title"])] = rec.ID
+	}
+
+	tags := func(id string) string {
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		rec, _ := store.GetRecord("tasks", id)
+		data, _ := json.Marshal(rec.Fields["Tags"])
+		return string(data)
+	}
+
+	if got := tags(ids["Fix bug"]); got != `["urgent","backend"]` {
+		t.Errorf("expected add to store a list, got %s", got)
+	}
+
+	t.Run("+= adds an element once", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", ids["Write docs"], "Tags+=docs"})
+		rootCmd.Execute()
+		rootCmd.SetArgs([]string{"set", ids["Write docs"], "Tags+=urgent"})
+		rootCmd.Execute()
+		rootCmd.SetArgs([]string{"set", ids["Write docs"], "Tags+=urgent"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := tags(ids["Write docs"]); got != `["docs","urgent"]` {
+			t.Errorf("expected [docs urgent], got %s", got)
+		}
+	})
+
+	t.Run("-= removes an element", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", ids["Fix bug"], "Tags-=backend"})
+		rootCmd.Execute()
+		resetFlags()
+		if got := tags(ids["Fix bug"]); got != `["urgent"]` {
+			t.Errorf("expected [urgent], got %s", got)
+		}
+	})
+
+	t.Run("CONTAINS filters on an element", func(t *testing.T) {
+		ExitCode = 0
+		output := captureSchemaOutput(t, "list", "--where", "Tags CONTAINS urgent", "--json")
+		var listed []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &listed); err != nil {
+			t.Fatalf("failed to parse output: %v\n%s", err, output)
+		}
+		if len(listed) != 2 {
+			t.Errorf("expected 2 records tagged urgent, got %d", len(listed))
+		}
+
+		output = captureSchemaOutput(t, "list", "--where", "Tags CONTAINS docs", "--json")
+		listed = nil
+		json.Unmarshal([]byte(output), &listed)
+		if len(listed) != 1 || listed[0]["_id"] != ids["Write docs"] {
+			t.Errorf("expected only %s tagged docs, got %v", ids["Write docs"], listed)
+		}
+	})
+
+	t.Run("list edit on a plain column fails", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", ids["Fix bug"], "Title+=more"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+func TestSetNumberEdits(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	rootCmd.SetArgs([]string{"column", "add", "Stock", "--validate", "number"})
+	if err := rootCmd.Execute(); err != nil || ExitCode != 0 {
+		t.Fatalf("column add failed: err=%v exit=%d", err, ExitCode)
+	}
+	resetFlags()
+	rootCmd.SetArgs([]string{"add", "Laptop"})
+	rootCmd.Execute()
+	resetFlags()
+
+	store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+	store.Close()
+	recordID := records[0].ID
+
+	stock := func() interface{} {
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		rec, _ := store.GetRecord("inventory", recordID)
+		return rec.Fields["Stock"]
+	}
+
+	t.Run("increment and decrement", func(t *testing.T) {
+		ExitCode = 0
+		for _, arg := range []string{"Stock+=5", "Stock+=5", "Stock-=1"} {
+			rootCmd.SetArgs([]string{"set", recordID, arg})
+			rootCmd.Execute()
+			resetFlags()
+		}
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := fmt.Sprintf("%v", stock()); got != "9" {
+			t.Errorf("expected Stock=9, got %s", got)
+		}
+	})
+
+	t.Run("non-numeric amount fails", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", recordID, "Stock+=lots"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		if got := fmt.Sprintf("%v", stock()); got != "9" {
+			t.Errorf("expected Stock unchanged at 9, got %s", got)
+		}
+	})
+
+	t.Run("auto-create makes a number column", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", recordID, "Sold+=2", "--auto-create"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		stash, _ := store.GetStash("inventory")
+		if col := stash.Columns.Find("Sold"); col == nil || col.Validate != "number" {
+			t.Errorf("expected Sold to be created as a number column, got %+v", col)
+		}
+	})
+}
+
+func TestSetValueFromFile(t *testing.T) {
+	tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Notes"})
+	defer cleanup()
+
+	rootCmd.SetArgs([]string{"add", "Laptop"})
+	rootCmd.Execute()
+	resetFlags()
+
+	stashDir := filepath.Join(tempDir, ".stash")
+	store, _ := storage.NewStore(stashDir)
+	records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+	store.Close()
+	recordID := records[0].ID
+
+	notes := func(rebuild bool) interface{} {
+		store, _ := storage.NewStore(stashDir)
+		defer store.Close()
+		if rebuild {
+			if err := store.RebuildCache("inventory"); err != nil {
+				t.Fatalf("failed to rebuild cache: %v", err)
+			}
+		}
+		rec, _ := store.GetRecord("inventory", recordID)
+		return rec.Fields["Notes"]
+	}
+
+	t.Run("file content survives the log and cache intact", func(t *testing.T) {
+		content := strings.Repeat("log line with \"quotes\" and\ttabs = ünïcode\n", 200)
+		path := filepath.Join(tempDir, "notes.txt")
+		os.WriteFile(path, []byte(content), 0644)
+
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", recordID, "Notes=@" + path})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := notes(false); got != content {
+			t.Errorf("cached value differs from the file (%d bytes, want %d)", len(fmt.Sprint(got)), len(content))
+		}
+		if got := notes(true); got != content {
+			t.Errorf("value rebuilt from JSONL differs from the file (%d bytes, want %d)", len(fmt.Sprint(got)), len(content))
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		rootCmd.SetIn(strings.NewReader("piped\nvalue\n"))
+		defer rootCmd.SetIn(nil)
+
+		rootCmd.SetArgs([]string{"set", recordID, "Notes=@-"})
+		rootCmd.Execute()
+		resetFlags()
+		if got := notes(false); got != "piped\nvalue\n" {
+			t.Errorf("expected value from stdin, got %q", got)
+		}
+	})
+
+	t.Run("@@ is a literal @", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"set", recordID, "Notes=@@alice"})
+		rootCmd.Execute()
+		resetFlags()
+		if got := notes(false); got != "@alice" {
+			t.Errorf("expected @alice, got %q", got)
+		}
+	})
+
+	t.Run("missing file fails", func(t *testing.T) {
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"set", recordID, "Notes=@missing.txt"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		if got := notes(false); got != "@alice" {
+			t.Errorf("expected Notes unchanged, got %q", got)
+		}
+	})
+}
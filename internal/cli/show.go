@@ -2,9 +2,11 @@
 package cli
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,12 +20,14 @@ import (
 var (
 	showWithFiles  bool
 	showHistory    bool
+	showProvenance bool
+	showFields     []string
 )
 
 var showCmd = &cobra.Command{
-	Use:   "show <id>",
-	Short: "Show a single record",
-	Long: `Display detailed information about a single record.
+	Use:   "show <id>...",
+	Short: "Show one or more records",
+	Long: `Display detailed information about one or more records.
 
 Shows:
 - Record ID and hash
@@ -32,27 +36,108 @@ Shows:
 - All user-defined fields
 - Child records (if any)
 
+Pass several IDs to show them all in one invocation, or "-" to read IDs
+from stdin, one or more per line. With more than one ID or "-", --json
+outputs an array of records in the order given.
+
 Options:
+  --fields A,B    Only show these user fields (system fields are always shown)
   --with-files    Include inline file contents
   --history       Show change history
+  --provenance    Show when, by whom, and from what source each field was set
 
 Examples:
   stash show inv-ex4j
   stash show inv-ex4j --json
   stash show inv-ex4j --with-files
-  stash show inv-ex4j --history`,
-	Args: cobra.ExactArgs(1),
+  stash show inv-ex4j --history
+  stash show inv-ex4j --provenance
+  stash show inv-ex4j inv-8t2m --fields Name,Price
+
+AI Agent Examples:
+  # Hydrate a batch of records in one process
+  stash list --where "Status=open" --json | jq -r '.[]._id' | stash show - --fields Name,Price --json
+
+Exit Codes:
+  0  Success
+  1  No .stash directory, stash or column not found
+  4  A record is not found or deleted
+
+JSON Output (--json):
+  {"_id": "inv-ex4j", "_hash": "...", "Name": "Laptop", "_children": []}
+  With --provenance: "_provenance": {"Name": {"set_at": "...", "set_by": "alice", "op": "create", "source": "vendors.csv"}}
+  With several IDs or "-": [{"_id": "inv-ex4j", ...}, {"_id": "inv-8t2m", ...}]`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runShow,
 }
 
 func init() {
 	showCmd.Flags().BoolVar(&showWithFiles, "with-files", false, "Include inline file contents")
 	showCmd.Flags().BoolVar(&showHistory, "history", false, "Show change history")
+	showCmd.Flags().BoolVar(&showProvenance, "provenance", false, "Show when, by whom, and from what source each field was set")
+	showCmd.Flags().StringSliceVar(&showFields, "fields", nil, "Only show these user fields (comma-separated; @group for a column group)")
 	rootCmd.AddCommand(showCmd)
 }
 
+// showRecordIDs expands the show arguments into record IDs, replacing "-"
+// with the IDs read from stdin. batch reports whether the caller asked for
+// more than a single record.
+func showRecordIDs(args []string, stdin io.Reader) (ids []string, batch bool, err error) {
+	batch = len(args) > 1
+	for _, arg := range args {
+		if arg != "-" {
+			ids = append(ids, arg)
+			continue
+		}
+		batch = true
+		scanner := bufio.NewScanner(stdin)
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			ids = append(ids, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, false, fmt.Errorf("failed to read IDs from stdin: %w", err)
+		}
+	}
+	return ids, batch, nil
+}
+
+// selectFields returns a copy of the record holding only the named fields.
+func selectFields(record *model.Record, fields []string) *model.Record {
+	selected := *record
+	selected.Fields = make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		if value, ok := record.Fields[name]; ok {
+			selected.Fields[name] = value
+		}
+	}
+	return &selected
+}
+
+// recordProvenance returns the provenance of the fields the record holds,
+// read from its history, or nil if --provenance was not given.
+func recordProvenance(store *storage.Store, stashName string, record *model.Record) (map[string]model.FieldProvenance, error) {
+	if !showProvenance {
+		return nil, nil
+	}
+	history, err := store.GetRecordHistory(stashName, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record history: %w", err)
+	}
+	provenance := make(map[string]model.FieldProvenance, len(record.Fields))
+	for name, prov := range model.Provenance(history) {
+		if _, ok := record.Fields[name]; ok {
+			provenance[name] = prov
+		}
+	}
+	return provenance, nil
+}
+
 func runShow(cmd *cobra.Command, args []string) error {
-	recordID := args[0]
+	recordIDs, batch, err := showRecordIDs(args, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
 
 	// Resolve context
 	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
@@ -71,7 +156,7 @@ func runShow(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -88,50 +173,68 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
-	// Get record
-	record, err := store.GetRecord(ctx.Stash, recordID)
-	if err != nil {
-		if errors.Is(err, model.ErrRecordNotFound) {
-			fmt.Fprintf(os.Stderr, "Error: record '%s' not found\n", recordID)
-			Exit(4)
+	// Resolve selected fields to their actual column names
+	names, ok := expandColumnGroups(stash, showFields)
+	if !ok {
+		return nil
+	}
+	var fields []string
+	for _, name := range names {
+		col := resolveColumn(stash, name)
+		if col == nil {
 			return nil
 		}
-		if errors.Is(err, model.ErrRecordDeleted) {
-			fmt.Fprintf(os.Stderr, "Error: record '%s' is deleted\n", recordID)
-			Exit(4)
-			return nil
+		fields = append(fields, col.Name)
+	}
+
+	// Get records; any missing record fails the whole batch before output
+	records := make([]*model.Record, 0, len(recordIDs))
+	for _, recordID := range recordIDs {
+		record, err := store.GetRecord(ctx.Stash, recordID)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				fmt.Fprintf(os.Stderr, "Error: record '%s' not found\n", recordID)
+				Exit(4)
+				return nil
+			}
+			if errors.Is(err, model.ErrRecordDeleted) {
+				fmt.Fprintf(os.Stderr, "Error: record '%s' is deleted\n", recordID)
+				Exit(4)
+				return nil
+			}
+			return fmt.Errorf("failed to get record: %w", err)
+		}
+		if fields != nil {
+			record = selectFields(record, fields)
 		}
-		return fmt.Errorf("failed to get record: %w", err)
+		records = append(records, record)
 	}
 
-	// Get children
-	children, err := store.GetChildren(ctx.Stash, recordID)
-	if err != nil {
-		// Non-fatal, continue without children
-		children = nil
+	// Count the reads for 'stash stats'; a failure here must not fail the read
+	readIDs := make([]string, len(records))
+	for i, record := range records {
+		readIDs[i] = record.ID
+	}
+	if err := store.RecordReads(ctx.Stash, readIDs...); err != nil && IsVerbose() {
+		fmt.Fprintf(os.Stderr, "Warning: failed to track read: %v\n", err)
 	}
 
-	// AC-02: JSON output format
 	if GetJSONOutput() {
-		// Build output map manually since Record has custom MarshalJSON
-		output := make(map[string]interface{})
-
-		// Marshal record to get its fields
-		recordData, err := json.Marshal(record)
-		if err != nil {
-			return fmt.Errorf("failed to marshal record: %w", err)
-		}
-		if err := json.Unmarshal(recordData, &output); err != nil {
-			return fmt.Errorf("failed to unmarshal record: %w", err)
+		outputs := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			output, err := showRecordJSON(store, ctx.Stash, record)
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, output)
 		}
 
-		// Add children array
-		if children == nil {
-			children = []*model.Record{}
+		var data []byte
+		if batch {
+			data, err = json.MarshalIndent(outputs, "", "  ")
+		} else {
+			data, err = json.MarshalIndent(outputs[0], "", "  ")
 		}
-		output["_children"] = children
-
-		data, err := json.MarshalIndent(output, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
@@ -139,6 +242,81 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if IsPorcelain() {
+		for i, record := range records {
+			if i > 0 {
+				fmt.Println()
+			}
+			children, err := store.GetChildren(ctx.Stash, record.ID)
+			if err != nil {
+				// Non-fatal, continue without children
+				children = nil
+			}
+			printRecordPorcelain(record, children)
+		}
+		return nil
+	}
+
+	for _, record := range records {
+		if err := printShowRecord(store, ctx, stash, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// showRecordJSON builds the JSON output for one record with its children.
+func showRecordJSON(store *storage.Store, stashName string, record *model.Record) (map[string]interface{}, error) {
+	children, err := store.GetChildren(stashName, record.ID)
+	if err != nil {
+		// Non-fatal, continue without children
+		children = nil
+	}
+
+	// Build output map manually since Record has custom MarshalJSON
+	output := make(map[string]interface{})
+
+	// Marshal record to get its fields
+	recordData, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record: %w", err)
+	}
+	if err := json.Unmarshal(recordData, &output); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+
+	// Add children array
+	if children == nil {
+		children = []*model.Record{}
+	}
+	output["_children"] = children
+
+	provenance, err := recordProvenance(store, stashName, record)
+	if err != nil {
+		return nil, err
+	}
+	if provenance != nil {
+		output["_provenance"] = provenance
+	}
+	return output, nil
+}
+
+// printShowRecord prints one record as markdown.
+func printShowRecord(store *storage.Store, ctx *context.Context, stash *model.Stash, record *model.Record) error {
+	recordID := record.ID
+
+	provenance, err := recordProvenance(store, ctx.Stash, record)
+	if err != nil {
+		return err
+	}
+
+	// Get children
+	children, err := store.GetChildren(ctx.Stash, recordID)
+	if err != nil {
+		// Non-fatal, continue without children
+		children = nil
+	}
+
 	// AC-01: Human-readable output
 	fmt.Printf("# Record %s\n", record.ID)
 	fmt.Println()
@@ -153,6 +331,15 @@ func runShow(cmd *cobra.Command, args []string) error {
 	if record.Branch != "" {
 		fmt.Printf("**Branch**: %s\n", record.Branch)
 	}
+	if ctx.CheckedOut {
+		fmt.Printf("**Checked out**: %s\n", ctx.Branch)
+	}
+	if record.IsArchived() {
+		fmt.Printf("**Archived**: %s by %s\n", record.ArchivedAt.Format("2006-01-02 15:04:05"), record.ArchivedBy)
+	}
+	if record.AssignedTo != "" {
+		fmt.Printf("**Assigned to**: %s\n", record.AssignedTo)
+	}
 	fmt.Println()
 
 	// User fields
@@ -168,13 +355,38 @@ func runShow(cmd *cobra.Command, args []string) error {
 
 		for _, name := range fieldNames {
 			value := record.Fields[name]
-			fmt.Printf("- **%s**: %v\n", name, value)
+			fmt.Printf("- **%s**: %s\n", name, displayValue(stash.Columns.Find(name), value))
 		}
 	} else {
 		fmt.Println("No fields set.")
 	}
 	fmt.Println()
 
+	// Provenance
+	if provenance != nil {
+		fmt.Println("## Provenance")
+		fmt.Println()
+		if len(provenance) > 0 {
+			fieldNames := make([]string, 0, len(provenance))
+			for name := range provenance {
+				fieldNames = append(fieldNames, name)
+			}
+			sort.Strings(fieldNames)
+
+			for _, name := range fieldNames {
+				prov := provenance[name]
+				fmt.Printf("- **%s**: set %s by %s (%s", name, prov.SetAt.Format("2006-01-02 15:04:05"), prov.SetBy, prov.Operation)
+				if prov.Source != "" {
+					fmt.Printf("; source: %s", prov.Source)
+				}
+				fmt.Println(")")
+			}
+		} else {
+			fmt.Println("No history for these fields.")
+		}
+		fmt.Println()
+	}
+
 	// Children
 	fmt.Println("## Children")
 	fmt.Println()
@@ -230,6 +442,5 @@ func runShow(cmd *cobra.Command, args []string) error {
 		fmt.Println("*Note: Full history requires reading JSONL file*")
 		fmt.Println()
 	}
-
 	return nil
 }
@@ -356,3 +356,160 @@ func TestUC_REC_003_ShowRecord_MustNot(t *testing.T) {
 		}
 	})
 }
+
+// TestShowMultiple tests showing several records at once and --fields
+func TestShowMultiple(t *testing.T) {
+	addRecord := func(t *testing.T, args ...string) string {
+		t.Helper()
+		var rec map[string]interface{}
+		out := captureSchemaOutput(t, append([]string{"add", "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(out), &rec); err != nil {
+			t.Fatalf("failed to parse add output: %v\n%s", err, out)
+		}
+		return rec["_id"].(string)
+	}
+
+	t.Run("several IDs output an array in order", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price", "Notes"})
+		defer cleanup()
+
+		first := addRecord(t, "Laptop", "--set", "Price=999", "--set", "Notes=refurb")
+		second := addRecord(t, "Mouse", "--set", "Price=25")
+		ExitCode = 0
+
+		out := captureSchemaOutput(t, "show", second, first, "--fields", "name,Price", "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &records); err != nil {
+			t.Fatalf("expected JSON array: %v\n%s", err, out)
+		}
+		if len(records) != 2 || records[0]["_id"] != second || records[1]["_id"] != first {
+			t.Fatalf("expected records in argument order, got %v", records)
+		}
+		if records[1]["Name"] != "Laptop" || records[1]["Price"] == nil {
+			t.Errorf("expected selected fields, got %v", records[1])
+		}
+		if _, ok := records[1]["Notes"]; ok {
+			t.Errorf("expected Notes to be left out, got %v", records[1])
+		}
+		if _, ok := records[1]["_children"]; !ok {
+			t.Errorf("expected _children in each record, got %v", records[1])
+		}
+	})
+
+	t.Run("single ID keeps object output", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := addRecord(t, "Laptop")
+		ExitCode = 0
+
+		out := captureSchemaOutput(t, "show", id, "--json")
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &record); err != nil {
+			t.Fatalf("expected JSON object: %v\n%s", err, out)
+		}
+		if record["_id"] != id {
+			t.Errorf("expected _id %s, got %v", id, record["_id"])
+		}
+	})
+
+	t.Run("reads IDs from stdin", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		first := addRecord(t, "Laptop")
+		second := addRecord(t, "Mouse")
+		ExitCode = 0
+
+		rootCmd.SetIn(strings.NewReader(first + "\n" + second + "\n"))
+		defer rootCmd.SetIn(nil)
+		out := captureSchemaOutput(t, "show", "-", "--json")
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &records); err != nil {
+			t.Fatalf("expected JSON array: %v\n%s", err, out)
+		}
+		if len(records) != 2 || records[0]["Name"] != "Laptop" || records[1]["Name"] != "Mouse" {
+			t.Errorf("expected both records from stdin, got %v", records)
+		}
+	})
+
+	t.Run("missing record fails the batch", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := addRecord(t, "Laptop")
+		ExitCode = 0
+
+		out := captureSchemaOutput(t, "show", id, "inv-zzzz", "--json")
+		if ExitCode != 4 {
+			t.Errorf("expected exit code 4, got %d", ExitCode)
+		}
+		if strings.TrimSpace(out) != "" {
+			t.Errorf("expected no output, got %s", out)
+		}
+	})
+
+	t.Run("unknown field is rejected", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		id := addRecord(t, "Laptop")
+		ExitCode = 0
+
+		captureSchemaOutput(t, "show", id, "--fields", "Nmae")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+}
+
+func TestShowProvenance(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+	defer cleanup()
+
+	var added map[string]interface{}
+	output := captureSchemaOutput(t, "add", "Laptop", "--set", "Price=999", "--source", "vendors.csv", "--json")
+	if err := json.Unmarshal([]byte(output), &added); err != nil {
+		t.Fatalf("failed to parse add output %q: %v", output, err)
+	}
+	id, _ := added["_id"].(string)
+
+	os.Setenv("STASH_SOURCE", "price-check")
+	captureSchemaOutput(t, "set", id, "Price=899")
+	os.Unsetenv("STASH_SOURCE")
+
+	var shown struct {
+		Provenance map[string]struct {
+			SetBy     string `json:"set_by"`
+			Operation string `json:"op"`
+			Source    string `json:"source"`
+		} `json:"_provenance"`
+	}
+	output = captureSchemaOutput(t, "show", id, "--provenance", "--json")
+	if err := json.Unmarshal([]byte(output), &shown); err != nil {
+		t.Fatalf("failed to parse show output %q: %v", output, err)
+	}
+	if name := shown.Provenance["Name"]; name.Operation != "create" || name.Source != "vendors.csv" {
+		t.Errorf("expected Name from the create with source vendors.csv, got %+v", name)
+	}
+	if price := shown.Provenance["Price"]; price.Operation != "update" || price.Source != "price-check" {
+		t.Errorf("expected Price from the update with source price-check, got %+v", price)
+	}
+
+	output = captureSchemaOutput(t, "show", id, "--provenance", "--fields", "Price")
+	if !strings.Contains(output, "## Provenance") || !strings.Contains(output, "source: price-check") {
+		t.Errorf("expected a provenance section naming price-check, got:\n%s", output)
+	}
+	if strings.Contains(output, "vendors.csv") {
+		t.Errorf("expected only the selected field's provenance, got:\n%s", output)
+	}
+
+	output = captureSchemaOutput(t, "show", id, "--json")
+	if strings.Contains(output, "_provenance") {
+		t.Errorf("expected no provenance without --provenance, got:\n%s", output)
+	}
+}
@@ -0,0 +1,169 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	statsHot          bool
+	statsCold         bool
+	statsLimit        int
+	statsTrackReads   bool
+	statsNoTrackReads bool
+	statsReset        bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how often records are read",
+	Long: `Show record read tracking for the current stash.
+
+With read tracking on, 'stash show' records when each record was last
+read and how many times. Tracking is kept in the SQLite cache only, not
+in records.jsonl, so reads never create changes to commit and each clone
+counts its own reads. Deleting the cache (or 'stash drop') forgets them.
+
+Use --hot to find the most read records, and --cold to find the least
+read ones, never-read records first: candidates for archiving or cleanup.
+'stash list --order-by _last_read' and '--order-by _read_count' sort by
+the same tracking.
+
+Examples:
+  stash stats --track-reads       # Start tracking reads
+  stash stats                     # Summary
+  stash stats --hot               # 10 most read records
+  stash stats --cold --limit 50   # 50 least read records
+  stash stats --reset             # Forget the reads counted so far
+  stash stats --no-track-reads    # Stop tracking reads
+
+AI Agent Examples:
+  # Archive records nobody has read
+  stash stats --cold --limit 0 --json | jq -r '.listed[] | select(.read_count == 0) | .id'
+
+Exit Codes:
+  0  Success
+  1  Stash not found
+  2  Validation error (conflicting flags, negative --limit)
+
+JSON Output (--json):
+  {"stash": "inventory", "track_reads": true, "records": 12, "read": 8, "never_read": 4}
+  With --hot or --cold, also:
+  "listed": [{"id": "inv-ex4j", "last_read": "2026-01-15T10:30:00Z", "read_count": 14}]`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsHot, "hot", false, "List the most read records")
+	statsCmd.Flags().BoolVar(&statsCold, "cold", false, "List the least read records, never-read first")
+	statsCmd.Flags().IntVar(&statsLimit, "limit", 10, "With --hot or --cold, number of records to list (0 = all)")
+	statsCmd.Flags().BoolVar(&statsTrackReads, "track-reads", false, "Start tracking record reads")
+	statsCmd.Flags().BoolVar(&statsNoTrackReads, "no-track-reads", false, "Stop tracking record reads")
+	statsCmd.Flags().BoolVar(&statsReset, "reset", false, "Forget the reads tracked so far")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	if statsHot && statsCold {
+		ExitValidationError("--hot and --cold cannot be used together", nil)
+		return nil
+	}
+	if statsTrackReads && statsNoTrackReads {
+		ExitValidationError("--track-reads and --no-track-reads cannot be used together", nil)
+		return nil
+	}
+	if statsLimit < 0 {
+		ExitValidationError("--limit must not be negative", map[string]interface{}{"limit": statsLimit})
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Update tracking if requested
+	if statsTrackReads || statsNoTrackReads {
+		stash.TrackReads = statsTrackReads
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update read tracking: %w", err)
+		}
+	}
+	if statsReset {
+		if err := store.ClearReads(stash.Name); err != nil {
+			return err
+		}
+	}
+
+	// Summarize every record; list only the ones asked for
+	all, err := store.ReadStats(stash.Name, true, 0)
+	if err != nil {
+		return err
+	}
+	read := 0
+	for _, stat := range all {
+		if stat.ReadCount > 0 {
+			read++
+		}
+	}
+	var listed []storage.ReadStat
+	if statsHot || statsCold {
+		if listed, err = store.ReadStats(stash.Name, statsHot, statsLimit); err != nil {
+			return err
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"stash":       stash.Name,
+			"track_reads": stash.TrackReads,
+			"records":     len(all),
+			"read":        read,
+			"never_read":  len(all) - read,
+		}
+		if statsHot || statsCold {
+			output["listed"] = listed
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	state := "off (enable with 'stash stats --track-reads')"
+	if stash.TrackReads {
+		state = "on"
+	}
+	fmt.Printf("Read tracking for stash '%s': %s\n", stash.Name, state)
+	fmt.Printf("  %d record(s): %d read, %d never read\n", len(all), read, len(all)-read)
+	if !statsHot && !statsCold {
+		return nil
+	}
+
+	title := "Most read"
+	if statsCold {
+		title = "Least read"
+	}
+	fmt.Printf("\n# %s records in %s\n\n", title, stash.Name)
+	fmt.Println("| ID | Reads | Last read |")
+	fmt.Println("|----|-------|-----------|")
+	for _, stat := range listed {
+		lastRead := "never"
+		if stat.LastRead != nil {
+			lastRead = stat.LastRead.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("| %s | %d | %s |\n", stat.ID, stat.ReadCount, lastRead)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestStats(t *testing.T) {
+	t.Run("show counts reads once tracking is on", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		hot := strings.TrimSpace(captureSchemaOutput(t, "add", "Laptop"))
+		cold := strings.TrimSpace(captureSchemaOutput(t, "add", "Mouse"))
+
+		// Reads before tracking is enabled are not counted
+		captureSchemaOutput(t, "show", cold)
+		captureSchemaOutput(t, "stats", "--track-reads")
+		for i := 0; i < 3; i++ {
+			captureSchemaOutput(t, "show", hot)
+		}
+
+		var stats struct {
+			TrackReads bool               `json:"track_reads"`
+			Read       int                `json:"read"`
+			NeverRead  int                `json:"never_read"`
+			Listed     []storage.ReadStat `json:"listed"`
+		}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "stats", "--hot", "--json")), &stats)
+		if !stats.TrackReads || stats.Read != 1 || stats.NeverRead != 1 {
+			t.Errorf("unexpected summary: %+v", stats)
+		}
+		if len(stats.Listed) != 2 || stats.Listed[0].ID != hot || stats.Listed[0].ReadCount != 3 {
+			t.Errorf("expected %s to be the hottest with 3 reads, got %+v", hot, stats.Listed)
+		}
+
+		if output := captureSchemaOutput(t, "stats", "--cold", "--limit", "1"); !strings.Contains(output, "| "+cold+" | 0 | never |") {
+			t.Errorf("expected %s to be the coldest, got: %s", cold, output)
+		}
+
+		var records []map[string]interface{}
+		json.Unmarshal([]byte(captureSchemaOutput(t, "list", "--order-by", "_last_read desc", "--json")), &records)
+		if len(records) != 2 || records[0]["_id"] != hot {
+			t.Errorf("expected list to sort by last read, got %v", records)
+		}
+
+		captureSchemaOutput(t, "stats", "--no-track-reads", "--reset")
+		captureSchemaOutput(t, "show", hot)
+		stats.Read = -1
+		json.Unmarshal([]byte(captureSchemaOutput(t, "stats", "--json")), &stats)
+		if stats.TrackReads || stats.Read != 0 {
+			t.Errorf("expected reads to be reset and untracked, got %+v", stats)
+		}
+	})
+
+	t.Run("rejects conflicting flags", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		for _, args := range [][]string{
+			{"stats", "--hot", "--cold"},
+			{"stats", "--track-reads", "--no-track-reads"},
+			{"stats", "--hot", "--limit", "-1"},
+		} {
+			ExitCode = 0
+			captureStderr(t, func() {
+				captureSchemaOutput(t, args...)
+			})
+			if ExitCode != 2 {
+				t.Errorf("%v: expected exit code 2, got %d", args, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
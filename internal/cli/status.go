@@ -98,7 +98,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -198,7 +198,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Human-readable output
 	if len(processingRecords) == 0 {
-		fmt.Println("No records in processing state.")
+		Infof("No records in processing state.\n")
 		return nil
 	}
 
@@ -274,7 +274,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print summary
-	fmt.Printf("\n%d record(s) in processing state\n", len(processingRecords))
+	Infof("\n%d record(s) in processing state\n", len(processingRecords))
 
 	return nil
 }
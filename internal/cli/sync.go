@@ -72,7 +72,7 @@ func runSync(cmd *cobra.Command, args []string) error {
 	}
 
 	// Open store
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to open store: %w", err)
 	}
@@ -0,0 +1,239 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/platform"
+)
+
+// ANSI styles used in human-readable output.
+const (
+	styleReset  = "\033[0m"
+	styleBold   = "\033[1m"
+	styleDim    = "\033[2m"
+	styleRed    = "\033[31m"
+	styleYellow = "\033[33m"
+)
+
+// minTableColumnWidth is the narrowest a column is shrunk to when fitting
+// a table to the terminal.
+const minTableColumnWidth = 6
+
+// colorEnabled reports whether output may be colored: stdout is a terminal,
+// and neither --no-color nor the NO_COLOR environment variable is set.
+func colorEnabled() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// colorize wraps s in an ANSI style when color is enabled.
+func colorize(s, style string) string {
+	if style == "" || !colorEnabled() {
+		return s
+	}
+	return style + s + styleReset
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when
+// stdout is not a terminal or --wide is set.
+func terminalWidth() int {
+	if wide || !isTerminal(os.Stdout) {
+		return 0
+	}
+	if n := platform.TerminalWidth(os.Stdout); n > 0 {
+		return n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// statusStyle colors a record status: deleted in red, locked in yellow,
+// and archived dimmed.
+func statusStyle(status string) string {
+	switch status {
+	case "deleted":
+		return styleRed
+	case "locked":
+		return styleYellow
+	case "archived":
+		return styleDim
+	}
+	return ""
+}
+
+// tableColumn describes one column of a table.
+type tableColumn struct {
+	Header string
+	// Max caps the column width; 0 means no cap. Ignored with --wide.
+	Max int
+	// System marks columns such as the ID and timestamps, shown dimmed.
+	System bool
+	// Style, if set, picks a style for each cell from its value.
+	Style func(value string) string
+}
+
+// table renders rows as aligned columns. Values too wide for their column
+// are cut with an ellipsis, and on a terminal the widest columns are
+// shrunk until the table fits its width.
+type table struct {
+	columns []tableColumn
+	rows    [][]string
+}
+
+// newTable creates an empty table with the given columns.
+func newTable(columns ...tableColumn) *table {
+	return &table{columns: columns}
+}
+
+// addRow appends a row; it must have one value per column.
+func (t *table) addRow(values ...string) {
+	t.rows = append(t.rows, values)
+}
+
+// widths computes the display width of each column.
+func (t *table) widths() []int {
+	widths := make([]int, len(t.columns))
+	for i, col := range t.columns {
+		widths[i] = utf8.RuneCountInString(col.Header)
+		for _, row := range t.rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+		if col.Max > 0 && !wide {
+			widths[i] = min(widths[i], col.Max)
+		}
+	}
+
+	// Shrink the widest column, one character at a time, until the table
+	// fits the terminal. Value columns give way before system columns.
+	if limit := terminalWidth(); limit > 0 {
+		total := 2 * (len(widths) - 1)
+		for _, w := range widths {
+			total += w
+		}
+		for _, system := range []bool{false, true} {
+			for total > limit {
+				widest := -1
+				for i, w := range widths {
+					if t.columns[i].System == system && w > minTableColumnWidth && (widest < 0 || w > widths[widest]) {
+						widest = i
+					}
+				}
+				if widest < 0 {
+					break
+				}
+				widths[widest]--
+				total--
+			}
+		}
+	}
+	return widths
+}
+
+// print writes the header, a separator, and the rows to stdout.
+func (t *table) print() {
+	widths := t.widths()
+
+	header := make([]string, len(t.columns))
+	separator := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		header[i] = colorize(fitCell(col.Header, widths[i], i == len(t.columns)-1), styleBold)
+		separator[i] = strings.Repeat("-", widths[i])
+	}
+	fmt.Println(strings.Join(header, "  "))
+	fmt.Println(strings.Join(separator, "  "))
+
+	for _, row := range t.rows {
+		cells := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			style := ""
+			if col.Style != nil {
+				style = col.Style(row[i])
+			}
+			if style == "" && col.System {
+				style = styleDim
+			}
+			cells[i] = colorize(fitCell(row[i], widths[i], i == len(t.columns)-1), style)
+		}
+		fmt.Println(strings.Join(cells, "  "))
+	}
+}
+
+// fitCell truncates a value to the width with an ellipsis, and pads it to
+// the width unless it is in the last column.
+func fitCell(value string, width int, last bool) string {
+	if n := utf8.RuneCountInString(value); n > width {
+		value = string([]rune(value)[:max(width-1, 0)]) + "…"
+	} else if !last {
+		value += strings.Repeat(" ", width-n)
+	}
+	return value
+}
+
+// displayValue formats a field value for table and markdown output. Numbers
+// in a column with display metadata are rounded to its decimals, grouped
+// in thousands, and followed by its unit, so 1299 shows as "1,299.00 USD".
+// Other values are shown as valueText.
+func displayValue(col *model.Column, value interface{}) string {
+	if col == nil || !col.HasDisplayFormat() {
+		return valueText(value)
+	}
+	if list, ok := value.([]interface{}); ok {
+		elems := make([]string, 0, len(list))
+		for _, e := range list {
+			if e != nil {
+				elems = append(elems, displayValue(col, e))
+			}
+		}
+		return strings.Join(elems, ", ")
+	}
+
+	text := valueText(value)
+	n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return text
+	}
+	precision := -1
+	if col.Decimals != nil {
+		precision = *col.Decimals
+	}
+	text = strconv.FormatFloat(n, 'f', precision, 64)
+	if col.ThousandsSep {
+		text = groupThousands(text)
+	}
+	if col.Unit != "" {
+		text += " " + col.Unit
+	}
+	return text
+}
+
+// groupThousands separates the digits of a formatted number's integer part
+// into groups of three with commas.
+func groupThousands(number string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, fraction := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		integer, fraction = number[:i], number[i:]
+	}
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
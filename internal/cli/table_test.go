@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/model"
+)
+
+// captureTable returns what printing the table writes to stdout.
+func captureTable(tbl *table) string {
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	tbl.print()
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestTable(t *testing.T) {
+	t.Run("cuts values to the column cap with an ellipsis", func(t *testing.T) {
+		defer resetFlags()
+		tbl := newTable(tableColumn{Header: "ID"}, tableColumn{Header: "Name", Max: 8})
+		tbl.addRow("inv-1", "A very long name")
+
+		out := captureTable(tbl)
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected header, separator and one row, got:\n%s", out)
+		}
+		if lines[0] != "ID     Name" || lines[2] != "inv-1  A very …" {
+			t.Errorf("unexpected table:\n%s", out)
+		}
+
+		// --wide shows the whole value
+		wide = true
+		out = captureTable(tbl)
+		if !strings.Contains(out, "inv-1  A very long name\n") {
+			t.Errorf("expected untruncated value with --wide, got:\n%s", out)
+		}
+	})
+
+	t.Run("no color when not a terminal or NO_COLOR is set", func(t *testing.T) {
+		defer resetFlags()
+		if colorize("deleted", styleRed) != "deleted" {
+			t.Error("expected no color when stdout is not a terminal")
+		}
+		t.Setenv("NO_COLOR", "1")
+		if colorEnabled() {
+			t.Error("expected NO_COLOR to disable color")
+		}
+	})
+
+	t.Run("list shows locked records", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		var rec map[string]interface{}
+		out := captureSchemaOutput(t, "add", "Laptop", "--json")
+		if err := json.Unmarshal([]byte(out), &rec); err != nil {
+			t.Fatalf("failed to parse add output: %v\n%s", err, out)
+		}
+		captureSchemaOutput(t, "add", "Desk")
+		captureSchemaOutput(t, "lock", rec["_id"].(string), "--agent", "agent-1")
+		resetLockFlags()
+		ExitCode = 0
+
+		out = captureSchemaOutput(t, "list")
+		if !strings.Contains(out, "locked") {
+			t.Fatalf("expected a locked record, got:\n%s", out)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Contains(line, "Laptop") && !strings.Contains(line, "locked") {
+				t.Errorf("expected Laptop to be locked, got %q", line)
+			}
+			if strings.Contains(line, "Desk") && !strings.Contains(line, "active") {
+				t.Errorf("expected Desk to be active, got %q", line)
+			}
+		}
+	})
+}
+
+func TestDisplayValue(t *testing.T) {
+	two := 2
+	price := &model.Column{Name: "Price", Unit: "USD", Decimals: &two, ThousandsSep: true}
+	tests := []struct {
+		col   *model.Column
+		value interface{}
+		want  string
+	}{
+		{price, 1299, "1,299.00 USD"},
+		{price, "1234567.891", "1,234,567.89 USD"},
+		{price, -1000.5, "-1,000.50 USD"},
+		{price, "n/a", "n/a"},
+		{price, nil, ""},
+		{price, []interface{}{1, 2000}, "1.00 USD, 2,000.00 USD"},
+		{&model.Column{Name: "Weight", Unit: "kg"}, 12.5, "12.5 kg"},
+		{&model.Column{Name: "Count", ThousandsSep: true}, "999", "999"},
+		{&model.Column{Name: "Code"}, "01234", "01234"},
+		{nil, 1299, "1299"},
+	}
+	for _, tt := range tests {
+		if got := displayValue(tt.col, tt.value); got != tt.want {
+			t.Errorf("displayValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestColumnDisplayFormat(t *testing.T) {
+	_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+	defer cleanup()
+
+	captureSchemaOutput(t, "column", "add", "Price", "--unit", "USD", "--decimals", "2", "--thousands-sep")
+	if ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", ExitCode)
+	}
+	captureSchemaOutput(t, "add", "Laptop", "--set", "Price=1299")
+
+	if out := captureSchemaOutput(t, "list", "--columns", "Name,Price"); !strings.Contains(out, "1,299.00 USD") {
+		t.Errorf("expected formatted price in table output, got:\n%s", out)
+	}
+	out := captureSchemaOutput(t, "list", "--json")
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatalf("failed to parse list output %q: %v", out, err)
+	}
+	if len(records) != 1 || valueText(records[0]["Price"]) != "1299" {
+		t.Errorf("expected raw price in JSON output, got %v", records)
+	}
+
+	captureSchemaOutput(t, "column", "add", "Weight", "--decimals", "-2")
+	if ExitCode != 2 {
+		t.Errorf("expected exit code 2 for negative decimals, got %d", ExitCode)
+	}
+}
@@ -2,22 +2,31 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
 )
 
-// Template represents a saved query template
+// Template represents a saved query template. Schedule and Owner are
+// free-form metadata for external schedulers, such as "daily" and the team
+// that reads the report.
 type Template struct {
 	Name      string    `json:"name"`
 	Query     string    `json:"query"`
 	Desc      string    `json:"desc,omitempty"`
+	Schedule  string    `json:"schedule,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	CreatedBy string    `json:"created_by"`
 }
@@ -29,7 +38,16 @@ const (
 	ErrCodeInvalidTemplate  = "INVALID_TEMPLATE"
 )
 
-var templateDesc string
+var (
+	templateDesc         string
+	templateSchedule     string
+	templateOwner        string
+	templateOutput       string
+	templateListSchedule string
+	templateInto         string
+	templatePrefix       string
+	templateReplace      bool
+)
 
 // templateNameRegex validates template names: alphanumeric, hyphens, underscores
 var templateNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
@@ -44,6 +62,7 @@ Templates let you save frequently-used queries for quick access.
 Examples:
   stash template save "needs-review" "SELECT id, name FROM tasks WHERE status='pending'"
   stash template run "needs-review"
+  stash template materialize "needs-review" --into review --prefix rv-
   stash template list
 
 AI Agent Examples:
@@ -64,9 +83,12 @@ Exit Codes:
   2  Validation error (invalid name, empty query)
 
 JSON Output (--json):
-  template list: [{"name": "high-priority", "query": "SELECT...", "created_at": "..."}]
+  template list: [{"name": "high-priority", "query": "SELECT...", "schedule": "daily",
+                  "owner": "ops", "created_at": "..."}]
   template show: {"name": "high-priority", "query": "SELECT...", "description": "..."}
   template run: (same as stash query output)
+  template run --output: {"name": "high-priority", "output": "report.csv",
+                          "format": "csv", "rows": 12}
 `,
 }
 
@@ -81,9 +103,19 @@ Template names must:
 
 The query must be a valid SELECT statement.
 
+--schedule and --owner are not interpreted by stash. They are shown by
+'stash template list' so that an external scheduler (cron, CI) can find
+which reports to run, and when.
+
+Options:
+  --desc TEXT       Template description
+  --schedule TEXT   When the report should run, e.g. "daily" or "0 6 * * 1"
+  --owner NAME      Who the report is for
+
 Examples:
   stash template save "high-priority" "SELECT * FROM inventory WHERE priority='high'"
   stash template save "needs-review" "SELECT id, name FROM tasks WHERE status='pending'" --desc "Tasks needing review"
+  stash template save "weekly-stock" "SELECT name, stock FROM inventory" --schedule weekly --owner ops
 
 Exit Codes:
   0  Success
@@ -103,25 +135,95 @@ Output flags from 'stash query' are supported:
   --no-headers   Omit header row in CSV output
   --columns      Select specific columns in CSV output
 
+--output writes the results to a file instead of stdout, as CSV or a JSON
+array depending on its extension (.csv or .json). An existing file is
+replaced. A summary of what was written is printed instead.
+
 Examples:
   stash template run "high-priority"
   stash template run "needs-review" --json
   stash template run "report" --csv > report.csv
+  stash template run "report" --output reports/report.json
+
+AI Agent Examples:
+  # Run every daily report from cron
+  stash template list --schedule daily --json | jq -r '.[].name' | while read name; do
+    stash template run "$name" --output "reports/$name.csv"
+  done
 
 Exit Codes:
   0  Success
-  1  Template not found`,
+  1  Template not found
+  2  Unsupported --output extension`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTemplateRun,
 }
 
+var templateMaterializeCmd = &cobra.Command{
+	Use:   "materialize <name> --into <stash>",
+	Short: "Write a template's results into a stash",
+	Long: `Run a saved query template and write each result row into a stash as a
+record, for report snapshots and derived datasets.
+
+The target stash is created if it does not exist, which requires
+--prefix. Result columns missing from the target stash are added to it,
+in result order, so the first result column is the primary column of a new
+stash. Cache system columns such as id and created_at are skipped; alias
+them to keep them (SELECT id AS source_id ...). Other result columns must
+be valid column names, so alias expressions (SELECT COUNT(*) AS total ...).
+
+Without --replace, rows are added alongside the stash's existing records.
+With --replace, the existing records are deleted first, so the stash holds
+only the latest snapshot (use 'stash purge' to remove them for good).
+
+Rows are written like 'stash add', and replaced records deleted like
+'stash rm': through the stash's permissions, locks, column validation,
+and hooks. Every row is checked before anything is written.
+
+Options:
+  --into STASH    Stash to write the results into (required)
+  --prefix PFX    ID prefix when the stash is created
+  --replace       Delete the stash's existing records first
+
+Examples:
+  stash template materialize low-stock --into restock --prefix rs-
+  stash template materialize weekly-totals --into totals --replace
+
+AI Agent Examples:
+  # Refresh a derived stash nightly, then work from it
+  stash template materialize open-bugs --into triage --replace --json
+  stash list --stash triage --json
+
+Exit Codes:
+  0  Success
+  1  Template not found
+  2  Validation error (invalid --into or --prefix, --into is the queried
+     stash, missing --prefix for a new stash, invalid result column name,
+     or a row breaks a column constraint or validation hook)
+  3  Query failed
+  5  A record to replace is locked by another agent
+  6  Permission denied (create, and delete with --replace; see
+     'stash permissions')
+  7  A write quota stopped the write
+
+JSON Output (--json):
+  {"name": "low-stock", "into": "restock", "created": true,
+   "new_columns": ["Name", "Stock"], "replaced": 0, "records": 12}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateMaterialize,
+}
+
 var templateListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all saved templates",
-	Long: `List all saved query templates.
+	Long: `List all saved query templates, with their schedule and owner if set.
+
+Options:
+  --schedule TEXT   Only list templates with this schedule
 
 Examples:
   stash template list
+  stash template list --schedule daily
   stash template list --json
 
 Exit Codes:
@@ -163,6 +265,14 @@ Exit Codes:
 
 func init() {
 	templateSaveCmd.Flags().StringVar(&templateDesc, "desc", "", "Template description")
+	templateSaveCmd.Flags().StringVar(&templateSchedule, "schedule", "", "When the report should run, for external schedulers")
+	templateSaveCmd.Flags().StringVar(&templateOwner, "owner", "", "Who the report is for")
+	templateRunCmd.Flags().StringVarP(&templateOutput, "output", "o", "", "Write results to a .csv or .json file")
+	templateListCmd.Flags().StringVar(&templateListSchedule, "schedule", "", "Only list templates with this schedule")
+	templateMaterializeCmd.Flags().StringVar(&templateInto, "into", "", "Stash to write the results into (required)")
+	templateMaterializeCmd.Flags().StringVar(&templatePrefix, "prefix", "", "ID prefix when the stash is created")
+	templateMaterializeCmd.Flags().BoolVar(&templateReplace, "replace", false, "Delete the stash's existing records first")
+	templateMaterializeCmd.MarkFlagRequired("into")
 
 	// Add query-compatible flags to run command
 	templateRunCmd.Flags().BoolVar(&queryCSV, "csv", false, "Output as CSV format")
@@ -171,6 +281,7 @@ func init() {
 
 	templateCmd.AddCommand(templateSaveCmd)
 	templateCmd.AddCommand(templateRunCmd)
+	templateCmd.AddCommand(templateMaterializeCmd)
 	templateCmd.AddCommand(templateListCmd)
 	templateCmd.AddCommand(templateShowCmd)
 	templateCmd.AddCommand(templateRmCmd)
@@ -288,6 +399,8 @@ func runTemplateSave(cmd *cobra.Command, args []string) error {
 		Name:      name,
 		Query:     query,
 		Desc:      templateDesc,
+		Schedule:  templateSchedule,
+		Owner:     templateOwner,
 		CreatedAt: now,
 		CreatedBy: ctx.Actor,
 	}
@@ -300,21 +413,16 @@ func runTemplateSave(cmd *cobra.Command, args []string) error {
 
 	// Output result
 	if GetJSONOutput() {
-		output := map[string]interface{}{
-			"name":       template.Name,
-			"query":      template.Query,
-			"desc":       template.Desc,
-			"created_at": template.CreatedAt.Format(time.RFC3339),
-			"created_by": template.CreatedBy,
-		}
-		data, _ := json.Marshal(output)
+		data, _ := json.Marshal(templateJSON(template))
 		fmt.Println(string(data))
 	} else if !IsQuiet() {
 		fmt.Printf("Saved template '%s'\n", template.Name)
 	}
 
-	// Reset flag for next call (important for tests)
+	// Reset flags for next call (important for tests)
 	templateDesc = ""
+	templateSchedule = ""
+	templateOwner = ""
 
 	return nil
 }
@@ -349,9 +457,317 @@ func runTemplateRun(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Execute the query using runQuery
-	// We need to set args for the query command
-	return runQuery(cmd, []string{template.Query})
+	if templateOutput == "" {
+		// Execute the query using runQuery
+		// We need to set args for the query command
+		return runQuery(cmd, []string{template.Query})
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(templateOutput)), ".")
+	if format != "csv" && format != "json" {
+		ExitValidationError(fmt.Sprintf("unsupported --output file '%s': must end in .csv or .json", templateOutput),
+			map[string]interface{}{"output": templateOutput})
+		return nil
+	}
+
+	rows, columns, ok, err := executeQuery(template.Query)
+	if !ok {
+		return err
+	}
+	if err := writeTemplateOutput(templateOutput, format, rows, columns); err != nil {
+		return err
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"name":   template.Name,
+			"output": templateOutput,
+			"format": format,
+			"rows":   len(rows),
+		})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Wrote %d row(s) to %s\n", len(rows), templateOutput)
+	}
+	return nil
+}
+
+// writeTemplateOutput writes query results to a CSV or JSON file. The file
+// is written under a temporary name and renamed, so a scheduled run never
+// leaves a half-written report behind.
+func writeTemplateOutput(path, format string, rows []map[string]interface{}, columns []string) error {
+	var buf bytes.Buffer
+	if format == "json" {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		buf.Write(append(data, '\n'))
+	} else if err := outputQueryCSV(&buf, rows, columns); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// templateJSON is the JSON form of a template in save, list, and show
+// output.
+func templateJSON(t *Template) map[string]interface{} {
+	output := map[string]interface{}{
+		"name":       t.Name,
+		"query":      t.Query,
+		"desc":       t.Desc,
+		"created_at": t.CreatedAt.Format(time.RFC3339),
+		"created_by": t.CreatedBy,
+	}
+	if t.Schedule != "" {
+		output["schedule"] = t.Schedule
+	}
+	if t.Owner != "" {
+		output["owner"] = t.Owner
+	}
+	return output
+}
+
+func runTemplateMaterialize(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	if err := model.ValidateStashName(templateInto); err != nil {
+		ExitValidationError(fmt.Sprintf("invalid --into: %v", err), map[string]interface{}{"into": templateInto})
+		return nil
+	}
+
+	// Resolve context (the queried stash may be needed below)
+	ctx, err := context.Resolve(GetActorName(), GetStashName())
+	if err != nil {
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Templates require a .stash directory
+	if ctx.StashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+
+	// Load templates
+	templates, err := loadTemplates(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	// Find template
+	template := findTemplate(templates, name)
+	if template == nil {
+		ExitWithError(1, ErrCodeTemplateNotFound,
+			fmt.Sprintf("template '%s' not found", name),
+			map[string]interface{}{"name": name})
+		return nil
+	}
+
+	if templateInto == ctx.Stash {
+		ExitValidationError(fmt.Sprintf("cannot materialize into '%s', the stash being queried", templateInto),
+			map[string]interface{}{"into": templateInto})
+		return nil
+	}
+
+	rows, resultColumns, ok, err := executeQuery(template.Query)
+	if !ok {
+		return err
+	}
+
+	// Result columns to store: system columns are skipped, and the rest
+	// must be usable as column names
+	var columns []string
+	for _, col := range resultColumns {
+		if storage.IsBaseColumn(col) {
+			continue
+		}
+		if err := model.ValidateColumnName(col); err != nil {
+			ExitValidationError(fmt.Sprintf("result column '%s' is not a valid column name (rename it with AS)", col),
+				map[string]interface{}{"column": col})
+			return nil
+		}
+		columns = append(columns, col)
+	}
+
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Create the target stash if needed
+	target, err := store.GetStash(templateInto)
+	created := false
+	if errors.Is(err, model.ErrStashNotFound) {
+		if templatePrefix == "" {
+			ExitValidationError(fmt.Sprintf("stash '%s' does not exist; --prefix is required to create it", templateInto),
+				map[string]interface{}{"into": templateInto})
+			return nil
+		}
+		if err := model.ValidatePrefix(templatePrefix); err != nil {
+			ExitValidationError(fmt.Sprintf("invalid --prefix: %v", err), map[string]interface{}{"prefix": templatePrefix})
+			return nil
+		}
+		target = &model.Stash{
+			Name:      templateInto,
+			Prefix:    templatePrefix,
+			Created:   time.Now(),
+			CreatedBy: ctx.Actor,
+			Columns:   model.ColumnList{},
+		}
+		if err := store.CreateStash(templateInto, templatePrefix, target); err != nil {
+			return fmt.Errorf("failed to create stash: %w", err)
+		}
+		if err := createStashFiles(store, filepath.Join(ctx.StashDir, templateInto)); err != nil {
+			return err
+		}
+		created = true
+	} else if err != nil {
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	// Rows are written like 'stash add', and a replaced snapshot is deleted
+	// like 'stash rm': everything is checked before anything is written
+	if !checkPermission(target, ctx.Actor, model.PermCreate, columns) {
+		return nil
+	}
+	var existing []*model.Record
+	if templateReplace && !created {
+		if !checkPermission(target, ctx.Actor, model.PermDelete, nil) {
+			return nil
+		}
+		existing, err = store.ListRecords(templateInto, storage.ListOptions{ParentID: "*"})
+		if err != nil {
+			return fmt.Errorf("failed to list records: %w", err)
+		}
+		intoCtx := *ctx
+		intoCtx.Stash = templateInto
+		if ok, err := checkRecordLocks(&intoCtx, existing); !ok {
+			return err
+		}
+	}
+
+	records := make([]*model.Record, 0, len(rows))
+	var warnings []ValidationError
+	for _, row := range rows {
+		fields := make(map[string]interface{})
+		for _, colName := range columns {
+			name, col := colName, target.Columns.Find(colName)
+			if col != nil {
+				name = col.Name
+			}
+			switch v := row[colName].(type) {
+			case nil:
+			case []byte:
+				fields[name] = columnValue(col, string(v))
+			case string:
+				fields[name] = columnValue(col, v)
+			default:
+				fields[name] = v
+			}
+		}
+		record := &model.Record{
+			CreatedBy: ctx.Actor,
+			UpdatedBy: ctx.Actor,
+			Fields:    fields,
+			Coerced:   coerceEnums(target, fields),
+		}
+		result := ValidateFields(target, fields)
+		if exitViolation(result) {
+			return nil
+		}
+		hookResult := ValidateHooks(target, record, nil)
+		if exitViolation(hookResult) {
+			return nil
+		}
+		warnings = append(warnings, result.Warnings...)
+		warnings = append(warnings, hookResult.Warnings...)
+		records = append(records, record)
+	}
+	printValidationWarnings(warnings)
+
+	// Add missing columns
+	newColumns := []string{}
+	for _, colName := range columns {
+		if target.Columns.Exists(colName) {
+			continue
+		}
+		col := model.Column{
+			Name:    colName,
+			Desc:    fmt.Sprintf("From template '%s'", template.Name),
+			Added:   time.Now(),
+			AddedBy: ctx.Actor,
+		}
+		if err := store.AddColumn(templateInto, col); err != nil {
+			return fmt.Errorf("failed to add column '%s': %w", colName, err)
+		}
+		target.Columns = append(target.Columns, col)
+		newColumns = append(newColumns, colName)
+	}
+
+	// Replace the previous snapshot
+	replaced := 0
+	for _, rec := range existing {
+		if err := store.DeleteRecord(templateInto, rec.ID, ctx.Actor); err != nil {
+			if exitWriteRefused(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to delete record '%s': %w", rec.ID, err)
+		}
+		replaced++
+	}
+
+	// Write one record per row
+	for _, record := range records {
+		recordID, err := store.NextRecordID(templateInto)
+		if err != nil {
+			return fmt.Errorf("failed to generate record ID: %w", err)
+		}
+		now := time.Now()
+		record.ID = recordID
+		record.CreatedAt, record.UpdatedAt = now, now
+		if err := store.CreateRecord(templateInto, record); err != nil {
+			if exitRecordTooLarge(templateInto, err) || exitWriteRefused(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to create record: %w", err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{
+			"name":        template.Name,
+			"into":        templateInto,
+			"created":     created,
+			"new_columns": newColumns,
+			"replaced":    replaced,
+			"records":     len(rows),
+		})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if created {
+			fmt.Printf("Created stash '%s' with prefix '%s'\n", templateInto, templatePrefix)
+		}
+		if len(newColumns) > 0 {
+			fmt.Printf("Added column(s): %s\n", strings.Join(newColumns, ", "))
+		}
+		if replaced > 0 {
+			fmt.Printf("Deleted %d previous record(s)\n", replaced)
+		}
+		fmt.Printf("Wrote %d record(s) from template '%s' to stash '%s'\n", len(rows), template.Name, templateInto)
+	}
+	return nil
 }
 
 func runTemplateList(cmd *cobra.Command, args []string) error {
@@ -373,17 +789,21 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load templates: %w", err)
 	}
 
+	if templateListSchedule != "" {
+		var scheduled []*Template
+		for _, t := range templates {
+			if strings.EqualFold(t.Schedule, templateListSchedule) {
+				scheduled = append(scheduled, t)
+			}
+		}
+		templates = scheduled
+	}
+
 	// Output result
 	if GetJSONOutput() {
 		output := make([]map[string]interface{}, len(templates))
 		for i, t := range templates {
-			output[i] = map[string]interface{}{
-				"name":       t.Name,
-				"query":      t.Query,
-				"desc":       t.Desc,
-				"created_at": t.CreatedAt.Format(time.RFC3339),
-				"created_by": t.CreatedBy,
-			}
+			output[i] = templateJSON(t)
 		}
 		data, _ := json.Marshal(output)
 		fmt.Println(string(data))
@@ -393,11 +813,21 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 		} else {
 			fmt.Println("Templates:")
 			for _, t := range templates {
+				line := "  " + t.Name
 				if t.Desc != "" {
-					fmt.Printf("  %s - %s\n", t.Name, t.Desc)
-				} else {
-					fmt.Printf("  %s\n", t.Name)
+					line += " - " + t.Desc
+				}
+				var meta []string
+				if t.Schedule != "" {
+					meta = append(meta, "schedule: "+t.Schedule)
+				}
+				if t.Owner != "" {
+					meta = append(meta, "owner: "+t.Owner)
 				}
+				if len(meta) > 0 {
+					line += " (" + strings.Join(meta, ", ") + ")"
+				}
+				fmt.Println(line)
 			}
 		}
 	}
@@ -437,20 +867,19 @@ func runTemplateShow(cmd *cobra.Command, args []string) error {
 
 	// Output result
 	if GetJSONOutput() {
-		output := map[string]interface{}{
-			"name":       template.Name,
-			"query":      template.Query,
-			"desc":       template.Desc,
-			"created_at": template.CreatedAt.Format(time.RFC3339),
-			"created_by": template.CreatedBy,
-		}
-		data, _ := json.Marshal(output)
+		data, _ := json.Marshal(templateJSON(template))
 		fmt.Println(string(data))
 	} else if !IsQuiet() {
 		fmt.Printf("Name: %s\n", template.Name)
 		if template.Desc != "" {
 			fmt.Printf("Description: %s\n", template.Desc)
 		}
+		if template.Schedule != "" {
+			fmt.Printf("Schedule: %s\n", template.Schedule)
+		}
+		if template.Owner != "" {
+			fmt.Printf("Owner: %s\n", template.Owner)
+		}
 		fmt.Printf("Query: %s\n", template.Query)
 		fmt.Printf("Created: %s by %s\n", template.CreatedAt.Format(time.RFC3339), template.CreatedBy)
 	}
@@ -6,11 +6,21 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
 )
 
 // resetTemplateFlags resets template and global flags between tests
 func resetTemplateFlags() {
 	templateDesc = ""
+	templateSchedule = ""
+	templateOwner = ""
+	templateOutput = ""
+	templateListSchedule = ""
+	templateInto = ""
+	templatePrefix = ""
+	templateReplace = false
 	jsonOutput = false
 	stashName = ""
 	actorName = ""
@@ -819,3 +829,231 @@ func TestTemplateNoStashDir(t *testing.T) {
 		})
 	}
 }
+
+// TestTemplateRunOutput tests writing template results to a file
+func TestTemplateRunOutput(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+	resetTemplateFlags()
+
+	for _, args := range [][]string{
+		{"init", "inventory", "--prefix", "inv-"},
+		{"column", "add", "Name", "Priority"},
+		{"add", "Widget", "--set", "Priority=high"},
+		{"template", "save", "all-items", "SELECT Name, Priority FROM inventory"},
+	} {
+		rootCmd.SetArgs(args)
+		rootCmd.Execute()
+		resetTemplateFlags()
+	}
+	ExitCode = 0
+
+	t.Run("writes CSV by extension", func(t *testing.T) {
+		path := filepath.Join(tempDir, "report.csv")
+		output := captureSchemaOutput(t, "template", "run", "all-items", "--output", path, "--json")
+		resetTemplateFlags()
+
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["format"] != "csv" || result["rows"] != float64(1) {
+			t.Errorf("expected 1 csv row, got %v", result)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected report file: %v", err)
+		}
+		if string(data) != "Name,Priority\nWidget,high\n" {
+			t.Errorf("unexpected CSV: %q", data)
+		}
+	})
+
+	t.Run("writes JSON by extension", func(t *testing.T) {
+		path := filepath.Join(tempDir, "report.json")
+		captureSchemaOutput(t, "template", "run", "all-items", "-o", path)
+		resetTemplateFlags()
+
+		data, _ := os.ReadFile(path)
+		var rows []map[string]interface{}
+		if err := json.Unmarshal(data, &rows); err != nil {
+			t.Fatalf("expected JSON array in file: %v\n%s", err, data)
+		}
+		if len(rows) != 1 || rows[0]["Name"] != "Widget" {
+			t.Errorf("unexpected rows: %v", rows)
+		}
+	})
+
+	t.Run("rejects unknown extensions", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "run", "all-items", "--output", filepath.Join(tempDir, "report.xlsx"))
+		resetTemplateFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+// TestTemplateScheduleMetadata tests schedule and owner metadata in list
+func TestTemplateScheduleMetadata(t *testing.T) {
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+	resetTemplateFlags()
+
+	for _, args := range [][]string{
+		{"init", "inventory", "--prefix", "inv-"},
+		{"template", "save", "daily-stock", "SELECT * FROM inventory", "--schedule", "daily", "--owner", "ops"},
+		{"template", "save", "adhoc", "SELECT * FROM inventory"},
+	} {
+		rootCmd.SetArgs(args)
+		rootCmd.Execute()
+		resetTemplateFlags()
+	}
+	ExitCode = 0
+
+	output := captureSchemaOutput(t, "template", "list", "--schedule", "daily", "--json")
+	resetTemplateFlags()
+	var templates []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &templates); err != nil {
+		t.Fatalf("failed to parse output %q: %v", output, err)
+	}
+	if len(templates) != 1 {
+		t.Fatalf("expected only the daily template, got %v", templates)
+	}
+	if templates[0]["name"] != "daily-stock" || templates[0]["schedule"] != "daily" || templates[0]["owner"] != "ops" {
+		t.Errorf("unexpected template metadata: %v", templates[0])
+	}
+
+	output = captureSchemaOutput(t, "template", "list")
+	resetTemplateFlags()
+	if !strings.Contains(output, "daily-stock (schedule: daily, owner: ops)") {
+		t.Errorf("expected schedule and owner in list output, got %q", output)
+	}
+}
+
+// TestTemplateMaterialize tests writing template results into a stash
+func TestTemplateMaterialize(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+	resetTemplateFlags()
+
+	for _, args := range [][]string{
+		{"init", "inventory", "--prefix", "inv-"},
+		{"column", "add", "Name", "Stock"},
+		{"add", "Widget", "--set", "Stock=2"},
+		{"add", "Gadget", "--set", "Stock=50"},
+		{"template", "save", "low-stock", "SELECT id, Name, Stock FROM inventory WHERE CAST(Stock AS INTEGER) < 10"},
+		{"template", "save", "count", "SELECT COUNT(*) FROM inventory"},
+	} {
+		rootCmd.SetArgs(args)
+		rootCmd.Execute()
+		resetTemplateFlags()
+	}
+	ExitCode = 0
+
+	restock := func() []*model.Record {
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("restock", storage.ListOptions{ParentID: "*"})
+		return records
+	}
+
+	t.Run("requires --prefix for a new stash", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock", "--stash", "inventory")
+		resetTemplateFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("creates the stash and its columns", func(t *testing.T) {
+		ExitCode = 0
+		output := captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock",
+			"--prefix", "rs-", "--stash", "inventory", "--json")
+		resetTemplateFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["created"] != true || result["records"] != float64(1) {
+			t.Errorf("unexpected result: %v", result)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		stash, err := store.GetStash("restock")
+		store.Close()
+		if err != nil {
+			t.Fatalf("expected stash restock: %v", err)
+		}
+		if names := stash.Columns.Names(); len(names) != 2 || names[0] != "Name" || names[1] != "Stock" {
+			t.Errorf("expected columns [Name Stock] without id, got %v", names)
+		}
+
+		records := restock()
+		if len(records) != 1 || records[0].Fields["Name"] != "Widget" {
+			t.Errorf("expected one Widget record, got %v", records)
+		}
+	})
+
+	t.Run("--replace keeps only the latest snapshot", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock", "--stash", "inventory")
+		resetTemplateFlags()
+		if n := len(restock()); n != 2 {
+			t.Fatalf("expected rows to be appended, got %d records", n)
+		}
+
+		captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock", "--stash", "inventory", "--replace")
+		resetTemplateFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if n := len(restock()); n != 1 {
+			t.Errorf("expected 1 record after --replace, got %d", n)
+		}
+	})
+
+	t.Run("writes through permissions and validation", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"permissions", "set", "agent", "--ops", "create", "--stash", "restock"})
+		rootCmd.Execute()
+		resetFlags()
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock", "--stash", "inventory",
+			"--replace", "--actor", "agent")
+		resetTemplateFlags()
+		if ExitCode != 6 {
+			t.Errorf("expected exit code 6 for a denied delete, got %d", ExitCode)
+		}
+		if n := len(restock()); n != 1 {
+			t.Errorf("expected the snapshot untouched, got %d records", n)
+		}
+
+		rootCmd.SetArgs([]string{"column", "add", "Owner", "--required", "--stash", "restock"})
+		rootCmd.Execute()
+		resetFlags()
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "materialize", "low-stock", "--into", "restock", "--stash", "inventory", "--replace")
+		resetTemplateFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for a missing required column, got %d", ExitCode)
+		}
+		if n := len(restock()); n != 1 {
+			t.Errorf("expected the snapshot untouched, got %d records", n)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("rejects unnamed expressions", func(t *testing.T) {
+		ExitCode = 0
+		captureSchemaOutput(t, "template", "materialize", "count", "--into", "totals", "--prefix", "tot-", "--stash", "inventory")
+		resetTemplateFlags()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -1,4 +1,5 @@
-// Package templates provides embedded slash command templates for Claude Code integration.
+// Package templates provides embedded slash command templates for Claude Code integration
+// and the built-in schema presets used by 'stash init --preset'.
 package templates
 
 import "embed"
@@ -7,3 +8,8 @@ import "embed"
 //
 //go:embed commands/*.md
 var Commands embed.FS
+
+// Presets contains the embedded schema preset files, one YAML schema per preset.
+//
+//go:embed presets/*.yaml
+var Presets embed.FS
@@ -0,0 +1,266 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+)
+
+var (
+	transitionsSet   string
+	transitionsClear bool
+)
+
+var transitionsCmd = &cobra.Command{
+	Use:   "transitions <column>",
+	Short: "Inspect or define allowed transitions for an enum column",
+	Long: `Inspect or define the workflow graph for an enum column.
+
+Transitions restrict which values may follow the current value when
+using 'stash set'. Setting an initial (empty) value is always allowed.
+Use 'stash set --force' to override the workflow.
+
+Transition syntax:
+  "pending>active,active>closed"   Comma-separated edges
+  "pending>active>closed"          Chains expand to consecutive edges
+
+Examples:
+  stash transitions Status
+  stash transitions Status --set "pending>active>closed,active>pending"
+  stash transitions Status --clear
+  stash transitions Status --json
+
+AI Agent Examples:
+  # Check which states may follow the current one
+  CURRENT=$(stash show "$ID" --json | jq -r '.Status')
+  stash transitions Status --json | jq -r --arg s "$CURRENT" '.transitions[$s][]'
+
+Exit Codes:
+  0  Success
+  1  Stash or column not found
+  2  Validation error (column has no enum, unknown state, invalid syntax)
+
+JSON Output (--json):
+  {"column": "Status", "states": ["pending", "active", "closed"],
+   "transitions": {"pending": ["active"], "active": ["closed"]}}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTransitions,
+}
+
+func init() {
+	transitionsCmd.Flags().StringVar(&transitionsSet, "set", "", "Replace transitions (e.g., \"pending>active,active>closed\")")
+	transitionsCmd.Flags().BoolVar(&transitionsClear, "clear", false, "Remove all transition restrictions")
+	rootCmd.AddCommand(transitionsCmd)
+}
+
+func runTransitions(cmd *cobra.Command, args []string) error {
+	columnName := args[0]
+
+	if transitionsSet != "" && transitionsClear {
+		ExitValidationError("--set and --clear cannot be used together", nil)
+		return nil
+	}
+
+	// Resolve context
+	ctx, err := context.ResolveRequired(GetActorName(), GetStashName())
+	if err != nil {
+		if errors.Is(err, context.ErrNoStashDir) {
+			ExitNoStashDir()
+			return nil
+		}
+		if errors.Is(err, context.ErrNoStash) {
+			ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+			return nil
+		}
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// Get stash configuration
+	stash, err := store.GetStash(ctx.Stash)
+	if err != nil {
+		if errors.Is(err, model.ErrStashNotFound) {
+			ExitStashNotFound(ctx.Stash)
+			return nil
+		}
+		return fmt.Errorf("failed to get stash: %w", err)
+	}
+
+	col := resolveColumn(stash, columnName)
+	if col == nil {
+		return nil
+	}
+
+	// Update the workflow if requested
+	if transitionsSet != "" || transitionsClear {
+		if transitionsClear {
+			col.Transitions = nil
+		} else {
+			transitions, err := parseTransitions(transitionsSet, col.Enum)
+			if err != nil {
+				ExitValidationError(err.Error(), map[string]interface{}{"column": col.Name})
+				return nil
+			}
+			col.Transitions = transitions
+		}
+
+		if err := store.UpdateStashConfig(stash); err != nil {
+			return fmt.Errorf("failed to update transitions: %w", err)
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		transitions := col.Transitions
+		if transitions == nil {
+			transitions = map[string][]string{}
+		}
+		states := col.Enum
+		if states == nil {
+			states = []string{}
+		}
+		output := map[string]interface{}{
+			"column":      col.Name,
+			"states":      states,
+			"transitions": transitions,
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsQuiet() {
+		return nil
+	}
+
+	if len(col.Transitions) == 0 {
+		fmt.Printf("Column '%s' has no transition restrictions\n", col.Name)
+		return nil
+	}
+
+	fmt.Printf("Transitions for column '%s':\n", col.Name)
+
+	// Pad state names so arrows line up
+	width := 0
+	for _, state := range col.Enum {
+		if len(state) > width {
+			width = len(state)
+		}
+	}
+	for _, state := range col.Enum {
+		next := col.Transitions[state]
+		if len(next) == 0 {
+			fmt.Printf("  %-*s    (terminal)\n", width, state)
+		} else {
+			fmt.Printf("  %-*s -> %s\n", width, state, strings.Join(next, ", "))
+		}
+	}
+
+	return nil
+}
+
+// parseTransitions parses a transition spec like "pending>active,active>closed"
+// into an adjacency map. Chains such as "a>b>c" expand to consecutive edges.
+// All states must be values of the column's enum.
+func parseTransitions(spec string, enum []string) (map[string][]string, error) {
+	if len(enum) == 0 {
+		return nil, fmt.Errorf("transitions require an enum column (use --enum)")
+	}
+
+	allowed := make(map[string]bool, len(enum))
+	for _, v := range enum {
+		allowed[v] = true
+	}
+
+	transitions := make(map[string][]string)
+	for _, edge := range strings.Split(spec, ",") {
+		edge = strings.TrimSpace(edge)
+		if edge == "" {
+			continue
+		}
+
+		states := strings.Split(strings.ReplaceAll(edge, "->", ">"), ">")
+		if len(states) < 2 {
+			return nil, fmt.Errorf("invalid transition '%s' (expected from>to)", edge)
+		}
+
+		for i := range states {
+			states[i] = strings.TrimSpace(states[i])
+			if !allowed[states[i]] {
+				return nil, fmt.Errorf("unknown state '%s' in transition '%s' (allowed: %s)",
+					states[i], edge, strings.Join(enum, ", "))
+			}
+		}
+
+		for i := 0; i < len(states)-1; i++ {
+			from, to := states[i], states[i+1]
+			duplicate := false
+			for _, existing := range transitions[from] {
+				if existing == to {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				transitions[from] = append(transitions[from], to)
+			}
+		}
+	}
+
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("no transitions specified")
+	}
+
+	return transitions, nil
+}
+
+// checkTransition reports an illegal workflow move for a column, if any.
+// Returns true if the update may proceed.
+func checkTransition(col *model.Column, record *model.Record, newValue interface{}) bool {
+	message, details := transitionProblem(col, record, newValue)
+	if message == "" {
+		return true
+	}
+	ExitValidationError(message, details)
+	return false
+}
+
+// transitionProblem returns why setting a column of a record to a value
+// is an illegal transition, with its error details, or "" if it is legal.
+func transitionProblem(col *model.Column, record *model.Record, newValue interface{}) (string, map[string]interface{}) {
+	from := ""
+	if current, ok := record.GetField(col.Name); ok && current != nil {
+		from = fmt.Sprintf("%v", current)
+	}
+	to := fmt.Sprintf("%v", newValue)
+	if to == "" || col.AllowsTransition(from, to) {
+		return "", nil
+	}
+
+	next := col.Transitions[from]
+	allowedMsg := "none (terminal state)"
+	if len(next) > 0 {
+		allowedMsg = strings.Join(next, ", ")
+	}
+	return fmt.Sprintf("illegal transition for '%s': %s -> %s (allowed: %s; use --force to override)",
+			col.Name, from, to, allowedMsg),
+		map[string]interface{}{
+			"column":  col.Name,
+			"from":    from,
+			"to":      to,
+			"allowed": next,
+			"rule":    "transition",
+		}
+}
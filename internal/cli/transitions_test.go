@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+// setupWorkflowStash creates a stash with a Status enum column restricted to
+// pending -> active -> closed, plus one record in the "pending" state.
+func setupWorkflowStash(t *testing.T) (tempDir string, recordID string, cleanup func()) {
+	t.Helper()
+	tempDir, cleanup = setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+
+	rootCmd.SetArgs([]string{"column", "add", "Status", "--enum", "pending,active,closed", "--transitions", "pending>active>closed"})
+	rootCmd.Execute()
+	if ExitCode != 0 {
+		t.Fatalf("failed to add Status column, exit code %d", ExitCode)
+	}
+
+	rootCmd.SetArgs([]string{"add", "Task", "--set", "Status=pending"})
+	rootCmd.Execute()
+
+	store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	records, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	ExitCode = 0
+	resetFlags()
+	return tempDir, records[0].ID, cleanup
+}
+
+func getStatus(t *testing.T, tempDir, recordID string) string {
+	t.Helper()
+	store, err := storage.NewStore(filepath.Join(tempDir, ".stash"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	rec, err := store.GetRecord("tasks", recordID)
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	return fmt.Sprintf("%v", rec.Fields["Status"])
+}
+
+func TestTransitions(t *testing.T) {
+	t.Run("set allows a permitted transition", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"set", recordID, "Status=active"})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := getStatus(t, tempDir, recordID); got != "active" {
+			t.Errorf("expected Status 'active', got '%s'", got)
+		}
+	})
+
+	t.Run("set rejects an illegal transition", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"set", recordID, "Status=closed"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+		if got := getStatus(t, tempDir, recordID); got != "pending" {
+			t.Errorf("expected Status to remain 'pending', got '%s'", got)
+		}
+	})
+
+	t.Run("set --force overrides the workflow", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"set", recordID, "Status=closed", "--force"})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := getStatus(t, tempDir, recordID); got != "closed" {
+			t.Errorf("expected Status 'closed', got '%s'", got)
+		}
+	})
+
+	t.Run("transitions --set replaces the graph", func(t *testing.T) {
+		tempDir, recordID, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"transitions", "Status", "--set", "pending>closed"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"set", recordID, "Status=closed"})
+		rootCmd.Execute()
+
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+		if got := getStatus(t, tempDir, recordID); got != "closed" {
+			t.Errorf("expected Status 'closed', got '%s'", got)
+		}
+	})
+
+	t.Run("transitions JSON output lists the graph", func(t *testing.T) {
+		_, _, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		rootCmd.SetArgs([]string{"transitions", "Status", "--json"})
+		rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		output := buf.String()
+
+		want := `{"column":"Status","states":["pending","active","closed"],"transitions":{"active":["closed"],"pending":["active"]}}`
+		if output != want+"\n" {
+			t.Errorf("unexpected output:\n got: %s\nwant: %s", output, want)
+		}
+	})
+
+	t.Run("must not allow unknown states", func(t *testing.T) {
+		_, _, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"transitions", "Status", "--set", "pending>done"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("must not allow transitions on non-enum columns", func(t *testing.T) {
+		_, _, cleanup := setupWorkflowStash(t)
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"transitions", "Name", "--set", "a>b"})
+		rootCmd.Execute()
+
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
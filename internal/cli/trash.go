@@ -0,0 +1,358 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	trashDeletedBy      string
+	trashOlderThan      string
+	trashRestoreAll     bool
+	trashPurgeOlderThan string
+	trashDryRun         bool
+	trashYes            bool
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore, or empty soft-deleted records",
+	Long: `List the soft-deleted records of the stash, newest deletion first, with
+who deleted them, how long ago, and how large they are.
+
+The trash is the stash's recycle bin: 'stash rm' moves records into it,
+and they stay there, restorable, until they are purged. --deleted-by and
+--older-than narrow the records; the same filters select what
+--restore-all restores or --purge-older-than purges.
+
+--restore-all restores every record listed. --purge-older-than <dur>
+permanently removes the records deleted longer ago than the duration, as
+'stash purge --before' does: it cannot be undone, so it asks first unless
+--yes is given. Deleted records whose children are still live are never
+purged. --dry-run previews either without changing anything.
+
+Examples:
+  stash trash                                     # What is in the trash?
+  stash trash --deleted-by agent-x                # What did agent-x delete?
+  stash trash --restore-all --deleted-by agent-x  # Undo agent-x's deletions
+  stash trash --purge-older-than 30d --dry-run    # Preview emptying old records
+  stash trash --purge-older-than 30d --yes
+
+Exit Codes:
+  0  Success (also when the trash is empty)
+  1  Stash not found, or purge not confirmed
+  2  Validation error (invalid duration, or both --restore-all and --purge-older-than)
+  5  A record to restore is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+Output:
+  With --porcelain, one line per record: ID, deleted at, deleted by, size
+  in bytes.
+
+JSON Output (--json):
+  [{"id": "inv-ex4j", "deleted_at": "...", "deleted_by": "agent-x",
+    "age_seconds": 86400, "size": 182, "record": {...}}]
+
+JSON Output (--restore-all --json):
+  {"stash": "inventory", "restored": 2, "ids": ["inv-ex4j", "inv-8t2m"]}
+
+JSON Output (--purge-older-than --json):
+  {"stash": "inventory", "purged": 2, "ids": ["inv-ex4j", "inv-8t2m"]}`,
+	Args: cobra.NoArgs,
+	RunE: runTrash,
+}
+
+func init() {
+	trashCmd.Flags().StringVar(&trashDeletedBy, "deleted-by", "", "Only records deleted by this actor (\"me\" for yourself)")
+	trashCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only records deleted longer ago than this (e.g., 7d, 24h)")
+	trashCmd.Flags().BoolVar(&trashRestoreAll, "restore-all", false, "Restore every record listed")
+	trashCmd.Flags().StringVar(&trashPurgeOlderThan, "purge-older-than", "", "Permanently delete records deleted longer ago than this (e.g., 30d)")
+	trashCmd.Flags().BoolVar(&trashDryRun, "dry-run", false, "Preview --restore-all or --purge-older-than without making changes")
+	trashCmd.Flags().BoolVarP(&trashYes, "yes", "y", false, "Skip the purge confirmation prompt")
+	rootCmd.AddCommand(trashCmd)
+}
+
+// trashEntry is a soft-deleted record as listed by 'stash trash'.
+type trashEntry struct {
+	ID         string        `json:"id"`
+	DeletedAt  time.Time     `json:"deleted_at"`
+	DeletedBy  string        `json:"deleted_by"`
+	AgeSeconds int64         `json:"age_seconds"`
+	Size       int64         `json:"size"`
+	Record     *model.Record `json:"record"`
+}
+
+// formatAge formats how long ago something happened at the coarsest
+// whole unit, e.g. "45m", "3h", "12d".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func runTrash(cmd *cobra.Command, args []string) error {
+	if trashRestoreAll && trashPurgeOlderThan != "" {
+		ExitValidationError("--restore-all and --purge-older-than cannot be used together", nil)
+		return nil
+	}
+	if trashDryRun && !trashRestoreAll && trashPurgeOlderThan == "" {
+		ExitValidationError("--dry-run previews --restore-all or --purge-older-than", nil)
+		return nil
+	}
+	now := time.Now()
+	var cutoff time.Time
+	for _, value := range []string{trashOlderThan, trashPurgeOlderThan} {
+		if value == "" {
+			continue
+		}
+		d, err := parsePurgeDuration(value)
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("invalid duration '%s': %v", value, err), map[string]interface{}{"duration": value})
+			return nil
+		}
+		if t := now.Add(-d); cutoff.IsZero() || t.Before(cutoff) {
+			cutoff = t
+		}
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	opts := storage.ListOptions{IncludeDeleted: true, DeletedOnly: true, ParentID: "*"}
+	if trashDeletedBy != "" {
+		opts.DeletedBy = resolveAssignee(trashDeletedBy, ctx.Actor)
+	}
+	deleted, err := store.ListRecords(stash.Name, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list deleted records: %w", err)
+	}
+	var records []*model.Record
+	for _, rec := range deleted {
+		if rec.DeletedAt == nil || (!cutoff.IsZero() && !rec.DeletedAt.Before(cutoff)) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].DeletedAt.After(*records[j].DeletedAt) })
+
+	if trashRestoreAll {
+		return restoreTrash(ctx, store, stash, records)
+	}
+	if trashPurgeOlderThan != "" {
+		return purgeTrash(ctx, store, stash, records)
+	}
+
+	entries := []trashEntry{}
+	for _, rec := range records {
+		entries = append(entries, trashEntry{
+			ID:         rec.ID,
+			DeletedAt:  *rec.DeletedAt,
+			DeletedBy:  rec.DeletedBy,
+			AgeSeconds: int64(now.Sub(*rec.DeletedAt).Seconds()),
+			Size:       model.RecordSize(rec),
+			Record:     rec,
+		})
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsPorcelain() {
+		for _, entry := range entries {
+			printPorcelain(entry.ID, porcelainTime(entry.DeletedAt), entry.DeletedBy, fmt.Sprint(entry.Size))
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		Infof("The trash of stash '%s' is empty.\n", stash.Name)
+		return nil
+	}
+	columns := []tableColumn{
+		{Header: "ID", System: true},
+		{Header: "Deleted", System: true},
+		{Header: "Age", System: true},
+		{Header: "By", Max: 20},
+		{Header: "Size", System: true},
+	}
+	if len(stash.Columns) > 0 {
+		columns = append(columns, tableColumn{Header: stash.Columns[0].Name, Max: 40})
+	}
+	t := newTable(columns...)
+	var total int64
+	for _, entry := range entries {
+		row := []string{
+			entry.ID,
+			entry.DeletedAt.Local().Format("2006-01-02 15:04:05"),
+			formatAge(now.Sub(entry.DeletedAt)),
+			entry.DeletedBy,
+			model.FormatSize(entry.Size),
+		}
+		if len(stash.Columns) > 0 {
+			row = append(row, displayValue(&stash.Columns[0], entry.Record.Fields[stash.Columns[0].Name]))
+		}
+		t.addRow(row...)
+		total += entry.Size
+	}
+	t.print()
+	Infof("\n%d deleted record(s), %s ('stash trash --restore-all' to restore, --purge-older-than to empty)\n", len(entries), model.FormatSize(total))
+	return nil
+}
+
+// restoreTrash restores the given deleted records.
+func restoreTrash(ctx *context.Context, store *storage.Store, stash *model.Stash, records []*model.Record) error {
+	actor := ctx.Actor
+	if !checkPermission(stash, actor, model.PermRestore, nil) {
+		return nil
+	}
+	if ok, err := checkRecordLocks(ctx, records); !ok {
+		return err
+	}
+
+	if trashDryRun {
+		now := time.Now()
+		for _, rec := range records {
+			rec.DeletedAt = nil
+			rec.DeletedBy = ""
+			rec.UpdatedAt = now
+			rec.UpdatedBy = actor
+		}
+		ops, err := previewOperations(store, stash.Name, records, model.OpRestore)
+		if err != nil {
+			return err
+		}
+		return outputDryRun(stash.Name, ops, map[string]interface{}{
+			"would_restore": len(records),
+			"ids":           getRecordIDs(records),
+		})
+	}
+
+	restored := []*model.Record{}
+	for _, rec := range records {
+		if err := store.RestoreRecord(stash.Name, rec.ID, actor); err != nil {
+			if exitWriteRefused(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to restore record %s: %w", rec.ID, err)
+		}
+		restored = append(restored, rec)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "restored": len(restored), "ids": getRecordIDs(restored)})
+		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		for _, rec := range restored {
+			printPorcelain(rec.ID)
+		}
+	} else if !IsQuiet() {
+		fmt.Printf("Restored %d record(s) from the trash\n", len(restored))
+		if IsVerbose() {
+			for _, rec := range restored {
+				fmt.Printf("  - %s\n", rec.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// purgeTrash permanently deletes the given deleted records, skipping those
+// with live children, after confirmation.
+func purgeTrash(ctx *context.Context, store *storage.Store, stash *model.Stash, records []*model.Record) error {
+	if !checkPermission(stash, ctx.Actor, model.PermDelete, nil) {
+		return nil
+	}
+
+	// Purging a parent would orphan its live children
+	var toPurge []*model.Record
+	for _, rec := range records {
+		children, err := store.GetChildren(stash.Name, rec.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get children: %w", err)
+		}
+		if len(children) > 0 {
+			if !IsQuiet() {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: it has %d live child record(s)\n", rec.ID, len(children))
+			}
+			continue
+		}
+		toPurge = append(toPurge, rec)
+	}
+
+	if trashDryRun {
+		if GetJSONOutput() {
+			data, _ := json.Marshal(map[string]interface{}{"dry_run": true, "would_purge": len(toPurge), "ids": getRecordIDs(toPurge)})
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("Would purge %d record(s):\n", len(toPurge))
+			for _, rec := range toPurge {
+				fmt.Printf("  - %s (deleted: %s)\n", rec.ID, rec.DeletedAt.Local().Format("2006-01-02 15:04:05"))
+			}
+		}
+		return nil
+	}
+
+	if len(toPurge) > 0 && !trashYes && !IsQuiet() {
+		fmt.Printf("Permanently delete %d record(s)? This cannot be undone! [y/N]: ", len(toPurge))
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "Y" {
+			fmt.Fprintln(os.Stderr, "Aborted.")
+			Exit(1)
+			return nil
+		}
+	}
+
+	purged := []*model.Record{}
+	for _, rec := range toPurge {
+		if err := store.PurgeRecord(stash.Name, rec.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to purge %s: %v\n", rec.ID, err)
+			continue
+		}
+		purged = append(purged, rec)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"stash": stash.Name, "purged": len(purged), "ids": getRecordIDs(purged)})
+		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		for _, rec := range purged {
+			printPorcelain(rec.ID)
+		}
+	} else if !IsQuiet() {
+		fmt.Printf("Purged %d record(s) from the trash\n", len(purged))
+		if IsVerbose() {
+			for _, rec := range purged {
+				fmt.Printf("  - %s\n", rec.ID)
+			}
+		}
+	}
+	return nil
+}
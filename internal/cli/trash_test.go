@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestTrash(t *testing.T) {
+	// trashIDs lists the IDs in the trash
+	trashIDs := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		var entries []trashEntry
+		output := captureSchemaOutput(t, append([]string{"trash", "--json"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &entries); err != nil {
+			t.Fatalf("failed to parse trash output %q: %v", output, err)
+		}
+		var ids []string
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	t.Run("lists and restores the records an actor deleted", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop")
+		captureSchemaOutput(t, "add", "Mouse")
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.DeleteRecord("inventory", records[0].ID, "agent-x")
+		store.DeleteRecord("inventory", records[1].ID, "alice")
+		store.Close()
+
+		if ids := trashIDs(t); len(ids) != 2 {
+			t.Fatalf("expected 2 records in the trash, got %v", ids)
+		}
+		if ids := trashIDs(t, "--deleted-by", "agent-x"); len(ids) != 1 || ids[0] != records[0].ID {
+			t.Errorf("expected only %s deleted by agent-x, got %v", records[0].ID, ids)
+		}
+
+		captureSchemaOutput(t, "trash", "--restore-all", "--deleted-by", "agent-x")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		if ids := trashIDs(t); len(ids) != 1 || ids[0] != records[1].ID {
+			t.Errorf("expected only %s left in the trash, got %v", records[1].ID, ids)
+		}
+	})
+
+	t.Run("purges only records older than the duration", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop")
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		records, _ := store.ListRecords("inventory", storage.ListOptions{ParentID: "*"})
+		store.DeleteRecord("inventory", records[0].ID, "alice")
+		store.Close()
+
+		captureSchemaOutput(t, "trash", "--purge-older-than", "1d", "--yes")
+		if ids := trashIDs(t); len(ids) != 1 {
+			t.Errorf("expected the recent deletion to stay in the trash, got %v", ids)
+		}
+		captureSchemaOutput(t, "trash", "--purge-older-than", "0s", "--yes")
+		if ids := trashIDs(t); len(ids) != 0 {
+			t.Errorf("expected the trash to be empty, got %v", ids)
+		}
+	})
+
+	t.Run("rejects restoring and purging together", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "trash", "--restore-all", "--purge-older-than", "30d")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
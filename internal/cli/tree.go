@@ -0,0 +1,239 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	treeDepth   int
+	treeColumns []string
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree [<id>]",
+	Short: "Show the parent/child hierarchy as a tree",
+	Long: `Print the parent/child hierarchy of a stash as an indented tree.
+
+Without an ID, every root record is shown with its descendants. With an
+ID, only that record and its descendants are shown. Children are listed in
+creation order. Deleted and archived records are left out.
+
+Each node shows the record ID and the primary column's value, or the
+columns chosen with --columns. When --depth hides a node's children, the
+number of hidden children is shown instead.
+
+Options:
+  --depth N        Show at most N levels below the top (0 = unlimited)
+  --columns A,B    Show these columns on each node (default: primary column)
+
+Examples:
+  stash tree
+  stash tree inv-ex4j
+  stash tree --depth 1
+  stash tree --columns Name,Status --json
+
+AI Agent Examples:
+  # Fetch a task and all its subtasks in one call
+  stash tree tk-ex4j --columns Name,Status --json
+
+Exit Codes:
+  0  Success
+  1  Stash, column, or record not found
+  2  Invalid --depth
+  3  Record is deleted
+
+JSON Output (--json):
+  [{"_id": "inv-ex4j", "Name": "Laptop", "_children": [
+    {"_id": "inv-8t2m", "Name": "Battery", "_children": []}]}]
+  With an ID, the single top node is output as an object. Nodes whose
+  children are hidden by --depth carry "_hidden_children": N.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTree,
+}
+
+func init() {
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Show at most N levels below the top (0 = unlimited)")
+	treeCmd.Flags().StringSliceVar(&treeColumns, "columns", nil, "Columns to show on each node (comma-separated)")
+	rootCmd.AddCommand(treeCmd)
+}
+
+// treeBuilder turns records into tree nodes, following the children index.
+type treeBuilder struct {
+	children map[string][]*model.Record
+	columns  []string
+	depth    int
+}
+
+// node builds the JSON node for a record at the given level (0 = top).
+func (b *treeBuilder) node(rec *model.Record, level int) map[string]interface{} {
+	node := map[string]interface{}{"_id": rec.ID}
+	for _, col := range b.columns {
+		if value, ok := rec.Fields[col]; ok {
+			node[col] = value
+		}
+	}
+
+	kids := make([]map[string]interface{}, 0)
+	children := b.children[rec.ID]
+	if b.depth > 0 && level >= b.depth {
+		if len(children) > 0 {
+			node["_hidden_children"] = len(children)
+		}
+	} else {
+		for _, child := range children {
+			kids = append(kids, b.node(child, level+1))
+		}
+	}
+	node["_children"] = kids
+	return node
+}
+
+// print writes a record and its descendants as indented tree lines.
+func (b *treeBuilder) print(rec *model.Record, prefix, branch string, level int) {
+	fmt.Println(prefix + branch + b.label(rec))
+
+	childPrefix := prefix
+	switch branch {
+	case "├── ":
+		childPrefix += "│   "
+	case "└── ":
+		childPrefix += "    "
+	}
+
+	children := b.children[rec.ID]
+	if b.depth > 0 && level >= b.depth {
+		if len(children) > 0 {
+			fmt.Printf("%s└── (%d hidden)\n", childPrefix, len(children))
+		}
+		return
+	}
+	for i, child := range children {
+		childBranch := "├── "
+		if i == len(children)-1 {
+			childBranch = "└── "
+		}
+		b.print(child, childPrefix, childBranch, level+1)
+	}
+}
+
+// label formats a node for display: the ID followed by the column values.
+func (b *treeBuilder) label(rec *model.Record) string {
+	parts := []string{rec.ID}
+	for _, col := range b.columns {
+		if value, ok := rec.Fields[col]; ok {
+			parts = append(parts, truncate(fmt.Sprintf("%v", value), 40))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+func runTree(cmd *cobra.Command, args []string) error {
+	if treeDepth < 0 {
+		ExitValidationError("--depth must not be negative", map[string]interface{}{"depth": treeDepth})
+		return nil
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Resolve columns to their actual names, defaulting to the primary column
+	names, ok := expandColumnGroups(stash, treeColumns)
+	if !ok {
+		return nil
+	}
+	var columns []string
+	for _, name := range names {
+		col := resolveColumn(stash, name)
+		if col == nil {
+			return nil
+		}
+		columns = append(columns, col.Name)
+	}
+	if len(treeColumns) == 0 {
+		if primary := stash.PrimaryColumn(); primary != nil {
+			columns = []string{primary.Name}
+		}
+	}
+
+	records, err := store.ListRecords(stash.Name, storage.ListOptions{
+		ParentID:        "*",
+		ExcludeArchived: true,
+		OrderBy:         []storage.OrderKey{{Field: "_created_at"}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	builder := &treeBuilder{
+		children: make(map[string][]*model.Record),
+		columns:  columns,
+		depth:    treeDepth,
+	}
+	var tops []*model.Record
+	for _, rec := range records {
+		if rec.ParentID == "" {
+			tops = append(tops, rec)
+		} else {
+			builder.children[rec.ParentID] = append(builder.children[rec.ParentID], rec)
+		}
+	}
+
+	if len(args) == 1 {
+		recordID := args[0]
+		rec, err := store.GetRecord(stash.Name, recordID)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				ExitRecordNotFound(recordID)
+				return nil
+			}
+			if errors.Is(err, model.ErrRecordDeleted) {
+				ExitRecordDeleted(recordID)
+				return nil
+			}
+			return fmt.Errorf("failed to get record: %w", err)
+		}
+		tops = []*model.Record{rec}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		nodes := make([]map[string]interface{}, 0, len(tops))
+		for _, rec := range tops {
+			nodes = append(nodes, builder.node(rec, 0))
+		}
+		var data []byte
+		if len(args) == 1 {
+			data, err = json.MarshalIndent(nodes[0], "", "  ")
+		} else {
+			data, err = json.MarshalIndent(nodes, "", "  ")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(tops) == 0 {
+		if !IsQuiet() {
+			fmt.Fprintln(os.Stderr, "No records found.")
+		}
+		return nil
+	}
+	for _, rec := range tops {
+		builder.print(rec, "", "", 0)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	// setup creates Laptop > Battery > Cell and Laptop > Charger, plus Desk
+	setup := func(t *testing.T) (map[string]string, func()) {
+		t.Helper()
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Status"})
+		ids := make(map[string]string)
+		add := func(args ...string) {
+			var rec map[string]interface{}
+			out := captureSchemaOutput(t, append([]string{"add", "--json"}, args...)...)
+			if err := json.Unmarshal([]byte(out), &rec); err != nil {
+				t.Fatalf("failed to parse add output: %v\n%s", err, out)
+			}
+			ids[args[0]] = rec["_id"].(string)
+		}
+		add("Laptop", "--set", "Status=open")
+		add("Battery", "--parent", ids["Laptop"])
+		add("Cell", "--parent", ids["Battery"])
+		add("Charger", "--parent", ids["Laptop"])
+		add("Desk")
+		ExitCode = 0
+		return ids, cleanup
+	}
+
+	t.Run("prints the hierarchy", func(t *testing.T) {
+		ids, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "tree", ids["Laptop"])
+		expected := strings.Join([]string{
+			ids["Laptop"] + "  Laptop",
+			"├── " + ids["Battery"] + "  Battery",
+			"│   └── " + ids["Cell"] + "  Cell",
+			"└── " + ids["Charger"] + "  Charger",
+		}, "\n") + "\n"
+		if out != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+		}
+
+		// Without an ID every root is shown
+		out = captureSchemaOutput(t, "tree")
+		if !strings.Contains(out, expected) || !strings.Contains(out, ids["Desk"]+"  Desk\n") {
+			t.Errorf("expected both roots, got:\n%s", out)
+		}
+	})
+
+	t.Run("subtree as nested JSON with columns", func(t *testing.T) {
+		ids, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "tree", ids["Laptop"], "--columns", "Name,status", "--json")
+		var node map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &node); err != nil {
+			t.Fatalf("expected JSON object: %v\n%s", err, out)
+		}
+		if node["_id"] != ids["Laptop"] || node["Status"] != "open" {
+			t.Errorf("unexpected top node: %v", node)
+		}
+		children := node["_children"].([]interface{})
+		if len(children) != 2 {
+			t.Fatalf("expected 2 children, got %v", children)
+		}
+		battery := children[0].(map[string]interface{})
+		cells := battery["_children"].([]interface{})
+		if battery["Name"] != "Battery" || len(cells) != 1 {
+			t.Errorf("expected Battery with one child, got %v", battery)
+		}
+	})
+
+	t.Run("depth hides deeper levels", func(t *testing.T) {
+		ids, cleanup := setup(t)
+		defer cleanup()
+
+		out := captureSchemaOutput(t, "tree", "--depth", "1", "--json")
+		var nodes []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &nodes); err != nil {
+			t.Fatalf("expected JSON array: %v\n%s", err, out)
+		}
+		if len(nodes) != 2 {
+			t.Fatalf("expected two roots, got %v", nodes)
+		}
+		laptop := nodes[0]
+		if laptop["_id"] != ids["Laptop"] {
+			laptop = nodes[1]
+		}
+		battery := laptop["_children"].([]interface{})[0].(map[string]interface{})
+		if len(battery["_children"].([]interface{})) != 0 || battery["_hidden_children"] != float64(1) {
+			t.Errorf("expected Battery's child to be hidden, got %v", battery)
+		}
+
+		out = captureSchemaOutput(t, "tree", "--depth", "1")
+		if !strings.Contains(out, "│   └── (1 hidden)") || strings.Contains(out, ids["Cell"]) {
+			t.Errorf("expected hidden marker instead of Cell, got:\n%s", out)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		captureSchemaOutput(t, "tree", "inv-zzzz")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for unknown record, got %d", ExitCode)
+		}
+		ExitCode = 0
+		captureSchemaOutput(t, "tree", "--depth", "-1")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for negative depth, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,445 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/platform"
+	"github.com/user/stash/internal/storage"
+)
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Show or manage transactions",
+	Long: `List the open transactions of this .stash directory.
+
+A transaction groups the writes of several commands so they land
+together or not at all. 'stash tx begin' prints a token; commands run
+with --tx <token> (or $STASH_TX) stage their operations in the
+transaction's pending area instead of the stash logs, and see the stash
+as it would be with them applied. 'stash tx commit' appends them all;
+'stash tx rollback' throws them away. Nobody else sees staged operations.
+
+Commit checks every staged operation first: if a record the transaction
+changed was changed by someone else since, or an ID it created has been
+taken, nothing is written and the transaction stays open to roll back.
+
+Record writes are staged: add, set, upsert, rm, restore, archive, assign,
+move, and so on. Schema changes (column, init, drop, filter, ...) and
+commands that rewrite the log (purge, compact, prefix) fail in a
+transaction, as do the commands that need files on disk (attach, lock,
+backup, sync, ...). Each command in a transaction loads the stashes it
+uses from their logs, so it is slower than a direct write on a large
+stash.
+
+The open transactions are kept in .stash/state.json and their staged
+operations in .stash/_tx; both describe this working copy, so leave them
+out of git commits.
+
+Examples:
+  stash tx
+  TX=$(stash tx begin)
+  stash add "Laptop" --set Price=999 --tx $TX
+  stash set inv-ex4j Stock=0 --tx $TX
+  stash tx commit $TX
+
+AI Agent Examples:
+  # Apply a multi-step change atomically from a script
+  export STASH_TX=$(stash tx begin)
+  stash add "Laptop" && stash set inv-ex4j Stock=0 || { stash tx rollback; exit 1; }
+  stash tx commit
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found
+
+JSON Output (--json):
+  [{"token": "tx-4k2p", "began_at": "...", "began_by": "alice", "operations": 2}]`,
+	Args: cobra.NoArgs,
+	RunE: runTx,
+}
+
+var txBeginCmd = &cobra.Command{
+	Use:   "begin",
+	Short: "Begin a transaction",
+	Long: `Begin a transaction and print its token, to pass to commands with --tx
+or in $STASH_TX.
+
+Examples:
+  TX=$(stash tx begin)
+  export STASH_TX=$(stash tx begin)
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found
+
+Output:
+  The transaction's token.
+
+JSON Output (--json):
+  {"token": "tx-4k2p"}`,
+	Args: cobra.NoArgs,
+	RunE: runTxBegin,
+}
+
+var txCommitCmd = &cobra.Command{
+	Use:   "commit [token]",
+	Short: "Commit a transaction",
+	Long: `Append the operations staged in a transaction to the stash logs, and
+close it. The token defaults to --tx or $STASH_TX.
+
+Operations keep the actors, timestamps, sources, and runs they were
+staged with. If a record the transaction changed was changed by someone
+else since, or an ID it created has been taken, nothing is committed:
+the conflicting records are reported, and the transaction stays open.
+
+Other writes to the stashes wait until the commit is done. Each
+stash's operations are appended in one write; if the commit fails
+partway (a full disk, say), the stashes already written are dropped
+from the transaction and running commit again finishes the rest.
+
+Examples:
+  stash tx commit tx-4k2p
+  STASH_TX=tx-4k2p stash tx commit
+
+Exit Codes:
+  0  Success
+  1  No .stash directory, transaction not found, or records changed since
+     they were staged
+  2  Validation error (no token given)
+
+JSON Output (--json):
+  {"token": "tx-4k2p", "committed": 2, "stashes": {"inventory": 2}}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTxCommit,
+}
+
+var txRollbackCmd = &cobra.Command{
+	Use:   "rollback [token]",
+	Short: "Discard a transaction",
+	Long: `Throw away the operations staged in a transaction, and close it. The
+token defaults to --tx or $STASH_TX.
+
+Examples:
+  stash tx rollback tx-4k2p
+
+Exit Codes:
+  0  Success
+  1  No .stash directory found, or transaction not found
+  2  Validation error (no token given)
+
+JSON Output (--json):
+  {"token": "tx-4k2p", "discarded": 2}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTxRollback,
+}
+
+func init() {
+	txCmd.AddCommand(txBeginCmd)
+	txCmd.AddCommand(txCommitCmd)
+	txCmd.AddCommand(txRollbackCmd)
+	rootCmd.AddCommand(txCmd)
+}
+
+// txInfo is an open transaction as listed by 'stash tx'.
+type txInfo struct {
+	Token      string    `json:"token"`
+	BeganAt    time.Time `json:"began_at"`
+	BeganBy    string    `json:"began_by"`
+	Operations int       `json:"operations"`
+}
+
+// openTxStore opens the view of the .stash directory that a transaction
+// sees, whose writes are staged in the transaction.
+func openTxStore(stashDir, token string) (*storage.Store, error) {
+	state, err := context.LoadState(stashDir)
+	if err != nil {
+		return nil, err
+	}
+	if state.Transaction(token) == nil {
+		return nil, fmt.Errorf("transaction '%s' not found (see 'stash tx')", token)
+	}
+
+	disk, err := newSignedStore(stashDir)
+	if err != nil {
+		return nil, err
+	}
+	defer disk.Close()
+	disk.SetContext(commandContext())
+	view, err := disk.OpenTx(token)
+	if err != nil {
+		return nil, err
+	}
+	view.SetSigner(signingKeyFor)
+	view.SetActor(schemaActorFor)
+	return view, nil
+}
+
+// countOperations returns the number of operations staged across stashes.
+func countOperations(pending []storage.TxStash) int {
+	n := 0
+	for _, p := range pending {
+		n += len(p.Operations)
+	}
+	return n
+}
+
+// txToken returns the token a tx subcommand acts on: its argument, or
+// else --tx or $STASH_TX. Reports a missing token and returns false.
+func txToken(args []string) (string, bool) {
+	if len(args) == 1 {
+		return args[0], true
+	}
+	if token := GetTx(); token != "" {
+		return token, true
+	}
+	ExitValidationError("no transaction given (pass its token, --tx, or $STASH_TX)", nil)
+	return "", false
+}
+
+// openTxState opens the store and state of the .stash directory for a tx
+// subcommand, holding the state's lock file until release is called.
+// Reports a missing .stash directory and returns false.
+func openTxState() (stashDir string, store *storage.Store, state *context.State, release func(), ok bool, err error) {
+	stashDir = context.FindStashDir()
+	if stashDir == "" {
+		ExitNoStashDir()
+		return "", nil, nil, nil, false, nil
+	}
+	fileLock, err := platform.LockFile(context.StatePath(stashDir) + ".lock")
+	if err != nil {
+		return "", nil, nil, nil, false, fmt.Errorf("failed to lock state: %w", err)
+	}
+	store, err = newSignedStore(stashDir)
+	if err != nil {
+		fileLock.Unlock()
+		return "", nil, nil, nil, false, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	store.SetContext(commandContext())
+	state, err = context.LoadState(stashDir)
+	if err != nil {
+		store.Close()
+		fileLock.Unlock()
+		return "", nil, nil, nil, false, err
+	}
+	release = func() {
+		store.Close()
+		fileLock.Unlock()
+	}
+	return stashDir, store, state, release, true, nil
+}
+
+// checkTxCommand refuses to run a command that needs the .stash directory
+// on disk (see ephemeralUnsupported) in a transaction, where it would
+// write around the transaction. The tx commands themselves manage it.
+func checkTxCommand(cmd *cobra.Command) error {
+	if GetTx() == "" {
+		return nil
+	}
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	if cmd.Name() != "tx" && ephemeralUnsupported[cmd.Name()] {
+		return fmt.Errorf("%s needs the .stash directory on disk and cannot be run in a transaction", cmd.Name())
+	}
+	return nil
+}
+
+// exitTxNotFound reports a token that names no open transaction.
+func exitTxNotFound(token string) {
+	ExitWithError(1, ErrCodeTxNotFound, fmt.Sprintf("transaction '%s' not found (see 'stash tx')", token),
+		map[string]interface{}{"token": token})
+}
+
+func runTx(cmd *cobra.Command, args []string) error {
+	_, store, state, release, ok, err := openTxState()
+	if !ok {
+		return err
+	}
+	defer release()
+
+	infos := []txInfo{}
+	for _, tx := range state.Transactions {
+		pending, err := store.TxOperations(tx.Token)
+		if err != nil && !errors.Is(err, storage.ErrTxNotFound) {
+			return fmt.Errorf("failed to read transaction %s: %w", tx.Token, err)
+		}
+		infos = append(infos, txInfo{Token: tx.Token, BeganAt: tx.BeganAt, BeganBy: tx.BeganBy, Operations: countOperations(pending)})
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(infos)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if IsPorcelain() {
+		for _, info := range infos {
+			printPorcelain(info.Token, porcelainTime(info.BeganAt), info.BeganBy, fmt.Sprint(info.Operations))
+		}
+		return nil
+	}
+
+	if len(infos) == 0 {
+		Infof("No open transactions.\n")
+		return nil
+	}
+	t := newTable(
+		tableColumn{Header: "Token", System: true},
+		tableColumn{Header: "Began", System: true},
+		tableColumn{Header: "By", Max: 20},
+		tableColumn{Header: "Operations", System: true},
+	)
+	for _, info := range infos {
+		t.addRow(info.Token, info.BeganAt.Format("2006-01-02 15:04:05"), info.BeganBy, fmt.Sprintf("%d", info.Operations))
+	}
+	t.print()
+	return nil
+}
+
+func runTxBegin(cmd *cobra.Command, args []string) error {
+	stashDir, store, state, release, ok, err := openTxState()
+	if !ok {
+		return err
+	}
+	defer release()
+
+	var token string
+	for token == "" || state.Transaction(token) != nil {
+		if token, err = model.GenerateID("tx-"); err != nil {
+			return err
+		}
+	}
+	if err := store.BeginTx(token); err != nil {
+		return err
+	}
+	state.Transactions = append(state.Transactions, context.Transaction{
+		Token:   token,
+		BeganAt: time.Now().UTC(),
+		BeganBy: context.ResolveActor(GetActorName()),
+	})
+	if err := context.SaveState(stashDir, state); err != nil {
+		store.RollbackTx(token)
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"token": token})
+		fmt.Println(string(data))
+	} else {
+		fmt.Println(token)
+	}
+	return nil
+}
+
+func runTxCommit(cmd *cobra.Command, args []string) error {
+	token, ok := txToken(args)
+	if !ok {
+		return nil
+	}
+	stashDir, store, state, release, ok, err := openTxState()
+	if !ok {
+		return err
+	}
+	defer release()
+	if state.Transaction(token) == nil {
+		exitTxNotFound(token)
+		return nil
+	}
+
+	// Hold the locks file of each stash written to, as other writes do
+	pending, err := store.TxOperations(token)
+	if err != nil && !errors.Is(err, storage.ErrTxNotFound) {
+		return fmt.Errorf("failed to read transaction: %w", err)
+	}
+	for _, p := range pending {
+		fileLock, err := lockLocksFile(stashDir, p.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to lock locks file: %w", err)
+		}
+		defer fileLock.Unlock()
+	}
+
+	if len(pending) > 0 {
+		pending, err = store.CommitTx(token)
+		var conflict *storage.TxConflictError
+		if errors.As(err, &conflict) {
+			ExitWithError(1, ErrCodeConflict,
+				fmt.Sprintf("%s; nothing was committed (roll back with 'stash tx rollback %s')", conflict.Error(), token),
+				map[string]interface{}{"token": token, "ids": conflict.IDs})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	} else {
+		store.RollbackTx(token)
+	}
+	state.RemoveTransaction(token)
+	if err := context.SaveState(stashDir, state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	// Output result
+	stashes := make(map[string]int, len(pending))
+	for _, p := range pending {
+		stashes[p.Stash] = len(p.Operations)
+	}
+	committed := countOperations(pending)
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"token": token, "committed": committed, "stashes": stashes})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Committed transaction %s: %d operation(s)\n", token, committed)
+	}
+	return nil
+}
+
+func runTxRollback(cmd *cobra.Command, args []string) error {
+	token, ok := txToken(args)
+	if !ok {
+		return nil
+	}
+	stashDir, store, state, release, ok, err := openTxState()
+	if !ok {
+		return err
+	}
+	defer release()
+	if state.Transaction(token) == nil {
+		exitTxNotFound(token)
+		return nil
+	}
+
+	pending, err := store.TxOperations(token)
+	if err != nil && !errors.Is(err, storage.ErrTxNotFound) {
+		return fmt.Errorf("failed to read transaction: %w", err)
+	}
+	if err := store.RollbackTx(token); err != nil && !errors.Is(err, storage.ErrTxNotFound) {
+		return fmt.Errorf("failed to discard transaction: %w", err)
+	}
+	state.RemoveTransaction(token)
+	if err := context.SaveState(stashDir, state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	// Output result
+	discarded := countOperations(pending)
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"token": token, "discarded": discarded})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		fmt.Printf("Rolled back transaction %s: %d operation(s) discarded\n", token, discarded)
+	}
+	return nil
+}
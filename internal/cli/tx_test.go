@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+	begin := func(t *testing.T) string {
+		t.Helper()
+		var result map[string]string
+		output := captureSchemaOutput(t, "tx", "begin", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse tx begin output %q: %v", output, err)
+		}
+		return result["token"]
+	}
+	names := func(t *testing.T, args ...string) string {
+		t.Helper()
+		return captureSchemaOutput(t, append([]string{"list", "--porcelain"}, args...)...)
+	}
+
+	t.Run("commit applies the staged writes together", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		token := begin(t)
+		captureSchemaOutput(t, "add", "Laptop", "--tx", token)
+		captureSchemaOutput(t, "add", "Mouse", "--tx", token)
+
+		if output := names(t, "--tx", token); !strings.Contains(output, "Laptop") || !strings.Contains(output, "Mouse") {
+			t.Errorf("expected the transaction to see its records, got:\n%s", output)
+		}
+		if output := names(t); strings.Contains(output, "Laptop") {
+			t.Errorf("expected staged records to be hidden before commit, got:\n%s", output)
+		}
+
+		var result struct {
+			Committed int `json:"committed"`
+		}
+		output := captureSchemaOutput(t, "tx", "commit", token, "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse tx commit output %q: %v", output, err)
+		}
+		if result.Committed != 2 {
+			t.Errorf("expected 2 operations committed, got %d", result.Committed)
+		}
+		if output := names(t); !strings.Contains(output, "Laptop") || !strings.Contains(output, "Mouse") {
+			t.Errorf("expected committed records, got:\n%s", output)
+		}
+
+		rootCmd.SetArgs([]string{"add", "Monitor", "--tx", token})
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected a committed transaction to be closed, got %v", err)
+		}
+		resetFlags()
+	})
+
+	t.Run("rollback discards the staged writes", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		token := begin(t)
+		captureSchemaOutput(t, "add", "Laptop", "--tx", token)
+		captureSchemaOutput(t, "tx", "rollback", token)
+		if ExitCode != 0 {
+			t.Fatalf("expected rollback to succeed, got exit code %d", ExitCode)
+		}
+		if output := names(t); strings.Contains(output, "Laptop") {
+			t.Errorf("expected no records after rollback, got:\n%s", output)
+		}
+
+		captureSchemaOutput(t, "tx", "commit", token, "--json")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for a closed transaction, got %d", ExitCode)
+		}
+	})
+
+	t.Run("commands that write around the transaction are refused", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		token := begin(t)
+		rootCmd.SetArgs([]string{"lock", "inv-ex4j", "--tx", token})
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "transaction") {
+			t.Errorf("expected lock to be refused in a transaction, got %v", err)
+		}
+		resetFlags()
+	})
+}
@@ -0,0 +1,394 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	undoRun    string
+	undoDryRun bool
+	undoForce  bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo --run <id>",
+	Short: "Undo everything an agent run did",
+	Long: `Put back every record an agent run changed, as it was before the run.
+
+Operations are tagged with a run when they are written with --run-id (or
+$STASH_RUN_ID); 'stash history --run <id>' lists them. Undo reverts the
+run as a unit: records the run added are deleted, records it deleted are
+restored, and records it changed get back their fields, archive state,
+and assignee. Values are put back as they were, without validation.
+
+Undo writes new operations, tagged with the current --run-id if any; the
+run's own operations stay in the history.
+
+A record changed by someone else after the run's first change to it would
+lose that later change, so undo refuses the whole run and lists those
+records; --force undoes them anyway. Records already back as they were
+before the run are left alone, so undoing a run twice does nothing. Locked records and permissions are
+checked as for the commands the undo stands in for.
+
+Options:
+  --run <id>    Agent run to undo (required)
+  --dry-run     Print the operations that would be written, without writing
+  --force       Undo records changed since the run, discarding the changes
+
+Examples:
+  stash history --run run-42          # Review what the run did
+  stash undo --run run-42 --dry-run   # Preview the undo
+  stash undo --run run-42
+
+AI Agent Examples:
+  # Tag a run, then roll it back if it went wrong
+  export STASH_RUN_ID=triage-$(date +%s)
+  stash set inv-ex4j Status=done          # tagged with the run
+  stash undo --run "$STASH_RUN_ID" --json
+
+Exit Codes:
+  0  Success (also when the run made no changes)
+  1  Stash not found, or records were changed since the run (see --force)
+  2  Validation error (missing --run)
+  5  A record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+Output:
+  With --porcelain, one line per operation written: ID, then operation.
+
+JSON Output (--json):
+  {"run": "run-42", "stash": "inventory", "undone": 2,
+   "ids": ["inv-ex4j", "inv-8t2m"],
+   "operations": [{"id": "inv-ex4j", "op": "delete"}, {"id": "inv-8t2m", "op": "update"}]}`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	undoCmd.Flags().StringVar(&undoRun, "run", "", "Agent run to undo (see --run-id)")
+	undoCmd.Flags().BoolVar(&undoDryRun, "dry-run", false, "Preview the operations without writing them")
+	undoCmd.Flags().BoolVar(&undoForce, "force", false, "Undo records changed since the run, discarding those changes")
+	rootCmd.AddCommand(undoCmd)
+}
+
+// undoStep is one operation an undo writes.
+type undoStep struct {
+	ID string `json:"id"`
+	Op string `json:"op"`
+}
+
+// runChange is a record an agent run changed.
+type runChange struct {
+	id     string
+	before *model.Record   // state before the run's first change; nil if the run created it
+	later  []*model.Record // operations by others after the run's first change
+}
+
+// runChanges returns the records the run changed, in the order it first
+// changed them, given a stash's operations in log order.
+func runChanges(history []*model.Record, run string) []*runChange {
+	var changes []*runChange
+	byID := make(map[string]*runChange)
+	last := make(map[string]*model.Record)
+	for _, op := range history {
+		change := byID[op.ID]
+		if op.RunID == run {
+			if change == nil {
+				change = &runChange{id: op.ID, before: last[op.ID]}
+				byID[op.ID] = change
+				changes = append(changes, change)
+			}
+		} else if change != nil {
+			change.later = append(change.later, op)
+		}
+		last[op.ID] = op
+	}
+	return changes
+}
+
+// undoFields returns the stored fields of the record state that can be
+// written back: computed columns and columns dropped since are left out.
+func undoFields(stash *model.Stash, record *model.Record) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for name, value := range record.Fields {
+		col := stash.Columns.Find(name)
+		if value == nil || col == nil || col.IsComputed() {
+			continue
+		}
+		fields[col.Name] = value
+	}
+	return fields
+}
+
+// changedValues returns the names of the fields whose values differ
+// between two sets of fields, sorted. Values read back from the store may
+// be typed (11 for "11"), so they are compared as text.
+func changedValues(a, b map[string]interface{}) []string {
+	var names []string
+	for name, value := range a {
+		if valueText(value) != valueText(b[name]) {
+			names = append(names, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// undoOps returns the operations that put the current record back to its
+// state before a run, in the order they are written, and the fields the
+// update among them changes.
+func undoOps(stash *model.Stash, before, current *model.Record) ([]string, []string) {
+	if before == nil || before.IsDeleted() {
+		if current.IsDeleted() {
+			return nil, nil
+		}
+		return []string{model.OpDelete}, nil
+	}
+
+	var ops []string
+	if current.IsDeleted() {
+		ops = append(ops, model.OpRestore)
+	}
+	changed := changedValues(undoFields(stash, before), undoFields(stash, current))
+	if len(changed) > 0 {
+		ops = append(ops, model.OpUpdate)
+	}
+	if before.IsArchived() != current.IsArchived() {
+		if before.IsArchived() {
+			ops = append(ops, model.OpArchive)
+		} else {
+			ops = append(ops, model.OpUnarchive)
+		}
+	}
+	if before.AssignedTo != current.AssignedTo {
+		if before.AssignedTo == "" {
+			ops = append(ops, model.OpUnassign)
+		} else {
+			ops = append(ops, model.OpAssign)
+		}
+	}
+	return ops, changed
+}
+
+// undoPermissions maps each undo operation to the permission it needs.
+var undoPermissions = map[string]string{
+	model.OpDelete:    model.PermDelete,
+	model.OpRestore:   model.PermRestore,
+	model.OpUpdate:    model.PermUpdate,
+	model.OpArchive:   model.PermArchive,
+	model.OpUnarchive: model.PermArchive,
+	model.OpAssign:    model.PermUpdate,
+	model.OpUnassign:  model.PermUpdate,
+}
+
+// applyUndoOp applies an undo operation to a copy of the record, as the
+// store would write it.
+func applyUndoOp(record, before *model.Record, stash *model.Stash, op, actor string, now time.Time) *model.Record {
+	next := *record
+	next.UpdatedAt = now
+	next.UpdatedBy = actor
+	switch op {
+	case model.OpDelete:
+		next.DeletedAt = &now
+		next.DeletedBy = actor
+	case model.OpRestore:
+		next.DeletedAt = nil
+		next.DeletedBy = ""
+	case model.OpUpdate:
+		next.Fields = undoFields(stash, before)
+	case model.OpArchive:
+		next.ArchivedAt = &now
+		next.ArchivedBy = actor
+	case model.OpUnarchive:
+		next.ArchivedAt = nil
+		next.ArchivedBy = ""
+	case model.OpAssign, model.OpUnassign:
+		next.AssignedTo = before.AssignedTo
+	}
+	return &next
+}
+
+// writeUndoOp writes an undo operation through the store.
+func writeUndoOp(store *storage.Store, stash *model.Stash, record, before *model.Record, op, actor string) error {
+	switch op {
+	case model.OpDelete:
+		return store.DeleteRecord(stash.Name, record.ID, actor)
+	case model.OpRestore:
+		return store.RestoreRecord(stash.Name, record.ID, actor)
+	case model.OpUpdate:
+		current, err := store.GetRecord(stash.Name, record.ID)
+		if err != nil {
+			return err
+		}
+		current.Fields = undoFields(stash, before)
+		current.UpdatedAt = time.Now()
+		current.UpdatedBy = actor
+		return store.UpdateRecord(stash.Name, current)
+	case model.OpArchive:
+		return store.ArchiveRecord(stash.Name, record.ID, actor)
+	case model.OpUnarchive:
+		return store.UnarchiveRecord(stash.Name, record.ID, actor)
+	case model.OpAssign:
+		return store.AssignRecord(stash.Name, record.ID, before.AssignedTo, actor)
+	case model.OpUnassign:
+		return store.UnassignRecord(stash.Name, record.ID, actor)
+	}
+	return fmt.Errorf("cannot undo with operation %s", op)
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(undoRun) == "" {
+		ExitValidationError("--run is required (the --run-id the operations were written with)", nil)
+		return nil
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	// Hold the locks file from reading the log to the last write, so no
+	// change slips in between
+	if !store.IsMemory() {
+		fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to lock locks file: %w", err)
+		}
+		defer fileLock.Unlock()
+	}
+
+	history, err := store.GetAllHistory(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+	changes := runChanges(history, undoRun)
+
+	// Work out every operation, and check them all, before writing any
+	type recordUndo struct {
+		current *model.Record
+		before  *model.Record
+		ops     []string
+	}
+	var undos []recordUndo
+	var records []*model.Record
+	var changed []string
+	for _, change := range changes {
+		current, err := store.GetRecordIncludeDeleted(stash.Name, change.id)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				continue // purged since; nothing left to put back
+			}
+			return fmt.Errorf("failed to get record: %w", err)
+		}
+		ops, fields := undoOps(stash, change.before, current)
+		if len(ops) == 0 {
+			continue
+		}
+		// A record changed since the run would lose those changes
+		if len(change.later) > 0 && !undoForce {
+			changed = append(changed, change.id)
+			continue
+		}
+		for _, op := range ops {
+			var columns []string
+			if op == model.OpUpdate {
+				columns = fields
+			}
+			if !checkPermission(stash, ctx.Actor, undoPermissions[op], columns) {
+				return nil
+			}
+		}
+		undos = append(undos, recordUndo{current: current, before: change.before, ops: ops})
+		records = append(records, current)
+	}
+	if len(changed) > 0 {
+		ExitWithError(1, ErrCodeConflict,
+			fmt.Sprintf("%d record(s) changed since run '%s' (%s); use --force to undo them anyway", len(changed), undoRun, strings.Join(changed, ", ")),
+			map[string]interface{}{"run": undoRun, "ids": changed})
+		return nil
+	}
+	if ok, err := checkRecordLocks(ctx, records); !ok {
+		return err
+	}
+
+	steps := []undoStep{}
+	if undoDryRun {
+		now := time.Now()
+		ops := []*model.Record{}
+		for _, undo := range undos {
+			record := undo.current
+			for _, op := range undo.ops {
+				record = applyUndoOp(record, undo.before, stash, op, ctx.Actor, now)
+				preview, err := store.PreviewRecord(stash.Name, record, op)
+				if err != nil {
+					return fmt.Errorf("failed to preview %s: %w", record.ID, err)
+				}
+				ops = append(ops, preview)
+			}
+		}
+		return outputDryRun(stash.Name, ops, map[string]interface{}{
+			"run":        undoRun,
+			"would_undo": len(undos),
+			"ids":        getRecordIDs(records),
+		})
+	}
+
+	for _, undo := range undos {
+		for _, op := range undo.ops {
+			if err := writeUndoOp(store, stash, undo.current, undo.before, op, ctx.Actor); err != nil {
+				if exitRecordTooLarge(stash.Name, err) || exitWriteRefused(err) {
+					return nil
+				}
+				return fmt.Errorf("failed to undo %s of %s: %w", op, undo.current.ID, err)
+			}
+			steps = append(steps, undoStep{ID: undo.current.ID, Op: op})
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(map[string]interface{}{
+			"run":        undoRun,
+			"stash":      stash.Name,
+			"undone":     len(undos),
+			"ids":        getRecordIDs(records),
+			"operations": steps,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		for _, step := range steps {
+			printPorcelain(step.ID, step.Op)
+		}
+	} else if !IsQuiet() {
+		if len(changes) == 0 {
+			fmt.Printf("No operations found for run '%s'\n", undoRun)
+		} else {
+			fmt.Printf("Undid run '%s': %d record(s) put back\n", undoRun, len(undos))
+		}
+		if IsVerbose() {
+			for _, step := range steps {
+				fmt.Printf("  - %s: %s\n", step.ID, step.Op)
+			}
+		}
+	}
+	return nil
+}
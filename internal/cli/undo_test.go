@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUndoRun(t *testing.T) {
+	addRecord := func(t *testing.T, args ...string) string {
+		t.Helper()
+		var added map[string]interface{}
+		output := captureSchemaOutput(t, append([]string{"add"}, append(args, "--json")...)...)
+		if err := json.Unmarshal([]byte(output), &added); err != nil {
+			t.Fatalf("failed to parse add output %q: %v", output, err)
+		}
+		id, _ := added["_id"].(string)
+		return id
+	}
+	price := func(t *testing.T, id string) interface{} {
+		t.Helper()
+		var shown map[string]interface{}
+		output := captureSchemaOutput(t, "show", id, "--json")
+		if err := json.Unmarshal([]byte(output), &shown); err != nil {
+			t.Fatalf("failed to parse show output %q: %v", output, err)
+		}
+		return shown["Price"]
+	}
+
+	t.Run("reverts everything the run did", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		laptop := addRecord(t, "Laptop", "--set", "Price=999")
+		phone := addRecord(t, "Phone", "--run-id", "run-1")
+		captureSchemaOutput(t, "set", laptop, "Price=1", "--run-id", "run-1")
+		captureSchemaOutput(t, "archive", laptop, "--run-id", "run-1")
+
+		var ops []map[string]interface{}
+		output := captureSchemaOutput(t, "history", "--run", "run-1", "--json")
+		if err := json.Unmarshal([]byte(output), &ops); err != nil {
+			t.Fatalf("failed to parse history output %q: %v", output, err)
+		}
+		if len(ops) != 3 {
+			t.Errorf("expected 3 operations in run-1, got %d: %s", len(ops), output)
+		}
+
+		var result struct {
+			Undone int      `json:"undone"`
+			IDs    []string `json:"ids"`
+		}
+		output = captureSchemaOutput(t, "undo", "--run", "run-1", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse undo output %q: %v", output, err)
+		}
+		if result.Undone != 2 {
+			t.Errorf("expected 2 records undone, got %+v", result)
+		}
+
+		if got := price(t, laptop); valueText(got) != "999" {
+			t.Errorf("expected Price back at 999, got %v", got)
+		}
+		output = captureSchemaOutput(t, "show", laptop)
+		if strings.Contains(output, "**Archived**") {
+			t.Errorf("expected %s to be unarchived, got:\n%s", laptop, output)
+		}
+		captureSchemaOutput(t, "show", phone)
+		if ExitCode != 4 {
+			t.Errorf("expected the run's record %s to be deleted, got exit code %d", phone, ExitCode)
+		}
+
+		// Undoing again finds nothing left to do
+		output = captureSchemaOutput(t, "undo", "--run", "run-1", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse undo output %q: %v", output, err)
+		}
+		if result.Undone != 0 {
+			t.Errorf("expected nothing to undo the second time, got %+v", result)
+		}
+	})
+
+	t.Run("refuses records changed since the run without --force", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		laptop := addRecord(t, "Laptop", "--set", "Price=999")
+		captureSchemaOutput(t, "set", laptop, "Price=1", "--run-id", "run-2")
+		captureSchemaOutput(t, "set", laptop, "Price=5")
+
+		var result map[string]interface{}
+		output := captureSchemaOutput(t, "undo", "--run", "run-2", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse undo output %q: %v", output, err)
+		}
+		if result["code"] != ErrCodeConflict || ExitCode != 1 {
+			t.Errorf("expected %s with exit code 1, got %v (exit %d)", ErrCodeConflict, result["code"], ExitCode)
+		}
+		if got := price(t, laptop); valueText(got) != "5" {
+			t.Errorf("expected Price left at 5, got %v", got)
+		}
+
+		captureSchemaOutput(t, "undo", "--run", "run-2", "--force")
+		if got := price(t, laptop); valueText(got) != "999" {
+			t.Errorf("expected --force to put Price back at 999, got %v", got)
+		}
+	})
+
+	t.Run("--run is required", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "undo")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,373 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	upsertSetFlags []string
+	upsertMatch    []string
+	upsertDryRun   bool
+	upsertForce    bool
+)
+
+// Outcomes of an upsert.
+const (
+	upsertCreated   = "created"
+	upsertUpdated   = "updated"
+	upsertUnchanged = "unchanged"
+)
+
+var upsertCmd = &cobra.Command{
+	Use:   "upsert <value>",
+	Short: "Update the record matching a value, or add it",
+	Long: `Find the record whose --match columns equal the given values and update
+it with --set, or add a new record if there is none.
+
+The value is assigned to the first (primary) column, as with 'stash add'.
+By default records are matched on the primary column; --match names the
+columns to match on instead (can be repeated), and each of them must be
+given a value, either as the primary value or with --set. Values compare
+under the stash's collation. Deleted records never match.
+
+Upserts of a stash run one at a time, so two agents upserting the same
+value at once get one record between them, not two. A record added with
+'stash add' at the same moment is not covered.
+
+An update follows the rules of 'stash set': locks, workflow transitions
+(--force bypasses them), and permissions. Fields that already hold the
+given value are not rewritten, and an upsert that changes nothing writes
+nothing.
+
+More than one matching record is an error: --match must identify a
+single record.
+
+Examples:
+  stash upsert "Laptop" --set Price=999
+  stash upsert "Alice" --match Email --set Email=alice@example.com --set Team=core
+  stash upsert "Laptop" --set Price=999 --json
+
+AI Agent Examples:
+  # Record a finding once, however many times it is seen
+  ID=$(stash upsert "$URL" --set LastSeen="$(date -Iseconds)")
+
+  # Branch on whether the record is new
+  stash upsert "$NAME" --json | jq -e '.created' >/dev/null && echo "new"
+
+Exit Codes:
+  0  Success - record created, updated, or already up to date
+  1  Stash or column not found, or more than one record matches
+  2  Validation error (empty value, --match column without a value)
+  5  Matching record is locked by another agent
+  6  Permission denied (see 'stash permissions')
+
+Output:
+  The record's ID. With --porcelain: ID, then created, updated, or unchanged.
+
+JSON Output (--json):
+  {"id": "inv-ex4j", "created": false, "updated": true,
+   "record": {"_id": "inv-ex4j", "Name": "Laptop", "Price": "999", ...}}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpsert,
+}
+
+func init() {
+	upsertCmd.Flags().StringArrayVar(&upsertSetFlags, "set", nil, "Set field value (can be repeated): --set Field=Value")
+	upsertCmd.Flags().StringArrayVar(&upsertMatch, "match", nil, "Column to match records on (can be repeated; default: primary column)")
+	upsertCmd.Flags().BoolVar(&upsertDryRun, "dry-run", false, "Validate and print the operation without saving it")
+	upsertCmd.Flags().BoolVar(&upsertForce, "force", false, "Bypass workflow transition rules when updating")
+	rootCmd.AddCommand(upsertCmd)
+}
+
+// UpsertResult is the JSON output of 'stash upsert'.
+type UpsertResult struct {
+	ID      string        `json:"id"`
+	Created bool          `json:"created"`
+	Updated bool          `json:"updated"`
+	Record  *model.Record `json:"record"`
+}
+
+func runUpsert(cmd *cobra.Command, args []string) error {
+	primaryValue := strings.TrimSpace(args[0])
+	if primaryValue == "" {
+		ExitValidationError("primary value cannot be empty", nil)
+		return nil
+	}
+
+	ctx, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if !stash.HasColumns() {
+		ExitValidationError("cannot upsert record - stash has no columns defined (use 'stash column add <name>' first)",
+			map[string]interface{}{"stash": stash.Name})
+		return nil
+	}
+
+	// Collect the fields: the primary value, then --set
+	fields := make(map[string]interface{})
+	primaryCol := stash.PrimaryColumn()
+	fields[primaryCol.Name] = columnValue(primaryCol, primaryValue)
+
+	values := &valueReader{stdin: cmd.InOrStdin()}
+	for _, setFlag := range upsertSetFlags {
+		parts := strings.SplitN(setFlag, "=", 2)
+		if len(parts) != 2 {
+			ExitValidationError(fmt.Sprintf("invalid --set format: %s (expected Field=Value)", setFlag),
+				map[string]interface{}{"input": setFlag})
+			return nil
+		}
+		fieldName := strings.TrimSpace(parts[0])
+		fieldValue, err := values.resolve(strings.TrimSpace(parts[1]))
+		if err != nil {
+			ExitValidationError(fmt.Sprintf("cannot read value for %s: %v", fieldName, err),
+				map[string]interface{}{"column": fieldName, "value": parts[1]})
+			return nil
+		}
+		col := resolveColumn(stash, fieldName)
+		if col == nil {
+			return nil
+		}
+		if col.IsComputed() {
+			ExitValidationError(fmt.Sprintf("column '%s' is computed and cannot be set", col.Name),
+				map[string]interface{}{"column": col.Name, "computed": col.Computed})
+			return nil
+		}
+		fields[col.Name] = columnValue(col, fieldValue)
+	}
+	coerced := coerceEnums(stash, fields)
+
+	// Match on the given columns, or the primary column
+	matchNames := upsertMatch
+	if len(matchNames) == 0 {
+		matchNames = []string{primaryCol.Name}
+	}
+	var where []storage.WhereCondition
+	for _, name := range matchNames {
+		col := resolveColumn(stash, name)
+		if col == nil {
+			return nil
+		}
+		value, ok := fields[col.Name]
+		if !ok || value == nil {
+			ExitValidationError(fmt.Sprintf("--match %s needs a value (use --set %s=...)", col.Name, col.Name),
+				map[string]interface{}{"column": col.Name})
+			return nil
+		}
+		if col.List {
+			ExitValidationError(fmt.Sprintf("cannot match on list column '%s'", col.Name),
+				map[string]interface{}{"column": col.Name})
+			return nil
+		}
+		where = append(where, storage.WhereCondition{Field: col.Name, Operator: "=", Value: valueText(value)})
+	}
+
+	// Hold the locks file from lookup to save, so concurrent upserts of
+	// the same value see each other's records rather than both adding one
+	if !store.IsMemory() {
+		fileLock, err := lockLocksFile(ctx.StashDir, ctx.Stash)
+		if err != nil {
+			return fmt.Errorf("failed to lock locks file: %w", err)
+		}
+		defer fileLock.Unlock()
+	}
+
+	matches, err := store.ListRecords(stash.Name, storage.ListOptions{ParentID: "*", Where: where})
+	if err != nil {
+		return fmt.Errorf("failed to find matching records: %w", err)
+	}
+	if len(matches) > 1 {
+		ids := make([]string, len(matches))
+		for i, r := range matches {
+			ids[i] = r.ID
+		}
+		ExitWithError(1, ErrCodeConflict,
+			fmt.Sprintf("%d records match (%s); --match must identify a single record", len(matches), strings.Join(ids, ", ")),
+			map[string]interface{}{"matches": ids})
+		return nil
+	}
+
+	var record *model.Record
+	var outcome string
+	var warnings []ValidationError
+	if len(matches) == 0 {
+		record, warnings, ok, err = upsertCreate(ctx.Actor, ctx.Branch, store, stash, fields)
+		outcome = upsertCreated
+	} else {
+		record, warnings, outcome, ok, err = upsertUpdate(ctx.StashDir, ctx.Actor, store, stash, matches[0].ID, fields)
+	}
+	if !ok {
+		return err
+	}
+	record.Coerced = coerced
+
+	// Violations of warning-severity columns are accepted, and reported
+	for i := range warnings {
+		warnings[i].RecordID = record.ID
+	}
+	printValidationWarnings(warnings)
+
+	if upsertDryRun {
+		if outcome == upsertUnchanged {
+			return outputDryRun(stash.Name, []*model.Record{}, nil)
+		}
+		op := model.OpUpdate
+		if outcome == upsertCreated {
+			op = model.OpCreate
+		}
+		ops, err := previewOperations(store, stash.Name, []*model.Record{record}, op)
+		if err != nil {
+			return err
+		}
+		return outputDryRun(stash.Name, ops, nil)
+	}
+
+	// Save record
+	switch outcome {
+	case upsertCreated:
+		err = store.CreateRecord(stash.Name, record)
+	case upsertUpdated:
+		err = store.UpdateRecord(stash.Name, record)
+	}
+	if err != nil {
+		if exitRecordTooLarge(stash.Name, err) || exitWriteRefused(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to save record: %w", err)
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, err := json.Marshal(UpsertResult{
+			ID:      record.ID,
+			Created: outcome == upsertCreated,
+			Updated: outcome == upsertUpdated,
+			Record:  record,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if IsPorcelain() {
+		printPorcelain(record.ID, outcome)
+	} else if !IsQuiet() {
+		fmt.Println(record.ID)
+		if IsVerbose() {
+			fmt.Printf("  %s\n", outcome)
+			fmt.Printf("  hash: %s\n", record.Hash)
+		}
+	}
+	return nil
+}
+
+// upsertCreate builds and validates the record an upsert adds when no
+// record matches. Returns false if the record may not be added, which has
+// been reported unless an error is returned.
+func upsertCreate(actor, branch string, store *storage.Store, stash *model.Stash, fields map[string]interface{}) (*model.Record, []ValidationError, bool, error) {
+	if !checkPermission(stash, actor, model.PermCreate, fieldNames(fields)) {
+		return nil, nil, false, nil
+	}
+	validationResult := ValidateFields(stash, fields)
+	if exitViolation(validationResult) {
+		return nil, nil, false, nil
+	}
+
+	recordID, err := store.NextRecordID(stash.Name)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to generate ID: %w", err)
+	}
+	now := time.Now()
+	record := &model.Record{
+		ID:        recordID,
+		CreatedAt: now,
+		CreatedBy: actor,
+		UpdatedAt: now,
+		UpdatedBy: actor,
+		Branch:    branch,
+		Fields:    fields,
+	}
+
+	hookResult := ValidateHooks(stash, record, nil)
+	if exitViolation(hookResult) {
+		return nil, nil, false, nil
+	}
+	validationResult.merge(hookResult)
+	return record, validationResult.Warnings, true, nil
+}
+
+// upsertUpdate applies the fields of an upsert to the matching record.
+// Fields that already hold their value are left alone; when none change,
+// the outcome is upsertUnchanged and nothing needs saving. Returns false
+// if the record may not be updated, which has been reported unless an
+// error is returned.
+func upsertUpdate(stashDir, actor string, store *storage.Store, stash *model.Stash, recordID string, fields map[string]interface{}) (*model.Record, []ValidationError, string, bool, error) {
+	record, err := store.GetRecord(stash.Name, recordID)
+	if err != nil {
+		return nil, nil, "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+
+	var touched []string
+	for name, value := range fields {
+		// Values read back from the store may be typed (11 for "11"), so
+		// compare them as text
+		if current, _ := record.GetField(name); valueText(current) != valueText(value) {
+			touched = append(touched, name)
+		}
+	}
+	if len(touched) == 0 {
+		return record, nil, upsertUnchanged, true, nil
+	}
+
+	lock, err := CheckLock(stashDir, stash.Name, recordID, actor)
+	if err != nil {
+		return nil, nil, "", false, fmt.Errorf("failed to check lock: %w", err)
+	}
+	if lock != nil {
+		ExitRecordLocked(recordID, lock)
+		return nil, nil, "", false, nil
+	}
+	if !checkPermission(stash, actor, model.PermUpdate, fieldNames(fields)) {
+		return nil, nil, "", false, nil
+	}
+
+	var warnings []ValidationError
+	for _, name := range touched {
+		col := stash.Columns.Find(name)
+		if !upsertForce && !checkTransition(col, record, fields[name]) {
+			return nil, nil, "", false, nil
+		}
+		valResult := ValidateValue(col, fields[name])
+		if exitViolation(valResult) {
+			return nil, nil, "", false, nil
+		}
+		warnings = append(warnings, valResult.Warnings...)
+	}
+	for _, name := range touched {
+		record.SetField(name, fields[name])
+	}
+
+	ruleResult := ValidateRules(stash, record.Fields, touched)
+	if exitViolation(ruleResult) {
+		return nil, nil, "", false, nil
+	}
+	warnings = append(warnings, ruleResult.Warnings...)
+	hookResult := ValidateHooks(stash, record, touched)
+	if exitViolation(hookResult) {
+		return nil, nil, "", false, nil
+	}
+	warnings = append(warnings, hookResult.Warnings...)
+
+	record.UpdatedAt = time.Now()
+	record.UpdatedBy = actor
+	return record, warnings, upsertUpdated, true, nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	upsert := func(t *testing.T, args ...string) UpsertResult {
+		t.Helper()
+		var result UpsertResult
+		output := captureSchemaOutput(t, append([]string{"upsert"}, append(args, "--json")...)...)
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse upsert output %q: %v", output, err)
+		}
+		return result
+	}
+
+	t.Run("creates, then updates, then leaves alone", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Price"})
+		defer cleanup()
+
+		created := upsert(t, "Laptop", "--set", "Price=999")
+		if !created.Created || created.ID == "" {
+			t.Fatalf("expected a created record, got %+v", created)
+		}
+
+		updated := upsert(t, "Laptop", "--set", "Price=899")
+		if updated.ID != created.ID || updated.Created || !updated.Updated {
+			t.Errorf("expected %s to be updated, got %+v", created.ID, updated)
+		}
+		if price, _ := updated.Record.GetField("Price"); price != "899" {
+			t.Errorf("expected Price 899, got %v", price)
+		}
+
+		unchanged := upsert(t, "Laptop", "--set", "Price=899")
+		if unchanged.ID != created.ID || unchanged.Created || unchanged.Updated {
+			t.Errorf("expected %s to be unchanged, got %+v", created.ID, unchanged)
+		}
+	})
+
+	t.Run("--match picks the columns to match on", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name", "Email"})
+		defer cleanup()
+
+		first := upsert(t, "Alice", "--match", "Email", "--set", "Email=alice@example.com")
+		renamed := upsert(t, "Alice Smith", "--match", "email", "--set", "Email=alice@example.com")
+		if renamed.ID != first.ID || !renamed.Updated {
+			t.Errorf("expected %s to be renamed, got %+v", first.ID, renamed)
+		}
+
+		rootCmd.SetArgs([]string{"upsert", "Bob", "--match", "Email"})
+		rootCmd.Execute()
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2 for --match without a value, got %d", ExitCode)
+		}
+	})
+
+	t.Run("more than one match is a conflict", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		for i := 0; i < 2; i++ {
+			rootCmd.SetArgs([]string{"add", "Laptop"})
+			rootCmd.Execute()
+			resetFlags()
+		}
+
+		output := captureSchemaOutput(t, "upsert", "Laptop", "--json")
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result["code"] != ErrCodeConflict {
+			t.Errorf("expected %s, got %v", ErrCodeConflict, result["code"])
+		}
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+	})
+}
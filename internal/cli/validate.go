@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -55,18 +56,173 @@ type ValidationError struct {
 	Rule     string `json:"rule"`
 	Message  string `json:"message"`
 	RecordID string `json:"record_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
 }
 
 // ValidationResult represents the result of validating a value against column constraints
 type ValidationResult struct {
-	Valid  bool              `json:"valid"`
-	Errors []ValidationError `json:"errors,omitempty"`
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationError `json:"errors,omitempty"`
+	Warnings []ValidationError `json:"warnings,omitempty"`
 }
 
-// ValidateValue validates a single value against a column's constraints
+// details returns the error details reported for a violation.
+func (v ValidationError) details() map[string]interface{} {
+	details := map[string]interface{}{
+		"column":   v.Column,
+		"value":    v.Value,
+		"rule":     v.Rule,
+		"severity": v.Severity,
+	}
+	if v.RecordID != "" {
+		details["record_id"] = v.RecordID
+	}
+	return details
+}
+
+// exitViolation reports the first violation of a failed validation. It
+// returns false if the result is valid.
+func exitViolation(result *ValidationResult) bool {
+	if result.Valid {
+		return false
+	}
+	if len(result.Errors) == 0 {
+		ExitValidationError("validation failed", nil)
+		return true
+	}
+	ExitValidationError(result.Errors[0].Message, result.Errors[0].details())
+	return true
+}
+
+// printValidationWarnings reports violations of warning-severity columns
+// that were accepted.
+func printValidationWarnings(warnings []ValidationError) {
+	for _, w := range warnings {
+		PrintWarning(ErrCodeValidationWarning, fmt.Sprintf("%s: %s", w.Column, w.Message), w.details())
+	}
+}
+
+// coerceEnumValue returns a value with the string, or each list element,
+// that a coercing enum column matches ignoring case replaced by the allowed
+// value (see model.Column.CoerceEnum). Returns false if nothing changed.
+func coerceEnumValue(col *model.Column, value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return col.CoerceEnum(v)
+	case []interface{}:
+		elems := make([]interface{}, len(v))
+		coerced := false
+		for i, e := range v {
+			elems[i] = e
+			if s, ok := e.(string); ok {
+				if canonical, ok := col.CoerceEnum(s); ok {
+					elems[i] = canonical
+					coerced = true
+				}
+			}
+		}
+		if coerced {
+			return elems, true
+		}
+	}
+	return value, false
+}
+
+// coerceEnums replaces the values being written to coercing enum columns
+// with the allowed values they stand for, before they are validated, and
+// notes each replacement. Returns the values as given by column name, to
+// record on the operation as _coerced, or nil if none were coerced.
+func coerceEnums(stash *model.Stash, fields map[string]interface{}) map[string]interface{} {
+	var coerced map[string]interface{}
+	for name, value := range fields {
+		col := stash.Columns.Find(name)
+		if col == nil {
+			continue
+		}
+		canonical, ok := coerceEnumValue(col, value)
+		if !ok {
+			continue
+		}
+		if coerced == nil {
+			coerced = make(map[string]interface{})
+		}
+		coerced[col.Name] = value
+		fields[name] = canonical
+		printCoercion(col, value, canonical)
+	}
+	return coerced
+}
+
+// coerceListEdits coerces the elements being added to or removed from list
+// columns like coerceEnums, adding the elements as given to coerced.
+func coerceListEdits(stash *model.Stash, edits []fieldAssignment, coerced map[string]interface{}) map[string]interface{} {
+	for i, edit := range edits {
+		col := stash.Columns.Find(edit.Field)
+		if col == nil || !col.List {
+			continue
+		}
+		canonical, ok := col.CoerceEnum(edit.Value)
+		if !ok {
+			continue
+		}
+		if coerced == nil {
+			coerced = make(map[string]interface{})
+		}
+		coerced[col.Name] = edit.Value
+		edits[i].Value = canonical
+		printCoercion(col, edit.Value, canonical)
+	}
+	return coerced
+}
+
+// printCoercion notes that a value was written as the enum value it
+// matched ignoring case.
+func printCoercion(col *model.Column, value, canonical interface{}) {
+	PrintWarning(ErrCodeEnumCoerced, fmt.Sprintf("%s: '%s' written as '%s'", col.Name, valueText(value), valueText(canonical)),
+		map[string]interface{}{"column": col.Name, "value": value, "coerced": canonical})
+}
+
+// ValidateValue validates a single value against a column's constraints.
+// Violations of a column with warning severity are returned as warnings
+// and leave the result valid.
 func ValidateValue(col *model.Column, value interface{}) *ValidationResult {
+	result := validateConstraints(col, value)
+	severity := col.ViolationSeverity()
+	for i := range result.Errors {
+		result.Errors[i].Severity = severity
+	}
+	if severity == model.SeverityWarning && len(result.Errors) > 0 {
+		result.Warnings = result.Errors
+		result.Errors = []ValidationError{}
+		result.Valid = true
+	}
+	return result
+}
+
+// validateConstraints checks a value against a column's constraints,
+// reporting every violation as an error.
+func validateConstraints(col *model.Column, value interface{}) *ValidationResult {
 	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
 
+	// List columns must have elements when required, and each element is
+	// checked against the enum and validation type
+	if col.List {
+		elems := listElements(value)
+		if col.Required && len(elems) == 0 {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Column:  col.Name,
+				Rule:    "required",
+				Message: fmt.Sprintf("column '%s' is required", col.Name),
+			})
+			return result
+		}
+		for _, elem := range elems {
+			checkValueConstraints(col, elem, result)
+		}
+		return result
+	}
+
 	// Convert value to string for validation
 	strValue := ""
 	if value != nil {
@@ -90,6 +246,13 @@ func ValidateValue(col *model.Column, value interface{}) *ValidationResult {
 		return result
 	}
 
+	checkValueConstraints(col, strValue, result)
+	return result
+}
+
+// checkValueConstraints checks a non-empty value against a column's enum
+// and validation type, adding any violations to result.
+func checkValueConstraints(col *model.Column, strValue string, result *ValidationResult) {
 	// Check enum constraint
 	if len(col.Enum) > 0 {
 		found := false
@@ -134,8 +297,6 @@ func ValidateValue(col *model.Column, value interface{}) *ValidationResult {
 			})
 		}
 	}
-
-	return result
 }
 
 // validateEmail checks if a string is a valid email address
@@ -173,20 +334,26 @@ func validateNumber(value string) error {
 
 // validateDate checks if a string is a valid ISO date
 func validateDate(value string) error {
-	// Try common date formats
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05",
-		"2006-01-02",
-		time.DateOnly,
+	if _, _, err := parseDate(value); err != nil {
+		return err
 	}
+	return nil
+}
 
-	for _, format := range formats {
-		if _, err := time.Parse(format, value); err == nil {
-			return nil
-		}
+// parseDate parses a date value in one of the accepted ISO formats.
+// Values without a time zone are interpreted in local time. dateOnly
+// reports whether the value had no time component.
+func parseDate(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local); err == nil {
+		return t, false, nil
 	}
-	return fmt.Errorf("invalid date format: '%s' (expected ISO format like 2006-01-02 or 2006-01-02T15:04:05Z)", value)
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date format: '%s' (expected ISO format like 2006-01-02 or 2006-01-02T15:04:05Z)", value)
 }
 
 // ValidateRecord validates all fields in a record against column constraints
@@ -203,6 +370,73 @@ func ValidateRecord(stash *model.Stash, record *model.Record) *ValidationResult
 			}
 			result.Errors = append(result.Errors, colResult.Errors...)
 		}
+		for i := range colResult.Warnings {
+			colResult.Warnings[i].RecordID = record.ID
+		}
+		result.Warnings = append(result.Warnings, colResult.Warnings...)
+	}
+
+	ruleResult := ValidateRules(stash, record.Fields, nil)
+	for i := range ruleResult.Errors {
+		ruleResult.Errors[i].RecordID = record.ID
+	}
+	for i := range ruleResult.Warnings {
+		ruleResult.Warnings[i].RecordID = record.ID
+	}
+	result.merge(ruleResult)
+	result.merge(ValidateHooks(stash, record, nil))
+
+	return result
+}
+
+// merge adds another result's violations to r.
+func (r *ValidationResult) merge(other *ValidationResult) {
+	if !other.Valid {
+		r.Valid = false
+	}
+	r.Errors = append(r.Errors, other.Errors...)
+	r.Warnings = append(r.Warnings, other.Warnings...)
+}
+
+// ValidateRules checks fields against the stash's conditional rules. With
+// columns given, only rules involving one of them are checked, so a write
+// does not fail on a rule it did not touch.
+func ValidateRules(stash *model.Stash, fields map[string]interface{}, columns []string) *ValidationResult {
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	involves := func(name string) bool {
+		for _, col := range columns {
+			if strings.EqualFold(col, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range stash.Rules {
+		cond, err := model.ParseCondition(rule.When)
+		if err != nil {
+			continue // Rules are checked when added
+		}
+		if columns != nil && !involves(rule.Require) && !involves(cond.Column) {
+			continue
+		}
+		if !cond.Matches(listElements(fields[cond.Column])) || len(listElements(fields[rule.Require])) > 0 {
+			continue
+		}
+
+		violation := ValidationError{
+			Column:   rule.Require,
+			Value:    "",
+			Rule:     "required_when",
+			Message:  fmt.Sprintf("column '%s' is required when %s", rule.Require, rule.When),
+			Severity: rule.ViolationSeverity(),
+		}
+		if violation.Severity == model.SeverityWarning {
+			result.Warnings = append(result.Warnings, violation)
+			continue
+		}
+		result.Valid = false
+		result.Errors = append(result.Errors, violation)
 	}
 
 	return result
@@ -223,23 +457,33 @@ func ValidateFields(stash *model.Stash, fields map[string]interface{}) *Validati
 			result.Valid = false
 			result.Errors = append(result.Errors, colResult.Errors...)
 		}
+		result.Warnings = append(result.Warnings, colResult.Warnings...)
 	}
 
 	// Check required fields that are not being set
 	for _, col := range stash.Columns {
 		if col.Required {
 			if _, ok := fields[col.Name]; !ok {
+				missing := ValidationError{
+					Column:   col.Name,
+					Value:    "",
+					Rule:     "required",
+					Message:  fmt.Sprintf("column '%s' is required", col.Name),
+					Severity: col.ViolationSeverity(),
+				}
+				if missing.Severity == model.SeverityWarning {
+					result.Warnings = append(result.Warnings, missing)
+					continue
+				}
 				result.Valid = false
-				result.Errors = append(result.Errors, ValidationError{
-					Column:  col.Name,
-					Value:   "",
-					Rule:    "required",
-					Message: fmt.Sprintf("column '%s' is required", col.Name),
-				})
+				result.Errors = append(result.Errors, missing)
 			}
 		}
 	}
 
+	// Check conditional rules against the new record
+	result.merge(ValidateRules(stash, fields, nil))
+
 	return result
 }
 
@@ -249,9 +493,25 @@ type ValidateStashOutput struct {
 	TotalRecords int              `json:"total_records"`
 	ValidRecords int              `json:"valid_records"`
 	ErrorCount   int              `json:"error_count"`
+	WarningCount int              `json:"warning_count"`
 	Errors       []ValidationError `json:"errors,omitempty"`
+	Warnings     []ValidationError `json:"warnings,omitempty"`
+}
+
+// ValidateReport is the report file written by validate --report
+type ValidateReport struct {
+	GeneratedAt time.Time  `json:"generated_at"`
+	Since       *time.Time `json:"since,omitempty"`
+	FailOn      string     `json:"fail_on"`
+	ValidateStashOutput
 }
 
+var (
+	validateReport string
+	validateFailOn string
+	validateSince  string
+)
+
 var validateCmd = &cobra.Command{
 	Use:   "validate [stash-name]",
 	Short: "Validate all records against column constraints",
@@ -261,11 +521,27 @@ Checks all records for:
   - Required field violations
   - Enum value violations
   - Format violations (email, url, number, date)
+  - Conditional rules (see 'stash rule'), reported with rule "required_when"
+
+Archived records are skipped.
+
+Violations of columns added with --severity warning (or --warn), and of
+rules added with --severity warning, are reported as warnings: writes
+that break them are accepted, and they only fail validate with
+--fail-on warning.
+
+Options:
+  --fail-on <level>  Exit 2 on errors (default) or on warnings too: error, warning
+  --report <file>    Also write the full report as JSON to a file
+  --since <when>     Only check records updated since a duration ago (24h, 7d)
+                     or a date (2025-01-31), to keep CI fast on large stashes
 
 Examples:
   stash validate
   stash validate inventory
   stash validate --json
+  stash validate --fail-on warning
+  stash validate --since 24h --report validation.json
 
 AI Agent Examples:
   # Validate before bulk import
@@ -277,10 +553,14 @@ AI Agent Examples:
   # Get count of invalid records
   stash validate --json | jq '.error_count'
 
+  # CI: check only today's changes and keep the report as an artifact
+  stash validate --since 24h --fail-on warning --report validation.json
+
 Exit Codes:
-  0  Success - all records valid
+  0  Success - all records valid (warnings allowed unless --fail-on warning)
   1  Stash not found
-  2  Validation errors found (records fail constraints)
+  2  Validation errors found (records fail constraints), or warnings
+     found with --fail-on warning; also an invalid --fail-on or --since
 
 JSON Output (--json):
   {
@@ -288,20 +568,45 @@ JSON Output (--json):
     "total_records": 100,
     "valid_records": 95,
     "error_count": 5,
+    "warning_count": 1,
     "errors": [
-      {"column": "email", "value": "invalid", "rule": "email", "message": "...", "record_id": "inv-abc1"}
+      {"column": "email", "value": "invalid", "rule": "email", "message": "...", "record_id": "inv-abc1", "severity": "error"}
+    ],
+    "warnings": [
+      {"column": "notes", "value": "", "rule": "required", "message": "...", "record_id": "inv-abc2", "severity": "warning"}
     ]
   }
+
+Report File (--report):
+  The JSON output above, plus "generated_at", "fail_on", and "since".
 `,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runValidate,
 }
 
 func init() {
+	validateCmd.Flags().StringVar(&validateReport, "report", "", "Write the full report as JSON to a file")
+	validateCmd.Flags().StringVar(&validateFailOn, "fail-on", model.SeverityError, "Lowest severity that fails validation: error, warning")
+	validateCmd.Flags().StringVar(&validateSince, "since", "", "Only check records updated since a duration ago or a date")
 	rootCmd.AddCommand(validateCmd)
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
+	// Validate flags before touching the store
+	if validateFailOn != model.SeverityError && validateFailOn != model.SeverityWarning {
+		ExitValidationError(fmt.Sprintf("invalid --fail-on '%s' (valid: error, warning)", validateFailOn),
+			map[string]interface{}{"fail_on": validateFailOn})
+		return nil
+	}
+	var since time.Time
+	if validateSince != "" {
+		var err error
+		if since, err = parseTimeFilter(validateSince); err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"since": validateSince})
+			return nil
+		}
+	}
+
 	// Resolve context - stash is required
 	var stashNameArg string
 	if len(args) > 0 {
@@ -327,7 +632,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create storage
-	store, err := storage.NewStore(ctx.StashDir)
+	store, err := openStore(ctx.StashDir)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -343,10 +648,12 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stash: %w", err)
 	}
 
-	// Get all records
+	// Get all records (archived records are exempt from validation)
 	records, err := store.ListRecords(ctx.Stash, storage.ListOptions{
-		ParentID:       "*",
-		IncludeDeleted: false,
+		ParentID:        "*",
+		IncludeDeleted:  false,
+		ExcludeArchived: true,
+		UpdatedSince:    since,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to list records: %w", err)
@@ -359,6 +666,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		ValidRecords: 0,
 		ErrorCount:   0,
 		Errors:       []ValidationError{},
+		Warnings:     []ValidationError{},
 	}
 
 	for _, record := range records {
@@ -369,6 +677,26 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			output.ErrorCount += len(result.Errors)
 			output.Errors = append(output.Errors, result.Errors...)
 		}
+		output.WarningCount += len(result.Warnings)
+		output.Warnings = append(output.Warnings, result.Warnings...)
+	}
+
+	if validateReport != "" {
+		report := ValidateReport{
+			GeneratedAt:         time.Now().UTC().Truncate(time.Second),
+			FailOn:              validateFailOn,
+			ValidateStashOutput: output,
+		}
+		if !since.IsZero() {
+			report.Since = &since
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if err := os.WriteFile(validateReport, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
 	}
 
 	// Output result
@@ -393,10 +721,16 @@ func runValidate(cmd *cobra.Command, args []string) error {
 				fmt.Printf("  [%s] %s: %s\n", err.RecordID, err.Column, err.Message)
 			}
 		}
+		if output.WarningCount > 0 && (!IsQuiet() || validateFailOn == model.SeverityWarning) {
+			fmt.Printf("\nWarnings in stash '%s': %d\n", ctx.Stash, output.WarningCount)
+			for _, warn := range output.Warnings {
+				fmt.Printf("  [%s] %s: %s\n", warn.RecordID, warn.Column, warn.Message)
+			}
+		}
 	}
 
-	// Exit with code 2 if validation errors found
-	if output.ErrorCount > 0 {
+	// Exit with code 2 if violations at or above the --fail-on level were found
+	if output.ErrorCount > 0 || (validateFailOn == model.SeverityWarning && output.WarningCount > 0) {
 		Exit(2)
 	}
 
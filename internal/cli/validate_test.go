@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -909,3 +910,302 @@ func TestColumnListShowsValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateWarnings(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		t.Helper()
+		tempDir, cleanup := setupTestStashWithColumns(t, "test", "tst-", []string{"Name"})
+		rootCmd.SetArgs([]string{"column", "add", "Owner", "--required", "--warn"})
+		rootCmd.Execute()
+		resetFlags()
+
+		// The write is accepted despite the missing Owner
+		ExitCode = 0
+		rootCmd.SetArgs([]string{"add", "first", "--quiet"})
+		rootCmd.Execute()
+		resetFlags()
+		if ExitCode != 0 {
+			t.Fatalf("expected warning column to accept the write, got exit code %d", ExitCode)
+		}
+		return tempDir, cleanup
+	}
+
+	t.Run("warnings are reported without failing", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		output := captureSchemaOutput(t, "validate", "--json")
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0 with only warnings, got %d", ExitCode)
+		}
+		var result ValidateStashOutput
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse JSON output: %v", err)
+		}
+		if result.ErrorCount != 0 || result.WarningCount != 1 {
+			t.Fatalf("expected 0 errors and 1 warning, got %d and %d", result.ErrorCount, result.WarningCount)
+		}
+		if result.Warnings[0].Severity != model.SeverityWarning || result.Warnings[0].Column != "Owner" {
+			t.Errorf("unexpected warning: %+v", result.Warnings[0])
+		}
+	})
+
+	t.Run("fail-on warning exits 2", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "validate", "--fail-on", "warning")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("invalid fail-on level", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "validate", "--fail-on", "info")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("report file", func(t *testing.T) {
+		tempDir, cleanup := setup(t)
+		defer cleanup()
+
+		reportPath := filepath.Join(tempDir, "report.json")
+		ExitCode = 0
+		captureSchemaOutput(t, "validate", "--report", reportPath, "--fail-on", "warning")
+
+		data, err := os.ReadFile(reportPath)
+		if err != nil {
+			t.Fatalf("expected report file: %v", err)
+		}
+		var report ValidateReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("failed to parse report: %v", err)
+		}
+		if report.FailOn != "warning" || report.WarningCount != 1 || report.GeneratedAt.IsZero() {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("since skips older records", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		output := captureSchemaOutput(t, "validate", "--json", "--since", "2999-01-01")
+		var result ValidateStashOutput
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse JSON output: %v", err)
+		}
+		if result.TotalRecords != 0 || result.WarningCount != 0 {
+			t.Errorf("expected no records checked, got %d records and %d warnings", result.TotalRecords, result.WarningCount)
+		}
+
+		output = captureSchemaOutput(t, "validate", "--json", "--since", "1h")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse JSON output: %v", err)
+		}
+		if result.TotalRecords != 1 {
+			t.Errorf("expected 1 recent record, got %d", result.TotalRecords)
+		}
+	})
+
+	t.Run("warn requires a constraint", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		captureSchemaOutput(t, "column", "add", "Notes", "--warn")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
+
+// captureStderr returns what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	fn()
+	w.Close()
+	os.Stderr = oldStderr
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestValidationSeverity(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name"})
+		captureSchemaOutput(t, "column", "add", "Email", "--validate", "email", "--severity", "warn")
+		captureSchemaOutput(t, "column", "add", "Site", "--validate", "url")
+		if ExitCode != 0 {
+			t.Fatalf("failed to add columns, exit %d", ExitCode)
+		}
+		return tempDir, cleanup
+	}
+
+	t.Run("add and set accept warnings and report them", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		var id string
+		stderr := captureStderr(t, func() {
+			id = strings.TrimSpace(captureSchemaOutput(t, "add", "Alice", "--set", "Email=not-an-email"))
+		})
+		if ExitCode != 0 || id == "" {
+			t.Fatalf("expected add to succeed, got exit %d", ExitCode)
+		}
+		if !strings.Contains(stderr, "Warning:") || !strings.Contains(stderr, "Email") {
+			t.Errorf("expected a warning on stderr, got %q", stderr)
+		}
+
+		stderr = captureStderr(t, func() {
+			captureSchemaOutput(t, "set", id, "Email=still-bad", "--json")
+		})
+		if ExitCode != 0 {
+			t.Fatalf("expected set to succeed, got exit %d", ExitCode)
+		}
+		var warning JSONWarning
+		if err := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &warning); err != nil {
+			t.Fatalf("failed to parse warning %q: %v", stderr, err)
+		}
+		if !warning.Warning || warning.Code != ErrCodeValidationWarning ||
+			warning.Details["severity"] != model.SeverityWarning || warning.Details["record_id"] != id {
+			t.Errorf("unexpected warning: %+v", warning)
+		}
+
+		// --quiet drops text warnings
+		stderr = captureStderr(t, func() {
+			captureSchemaOutput(t, "set", id, "Email=bad-again", "--quiet")
+		})
+		if stderr != "" {
+			t.Errorf("expected no warning with --quiet, got %q", stderr)
+		}
+	})
+
+	t.Run("errors still reject writes and report their severity", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+
+		ExitCode = 0
+		output := captureSchemaOutput(t, "add", "Bob", "--set", "Site=nope", "--json")
+		if ExitCode != 2 {
+			t.Fatalf("expected exit code 2, got %d", ExitCode)
+		}
+		var errResp JSONError
+		json.Unmarshal([]byte(output), &errResp)
+		if errResp.Details["severity"] != model.SeverityError || errResp.Details["column"] != "Site" {
+			t.Errorf("unexpected error: %+v", errResp)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("validate reports warnings separately", func(t *testing.T) {
+		_, cleanup := setup(t)
+		defer cleanup()
+		captureSchemaOutput(t, "add", "Alice", "--set", "Email=not-an-email", "--quiet")
+
+		var result ValidateStashOutput
+		json.Unmarshal([]byte(captureSchemaOutput(t, "validate", "--json")), &result)
+		if result.ErrorCount != 0 || result.WarningCount != 1 || result.Warnings[0].Severity != model.SeverityWarning {
+			t.Errorf("expected 1 warning and no errors, got %+v", result)
+		}
+	})
+
+	t.Run("invalid severity", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "contacts", "ct-", []string{"Name"})
+		defer cleanup()
+
+		for _, args := range [][]string{
+			{"column", "add", "Email", "--validate", "email", "--severity", "info"},
+			{"column", "add", "Email", "--validate", "email", "--severity", "error", "--warn"},
+		} {
+			ExitCode = 0
+			captureSchemaOutput(t, args...)
+			if ExitCode != 2 {
+				t.Errorf("%v: expected exit code 2, got %d", args, ExitCode)
+			}
+		}
+		ExitCode = 0
+	})
+}
+
+func TestEnumCoerce(t *testing.T) {
+	setup := func(t *testing.T, coerce bool) (string, func()) {
+		t.Helper()
+		tempDir, cleanup := setupTestStashWithColumns(t, "tasks", "tsk-", []string{"Title"})
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		store.AddColumn("tasks", model.Column{
+			Name:    "Status",
+			Enum:    []string{"pending", "active"},
+			Coerce:  coerce,
+			Added:   time.Now(),
+			AddedBy: "test",
+		})
+		store.Close()
+		return tempDir, cleanup
+	}
+
+	t.Run("coercing column writes the allowed value and records the given one", func(t *testing.T) {
+		tempDir, cleanup := setup(t, true)
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Ship it", "--set", "Status=Pending")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+
+		store, _ := storage.NewStore(filepath.Join(tempDir, ".stash"))
+		defer store.Close()
+		records, _ := store.ListRecords("tasks", storage.ListOptions{ParentID: "*"})
+		if len(records) != 1 || records[0].Fields["Status"] != "pending" {
+			t.Fatalf("expected Status pending, got %v", records)
+		}
+
+		captureSchemaOutput(t, "set", records[0].ID, "Status=ACTIVE")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d", ExitCode)
+		}
+		ops, _ := store.GetRecordHistory("tasks", records[0].ID)
+		if len(ops) != 2 {
+			t.Fatalf("expected 2 operations, got %d", len(ops))
+		}
+		if ops[0].Coerced["Status"] != "Pending" || ops[1].Coerced["Status"] != "ACTIVE" {
+			t.Errorf("expected coercions recorded on the operations, got %v and %v", ops[0].Coerced, ops[1].Coerced)
+		}
+		if ops[1].Fields["Status"] != "active" {
+			t.Errorf("expected Status active, got %v", ops[1].Fields["Status"])
+		}
+	})
+
+	t.Run("strict column rejects a value in another case", func(t *testing.T) {
+		_, cleanup := setup(t, false)
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Ship it", "--set", "Status=Pending")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+
+	t.Run("column add requires an enum", func(t *testing.T) {
+		_, cleanup := setup(t, false)
+		defer cleanup()
+
+		captureSchemaOutput(t, "column", "add", "Kind", "--coerce")
+		if ExitCode != 2 {
+			t.Errorf("expected exit code 2, got %d", ExitCode)
+		}
+	})
+}
@@ -0,0 +1,427 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/context"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	verifyEnable     bool
+	verifySignatures bool
+	verifyDeep       bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the integrity of stashes, for scripts and CI",
+	Long: `Check that the stashes of a .stash directory are intact, and exit
+non-zero if any check fails: a gate for pre-commit hooks and CI in
+repositories that version their .stash directory. Unlike 'stash doctor',
+verify prints only what it checked, changes nothing, and treats every
+warning as a failure.
+
+For each stash (the current one with --stash, else all of them):
+  config      config.json parses, and names the stash and a valid prefix
+  jsonl       every line of records.jsonl parses
+  hashes      each record's hash matches its fields (--deep: every
+              operation's, not only each record's latest)
+  cache       the SQLite cache holds each record, values included, as its
+              last operation left it
+  schema      the cache has a column for every column in config.json
+  cache_schema  the cache is at this build's schema version
+  hash_chain  the log's hash chain is intact (stashes in hash chain mode)
+
+In hash chain mode every line of records.jsonl stores the SHA-256 hash of
+the line before it, so editing, inserting, or removing an earlier line
+breaks the chain at the line that follows it. Enable it with 'stash init
+--hash-chain' or, for an existing stash, 'stash verify --enable', which
+chains the existing log from its first line. Compaction and prefix
+migrations rewrite the log, but the chain runs on: the first line of the
+new log links to the last line of the old one, and the rewrite is listed
+in records.jsonl.chain. The check reports how often the log was rewritten
+and the head the chain continues from, so an old copy of the log can
+still be matched against it.
+
+With --signatures, verify checks operation signatures instead (see 'stash
+actor'). It reports every signature that does not match the actor's
+published key, and every unsigned operation attributed to an actor who had
+already published a key, such as one made with another person's --actor
+value. Operations by actors without keys are counted but not reported.
+Records renamed by 'stash prefix set --migrate' lose the signatures this
+machine has no key to sign again, and that command lists them.
+
+Examples:
+  stash verify
+  stash verify --deep
+  stash verify --stash audit --json
+  stash verify --enable
+  stash verify --signatures
+
+AI Agent Examples:
+  # Fail a pipeline if a stash was corrupted or tampered with
+  stash verify --deep --quiet || echo "stash integrity check failed"
+
+Exit Codes:
+  0  Every check passed (or chain enabled); with --signatures, no issues found
+  1  Stash not found, a check failed, or signature issues found
+
+Output:
+  One line per check: [OK], [WARN], or [ERROR], the stash and check, and
+  what was found. Nothing with --quiet.
+
+JSON Output (--json):
+  {"valid": false, "stashes": ["audit"],
+   "checks": [{"check": "audit/hash_chain", "status": "error", "message": "...", "details": "..."}, ...]}
+
+JSON Output (--signatures --json):
+  {"stash": "audit", "valid": false, "lines": 42, "signed": 40, "valid_signatures": 39,
+   "unsigned": 2, "unverified": 0,
+   "issues": [{"line": 17, "id": "aud-ex4j", "actor": "alice", "problem": "..."}]}`,
+	Args: cobra.NoArgs,
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyEnable, "enable", false, "Enable hash chain mode and chain the existing log")
+	verifyCmd.Flags().BoolVar(&verifySignatures, "signatures", false, "Verify operation signatures against published actor keys")
+	verifyCmd.Flags().BoolVar(&verifyDeep, "deep", false, "Verify the hash of every operation, not only each record's latest")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	if verifyEnable && verifySignatures {
+		ExitValidationError("--enable and --signatures cannot be used together", nil)
+		return nil
+	}
+
+	// Resolve context; without a stash, every stash is verified
+	ctx, err := context.Resolve(GetActorName(), GetStashName())
+	if err != nil {
+		return fmt.Errorf("failed to resolve context: %w", err)
+	}
+	if ctx.StashDir == "" {
+		ExitNoStashDir()
+		return nil
+	}
+	if ctx.Stash == "" && (verifyEnable || verifySignatures) {
+		ExitValidationError("no stash specified and multiple stashes exist (use --stash)", nil)
+		return nil
+	}
+
+	// Create storage
+	store, err := openStore(ctx.StashDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	var stashes []*model.Stash
+	if ctx.Stash != "" {
+		stash, err := store.GetStash(ctx.Stash)
+		if err != nil {
+			if errors.Is(err, model.ErrStashNotFound) {
+				ExitStashNotFound(ctx.Stash)
+				return nil
+			}
+			return fmt.Errorf("failed to get stash: %w", err)
+		}
+		stashes = append(stashes, stash)
+	} else if stashes, err = store.ListStashes(); err != nil {
+		return fmt.Errorf("failed to list stashes: %w", err)
+	}
+
+	if verifySignatures {
+		return runVerifySignatures(store, stashes[0])
+	}
+
+	if verifyEnable && !stashes[0].HashChain {
+		if err := store.EnableHashChain(stashes[0].Name); err != nil {
+			return fmt.Errorf("failed to enable hash chain: %w", err)
+		}
+		stashes[0].HashChain = true
+		if !GetJSONOutput() && !IsQuiet() {
+			fmt.Printf("Enabled hash chain for stash '%s'\n", stashes[0].Name)
+		}
+	}
+
+	results := []CheckResult{}
+	names := []string{}
+	for _, stash := range stashes {
+		results = append(results, verifyStash(ctx, store, stash)...)
+		names = append(names, stash.Name)
+	}
+	valid := true
+	failed := 0
+	for _, r := range results {
+		if r.Status != "ok" {
+			valid = false
+			failed++
+		}
+	}
+
+	// Output result
+	if GetJSONOutput() {
+		data, _ := json.Marshal(map[string]interface{}{"valid": valid, "stashes": names, "checks": results})
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		for _, r := range results {
+			fmt.Printf("%-8s %s: %s\n", checkStatusIcon(r.Status), r.Check, r.Message)
+			if r.Details != "" {
+				fmt.Printf("         %s\n", r.Details)
+			}
+		}
+		if valid {
+			fmt.Printf("Verified %d stash(es): all %d check(s) passed\n", len(stashes), len(results))
+		} else {
+			fmt.Printf("Verification FAILED: %d of %d check(s) did not pass\n", failed, len(results))
+		}
+	}
+
+	if !valid {
+		Exit(1)
+	}
+	return nil
+}
+
+// verifyStash runs verify's checks on one stash.
+func verifyStash(ctx *context.Context, store *storage.Store, stash *model.Stash) []CheckResult {
+	results := []CheckResult{
+		checkConfig(ctx, stash.Name),
+		checkJSONLIntegrity(ctx, stash.Name),
+	}
+
+	history, err := store.GetAllHistory(stash.Name)
+	if err != nil {
+		results = append(results, CheckResult{
+			Check:   fmt.Sprintf("%s/hashes", stash.Name),
+			Status:  "error",
+			Message: "Cannot read records.jsonl",
+			Details: err.Error(),
+		})
+	} else {
+		latest := make(map[string]*model.Record)
+		for _, op := range history {
+			latest[op.ID] = op
+		}
+		results = append(results, checkOperationHashes(stash, history, latest), checkCacheRecords(store, stash, latest))
+	}
+
+	results = append(results, checkSchemaAgreement(store, stash), checkCacheSchema(store, stash.Name))
+	if stash.HashChain {
+		results = append(results, checkHashChain(store, stash))
+	}
+	return results
+}
+
+// checkOperationHashes checks that the hash each record's last operation
+// carries matches its fields, or with --deep that every operation's does.
+func checkOperationHashes(stash *model.Stash, history []*model.Record, latest map[string]*model.Record) CheckResult {
+	check := fmt.Sprintf("%s/hashes", stash.Name)
+
+	ops := history
+	if !verifyDeep {
+		ops = ops[:0:0]
+		for _, op := range latest {
+			ops = append(ops, op)
+		}
+		sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	}
+	var mismatches []string
+	for _, op := range ops {
+		if expected := model.CalculateHash(op.Fields); op.Hash != expected && op.Hash != model.CalculateHash(scalarText(op.Fields)) {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s (expected %s, got %s)", op.ID, op.Operation, expected, op.Hash))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		details := mismatches
+		if len(details) > 5 {
+			details = append(details[:5:5], "...")
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: fmt.Sprintf("%d hash mismatch(es)", len(mismatches)),
+			Details: strings.Join(details, "; "),
+		}
+	}
+	what := "record(s)"
+	if verifyDeep {
+		what = "operation(s)"
+	}
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("All %d %s match their hashes", len(ops), what),
+	}
+}
+
+// scalarText returns the fields with numbers and booleans as text.
+// Operations that leave fields alone, such as delete and archive, write
+// them as the cache reads them back (5 for "5") under the hash they had.
+func scalarText(fields map[string]interface{}) map[string]interface{} {
+	text := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		switch value.(type) {
+		case float64, bool, json.Number:
+			text[name] = fmt.Sprint(value)
+		default:
+			text[name] = value
+		}
+	}
+	return text
+}
+
+// checkCacheRecords checks that the cache holds each record as its last
+// operation in the log left it, and no record the log lacks.
+func checkCacheRecords(store *storage.Store, stash *model.Stash, latest map[string]*model.Record) CheckResult {
+	check := fmt.Sprintf("%s/cache", stash.Name)
+
+	cached, err := store.ListRecords(stash.Name, storage.ListOptions{IncludeDeleted: true, ParentID: "*"})
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot list cached records",
+			Details: err.Error(),
+		}
+	}
+
+	// The cached values are compared with the log's, so a value changed
+	// in the cache alone is found. Computed columns are not logged.
+	columns := stash.Columns.StoredNames()
+
+	var problems []string
+	seen := make(map[string]bool, len(cached))
+	for _, rec := range cached {
+		seen[rec.ID] = true
+		op, ok := latest[rec.ID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is cached but not in the log", rec.ID))
+			continue
+		}
+		var differ []string
+		for _, name := range columns {
+			if !reflect.DeepEqual(storage.CachedValue(op.Fields[name]), rec.Fields[name]) {
+				differ = append(differ, name)
+			}
+		}
+		switch {
+		case len(differ) > 0:
+			problems = append(problems, fmt.Sprintf("%s has other values in the cache than in the log for %s", rec.ID, strings.Join(differ, ", ")))
+		case op.Hash != rec.Hash:
+			problems = append(problems, fmt.Sprintf("%s has hash %s in the cache, %s in the log", rec.ID, rec.Hash, op.Hash))
+		case op.ParentID != rec.ParentID:
+			problems = append(problems, fmt.Sprintf("%s has parent %q in the cache, %q in the log", rec.ID, rec.ParentID, op.ParentID))
+		case op.IsDeleted() != rec.IsDeleted():
+			problems = append(problems, fmt.Sprintf("%s differs in deletion between the cache and the log", rec.ID))
+		}
+	}
+	for id := range latest {
+		if !seen[id] {
+			problems = append(problems, fmt.Sprintf("%s is in the log but not cached", id))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		details := problems
+		if len(details) > 5 {
+			details = append(details[:5:5], "...")
+		}
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: fmt.Sprintf("Cache differs from the log for %d record(s)", len(problems)),
+			Details: strings.Join(details, "; ") + "; run 'stash sync --rebuild'",
+		}
+	}
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("Cache matches the log (%d records)", len(cached)),
+	}
+}
+
+// checkSchemaAgreement checks that the cache has a column for every
+// column in the stash's config.
+func checkSchemaAgreement(store *storage.Store, stash *model.Stash) CheckResult {
+	check := fmt.Sprintf("%s/schema", stash.Name)
+
+	missing, err := store.MissingCacheColumns(stash.Name)
+	if err != nil {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: "Cannot read cache columns",
+			Details: err.Error(),
+		}
+	}
+	if len(missing) > 0 {
+		return CheckResult{
+			Check:   check,
+			Status:  "error",
+			Message: fmt.Sprintf("%d column(s) in config.json missing from the cache", len(missing)),
+			Details: strings.Join(missing, ", ") + "; run 'stash sync --rebuild'",
+		}
+	}
+	return CheckResult{
+		Check:   check,
+		Status:  "ok",
+		Message: fmt.Sprintf("Cache has all %d column(s) of config.json", len(stash.Columns)),
+	}
+}
+
+func runVerifySignatures(store *storage.Store, stash *model.Stash) error {
+	report, err := store.VerifySignatures(stash.Name)
+	if err != nil {
+		return fmt.Errorf("failed to verify signatures: %w", err)
+	}
+	valid := len(report.Issues) == 0
+
+	// Output result
+	if GetJSONOutput() {
+		output := map[string]interface{}{
+			"stash":            stash.Name,
+			"valid":            valid,
+			"lines":            report.Lines,
+			"signed":           report.Signed,
+			"valid_signatures": report.Valid,
+			"unsigned":         report.Unsigned,
+			"unverified":       report.Unverified,
+			"issues":           report.Issues,
+		}
+		data, _ := json.Marshal(output)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if valid {
+			fmt.Printf("Signatures OK: %d of %d operation(s) signed and verified in stash '%s'\n", report.Valid, report.Lines, stash.Name)
+		} else {
+			fmt.Printf("Signature issues in stash '%s'\n", stash.Name)
+			for _, issue := range report.Issues {
+				fmt.Printf("  line %d (%s by %s): %s\n", issue.Line, issue.ID, issue.Actor, issue.Problem)
+			}
+		}
+		if report.Unsigned > 0 {
+			fmt.Printf("  %d unsigned operation(s)\n", report.Unsigned)
+		}
+		if report.Unverified > 0 {
+			fmt.Printf("  %d signed operation(s) by actors with no published key\n", report.Unverified)
+		}
+	}
+
+	if !valid {
+		Exit(1)
+	}
+	return nil
+}
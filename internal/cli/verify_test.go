@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	t.Run("intact chain verifies and edits are detected", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		rootCmd.SetArgs([]string{"verify", "--enable"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0 after enabling, got %d", ExitCode)
+		}
+		resetFlags()
+
+		for _, name := range []string{"Laptop", "Mouse", "Monitor"} {
+			rootCmd.SetArgs([]string{"add", name})
+			rootCmd.Execute()
+			resetFlags()
+		}
+
+		rootCmd.SetArgs([]string{"verify"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Fatalf("expected intact chain, got exit code %d", ExitCode)
+		}
+		resetFlags()
+
+		// Edit the first record in place
+		logPath := filepath.Join(tempDir, ".stash", "inventory", "records.jsonl")
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("failed to read log: %v", err)
+		}
+		tampered := bytes.Replace(data, []byte(`"Name":"Laptop"`), []byte(`"Name":"Tablet"`), 1)
+		if bytes.Equal(data, tampered) {
+			t.Fatal("expected to find the record to tamper with")
+		}
+		if err := os.WriteFile(logPath, tampered, 0644); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+
+		rootCmd.SetArgs([]string{"verify"})
+		rootCmd.Execute()
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for broken chain, got %d", ExitCode)
+		}
+	})
+
+	t.Run("init --hash-chain chains from the first operation", func(t *testing.T) {
+		_, cleanup := setupTestEnv(t)
+		defer cleanup()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"init", "audit", "--prefix", "aud-", "--hash-chain"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"column", "add", "Event"})
+		rootCmd.Execute()
+		resetFlags()
+		rootCmd.SetArgs([]string{"add", "login"})
+		rootCmd.Execute()
+		resetFlags()
+
+		rootCmd.SetArgs([]string{"verify"})
+		rootCmd.Execute()
+		if ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %d", ExitCode)
+		}
+	})
+
+	t.Run("stash without hash chain passes until its log is edited", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop")
+		captureSchemaOutput(t, "add", "Mouse")
+
+		var result struct {
+			Valid  bool          `json:"valid"`
+			Checks []CheckResult `json:"checks"`
+		}
+		output := captureSchemaOutput(t, "verify", "--deep", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if !result.Valid || ExitCode != 0 {
+			t.Fatalf("expected an intact stash to verify, got exit code %d: %s", ExitCode, output)
+		}
+		for _, check := range result.Checks {
+			if check.Check == "inventory/hash_chain" {
+				t.Errorf("expected no hash chain check for a stash without one")
+			}
+		}
+
+		// Edit a value without updating its hash
+		logPath := filepath.Join(tempDir, ".stash", "inventory", "records.jsonl")
+		data, _ := os.ReadFile(logPath)
+		os.WriteFile(logPath, bytes.Replace(data, []byte(`"Name":"Mouse"`), []byte(`"Name":"Mice"`), 1), 0644)
+
+		output = captureSchemaOutput(t, "verify", "--json")
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("failed to parse output %q: %v", output, err)
+		}
+		if result.Valid || ExitCode != 1 {
+			t.Errorf("expected the edit to fail verification, got exit code %d: %s", ExitCode, output)
+		}
+	})
+	t.Run("a value changed in the cache alone is detected", func(t *testing.T) {
+		tempDir, cleanup := setupTestStashWithColumns(t, "inventory", "inv-", []string{"Name", "Qty"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Laptop", "--set", "Qty=2")
+		captureSchemaOutput(t, "verify")
+		if ExitCode != 0 {
+			t.Fatalf("expected a numeric text value to match the cache, got exit code %d", ExitCode)
+		}
+
+		// The storage package registers its SQLite driver as sqlite3_stash
+		db, err := sql.Open("sqlite3_stash", filepath.Join(tempDir, ".stash", "cache.db"))
+		if err != nil {
+			t.Fatalf("failed to open cache: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE inventory SET Qty = '3'`); err != nil {
+			t.Fatalf("failed to edit cache: %v", err)
+		}
+		db.Close()
+
+		output := captureSchemaOutput(t, "verify")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1 for an edited cache, got %d: %s", ExitCode, output)
+		}
+		if !strings.Contains(output, "for Qty") {
+			t.Errorf("expected the edited column to be named, got: %s", output)
+		}
+	})
+}
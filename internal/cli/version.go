@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/storage"
 )
 
 // Version information (set at build time)
@@ -18,12 +19,13 @@ var versionCmd = &cobra.Command{
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
 		if jsonOutput {
-			fmt.Printf(`{"version":"%s","commit":"%s","date":"%s"}`+"\n", Version, GitCommit, BuildDate)
+			fmt.Printf(`{"version":"%s","commit":"%s","date":"%s","sqlite_driver":"%s"}`+"\n", Version, GitCommit, BuildDate, storage.SQLiteDriverName())
 		} else {
 			fmt.Printf("stash version %s\n", Version)
 			if verbose {
 				fmt.Printf("  commit: %s\n", GitCommit)
 				fmt.Printf("  built:  %s\n", BuildDate)
+				fmt.Printf("  sqlite: %s\n", storage.SQLiteDriverName())
 			}
 		}
 	},
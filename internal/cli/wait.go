@@ -0,0 +1,129 @@
+// Package cli provides the command-line interface for stash.
+package cli
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/stash/internal/storage"
+)
+
+var (
+	waitWhere   []string
+	waitSince   int
+	waitTimeout int
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for a change to the stash",
+	Long: `Wait until a record in the current stash changes, and print the change.
+Agents can wait for each other's writes this way instead of polling in a
+loop.
+
+Every operation in the stash's log has a sequence number. By default wait
+returns the first matching change made after it starts; with --since it
+returns every matching change after that sequence number, at once if there
+are any. Each result reports the latest sequence number, to pass as --since
+to the next wait so no change is missed.
+
+  --where CONDITION  The changed record must match, as it was after the
+                     change (same format as list; can be repeated)
+  --since SEQ        Return the changes after this sequence number
+  --timeout SECONDS  Give up after this long (default 60; 0 waits forever)
+
+A log rewritten by compaction has fewer operations than an old --since;
+wait then returns the matching changes from the start and reports "reset".
+
+The daemon serves the same changes over HTTP with --changes-addr:
+  GET /stashes/<name>/changes?since=SEQ&where=COND&timeout=SECONDS
+
+Examples:
+  stash wait --where "Status=done"              # Next task finished
+  stash wait --where "assigned_to=bot" --timeout 300
+  stash wait --since 42 --json                  # Changes after 42
+
+Exit Codes:
+  0  A matching change was found
+  1  Timed out, stash or column not found
+  2  Validation error (invalid --where, --since, or --timeout)
+
+JSON Output (--json):
+  {"stash": "tasks", "since": 42, "last": 44,
+   "changes": [{"seq": 44, "record": {"_id": "tk-ab12", "_op": "update", ...}}]}
+  On a timeout, changes is empty.`,
+	Args: cobra.NoArgs,
+	RunE: runWait,
+}
+
+func init() {
+	waitCmd.Flags().StringArrayVar(&waitWhere, "where", nil, "The changed record must match (can be repeated)")
+	waitCmd.Flags().IntVar(&waitSince, "since", -1, "Return the changes after this sequence number")
+	waitCmd.Flags().IntVar(&waitTimeout, "timeout", 60, "Seconds to wait (0 waits forever)")
+	rootCmd.AddCommand(waitCmd)
+}
+
+func runWait(cmd *cobra.Command, args []string) error {
+	if waitSince < -1 {
+		ExitValidationError("--since must be a sequence number", map[string]interface{}{"since": waitSince})
+		return nil
+	}
+	if waitTimeout < 0 {
+		ExitValidationError("--timeout must not be negative", map[string]interface{}{"timeout": waitTimeout})
+		return nil
+	}
+	var where []storage.WhereCondition
+	for _, clause := range waitWhere {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			ExitValidationError(err.Error(), map[string]interface{}{"where": clause})
+			return nil
+		}
+		where = append(where, cond)
+	}
+
+	_, store, stash, ok, err := openSchemaStash()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+	if !checkWhereColumns(stash, where) {
+		return nil
+	}
+
+	ctx := gocontext.Background()
+	if waitTimeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, time.Duration(waitTimeout)*time.Second)
+		defer cancel()
+	}
+	set, err := store.WaitForChanges(ctx, stash.Name, waitSince, where)
+	if err != nil {
+		return fmt.Errorf("failed to wait for changes: %w", err)
+	}
+
+	if GetJSONOutput() {
+		data, _ := json.Marshal(set)
+		fmt.Println(string(data))
+	} else if !IsQuiet() {
+		if set.Reset {
+			fmt.Println("The log was compacted; changes since the start:")
+		}
+		for _, change := range set.Changes {
+			fmt.Printf("%d: %s %s by %s\n", change.Seq, change.Record.Operation, change.Record.ID, change.Record.UpdatedBy)
+		}
+		if len(set.Changes) == 0 {
+			fmt.Fprintf(os.Stderr, "No matching change within %ds\n", waitTimeout)
+		} else {
+			fmt.Printf("Last: %d (pass as --since to wait for the next change)\n", set.Last)
+		}
+	}
+	if len(set.Changes) == 0 {
+		Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/stash/internal/storage"
+)
+
+func TestWait(t *testing.T) {
+	t.Run("returns the matching changes after since", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name", "Status"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Write docs", "--set", "Status=open")
+		captureSchemaOutput(t, "add", "Fix bug", "--set", "Status=done")
+		captureSchemaOutput(t, "add", "Review", "--set", "Status=open")
+
+		out := captureSchemaOutput(t, "wait", "--since", "0", "--where", "Status=done", "--json")
+		if ExitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", ExitCode, out)
+		}
+		var set storage.ChangeSet
+		if err := json.Unmarshal([]byte(out), &set); err != nil {
+			t.Fatalf("expected a change set, got %q", out)
+		}
+		if len(set.Changes) != 1 || set.Changes[0].Seq != 2 || set.Changes[0].Record.Fields["Name"] != "Fix bug" {
+			t.Errorf("expected the one done change, got %+v", set.Changes)
+		}
+		if set.Last != 3 {
+			t.Errorf("expected last 3, got %d", set.Last)
+		}
+
+		out = captureSchemaOutput(t, "wait", "--since", "1")
+		if !strings.Contains(out, "2: create") || !strings.Contains(out, "3: create") || !strings.Contains(out, "Last: 3") {
+			t.Errorf("expected changes 2 and 3, got %q", out)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "add", "Write docs")
+		out := captureSchemaOutput(t, "wait", "--timeout", "1", "--json")
+		if ExitCode != 1 {
+			t.Fatalf("expected exit code 1, got %d: %s", ExitCode, out)
+		}
+		var set storage.ChangeSet
+		if err := json.Unmarshal([]byte(out), &set); err != nil {
+			t.Fatalf("expected a change set, got %q", out)
+		}
+		if len(set.Changes) != 0 || set.Last != 1 {
+			t.Errorf("expected no changes after 1, got %+v", set)
+		}
+		ExitCode = 0
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, cleanup := setupTestStashWithColumns(t, "tasks", "tk-", []string{"Name"})
+		defer cleanup()
+
+		captureSchemaOutput(t, "wait", "--where", "Nope=x", "--timeout", "1")
+		if ExitCode != 1 {
+			t.Errorf("expected exit code 1, got %d", ExitCode)
+		}
+		ExitCode = 0
+	})
+}
@@ -2,29 +2,111 @@
 // It handles actor resolution, git branch detection, and stash location finding.
 package context
 
-import "os"
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+)
+
+// Actor sources reported by ResolveActorSource.
+const (
+	ActorSourceFlag     = "flag"
+	ActorSourceEnv      = "STASH_ACTOR"
+	ActorSourceStash    = "stash"
+	ActorSourceConfig   = "config"
+	ActorSourceGit      = "git"
+	ActorSourceUser     = "USER"
+	ActorSourceOS       = "os"
+	ActorSourceFallback = "fallback"
+)
+
+// gitConfigValue returns a git config value, or empty string if it is not
+// set or git is not available. A variable so tests can replace it.
+var gitConfigValue = func(key string) string {
+	out, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// osUsername returns the name of the user running the process, or empty
+// string if it cannot be looked up. A variable so tests can replace it.
+var osUsername = func() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	// Windows usernames are DOMAIN\name
+	name := u.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
 
 // ResolveActor returns the actor name following priority order:
 // 1. flagValue (--actor flag) if non-empty
 // 2. $STASH_ACTOR environment variable if set
-// 3. $USER environment variable if set
-// 4. "unknown" as fallback
+// 3. the actor configured with 'stash actor set'
+// 4. git config user.name, or user.email
+// 5. $USER environment variable if set
+// 6. the OS username
+// 7. "unknown" as fallback
 func ResolveActor(flagValue string) string {
+	actor, _ := ResolveActorSource(flagValue)
+	return actor
+}
+
+// ResolveActorSource resolves the actor like ResolveActor and also reports
+// where the name came from.
+func ResolveActorSource(flagValue string) (actor, source string) {
+	return ResolveStashActor(flagValue, "")
+}
+
+// ResolveStashActor resolves the actor for operations on a stash. It is
+// like ResolveActorSource, except that an actor configured for the stash
+// with 'stash actor set --stash' comes before the default configured actor.
+func ResolveStashActor(flagValue, stash string) (actor, source string) {
 	// Priority 1: Flag value
 	if flagValue != "" {
-		return flagValue
+		return flagValue, ActorSourceFlag
 	}
 
 	// Priority 2: STASH_ACTOR environment variable
 	if actor := os.Getenv("STASH_ACTOR"); actor != "" {
-		return actor
+		return actor, ActorSourceEnv
 	}
 
-	// Priority 3: USER environment variable
+	// Priority 3: Configured identity, for this stash or by default
+	if identity, err := LoadIdentity(); err == nil {
+		if actor := identity.Stashes[stash]; stash != "" && actor != "" {
+			return actor, ActorSourceStash
+		}
+		if identity.Actor != "" {
+			return identity.Actor, ActorSourceConfig
+		}
+	}
+
+	// Priority 4: git identity
+	if name := gitConfigValue("user.name"); name != "" {
+		return name, ActorSourceGit
+	}
+	if email := gitConfigValue("user.email"); email != "" {
+		return email, ActorSourceGit
+	}
+
+	// Priority 5: USER environment variable
 	if user := os.Getenv("USER"); user != "" {
-		return user
+		return user, ActorSourceUser
+	}
+
+	// Priority 6: OS username
+	if name := osUsername(); name != "" {
+		return name, ActorSourceOS
 	}
 
-	// Priority 4: Fallback
-	return "unknown"
+	// Priority 7: Fallback
+	return "unknown", ActorSourceFallback
 }
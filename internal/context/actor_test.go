@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResolveActor(t *testing.T) {
@@ -16,6 +17,10 @@ func TestResolveActor(t *testing.T) {
 		os.Setenv("USER", origUser)
 	}()
 
+	// Keep the user's own identity config and git identity out of the way
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+	stubActorSources(t, nil, "")
+
 	t.Run("priority 1: flag value takes precedence", func(t *testing.T) {
 		os.Setenv("STASH_ACTOR", "env-actor")
 		os.Setenv("USER", "env-user")
@@ -72,3 +77,145 @@ func TestResolveActor(t *testing.T) {
 		assert.Equal(t, "unknown", result)
 	})
 }
+
+// stubActorSources replaces the git config and OS user lookups for the
+// duration of a test.
+func stubActorSources(t *testing.T, git map[string]string, osName string) {
+	t.Helper()
+	origGit, origOS := gitConfigValue, osUsername
+	gitConfigValue = func(key string) string { return git[key] }
+	osUsername = func() string { return osName }
+	t.Cleanup(func() {
+		gitConfigValue, osUsername = origGit, origOS
+	})
+}
+
+func TestResolveActor_ConfiguredIdentity(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+	stubActorSources(t, nil, "")
+	t.Setenv("STASH_ACTOR", "")
+	t.Setenv("USER", "env-user")
+
+	require.NoError(t, SaveIdentity(&Identity{Actor: "alice"}))
+
+	t.Run("config beats USER", func(t *testing.T) {
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "alice", actor)
+		assert.Equal(t, ActorSourceConfig, source)
+	})
+
+	t.Run("STASH_ACTOR beats config", func(t *testing.T) {
+		t.Setenv("STASH_ACTOR", "env-actor")
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "env-actor", actor)
+		assert.Equal(t, ActorSourceEnv, source)
+	})
+
+	t.Run("flag beats config", func(t *testing.T) {
+		actor, source := ResolveActorSource("flag-actor")
+		assert.Equal(t, "flag-actor", actor)
+		assert.Equal(t, ActorSourceFlag, source)
+	})
+}
+
+func TestResolveActor_GitAndOS(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+	t.Setenv("STASH_ACTOR", "")
+	t.Setenv("USER", "env-user")
+
+	t.Run("git user.name beats USER", func(t *testing.T) {
+		stubActorSources(t, map[string]string{"user.name": "Alice Smith", "user.email": "alice@example.com"}, "os-user")
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "Alice Smith", actor)
+		assert.Equal(t, ActorSourceGit, source)
+	})
+
+	t.Run("git user.email when no user.name", func(t *testing.T) {
+		stubActorSources(t, map[string]string{"user.email": "alice@example.com"}, "os-user")
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "alice@example.com", actor)
+		assert.Equal(t, ActorSourceGit, source)
+	})
+
+	t.Run("config beats git", func(t *testing.T) {
+		t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+		stubActorSources(t, map[string]string{"user.name": "Alice Smith"}, "os-user")
+		require.NoError(t, SaveIdentity(&Identity{Actor: "alice"}))
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "alice", actor)
+		assert.Equal(t, ActorSourceConfig, source)
+	})
+
+	t.Run("OS username when no git identity or USER", func(t *testing.T) {
+		stubActorSources(t, nil, "os-user")
+		t.Setenv("USER", "")
+		actor, source := ResolveActorSource("")
+		assert.Equal(t, "os-user", actor)
+		assert.Equal(t, ActorSourceOS, source)
+	})
+}
+
+func TestResolveStashActor(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+	t.Setenv("STASH_ACTOR", "")
+	stubActorSources(t, map[string]string{"user.name": "Alice Smith"}, "")
+
+	require.NoError(t, SaveIdentity(&Identity{
+		Actor:   "alice",
+		Stashes: map[string]string{"inbox": "triage-bot"},
+	}))
+
+	t.Run("stash override beats configured actor", func(t *testing.T) {
+		actor, source := ResolveStashActor("", "inbox")
+		assert.Equal(t, "triage-bot", actor)
+		assert.Equal(t, ActorSourceStash, source)
+	})
+
+	t.Run("other stashes use configured actor", func(t *testing.T) {
+		actor, source := ResolveStashActor("", "tasks")
+		assert.Equal(t, "alice", actor)
+		assert.Equal(t, ActorSourceConfig, source)
+	})
+
+	t.Run("STASH_ACTOR beats stash override", func(t *testing.T) {
+		t.Setenv("STASH_ACTOR", "env-actor")
+		actor, source := ResolveStashActor("", "inbox")
+		assert.Equal(t, "env-actor", actor)
+		assert.Equal(t, ActorSourceEnv, source)
+	})
+
+	t.Run("Resolve records the source", func(t *testing.T) {
+		ctx, err := Resolve("", "inbox")
+		require.NoError(t, err)
+		assert.Equal(t, "triage-bot", ctx.Actor)
+		assert.Equal(t, ActorSourceStash, ctx.ActorSource)
+	})
+}
+
+func TestSigningKey(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+
+	key, err := LoadSigningKey("alice")
+	require.NoError(t, err)
+	assert.Nil(t, key, "no key before one is generated")
+
+	generated, err := GenerateSigningKey("alice", false)
+	require.NoError(t, err)
+
+	loaded, err := LoadSigningKey("alice")
+	require.NoError(t, err)
+	assert.Equal(t, generated, loaded)
+
+	_, err = GenerateSigningKey("alice", false)
+	assert.ErrorIs(t, err, ErrKeyExists)
+
+	replaced, err := GenerateSigningKey("alice", true)
+	require.NoError(t, err)
+	assert.NotEqual(t, generated, replaced)
+
+	path, err := SigningKeyPath("alice")
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
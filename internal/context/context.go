@@ -4,10 +4,12 @@ import "errors"
 
 // Context holds the resolved runtime context for stash CLI commands.
 type Context struct {
-	Actor    string // Resolved actor name
-	Branch   string // Current git branch (may be empty)
-	StashDir string // Path to .stash directory (may be empty)
-	Stash    string // Default or selected stash name (may be empty)
+	Actor       string // Resolved actor name
+	ActorSource string // Where the actor name came from (see ActorSourceFlag etc.)
+	Branch      string // Checked out or current git branch (may be empty)
+	CheckedOut  bool   // Branch was set with 'stash checkout'
+	StashDir    string // Path to .stash directory (may be empty)
+	Stash       string // Default or selected stash name (may be empty)
 }
 
 // ErrNoStashDir is returned when no .stash directory is found
@@ -17,17 +19,20 @@ var ErrNoStashDir = errors.New("no .stash directory found")
 var ErrNoStash = errors.New("no stash specified and multiple stashes exist (use --stash)")
 
 // Resolve builds full context from flags and environment.
-// It resolves actor, detects git branch, finds stash directory,
-// and determines the default stash.
+// It finds stash directory, takes the branch checked out in it (see State)
+// or else detects the git branch, determines the default stash, and
+// resolves the actor for that stash.
 //
 // Parameters:
 //   - actorFlag: value of --actor flag (empty if not provided)
 //   - stashFlag: value of --stash flag (empty if not provided)
 //
-// Returns an error if the stash directory is required but not found.
+// A stash given as root:stash, in the flag or $STASH_DEFAULT, is looked up
+// in the user's registry (see Registry). An unknown root is an error
+// wrapping ErrUnknownRoot; the context is still returned, without a stash
+// directory.
 func Resolve(actorFlag, stashFlag string) (*Context, error) {
 	ctx := &Context{
-		Actor:    ResolveActor(actorFlag),
 		Branch:   DetectBranch(),
 		StashDir: FindStashDir(),
 	}
@@ -39,7 +44,24 @@ func Resolve(actorFlag, stashFlag string) (*Context, error) {
 		ctx.Stash = DefaultStash(ctx.StashDir)
 	}
 
-	return ctx, nil
+	// A stash addressed as root:stash lives in a registered directory
+	stashDir, stash, ok, err := resolveRootStash(ctx.Stash)
+	if ok {
+		ctx.StashDir, ctx.Stash = stashDir, stash
+		if err == nil && stash == "" {
+			if stashes := listStashes(stashDir); len(stashes) == 1 {
+				ctx.Stash = stashes[0]
+			}
+		}
+	}
+
+	if branch := CheckedOutBranch(ctx.StashDir); branch != "" {
+		ctx.Branch, ctx.CheckedOut = branch, true
+	}
+
+	ctx.Actor, ctx.ActorSource = ResolveStashActor(actorFlag, ctx.Stash)
+
+	return ctx, err
 }
 
 // ResolveRequired is like Resolve but returns an error if:
@@ -0,0 +1,144 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HookTrust lists the validation hook commands the user has allowed to
+// run, per .stash directory. Like the hooks of a git repository, the
+// decision stays on this machine: it is kept in the config directory, not
+// in .stash, so hooks that arrive with a clone or a pull do not run until
+// someone here has reviewed and trusted them.
+type HookTrust struct {
+	Dirs []TrustedHooks `json:"dirs"`
+}
+
+// TrustedHooks are the hook commands trusted in one .stash directory.
+type TrustedHooks struct {
+	Path     string    `json:"path"` // Absolute path to the .stash directory
+	Commands []string  `json:"commands"`
+	Updated  time.Time `json:"updated"`
+}
+
+func hookTrustPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted-hooks.json"), nil
+}
+
+// LoadHookTrust reads the user's trusted hooks. A missing file yields no
+// trusted hooks.
+func LoadHookTrust() (*HookTrust, error) {
+	path, err := hookTrustPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &HookTrust{}, nil
+		}
+		return nil, err
+	}
+	var trust HookTrust
+	if err := json.Unmarshal(data, &trust); err != nil {
+		return nil, fmt.Errorf("invalid trusted hooks %s: %w", path, err)
+	}
+	return &trust, nil
+}
+
+// SaveHookTrust writes the trusted hooks to the config directory.
+func SaveHookTrust(trust *HookTrust) error {
+	path, err := hookTrustPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if trust.Dirs == nil {
+		trust.Dirs = []TrustedHooks{}
+	}
+	data, err := json.MarshalIndent(trust, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Trusted reports whether a hook command may run for a .stash directory.
+func (t *HookTrust) Trusted(stashDir, command string) bool {
+	if entry := t.lookup(stashDir); entry != nil {
+		for _, c := range entry.Commands {
+			if c == command {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Trust allows hook commands to run for a .stash directory.
+func (t *HookTrust) Trust(stashDir string, commands ...string) {
+	entry := t.lookup(stashDir)
+	if entry == nil {
+		t.Dirs = append(t.Dirs, TrustedHooks{Path: trustKey(stashDir)})
+		entry = &t.Dirs[len(t.Dirs)-1]
+	}
+	for _, command := range commands {
+		if !t.Trusted(stashDir, command) {
+			entry.Commands = append(entry.Commands, command)
+		}
+	}
+	entry.Updated = time.Now().UTC()
+}
+
+// Revoke stops hook commands from running for a .stash directory, and
+// returns how many were trusted.
+func (t *HookTrust) Revoke(stashDir string, commands ...string) int {
+	entry := t.lookup(stashDir)
+	if entry == nil {
+		return 0
+	}
+	revoked := 0
+	kept := entry.Commands[:0]
+	for _, c := range entry.Commands {
+		drop := false
+		for _, command := range commands {
+			drop = drop || c == command
+		}
+		if drop {
+			revoked++
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	entry.Commands = kept
+	entry.Updated = time.Now().UTC()
+	return revoked
+}
+
+func (t *HookTrust) lookup(stashDir string) *TrustedHooks {
+	key := trustKey(stashDir)
+	for i := range t.Dirs {
+		if t.Dirs[i].Path == key {
+			return &t.Dirs[i]
+		}
+	}
+	return nil
+}
+
+// trustKey returns the path trust is recorded under for a .stash
+// directory.
+func trustKey(stashDir string) string {
+	if abs, err := filepath.Abs(stashDir); err == nil {
+		return abs
+	}
+	return stashDir
+}
@@ -0,0 +1,38 @@
+package context
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookTrust(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+
+	trust, err := LoadHookTrust()
+	require.NoError(t, err)
+	workDir := filepath.Join(t.TempDir(), ".stash")
+	assert.False(t, trust.Trusted(workDir, "./check.sh"))
+
+	trust.Trust(workDir, "./check.sh", "./check.sh")
+	require.NoError(t, SaveHookTrust(trust))
+
+	trust, err = LoadHookTrust()
+	require.NoError(t, err)
+	require.Len(t, trust.Dirs, 1)
+	assert.Equal(t, []string{"./check.sh"}, trust.Dirs[0].Commands)
+	assert.True(t, trust.Trusted(workDir, "./check.sh"))
+
+	t.Run("trust is per directory and command", func(t *testing.T) {
+		assert.False(t, trust.Trusted(workDir, "./other.sh"))
+		assert.False(t, trust.Trusted(filepath.Join(t.TempDir(), ".stash"), "./check.sh"))
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		assert.Equal(t, 1, trust.Revoke(workDir, "./check.sh"))
+		assert.Equal(t, 0, trust.Revoke(workDir, "./check.sh"))
+		assert.False(t, trust.Trusted(workDir, "./check.sh"))
+	})
+}
@@ -0,0 +1,137 @@
+package context
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Identity is the user's stash identity, stored in the config directory.
+type Identity struct {
+	Actor string `json:"actor"`
+	// Stashes maps stash names to the actor used for operations on them,
+	// overriding Actor.
+	Stashes map[string]string `json:"stashes,omitempty"`
+}
+
+// ConfigDir returns the directory holding the user's stash configuration:
+// $STASH_CONFIG_DIR if set, otherwise "stash" under the user config directory.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("STASH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stash"), nil
+}
+
+func identityPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "identity.json"), nil
+}
+
+// LoadIdentity reads the configured identity. A missing config file yields
+// an empty identity.
+func LoadIdentity() (*Identity, error) {
+	path, err := identityPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Identity{}, nil
+		}
+		return nil, err
+	}
+	var identity Identity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("invalid identity config %s: %w", path, err)
+	}
+	return &identity, nil
+}
+
+// SaveIdentity writes the identity to the config directory.
+func SaveIdentity(identity *Identity) error {
+	path, err := identityPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// SigningKeyPath returns the path of an actor's private signing key.
+func SigningKeyPath(actor string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "keys", actor+".key"), nil
+}
+
+// LoadSigningKey reads an actor's private signing key. It returns a nil key
+// and no error when the actor has no key.
+func LoadSigningKey(actor string) (ed25519.PrivateKey, error) {
+	path, err := SigningKeyPath(actor)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("malformed signing key %s", path)
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// ErrKeyExists is returned when generating a key for an actor that has one.
+var ErrKeyExists = errors.New("signing key already exists")
+
+// GenerateSigningKey creates and stores a new ed25519 signing key for the
+// actor. An existing key is only replaced when overwrite is set.
+func GenerateSigningKey(actor string, overwrite bool) (ed25519.PrivateKey, error) {
+	path, err := SigningKeyPath(actor)
+	if err != nil {
+		return nil, err
+	}
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return nil, ErrKeyExists
+		}
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,88 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Preferences are the user's saved defaults for commands, stored in the
+// config directory. They are per user, so they are not shared through git.
+type Preferences struct {
+	// Stashes maps stash names to their settings, keyed as "list.columns".
+	Stashes map[string]map[string]string `json:"stashes,omitempty"`
+}
+
+func preferencesPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "preferences.json"), nil
+}
+
+// LoadPreferences reads the user's preferences. A missing preferences file
+// yields empty preferences.
+func LoadPreferences() (*Preferences, error) {
+	path, err := preferencesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Preferences{}, nil
+		}
+		return nil, err
+	}
+	var prefs Preferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, fmt.Errorf("invalid preferences %s: %w", path, err)
+	}
+	return &prefs, nil
+}
+
+// SavePreferences writes the preferences to the config directory.
+func SavePreferences(prefs *Preferences) error {
+	path, err := preferencesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Get returns a stash's setting, or "" if it is not set.
+func (p *Preferences) Get(stash, key string) string {
+	return p.Stashes[stash][key]
+}
+
+// Set saves a stash's setting.
+func (p *Preferences) Set(stash, key, value string) {
+	if p.Stashes == nil {
+		p.Stashes = make(map[string]map[string]string)
+	}
+	if p.Stashes[stash] == nil {
+		p.Stashes[stash] = make(map[string]string)
+	}
+	p.Stashes[stash][key] = value
+}
+
+// Unset removes a stash's setting, reporting whether it was set.
+func (p *Preferences) Unset(stash, key string) bool {
+	if _, ok := p.Stashes[stash][key]; !ok {
+		return false
+	}
+	delete(p.Stashes[stash], key)
+	if len(p.Stashes[stash]) == 0 {
+		delete(p.Stashes, stash)
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferences(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+
+	prefs, err := LoadPreferences()
+	require.NoError(t, err)
+	assert.Empty(t, prefs.Get("inventory", "list.columns"))
+
+	prefs.Set("inventory", "list.columns", "Name,Price")
+	prefs.Set("inventory", "list.limit", "20")
+	require.NoError(t, SavePreferences(prefs))
+
+	loaded, err := LoadPreferences()
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Price", loaded.Get("inventory", "list.columns"))
+	assert.Empty(t, loaded.Get("contacts", "list.columns"))
+
+	assert.True(t, loaded.Unset("inventory", "list.columns"))
+	assert.False(t, loaded.Unset("inventory", "list.columns"))
+	assert.True(t, loaded.Unset("inventory", "list.limit"))
+	assert.NotContains(t, loaded.Stashes, "inventory", "a stash without settings is dropped")
+}
@@ -0,0 +1,133 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnknownRoot is returned when a stash is addressed as root:stash and
+// no registered stash directory has that name.
+var ErrUnknownRoot = errors.New("unknown registry root")
+
+// RegistryEntry is a stash directory in the user's registry.
+type RegistryEntry struct {
+	Name  string    `json:"name"`
+	Path  string    `json:"path"` // Absolute path to the .stash directory
+	Added time.Time `json:"added"`
+}
+
+// Registry is the user's index of stash directories across the
+// filesystem, stored in the config directory. It lets a stash anywhere be
+// addressed as root:stash.
+type Registry struct {
+	Roots []RegistryEntry `json:"roots"`
+}
+
+func registryPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "registry.json"), nil
+}
+
+// LoadRegistry reads the user's registry. A missing registry file yields
+// an empty registry.
+func LoadRegistry() (*Registry, error) {
+	path, err := registryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Registry{}, nil
+		}
+		return nil, err
+	}
+	var registry Registry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %w", path, err)
+	}
+	return &registry, nil
+}
+
+// SaveRegistry writes the registry to the config directory.
+func SaveRegistry(registry *Registry) error {
+	path, err := registryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if registry.Roots == nil {
+		registry.Roots = []RegistryEntry{}
+	}
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Lookup returns the entry with the given name, or nil.
+func (r *Registry) Lookup(name string) *RegistryEntry {
+	for i := range r.Roots {
+		if r.Roots[i].Name == name {
+			return &r.Roots[i]
+		}
+	}
+	return nil
+}
+
+// LookupPath returns the entry for the given .stash directory, or nil.
+func (r *Registry) LookupPath(path string) *RegistryEntry {
+	for i := range r.Roots {
+		if r.Roots[i].Path == path {
+			return &r.Roots[i]
+		}
+	}
+	return nil
+}
+
+// Remove removes the entry with the given name. Returns false if there is
+// none.
+func (r *Registry) Remove(name string) bool {
+	for i := range r.Roots {
+		if r.Roots[i].Name == name {
+			r.Roots = append(r.Roots[:i], r.Roots[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ListStashes returns the names of the stashes in a .stash directory.
+func ListStashes(stashDir string) []string {
+	return listStashes(stashDir)
+}
+
+// resolveRootStash resolves a stash addressed as root:stash to the
+// registered .stash directory and the stash name. ok is false if name has
+// no root.
+func resolveRootStash(name string) (stashDir, stash string, ok bool, err error) {
+	root, stash, ok := strings.Cut(name, ":")
+	if !ok {
+		return "", "", false, nil
+	}
+	registry, err := LoadRegistry()
+	if err != nil {
+		return "", "", true, err
+	}
+	entry := registry.Lookup(root)
+	if entry == nil {
+		return "", "", true, fmt.Errorf("%w '%s' (see 'stash registry list')", ErrUnknownRoot, root)
+	}
+	return entry.Path, stash, true, nil
+}
@@ -0,0 +1,55 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Setenv("STASH_CONFIG_DIR", t.TempDir())
+	t.Setenv("STASH_DEFAULT", "")
+
+	registry, err := LoadRegistry()
+	require.NoError(t, err)
+	assert.Empty(t, registry.Roots)
+
+	workDir := filepath.Join(t.TempDir(), ".stash")
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "inventory"), 0755))
+	registry.Roots = append(registry.Roots, RegistryEntry{Name: "work", Path: workDir, Added: time.Now()})
+	require.NoError(t, SaveRegistry(registry))
+
+	registry, err = LoadRegistry()
+	require.NoError(t, err)
+	require.NotNil(t, registry.Lookup("work"))
+	assert.Equal(t, "work", registry.LookupPath(workDir).Name)
+	assert.Nil(t, registry.Lookup("home"))
+
+	t.Run("resolves root:stash to the registered directory", func(t *testing.T) {
+		ctx, err := Resolve("", "work:inventory")
+		require.NoError(t, err)
+		assert.Equal(t, workDir, ctx.StashDir)
+		assert.Equal(t, "inventory", ctx.Stash)
+
+		ctx, err = Resolve("", "work:")
+		require.NoError(t, err)
+		assert.Equal(t, "inventory", ctx.Stash)
+	})
+
+	t.Run("unknown root", func(t *testing.T) {
+		ctx, err := Resolve("", "home:inventory")
+		assert.ErrorIs(t, err, ErrUnknownRoot)
+		require.NotNil(t, ctx)
+		assert.Empty(t, ctx.StashDir)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		assert.True(t, registry.Remove("work"))
+		assert.False(t, registry.Remove("work"))
+		assert.Empty(t, registry.Roots)
+	})
+}
@@ -7,10 +7,57 @@ import (
 
 const stashDirName = ".stash"
 
-// FindStashDir returns the path to .stash directory
-// Searches current directory and parents up to root or git repo boundary
-// Returns empty string if not found
+// stashDirOverride, when dir is set, replaces the search for .stash: dir is
+// used as the stash directory, and list names its stashes.
+var stashDirOverride struct {
+	dir  string
+	list func() []string
+}
+
+// SetStashDirOverride makes FindStashDir return dir instead of searching
+// for .stash, with list naming the stashes in it. It is used for stash
+// directories that are not on disk, such as an in-memory store. An empty
+// dir restores the search.
+func SetStashDirOverride(dir string, list func() []string) {
+	stashDirOverride.dir = dir
+	stashDirOverride.list = list
+}
+
+// stashDirFlag is the directory given with --dir, if any.
+var stashDirFlag string
+
+// SetDir makes FindStashDir use the .stash directory in dir instead of
+// searching from the working directory. dir may also name the .stash
+// directory itself. An empty dir restores the search.
+func SetDir(dir string) {
+	stashDirFlag = dir
+}
+
+// StashDirIn returns the .stash directory for dir: dir itself if it is a
+// .stash directory, otherwise dir/.stash.
+func StashDirIn(dir string) string {
+	dir = filepath.Clean(dir)
+	if filepath.Base(dir) == stashDirName {
+		return dir
+	}
+	return filepath.Join(dir, stashDirName)
+}
+
+// FindStashDir returns the path to the .stash directory. With --dir (see
+// SetDir) it is the .stash directory there; otherwise it is the nearest
+// .stash in the current directory or its parents, like git finds .git.
+// Returns empty string if not found.
 func FindStashDir() string {
+	if stashDirOverride.dir != "" {
+		return stashDirOverride.dir
+	}
+	if stashDirFlag != "" {
+		stashPath := StashDirIn(stashDirFlag)
+		if info, err := os.Stat(stashPath); err == nil && info.IsDir() {
+			return stashPath
+		}
+		return ""
+	}
 	dir, err := os.Getwd()
 	if err != nil {
 		return ""
@@ -18,8 +65,22 @@ func FindStashDir() string {
 	return findStashDirFrom(dir)
 }
 
+// TargetStashDir returns the .stash directory to create stashes in: the
+// one FindStashDir finds, or else a new one in the --dir directory or the
+// current directory.
+func TargetStashDir() string {
+	if dir := FindStashDir(); dir != "" {
+		return dir
+	}
+	if stashDirFlag != "" {
+		return StashDirIn(stashDirFlag)
+	}
+	return stashDirName
+}
+
 // findStashDirFrom searches for .stash starting from the given directory
-// and walking up to the root or git repo boundary.
+// and walking up to the root. The nearest .stash wins, so a project nested
+// inside another can keep its own.
 func findStashDirFrom(startDir string) string {
 	dir := startDir
 	for {
@@ -28,13 +89,6 @@ func findStashDirFrom(startDir string) string {
 			return stashPath
 		}
 
-		// Check for git boundary - stop searching if we hit a .git directory
-		gitPath := filepath.Join(dir, ".git")
-		if _, err := os.Stat(gitPath); err == nil {
-			// We found a .git but no .stash in or above it
-			// Continue searching in case .stash is above .git
-		}
-
 		// Move to parent directory
 		parent := filepath.Dir(dir)
 		if parent == dir {
@@ -72,6 +126,9 @@ func DefaultStash(stashDir string) string {
 // listStashes returns a list of stash names in the given stash directory.
 // Each stash is a subdirectory within .stash/
 func listStashes(stashDir string) []string {
+	if stashDirOverride.dir != "" && stashDir == stashDirOverride.dir {
+		return stashDirOverride.list()
+	}
 	entries, err := os.ReadDir(stashDir)
 	if err != nil {
 		return nil
@@ -79,14 +136,15 @@ func listStashes(stashDir string) []string {
 
 	var stashes []string
 	for _, entry := range entries {
-		if entry.IsDir() && !isHiddenFile(entry.Name()) {
+		if entry.IsDir() && !isHiddenOrMeta(entry.Name()) {
 			stashes = append(stashes, entry.Name())
 		}
 	}
 	return stashes
 }
 
-// isHiddenFile returns true if the filename starts with a dot
-func isHiddenFile(name string) bool {
-	return len(name) > 0 && name[0] == '.'
+// isHiddenOrMeta returns true for hidden directories and meta directories
+// (such as _keys), which are never stashes.
+func isHiddenOrMeta(name string) bool {
+	return len(name) > 0 && (name[0] == '.' || name[0] == '_')
 }
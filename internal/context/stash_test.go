@@ -211,13 +211,14 @@ func TestListStashes(t *testing.T) {
 	})
 }
 
-func TestIsHiddenFile(t *testing.T) {
+func TestIsHiddenOrMeta(t *testing.T) {
 	tests := []struct {
 		name     string
 		expected bool
 	}{
 		{".hidden", true},
 		{".git", true},
+		{"_keys", true},
 		{"visible", false},
 		{"file.txt", false},
 		{"", false},
@@ -225,7 +226,7 @@ func TestIsHiddenFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isHiddenFile(tt.name)
+			result := isHiddenOrMeta(tt.name)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -328,3 +329,67 @@ func TestListStashes_EdgeCases(t *testing.T) {
 		assert.Empty(t, result)
 	})
 }
+
+func TestSetStashDirOverride(t *testing.T) {
+	t.Setenv("STASH_DEFAULT", "")
+	SetStashDirOverride(":memory:", func() []string { return []string{"scratch"} })
+	defer SetStashDirOverride("", nil)
+
+	assert.Equal(t, ":memory:", FindStashDir())
+	assert.Equal(t, "scratch", DefaultStash(FindStashDir()))
+
+	SetStashDirOverride("", nil)
+	assert.NotEqual(t, ":memory:", FindStashDir())
+}
+
+func TestSetDir(t *testing.T) {
+	defer SetDir("")
+
+	t.Run("nearest .stash wins for nested projects", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".stash"), 0755))
+		inner := filepath.Join(tmpDir, "vendor", "lib")
+		require.NoError(t, os.MkdirAll(filepath.Join(inner, ".stash"), 0755))
+		deep := filepath.Join(inner, "src")
+		require.NoError(t, os.Mkdir(deep, 0755))
+
+		origDir, _ := os.Getwd()
+		defer os.Chdir(origDir)
+		os.Chdir(deep)
+
+		assert.Equal(t, filepath.Join(inner, ".stash"), FindStashDir())
+	})
+
+	t.Run("uses the .stash in dir without searching parents", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".stash"), 0755))
+		sub := filepath.Join(tmpDir, "sub")
+		require.NoError(t, os.Mkdir(sub, 0755))
+
+		origDir, _ := os.Getwd()
+		defer os.Chdir(origDir)
+		os.Chdir(tmpDir)
+
+		SetDir(sub)
+		assert.Empty(t, FindStashDir())
+		assert.Equal(t, filepath.Join(sub, ".stash"), TargetStashDir())
+
+		require.NoError(t, os.Mkdir(filepath.Join(sub, ".stash"), 0755))
+		assert.Equal(t, filepath.Join(sub, ".stash"), FindStashDir())
+
+		SetDir(filepath.Join(sub, ".stash"))
+		assert.Equal(t, filepath.Join(sub, ".stash"), FindStashDir())
+
+		SetDir("")
+		assert.Equal(t, filepath.Join(tmpDir, ".stash"), FindStashDir())
+	})
+
+	t.Run("target defaults to the current directory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origDir, _ := os.Getwd()
+		defer os.Chdir(origDir)
+		os.Chdir(tmpDir)
+
+		assert.Equal(t, ".stash", TargetStashDir())
+	})
+}
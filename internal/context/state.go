@@ -0,0 +1,109 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the working state of one .stash directory, kept in its
+// state.json. Unlike the stash configs it describes this checkout, not the
+// data, so it should not be committed.
+type State struct {
+	// Branch is the branch checked out with 'stash checkout'. It replaces
+	// the detected git branch for the records commands write.
+	Branch string `json:"branch,omitempty"`
+
+	// Transactions are the open transactions begun with 'stash tx begin'.
+	// Their staged operations are kept in the store's pending area.
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+// Transaction is an open transaction of a .stash directory.
+type Transaction struct {
+	Token   string    `json:"token"`
+	BeganAt time.Time `json:"began_at"`
+	BeganBy string    `json:"began_by"`
+}
+
+// Transaction returns the open transaction with the token, or nil.
+func (s *State) Transaction(token string) *Transaction {
+	for i := range s.Transactions {
+		if s.Transactions[i].Token == token {
+			return &s.Transactions[i]
+		}
+	}
+	return nil
+}
+
+// RemoveTransaction removes the open transaction with the token. Returns
+// false if there is none.
+func (s *State) RemoveTransaction(token string) bool {
+	for i, tx := range s.Transactions {
+		if tx.Token == token {
+			s.Transactions = append(s.Transactions[:i], s.Transactions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// empty returns true if the state holds nothing worth saving.
+func (s *State) empty() bool {
+	return s.Branch == "" && len(s.Transactions) == 0
+}
+
+// StatePath returns the path of a .stash directory's state file.
+func StatePath(stashDir string) string {
+	return filepath.Join(stashDir, "state.json")
+}
+
+// LoadState reads the state of a .stash directory. A missing state file
+// yields an empty state.
+func LoadState(stashDir string) (*State, error) {
+	path := StatePath(stashDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// SaveState writes the state of a .stash directory. An empty state
+// removes the file.
+func SaveState(stashDir string, state *State) error {
+	path := StatePath(stashDir)
+	if state.empty() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// CheckedOutBranch returns the branch checked out in a .stash directory,
+// or "" if none is (or the state cannot be read).
+func CheckedOutBranch(stashDir string) string {
+	if stashDir == "" {
+		return ""
+	}
+	state, err := LoadState(stashDir)
+	if err != nil {
+		return ""
+	}
+	return state.Branch
+}
@@ -0,0 +1,37 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	stashDir := filepath.Join(t.TempDir(), ".stash")
+	require.NoError(t, os.MkdirAll(stashDir, 0755))
+
+	state, err := LoadState(stashDir)
+	require.NoError(t, err)
+	assert.Empty(t, state.Branch)
+	assert.Empty(t, CheckedOutBranch(stashDir))
+
+	require.NoError(t, SaveState(stashDir, &State{Branch: "agent/work"}))
+	assert.Equal(t, "agent/work", CheckedOutBranch(stashDir))
+
+	t.Run("a checked out branch replaces the git branch", func(t *testing.T) {
+		SetDir(stashDir)
+		defer SetDir("")
+
+		ctx, err := Resolve("", "")
+		require.NoError(t, err)
+		assert.Equal(t, "agent/work", ctx.Branch)
+		assert.True(t, ctx.CheckedOut)
+	})
+
+	require.NoError(t, SaveState(stashDir, &State{}))
+	_, err = os.Stat(StatePath(stashDir))
+	assert.True(t, os.IsNotExist(err), "an empty state removes the file")
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+// ChangesPattern is the route of the changes feed: a GET of a stash's
+// changes after a sequence number, waiting for one if there are none yet.
+const ChangesPattern = "GET /stashes/{name}/changes"
+
+const (
+	// DefaultChangesWait is how long a changes request waits when it does
+	// not give a timeout.
+	DefaultChangesWait = 30 * time.Second
+	// MaxChangesWait bounds the timeout of a changes request.
+	MaxChangesWait = 5 * time.Minute
+)
+
+// Changes serves the changes feed of the stashes in a .stash directory, so
+// agents can wait for each other's writes without polling in a loop.
+//
+// Query parameters:
+//
+//	since    Sequence number of the last change seen (default: the latest)
+//	where    Condition the changed record must match, as in --where;
+//	         may be repeated
+//	timeout  Seconds to wait for a matching change (default 30, max 300);
+//	         0 answers at once
+//
+// The response is a storage.ChangeSet; its changes are empty when the
+// timeout passed first, and its last is the since of the next request.
+//
+// The feed returns whole records without authentication, so it has its
+// own address and is only served on loopback unless asked for.
+type Changes struct {
+	store *storage.Store
+}
+
+// NewChanges creates the changes feed of the stashes in store. Every
+// request reads the one store; the caller closes it when done serving.
+func NewChanges(store *storage.Store) *Changes {
+	return &Changes{store: store}
+}
+
+// CheckChangesAddr returns an error if addr (host:port) is not a loopback
+// address, unless allowRemote is set. An empty host listens on every
+// interface, so it is not loopback.
+func CheckChangesAddr(addr string, allowRemote bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid changes address '%s': %w", addr, err)
+	}
+	if allowRemote || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("changes address '%s' is not a loopback address; the feed serves every record without authentication (allow it with --changes-allow-remote)", addr)
+}
+
+// ServeHTTP answers a changes request.
+func (c *Changes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since := -1
+	if v := query.Get("since"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "since must be a sequence number", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	wait := DefaultChangesWait
+	if v := query.Get("timeout"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "timeout must be a number of seconds", http.StatusBadRequest)
+			return
+		}
+		wait = min(time.Duration(n)*time.Second, MaxChangesWait)
+	}
+	var where []storage.WhereCondition
+	for _, clause := range query["where"] {
+		cond, err := storage.ParseWhereClause(clause)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		where = append(where, cond)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	set, err := c.store.WaitForChanges(ctx, r.PathValue("name"), since, where)
+	switch {
+	case errors.Is(err, model.ErrStashNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, model.ErrColumnNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestChanges(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".stash")
+	store, err := storage.NewStore(baseDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	now := time.Now()
+	require.NoError(t, store.CreateStash("tasks", "tk-", &model.Stash{
+		Name: "tasks", Prefix: "tk-", Created: now,
+		Columns: model.ColumnList{{Name: "Status", Added: now}},
+	}))
+	addRecord := func(id, status string) {
+		t.Helper()
+		fields := map[string]interface{}{"Status": status}
+		require.NoError(t, store.CreateRecord("tasks", &model.Record{
+			ID: id, Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}))
+	}
+	addRecord("tk-1", "open")
+
+	mux := http.NewServeMux()
+	mux.Handle(ChangesPattern, NewChanges(store))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	get := func(query string) (int, *storage.ChangeSet) {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/stashes/tasks/changes?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		var set storage.ChangeSet
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+		return resp.StatusCode, &set
+	}
+
+	t.Run("changes already there", func(t *testing.T) {
+		code, set := get("since=0&timeout=0")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, set.Changes, 1)
+		assert.Equal(t, 1, set.Changes[0].Seq)
+		assert.Equal(t, "tk-1", set.Changes[0].Record.ID)
+	})
+
+	t.Run("timeout 0 without since gives the cursor", func(t *testing.T) {
+		code, set := get("timeout=0")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, set.Changes)
+		assert.Equal(t, 1, set.Last)
+	})
+
+	t.Run("waits for a matching change", func(t *testing.T) {
+		go func() {
+			time.Sleep(2 * storage.ChangePollInterval)
+			addRecord("tk-2", "open")
+			addRecord("tk-3", "done")
+		}()
+		code, set := get("since=1&where=Status%3Ddone&timeout=5")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, set.Changes, 1)
+		assert.Equal(t, 3, set.Changes[0].Seq)
+		assert.Equal(t, "tk-3", set.Changes[0].Record.ID)
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		code, _ := get("since=x")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = get("where=Nope%3Dx&timeout=0")
+		assert.Equal(t, http.StatusBadRequest, code)
+		resp, err := http.Get(server.URL + "/stashes/missing/changes?timeout=0")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestCheckChangesAddr(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:9465", "[::1]:9465", "localhost:9465", "127.0.0.2:0"} {
+		assert.NoError(t, CheckChangesAddr(addr, false), addr)
+	}
+	for _, addr := range []string{":9465", "0.0.0.0:9465", "10.0.0.5:9465", "example.com:9465"} {
+		assert.Error(t, CheckChangesAddr(addr, false), addr)
+		assert.NoError(t, CheckChangesAddr(addr, true), addr)
+	}
+	assert.Error(t, CheckChangesAddr("9465", true))
+}
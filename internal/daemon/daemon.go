@@ -7,8 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"syscall"
 	"time"
+
+	"github.com/user/stash/internal/platform"
 )
 
 const (
@@ -29,6 +30,9 @@ type Status struct {
 	LastSync       time.Time `json:"last_sync,omitempty"`
 	StashesWatched int       `json:"stashes_watched,omitempty"`
 	MemoryMB       float64   `json:"memory_mb,omitempty"`
+	MetricsAddr    string    `json:"metrics_addr,omitempty"`
+	ChangesAddr    string    `json:"changes_addr,omitempty"`
+	ChangesRemote  bool      `json:"changes_remote,omitempty"`
 }
 
 // Daemon manages the background sync daemon process.
@@ -37,6 +41,10 @@ type Daemon struct {
 	pidFile    string
 	logFile    string
 	statusFile string
+
+	metricsAddr   string // passed to the daemon process by Start
+	changesAddr   string // passed to the daemon process by Start
+	changesRemote bool   // serve changes on a non-loopback address
 }
 
 // New creates a new Daemon manager.
@@ -70,6 +78,20 @@ func (d *Daemon) StatusFile() string {
 	return d.statusFile
 }
 
+// SetMetricsAddr makes the daemon started by Start serve Prometheus
+// metrics on addr (host:port). Empty turns the endpoint off.
+func (d *Daemon) SetMetricsAddr(addr string) {
+	d.metricsAddr = addr
+}
+
+// SetChangesAddr makes the daemon started by Start serve the changes feed
+// on addr (host:port). Empty turns the feed off. The feed is only served
+// on a loopback address unless allowRemote is set (see CheckChangesAddr).
+func (d *Daemon) SetChangesAddr(addr string, allowRemote bool) {
+	d.changesAddr = addr
+	d.changesRemote = allowRemote
+}
+
 // IsRunning checks if the daemon is currently running.
 // Returns (running, pid).
 func (d *Daemon) IsRunning() (bool, int) {
@@ -115,7 +137,17 @@ func (d *Daemon) Start() error {
 	}
 
 	// Start the daemon process
-	cmd := exec.Command(execPath, "daemon", "run")
+	args := []string{"daemon", "run"}
+	if d.metricsAddr != "" {
+		args = append(args, "--metrics-addr", d.metricsAddr)
+	}
+	if d.changesAddr != "" {
+		args = append(args, "--changes-addr", d.changesAddr)
+		if d.changesRemote {
+			args = append(args, "--changes-allow-remote")
+		}
+	}
+	cmd := exec.Command(execPath, args...)
 	cmd.Dir = d.baseDir
 
 	// Redirect stdout/stderr to log file
@@ -127,9 +159,7 @@ func (d *Daemon) Start() error {
 	cmd.Stderr = logFile
 
 	// Detach from parent process
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-	}
+	cmd.SysProcAttr = platform.DetachedProcAttr()
 
 	if err := cmd.Start(); err != nil {
 		logFile.Close()
@@ -150,9 +180,12 @@ func (d *Daemon) Start() error {
 
 	// Write initial status
 	status := &Status{
-		Running:   true,
-		PID:       pid,
-		StartTime: time.Now(),
+		Running:       true,
+		PID:           pid,
+		StartTime:     time.Now(),
+		MetricsAddr:   d.metricsAddr,
+		ChangesAddr:   d.changesAddr,
+		ChangesRemote: d.changesRemote,
 	}
 	if err := d.writeStatus(status); err != nil {
 		// Non-fatal - daemon is still running
@@ -173,14 +206,14 @@ func (d *Daemon) Stop() error {
 		return nil
 	}
 
-	// Send SIGTERM for graceful shutdown
+	// Ask the daemon to shut down (SIGTERM on Unix; killed on Windows)
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		_ = RemovePID(d.pidFile)
 		return nil
 	}
 
-	if err := process.Signal(syscall.SIGTERM); err != nil {
+	if err := platform.Terminate(process); err != nil {
 		// Process might have already exited
 		_ = RemovePID(d.pidFile)
 		return nil
@@ -257,7 +290,7 @@ func (d *Daemon) GetStatus() (*Status, error) {
 	}
 
 	// Get memory usage
-	status.MemoryMB = getProcessMemory(pid)
+	status.MemoryMB = float64(platform.ProcessMemory(pid)) / (1024 * 1024)
 
 	return status, nil
 }
@@ -304,28 +337,6 @@ func (d *Daemon) UpdateStatus(lastSync time.Time, stashesWatched int) error {
 	return d.writeStatus(status)
 }
 
-// getProcessMemory returns the memory usage of a process in MB.
-func getProcessMemory(pid int) float64 {
-	// Read from /proc/[pid]/statm
-	statmPath := fmt.Sprintf("/proc/%d/statm", pid)
-	data, err := os.ReadFile(statmPath)
-	if err != nil {
-		return 0
-	}
-
-	var size, resident, shared, text, lib, data_, dt int64
-	_, err = fmt.Sscanf(string(data), "%d %d %d %d %d %d %d",
-		&size, &resident, &shared, &text, &lib, &data_, &dt)
-	if err != nil {
-		return 0
-	}
-
-	// resident is in pages, typically 4KB
-	pageSize := int64(os.Getpagesize())
-	memBytes := resident * pageSize
-	return float64(memBytes) / (1024 * 1024)
-}
-
 // LogExists checks if the log file exists.
 func (d *Daemon) LogExists() bool {
 	_, err := os.Stat(d.logFile)
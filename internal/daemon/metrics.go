@@ -0,0 +1,295 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/stash/internal/storage"
+)
+
+// MetricsPath is where the daemon serves its metrics.
+const MetricsPath = "/metrics"
+
+// rebuildBuckets are the upper bounds, in seconds, of the cache rebuild
+// duration histogram.
+var rebuildBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics reports the health of the stashes in a .stash directory in the
+// Prometheus text format. Record counts come from the cache, operation
+// and lock conflict totals from each stash's records.jsonl and
+// lock-audit.jsonl, and rebuild durations from the daemon's own rebuilds.
+// The logs are read incrementally, so a scrape only reads the lines
+// appended since the last one.
+type Metrics struct {
+	baseDir string
+	start   time.Time
+
+	mu         sync.Mutex
+	operations map[string]*logTally // by stash, counted by _op
+	conflicts  map[string]*logTally // by stash, counted by lock event
+	rebuilds   map[string]*histogram
+	failures   map[string]int64 // failed rebuilds by stash
+}
+
+// NewMetrics creates the metrics of the stashes in baseDir.
+func NewMetrics(baseDir string) *Metrics {
+	return &Metrics{
+		baseDir:    baseDir,
+		start:      time.Now(),
+		operations: make(map[string]*logTally),
+		conflicts:  make(map[string]*logTally),
+		rebuilds:   make(map[string]*histogram),
+		failures:   make(map[string]int64),
+	}
+}
+
+// ObserveRebuild records a rebuild of a stash's cache that took d.
+func (m *Metrics) ObserveRebuild(stashName string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.failures[stashName]++
+		return
+	}
+	h := m.rebuilds[stashName]
+	if h == nil {
+		h = &histogram{counts: make([]int64, len(rebuildBuckets))}
+		m.rebuilds[stashName] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// ServeHTTP writes the current metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// Write writes the current metrics to w in the Prometheus text format.
+func (m *Metrics) Write(w io.Writer) error {
+	store, err := storage.NewStore(m.baseDir)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+
+	stashes, err := store.ListStashes()
+	if err != nil {
+		return fmt.Errorf("listing stashes: %w", err)
+	}
+	names := make([]string, 0, len(stashes))
+	for _, stash := range stashes {
+		names = append(names, stash.Name)
+	}
+	sort.Strings(names)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &exposition{w: bufio.NewWriter(w)}
+
+	e.family("stash_daemon_start_time_seconds", "gauge", "Time the daemon started, in seconds since the epoch.")
+	e.sample("stash_daemon_start_time_seconds", "", float64(m.start.Unix()))
+
+	stats := make(map[string]*storage.StashStats, len(names))
+	for _, name := range names {
+		if s, err := store.StashStats(name); err == nil {
+			stats[name] = s
+		}
+	}
+	e.family("stash_records", "gauge", "Active (not deleted) records in the stash.")
+	for _, name := range names {
+		if s := stats[name]; s != nil {
+			e.sample("stash_records", labels("stash", name), float64(s.Records))
+		}
+	}
+	e.family("stash_deleted_records", "gauge", "Soft-deleted records in the stash.")
+	for _, name := range names {
+		if s := stats[name]; s != nil {
+			e.sample("stash_deleted_records", labels("stash", name), float64(s.Deleted))
+		}
+	}
+	e.family("stash_last_operation_timestamp_seconds", "gauge", "Time of the stash's latest operation, in seconds since the epoch.")
+	for _, name := range names {
+		if s := stats[name]; s != nil && !s.LastModified.IsZero() {
+			e.sample("stash_last_operation_timestamp_seconds", labels("stash", name), float64(s.LastModified.Unix()))
+		}
+	}
+
+	e.family("stash_jsonl_bytes", "gauge", "Size of the stash's records.jsonl.")
+	for _, name := range names {
+		if info, err := os.Stat(m.recordsPath(name)); err == nil {
+			e.sample("stash_jsonl_bytes", labels("stash", name), float64(info.Size()))
+		}
+	}
+
+	e.family("stash_operations_total", "counter", "Operations in the stash's records.jsonl, by type. Resets when the log is compacted.")
+	for _, name := range names {
+		counts := tally(m.operations, name, m.recordsPath(name), "_op")
+		for _, op := range sortedKeys(counts) {
+			e.sample("stash_operations_total", labels("stash", name, "op", op), float64(counts[op]))
+		}
+	}
+
+	e.family("stash_lock_conflicts_total", "counter", "Attempts to lock a record another agent holds.")
+	for _, name := range names {
+		counts := tally(m.conflicts, name, filepath.Join(m.baseDir, name, "lock-audit.jsonl"), "event")
+		e.sample("stash_lock_conflicts_total", labels("stash", name), float64(counts["conflict"]))
+	}
+
+	e.family("stash_cache_rebuild_duration_seconds", "histogram", "Time the daemon took to rebuild the stash's cache from records.jsonl.")
+	for _, name := range sortedKeys(m.rebuilds) {
+		h := m.rebuilds[name]
+		var cumulative int64
+		for i, bound := range rebuildBuckets {
+			cumulative += h.counts[i]
+			e.sample("stash_cache_rebuild_duration_seconds_bucket", labels("stash", name, "le", formatFloat(bound)), float64(cumulative))
+		}
+		e.sample("stash_cache_rebuild_duration_seconds_bucket", labels("stash", name, "le", "+Inf"), float64(h.count))
+		e.sample("stash_cache_rebuild_duration_seconds_sum", labels("stash", name), h.sum)
+		e.sample("stash_cache_rebuild_duration_seconds_count", labels("stash", name), float64(h.count))
+	}
+	e.family("stash_cache_rebuild_failures_total", "counter", "Rebuilds of the stash's cache by the daemon that failed.")
+	for _, name := range sortedKeys(m.failures) {
+		e.sample("stash_cache_rebuild_failures_total", labels("stash", name), float64(m.failures[name]))
+	}
+
+	return e.w.Flush()
+}
+
+// recordsPath returns the path of a stash's records.jsonl.
+func (m *Metrics) recordsPath(stashName string) string {
+	return filepath.Join(m.baseDir, stashName, "records.jsonl")
+}
+
+// tally brings the counts of a stash's log up to date and returns them.
+// Callers hold m.mu.
+func tally(tallies map[string]*logTally, stashName, path, key string) map[string]int64 {
+	t := tallies[stashName]
+	if t == nil {
+		t = &logTally{counts: make(map[string]int64)}
+		tallies[stashName] = t
+	}
+	t.update(path, key)
+	return t.counts
+}
+
+// logTally counts the lines of an append-only JSONL file by the value of
+// one key, remembering how far it has read.
+type logTally struct {
+	offset int64
+	counts map[string]int64
+}
+
+// update counts the complete lines appended to the file at path since the
+// last update. A file that shrank was compacted or replaced, so it is
+// counted again from the start.
+func (t *logTally) update(path, key string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() < t.offset {
+		t.offset = 0
+		t.counts = make(map[string]int64)
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// Leave a partly written last line for the next update
+			return
+		}
+		t.offset += int64(len(line))
+
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(line, &fields) != nil {
+			continue
+		}
+		var value string
+		if json.Unmarshal(fields[key], &value) == nil && value != "" {
+			t.counts[value]++
+		}
+	}
+}
+
+// histogram counts observations into rebuildBuckets.
+type histogram struct {
+	counts []int64 // per bucket, not cumulative
+	count  int64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	h.count++
+	h.sum += v
+	for i, bound := range rebuildBuckets {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// exposition writes metric families in the Prometheus text format.
+type exposition struct {
+	w *bufio.Writer
+}
+
+func (e *exposition) family(name, kind, help string) {
+	fmt.Fprintf(e.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (e *exposition) sample(name string, labels string, value float64) {
+	fmt.Fprintf(e.w, "%s%s %s\n", name, labels, formatFloat(value))
+}
+
+// labels formats label name/value pairs as {name="value",...}.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/storage"
+)
+
+func TestMetrics(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".stash")
+	store, err := storage.NewStore(baseDir)
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, store.CreateStash("inventory", "inv-", &model.Stash{
+		Name: "inventory", Prefix: "inv-", Created: now,
+		Columns: model.ColumnList{{Name: "Name", Added: now}},
+	}))
+	addRecord := func(id string) {
+		t.Helper()
+		fields := map[string]interface{}{"Name": id}
+		require.NoError(t, store.CreateRecord("inventory", &model.Record{
+			ID: id, Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}))
+	}
+	addRecord("inv-1")
+	addRecord("inv-2")
+	require.NoError(t, store.DeleteRecord("inventory", "inv-2", "alice"))
+	store.Close()
+
+	audit := `{"event":"lock","record_id":"inv-1","agent":"a"}
+{"event":"conflict","record_id":"inv-1","agent":"b"}
+{"event":"conflict","record_id":"inv-1","agent":"c"}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "inventory", "lock-audit.jsonl"), []byte(audit), 0644))
+
+	metrics := NewMetrics(baseDir)
+	scrape := func() string {
+		t.Helper()
+		var buf strings.Builder
+		require.NoError(t, metrics.Write(&buf))
+		return buf.String()
+	}
+
+	out := scrape()
+	assert.Contains(t, out, "# TYPE stash_records gauge\n")
+	assert.Contains(t, out, `stash_records{stash="inventory"} 1`+"\n")
+	assert.Contains(t, out, `stash_deleted_records{stash="inventory"} 1`+"\n")
+	assert.Contains(t, out, `stash_operations_total{stash="inventory",op="create"} 2`+"\n")
+	assert.Contains(t, out, `stash_operations_total{stash="inventory",op="delete"} 1`+"\n")
+	assert.Contains(t, out, `stash_lock_conflicts_total{stash="inventory"} 2`+"\n")
+	info, err := os.Stat(filepath.Join(baseDir, "inventory", "records.jsonl"))
+	require.NoError(t, err)
+	assert.Contains(t, out, `stash_jsonl_bytes{stash="inventory"} `+formatFloat(float64(info.Size()))+"\n")
+
+	t.Run("counts appended operations", func(t *testing.T) {
+		store, err := storage.NewStore(baseDir)
+		require.NoError(t, err)
+		fields := map[string]interface{}{"Name": "inv-3"}
+		require.NoError(t, store.CreateRecord("inventory", &model.Record{
+			ID: "inv-3", Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}))
+		store.Close()
+
+		out := scrape()
+		assert.Contains(t, out, `stash_operations_total{stash="inventory",op="create"} 3`+"\n")
+		assert.Contains(t, out, `stash_records{stash="inventory"} 2`+"\n")
+	})
+
+	t.Run("rebuild histogram", func(t *testing.T) {
+		metrics.ObserveRebuild("inventory", 30*time.Millisecond, nil)
+		metrics.ObserveRebuild("inventory", 2*time.Second, nil)
+		metrics.ObserveRebuild("inventory", time.Second, assert.AnError)
+
+		out := scrape()
+		assert.Contains(t, out, `stash_cache_rebuild_duration_seconds_bucket{stash="inventory",le="0.01"} 0`+"\n")
+		assert.Contains(t, out, `stash_cache_rebuild_duration_seconds_bucket{stash="inventory",le="0.05"} 1`+"\n")
+		assert.Contains(t, out, `stash_cache_rebuild_duration_seconds_bucket{stash="inventory",le="2.5"} 2`+"\n")
+		assert.Contains(t, out, `stash_cache_rebuild_duration_seconds_bucket{stash="inventory",le="+Inf"} 2`+"\n")
+		assert.Contains(t, out, `stash_cache_rebuild_duration_seconds_count{stash="inventory"} 2`+"\n")
+		assert.Contains(t, out, `stash_cache_rebuild_failures_total{stash="inventory"} 1`+"\n")
+	})
+
+	t.Run("served over HTTP", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+		assert.Contains(t, rec.Body.String(), `stash_records{stash="inventory"}`)
+	})
+}
+
+func TestLogTally(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"_op":"create"}`+"\n"+`{"_op":"upd`), 0644))
+
+	tally := &logTally{counts: make(map[string]int64)}
+	tally.update(path, "_op")
+	assert.Equal(t, map[string]int64{"create": 1}, tally.counts, "a partly written line waits")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	f.WriteString(`ate"}` + "\n")
+	f.Close()
+	tally.update(path, "_op")
+	assert.Equal(t, map[string]int64{"create": 1, "update": 1}, tally.counts)
+
+	// Compaction rewrites the log smaller, so it is counted again
+	require.NoError(t, os.WriteFile(path, []byte(`{"_op":"create"}`+"\n"), 0644))
+	tally.update(path, "_op")
+	assert.Equal(t, map[string]int64{"create": 1}, tally.counts)
+}
+
+func TestLabels(t *testing.T) {
+	assert.Equal(t, `{stash="a\"b\\c\nd",op="x"}`, labels("stash", "a\"b\\c\nd", "op", "x"))
+}
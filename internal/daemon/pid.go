@@ -7,7 +7,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"syscall"
+
+	"github.com/user/stash/internal/platform"
 )
 
 var (
@@ -69,15 +70,7 @@ func IsProcessRunning(pid int) bool {
 		return false
 	}
 
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-
-	// On Unix, FindProcess always succeeds, so we need to send signal 0
-	// to check if the process actually exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+	return platform.ProcessExists(pid)
 }
 
 // CleanStalePID removes the PID file if it references a non-running process.
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"syscall"
 	"time"
 
+	"github.com/user/stash/internal/platform"
 	"github.com/user/stash/internal/storage"
 )
 
@@ -21,6 +23,8 @@ const (
 	MaxLogSize = 10 * 1024 * 1024
 	// MaxLogFiles is the number of rotated log files to keep.
 	MaxLogFiles = 3
+	// PurgeInterval is how often retention policies are enforced.
+	PurgeInterval = time.Hour
 )
 
 // Process represents a running daemon process.
@@ -31,6 +35,12 @@ type Process struct {
 	stopChan   chan struct{}
 	stashesDir string
 	watcher    *Watcher
+	lastPurge  time.Time
+
+	metricsAddr   string // address to serve metrics on, or empty
+	metrics       *Metrics
+	changesAddr   string // address to serve the changes feed on, or empty
+	changesRemote bool   // serve changes on a non-loopback address
 }
 
 // NewProcess creates a new daemon process.
@@ -40,9 +50,24 @@ func NewProcess(baseDir string) *Process {
 		daemon:     d,
 		stopChan:   make(chan struct{}),
 		stashesDir: filepath.Dir(baseDir), // Parent of .stash is where stashes are
+		metrics:    NewMetrics(baseDir),
 	}
 }
 
+// SetMetricsAddr makes the process serve Prometheus metrics on addr
+// (host:port) at MetricsPath while it runs.
+func (p *Process) SetMetricsAddr(addr string) {
+	p.metricsAddr = addr
+}
+
+// SetChangesAddr makes the process serve the changes feed on addr
+// (host:port) at ChangesPattern while it runs. The feed is only served on
+// a loopback address unless allowRemote is set (see CheckChangesAddr).
+func (p *Process) SetChangesAddr(addr string, allowRemote bool) {
+	p.changesAddr = addr
+	p.changesRemote = allowRemote
+}
+
 // Run starts the daemon process loop.
 // This should be called by the background process after fork.
 func (p *Process) Run(ctx context.Context) error {
@@ -61,9 +86,26 @@ func (p *Process) Run(ctx context.Context) error {
 		defer p.watcher.Close()
 	}
 
+	p.verifySegments()
+
+	if p.metricsAddr != "" {
+		if server, err := p.serveMetrics(); err != nil {
+			p.logger.Printf("Error: could not serve metrics on %s: %v", p.metricsAddr, err)
+		} else {
+			defer server.Close()
+		}
+	}
+	if p.changesAddr != "" {
+		if stop, err := p.serveChanges(); err != nil {
+			p.logger.Printf("Error: could not serve changes on %s: %v", p.changesAddr, err)
+		} else {
+			defer stop()
+		}
+	}
+
 	// Setup signal handling
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(sigChan, platform.ShutdownSignals()...)
 
 	// Create a ticker for periodic sync
 	ticker := time.NewTicker(SyncInterval)
@@ -92,6 +134,9 @@ func (p *Process) Run(ctx context.Context) error {
 			p.performSync()
 			p.updateStatus()
 			p.checkLogRotation()
+			if time.Since(p.lastPurge) >= PurgeInterval {
+				p.enforceRetention()
+			}
 		}
 	}
 }
@@ -144,8 +189,59 @@ func (p *Process) setupWatcher() error {
 	return nil
 }
 
-// rebuildStashCache rebuilds the SQLite cache for a stash from JSONL.
-func (p *Process) rebuildStashCache(stashName string) error {
+// serveMetrics starts serving metrics on the process's metrics address.
+func (p *Process) serveMetrics() (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, p.metrics)
+	return p.serve("metrics", p.metricsAddr, mux)
+}
+
+// serveChanges starts serving the changes feed on the process's changes
+// address, from one store shared by every request. It returns the
+// function that stops the server and closes the store.
+func (p *Process) serveChanges() (func(), error) {
+	if err := CheckChangesAddr(p.changesAddr, p.changesRemote); err != nil {
+		return nil, err
+	}
+	store, err := storage.NewStore(p.daemon.BaseDir())
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(ChangesPattern, NewChanges(store))
+	server, err := p.serve("changes", p.changesAddr, mux)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return func() {
+		server.Close()
+		store.Close()
+	}, nil
+}
+
+// serve starts an HTTP server for handler on addr, logging what it serves.
+func (p *Process) serve(what, addr string, handler http.Handler) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			p.logger.Printf("Error serving %s: %v", what, err)
+		}
+	}()
+	p.logger.Printf("Serving %s on http://%s", what, listener.Addr())
+	return server, nil
+}
+
+// rebuildStashCache rebuilds the SQLite cache for a stash from JSONL,
+// timing it for the metrics.
+func (p *Process) rebuildStashCache(stashName string) (err error) {
+	start := time.Now()
+	defer func() { p.metrics.ObserveRebuild(stashName, time.Since(start), err) }()
+
 	store, err := storage.NewStore(p.daemon.BaseDir())
 	if err != nil {
 		return fmt.Errorf("opening store: %w", err)
@@ -173,6 +269,64 @@ func (p *Process) performSync() {
 	p.logger.Println("Performing sync check...")
 }
 
+// enforceRetention purges expired deleted records from stashes that have
+// auto-purge enabled.
+func (p *Process) enforceRetention() {
+	p.lastPurge = time.Now()
+
+	store, err := storage.NewStore(p.daemon.BaseDir())
+	if err != nil {
+		p.logger.Printf("Error opening store for retention: %v", err)
+		return
+	}
+	defer store.Close()
+
+	stashes, err := store.ListStashes()
+	if err != nil {
+		p.logger.Printf("Error listing stashes for retention: %v", err)
+		return
+	}
+
+	for _, stash := range stashes {
+		if !stash.AutoPurge {
+			continue
+		}
+		purged, err := store.PurgeExpired(stash.Name, p.lastPurge)
+		if err != nil {
+			p.logger.Printf("Error purging expired records in %s: %v", stash.Name, err)
+		}
+		if len(purged) > 0 {
+			p.logger.Printf("Purged %d expired record(s) from %s", len(purged), stash.Name)
+		}
+	}
+}
+
+// verifySegments checks each stash's log against the segment sealed by
+// its last compaction, logging any that changed since. Only the sealed
+// bytes are read, so this is cheap even for big stashes.
+func (p *Process) verifySegments() {
+	entries, err := os.ReadDir(p.daemon.BaseDir())
+	if err != nil {
+		p.logger.Printf("Error listing stashes for segment checks: %v", err)
+		return
+	}
+	jsonl := storage.NewJSONLStore(p.daemon.BaseDir())
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		report, err := jsonl.VerifySegment(entry.Name())
+		switch {
+		case err != nil:
+			p.logger.Printf("Error checking sealed segment of %s: %v", entry.Name(), err)
+		case report.Valid:
+			p.logger.Printf("Sealed segment of %s verified (%d records)", entry.Name(), report.Segment.Records)
+		case report.Sealed:
+			p.logger.Printf("Warning: %s: %s; run 'stash doctor' to check it", entry.Name(), report.Problem)
+		}
+	}
+}
+
 // updateStatus updates the daemon status file.
 func (p *Process) updateStatus() {
 	stashCount := p.countWatchedStashes()
@@ -0,0 +1,116 @@
+// Package logging provides the diagnostic log of stash commands: structured
+// events from the store (SQL executed, JSONL writes, cache rebuilds) and
+// the CLI (lock decisions), for working out why a command misbehaved.
+//
+// Logging is off unless a level is set with 'stash --log-level' or
+// $STASH_LOG_LEVEL. Events go to stderr as text, or to the file named by
+// --log-file or $STASH_LOG_FILE as JSON lines.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LevelTrace is below debug: it adds an event for every SQL statement and
+// file lock.
+const LevelTrace = slog.Level(-8)
+
+// LevelOff is above every level used, so nothing is logged.
+const LevelOff = slog.Level(100)
+
+// levelNames maps --log-level values to levels.
+var levelNames = map[string]slog.Level{
+	"off":   LevelOff,
+	"error": slog.LevelError,
+	"warn":  slog.LevelWarn,
+	"info":  slog.LevelInfo,
+	"debug": slog.LevelDebug,
+	"trace": LevelTrace,
+}
+
+var (
+	level  = new(slog.LevelVar)
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+)
+
+func init() {
+	level.Set(LevelOff)
+}
+
+// ParseLevel parses a log level name: off, error, warn, info, debug, or
+// trace. Case is ignored; an empty name is off.
+func ParseLevel(name string) (slog.Level, error) {
+	if name == "" {
+		return LevelOff, nil
+	}
+	l, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid log level '%s' (valid levels: off, error, warn, info, debug, trace)", name)
+	}
+	return l, nil
+}
+
+// Setup directs events at or above l to w, as JSON lines if asJSON is set
+// or as text otherwise.
+func Setup(l slog.Level, w io.Writer, asJSON bool) {
+	level.Set(l)
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevel}
+	if asJSON {
+		logger = slog.New(slog.NewJSONHandler(w, opts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(w, opts))
+	}
+}
+
+// SetupFile directs events at or above l to the file at path, appending to
+// it as JSON lines, or to stderr as text if path is empty. The returned
+// function closes the file.
+func SetupFile(l slog.Level, path string) (func() error, error) {
+	if path == "" {
+		Setup(l, os.Stderr, false)
+		return func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	Setup(l, f, true)
+	return f.Close, nil
+}
+
+// Disable turns logging off.
+func Disable() {
+	level.Set(LevelOff)
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Enabled reports whether events at l are logged, so callers can skip
+// building expensive attributes.
+func Enabled(l slog.Level) bool {
+	return l >= level.Level()
+}
+
+// Debug logs an event at debug level.
+func Debug(msg string, args ...any) {
+	logger.Log(context.Background(), slog.LevelDebug, msg, args...)
+}
+
+// Trace logs an event at trace level.
+func Trace(msg string, args ...any) {
+	logger.Log(context.Background(), LevelTrace, msg, args...)
+}
+
+// replaceLevel names the trace level, which slog would print as DEBUG-4.
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if l, ok := a.Value.Any().(slog.Level); ok && l == LevelTrace {
+			return slog.String(slog.LevelKey, "TRACE")
+		}
+	}
+	return a
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"":      LevelOff,
+		"off":   LevelOff,
+		"warn":  slog.LevelWarn,
+		"DEBUG": slog.LevelDebug,
+		"trace": LevelTrace,
+	} {
+		got, err := ParseLevel(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseLevel("loud")
+	assert.ErrorContains(t, err, "invalid log level")
+}
+
+func TestSetup(t *testing.T) {
+	defer Disable()
+
+	t.Run("off by default", func(t *testing.T) {
+		Disable()
+		assert.False(t, Enabled(slog.LevelError))
+	})
+
+	t.Run("events below the level are dropped", func(t *testing.T) {
+		var buf bytes.Buffer
+		Setup(slog.LevelDebug, &buf, false)
+		Debug("jsonl append", "stash", "tasks")
+		Trace("sql", "query", "SELECT 1")
+
+		assert.True(t, Enabled(slog.LevelDebug))
+		assert.False(t, Enabled(LevelTrace))
+		assert.Contains(t, buf.String(), `msg="jsonl append" stash=tasks`)
+		assert.NotContains(t, buf.String(), "SELECT 1")
+	})
+
+	t.Run("trace events are named", func(t *testing.T) {
+		var buf bytes.Buffer
+		Setup(LevelTrace, &buf, false)
+		Trace("sql", "query", "SELECT 1")
+		assert.Contains(t, buf.String(), "level=TRACE")
+	})
+
+	t.Run("log files hold JSON lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "stash.log")
+		closeFile, err := SetupFile(LevelTrace, path)
+		require.NoError(t, err)
+		Trace("sql", "query", "SELECT 1")
+		Debug("cache rebuild", "records", 3)
+		require.NoError(t, closeFile())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 2)
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+		assert.Equal(t, "TRACE", event["level"])
+		assert.Equal(t, "SELECT 1", event["query"])
+	})
+}
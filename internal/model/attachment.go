@@ -4,8 +4,13 @@ package model
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -40,8 +45,139 @@ var (
 	ErrFileNotFound       = newError("file not found")
 	ErrAttachmentNotFound = newError("attachment not found")
 	ErrAttachmentExists   = newError("attachment already exists")
+	ErrInvalidFileName    = newError("invalid attachment name")
+	ErrAttachmentRejected = newError("attachment rejected by policy")
 )
 
+// ValidateAttachmentName checks that an attachment name is a plain file
+// name, so it cannot point outside the record's files directory.
+func ValidateAttachmentName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return ErrInvalidFileName
+	}
+	return nil
+}
+
+// Attachment policy rules, as reported in AttachmentPolicyError.
+const (
+	PolicyMaxSize   = "max_size"
+	PolicyExtension = "extension"
+	PolicyMimeType  = "mime_type"
+	PolicyScanner   = "scanner"
+)
+
+// AttachmentPolicy limits what may be attached to a stash's records. An
+// empty field means no limit on that axis.
+type AttachmentPolicy struct {
+	MaxSize    int64    `json:"max_size,omitempty"`   // Largest attachment in bytes
+	Extensions []string `json:"extensions,omitempty"` // Allowed file extensions, without the dot (e.g., "pdf")
+	MimeTypes  []string `json:"mime_types,omitempty"` // Allowed content types, by sniffing; "image/*" allows a whole type
+	Scanner    string   `json:"scanner,omitempty"`    // Shell command that rejects a file by exiting non-zero
+}
+
+// IsEmpty reports whether the policy allows everything.
+func (p *AttachmentPolicy) IsEmpty() bool {
+	return p == nil || (p.MaxSize == 0 && len(p.Extensions) == 0 && len(p.MimeTypes) == 0 && p.Scanner == "")
+}
+
+// AttachmentPolicyError describes an attachment the stash's policy rejects.
+type AttachmentPolicyError struct {
+	Name    string // Attachment name
+	Rule    string // One of the Policy* rules
+	Message string
+}
+
+func (e *AttachmentPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrAttachmentRejected, e.Message)
+}
+
+func (e *AttachmentPolicyError) Unwrap() error {
+	return ErrAttachmentRejected
+}
+
+// CheckSize rejects an attachment larger than the policy allows.
+func (p *AttachmentPolicy) CheckSize(name string, size int64) error {
+	if p == nil || p.MaxSize <= 0 || size <= p.MaxSize {
+		return nil
+	}
+	return &AttachmentPolicyError{Name: name, Rule: PolicyMaxSize,
+		Message: fmt.Sprintf("'%s' is %s, larger than the limit of %s", name, FormatSize(size), FormatSize(p.MaxSize))}
+}
+
+// CheckExtension rejects an attachment whose extension is not allowed.
+func (p *AttachmentPolicy) CheckExtension(name string) error {
+	if p == nil || len(p.Extensions) == 0 {
+		return nil
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	for _, allowed := range p.Extensions {
+		if ext != "" && strings.EqualFold(strings.TrimPrefix(allowed, "."), ext) {
+			return nil
+		}
+	}
+	return &AttachmentPolicyError{Name: name, Rule: PolicyExtension,
+		Message: fmt.Sprintf("'%s' does not have an allowed extension (allowed: %s)", name, strings.Join(p.Extensions, ", "))}
+}
+
+// CheckMimeType rejects an attachment whose sniffed content type is not
+// allowed. Parameters such as charset are ignored.
+func (p *AttachmentPolicy) CheckMimeType(name, mimeType string) error {
+	if p == nil || len(p.MimeTypes) == 0 {
+		return nil
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	for _, allowed := range p.MimeTypes {
+		if ok, _ := path.Match(strings.ToLower(allowed), mimeType); ok {
+			return nil
+		}
+	}
+	return &AttachmentPolicyError{Name: name, Rule: PolicyMimeType,
+		Message: fmt.Sprintf("'%s' has content type %s, which is not allowed (allowed: %s)", name, mimeType, strings.Join(p.MimeTypes, ", "))}
+}
+
+// ParseSize parses a size such as 512, 100KB, 10MB, or 1GB into bytes.
+// Units are powers of 1024 and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size '%s' (e.g. 512KB, 10MB, 1GB)", s)
+	}
+	return n * multiplier, nil
+}
+
+// FormatSize formats a size in bytes in human-readable form, e.g. "1.5 MB".
+func FormatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/GB)
+	case bytes >= MB:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/MB)
+	case bytes >= KB:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/KB)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
 func newError(msg string) error {
 	return &stashError{msg: msg}
 }
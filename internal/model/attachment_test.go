@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentPolicy(t *testing.T) {
+	policy := &AttachmentPolicy{
+		MaxSize:    1024,
+		Extensions: []string{"pdf", "PNG"},
+		MimeTypes:  []string{"image/*", "application/pdf"},
+	}
+
+	t.Run("nil policy allows everything", func(t *testing.T) {
+		var none *AttachmentPolicy
+		assert.True(t, none.IsEmpty())
+		assert.NoError(t, none.CheckSize("a.bin", 1<<40))
+		assert.NoError(t, none.CheckExtension("a.bin"))
+		assert.NoError(t, none.CheckMimeType("a.bin", "application/octet-stream"))
+	})
+
+	t.Run("size", func(t *testing.T) {
+		assert.NoError(t, policy.CheckSize("a.pdf", 1024))
+
+		err := policy.CheckSize("a.pdf", 2048)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrAttachmentRejected))
+		var policyErr *AttachmentPolicyError
+		require.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, PolicyMaxSize, policyErr.Rule)
+		assert.Equal(t, "a.pdf", policyErr.Name)
+	})
+
+	t.Run("extension", func(t *testing.T) {
+		assert.NoError(t, policy.CheckExtension("scan.PDF"))
+		assert.NoError(t, policy.CheckExtension("logo.png"))
+		for _, name := range []string{"tool.exe", "README", "archive.pdf.zip"} {
+			var policyErr *AttachmentPolicyError
+			require.True(t, errors.As(policy.CheckExtension(name), &policyErr), name)
+			assert.Equal(t, PolicyExtension, policyErr.Rule)
+		}
+	})
+
+	t.Run("mime type", func(t *testing.T) {
+		assert.NoError(t, policy.CheckMimeType("logo.png", "image/png"))
+		assert.NoError(t, policy.CheckMimeType("a.pdf", "application/pdf"))
+
+		var policyErr *AttachmentPolicyError
+		require.True(t, errors.As(policy.CheckMimeType("a.pdf", "text/plain; charset=utf-8"), &policyErr))
+		assert.Equal(t, PolicyMimeType, policyErr.Rule)
+		assert.Contains(t, policyErr.Message, "text/plain")
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"100KB", 100 * 1024},
+		{"10mb", 10 * 1024 * 1024},
+		{"1G", 1024 * 1024 * 1024},
+		{" 2 MB ", 2 * 1024 * 1024},
+	}
+	for _, tc := range tests {
+		size, err := ParseSize(tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, size, tc.input)
+	}
+
+	for _, input := range []string{"", "MB", "-1KB", "0", "1.5MB", "10TB"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Collations of a stash's text values.
+const (
+	CollationBinary = "binary" // byte by byte, so "Apple" sorts before "apple" (default)
+	CollationNoCase = "nocase" // ignoring case, in code point order
+)
+
+// ValidateCollation checks a collation: binary, nocase, or a BCP 47
+// language tag such as de or sv-SE for that language's order, ignoring
+// case.
+func ValidateCollation(name string) error {
+	switch name {
+	case CollationBinary, CollationNoCase:
+		return nil
+	}
+	if name != "" && !strings.ContainsAny(name, " '") {
+		if tag, err := language.Parse(name); err == nil && tag != language.Und {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid collation '%s' (valid: binary, nocase, or a language tag such as en, de, sv-SE)", name)
+}
+
+// TextCollation returns the collation of the stash's text values.
+func (s *Stash) TextCollation() string {
+	if s.Collation == "" {
+		return CollationBinary
+	}
+	return s.Collation
+}
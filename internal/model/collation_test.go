@@ -0,0 +1,21 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCollation(t *testing.T) {
+	for _, name := range []string{"binary", "nocase", "en", "de", "sv-SE", "zh-Hant"} {
+		assert.NoError(t, ValidateCollation(name), name)
+	}
+	for _, name := range []string{"", "und", "not a tag", "de'", "x-"} {
+		assert.Error(t, ValidateCollation(name), name)
+	}
+
+	stash := &Stash{}
+	assert.Equal(t, CollationBinary, stash.TextCollation())
+	stash.Collation = "de"
+	assert.Equal(t, "de", stash.TextCollation())
+}
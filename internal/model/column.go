@@ -8,17 +8,23 @@ import (
 
 // Reserved column names (system fields)
 var reservedColumnNames = map[string]bool{
-	"_id":         true,
-	"_hash":       true,
-	"_parent":     true,
-	"_created_at": true,
-	"_created_by": true,
-	"_updated_at": true,
-	"_updated_by": true,
-	"_branch":     true,
-	"_deleted_at": true,
-	"_deleted_by": true,
-	"_op":         true,
+	"_id":          true,
+	"_hash":        true,
+	"_parent":      true,
+	"_created_at":  true,
+	"_created_by":  true,
+	"_updated_at":  true,
+	"_updated_by":  true,
+	"_branch":      true,
+	"_deleted_at":  true,
+	"_deleted_by":  true,
+	"_archived_at": true,
+	"_archived_by": true,
+	"_assigned_to": true,
+	"_op":          true,
+	"_source":      true,
+	"_run":         true,
+	"_coerced":     true,
 }
 
 // Column name validation regex:
@@ -36,6 +42,87 @@ type Column struct {
 	Validate string    `json:"validate,omitempty"` // Validation type: "email", "url", "number", "date"
 	Enum     []string  `json:"enum,omitempty"`     // Allowed values for enum validation
 	Required bool      `json:"required,omitempty"` // Whether field is required
+	Computed string    `json:"computed,omitempty"` // SQL expression evaluated at read time
+	Due      bool      `json:"due,omitempty"`      // Date column tracked by 'stash due'
+	Severity string    `json:"severity,omitempty"` // "warning" makes constraint violations non-blocking
+	List     bool      `json:"list,omitempty"`     // Values are lists; validation applies to each element
+	Hook     string    `json:"hook,omitempty"`     // Shell command that validates values (see 'stash hook')
+	Coerce   bool      `json:"coerce,omitempty"`   // Accept enum values in any case, writing the canonical one
+
+	// Transitions maps each enum value to the values it may move to.
+	// When empty, any enum value may follow any other.
+	Transitions map[string][]string `json:"transitions,omitempty"`
+
+	// Display metadata for numbers in table and markdown output: the unit
+	// shown after them, the digits after the decimal point, and whether
+	// thousands are separated by commas. JSON and CSV output keep values
+	// as stored.
+	Unit         string `json:"unit,omitempty"`
+	Decimals     *int   `json:"decimals,omitempty"`
+	ThousandsSep bool   `json:"thousands_sep,omitempty"`
+}
+
+// Constraint violation severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ParseSeverity returns the severity a name stands for: "error", or
+// "warning" (also "warn"), in any case. Returns false for anything else.
+func ParseSeverity(name string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case SeverityError:
+		return SeverityError, true
+	case SeverityWarning, "warn":
+		return SeverityWarning, true
+	}
+	return "", false
+}
+
+// ViolationSeverity returns the severity of the column's constraint
+// violations. Warnings are reported by 'stash validate' but do not block
+// writes.
+func (c *Column) ViolationSeverity() string {
+	if c.Severity == SeverityWarning {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// Value types that decide how column values are compared and sorted.
+const (
+	ValueTypeText    = "text"
+	ValueTypeNumeric = "numeric"
+	ValueTypeDate    = "date"
+)
+
+// ValueTypes lists the valid value types.
+var ValueTypes = []string{ValueTypeText, ValueTypeNumeric, ValueTypeDate}
+
+// ValueType returns how the column's values compare: numeric for number
+// columns, date for date and due columns, and text otherwise.
+func (c *Column) ValueType() string {
+	switch {
+	case c.Validate == "number":
+		return ValueTypeNumeric
+	case c.Validate == "date" || c.Due:
+		return ValueTypeDate
+	default:
+		return ValueTypeText
+	}
+}
+
+// HasDisplayFormat returns true if the column has display metadata for
+// its numbers.
+func (c *Column) HasDisplayFormat() bool {
+	return c.Unit != "" || c.Decimals != nil || c.ThousandsSep
+}
+
+// IsComputed returns true if the column is derived from an expression
+// rather than stored on records.
+func (c *Column) IsComputed() bool {
+	return c.Computed != ""
 }
 
 // ValidateColumnName checks if a column name is valid.
@@ -59,6 +146,46 @@ func IsReservedColumn(name string) bool {
 	return reservedColumnNames[strings.ToLower(name)]
 }
 
+// CoerceEnum returns the enum value a written value stands for when the
+// column coerces enum values: the allowed value it matches ignoring case,
+// if exactly one does. Returns the value unchanged and false when it is
+// already allowed, the column does not coerce, or there is no single match.
+func (c *Column) CoerceEnum(value string) (string, bool) {
+	if !c.Coerce {
+		return value, false
+	}
+	var match string
+	matches := 0
+	for _, allowed := range c.Enum {
+		if allowed == value {
+			return value, false
+		}
+		if strings.EqualFold(allowed, value) {
+			match = allowed
+			matches++
+		}
+	}
+	if matches != 1 {
+		return value, false
+	}
+	return match, true
+}
+
+// AllowsTransition returns true if the column's workflow permits moving
+// from one value to another. Setting an initial value (from is empty) or
+// re-setting the same value is always allowed.
+func (c *Column) AllowsTransition(from, to string) bool {
+	if len(c.Transitions) == 0 || from == "" || from == to {
+		return true
+	}
+	for _, next := range c.Transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 // ColumnList provides case-insensitive column operations.
 type ColumnList []Column
 
@@ -79,6 +206,42 @@ func (cl ColumnList) Exists(name string) bool {
 	return cl.Find(name) != nil
 }
 
+// Suggest returns the column name closest to a name that matched no
+// column, for "did you mean" hints. Returns "" if no column is close.
+func (cl ColumnList) Suggest(name string) string {
+	nameLower := strings.ToLower(name)
+	maxDistance := (len(nameLower) + 2) / 3
+	best, bestDistance := "", maxDistance+1
+	for _, col := range cl {
+		if d := editDistance(nameLower, strings.ToLower(col.Name)); d < bestDistance {
+			best, bestDistance = col.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
 // Index returns the index of the column with the given name (case-insensitive).
 // Returns -1 if not found.
 func (cl ColumnList) Index(name string) int {
@@ -100,6 +263,30 @@ func (cl ColumnList) Names() []string {
 	return names
 }
 
+// ValueTypes returns the value type of each numeric or date column, keyed
+// by column name. Text columns are omitted.
+func (cl ColumnList) ValueTypes() map[string]string {
+	types := make(map[string]string)
+	for i := range cl {
+		if t := cl[i].ValueType(); t != ValueTypeText {
+			types[cl[i].Name] = t
+		}
+	}
+	return types
+}
+
+// StoredNames returns the names of all non-computed columns.
+// These are the columns whose values are persisted on records.
+func (cl ColumnList) StoredNames() []string {
+	names := make([]string, 0, len(cl))
+	for i := range cl {
+		if !cl[i].IsComputed() {
+			names = append(names, cl[i].Name)
+		}
+	}
+	return names
+}
+
 // First returns the first column, or nil if empty.
 func (cl ColumnList) First() *Column {
 	if len(cl) == 0 {
@@ -261,3 +261,85 @@ func TestColumnList_Modification(t *testing.T) {
 		assert.Equal(t, "Modified", columns[0].Desc)
 	})
 }
+
+func TestColumn_AllowsTransition(t *testing.T) {
+	col := Column{
+		Name: "Status",
+		Enum: []string{"pending", "active", "closed"},
+		Transitions: map[string][]string{
+			"pending": {"active"},
+			"active":  {"closed", "pending"},
+		},
+	}
+
+	assert.True(t, col.AllowsTransition("", "closed"), "initial value is always allowed")
+	assert.True(t, col.AllowsTransition("active", "active"), "same value is always allowed")
+	assert.True(t, col.AllowsTransition("pending", "active"))
+	assert.True(t, col.AllowsTransition("active", "pending"))
+	assert.False(t, col.AllowsTransition("pending", "closed"))
+	assert.False(t, col.AllowsTransition("closed", "pending"), "terminal state has no exits")
+
+	unrestricted := Column{Name: "Status", Enum: []string{"a", "b"}}
+	assert.True(t, unrestricted.AllowsTransition("a", "b"))
+}
+
+func TestColumn_CoerceEnum(t *testing.T) {
+	col := Column{Name: "Status", Enum: []string{"pending", "active", "Open", "OPEN"}, Coerce: true}
+
+	value, ok := col.CoerceEnum("Pending")
+	assert.True(t, ok)
+	assert.Equal(t, "pending", value)
+
+	value, ok = col.CoerceEnum("active")
+	assert.False(t, ok, "allowed values are not coerced")
+	assert.Equal(t, "active", value)
+
+	_, ok = col.CoerceEnum("open")
+	assert.False(t, ok, "ambiguous matches are not coerced")
+	_, ok = col.CoerceEnum("closed")
+	assert.False(t, ok)
+
+	col.Coerce = false
+	_, ok = col.CoerceEnum("Pending")
+	assert.False(t, ok, "strict columns do not coerce")
+}
+
+func TestColumnList_Suggest(t *testing.T) {
+	columns := ColumnList{
+		{Name: "Price"},
+		{Name: "Category"},
+		{Name: "Name"},
+	}
+
+	assert.Equal(t, "Price", columns.Suggest("Prce"))
+	assert.Equal(t, "Price", columns.Suggest("pirce"))
+	assert.Equal(t, "Category", columns.Suggest("categroy"))
+	assert.Equal(t, "Name", columns.Suggest("nmae"))
+	assert.Empty(t, columns.Suggest("Warehouse"), "nothing close enough")
+	assert.Empty(t, ColumnList{}.Suggest("Price"))
+}
+
+func TestColumn_ValueType(t *testing.T) {
+	assert.Equal(t, ValueTypeNumeric, (&Column{Validate: "number"}).ValueType())
+	assert.Equal(t, ValueTypeDate, (&Column{Validate: "date"}).ValueType())
+	assert.Equal(t, ValueTypeDate, (&Column{Due: true}).ValueType())
+	assert.Equal(t, ValueTypeText, (&Column{Validate: "email"}).ValueType())
+
+	columns := ColumnList{{Name: "Price", Validate: "number"}, {Name: "Name"}}
+	assert.Equal(t, map[string]string{"Price": ValueTypeNumeric}, columns.ValueTypes())
+}
+
+func TestParseSeverity(t *testing.T) {
+	for name, want := range map[string]string{
+		"error":   SeverityError,
+		"warning": SeverityWarning,
+		"warn":    SeverityWarning,
+		" WARN ":  SeverityWarning,
+	} {
+		got, ok := ParseSeverity(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+	_, ok := ParseSeverity("info")
+	assert.False(t, ok)
+}
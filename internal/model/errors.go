@@ -9,6 +9,9 @@ var (
 	ErrStashExists      = errors.New("stash already exists")
 	ErrRecordNotFound   = errors.New("record not found")
 	ErrRecordDeleted    = errors.New("record is deleted")
+	ErrRecordArchived   = errors.New("record is archived")
+	ErrRecordNotArchived = errors.New("record is not archived")
+	ErrRecordNotAssigned = errors.New("record is not assigned")
 	ErrColumnNotFound   = errors.New("column not found")
 	ErrColumnExists     = errors.New("column already exists")
 	ErrInvalidID        = errors.New("invalid record ID")
@@ -23,4 +26,7 @@ var (
 	ErrHasChildren       = errors.New("record has children")
 	ErrValidationFailed  = errors.New("validation failed")
 	ErrInvalidValidation = errors.New("invalid validation type")
+	ErrComputedColumn    = errors.New("column is computed")
+	ErrRecordExists      = errors.New("record already exists")
+	ErrPermissionDenied  = errors.New("permission denied")
 )
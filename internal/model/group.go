@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GroupPrefix marks a column group where columns are listed, as in
+// "--columns @pricing".
+const GroupPrefix = "@"
+
+// Group name validation:
+// - Must start with a letter
+// - Can contain letters, numbers, hyphens, underscores
+// - Max 64 characters
+var groupNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+
+// ColumnGroup is a named slice of a stash's columns, so a wide stash can
+// be viewed a few relevant columns at a time.
+type ColumnGroup struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
+
+// ValidateGroupName checks if a column group name is valid.
+func ValidateGroupName(name string) error {
+	if !groupNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid group name '%s': must start with a letter and contain only letters, numbers, hyphens, and underscores", name)
+	}
+	return nil
+}
+
+// Group returns the column group with the given name (case-insensitive),
+// or nil if there is none.
+func (s *Stash) Group(name string) *ColumnGroup {
+	for i := range s.Groups {
+		if strings.EqualFold(s.Groups[i].Name, name) {
+			return &s.Groups[i]
+		}
+	}
+	return nil
+}
+
+// SetGroup adds or replaces a column group, keeping groups sorted by name.
+func (s *Stash) SetGroup(group ColumnGroup) {
+	if existing := s.Group(group.Name); existing != nil {
+		*existing = group
+		return
+	}
+	s.Groups = append(s.Groups, group)
+	sort.SliceStable(s.Groups, func(i, j int) bool {
+		return strings.ToLower(s.Groups[i].Name) < strings.ToLower(s.Groups[j].Name)
+	})
+}
+
+// RemoveGroup removes a column group. Returns false if there is no group
+// with that name.
+func (s *Stash) RemoveGroup(name string) bool {
+	for i := range s.Groups {
+		if strings.EqualFold(s.Groups[i].Name, name) {
+			s.Groups = append(s.Groups[:i], s.Groups[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GroupName returns the group named by a column list entry such as
+// "@pricing", and whether the entry names a group at all.
+func GroupName(entry string) (string, bool) {
+	if !strings.HasPrefix(entry, GroupPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(entry, GroupPrefix), true
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStash_Groups(t *testing.T) {
+	stash := &Stash{}
+	stash.SetGroup(ColumnGroup{Name: "pricing", Columns: []string{"Price"}})
+	stash.SetGroup(ColumnGroup{Name: "contact", Columns: []string{"Email"}})
+	stash.SetGroup(ColumnGroup{Name: "Pricing", Columns: []string{"Price", "Cost"}})
+
+	require.Len(t, stash.Groups, 2)
+	assert.Equal(t, "contact", stash.Groups[0].Name)
+	assert.Equal(t, []string{"Price", "Cost"}, stash.Group("PRICING").Columns)
+	assert.Nil(t, stash.Group("missing"))
+
+	assert.True(t, stash.RemoveGroup("contact"))
+	assert.False(t, stash.RemoveGroup("contact"))
+	assert.Len(t, stash.Groups, 1)
+}
+
+func TestGroupName(t *testing.T) {
+	name, ok := GroupName("@pricing")
+	assert.True(t, ok)
+	assert.Equal(t, "pricing", name)
+
+	_, ok = GroupName("Price")
+	assert.False(t, ok)
+
+	assert.NoError(t, ValidateGroupName("q3-pricing"))
+	assert.Error(t, ValidateGroupName("3q"))
+	assert.Error(t, ValidateGroupName("@pricing"))
+}
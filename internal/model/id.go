@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Base36 character set (lowercase)
@@ -15,10 +16,37 @@ const base36Chars = "0123456789abcdefghijklmnopqrstuvwxyz"
 // IDLength is the length of the random part of an ID
 const IDLength = 4
 
-// ID format regex for validation
+// ID strategies for generating root record IDs
+const (
+	IDStrategyRandom     = "random"     // inv-ex4j (default)
+	IDStrategySequential = "sequential" // inv-0001, inv-0002
+	IDStrategyULID       = "ulid"       // inv-01j9x3k2v8q0r5m7n4c6t1w9yz (time-sortable)
+	IDStrategyTemplate   = "template"   // inv-20240115-0001 from "{date}-{seq}"
+)
+
+// IDStrategies lists the supported ID strategies.
+var IDStrategies = []string{IDStrategyRandom, IDStrategySequential, IDStrategyULID, IDStrategyTemplate}
+
+// SeqWidth is the minimum number of digits in a sequence number (zero-padded).
+const SeqWidth = 4
+
+// Crockford base32 alphabet (lowercase) used for ULIDs
+const crockfordChars = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// Characters allowed in an expanded ID template. Dots are reserved for child IDs.
+var idTemplateRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ID format regex for validation of random (default strategy) IDs
 // Matches: prefix-xxxx or prefix-xxxx.N or prefix-xxxx.N.M etc.
 var idRegex = regexp.MustCompile(`^[a-z]{2,4}-[0-9a-z]{4}(\.\d+)*$`)
 
+// ID format regexes for the other strategies, including child suffixes
+var (
+	seqIDRegex      = regexp.MustCompile(`^[a-z]{2,4}-\d{4,}(\.\d+)*$`)
+	ulidIDRegex     = regexp.MustCompile(`^[a-z]{2,4}-[0-9a-hjkmnp-tv-z]{26}(\.\d+)*$`)
+	templateIDRegex = regexp.MustCompile(`^[a-z]{2,4}-[a-z0-9_-]+(\.\d+)*$`)
+)
+
 // GenerateID creates a new random ID with the given prefix.
 // Format: <prefix><4-char-base36>
 // Example: inv-ex4j
@@ -36,6 +64,110 @@ func GenerateID(prefix string) (string, error) {
 	return prefix + random, nil
 }
 
+// GenerateULID creates a time-sortable ID with the given prefix.
+// Format: <prefix><26-char lowercase ULID>
+// IDs generated later sort after earlier ones (millisecond precision).
+func GenerateULID(prefix string, t time.Time) (string, error) {
+	if err := ValidatePrefix(prefix); err != nil {
+		return "", err
+	}
+
+	// 48-bit millisecond timestamp followed by 80 random bits
+	var data [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		data[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := rand.Read(data[6:]); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+
+	// Encode 128 bits as 26 base32 characters
+	n := new(big.Int).SetBytes(data[:])
+	base := big.NewInt(32)
+	mod := new(big.Int)
+	encoded := make([]byte, 26)
+	for i := len(encoded) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		encoded[i] = crockfordChars[mod.Int64()]
+	}
+
+	return prefix + string(encoded), nil
+}
+
+// FormatSeq formats a sequence number, zero-padded to SeqWidth digits.
+// Example: 7 -> "0007"
+func FormatSeq(seq int) string {
+	return fmt.Sprintf("%0*d", SeqWidth, seq)
+}
+
+// ValidateIDStrategy checks an ID strategy and, for the template strategy,
+// its template.
+func ValidateIDStrategy(strategy, template string) error {
+	switch strategy {
+	case "", IDStrategyRandom, IDStrategySequential, IDStrategyULID:
+		if template != "" {
+			return fmt.Errorf("an ID template requires the %s strategy", IDStrategyTemplate)
+		}
+		return nil
+	case IDStrategyTemplate:
+		if template == "" {
+			return fmt.Errorf("the %s strategy requires an ID template", IDStrategyTemplate)
+		}
+		if !strings.Contains(template, "{seq}") && !strings.Contains(template, "{rand}") {
+			return fmt.Errorf("ID template must contain {seq} or {rand} to produce unique IDs")
+		}
+		if strings.Count(template, "{seq}") > 1 {
+			return fmt.Errorf("ID template may contain {seq} only once")
+		}
+		head, tail, _, err := ExpandIDTemplate(template, time.Now())
+		if err != nil {
+			return err
+		}
+		if !idTemplateRegex.MatchString(head + "0" + tail) {
+			return fmt.Errorf("ID template may only contain lowercase letters, digits, '-', '_', and placeholders")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown ID strategy '%s' (valid: %s)", strategy, strings.Join(IDStrategies, ", "))
+	}
+}
+
+// ExpandIDTemplate expands the placeholders in an ID template for time t.
+// The result is split around {seq}: head is the text before it and tail the
+// text after it. hasSeq reports whether the template contains {seq}.
+//
+// Placeholders:
+//
+//	{seq}    Next sequence number, zero-padded (0001)
+//	{date}   Date as YYYYMMDD
+//	{year}   Four-digit year
+//	{month}  Two-digit month
+//	{day}    Two-digit day
+//	{rand}   Four random base36 characters
+func ExpandIDTemplate(template string, t time.Time) (head, tail string, hasSeq bool, err error) {
+	random, err := randomBase36(IDLength)
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to generate random ID: %w", err)
+	}
+
+	expanded := strings.NewReplacer(
+		"{date}", t.Format("20060102"),
+		"{year}", t.Format("2006"),
+		"{month}", t.Format("01"),
+		"{day}", t.Format("02"),
+		"{rand}", random,
+	).Replace(template)
+
+	if strings.ContainsAny(strings.ReplaceAll(expanded, "{seq}", ""), "{}") {
+		return "", "", false, fmt.Errorf("unknown placeholder in ID template '%s'", template)
+	}
+
+	head, tail, hasSeq = strings.Cut(expanded, "{seq}")
+	return head, tail, hasSeq, nil
+}
+
 // GenerateChildID creates a child ID from a parent ID.
 // Format: <parent-id>.<next-seq>
 // Example: inv-ex4j.1, inv-ex4j.2, inv-ex4j.1.1
@@ -43,7 +175,9 @@ func GenerateChildID(parentID string, nextSeq int) string {
 	return fmt.Sprintf("%s.%d", parentID, nextSeq)
 }
 
-// ValidateID checks if an ID is valid.
+// ValidateID checks if an ID has the format of the random (default) ID
+// strategy. IDs from the other strategies fail this check; use
+// ValidateStrategyID when the stash's strategy is known.
 func ValidateID(id string) error {
 	if !idRegex.MatchString(id) {
 		return ErrInvalidID
@@ -51,7 +185,29 @@ func ValidateID(id string) error {
 	return nil
 }
 
-// ParseID extracts components from an ID.
+// ValidateStrategyID checks if an ID has the format produced by the given
+// ID strategy. An empty strategy means random.
+func ValidateStrategyID(id, strategy string) error {
+	var re *regexp.Regexp
+	switch strategy {
+	case "", IDStrategyRandom:
+		re = idRegex
+	case IDStrategySequential:
+		re = seqIDRegex
+	case IDStrategyULID:
+		re = ulidIDRegex
+	case IDStrategyTemplate:
+		re = templateIDRegex
+	default:
+		return fmt.Errorf("unknown ID strategy '%s' (valid: %s)", strategy, strings.Join(IDStrategies, ", "))
+	}
+	if !re.MatchString(id) {
+		return ErrInvalidID
+	}
+	return nil
+}
+
+// ParseID extracts components from a random (default strategy) ID.
 // Returns prefix, base (random part), and sequence numbers.
 // Example: "inv-ex4j.1.2" -> "inv-", "ex4j", [1, 2]
 func ParseID(id string) (prefix, base string, seq []int, err error) {
@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -301,3 +302,114 @@ func TestGenerateID_MultiplePrefixes(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateULID(t *testing.T) {
+	t.Run("generates 26-char lowercase ULID with prefix", func(t *testing.T) {
+		id, err := GenerateULID("inv-", time.Now())
+		require.NoError(t, err)
+		assert.Regexp(t, `^inv-[0-9a-hjkmnp-tv-z]{26}$`, id)
+	})
+
+	t.Run("later IDs sort after earlier ones", func(t *testing.T) {
+		base := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+		earlier, err := GenerateULID("inv-", base)
+		require.NoError(t, err)
+		later, err := GenerateULID("inv-", base.Add(time.Millisecond))
+		require.NoError(t, err)
+		assert.Less(t, earlier, later)
+	})
+
+	t.Run("rejects invalid prefix", func(t *testing.T) {
+		_, err := GenerateULID("invalid", time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateIDStrategy(t *testing.T) {
+	valid := []struct{ strategy, template string }{
+		{"", ""},
+		{IDStrategyRandom, ""},
+		{IDStrategySequential, ""},
+		{IDStrategyULID, ""},
+		{IDStrategyTemplate, "{date}-{seq}"},
+		{IDStrategyTemplate, "q{rand}"},
+	}
+	for _, tc := range valid {
+		t.Run("valid: "+tc.strategy+" "+tc.template, func(t *testing.T) {
+			assert.NoError(t, ValidateIDStrategy(tc.strategy, tc.template))
+		})
+	}
+
+	invalid := []struct{ strategy, template string }{
+		{"uuid", ""},
+		{IDStrategyTemplate, ""},
+		{IDStrategySequential, "{seq}"},
+		{IDStrategyTemplate, "{date}"},
+		{IDStrategyTemplate, "{seq}-{seq}"},
+		{IDStrategyTemplate, "{seq}.x"},
+		{IDStrategyTemplate, "{seq}-{week}"},
+		{IDStrategyTemplate, "Item{seq}"},
+	}
+	for _, tc := range invalid {
+		t.Run("invalid: "+tc.strategy+" "+tc.template, func(t *testing.T) {
+			assert.Error(t, ValidateIDStrategy(tc.strategy, tc.template))
+		})
+	}
+}
+
+func TestValidateStrategyID(t *testing.T) {
+	ulid, err := GenerateULID("inv-", time.Now())
+	require.NoError(t, err)
+
+	valid := []struct{ id, strategy string }{
+		{"inv-ex4j", ""},
+		{"inv-ex4j.1", IDStrategyRandom},
+		{"inv-0001", IDStrategySequential},
+		{"inv-12345.2", IDStrategySequential},
+		{ulid, IDStrategyULID},
+		{ulid + ".1", IDStrategyULID},
+		{"inv-20240115-0001", IDStrategyTemplate},
+		{"inv-q_ex4j.3", IDStrategyTemplate},
+	}
+	for _, tc := range valid {
+		t.Run("valid: "+tc.strategy+" "+tc.id, func(t *testing.T) {
+			assert.NoError(t, ValidateStrategyID(tc.id, tc.strategy))
+		})
+	}
+
+	invalid := []struct{ id, strategy string }{
+		{"inv-0001x", IDStrategyRandom},
+		{"inv-001", IDStrategySequential},
+		{"inv-ex4j", IDStrategySequential},
+		{"inv-ex4j", IDStrategyULID},
+		{"inv-01j9x3k2v8q0r5m7n4c6t1w9yi", IDStrategyULID},
+		{"inv-Item-0001", IDStrategyTemplate},
+		{"inv-0001.x", IDStrategyTemplate},
+		{"inv-ex4j", "uuid"},
+	}
+	for _, tc := range invalid {
+		t.Run("invalid: "+tc.strategy+" "+tc.id, func(t *testing.T) {
+			assert.Error(t, ValidateStrategyID(tc.id, tc.strategy))
+		})
+	}
+
+	t.Run("ValidateID only accepts random IDs", func(t *testing.T) {
+		assert.Error(t, ValidateID(ulid))
+		assert.Error(t, ValidateID("inv-20240115-0001"))
+	})
+}
+
+func TestExpandIDTemplate(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	head, tail, hasSeq, err := ExpandIDTemplate("{year}{month}{day}-{seq}-x", now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240115-", head)
+	assert.Equal(t, "-x", tail)
+	assert.True(t, hasSeq)
+
+	head, _, hasSeq, err = ExpandIDTemplate("{date}{rand}", now)
+	require.NoError(t, err)
+	assert.Regexp(t, `^20240115[0-9a-z]{4}$`, head)
+	assert.False(t, hasSeq)
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Default size limits of records, applied to stashes that do not set their
+// own. Every read of the cache and every copy of records.jsonl carries a
+// record's fields, so large values belong in attachments (see 'stash
+// attach') rather than in text fields.
+const (
+	DefaultMaxRecordSize int64 = 1 << 20   // 1 MB of fields in one record
+	DefaultMaxFieldSize  int64 = 256 << 10 // 256 KB in one field
+)
+
+// NoSizeLimit turns a size limit off.
+const NoSizeLimit int64 = -1
+
+// ErrRecordTooLarge is the error a record over its stash's size limits
+// is rejected with.
+var ErrRecordTooLarge = newError("record too large")
+
+// SizeLimits caps the size of a stash's records. Zero means the default
+// limit, and NoSizeLimit no limit.
+type SizeLimits struct {
+	MaxRecord int64 `json:"max_record,omitempty"` // Largest record, as the JSON of its fields
+	MaxField  int64 `json:"max_field,omitempty"`  // Largest single field value
+}
+
+// IsEmpty reports whether the limits are all defaults.
+func (l *SizeLimits) IsEmpty() bool {
+	return l == nil || (l.MaxRecord == 0 && l.MaxField == 0)
+}
+
+// RecordSizeLimits returns the stash's largest allowed record and field in
+// bytes, with defaults filled in. A limit of NoSizeLimit is off.
+func (s *Stash) RecordSizeLimits() (maxRecord, maxField int64) {
+	maxRecord, maxField = DefaultMaxRecordSize, DefaultMaxFieldSize
+	if s.Limits != nil {
+		if s.Limits.MaxRecord != 0 {
+			maxRecord = s.Limits.MaxRecord
+		}
+		if s.Limits.MaxField != 0 {
+			maxField = s.Limits.MaxField
+		}
+	}
+	return maxRecord, maxField
+}
+
+// SizeLimitError describes a record over a size limit. Field is empty when
+// the record as a whole is too large.
+type SizeLimitError struct {
+	RecordID string
+	Field    string
+	Size     int64
+	Limit    int64
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s: field '%s' is %s, over the limit of %s", ErrRecordTooLarge, e.Field, FormatSize(e.Size), FormatSize(e.Limit))
+	}
+	return fmt.Sprintf("%s: fields total %s, over the limit of %s", ErrRecordTooLarge, FormatSize(e.Size), FormatSize(e.Limit))
+}
+
+func (e *SizeLimitError) Unwrap() error {
+	return ErrRecordTooLarge
+}
+
+// FieldSize returns the size of a field value in bytes: the length of a
+// string, or of the JSON of any other value.
+func FieldSize(value interface{}) int64 {
+	return int64(len(fieldBytes(value)))
+}
+
+// fieldBytes returns a field value as text, as it is measured.
+func fieldBytes(value interface{}) []byte {
+	if s, ok := value.(string); ok {
+		return []byte(s)
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// RecordSize returns the size of a record's fields in bytes, as the JSON
+// stash writes them.
+func RecordSize(record *Record) int64 {
+	data, _ := json.Marshal(record.Fields)
+	return int64(len(data))
+}
+
+// CheckSize rejects a record over the stash's size limits, naming the
+// largest field over the field limit before checking the whole record.
+// previous is the record's current version when it is being updated, or
+// nil: fields it already held, and a total no larger than its own, pass,
+// so records written before a limit was lowered can still be edited.
+func (s *Stash) CheckSize(record, previous *Record) error {
+	maxRecord, maxField := s.RecordSizeLimits()
+
+	if maxField > 0 {
+		names := make([]string, 0, len(record.Fields))
+		for name := range record.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var largest *SizeLimitError
+		for _, name := range names {
+			value := fieldBytes(record.Fields[name])
+			size := int64(len(value))
+			if size <= maxField || (largest != nil && size <= largest.Size) {
+				continue
+			}
+			if previous != nil && bytes.Equal(fieldBytes(previous.Fields[name]), value) {
+				continue
+			}
+			largest = &SizeLimitError{RecordID: record.ID, Field: name, Size: size, Limit: maxField}
+		}
+		if largest != nil {
+			return largest
+		}
+	}
+	if maxRecord > 0 {
+		size := RecordSize(record)
+		if size > maxRecord && (previous == nil || size > RecordSize(previous)) {
+			return &SizeLimitError{RecordID: record.ID, Size: size, Limit: maxRecord}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeLimits(t *testing.T) {
+	record := func(fields map[string]interface{}) *Record {
+		return &Record{ID: "inv-ex4j", Fields: fields}
+	}
+
+	t.Run("defaults apply without limits", func(t *testing.T) {
+		stash := &Stash{Name: "inventory"}
+		maxRecord, maxField := stash.RecordSizeLimits()
+		assert.Equal(t, DefaultMaxRecordSize, maxRecord)
+		assert.Equal(t, DefaultMaxFieldSize, maxField)
+		assert.True(t, stash.Limits.IsEmpty())
+
+		assert.NoError(t, stash.CheckSize(record(map[string]interface{}{"Name": "Laptop"}), nil))
+		err := stash.CheckSize(record(map[string]interface{}{"Notes": strings.Repeat("x", int(DefaultMaxFieldSize)+1)}), nil)
+		assert.True(t, errors.Is(err, ErrRecordTooLarge))
+	})
+
+	t.Run("largest field over the limit is named", func(t *testing.T) {
+		stash := &Stash{Limits: &SizeLimits{MaxField: 10}}
+		err := stash.CheckSize(record(map[string]interface{}{
+			"A": strings.Repeat("a", 11),
+			"B": strings.Repeat("b", 20),
+			"C": "short",
+		}), nil)
+		var sizeErr *SizeLimitError
+		require.True(t, errors.As(err, &sizeErr))
+		assert.Equal(t, "B", sizeErr.Field)
+		assert.Equal(t, int64(20), sizeErr.Size)
+		assert.Equal(t, int64(10), sizeErr.Limit)
+		assert.Contains(t, err.Error(), "field 'B' is 20 B, over the limit of 10 B")
+	})
+
+	t.Run("whole record", func(t *testing.T) {
+		stash := &Stash{Limits: &SizeLimits{MaxRecord: 30, MaxField: NoSizeLimit}}
+		err := stash.CheckSize(record(map[string]interface{}{"A": strings.Repeat("a", 20), "B": strings.Repeat("b", 20)}), nil)
+		var sizeErr *SizeLimitError
+		require.True(t, errors.As(err, &sizeErr))
+		assert.Empty(t, sizeErr.Field)
+		assert.Contains(t, err.Error(), "fields total")
+	})
+
+	t.Run("non-string values are measured as JSON", func(t *testing.T) {
+		assert.Equal(t, int64(5), FieldSize("hello"))
+		assert.Equal(t, int64(9), FieldSize([]interface{}{"a", "b"}))
+		assert.Equal(t, int64(3), FieldSize(999.0))
+	})
+
+	t.Run("unchanged oversized fields can still be edited", func(t *testing.T) {
+		stash := &Stash{Limits: &SizeLimits{MaxField: 10, MaxRecord: 40}}
+		big := strings.Repeat("x", 30)
+		previous := record(map[string]interface{}{"Notes": big, "Status": "open"})
+
+		assert.NoError(t, stash.CheckSize(record(map[string]interface{}{"Notes": big, "Status": "done"}), previous))
+		assert.Error(t, stash.CheckSize(record(map[string]interface{}{"Notes": big + "y", "Status": "open"}), previous))
+		// Growing an oversized record is rejected
+		assert.Error(t, stash.CheckSize(record(map[string]interface{}{"Notes": big, "Status": "in progress"}), previous))
+	})
+
+	t.Run("limits can be turned off", func(t *testing.T) {
+		stash := &Stash{Limits: &SizeLimits{MaxRecord: NoSizeLimit, MaxField: NoSizeLimit}}
+		assert.NoError(t, stash.CheckSize(record(map[string]interface{}{"Notes": strings.Repeat("x", 2<<20)}), nil))
+	})
+}
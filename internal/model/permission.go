@@ -0,0 +1,137 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Record operations that permission rules can allow.
+const (
+	PermCreate  = "create"  // add, import, template instantiation
+	PermUpdate  = "update"  // set, bulk-set, move, assign, unassign
+	PermDelete  = "delete"  // rm, purge
+	PermRestore = "restore" // restore
+	PermArchive = "archive" // archive, unarchive
+	PermAttach  = "attach"  // attach, detach
+)
+
+// PermissionOperations lists every operation a rule can allow, in display order.
+var PermissionOperations = []string{PermCreate, PermUpdate, PermDelete, PermRestore, PermArchive, PermAttach}
+
+// AnyActor is the rule actor that applies to actors without a rule of their own.
+const AnyActor = "*"
+
+// Permission restricts what an actor may write in a stash. An actor with a
+// rule may only perform the listed operations and, when creating or
+// updating records, only set the listed columns. An empty list means no
+// restriction on that axis.
+type Permission struct {
+	Actor      string   `json:"actor"`
+	Operations []string `json:"operations,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
+}
+
+// PermissionError describes a write the acting actor is not allowed to make.
+type PermissionError struct {
+	Actor     string
+	Operation string
+	Column    string // set when a column is not writable
+}
+
+func (e *PermissionError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("%s: actor '%s' may not set column '%s'", ErrPermissionDenied, e.Actor, e.Column)
+	}
+	return fmt.Sprintf("%s: actor '%s' may not %s records", ErrPermissionDenied, e.Actor, e.Operation)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return ErrPermissionDenied
+}
+
+// ValidatePermissionOperation checks that op is a known operation.
+func ValidatePermissionOperation(op string) error {
+	for _, known := range PermissionOperations {
+		if op == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operation '%s' (valid: %s)", op, strings.Join(PermissionOperations, ", "))
+}
+
+// PermissionFor returns the rule governing actor: its own rule if it has
+// one, otherwise the AnyActor rule, otherwise nil (unrestricted).
+func (s *Stash) PermissionFor(actor string) *Permission {
+	var fallback *Permission
+	for i := range s.Permissions {
+		switch s.Permissions[i].Actor {
+		case actor:
+			return &s.Permissions[i]
+		case AnyActor:
+			fallback = &s.Permissions[i]
+		}
+	}
+	return fallback
+}
+
+// SetPermission adds or replaces the rule for an actor.
+func (s *Stash) SetPermission(perm Permission) {
+	for i := range s.Permissions {
+		if s.Permissions[i].Actor == perm.Actor {
+			s.Permissions[i] = perm
+			return
+		}
+	}
+	s.Permissions = append(s.Permissions, perm)
+	sort.SliceStable(s.Permissions, func(i, j int) bool {
+		return s.Permissions[i].Actor < s.Permissions[j].Actor
+	})
+}
+
+// RemovePermission removes the rule for an actor. Returns false if the
+// actor had no rule.
+func (s *Stash) RemovePermission(actor string) bool {
+	for i := range s.Permissions {
+		if s.Permissions[i].Actor == actor {
+			s.Permissions = append(s.Permissions[:i], s.Permissions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPermission reports whether actor may perform op, setting the given
+// columns. It returns a *PermissionError when the write is not allowed.
+// Every write path, in the CLI or elsewhere, should call it before
+// changing a record.
+func (s *Stash) CheckPermission(actor, op string, columns []string) error {
+	perm := s.PermissionFor(actor)
+	if perm == nil {
+		return nil
+	}
+
+	if len(perm.Operations) > 0 && !ContainsFold(perm.Operations, op) {
+		return &PermissionError{Actor: actor, Operation: op}
+	}
+
+	if len(perm.Columns) > 0 {
+		for _, col := range columns {
+			if !ContainsFold(perm.Columns, col) {
+				return &PermissionError{Actor: actor, Operation: op, Column: col}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ContainsFold reports whether list contains s, ignoring case.
+func ContainsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
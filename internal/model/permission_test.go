@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStash_CheckPermission(t *testing.T) {
+	stash := &Stash{Name: "tasks"}
+	stash.SetPermission(Permission{Actor: "reviewer", Operations: []string{PermUpdate}, Columns: []string{"Status"}})
+	stash.SetPermission(Permission{Actor: "ingest", Operations: []string{PermCreate}})
+
+	t.Run("actors without a rule are unrestricted", func(t *testing.T) {
+		assert.NoError(t, stash.CheckPermission("alice", PermDelete, nil))
+	})
+
+	t.Run("allowed operation and column", func(t *testing.T) {
+		assert.NoError(t, stash.CheckPermission("reviewer", PermUpdate, []string{"status"}))
+	})
+
+	t.Run("denied operation", func(t *testing.T) {
+		err := stash.CheckPermission("reviewer", PermDelete, nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrPermissionDenied))
+
+		var permErr *PermissionError
+		require.True(t, errors.As(err, &permErr))
+		assert.Equal(t, PermDelete, permErr.Operation)
+		assert.Empty(t, permErr.Column)
+	})
+
+	t.Run("denied column", func(t *testing.T) {
+		err := stash.CheckPermission("reviewer", PermUpdate, []string{"Status", "Priority"})
+		var permErr *PermissionError
+		require.True(t, errors.As(err, &permErr))
+		assert.Equal(t, "Priority", permErr.Column)
+	})
+
+	t.Run("rule without columns allows any column", func(t *testing.T) {
+		assert.NoError(t, stash.CheckPermission("ingest", PermCreate, []string{"Name", "Priority"}))
+	})
+
+	t.Run("wildcard rule applies to actors without their own", func(t *testing.T) {
+		withDefault := &Stash{Permissions: append([]Permission{}, stash.Permissions...)}
+		withDefault.SetPermission(Permission{Actor: AnyActor, Operations: []string{PermCreate}})
+
+		assert.Error(t, withDefault.CheckPermission("alice", PermUpdate, nil))
+		assert.NoError(t, withDefault.CheckPermission("alice", PermCreate, nil))
+		assert.NoError(t, withDefault.CheckPermission("reviewer", PermUpdate, []string{"Status"}))
+	})
+}
+
+func TestStash_SetRemovePermission(t *testing.T) {
+	stash := &Stash{}
+	stash.SetPermission(Permission{Actor: "reviewer", Operations: []string{PermUpdate}})
+	stash.SetPermission(Permission{Actor: "reviewer", Operations: []string{PermCreate}})
+	require.Len(t, stash.Permissions, 1)
+	assert.Equal(t, []string{PermCreate}, stash.Permissions[0].Operations)
+
+	assert.True(t, stash.RemovePermission("reviewer"))
+	assert.False(t, stash.RemovePermission("reviewer"))
+	assert.Empty(t, stash.Permissions)
+}
+
+func TestValidatePermissionOperation(t *testing.T) {
+	for _, op := range PermissionOperations {
+		assert.NoError(t, ValidatePermissionOperation(op))
+	}
+	assert.Error(t, ValidatePermissionOperation("drop"))
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"reflect"
+	"time"
+)
+
+// FieldProvenance tells where the current value of a field came from: the
+// operation that last changed it, who made it, and the source it named.
+type FieldProvenance struct {
+	SetAt     time.Time `json:"set_at"`
+	SetBy     string    `json:"set_by"`
+	Operation string    `json:"op"`
+	Source    string    `json:"source,omitempty"`
+}
+
+// Provenance returns the provenance of each field a record holds, given
+// the record's operations in log order. A field's provenance is the last
+// operation that changed its value; operations that leave a value as it
+// was (archive, assign, ...) do not count. Fields no longer set have none.
+func Provenance(history []*Record) map[string]FieldProvenance {
+	provenance := make(map[string]FieldProvenance)
+	var prev map[string]interface{}
+	for _, op := range history {
+		for name, value := range op.Fields {
+			if value == nil {
+				continue
+			}
+			if old, ok := prev[name]; ok && reflect.DeepEqual(old, value) {
+				continue
+			}
+			provenance[name] = FieldProvenance{
+				SetAt:     op.UpdatedAt,
+				SetBy:     op.UpdatedBy,
+				Operation: op.Operation,
+				Source:    op.Source,
+			}
+		}
+		for name := range provenance {
+			if op.Fields[name] == nil {
+				delete(provenance, name)
+			}
+		}
+		prev = op.Fields
+	}
+	return provenance
+}
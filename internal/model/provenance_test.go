@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	t0 := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	history := []*Record{
+		{ID: "inv-ex4j", Operation: OpCreate, UpdatedAt: t0, UpdatedBy: "importer", Source: "vendors.csv",
+			Fields: map[string]interface{}{"Name": "Laptop", "Price": "999", "Notes": "old"}},
+		{ID: "inv-ex4j", Operation: OpUpdate, UpdatedAt: t0.Add(time.Hour), UpdatedBy: "alice",
+			Fields: map[string]interface{}{"Name": "Laptop", "Price": "899"}},
+		{ID: "inv-ex4j", Operation: OpArchive, UpdatedAt: t0.Add(2 * time.Hour), UpdatedBy: "bob", Source: "cleanup",
+			Fields: map[string]interface{}{"Name": "Laptop", "Price": "899"}},
+	}
+
+	provenance := Provenance(history)
+	require.Len(t, provenance, 2)
+
+	name := provenance["Name"]
+	assert.Equal(t, "importer", name.SetBy)
+	assert.Equal(t, "vendors.csv", name.Source)
+	assert.Equal(t, OpCreate, name.Operation)
+	assert.Equal(t, t0, name.SetAt)
+
+	price := provenance["Price"]
+	assert.Equal(t, "alice", price.SetBy)
+	assert.Empty(t, price.Source)
+	assert.Equal(t, OpUpdate, price.Operation)
+
+	_, ok := provenance["Notes"]
+	assert.False(t, ok, "a field no longer set has no provenance")
+}
+
+func TestRecord_SourceRoundTrip(t *testing.T) {
+	record := &Record{ID: "inv-ex4j", Operation: OpCreate, Source: "run-42", Fields: map[string]interface{}{"Name": "Laptop"}}
+	data, err := record.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"_source":"run-42"`)
+
+	var decoded Record
+	require.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, "run-42", decoded.Source)
+	assert.NotContains(t, decoded.Fields, "_source")
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrThrottled is the error a write is rejected with when its agent has
+// used up its write rate.
+var ErrThrottled = newError("write rate limit exceeded")
+
+// ErrQuotaExceeded is the error a new record is rejected with when the
+// stash holds as many records as its quota allows.
+var ErrQuotaExceeded = newError("record quota exceeded")
+
+// Quotas keep one agent from flooding a shared stash. Zero means no limit.
+type Quotas struct {
+	MaxRecords      int `json:"max_records,omitempty"`       // Active records the stash may hold
+	WritesPerMinute int `json:"writes_per_minute,omitempty"` // Writes each agent may make a minute
+	// Agents sets the writes a minute of named agents, in place of
+	// WritesPerMinute; an agent set to 0 is not limited.
+	Agents map[string]int `json:"agents,omitempty"`
+}
+
+// IsEmpty reports whether the quotas set no limits.
+func (q *Quotas) IsEmpty() bool {
+	return q == nil || (q.MaxRecords == 0 && q.WritesPerMinute == 0 && len(q.Agents) == 0)
+}
+
+// RecordQuota returns the most active records the stash may hold, or 0
+// for no quota.
+func (s *Stash) RecordQuota() int {
+	if s.Quotas == nil {
+		return 0
+	}
+	return s.Quotas.MaxRecords
+}
+
+// WriteRate returns the writes an agent may make a minute, or 0 for no
+// limit.
+func (s *Stash) WriteRate(agent string) int {
+	if s.Quotas == nil {
+		return 0
+	}
+	if rate, ok := s.Quotas.Agents[agent]; ok {
+		return rate
+	}
+	return s.Quotas.WritesPerMinute
+}
+
+// ThrottleError describes a write rejected by an agent's write rate limit.
+type ThrottleError struct {
+	Stash      string
+	Agent      string
+	Limit      int           // Writes a minute
+	RetryAfter time.Duration // Until the agent may write again
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%s: %s may write to %s %d time(s) a minute; retry in %s",
+		ErrThrottled, e.Agent, e.Stash, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return ErrThrottled
+}
+
+// QuotaError describes a record rejected by its stash's record quota.
+type QuotaError struct {
+	Stash   string
+	Records int
+	Limit   int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s holds %d of %d record(s)", ErrQuotaExceeded, e.Stash, e.Records, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
@@ -12,26 +12,53 @@ import (
 
 // Operation types for JSONL records
 const (
-	OpCreate  = "create"
-	OpUpdate  = "update"
-	OpDelete  = "delete"
-	OpRestore = "restore"
+	OpCreate    = "create"
+	OpUpdate    = "update"
+	OpDelete    = "delete"
+	OpRestore   = "restore"
+	OpArchive   = "archive"
+	OpUnarchive = "unarchive"
+	OpAssign    = "assign"
+	OpUnassign  = "unassign"
 )
 
+// IsStateOp returns true if an operation records the whole state of its
+// record, so replaying a log takes the record's state from it. A delete
+// is not one: it only marks the state before it deleted.
+func IsStateOp(op string) bool {
+	switch op {
+	case OpCreate, OpUpdate, OpRestore, OpArchive, OpUnarchive, OpAssign, OpUnassign:
+		return true
+	}
+	return false
+}
+
 // Record represents a single record in a stash.
 type Record struct {
-	ID        string     `json:"_id"`
-	Hash      string     `json:"_hash"`
-	ParentID  string     `json:"_parent,omitempty"`
-	CreatedAt time.Time  `json:"_created_at"`
-	CreatedBy string     `json:"_created_by"`
-	UpdatedAt time.Time  `json:"_updated_at"`
-	UpdatedBy string     `json:"_updated_by"`
-	Branch    string     `json:"_branch,omitempty"`
-	DeletedAt *time.Time `json:"_deleted_at,omitempty"`
-	DeletedBy string     `json:"_deleted_by,omitempty"`
-	Operation string     `json:"_op"`
-	Fields    map[string]interface{}
+	ID         string     `json:"_id"`
+	Hash       string     `json:"_hash"`
+	ParentID   string     `json:"_parent,omitempty"`
+	CreatedAt  time.Time  `json:"_created_at"`
+	CreatedBy  string     `json:"_created_by"`
+	UpdatedAt  time.Time  `json:"_updated_at"`
+	UpdatedBy  string     `json:"_updated_by"`
+	Branch     string     `json:"_branch,omitempty"`
+	DeletedAt  *time.Time `json:"_deleted_at,omitempty"`
+	DeletedBy  string     `json:"_deleted_by,omitempty"`
+	ArchivedAt *time.Time `json:"_archived_at,omitempty"`
+	ArchivedBy string     `json:"_archived_by,omitempty"`
+	AssignedTo string     `json:"_assigned_to,omitempty"`
+	Operation  string     `json:"_op"`
+	PrevHash   string     `json:"_prev,omitempty"`   // hash of the preceding JSONL line (hash chain mode)
+	BaseHash   string     `json:"_base,omitempty"`   // hash of the record state the operation was made from
+	Signature  string     `json:"_sig,omitempty"`    // actor's signature over SigningPayload
+	Source     string     `json:"_source,omitempty"` // where the operation's values came from (see --source)
+	RunID      string     `json:"_run,omitempty"`    // the agent run that made the operation (see --run-id)
+	Fields     map[string]interface{}
+
+	// Coerced holds the values the operation was given for coercing enum
+	// columns, by column, where they differ from the values written.
+	Coerced map[string]interface{} `json:"_coerced,omitempty"`
 }
 
 // IsDeleted returns true if the record has been soft-deleted.
@@ -39,6 +66,11 @@ func (r *Record) IsDeleted() bool {
 	return r.DeletedAt != nil
 }
 
+// IsArchived returns true if the record has been archived.
+func (r *Record) IsArchived() bool {
+	return r.ArchivedAt != nil
+}
+
 // CalculateHash computes the SHA-256 hash of the record's user fields.
 // The hash is deterministic: same fields produce the same hash.
 // Returns the first 12 characters of the hex-encoded hash.
@@ -80,6 +112,17 @@ func CalculateHash(fields map[string]interface{}) string {
 	return hex.EncodeToString(hash[:])[:12]
 }
 
+// ChainGenesis is the previous-line hash recorded on the first line of a
+// hash-chained log.
+var ChainGenesis = LineHash(nil)
+
+// LineHash returns the hex-encoded SHA-256 hash of a raw JSONL line,
+// used to link operations in hash chain mode.
+func LineHash(line []byte) string {
+	hash := sha256.Sum256(line)
+	return hex.EncodeToString(hash[:])
+}
+
 // MarshalJSON implements custom JSON marshaling that flattens Fields into the output.
 func (r *Record) MarshalJSON() ([]byte, error) {
 	// Create a map with all system fields
@@ -102,6 +145,31 @@ func (r *Record) MarshalJSON() ([]byte, error) {
 		m["_deleted_at"] = r.DeletedAt
 		m["_deleted_by"] = r.DeletedBy
 	}
+	if r.ArchivedAt != nil {
+		m["_archived_at"] = r.ArchivedAt
+		m["_archived_by"] = r.ArchivedBy
+	}
+	if r.AssignedTo != "" {
+		m["_assigned_to"] = r.AssignedTo
+	}
+	if r.PrevHash != "" {
+		m["_prev"] = r.PrevHash
+	}
+	if r.BaseHash != "" {
+		m["_base"] = r.BaseHash
+	}
+	if r.Signature != "" {
+		m["_sig"] = r.Signature
+	}
+	if r.Source != "" {
+		m["_source"] = r.Source
+	}
+	if r.RunID != "" {
+		m["_run"] = r.RunID
+	}
+	if len(r.Coerced) > 0 {
+		m["_coerced"] = r.Coerced
+	}
 
 	// Merge user fields
 	for k, v := range r.Fields {
@@ -144,6 +212,30 @@ func (r *Record) UnmarshalJSON(data []byte) error {
 	if v, ok := m["_deleted_by"].(string); ok {
 		r.DeletedBy = v
 	}
+	if v, ok := m["_archived_by"].(string); ok {
+		r.ArchivedBy = v
+	}
+	if v, ok := m["_assigned_to"].(string); ok {
+		r.AssignedTo = v
+	}
+	if v, ok := m["_prev"].(string); ok {
+		r.PrevHash = v
+	}
+	if v, ok := m["_base"].(string); ok {
+		r.BaseHash = v
+	}
+	if v, ok := m["_sig"].(string); ok {
+		r.Signature = v
+	}
+	if v, ok := m["_source"].(string); ok {
+		r.Source = v
+	}
+	if v, ok := m["_run"].(string); ok {
+		r.RunID = v
+	}
+	if v, ok := m["_coerced"].(map[string]interface{}); ok {
+		r.Coerced = v
+	}
 
 	// Parse timestamps
 	if v, ok := m["_created_at"].(string); ok {
@@ -160,6 +252,12 @@ func (r *Record) UnmarshalJSON(data []byte) error {
 			r.DeletedAt = &t
 		}
 	}
+	if v, ok := m["_archived_at"]; ok && v != nil {
+		if s, ok := v.(string); ok {
+			t, _ := time.Parse(time.RFC3339, s)
+			r.ArchivedAt = &t
+		}
+	}
 
 	// Extract user fields (everything not starting with "_")
 	r.Fields = make(map[string]interface{})
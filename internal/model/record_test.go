@@ -396,6 +396,14 @@ func TestRecordOperationConstants(t *testing.T) {
 	})
 }
 
+func TestIsStateOp(t *testing.T) {
+	for _, op := range []string{OpCreate, OpUpdate, OpRestore, OpArchive, OpUnarchive, OpAssign, OpUnassign} {
+		assert.True(t, IsStateOp(op), op)
+	}
+	assert.False(t, IsStateOp(OpDelete))
+	assert.False(t, IsStateOp(""))
+}
+
 func TestRecordIsDeleted_EdgeCases(t *testing.T) {
 	t.Run("zero time pointer is still deleted", func(t *testing.T) {
 		zeroTime := time.Time{}
@@ -404,3 +412,15 @@ func TestRecordIsDeleted_EdgeCases(t *testing.T) {
 		assert.True(t, r.IsDeleted())
 	})
 }
+
+func TestRecord_RunIDRoundTrip(t *testing.T) {
+	record := &Record{ID: "inv-ex4j", Operation: OpUpdate, RunID: "triage-7", Fields: map[string]interface{}{"Name": "Laptop"}}
+	data, err := record.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"_run":"triage-7"`)
+
+	var decoded Record
+	require.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, "triage-7", decoded.RunID)
+	assert.NotContains(t, decoded.Fields, "_run")
+}
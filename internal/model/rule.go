@@ -0,0 +1,103 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition operators accepted in a rule's When clause.
+const (
+	ConditionEquals    = "="
+	ConditionNotEquals = "!="
+)
+
+// Rule is a conditional constraint: the Require column must have a value
+// in every record where the When condition holds, e.g. Ship_Date is
+// required when Status=shipped.
+type Rule struct {
+	Require  string `json:"require"`
+	When     string `json:"when"`               // Condition: Column=value or Column!=value
+	Severity string `json:"severity,omitempty"` // "warning" makes violations non-blocking
+}
+
+// Condition is a parsed rule When clause. An empty Value matches an unset
+// column, so Column!= holds whenever Column has a value.
+type Condition struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// ParseCondition parses Column=value or Column!=value.
+func ParseCondition(when string) (Condition, error) {
+	idx := strings.Index(when, "=")
+	if idx <= 0 {
+		return Condition{}, fmt.Errorf("invalid condition '%s' (expected Column=value or Column!=value)", when)
+	}
+	cond := Condition{Column: when[:idx], Op: ConditionEquals, Value: strings.TrimSpace(when[idx+1:])}
+	if strings.HasSuffix(cond.Column, "!") {
+		cond.Column, cond.Op = strings.TrimSuffix(cond.Column, "!"), ConditionNotEquals
+	}
+	cond.Column = strings.TrimSpace(cond.Column)
+	if cond.Column == "" {
+		return Condition{}, fmt.Errorf("invalid condition '%s' (expected Column=value or Column!=value)", when)
+	}
+	return cond, nil
+}
+
+// String formats the condition as it is written in a rule.
+func (c Condition) String() string {
+	return c.Column + c.Op + c.Value
+}
+
+// Matches reports whether the condition holds for a column's values. A
+// list column matches = when any element equals the value; a scalar is
+// a one-element list, and an unset column has no elements.
+func (c Condition) Matches(values []string) bool {
+	found := false
+	if c.Value == "" {
+		found = len(values) == 0
+	}
+	for _, v := range values {
+		if v == c.Value {
+			found = true
+			break
+		}
+	}
+	if c.Op == ConditionNotEquals {
+		return !found
+	}
+	return found
+}
+
+// ViolationSeverity returns the severity of the rule's violations.
+func (r Rule) ViolationSeverity() string {
+	if r.Severity == SeverityWarning {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// SetRule adds a rule, replacing one that requires the same column under
+// the same condition.
+func (s *Stash) SetRule(rule Rule) {
+	for i := range s.Rules {
+		if s.Rules[i].Require == rule.Require && s.Rules[i].When == rule.When {
+			s.Rules[i] = rule
+			return
+		}
+	}
+	s.Rules = append(s.Rules, rule)
+}
+
+// RemoveRule removes the rule that requires a column under a condition.
+// Returns false if there is no such rule.
+func (s *Stash) RemoveRule(require, when string) bool {
+	for i := range s.Rules {
+		if s.Rules[i].Require == require && s.Rules[i].When == when {
+			s.Rules = append(s.Rules[:i], s.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
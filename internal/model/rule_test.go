@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCondition(t *testing.T) {
+	cond, err := ParseCondition("Status=shipped")
+	require.NoError(t, err)
+	assert.Equal(t, Condition{Column: "Status", Op: ConditionEquals, Value: "shipped"}, cond)
+
+	cond, err = ParseCondition(" Carrier != ")
+	require.NoError(t, err)
+	assert.Equal(t, Condition{Column: "Carrier", Op: ConditionNotEquals, Value: ""}, cond)
+	assert.Equal(t, "Carrier!=", cond.String())
+
+	for _, bad := range []string{"Status", "=shipped", "!=x", ""} {
+		_, err := ParseCondition(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCondition_Matches(t *testing.T) {
+	shipped := Condition{Column: "Status", Op: ConditionEquals, Value: "shipped"}
+	assert.True(t, shipped.Matches([]string{"shipped"}))
+	assert.True(t, shipped.Matches([]string{"urgent", "shipped"}))
+	assert.False(t, shipped.Matches([]string{"pending"}))
+	assert.False(t, shipped.Matches(nil))
+
+	notShipped := Condition{Column: "Status", Op: ConditionNotEquals, Value: "shipped"}
+	assert.False(t, notShipped.Matches([]string{"shipped"}))
+	assert.True(t, notShipped.Matches(nil))
+
+	unset := Condition{Column: "Carrier", Op: ConditionEquals}
+	assert.True(t, unset.Matches(nil))
+	assert.False(t, unset.Matches([]string{"ups"}))
+
+	set := Condition{Column: "Carrier", Op: ConditionNotEquals}
+	assert.True(t, set.Matches([]string{"ups"}))
+	assert.False(t, set.Matches(nil))
+}
+
+func TestStash_Rules(t *testing.T) {
+	stash := &Stash{}
+	stash.SetRule(Rule{Require: "Ship_Date", When: "Status=shipped"})
+	stash.SetRule(Rule{Require: "Reason", When: "Status=cancelled"})
+	stash.SetRule(Rule{Require: "Ship_Date", When: "Status=shipped", Severity: SeverityWarning})
+
+	require.Len(t, stash.Rules, 2)
+	assert.Equal(t, SeverityWarning, stash.Rules[0].ViolationSeverity())
+	assert.Equal(t, SeverityError, stash.Rules[1].ViolationSeverity())
+
+	assert.True(t, stash.RemoveRule("Ship_Date", "Status=shipped"))
+	assert.False(t, stash.RemoveRule("Ship_Date", "Status=shipped"))
+	assert.Len(t, stash.Rules, 1)
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Schema change operations, as recorded in a stash's schema log.
+const (
+	SchemaStashCreate  = "stash_create"    // The stash was created
+	SchemaColumnAdd    = "column_add"      // A column was added
+	SchemaColumnChange = "column_change"   // A column's settings changed (description, validation, ...)
+	SchemaColumnDrop   = "column_drop"     // A column was removed
+	SchemaSettings     = "settings_change" // Stash-level settings changed (prefix, rules, limits, ...)
+)
+
+// SchemaChange is one entry of a stash's schema log (schema.jsonl).
+type SchemaChange struct {
+	Timestamp time.Time     `json:"ts"`
+	Actor     string        `json:"actor,omitempty"`
+	Op        string        `json:"op"`
+	Column    string        `json:"column,omitempty"`
+	Fields    []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is one setting changed by a schema change, with its value
+// before and after. A nil value means the setting was unset.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+// DiffSchema returns the schema changes that turn old into stash, made at
+// the given time by actor. A nil old describes the creation of stash and
+// each of its columns, dated and attributed as recorded in the config.
+// New columns keep their own Added and AddedBy when set. Columns are
+// matched by name.
+func DiffSchema(old, stash *Stash, at time.Time, actor string) []SchemaChange {
+	var changes []SchemaChange
+	if old == nil {
+		changes = append(changes, SchemaChange{
+			Timestamp: stash.Created,
+			Actor:     stash.CreatedBy,
+			Op:        SchemaStashCreate,
+			Fields:    diffFields(nil, settingsMap(stash)),
+		})
+		at, actor = stash.Created, stash.CreatedBy
+	} else if fields := diffFields(settingsMap(old), settingsMap(stash)); len(fields) > 0 {
+		changes = append(changes, SchemaChange{Timestamp: at, Actor: actor, Op: SchemaSettings, Fields: fields})
+	}
+
+	var oldColumns ColumnList
+	if old != nil {
+		oldColumns = old.Columns
+	}
+	for _, col := range stash.Columns {
+		prev := oldColumns.Find(col.Name)
+		if prev == nil {
+			change := SchemaChange{Timestamp: at, Actor: actor, Op: SchemaColumnAdd, Column: col.Name,
+				Fields: diffFields(nil, columnMap(&col))}
+			if !col.Added.IsZero() {
+				change.Timestamp = col.Added
+			}
+			if col.AddedBy != "" {
+				change.Actor = col.AddedBy
+			}
+			changes = append(changes, change)
+		} else if fields := diffFields(columnMap(prev), columnMap(&col)); len(fields) > 0 {
+			changes = append(changes, SchemaChange{Timestamp: at, Actor: actor, Op: SchemaColumnChange, Column: col.Name, Fields: fields})
+		}
+	}
+	for _, col := range oldColumns {
+		if stash.Columns.Find(col.Name) == nil {
+			changes = append(changes, SchemaChange{Timestamp: at, Actor: actor, Op: SchemaColumnDrop, Column: col.Name})
+		}
+	}
+	return changes
+}
+
+// settingsMap returns the stash-level settings of a stash as JSON values,
+// leaving out its columns and creation details.
+func settingsMap(stash *Stash) map[string]interface{} {
+	m := jsonMap(stash)
+	for _, key := range []string{"name", "created", "created_by", "columns"} {
+		delete(m, key)
+	}
+	return m
+}
+
+// columnMap returns the settings of a column as JSON values, leaving out
+// its name and when and by whom it was added.
+func columnMap(col *Column) map[string]interface{} {
+	m := jsonMap(col)
+	for _, key := range []string{"name", "added", "added_by"} {
+		delete(m, key)
+	}
+	return m
+}
+
+// jsonMap returns v as its JSON object.
+func jsonMap(v interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	data, err := json.Marshal(v)
+	if err == nil {
+		json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+// diffFields returns the keys whose values differ between two JSON
+// objects, sorted by key.
+func diffFields(old, new map[string]interface{}) []FieldChange {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	var fields []FieldChange
+	for k := range keys {
+		if !reflect.DeepEqual(old[k], new[k]) {
+			fields = append(fields, FieldChange{Field: k, From: old[k], To: new[k]})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchema(t *testing.T) {
+	created := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	old := &Stash{
+		Name: "inventory", Prefix: "inv-", Created: created, CreatedBy: "alice",
+		Columns: ColumnList{
+			{Name: "Name", Added: created, AddedBy: "alice"},
+			{Name: "Notes", Added: created, AddedBy: "alice"},
+		},
+	}
+
+	t.Run("creation", func(t *testing.T) {
+		changes := DiffSchema(nil, old, time.Time{}, "")
+		require.Len(t, changes, 3)
+		assert.Equal(t, SchemaChange{
+			Timestamp: created, Actor: "alice", Op: SchemaStashCreate,
+			Fields: []FieldChange{{Field: "prefix", To: "inv-"}},
+		}, changes[0])
+		assert.Equal(t, SchemaColumnAdd, changes[1].Op)
+		assert.Equal(t, "Name", changes[1].Column)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		at := created.Add(time.Hour)
+		stash := *old
+		stash.Collation = CollationNoCase
+		stash.Columns = ColumnList{
+			{Name: "Name", Desc: "Item name", Added: created, AddedBy: "alice"},
+			{Name: "Price", Validate: "number", Added: at, AddedBy: "carol"},
+		}
+
+		changes := DiffSchema(old, &stash, at, "bob")
+		assert.Equal(t, []SchemaChange{
+			{Timestamp: at, Actor: "bob", Op: SchemaSettings, Fields: []FieldChange{{Field: "collation", To: "nocase"}}},
+			{Timestamp: at, Actor: "bob", Op: SchemaColumnChange, Column: "Name", Fields: []FieldChange{{Field: "desc", To: "Item name"}}},
+			{Timestamp: at, Actor: "carol", Op: SchemaColumnAdd, Column: "Price", Fields: []FieldChange{{Field: "validate", To: "number"}}},
+			{Timestamp: at, Actor: "bob", Op: SchemaColumnDrop, Column: "Notes"},
+		}, changes)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		assert.Empty(t, DiffSchema(old, old, created, "bob"))
+	})
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRecord(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	newRecord := func() *Record {
+		now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		return &Record{
+			ID:        "inv-ex4j",
+			CreatedAt: now,
+			CreatedBy: "alice",
+			UpdatedAt: now,
+			UpdatedBy: "alice",
+			Operation: OpCreate,
+			Fields:    map[string]interface{}{"Name": "Laptop", "Price": 999.0},
+		}
+	}
+
+	t.Run("signed record verifies", func(t *testing.T) {
+		r := newRecord()
+		SignRecord(r, priv)
+		assert.NotEmpty(t, r.Signature)
+		assert.True(t, VerifyRecordSignature(r, pub))
+	})
+
+	t.Run("tampered fields fail", func(t *testing.T) {
+		r := newRecord()
+		SignRecord(r, priv)
+		r.Fields["Price"] = 1.0
+		assert.False(t, VerifyRecordSignature(r, pub))
+	})
+
+	t.Run("changed actor fails", func(t *testing.T) {
+		r := newRecord()
+		SignRecord(r, priv)
+		r.UpdatedBy = "mallory"
+		assert.False(t, VerifyRecordSignature(r, pub))
+	})
+
+	t.Run("other key fails", func(t *testing.T) {
+		otherPub, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		r := newRecord()
+		SignRecord(r, priv)
+		assert.False(t, VerifyRecordSignature(r, otherPub))
+	})
+
+	t.Run("operation and chain link are not covered", func(t *testing.T) {
+		r := newRecord()
+		SignRecord(r, priv)
+		r.Operation = OpUpdate
+		r.PrevHash = ChainGenesis
+		assert.True(t, VerifyRecordSignature(r, pub))
+	})
+
+	t.Run("assignment is covered", func(t *testing.T) {
+		r := newRecord()
+		r.AssignedTo = "bob"
+		SignRecord(r, priv)
+		r.AssignedTo = "mallory"
+		assert.False(t, VerifyRecordSignature(r, pub))
+	})
+
+	t.Run("unassigned payload is unchanged", func(t *testing.T) {
+		assert.NotContains(t, string(SigningPayload(newRecord())), "assigned_to")
+	})
+
+	t.Run("signature survives JSON roundtrip", func(t *testing.T) {
+		r := newRecord()
+		SignRecord(r, priv)
+		data, err := r.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"_sig":`)
+
+		var decoded Record
+		require.NoError(t, decoded.UnmarshalJSON(data))
+		assert.True(t, VerifyRecordSignature(&decoded, pub))
+	})
+}
+
+func TestActorKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	key := &ActorKey{
+		Actor:     "alice",
+		Algorithm: SignatureAlgorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+	}
+	decoded, err := key.Key()
+	require.NoError(t, err)
+	assert.Equal(t, pub, decoded)
+	assert.Len(t, key.Fingerprint(), 16)
+
+	key.PublicKey = "not-a-key"
+	_, err = key.Key()
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	key.Algorithm = "rsa"
+	_, err = key.Key()
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,11 +22,33 @@ var stashNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
 
 // Stash represents a named collection of records with a shared prefix.
 type Stash struct {
-	Name      string     `json:"name"`
-	Prefix    string     `json:"prefix"`
-	Created   time.Time  `json:"created"`
-	CreatedBy string     `json:"created_by"`
-	Columns   ColumnList `json:"columns"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Created    time.Time  `json:"created"`
+	CreatedBy  string     `json:"created_by"`
+	Columns    ColumnList `json:"columns"`
+	IDStrategy string     `json:"id_strategy,omitempty"` // How root IDs are generated (default: random)
+	IDTemplate string     `json:"id_template,omitempty"` // Template for the "template" strategy
+	Retention  string     `json:"retention,omitempty"`   // Purge deleted records older than this (e.g., "30d")
+	AutoPurge  bool       `json:"auto_purge,omitempty"`  // Whether the daemon enforces the retention policy
+	HashChain  bool       `json:"hash_chain,omitempty"`  // Link each JSONL operation to the previous line's hash
+	TrackReads bool       `json:"track_reads,omitempty"` // Count record reads in the cache (see 'stash stats')
+	Durability string     `json:"durability,omitempty"`  // How JSONL writes reach the disk (default: normal)
+	Collation  string     `json:"collation,omitempty"`   // How text values sort and compare (default: binary)
+
+	RequireDescriptions bool `json:"require_descriptions,omitempty"` // Reject new columns without a description
+
+	Permissions []Permission  `json:"permissions,omitempty"` // Per-actor write restrictions
+	Rules       []Rule        `json:"rules,omitempty"`       // Conditional constraints across columns
+	Groups      []ColumnGroup `json:"groups,omitempty"`      // Named slices of the columns (see 'stash column group')
+
+	DefaultFilter []string `json:"default_filter,omitempty"` // --where conditions listings apply unless told not to (see 'stash filter')
+
+	ValidateHook string `json:"validate_hook,omitempty"` // Shell command that validates whole records (see 'stash hook')
+
+	AttachPolicy *AttachmentPolicy `json:"attach_policy,omitempty"` // Limits on attached files (see 'stash file policy')
+	Limits       *SizeLimits       `json:"limits,omitempty"`        // Size limits of records (see 'stash limits')
+	Quotas       *Quotas           `json:"quotas,omitempty"`        // Record quota and write rate limits (see 'stash quotas')
 }
 
 // ValidatePrefix checks if a prefix is valid.
@@ -94,3 +118,60 @@ func (s *Stash) HasColumns() bool {
 func (s *Stash) PrimaryColumn() *Column {
 	return s.Columns.First()
 }
+
+// RetentionPeriod returns the stash's retention period for deleted records.
+// Returns false if no (valid) retention policy is set.
+func (s *Stash) RetentionPeriod() (time.Duration, bool) {
+	if s.Retention == "" {
+		return 0, false
+	}
+	d, err := ParseRetention(s.Retention)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// ParseRetention parses a retention period such as "30d", "2w", or "12h".
+// The period must be positive.
+func ParseRetention(value string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	switch {
+	case strings.HasSuffix(value, "w"), strings.HasSuffix(value, "d"):
+		unit := 24 * time.Hour
+		if strings.HasSuffix(value, "w") {
+			unit *= 7
+		}
+		var n int
+		n, err = strconv.Atoi(value[:len(value)-1])
+		d = time.Duration(n) * unit
+	default:
+		d, err = time.ParseDuration(value)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid retention period '%s' (use e.g. 30d, 2w, 12h)", value)
+	}
+	return d, nil
+}
+
+// Durability modes for JSONL writes.
+const (
+	DurabilityNormal = "normal" // the log is synced before it replaces the old one (default)
+	DurabilityFull   = "full"   // the directory is synced too, so a write survives power loss once it returns
+)
+
+// ValidateDurability checks a durability mode.
+func ValidateDurability(mode string) error {
+	switch mode {
+	case DurabilityNormal, DurabilityFull:
+		return nil
+	}
+	return fmt.Errorf("invalid durability '%s' (valid: normal, full)", mode)
+}
+
+// FullDurability reports whether the stash syncs every JSONL write through
+// to the disk before it returns.
+func (s *Stash) FullDurability() bool {
+	return s.Durability == DurabilityFull
+}
@@ -320,3 +320,25 @@ func TestStashGetColumn_EdgeCases(t *testing.T) {
 		assert.Equal(t, "CamelCaseName", col.Name)
 	})
 }
+
+func TestParseRetention(t *testing.T) {
+	valid := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+	for value, want := range valid {
+		t.Run("valid: "+value, func(t *testing.T) {
+			got, err := ParseRetention(value)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	for _, value := range []string{"", "0d", "-1d", "abc", "30x"} {
+		t.Run("invalid: "+value, func(t *testing.T) {
+			_, err := ParseRetention(value)
+			assert.Error(t, err)
+		})
+	}
+}
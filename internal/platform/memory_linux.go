@@ -0,0 +1,24 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"os"
+)
+
+// ProcessMemory returns the resident memory of a process in bytes, or 0
+// if it cannot be read.
+func ProcessMemory(pid int) int64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0
+	}
+
+	// statm reports sizes in pages: total, then resident
+	var size, resident int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &size, &resident); err != nil {
+		return 0
+	}
+	return resident * int64(os.Getpagesize())
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package platform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessMemory(t *testing.T) {
+	t.Run("current process uses memory", func(t *testing.T) {
+		assert.Greater(t, ProcessMemory(os.Getpid()), int64(0))
+	})
+
+	t.Run("unused PID reports zero", func(t *testing.T) {
+		assert.Equal(t, int64(0), ProcessMemory(999999999))
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package platform
+
+// ProcessMemory returns the resident memory of a process in bytes, or 0
+// if it cannot be read. Only Linux exposes it without extra privileges or
+// cgo, so other systems report 0.
+func ProcessMemory(pid int) int64 {
+	return 0
+}
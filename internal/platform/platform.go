@@ -0,0 +1,48 @@
+// Package platform hides the differences between operating systems that
+// stash cares about: checking and stopping processes, detaching the
+// daemon, advisory file locks, syncing directories, and the terminal size.
+//
+// Unix systems (Linux, macOS, the BSDs) share one implementation in the
+// *_unix.go files; Windows has its own in the *_windows.go files.
+package platform
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/user/stash/internal/logging"
+)
+
+// FileLock is an exclusive advisory lock held on a lock file.
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile creates the file at path if needed and takes an exclusive lock
+// on it, waiting until any other holder releases it. The lock is
+// advisory: it only excludes other callers of LockFile, so guard data
+// with a separate lock file rather than locking the data file itself.
+func LockFile(path string) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+	start := time.Now()
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	logging.Trace("file lock", "path", path, "waited", time.Since(start))
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock. The lock file is left in place.
+func (l *FileLock) Unlock() error {
+	logging.Trace("file unlock", "path", l.f.Name())
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
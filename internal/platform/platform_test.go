@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessExists(t *testing.T) {
+	t.Run("current process exists", func(t *testing.T) {
+		assert.True(t, ProcessExists(os.Getpid()))
+	})
+
+	t.Run("invalid PIDs do not exist", func(t *testing.T) {
+		assert.False(t, ProcessExists(0))
+		assert.False(t, ProcessExists(-1))
+	})
+
+	t.Run("unused PID does not exist", func(t *testing.T) {
+		assert.False(t, ProcessExists(999999999))
+	})
+}
+
+func TestLockFile(t *testing.T) {
+	t.Run("creates the lock file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.lock")
+		lock, err := LockFile(path)
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+		require.NoError(t, lock.Unlock())
+		assert.FileExists(t, path)
+	})
+
+	t.Run("second lock waits for the first", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.lock")
+		first, err := LockFile(path)
+		require.NoError(t, err)
+
+		acquired := make(chan *FileLock)
+		go func() {
+			second, err := LockFile(path)
+			if err != nil {
+				t.Error(err)
+			}
+			acquired <- second
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("second lock acquired while the first was held")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		require.NoError(t, first.Unlock())
+		select {
+		case second := <-acquired:
+			require.NotNil(t, second)
+			require.NoError(t, second.Unlock())
+		case <-time.After(5 * time.Second):
+			t.Fatal("second lock not acquired after the first was released")
+		}
+	})
+
+	t.Run("missing directory fails", func(t *testing.T) {
+		_, err := LockFile(filepath.Join(t.TempDir(), "missing", "test.lock"))
+		assert.Error(t, err)
+	})
+}
+
+func TestTerminalWidth(t *testing.T) {
+	t.Run("regular file is not a terminal", func(t *testing.T) {
+		f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+		require.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, 0, TerminalWidth(f))
+	})
+}
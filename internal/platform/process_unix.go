@@ -0,0 +1,41 @@
+//go:build !windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ProcessExists reports whether a process with the given PID is running.
+func ProcessExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// FindProcess always succeeds on Unix; signal 0 checks the process
+	// without touching it. EPERM means it exists but belongs to another user.
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// DetachedProcAttr returns the attributes that start a child process in
+// its own process group, so it survives the parent's terminal closing.
+func DetachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// Terminate asks a process to shut down gracefully with SIGTERM.
+func Terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+// ShutdownSignals returns the signals a long-running process should treat
+// as a request to shut down.
+func ShutdownSignals() []os.Signal {
+	return []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+}
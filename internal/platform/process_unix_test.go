@@ -0,0 +1,36 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetachedProcAttr(t *testing.T) {
+	attr := DetachedProcAttr()
+	assert.True(t, attr.Setpgid)
+}
+
+func TestTerminate(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, cmd.Start())
+	pid := cmd.Process.Pid
+	assert.True(t, ProcessExists(pid))
+
+	require.NoError(t, Terminate(cmd.Process))
+	err := cmd.Wait()
+	require.Error(t, err)
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGTERM, status.Signal())
+	assert.False(t, ProcessExists(pid))
+}
+
+func TestShutdownSignals(t *testing.T) {
+	assert.Equal(t, []os.Signal{syscall.SIGTERM, syscall.SIGINT}, ShutdownSignals())
+}
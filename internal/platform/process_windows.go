@@ -0,0 +1,55 @@
+//go:build windows
+
+package platform
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a process
+// that has not exited.
+const stillActive = 259
+
+// ProcessExists reports whether a process with the given PID is running.
+func ProcessExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Access denied means the process exists but belongs to someone else
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// DetachedProcAttr returns the attributes that start a child process
+// without a console in its own process group, so it survives the parent's
+// console closing.
+func DetachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+		HideWindow:    true,
+	}
+}
+
+// Terminate stops a process. Windows cannot deliver SIGTERM to another
+// process, so the process is killed.
+func Terminate(process *os.Process) error {
+	return process.Kill()
+}
+
+// ShutdownSignals returns the signals a long-running process should treat
+// as a request to shut down.
+func ShutdownSignals() []os.Signal {
+	return []os.Signal{os.Interrupt}
+}
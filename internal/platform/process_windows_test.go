@@ -0,0 +1,34 @@
+//go:build windows
+
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+func TestDetachedProcAttr(t *testing.T) {
+	attr := DetachedProcAttr()
+	assert.NotZero(t, attr.CreationFlags&windows.CREATE_NEW_PROCESS_GROUP)
+	assert.NotZero(t, attr.CreationFlags&windows.DETACHED_PROCESS)
+}
+
+func TestTerminate(t *testing.T) {
+	cmd := exec.Command("ping", "-n", "30", "127.0.0.1")
+	require.NoError(t, cmd.Start())
+	pid := cmd.Process.Pid
+	assert.True(t, ProcessExists(pid))
+
+	require.NoError(t, Terminate(cmd.Process))
+	_ = cmd.Wait()
+	assert.False(t, ProcessExists(pid))
+}
+
+func TestShutdownSignals(t *testing.T) {
+	assert.Equal(t, []os.Signal{os.Interrupt}, ShutdownSignals())
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package platform
+
+import "os"
+
+// SyncDir flushes a directory's entries to disk, so that files created or
+// renamed in it survive a crash.
+func SyncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
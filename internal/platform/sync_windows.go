@@ -0,0 +1,10 @@
+//go:build windows
+
+package platform
+
+// SyncDir flushes a directory's entries to disk. Windows cannot open a
+// directory for syncing, and NTFS journals renames itself, so this does
+// nothing.
+func SyncDir(path string) error {
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// TerminalWidth returns the width in columns of the terminal f refers to,
+// or 0 if it is not a terminal.
+func TerminalWidth(f *os.File) int {
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// ScannerTimeout bounds how long an attachment scanner may run before the
+// file is rejected.
+var ScannerTimeout = 2 * time.Minute
+
+// checkAttachmentPolicy enforces the stash's attachment policy on the file
+// at path, which is about to be attached to recordID under name.
+func (s *Store) checkAttachmentPolicy(stashName, recordID, name, path string, size int64) error {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+	policy := stash.AttachPolicy
+	if policy.IsEmpty() {
+		return nil
+	}
+
+	if err := policy.CheckSize(name, size); err != nil {
+		return err
+	}
+	if err := policy.CheckExtension(name); err != nil {
+		return err
+	}
+	if len(policy.MimeTypes) > 0 {
+		mimeType, err := sniffContentType(path)
+		if err != nil {
+			return err
+		}
+		if err := policy.CheckMimeType(name, mimeType); err != nil {
+			return err
+		}
+	}
+	if policy.Scanner != "" {
+		if message := runAttachmentScanner(policy.Scanner, stashName, recordID, name, path); message != "" {
+			return &model.AttachmentPolicyError{Name: name, Rule: model.PolicyScanner,
+				Message: fmt.Sprintf("'%s' rejected by scanner: %s", name, message)}
+		}
+	}
+	return nil
+}
+
+// sniffContentType detects the content type of a file from its first
+// bytes, as http.DetectContentType does.
+func sniffContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// runAttachmentScanner runs a scanner command on a file and returns its
+// rejection message, or "" if it accepted the file. The file's path is
+// passed as the last argument and in STASH_FILE.
+func runAttachmentScanner(command, stashName, recordID, name, path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), ScannerTimeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	scanner := exec.CommandContext(ctx, "sh", "-c", command+` "$STASH_FILE"`)
+	scanner.Stdout = &output
+	scanner.Stderr = &output
+	scanner.Env = append(os.Environ(),
+		"STASH_FILE="+path,
+		"STASH_STASH="+stashName,
+		"STASH_ID="+recordID,
+		"STASH_NAME="+name,
+	)
+
+	err := scanner.Run()
+	if err == nil {
+		return ""
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Sprintf("timed out after %s", ScannerTimeout)
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(output.String()), "\n"); line != "" {
+		return line
+	}
+	return fmt.Sprintf("exited with %v", err)
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// benchRecordCount is the stash size used by the rebuild benchmarks.
+// Run with: go test ./internal/storage -run '^$' -bench Rebuild -benchtime 3x
+const benchRecordCount = 100000
+
+var benchColumns = []string{"name", "price", "category", "notes"}
+
+// newBenchStore creates a store holding a stash whose JSONL log has n records.
+func newBenchStore(b *testing.B, n int) *Store {
+	b.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "stash-bench-*")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+
+	stash := &model.Stash{
+		Name:      "bench",
+		Prefix:    "bn-",
+		Created:   time.Now(),
+		CreatedBy: "bench",
+	}
+	for _, name := range benchColumns {
+		stash.Columns = append(stash.Columns, model.Column{Name: name, Added: time.Now(), AddedBy: "bench"})
+	}
+	if err := store.CreateStash("bench", "bn-", stash); err != nil {
+		b.Fatal(err)
+	}
+
+	records := make([]*model.Record, n)
+	now := time.Now()
+	for i := range records {
+		records[i] = &model.Record{
+			ID:        fmt.Sprintf("bn-%06d", i),
+			Hash:      fmt.Sprintf("%012x", i),
+			CreatedAt: now,
+			CreatedBy: "bench",
+			UpdatedAt: now,
+			UpdatedBy: "bench",
+			Operation: model.OpCreate,
+			Fields: map[string]interface{}{
+				"name":     fmt.Sprintf("Item %d", i),
+				"price":    float64(i % 1000),
+				"category": []string{"tools", "parts", "misc"}[i%3],
+				"notes":    "benchmark record",
+			},
+		}
+	}
+	if err := store.jsonl.WriteAllRecords("bench", records); err != nil {
+		b.Fatal(err)
+	}
+
+	return store
+}
+
+func benchSize() int {
+	if testing.Short() {
+		return benchRecordCount / 10
+	}
+	return benchRecordCount
+}
+
+// BenchmarkRebuildCache measures a full rebuild using prepared statements
+// inside a single transaction.
+func BenchmarkRebuildCache(b *testing.B) {
+	store := newBenchStore(b, benchSize())
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := store.RebuildCache("bench"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRebuildCacheUnbatched measures the previous rebuild strategy for
+// comparison: SQL rebuilt for every record and one implicit transaction per
+// insert.
+func BenchmarkRebuildCacheUnbatched(b *testing.B) {
+	store := newBenchStore(b, benchSize())
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := store.sqlite.ClearTable("bench"); err != nil {
+			b.Fatal(err)
+		}
+		records, err := store.jsonl.ReadAllRecords("bench")
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, record := range records {
+			if err := unpreparedUpsert(store.sqlite, "bench", record, benchColumns); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// unpreparedUpsert inserts a record without statement caching or batching.
+func unpreparedUpsert(c *SQLiteCache, stashName string, record *model.Record, columns []string) error {
+	allCols := append(append([]string{}, baseColumns...), columns...)
+	quotedCols := make([]string, len(allCols))
+	placeholders := make([]string, len(allCols))
+	for i, col := range allCols {
+		quotedCols[i] = fmt.Sprintf(`"%s"`, col)
+		placeholders[i] = "?"
+	}
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO "%s" (%s) VALUES (%s)`,
+		sanitizeTableName(stashName), strings.Join(quotedCols, ", "), strings.Join(placeholders, ", "))
+	_, err := c.db.Exec(query, recordValues(record, columns)...)
+	return err
+}
+
+func BenchmarkGetRecord(b *testing.B) {
+	store := newBenchStore(b, 1000)
+	if err := store.RebuildCache("bench"); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetRecord("bench", fmt.Sprintf("bn-%06d", i%1000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListRecordsPage(b *testing.B) {
+	store := newBenchStore(b, 1000)
+	if err := store.RebuildCache("bench"); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		opts := ListOptions{ParentID: "*", Limit: 50, Offset: (i * 50) % 1000}
+		if _, err := store.ListRecords("bench", opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
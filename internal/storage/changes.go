@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// ChangePollInterval is how often WaitForChanges checks a stash's log for
+// new operations.
+const ChangePollInterval = 200 * time.Millisecond
+
+// Change is one operation in a stash's records.jsonl. Seq numbers the
+// operations from 1 in log order, so a reader can ask for the changes after
+// the last one it saw.
+type Change struct {
+	Seq    int           `json:"seq"`
+	Record *model.Record `json:"record"`
+}
+
+// ChangeSet is the answer to a changes request.
+type ChangeSet struct {
+	Stash   string   `json:"stash"`
+	Since   int      `json:"since"`
+	Last    int      `json:"last"` // Seq of the latest operation; the next since
+	Changes []Change `json:"changes"`
+	// Reset reports that the log holds fewer operations than since: it was
+	// compacted or replaced, so Changes start again from the first one.
+	Reset bool `json:"reset,omitempty"`
+}
+
+// ChangesSince returns the operations in a stash's log after since that
+// match the where conditions. A condition is evaluated against the record
+// as the operation left it, so an update that set Status=done matches
+// Status=done even if a later one changed it again.
+func (s *Store) ChangesSince(stashName string, since int, where []WhereCondition) (*ChangeSet, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+	for _, cond := range where {
+		if !IsBaseColumn(cond.Field) && stash.Columns.Find(cond.Field) == nil {
+			return nil, fmt.Errorf("%w: %s", model.ErrColumnNotFound, cond.Field)
+		}
+	}
+
+	records, err := s.jsonl.ReadAllRecords(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &ChangeSet{Stash: stashName, Since: since, Last: len(records), Changes: []Change{}}
+	if since > len(records) {
+		set.Reset = true
+		since = 0
+	}
+	opts := ListOptions{
+		IncludeDeleted: true,
+		ParentID:       "*",
+		Where:          where,
+		ColumnTypes:    stash.Columns.ValueTypes(),
+		Collation:      stash.Collation,
+	}
+	columns := stash.Columns.Names()
+	for i := max(since, 0); i < len(records); i++ {
+		if len(where) > 0 {
+			ok, err := s.sqlite.MatchRecord(stashName, records[i], columns, opts)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		set.Changes = append(set.Changes, Change{Seq: i + 1, Record: records[i]})
+	}
+	return set, nil
+}
+
+// WaitForChanges waits until the stash's log holds an operation after since
+// that matches the where conditions, and returns the matching operations.
+// A negative since waits for operations after the latest one. When ctx is
+// done first, it returns an empty change set whose Last is where the log
+// stood, so the caller can wait again from there.
+func (s *Store) WaitForChanges(ctx context.Context, stashName string, since int, where []WhereCondition) (*ChangeSet, error) {
+	set, err := s.ChangesSince(stashName, max(since, 0), where)
+	if err != nil {
+		return nil, err
+	}
+	if since < 0 {
+		since = set.Last
+		set = &ChangeSet{Stash: stashName, Since: since, Last: since, Changes: []Change{}}
+	}
+
+	ticker := time.NewTicker(ChangePollInterval)
+	defer ticker.Stop()
+	stamp := s.logStamp(stashName)
+	for len(set.Changes) == 0 && !set.Reset {
+		select {
+		case <-ctx.Done():
+			set.Since = since
+			return set, nil
+		case <-ticker.C:
+		}
+		current := s.logStamp(stashName)
+		if current != "" && current == stamp {
+			continue
+		}
+		stamp = current
+
+		// Skip the operations already seen not to match
+		if set, err = s.ChangesSince(stashName, set.Last, where); err != nil {
+			return nil, err
+		}
+	}
+	set.Since = since
+	return set, nil
+}
+
+// logStamp identifies the state of a stash's log by its size and
+// modification time, so a waiter only rereads the log when it may have
+// changed. In-memory logs have no stamp and are reread every poll.
+func (s *Store) logStamp(stashName string) string {
+	if s.jsonl.mem != nil {
+		return ""
+	}
+	info, err := os.Stat(s.jsonl.getRecordsPath(stashName))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+}
+
+// MatchRecord reports whether a record matches the filters of opts. It
+// evaluates the same SQL a listing would, but against the given record
+// rather than its cached row, so it can match a record as it was.
+func (c *SQLiteCache) MatchRecord(stashName string, record *model.Record, columns []string, opts ListOptions) (bool, error) {
+	whereClause, args := c.buildWhere(sanitizeTableName(stashName), columns, opts)
+	if whereClause == "" {
+		return true, nil
+	}
+
+	allCols := append(append([]string{}, baseColumns...), columns...)
+	selects := make([]string, len(allCols))
+	for i, col := range allCols {
+		selects[i] = "? AS " + quoteIdent(col)
+	}
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM (SELECT %s) %s`, strings.Join(selects, ", "), whereClause)
+
+	var n int
+	if err := c.db.QueryRowContext(c.ctx, query, append(recordValues(record, columns), args...)...).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to match record: %w", queryError(err))
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_ChangesSince(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), ".stash"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	require.NoError(t, store.CreateStash("tasks", "tk-", &model.Stash{
+		Name: "tasks", Prefix: "tk-", Created: now,
+		Columns: model.ColumnList{{Name: "Status", Added: now}, {Name: "Priority", Added: now, Validate: "number"}},
+	}))
+	write := func(id string, fields map[string]interface{}, update bool) {
+		t.Helper()
+		record := &model.Record{
+			ID: id, Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}
+		if update {
+			require.NoError(t, store.UpdateRecord("tasks", record))
+		} else {
+			require.NoError(t, store.CreateRecord("tasks", record))
+		}
+	}
+	write("tk-0001", map[string]interface{}{"Status": "open", "Priority": "1"}, false)
+	write("tk-0002", map[string]interface{}{"Status": "open", "Priority": "5"}, false)
+	write("tk-0001", map[string]interface{}{"Status": "done", "Priority": "1"}, true)
+	write("tk-0001", map[string]interface{}{"Status": "open", "Priority": "1"}, true)
+
+	seqs := func(set *ChangeSet) []int {
+		var seqs []int
+		for _, c := range set.Changes {
+			seqs = append(seqs, c.Seq)
+		}
+		return seqs
+	}
+
+	t.Run("all changes after since", func(t *testing.T) {
+		set, err := store.ChangesSince("tasks", 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 4, set.Last)
+		assert.Equal(t, []int{2, 3, 4}, seqs(set))
+		assert.Equal(t, model.OpUpdate, set.Changes[1].Record.Operation)
+	})
+
+	t.Run("conditions match the record as the change left it", func(t *testing.T) {
+		set, err := store.ChangesSince("tasks", 0, []WhereCondition{{Field: "status", Operator: "=", Value: "done"}})
+		require.NoError(t, err)
+		assert.Equal(t, []int{3}, seqs(set))
+
+		set, err = store.ChangesSince("tasks", 0, []WhereCondition{{Field: "Priority", Operator: ">", Value: "2"}})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2}, seqs(set))
+	})
+
+	t.Run("since past the end of the log", func(t *testing.T) {
+		set, err := store.ChangesSince("tasks", 10, nil)
+		require.NoError(t, err)
+		assert.True(t, set.Reset)
+		assert.Len(t, set.Changes, 4)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := store.ChangesSince("tasks", 0, []WhereCondition{{Field: "Nope", Operator: "=", Value: "x"}})
+		assert.ErrorIs(t, err, model.ErrColumnNotFound)
+	})
+
+	t.Run("wait for a matching change", func(t *testing.T) {
+		done := make(chan *ChangeSet)
+		go func() {
+			set, err := store.WaitForChanges(context.Background(), "tasks", -1, []WhereCondition{{Field: "Status", Operator: "=", Value: "done"}})
+			assert.NoError(t, err)
+			done <- set
+		}()
+
+		time.Sleep(2 * ChangePollInterval)
+		write("tk-0002", map[string]interface{}{"Status": "blocked", "Priority": "5"}, true)
+		time.Sleep(2 * ChangePollInterval)
+		write("tk-0002", map[string]interface{}{"Status": "done", "Priority": "5"}, true)
+
+		select {
+		case set := <-done:
+			assert.Equal(t, 4, set.Since)
+			assert.Equal(t, []int{6}, seqs(set))
+			assert.Equal(t, "tk-0002", set.Changes[0].Record.ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change seen")
+		}
+	})
+
+	t.Run("wait times out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*ChangePollInterval)
+		defer cancel()
+		set, err := store.WaitForChanges(ctx, "tasks", 6, nil)
+		require.NoError(t, err)
+		assert.Empty(t, set.Changes)
+		assert.Equal(t, 6, set.Last)
+	})
+}
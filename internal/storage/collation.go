@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/user/stash/internal/model"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// A stash's collation applies to the text values of its user columns when
+// a listing sorts by them, compares them with = or !=, or groups them for
+// distinct values. Values are compared by the key stash_collate derives
+// from them: the folded text for nocase, and a CLDR sort key for a
+// language, so the cache needs no per-connection collation sequences.
+
+// collated returns the SQL expression that compares expr under a
+// collation; binary compares expr itself.
+func collated(expr, collation string) string {
+	if collation == "" || collation == model.CollationBinary {
+		return expr
+	}
+	return fmt.Sprintf(`stash_collate(%s, '%s')`, expr, strings.ReplaceAll(collation, "'", "''"))
+}
+
+// collatedColumn returns the SQL expression that compares a column's
+// values by their value type, under the collation for text user columns.
+func collatedColumn(column, valueType, collation string) string {
+	expr := typedColumn(column, valueType)
+	if valueType != model.ValueTypeText || IsBaseColumn(column) {
+		return expr
+	}
+	return collated(expr, collation)
+}
+
+// collateFunc implements the stash_collate SQL function.
+func collateFunc(v interface{}, collation string) interface{} {
+	if v == nil {
+		return nil
+	}
+	text := searchText(v)
+	switch collation {
+	case "", model.CollationBinary:
+		return text
+	case model.CollationNoCase:
+		return cases.Fold().String(text)
+	}
+	return collationKey(collation, text)
+}
+
+// collators pools the collators of each language, which are not safe for
+// concurrent use.
+var collators sync.Map // collation name -> *sync.Pool of *collate.Collator
+
+// collationKey returns the sort key of text in a language's order,
+// ignoring case. Keys start with a marker byte, so the key of "" is not
+// empty (and so not NULL).
+func collationKey(collation, text string) []byte {
+	pool, ok := collators.Load(collation)
+	if !ok {
+		tag, err := language.Parse(collation)
+		if err != nil {
+			tag = language.Und
+		}
+		pool, _ = collators.LoadOrStore(collation, &sync.Pool{New: func() interface{} {
+			return collate.New(tag, collate.IgnoreCase)
+		}})
+	}
+	c := pool.(*sync.Pool).Get().(*collate.Collator)
+	defer pool.(*sync.Pool).Put(c)
+
+	var buf collate.Buffer
+	return append([]byte{1}, c.KeyFromString(&buf, text)...)
+}
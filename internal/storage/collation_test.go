@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_Collation(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), ".stash"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	stash := &model.Stash{
+		Name: "fruit", Prefix: "fr-", Created: now,
+		Columns: model.ColumnList{{Name: "Name", Added: now}},
+	}
+	require.NoError(t, store.CreateStash("fruit", "fr-", stash))
+	for i, name := range []string{"banana", "Apple", "zucchini", "apple", "äpple"} {
+		fields := map[string]interface{}{"Name": name}
+		require.NoError(t, store.CreateRecord("fruit", &model.Record{
+			ID: "fr-000" + string(rune('1'+i)), Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}))
+	}
+
+	names := func(opts ListOptions) []string {
+		t.Helper()
+		opts.OrderBy = []OrderKey{{Field: "Name"}}
+		records, err := store.ListRecords("fruit", opts)
+		require.NoError(t, err)
+		var names []string
+		for _, r := range records {
+			names = append(names, r.Fields["Name"].(string))
+		}
+		return names
+	}
+	equal := func(value string) []WhereCondition {
+		return []WhereCondition{{Field: "Name", Operator: "=", Value: value}}
+	}
+
+	t.Run("binary by default", func(t *testing.T) {
+		assert.Equal(t, []string{"Apple", "apple", "banana", "zucchini", "äpple"}, names(ListOptions{}))
+		assert.Equal(t, []string{"apple"}, names(ListOptions{Where: equal("apple")}))
+	})
+
+	t.Run("nocase", func(t *testing.T) {
+		opts := ListOptions{Collation: model.CollationNoCase}
+		assert.Equal(t, []string{"Apple", "apple", "banana", "zucchini", "äpple"}, names(opts))
+		opts.Where = equal("APPLE")
+		assert.Equal(t, []string{"Apple", "apple"}, names(opts))
+		opts.Where = []WhereCondition{{Field: "Name", Operator: "!=", Value: "apple"}}
+		assert.Equal(t, []string{"banana", "zucchini", "äpple"}, names(opts))
+	})
+
+	t.Run("language order", func(t *testing.T) {
+		assert.Equal(t, []string{"Apple", "apple", "äpple", "banana", "zucchini"}, names(ListOptions{Collation: "de"}))
+		assert.Equal(t, []string{"Apple", "apple", "banana", "zucchini", "äpple"}, names(ListOptions{Collation: "sv"}))
+		assert.Equal(t, []string{"Apple", "apple"}, names(ListOptions{Collation: "de", Where: equal("aPPle")}))
+	})
+
+	t.Run("stash config applies and groups distinct values", func(t *testing.T) {
+		stash.Collation = model.CollationNoCase
+		require.NoError(t, store.UpdateStashConfig(stash))
+
+		assert.Equal(t, []string{"Apple", "apple"}, names(ListOptions{Where: equal("APPLE")}))
+		values, err := store.DistinctValues("fruit", "Name", ListOptions{ParentID: "*"})
+		require.NoError(t, err)
+		require.NotEmpty(t, values)
+		assert.Equal(t, ValueCount{Value: "Apple", Count: 2}, values[0])
+		assert.Len(t, values, 4)
+	})
+}
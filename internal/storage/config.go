@@ -12,6 +12,7 @@ import (
 // ConfigStore manages stash configuration files.
 type ConfigStore struct {
 	baseDir string // .stash directory
+	mem     *memFS // files of an in-memory store; nil on disk
 }
 
 // NewConfigStore creates a new config store.
@@ -32,8 +33,10 @@ func (s *ConfigStore) getStashDir(stashName string) string {
 // WriteConfig writes a stash configuration to config.json.
 func (s *ConfigStore) WriteConfig(stash *model.Stash) error {
 	dir := s.getStashDir(stash.Name)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create stash directory: %w", err)
+	if s.mem == nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create stash directory: %w", err)
+		}
 	}
 
 	configPath := s.getConfigPath(stash.Name)
@@ -44,6 +47,11 @@ func (s *ConfigStore) WriteConfig(stash *model.Stash) error {
 	}
 	data = append(data, '\n')
 
+	if s.mem != nil {
+		s.mem.writeFile(configPath, data)
+		return nil
+	}
+
 	// Write atomically via temp file
 	tmpFile, err := os.CreateTemp(dir, "config-*.tmp")
 	if err != nil {
@@ -77,7 +85,13 @@ func (s *ConfigStore) WriteConfig(stash *model.Stash) error {
 func (s *ConfigStore) ReadConfig(stashName string) (*model.Stash, error) {
 	configPath := s.getConfigPath(stashName)
 
-	data, err := os.ReadFile(configPath)
+	var data []byte
+	var err error
+	if s.mem != nil {
+		data, err = s.mem.readFile(configPath)
+	} else {
+		data, err = os.ReadFile(configPath)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, model.ErrStashNotFound
@@ -96,6 +110,10 @@ func (s *ConfigStore) ReadConfig(stashName string) (*model.Stash, error) {
 // DeleteConfig removes a stash's configuration directory.
 func (s *ConfigStore) DeleteConfig(stashName string) error {
 	dir := s.getStashDir(stashName)
+	if s.mem != nil {
+		s.mem.removeAll(dir)
+		return nil
+	}
 	if err := os.RemoveAll(dir); err != nil {
 		return fmt.Errorf("failed to delete stash directory: %w", err)
 	}
@@ -105,12 +123,24 @@ func (s *ConfigStore) DeleteConfig(stashName string) error {
 // Exists returns true if the stash config exists.
 func (s *ConfigStore) Exists(stashName string) bool {
 	configPath := s.getConfigPath(stashName)
+	if s.mem != nil {
+		return s.mem.exists(configPath)
+	}
 	_, err := os.Stat(configPath)
 	return err == nil
 }
 
 // ListStashDirs returns all stash directory names.
 func (s *ConfigStore) ListStashDirs() ([]string, error) {
+	if s.mem != nil {
+		var stashes []string
+		for _, name := range s.mem.dirs(s.baseDir, "config.json") {
+			if !isHiddenOrMeta(name) {
+				stashes = append(stashes, name)
+			}
+		}
+		return stashes, nil
+	}
 	entries, err := os.ReadDir(s.baseDir)
 	if err != nil {
 		if os.IsNotExist(err) {
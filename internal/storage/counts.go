@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Each stash's row in _stash_meta keeps counts of its active and deleted
+// records and the time of its latest operation, so info, describe, and
+// doctor read them without a COUNT(*) over the table. Triggers on the
+// stash table update them in the same statement as the change, so they
+// cannot drift from the table, even if a write fails part way.
+
+// createCountTriggers adds the triggers that keep a stash's counts in
+// _stash_meta as records are written and removed.
+func (c *SQLiteCache) createCountTriggers(stashName string) error {
+	tableName := sanitizeTableName(stashName)
+	name := "'" + strings.ReplaceAll(stashName, "'", "''") + "'"
+
+	triggers := []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS "trg_%s_count_insert" AFTER INSERT ON "%s" BEGIN
+			UPDATE _stash_meta SET
+				record_count = record_count + (NEW.deleted_at IS NULL),
+				deleted_count = deleted_count + (NEW.deleted_at IS NOT NULL),
+				last_op_at = MAX(COALESCE(last_op_at, ''), NEW.updated_at)
+			WHERE stash_name = %s;
+		END`, tableName, tableName, name),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS "trg_%s_count_update" AFTER UPDATE ON "%s" BEGIN
+			UPDATE _stash_meta SET
+				record_count = record_count - (OLD.deleted_at IS NULL) + (NEW.deleted_at IS NULL),
+				deleted_count = deleted_count - (OLD.deleted_at IS NOT NULL) + (NEW.deleted_at IS NOT NULL),
+				last_op_at = MAX(COALESCE(last_op_at, ''), NEW.updated_at)
+			WHERE stash_name = %s;
+		END`, tableName, tableName, name),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS "trg_%s_count_delete" AFTER DELETE ON "%s" BEGIN
+			UPDATE _stash_meta SET
+				record_count = record_count - (OLD.deleted_at IS NULL),
+				deleted_count = deleted_count - (OLD.deleted_at IS NOT NULL)
+			WHERE stash_name = %s;
+		END`, tableName, tableName, name),
+	}
+	for _, trigger := range triggers {
+		if _, err := c.db.Exec(trigger); err != nil {
+			return fmt.Errorf("failed to create count trigger: %w", err)
+		}
+	}
+	return nil
+}
+
+// recount sets a stash's counts in _stash_meta from its table, for a table
+// whose counts were not kept before.
+func (c *SQLiteCache) recount(stashName string) error {
+	tableName := sanitizeTableName(stashName)
+	_, err := c.db.Exec(fmt.Sprintf(`
+		UPDATE _stash_meta SET
+			record_count = (SELECT COUNT(*) - COUNT(deleted_at) FROM "%s"),
+			deleted_count = (SELECT COUNT(deleted_at) FROM "%s"),
+			last_op_at = (SELECT MAX(updated_at) FROM "%s")
+		WHERE stash_name = ?
+	`, tableName, tableName, tableName), stashName)
+	if err != nil {
+		return fmt.Errorf("failed to count records: %w", err)
+	}
+	return nil
+}
+
+// CountRecords returns the number of non-deleted records in a stash.
+func (c *SQLiteCache) CountRecords(stashName string) (int, error) {
+	records, _, _, err := c.StashStats(stashName)
+	return records, err
+}
+
+// StashStats returns the number of active and deleted records in a stash
+// table, and the time the most recently changed record was updated (zero
+// if there are none).
+func (c *SQLiteCache) StashStats(stashName string) (records, deleted int, lastModified time.Time, err error) {
+	var last sql.NullString
+	err = c.db.QueryRow(
+		`SELECT record_count, deleted_count, last_op_at FROM _stash_meta WHERE stash_name = ?`, stashName,
+	).Scan(&records, &deleted, &last)
+	if err == sql.ErrNoRows {
+		return 0, 0, time.Time{}, fmt.Errorf("failed to get stash stats: no cache metadata for stash '%s'", stashName)
+	}
+	if err != nil {
+		return 0, 0, time.Time{}, fmt.Errorf("failed to get stash stats: %w", err)
+	}
+	if last.Valid && last.String != "" {
+		lastModified, _ = time.Parse(time.RFC3339, last.String)
+	}
+	return records, deleted, lastModified, nil
+}
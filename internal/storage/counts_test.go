@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestSQLiteCache_Counts(t *testing.T) {
+	setup := func(t *testing.T) *SQLiteCache {
+		t.Helper()
+		cache, err := NewSQLiteCache(t.TempDir())
+		require.NoError(t, err)
+		t.Cleanup(func() { cache.Close() })
+
+		stash := &model.Stash{
+			Name:    "test-stash",
+			Prefix:  "ts-",
+			Created: time.Now(),
+			Columns: model.ColumnList{{Name: "name", Added: time.Now()}},
+		}
+		require.NoError(t, cache.CreateStashTable(stash))
+		return cache
+	}
+	base := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	record := func(id string, updated time.Time) *model.Record {
+		return &model.Record{
+			ID: id, Hash: "h-" + id,
+			CreatedAt: base, CreatedBy: "alice",
+			UpdatedAt: updated, UpdatedBy: "alice",
+			Fields: map[string]interface{}{"name": id},
+		}
+	}
+	columns := []string{"name"}
+
+	assertCounts := func(t *testing.T, cache *SQLiteCache, records, deleted int) {
+		t.Helper()
+		gotRecords, gotDeleted, _, err := cache.StashStats("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, records, gotRecords, "records")
+		assert.Equal(t, deleted, gotDeleted, "deleted")
+
+		var wantRecords, wantDeleted int
+		require.NoError(t, cache.db.QueryRow(`SELECT COUNT(*) - COUNT(deleted_at), COUNT(deleted_at) FROM "test_stash"`).Scan(&wantRecords, &wantDeleted))
+		assert.Equal(t, wantRecords, gotRecords, "records match the table")
+		assert.Equal(t, wantDeleted, gotDeleted, "deleted match the table")
+	}
+
+	t.Run("kept as records are written", func(t *testing.T) {
+		cache := setup(t)
+		assertCounts(t, cache, 0, 0)
+
+		require.NoError(t, cache.UpsertRecords("test-stash", []*model.Record{
+			record("ts-1", base), record("ts-2", base), record("ts-3", base.Add(time.Hour)),
+		}, columns))
+		assertCounts(t, cache, 3, 0)
+
+		// Updating a record does not count it twice
+		require.NoError(t, cache.UpsertRecord("test-stash", record("ts-1", base.Add(2*time.Hour)), columns))
+		assertCounts(t, cache, 3, 0)
+
+		deleted := record("ts-2", base.Add(3*time.Hour))
+		deletedAt := base.Add(3 * time.Hour)
+		deleted.DeletedAt, deleted.DeletedBy = &deletedAt, "alice"
+		require.NoError(t, cache.UpsertRecord("test-stash", deleted, columns))
+		assertCounts(t, cache, 2, 1)
+
+		require.NoError(t, cache.DeleteRecord("test-stash", "ts-2"))
+		require.NoError(t, cache.DeleteRecord("test-stash", "ts-3"))
+		assertCounts(t, cache, 1, 0)
+
+		_, _, last, err := cache.StashStats("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, base.Add(3*time.Hour), last.UTC())
+
+		count, err := cache.CountRecords("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("cleared with the table", func(t *testing.T) {
+		cache := setup(t)
+		require.NoError(t, cache.UpsertRecord("test-stash", record("ts-1", base), columns))
+		require.NoError(t, cache.ClearTable("test-stash"))
+		assertCounts(t, cache, 0, 0)
+
+		_, _, last, err := cache.StashStats("test-stash")
+		require.NoError(t, err)
+		assert.True(t, last.IsZero())
+	})
+
+	t.Run("filled in by migration", func(t *testing.T) {
+		cache := setup(t)
+		require.NoError(t, cache.UpsertRecord("test-stash", record("ts-1", base), columns))
+
+		// A table from before counts were kept
+		_, err := cache.db.Exec(`DROP TRIGGER "trg_test_stash_count_insert"`)
+		require.NoError(t, err)
+		require.NoError(t, cache.UpsertRecord("test-stash", record("ts-2", base), columns))
+		_, err = cache.db.Exec(`UPDATE _stash_meta SET record_count = 0, deleted_count = 0`)
+		require.NoError(t, err)
+		require.NoError(t, cache.setSchemaVersion("test-stash", 3))
+
+		require.NoError(t, cache.Migrate("test-stash"))
+		assertCounts(t, cache, 2, 0)
+
+		require.NoError(t, cache.UpsertRecord("test-stash", record("ts-3", base), columns))
+		assertCounts(t, cache, 3, 0)
+	})
+
+	t.Run("unknown stash", func(t *testing.T) {
+		cache := setup(t)
+		_, _, _, err := cache.StashStats("missing")
+		assert.Error(t, err)
+	})
+}
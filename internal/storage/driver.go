@@ -0,0 +1,49 @@
+package storage
+
+import "hash/fnv"
+
+// The SQLite driver is chosen at build time. By default stash uses
+// github.com/mattn/go-sqlite3, which needs cgo. Building with the purego
+// tag uses modernc.org/sqlite instead, a pure-Go port that cross-compiles
+// with CGO_ENABLED=0:
+//
+//	CGO_ENABLED=0 go build -tags purego ./cmd/stash
+//
+// Each driver file registers the driver under sqliteDriver, wrapped in a
+// traceDriver and with the search functions (stash_fold, stash_similarity),
+// the collation function (stash_collate), and the sampling function
+// (stash_sample) available on every connection, and builds DSNs in its own
+// syntax. The rest of the storage code only uses database/sql and does not
+// depend on the driver.
+
+// sqliteDriver is the name the SQLite driver is registered under.
+const sqliteDriver = "sqlite3_stash"
+
+// SQLiteDriverName returns the SQLite driver stash was built with.
+func SQLiteDriverName() string {
+	return sqliteDriverName
+}
+
+// foldFunc implements the stash_fold SQL function.
+func foldFunc(v interface{}) string {
+	return FoldText(searchText(v))
+}
+
+// similarityFunc implements the stash_similarity SQL function. A value of
+// several columns joined by searchSeparator scores as its best column.
+func similarityFunc(v interface{}, term string) float64 {
+	return fieldsSimilarity(searchText(v), term)
+}
+
+// sampleFunc implements the stash_sample SQL function: a pseudo-random
+// sort key for a record ID that only changes with the seed.
+func sampleFunc(id string, seed int64) int64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(seed >> (8 * i))
+	}
+	h.Write(b[:])
+	h.Write([]byte(id))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,40 @@
+//go:build !purego
+
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName identifies the driver in 'stash version --verbose'.
+const sqliteDriverName = "mattn/go-sqlite3"
+
+func init() {
+	sql.Register(sqliteDriver, traceDriver{&sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("stash_fold", foldFunc, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("stash_collate", collateFunc, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("stash_sample", sampleFunc, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("stash_similarity", similarityFunc, true)
+		},
+	}})
+}
+
+// sqliteFileDSN returns the DSN of the cache database file at path.
+func sqliteFileDSN(path string) string {
+	return path + "?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000"
+}
+
+// sqliteMemoryDSN returns the DSN of a named in-memory database shared by
+// every connection that opens it.
+func sqliteMemoryDSN(name string) string {
+	return "file:" + name + "?mode=memory&cache=shared&_busy_timeout=5000"
+}
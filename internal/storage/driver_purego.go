@@ -0,0 +1,60 @@
+//go:build purego
+
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteDriverName identifies the driver in 'stash version --verbose'.
+const sqliteDriverName = "modernc.org/sqlite"
+
+func init() {
+	// modernc.org/sqlite adds these functions to every connection its
+	// registered driver opens
+	sqlite.MustRegisterDeterministicScalarFunction("stash_fold", 1,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return foldFunc(args[0]), nil
+		})
+	sqlite.MustRegisterDeterministicScalarFunction("stash_collate", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return collateFunc(args[0], searchText(args[1])), nil
+		})
+	sqlite.MustRegisterDeterministicScalarFunction("stash_sample", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			seed, _ := args[1].(int64)
+			return sampleFunc(searchText(args[0]), seed), nil
+		})
+	sqlite.MustRegisterDeterministicScalarFunction("stash_similarity", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return similarityFunc(args[0], searchText(args[1])), nil
+		})
+	sql.Register(sqliteDriver, traceDriver{registeredDriver("sqlite")})
+}
+
+// registeredDriver returns the driver registered under name. The functions
+// above are registered with the driver modernc.org/sqlite registers itself,
+// not with every sqlite.Driver, so that is the one to wrap.
+func registeredDriver(name string) driver.Driver {
+	db, err := sql.Open(name, "")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	return db.Driver()
+}
+
+// sqliteFileDSN returns the DSN of the cache database file at path.
+func sqliteFileDSN(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)", path)
+}
+
+// sqliteMemoryDSN returns the DSN of a named in-memory database shared by
+// every connection that opens it.
+func sqliteMemoryDSN(name string) string {
+	return "file:" + name + "?mode=memory&cache=shared&_pragma=busy_timeout(5000)"
+}
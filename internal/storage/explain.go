@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryPlan is a query as SQLite would run it: the SQL, the arguments it
+// binds, and the steps of its plan from EXPLAIN QUERY PLAN.
+type QueryPlan struct {
+	SQL   string        `json:"sql"`
+	Args  []interface{} `json:"args"`
+	Steps []PlanStep    `json:"plan"`
+}
+
+// PlanStep is one line of a query plan. Steps nest under the step whose ID
+// is their Parent; top-level steps have Parent 0.
+type PlanStep struct {
+	ID     int    `json:"id"`
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+// FullScans returns the tables the plan reads every row of, as opposed to
+// searching an index for the rows it needs. A search of the deleted_at or
+// archived_at index counts as a scan: stash searches them for NULL, which
+// matches nearly every record.
+func (p *QueryPlan) FullScans() []string {
+	var tables []string
+	for _, step := range p.Steps {
+		// "SCAN t" reads the table, and "SCAN t USING INDEX i" all of it
+		// in index order; "SCAN CONSTANT ROW" reads no table
+		fields := strings.Fields(step.Detail)
+		switch {
+		case len(fields) >= 2 && fields[0] == "SCAN" && fields[1] != "CONSTANT":
+		case len(fields) > 2 && fields[0] == "SEARCH" &&
+			(strings.HasSuffix(step.Detail, "(deleted_at=?)") || strings.HasSuffix(step.Detail, "(archived_at=?)")):
+		default:
+			continue
+		}
+		tables = append(tables, strings.Trim(fields[1], `"`))
+	}
+	return tables
+}
+
+// TempSorts reports whether the plan sorts rows after reading them,
+// because no index returns them in the requested order.
+func (p *QueryPlan) TempSorts() bool {
+	for _, step := range p.Steps {
+		if strings.HasPrefix(step.Detail, "USE TEMP B-TREE FOR") {
+			return true
+		}
+	}
+	return false
+}
+
+// explain returns the plan SQLite chooses for query, without running it.
+func (c *SQLiteCache) explain(query string, args ...interface{}) (*QueryPlan, error) {
+	rows, err := c.db.QueryContext(c.ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	plan := &QueryPlan{SQL: query, Args: args}
+	if plan.Args == nil {
+		plan.Args = []interface{}{}
+	}
+	for rows.Next() {
+		var step PlanStep
+		var notUsed int
+		if err := rows.Scan(&step.ID, &step.Parent, &notUsed, &step.Detail); err != nil {
+			return nil, fmt.Errorf("failed to read query plan: %w", err)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read query plan: %w", err)
+	}
+	return plan, nil
+}
+
+// ExplainList returns the query ListRecords and IterateRecords run for
+// opts, and its plan.
+func (c *SQLiteCache) ExplainList(stashName string, columns []string, opts ListOptions) (*QueryPlan, error) {
+	query, args := c.listQuery(stashName, columns, opts)
+	return c.explain(query, args...)
+}
+
+// ExplainQuery returns the plan of a raw SELECT query, as RawQuery would
+// run it.
+func (c *SQLiteCache) ExplainQuery(query string) (*QueryPlan, error) {
+	return c.explain(query)
+}
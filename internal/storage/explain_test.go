@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestSQLiteCache_Explain(t *testing.T) {
+	cache, err := NewSQLiteCache(t.TempDir())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:    "test-stash",
+		Prefix:  "ts-",
+		Created: time.Now(),
+		Columns: model.ColumnList{{Name: "name", Added: time.Now()}},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+	columns := []string{"name"}
+
+	t.Run("list query matches the one run", func(t *testing.T) {
+		opts := ListOptions{
+			ParentID: "*",
+			Where:    []WhereCondition{{Field: "name", Operator: "=", Value: "First"}},
+			Limit:    10,
+		}
+		plan, err := cache.ExplainList("test-stash", columns, opts)
+		require.NoError(t, err)
+
+		query, args := cache.listQuery("test-stash", columns, opts)
+		assert.Equal(t, query, plan.SQL)
+		assert.Equal(t, args, plan.Args)
+		require.NotEmpty(t, plan.Steps)
+		assert.Equal(t, []string{"test_stash"}, plan.FullScans())
+	})
+
+	t.Run("index search is not a full scan", func(t *testing.T) {
+		plan, err := cache.ExplainQuery(`SELECT id FROM "test_stash" WHERE parent_id = 'ts-1'`)
+		require.NoError(t, err)
+		assert.Empty(t, plan.FullScans())
+		assert.False(t, plan.TempSorts())
+		assert.Equal(t, []interface{}{}, plan.Args)
+	})
+
+	t.Run("sorting without an index", func(t *testing.T) {
+		plan, err := cache.ExplainQuery(`SELECT id FROM "test_stash" ORDER BY "name"`)
+		require.NoError(t, err)
+		assert.True(t, plan.TempSorts())
+	})
+
+	t.Run("invalid SQL", func(t *testing.T) {
+		_, err := cache.ExplainQuery(`SELECT nope FROM "test_stash"`)
+		assert.Error(t, err)
+	})
+}
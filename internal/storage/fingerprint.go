@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/user/stash/internal/model"
+)
+
+// StashFingerprint is a deterministic hash of the logical state of a stash,
+// with the hashes of its parts so a mismatch can be narrowed down.
+type StashFingerprint struct {
+	Stash       string `json:"stash"`
+	Hash        string `json:"fingerprint"`
+	Schema      string `json:"schema"`      // Hash of the stash configuration
+	Records     string `json:"records"`     // Hash of the current state of every record
+	Attachments string `json:"attachments"` // Hash of the attached files' names and contents
+	RecordCount int    `json:"record_count"`
+	FileCount   int    `json:"attachment_count"`
+}
+
+// Fingerprint returns the hash of the logical state of a stash.
+func (s *Store) Fingerprint(stashName string) (string, error) {
+	fp, err := s.FingerprintDetail(stashName)
+	if err != nil {
+		return "", err
+	}
+	return fp.Hash, nil
+}
+
+// FingerprintDetail hashes the logical state of a stash: its configuration,
+// the current state of every record (including deleted ones) and the
+// contents of its attachments. It reads records.jsonl and the attached
+// files, never the cache, so two copies of a stash have the same
+// fingerprint exactly when they hold the same data, however they got it.
+func (s *Store) FingerprintDetail(stashName string) (*StashFingerprint, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
+	stash, err := s.config.ReadConfig(stashName)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.jsonl.ReadAllRecords(stashName)
+	if err != nil {
+		return nil, err
+	}
+	fp := &StashFingerprint{Stash: stashName}
+
+	config, err := json.Marshal(stash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stash config: %w", err)
+	}
+	fp.Schema = sumHex(sha256.Sum256(config))
+
+	state := ReplayRecords(records)
+	ids := make([]string, 0, len(state))
+	for id := range state {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		// The operation and its links describe how the record got here,
+		// not what it is
+		record := *state[id]
+		record.Operation = ""
+		record.PrevHash = ""
+		record.BaseHash = ""
+		data, err := json.Marshal(&record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal record %s: %w", id, err)
+		}
+		fmt.Fprintf(h, "%s\n", data)
+	}
+	fp.Records = hashHex(h)
+	fp.RecordCount = len(ids)
+
+	h = sha256.New()
+	err = s.WalkAttachments(stashName, func(recordID, name, path string) error {
+		fileHash, err := model.CalculateFileHash(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash attachment %s/%s: %w", recordID, name, err)
+		}
+		fmt.Fprintf(h, "%s/%s %s\n", recordID, name, fileHash)
+		fp.FileCount++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fp.Attachments = hashHex(h)
+
+	h = sha256.New()
+	fmt.Fprintf(h, "schema %s\nrecords %s\nattachments %s\n", fp.Schema, fp.Records, fp.Attachments)
+	fp.Hash = hashHex(h)
+	return fp, nil
+}
+
+// hashHex returns the prefixed hex digest of h.
+func hashHex(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// sumHex returns the prefixed hex form of a SHA-256 sum.
+func sumHex(sum [sha256.Size]byte) string {
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// WalkAttachments calls fn for every attached file of a stash, ordered by
+// record ID and then name. In-memory stores have no attachments.
+func (s *Store) WalkAttachments(stashName string, fn func(recordID, name, path string) error) error {
+	if s.IsMemory() {
+		return nil
+	}
+	root := filepath.Join(s.baseDir, stashName, "files")
+	records, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read files directory: %w", err)
+	}
+	for _, record := range records {
+		if !record.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, record.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read files directory: %w", err)
+		}
+		for _, entry := range entries {
+			// Skip the temporary files of attachments being written
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".attach-") {
+				continue
+			}
+			if err := fn(record.Name(), entry.Name(), filepath.Join(root, record.Name(), entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
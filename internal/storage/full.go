@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// FullExportFormat identifies a full-fidelity export document.
+const FullExportFormat = "stash-full"
+
+// FullExportVersion is the layout version of full-fidelity exports.
+const FullExportVersion = 1
+
+// ErrFingerprintMismatch is returned when an imported stash does not hash to
+// the fingerprint recorded in its export.
+var ErrFingerprintMismatch = errors.New("fingerprint mismatch")
+
+// FullExport is a complete copy of a stash: its schema, every line of its
+// operations log, and the contents of its attachments. Importing it
+// reproduces the stash with the same IDs, hashes, and fingerprint.
+type FullExport struct {
+	Format      string                 `json:"format"`
+	Version     int                    `json:"version"`
+	ExportedAt  time.Time              `json:"exported_at"`
+	ExportedBy  string                 `json:"exported_by,omitempty"`
+	Fingerprint string                 `json:"fingerprint"`
+	Stash       *model.Stash           `json:"stash"`
+	Operations  []string               `json:"operations"`         // records.jsonl lines, byte for byte
+	Rechains    []Rechain              `json:"rechains,omitempty"` // rewrites of the log's hash chain
+	Attachments []FullExportAttachment `json:"attachments"`
+}
+
+// FullExportAttachment is an attached file within a full export.
+type FullExportAttachment struct {
+	RecordID string `json:"record_id"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Hash     string `json:"hash"`
+	Data     []byte `json:"data"` // base64 encoded in JSON
+}
+
+// ReadLines returns the non-empty lines of a stash's JSONL log exactly as
+// stored, so hash chains and signatures survive a copy.
+func (s *JSONLStore) ReadLines(stashName string) ([]string, error) {
+	file, err := s.open(s.getRecordsPath(stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading records file: %w", err)
+	}
+	return lines, nil
+}
+
+// WriteLines overwrites a stash's JSONL log with the given lines, sealing
+// them as its segment.
+func (s *JSONLStore) WriteLines(stashName string, lines []string) error {
+	if err := s.ensureStashDir(stashName); err != nil {
+		return fmt.Errorf("failed to create stash directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	sw := newSegmentWriter(&buf)
+	for _, line := range lines {
+		io.WriteString(sw, line+"\n")
+	}
+
+	recordsPath := s.getRecordsPath(stashName)
+	if s.mem != nil {
+		s.mem.writeFile(recordsPath, buf.Bytes())
+	} else if err := os.WriteFile(recordsPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write records file: %w", err)
+	}
+	return s.writeSeal(stashName, sw.segment())
+}
+
+// ExportFull returns a full-fidelity export of a stash.
+func (s *Store) ExportFull(stashName, actor string) (*FullExport, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
+	stash, err := s.config.ReadConfig(stashName)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.jsonl.ReadLines(stashName)
+	if err != nil {
+		return nil, err
+	}
+	rechains, err := s.jsonl.ReadRechains(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []FullExportAttachment
+	err = s.WalkAttachments(stashName, func(recordID, name, path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s/%s: %w", recordID, name, err)
+		}
+		attachments = append(attachments, FullExportAttachment{
+			RecordID: recordID,
+			Name:     name,
+			Size:     int64(len(data)),
+			Hash:     model.LineHash(data),
+			Data:     data,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attachments == nil {
+		attachments = []FullExportAttachment{}
+	}
+
+	fingerprint, err := s.Fingerprint(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FullExport{
+		Format:      FullExportFormat,
+		Version:     FullExportVersion,
+		ExportedAt:  time.Now().UTC(),
+		ExportedBy:  actor,
+		Fingerprint: fingerprint,
+		Stash:       stash,
+		Operations:  lines,
+		Rechains:    rechains,
+		Attachments: attachments,
+	}, nil
+}
+
+// ImportFull creates a stash from a full export. The stash must not already
+// exist. The imported stash is checked against the export's fingerprint and
+// removed again if it does not match.
+func (s *Store) ImportFull(export *FullExport) error {
+	if export.Format != FullExportFormat {
+		return fmt.Errorf("not a full export (format %q)", export.Format)
+	}
+	if export.Version > FullExportVersion {
+		return fmt.Errorf("unsupported full export version %d", export.Version)
+	}
+	if export.Stash == nil {
+		return fmt.Errorf("full export has no stash configuration")
+	}
+	stash := export.Stash
+	if err := model.ValidateStashName(stash.Name); err != nil {
+		return err
+	}
+	for _, a := range export.Attachments {
+		if err := model.ValidateAttachmentName(a.Name); err != nil {
+			return err
+		}
+		if strings.ContainsAny(a.RecordID, `/\`) || a.RecordID == "" || a.RecordID == "." || a.RecordID == ".." {
+			return fmt.Errorf("invalid attachment record ID %q", a.RecordID)
+		}
+		if model.LineHash(a.Data) != a.Hash {
+			return fmt.Errorf("attachment %s/%s does not match its hash", a.RecordID, a.Name)
+		}
+	}
+	if len(export.Attachments) > 0 && s.IsMemory() {
+		return ErrInMemory
+	}
+
+	if err := s.CreateStash(stash.Name, stash.Prefix, stash); err != nil {
+		return err
+	}
+	if err := s.importFullData(export); err != nil {
+		s.DropStash(stash.Name)
+		return err
+	}
+	return nil
+}
+
+// importFullData writes the log and attachments of a full export into its
+// freshly created stash and verifies the result.
+func (s *Store) importFullData(export *FullExport) error {
+	name := export.Stash.Name
+	if err := s.jsonl.WriteLines(name, export.Operations); err != nil {
+		return err
+	}
+	for _, rechain := range export.Rechains {
+		if err := s.jsonl.appendRechain(name, rechain); err != nil {
+			return err
+		}
+	}
+	for _, a := range export.Attachments {
+		dir := s.GetFilesDir(name, a.RecordID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create files directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, a.Name), a.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write attachment %s/%s: %w", a.RecordID, a.Name, err)
+		}
+	}
+	if err := s.RebuildCache(name); err != nil {
+		return err
+	}
+
+	if export.Fingerprint == "" {
+		return nil
+	}
+	fingerprint, err := s.Fingerprint(name)
+	if err != nil {
+		return err
+	}
+	if fingerprint != export.Fingerprint {
+		return fmt.Errorf("%w: export has %s, imported stash has %s", ErrFingerprintMismatch, export.Fingerprint, fingerprint)
+	}
+	return nil
+}
+
+// WriteFullExport encodes a full export as indented JSON.
+func WriteFullExport(w io.Writer, export *FullExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// ReadFullExport decodes a full export.
+func ReadFullExport(r io.Reader) (*FullExport, error) {
+	var export FullExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse full export: %w", err)
+	}
+	if export.Format != FullExportFormat {
+		return nil, fmt.Errorf("not a full export (format %q)", export.Format)
+	}
+	return &export, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_FullExport(t *testing.T) {
+	newStore := func() *Store {
+		tmpDir, err := os.MkdirTemp("", "stash-full-test-*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(tmpDir) })
+		store, err := NewStore(tmpDir)
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+
+	source := newStore()
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		HashChain: true,
+		Columns:   model.ColumnList{{Name: "name", Added: time.Now(), AddedBy: "test-user"}},
+	}
+	require.NoError(t, source.CreateStash("test-stash", "ts-", stash))
+	now := time.Now()
+	for _, id := range []string{"ts-aaaa", "ts-bbbb"} {
+		fields := map[string]interface{}{"name": id}
+		require.NoError(t, source.CreateRecord("test-stash", &model.Record{
+			ID: id, Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "test-user", UpdatedAt: now, UpdatedBy: "test-user",
+		}))
+	}
+
+	export, err := source.ExportFull("test-stash", "test-user")
+	require.NoError(t, err)
+	assert.Len(t, export.Operations, 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFullExport(&buf, export))
+	decoded, err := ReadFullExport(&buf)
+	require.NoError(t, err)
+
+	t.Run("import reproduces the stash", func(t *testing.T) {
+		target := newStore()
+		require.NoError(t, target.ImportFull(decoded))
+
+		fingerprint, err := target.Fingerprint("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, export.Fingerprint, fingerprint)
+
+		lines, err := target.jsonl.ReadLines("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, export.Operations, lines)
+
+		report, err := target.VerifyChain("test-stash")
+		require.NoError(t, err)
+		assert.Empty(t, report.Breaks)
+
+		record, err := target.GetRecord("test-stash", "ts-bbbb")
+		require.NoError(t, err)
+		assert.Equal(t, "ts-bbbb", record.Fields["name"])
+
+		assert.ErrorIs(t, target.ImportFull(decoded), model.ErrStashExists)
+	})
+
+	t.Run("a tampered export is rejected", func(t *testing.T) {
+		tampered := *decoded
+		tampered.Operations = []string{strings.Replace(decoded.Operations[0], `"ts-aaaa"}`, `"ts-zzzz"}`, 1), decoded.Operations[1]}
+		require.NotEqual(t, decoded.Operations[0], tampered.Operations[0])
+
+		target := newStore()
+		assert.ErrorIs(t, target.ImportFull(&tampered), ErrFingerprintMismatch)
+		_, err := target.GetStash("test-stash")
+		assert.ErrorIs(t, err, model.ErrStashNotFound)
+	})
+
+	t.Run("import keeps the chain of a compacted stash", func(t *testing.T) {
+		require.NoError(t, source.FlushToJSONL("test-stash"))
+		export, err := source.ExportFull("test-stash", "test-user")
+		require.NoError(t, err)
+		require.Len(t, export.Rechains, 1)
+
+		target := newStore()
+		require.NoError(t, target.ImportFull(export))
+		report, err := target.VerifyChain("test-stash")
+		require.NoError(t, err)
+		assert.Empty(t, report.Breaks)
+		assert.Equal(t, export.Rechains, report.Rechains)
+	})
+}
@@ -2,22 +2,37 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/user/stash/internal/logging"
 	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// maxLineSize is the longest JSONL line the hash chain readers accept.
+const maxLineSize = 16 * 1024 * 1024
+
 // JSONLStore provides append-only JSONL storage for records.
 type JSONLStore struct {
-	baseDir string // .stash directory
+	baseDir string          // .stash directory
+	mem     *memFS          // files of an in-memory store; nil on disk
+	ctx     context.Context // reads fail once it is done (see Store.SetContext)
+
+	mu   sync.Mutex
+	torn []TornWrite // torn final lines recovered (see RecoverTornWrite)
 }
 
 // NewJSONLStore creates a new JSONL store.
 func NewJSONLStore(baseDir string) *JSONLStore {
-	return &JSONLStore{baseDir: baseDir}
+	return &JSONLStore{baseDir: baseDir, ctx: context.Background()}
 }
 
 // getRecordsPath returns the path to records.jsonl for a stash.
@@ -25,8 +40,44 @@ func (s *JSONLStore) getRecordsPath(stashName string) string {
 	return filepath.Join(s.baseDir, stashName, "records.jsonl")
 }
 
+// open opens a records file for reading. Reads fail with the store's
+// context error once it is done.
+func (s *JSONLStore) open(path string) (io.ReadCloser, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	var f io.ReadCloser
+	var err error
+	if s.mem != nil {
+		f, err = s.mem.open(path)
+	} else {
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &contextReader{ReadCloser: f, ctx: s.ctx}, nil
+}
+
+// contextReader is a file whose reads fail once ctx is done, so reading a
+// long log stops when its command is cancelled.
+type contextReader struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
 // ensureStashDir ensures the stash directory exists.
 func (s *JSONLStore) ensureStashDir(stashName string) error {
+	if s.mem != nil {
+		return nil
+	}
 	dir := filepath.Join(s.baseDir, stashName)
 	return os.MkdirAll(dir, 0755)
 }
@@ -34,18 +85,66 @@ func (s *JSONLStore) ensureStashDir(stashName string) error {
 // AppendRecord appends a record to the JSONL file atomically.
 // The file is created if it doesn't exist.
 func (s *JSONLStore) AppendRecord(stashName string, record *model.Record) error {
+	return s.appendRecords(stashName, []*model.Record{record}, false)
+}
+
+// AppendChainedRecord appends a record linked to the hash of the preceding
+// line, extending the log's hash chain.
+func (s *JSONLStore) AppendChainedRecord(stashName string, record *model.Record) error {
+	return s.appendRecords(stashName, []*model.Record{record}, true)
+}
+
+// AppendRecords appends records to the JSONL file in one atomic write:
+// either all of them are in the log afterwards or none are. When chained,
+// each is linked to the hash of the line before it.
+func (s *JSONLStore) AppendRecords(stashName string, records []*model.Record, chained bool) error {
+	return s.appendRecords(stashName, records, chained)
+}
+
+func (s *JSONLStore) appendRecords(stashName string, records []*model.Record, chained bool) (err error) {
+	span := telemetry.Start("jsonl.append", stashAttr(stashName))
+	if len(records) == 1 {
+		span.SetAttributes(recordAttr(records[0].ID))
+	}
+	defer span.End(&err)
+
 	if err := s.ensureStashDir(stashName); err != nil {
 		return fmt.Errorf("failed to create stash directory: %w", err)
 	}
+	// Never append onto the fragment of an interrupted write
+	if _, err := s.RecoverTornWrite(stashName); err != nil {
+		return err
+	}
 
 	recordsPath := s.getRecordsPath(stashName)
 
-	// Marshal record to JSON
-	data, err := json.Marshal(record)
-	if err != nil {
-		return fmt.Errorf("failed to marshal record: %w", err)
+	prev := ""
+	if chained {
+		if prev, err = s.chainHead(stashName); err != nil {
+			return err
+		}
+	}
+
+	// Marshal records to JSON
+	var data []byte
+	for _, record := range records {
+		record.PrevHash = prev
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		if chained {
+			prev = model.LineHash(line)
+		}
+		data = append(append(data, line...), '\n')
+		logging.Debug("jsonl append", "stash", stashName, "id", record.ID, "op", record.Operation, "chained", chained, "bytes", len(line)+1)
+	}
+	span.SetAttributes(attribute.Int("stash.bytes", len(data)))
+
+	if s.mem != nil {
+		s.mem.appendFile(recordsPath, data)
+		return nil
 	}
-	data = append(data, '\n')
 
 	// Write to temp file first for atomicity
 	dir := filepath.Dir(recordsPath)
@@ -66,7 +165,7 @@ func (s *JSONLStore) AppendRecord(stashName string, record *model.Record) error
 		}
 	}
 
-	// Append new record
+	// Append new records
 	if _, err := tmpFile.Write(data); err != nil {
 		tmpFile.Close()
 		return fmt.Errorf("failed to write record: %w", err)
@@ -91,10 +190,12 @@ func (s *JSONLStore) AppendRecord(stashName string, record *model.Record) error
 
 // ReadAllRecords reads all records from the JSONL file.
 // Returns an empty slice if the file doesn't exist.
-func (s *JSONLStore) ReadAllRecords(stashName string) ([]*model.Record, error) {
+func (s *JSONLStore) ReadAllRecords(stashName string) (_ []*model.Record, err error) {
+	defer telemetry.Start("jsonl.read", stashAttr(stashName)).End(&err)
+
 	recordsPath := s.getRecordsPath(stashName)
 
-	file, err := os.Open(recordsPath)
+	file, err := s.open(recordsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []*model.Record{}, nil
@@ -103,8 +204,14 @@ func (s *JSONLStore) ReadAllRecords(stashName string) ([]*model.Record, error) {
 	}
 	defer file.Close()
 
+	return ReadRecords(file)
+}
+
+// ReadRecords reads the records of a JSONL log, such as the records.jsonl
+// of another git branch.
+func ReadRecords(r io.Reader) ([]*model.Record, error) {
 	var records []*model.Record
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 
 	for scanner.Scan() {
@@ -131,11 +238,61 @@ func (s *JSONLStore) ReadAllRecords(stashName string) ([]*model.Record, error) {
 // WriteAllRecords overwrites the JSONL file with the given records.
 // This is used during sync operations.
 func (s *JSONLStore) WriteAllRecords(stashName string, records []*model.Record) error {
+	return s.WriteRecordsFrom(stashName, func(write func(*model.Record) error) error {
+		for _, record := range records {
+			if err := write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WriteRecordsFrom overwrites the JSONL file with the records passed to write
+// by produce. Records are encoded as they arrive, so the full set never needs
+// to be held in memory. The file is only replaced if produce succeeds.
+func (s *JSONLStore) WriteRecordsFrom(stashName string, produce func(write func(*model.Record) error) error) error {
+	return s.writeRecords(stashName, "", produce)
+}
+
+// WriteChainedRecordsFrom is like WriteRecordsFrom but links every line to
+// the hash of the line before it. The first line links to the last line of
+// the log being replaced, so the chain runs on across the rewrite, which
+// is recorded as a Rechain with the reason given.
+func (s *JSONLStore) WriteChainedRecordsFrom(stashName, reason string, produce func(write func(*model.Record) error) error) error {
+	return s.writeRecords(stashName, reason, produce)
+}
+
+// writeRecords rewrites a stash's log, chaining it and recording the
+// rewrite as a Rechain when reason is set.
+func (s *JSONLStore) writeRecords(stashName, reason string, produce func(write func(*model.Record) error) error) (err error) {
+	defer telemetry.Start("jsonl.rewrite", stashAttr(stashName)).End(&err)
+
 	if err := s.ensureStashDir(stashName); err != nil {
 		return fmt.Errorf("failed to create stash directory: %w", err)
 	}
+	chained := reason != ""
+	logging.Debug("jsonl rewrite", "stash", stashName, "chained", chained)
 
 	recordsPath := s.getRecordsPath(stashName)
+	prev := ""
+	if chained {
+		if prev, err = s.chainHead(stashName); err != nil {
+			return err
+		}
+	}
+	if s.mem != nil {
+		var buf bytes.Buffer
+		sw := newSegmentWriter(&buf)
+		if err := encodeRecords(sw, prev, produce); err != nil {
+			return err
+		}
+		s.mem.writeFile(recordsPath, buf.Bytes())
+		if err := s.writeSeal(stashName, sw.segment()); err != nil {
+			return err
+		}
+		return s.recordRechain(stashName, reason, prev)
+	}
 	dir := filepath.Dir(recordsPath)
 
 	// Write to temp file
@@ -147,20 +304,10 @@ func (s *JSONLStore) WriteAllRecords(stashName string, records []*model.Record)
 	defer os.Remove(tmpPath)
 
 	writer := bufio.NewWriter(tmpFile)
-	for _, record := range records {
-		data, err := json.Marshal(record)
-		if err != nil {
-			tmpFile.Close()
-			return fmt.Errorf("failed to marshal record: %w", err)
-		}
-		if _, err := writer.Write(data); err != nil {
-			tmpFile.Close()
-			return fmt.Errorf("failed to write record: %w", err)
-		}
-		if err := writer.WriteByte('\n'); err != nil {
-			tmpFile.Close()
-			return fmt.Errorf("failed to write newline: %w", err)
-		}
+	sw := newSegmentWriter(writer)
+	if err := encodeRecords(sw, prev, produce); err != nil {
+		tmpFile.Close()
+		return err
 	}
 
 	if err := writer.Flush(); err != nil {
@@ -181,12 +328,194 @@ func (s *JSONLStore) WriteAllRecords(stashName string, records []*model.Record)
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
-	return nil
+	// The new log is sealed as one segment, so later checks can skip it
+	if err := s.writeSeal(stashName, sw.segment()); err != nil {
+		return err
+	}
+	return s.recordRechain(stashName, reason, prev)
+}
+
+// encodeRecords writes the records passed to write by produce as JSONL
+// lines. Unless prev is empty, each is linked to the hash of the line
+// before it, the first to prev.
+func encodeRecords(w io.Writer, prev string, produce func(write func(*model.Record) error) error) error {
+	return produce(func(record *model.Record) error {
+		record.PrevHash = prev
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		if prev != "" {
+			prev = model.LineHash(data)
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		return nil
+	})
+}
+
+// LastRecord returns the last operation in a stash's log, or nil if the
+// log is missing or empty. Only the end of the file is read.
+func (s *JSONLStore) LastRecord(stashName string) (*model.Record, error) {
+	line, err := s.readLastLine(s.getRecordsPath(stashName))
+	if err != nil || len(line) == 0 {
+		return nil, err
+	}
+	var record model.Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse last record: %w", err)
+	}
+	return &record, nil
+}
+
+// readLastLine returns the last non-empty line of a JSONL file, or nil if
+// the file is missing or empty, reading a growing tail of the file until it
+// holds the whole line.
+func (s *JSONLStore) readLastLine(path string) ([]byte, error) {
+	if s.mem != nil {
+		data, err := s.mem.readFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		line, _ := lastLine(data)
+		return line, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat records file: %w", err)
+	}
+
+	for tail := int64(64 * 1024); ; tail *= 2 {
+		offset := max(info.Size()-tail, 0)
+		buf := make([]byte, info.Size()-offset)
+		if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read records file: %w", err)
+		}
+		line, whole := lastLine(buf)
+		if whole || offset == 0 || tail >= maxLineSize {
+			return line, nil
+		}
+	}
+}
+
+// lastLine returns the last non-empty line in data, and whether a newline
+// comes before it, so that it is known to be whole.
+func lastLine(data []byte) ([]byte, bool) {
+	data = bytes.TrimRight(data, "\r\n \t")
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return data[i+1:], true
+	}
+	return data, false
+}
+
+// ChainBreak describes a line whose recorded previous-line hash does not
+// match the line before it.
+type ChainBreak struct {
+	Line     int    `json:"line"`
+	ID       string `json:"id"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ChainReport summarizes a hash chain verification.
+type ChainReport struct {
+	Lines     int          `json:"lines"`     // Non-empty lines checked
+	Unchained int          `json:"unchained"` // Lines before the chain starts
+	Breaks    []ChainBreak `json:"breaks"`
+	Rechains  []Rechain    `json:"rechains"` // Rewrites of the log, oldest first
+}
+
+// VerifyChain walks the JSONL file and checks that every chained line
+// records the hash of the line before it. Lines written before the chain
+// was started are counted as unchained; once a chained line is seen, every
+// following line must continue the chain. The first line of a rewritten
+// log must link to the head of the log it replaced, as recorded by its
+// latest Rechain.
+func (s *JSONLStore) VerifyChain(stashName string) (*ChainReport, error) {
+	rechains, err := s.ReadRechains(stashName)
+	if err != nil {
+		return nil, err
+	}
+	report := &ChainReport{Breaks: []ChainBreak{}, Rechains: rechains}
+
+	file, err := s.open(s.getRecordsPath(stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return report, nil
+		}
+		return nil, fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+
+	prev := model.ChainGenesis
+	if len(rechains) > 0 {
+		prev = rechains[len(rechains)-1].PrevHead
+	}
+	chained := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		report.Lines++
+
+		var entry struct {
+			ID   string `json:"_id"`
+			Prev string `json:"_prev"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse record at line %d: %w", lineNum, err)
+		}
+
+		if entry.Prev != "" {
+			chained = true
+		}
+		switch {
+		case !chained:
+			report.Unchained++
+		case entry.Prev != prev:
+			report.Breaks = append(report.Breaks, ChainBreak{
+				Line:     lineNum,
+				ID:       entry.ID,
+				Expected: prev,
+				Actual:   entry.Prev,
+			})
+		}
+		prev = model.LineHash(line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading records file: %w", err)
+	}
+
+	return report, nil
 }
 
 // DeleteFile removes the records.jsonl file for a stash.
 func (s *JSONLStore) DeleteFile(stashName string) error {
 	recordsPath := s.getRecordsPath(stashName)
+	if s.mem != nil {
+		s.mem.remove(recordsPath)
+		return nil
+	}
 	err := os.Remove(recordsPath)
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete records file: %w", err)
@@ -197,6 +526,9 @@ func (s *JSONLStore) DeleteFile(stashName string) error {
 // Exists returns true if the records file exists.
 func (s *JSONLStore) Exists(stashName string) bool {
 	recordsPath := s.getRecordsPath(stashName)
+	if s.mem != nil {
+		return s.mem.exists(recordsPath)
+	}
 	_, err := os.Stat(recordsPath)
 	return err == nil
 }
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -421,3 +423,148 @@ func TestJSONLStore_GetRecordsPath(t *testing.T) {
 	path := store.getRecordsPath("my-stash")
 	assert.Equal(t, "/base/dir/my-stash/records.jsonl", path)
 }
+
+func TestJSONLStore_WriteRecordsFrom(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-jsonl-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store := NewJSONLStore(tmpDir)
+	stashName := "test-stash"
+
+	record := &model.Record{
+		ID:        "ts-abc1",
+		CreatedAt: time.Now(),
+		CreatedBy: "user",
+		UpdatedAt: time.Now(),
+		UpdatedBy: "user",
+		Operation: model.OpCreate,
+		Fields:    map[string]interface{}{"name": "First"},
+	}
+	require.NoError(t, store.WriteAllRecords(stashName, []*model.Record{record}))
+
+	t.Run("failed producer leaves existing file untouched", func(t *testing.T) {
+		err := store.WriteRecordsFrom(stashName, func(write func(*model.Record) error) error {
+			if err := write(&model.Record{ID: "ts-xyz1", Operation: model.OpCreate}); err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+
+		readRecords, err := store.ReadAllRecords(stashName)
+		require.NoError(t, err)
+		require.Len(t, readRecords, 1)
+		assert.Equal(t, "ts-abc1", readRecords[0].ID)
+	})
+}
+
+func TestJSONLStore_VerifyChain(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-jsonl-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store := NewJSONLStore(tmpDir)
+	stashName := "test-stash"
+
+	newRecord := func(id string) *model.Record {
+		return &model.Record{
+			ID:        id,
+			CreatedAt: time.Now(),
+			CreatedBy: "user",
+			UpdatedAt: time.Now(),
+			UpdatedBy: "user",
+			Operation: model.OpCreate,
+			Fields:    map[string]interface{}{"name": id},
+		}
+	}
+
+	// One unchained line, then a chain continuing from it
+	require.NoError(t, store.AppendRecord(stashName, newRecord("ts-abc1")))
+	for _, id := range []string{"ts-abc2", "ts-abc3", "ts-abc4"} {
+		require.NoError(t, store.AppendChainedRecord(stashName, newRecord(id)))
+	}
+
+	t.Run("intact chain has no breaks", func(t *testing.T) {
+		report, err := store.VerifyChain(stashName)
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Lines)
+		assert.Equal(t, 1, report.Unchained)
+		assert.Empty(t, report.Breaks)
+	})
+
+	t.Run("editing a line breaks the chain at the next line", func(t *testing.T) {
+		path := filepath.Join(tmpDir, stashName, "records.jsonl")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		tampered := strings.Replace(string(data), `"name":"ts-abc2"`, `"name":"edited"`, 1)
+		require.NoError(t, os.WriteFile(path, []byte(tampered), 0644))
+
+		report, err := store.VerifyChain(stashName)
+		require.NoError(t, err)
+		require.Len(t, report.Breaks, 1)
+		assert.Equal(t, 3, report.Breaks[0].Line)
+		assert.Equal(t, "ts-abc3", report.Breaks[0].ID)
+	})
+
+	t.Run("rewriting continues the chain from the old head", func(t *testing.T) {
+		records, err := store.ReadAllRecords(stashName)
+		require.NoError(t, err)
+		head, err := store.chainHead(stashName)
+		require.NoError(t, err)
+		err = store.WriteChainedRecordsFrom(stashName, "compact", func(write func(*model.Record) error) error {
+			for _, rec := range records {
+				if err := write(rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		first, err := store.ReadAllRecords(stashName)
+		require.NoError(t, err)
+		assert.Equal(t, head, first[0].PrevHash)
+
+		report, err := store.VerifyChain(stashName)
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Unchained)
+		assert.Empty(t, report.Breaks)
+		require.Len(t, report.Rechains, 1)
+		assert.Equal(t, "compact", report.Rechains[0].Reason)
+		assert.Equal(t, head, report.Rechains[0].PrevHead)
+	})
+
+	t.Run("a rewrite from genesis is reported as a break", func(t *testing.T) {
+		records, err := store.ReadAllRecords(stashName)
+		require.NoError(t, err)
+		prev := model.ChainGenesis
+		var data []byte
+		for _, rec := range records {
+			rec.PrevHash = prev
+			line, err := json.Marshal(rec)
+			require.NoError(t, err)
+			prev = model.LineHash(line)
+			data = append(append(data, line...), '\n')
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, stashName, "records.jsonl"), data, 0644))
+
+		report, err := store.VerifyChain(stashName)
+		require.NoError(t, err)
+		require.Len(t, report.Breaks, 1)
+		assert.Equal(t, 1, report.Breaks[0].Line)
+	})
+
+	t.Run("appends to an emptied log link to the head it was rewritten from", func(t *testing.T) {
+		err := store.WriteChainedRecordsFrom(stashName, "compact", func(write func(*model.Record) error) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, store.AppendChainedRecord(stashName, newRecord("ts-abc5")))
+
+		report, err := store.VerifyChain(stashName)
+		require.NoError(t, err)
+		assert.Empty(t, report.Breaks)
+		assert.Len(t, report.Rechains, 2)
+	})
+}
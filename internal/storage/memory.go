@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryDir is the base directory of an in-memory store. Passing it to
+// NewStore or NewSQLiteCache keeps everything in memory.
+const MemoryDir = ":memory:"
+
+// ErrInMemory is returned by operations that need files on disk, such as
+// attachments, when the store is in memory.
+var ErrInMemory = errors.New("not supported by an in-memory store")
+
+// memoryDBs numbers in-memory SQLite databases so that each cache gets
+// its own.
+var memoryDBs atomic.Int64
+
+// memFS holds the config and JSONL files of an in-memory store, keyed by
+// path.
+type memFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string][]byte)}
+}
+
+// readFile returns a copy of a file's contents, or an error satisfying
+// os.IsNotExist if there is no such file.
+func (m *memFS) readFile(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[path]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+// open returns a reader over a file's contents.
+func (m *memFS) open(path string) (io.ReadCloser, error) {
+	data, err := m.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// writeFile replaces a file's contents.
+func (m *memFS) writeFile(path string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = bytes.Clone(data)
+}
+
+// appendFile appends to a file, creating it if needed.
+func (m *memFS) appendFile(path string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = append(m.files[path], data...)
+}
+
+// exists returns true if the file exists.
+func (m *memFS) exists(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.files[path]
+	return ok
+}
+
+// remove deletes a file.
+func (m *memFS) remove(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, path)
+}
+
+// removeAll deletes every file under dir.
+func (m *memFS) removeAll(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := dir + string(filepath.Separator)
+	for path := range m.files {
+		if strings.HasPrefix(path, prefix) {
+			delete(m.files, path)
+		}
+	}
+}
+
+// dirs returns the sorted names of the directories directly inside dir
+// that hold a file with the given name.
+func (m *memFS) dirs(dir, name string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for path := range m.files {
+		if filepath.Base(path) == name && filepath.Dir(filepath.Dir(path)) == dir {
+			names = append(names, filepath.Base(filepath.Dir(path)))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CacheMigration is one change to the layout of a stash table in the
+// SQLite cache. Migrations are applied in order when the cache is opened,
+// and the version of the last one applied is recorded for the stash in
+// _stash_meta.
+type CacheMigration struct {
+	Version     int
+	Description string
+
+	// apply brings the table from Version-1 to Version. It must be safe to
+	// run again on a table that already has the change.
+	apply func(c *SQLiteCache, stashName string) error
+}
+
+// cacheMigrations lists every migration, oldest first. CreateStashTable
+// builds new tables in their latest layout, so a change added here must
+// be made there too.
+var cacheMigrations = []CacheMigration{
+	{
+		Version:     1,
+		Description: "Add archive and signature columns",
+		apply: func(c *SQLiteCache, stashName string) error {
+			for _, col := range []string{"archived_at", "archived_by", "signature"} {
+				if err := c.AddColumn(stashName, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Index updated_at and archived_at",
+		apply: func(c *SQLiteCache, stashName string) error {
+			tableName := sanitizeTableName(stashName)
+			for _, col := range []string{"updated", "archived"} {
+				idx := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_%s" ON "%s"(%s_at)`, tableName, col, tableName, col)
+				if _, err := c.db.Exec(idx); err != nil {
+					return fmt.Errorf("failed to create index: %w", err)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "Add assigned_to column",
+		apply: func(c *SQLiteCache, stashName string) error {
+			if err := c.AddColumn(stashName, "assigned_to"); err != nil {
+				return err
+			}
+			tableName := sanitizeTableName(stashName)
+			idx := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_assigned" ON "%s"(assigned_to)`, tableName, tableName)
+			if _, err := c.db.Exec(idx); err != nil {
+				return fmt.Errorf("failed to create index: %w", err)
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "Keep record counts in _stash_meta",
+		apply: func(c *SQLiteCache, stashName string) error {
+			if err := c.createCountTriggers(stashName); err != nil {
+				return err
+			}
+			return c.recount(stashName)
+		},
+	},
+}
+
+// CacheSchemaVersion is the cache layout version this build creates and
+// migrates stash tables to.
+var CacheSchemaVersion = cacheMigrations[len(cacheMigrations)-1].Version
+
+// metaColumns are the columns added to _stash_meta since it was first
+// created, with their definitions.
+var metaColumns = []struct{ name, definition string }{
+	{"schema_version", "INTEGER NOT NULL DEFAULT 0"},
+	{"record_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"deleted_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"last_op_at", "TEXT"},
+	{"root_seq_key", "TEXT"},
+	{"root_seq", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// initMetaColumns adds the columns a _stash_meta table created by an older
+// build lacks. A stash from before versions were recorded reads as version
+// 0, and its counts are filled in by the migration that keeps them.
+func (c *SQLiteCache) initMetaColumns() error {
+	for _, col := range metaColumns {
+		exists, err := c.columnExists("_stash_meta", col.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := c.db.Exec(fmt.Sprintf(`ALTER TABLE _stash_meta ADD COLUMN %s %s`, col.name, col.definition)); err != nil {
+			return fmt.Errorf("failed to add %s to stash metadata: %w", col.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateStashTables applies pending migrations to every stash table. A
+// stash whose migration fails is left at the last version that applied,
+// and the error is kept for doctor to report, so one bad table does not
+// stop the others from opening.
+func (c *SQLiteCache) migrateStashTables() error {
+	rows, err := c.db.Query(`SELECT stash_name FROM _stash_meta`)
+	if err != nil {
+		return fmt.Errorf("failed to list stash tables: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	for _, name := range names {
+		if err := c.Migrate(name); err != nil {
+			c.migrationErrs[name] = err
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the cache layout version of a stash table.
+func (c *SQLiteCache) SchemaVersion(stashName string) (int, error) {
+	var version int
+	err := c.db.QueryRow(`SELECT schema_version FROM _stash_meta WHERE stash_name = ?`, stashName).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no cache metadata for stash '%s'", stashName)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to a stash
+// table, oldest first. A table newer than this build has none.
+func (c *SQLiteCache) PendingMigrations(stashName string) ([]CacheMigration, error) {
+	version, err := c.SchemaVersion(stashName)
+	if err != nil {
+		return nil, err
+	}
+	var pending []CacheMigration
+	for _, m := range cacheMigrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrationError returns the error from migrating a stash table when the
+// cache was opened, or nil if it migrated cleanly.
+func (c *SQLiteCache) MigrationError(stashName string) error {
+	return c.migrationErrs[stashName]
+}
+
+// Migrate applies the pending migrations to a stash table, recording the
+// version after each one. A stash with no table yet is left alone; it is
+// created at the latest version.
+func (c *SQLiteCache) Migrate(stashName string) error {
+	exists, err := c.TableExists(stashName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	pending, err := c.PendingMigrations(stashName)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		defer c.resetStatements()
+	}
+	for _, m := range pending {
+		if err := m.apply(c, stashName); err != nil {
+			return fmt.Errorf("cache migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		if err := c.setSchemaVersion(stashName, m.Version); err != nil {
+			return err
+		}
+	}
+
+	delete(c.migrationErrs, stashName)
+	return nil
+}
+
+// setSchemaVersion records the cache layout version of a stash table.
+func (c *SQLiteCache) setSchemaVersion(stashName string, version int) error {
+	if _, err := c.db.Exec(`UPDATE _stash_meta SET schema_version = ? WHERE stash_name = ?`, version, stashName); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
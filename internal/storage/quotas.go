@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// Write rate limits are token buckets, one per stash and agent, kept in
+// the SQLite cache so every process writing to a .stash directory shares
+// them. A bucket holds up to a minute of writes and refills continuously;
+// each write takes one token. Like read tracking, the buckets are never
+// written to JSONL; they survive cache rebuilds, and an agent without one
+// starts with it full.
+
+// initRateTable creates the write rate table if it doesn't exist.
+func (c *SQLiteCache) initRateTable() error {
+	_, err := c.db.Exec(`
+		CREATE TABLE IF NOT EXISTS _write_rates (
+			stash_name TEXT NOT NULL,
+			agent TEXT NOT NULL,
+			tokens REAL NOT NULL,
+			updated_at REAL NOT NULL,
+			PRIMARY KEY (stash_name, agent)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create write rate table: %w", err)
+	}
+	return nil
+}
+
+// TakeWriteToken takes a token from an agent's bucket, which holds up to
+// perMinute tokens. When the bucket is empty it returns false and how long
+// until a token is available. The check and the take are one statement,
+// so concurrent writers cannot both take the last token.
+func (c *SQLiteCache) TakeWriteToken(stashName, agent string, perMinute int, now time.Time) (bool, time.Duration, error) {
+	capacity := float64(perMinute)
+	rate := capacity / 60 // tokens a second
+	at := float64(now.UnixNano()) / 1e9
+
+	result, err := c.db.Exec(`
+		INSERT INTO _write_rates (stash_name, agent, tokens, updated_at) VALUES (?1, ?2, ?3 - 1, ?5)
+		ON CONFLICT (stash_name, agent) DO UPDATE SET
+			tokens = MIN(?3, tokens + MAX(0, ?5 - updated_at) * ?4) - 1,
+			updated_at = MAX(updated_at, ?5)
+		WHERE MIN(?3, tokens + MAX(0, ?5 - updated_at) * ?4) >= 1
+	`, stashName, agent, capacity, rate, at)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check write rate: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return false, 0, fmt.Errorf("failed to check write rate: %w", err)
+	} else if n > 0 {
+		return true, 0, nil
+	}
+
+	var tokens, updated float64
+	err = c.db.QueryRow(`SELECT tokens, updated_at FROM _write_rates WHERE stash_name = ? AND agent = ?`,
+		stashName, agent).Scan(&tokens, &updated)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check write rate: %w", err)
+	}
+	available := math.Min(capacity, tokens+math.Max(0, at-updated)*rate)
+	wait := time.Duration(math.Ceil((1-available)/rate*1000)) * time.Millisecond
+	return false, max(wait, 0), nil
+}
+
+// ClearWriteRates empties the write rate buckets of a stash, so its agents
+// start with full buckets.
+func (c *SQLiteCache) ClearWriteRates(stashName string) error {
+	if _, err := c.db.Exec(`DELETE FROM _write_rates WHERE stash_name = ?`, stashName); err != nil {
+		return fmt.Errorf("failed to clear write rates: %w", err)
+	}
+	return nil
+}
+
+// checkRecordQuota rejects a new active record when the stash already
+// holds as many as its quota allows.
+func (s *Store) checkRecordQuota(stash *model.Stash) error {
+	limit := stash.RecordQuota()
+	if limit <= 0 {
+		return nil
+	}
+	records, _, _, err := s.sqlite.StashStats(stash.Name)
+	if err != nil {
+		return err
+	}
+	if records >= limit {
+		return &model.QuotaError{Stash: stash.Name, Records: records, Limit: limit}
+	}
+	return nil
+}
+
+// checkWriteRate takes a write from the agent's rate limit, rejecting the
+// write when the agent has used it up.
+func (s *Store) checkWriteRate(stash *model.Stash, agent string) error {
+	limit := stash.WriteRate(agent)
+	if limit <= 0 {
+		return nil
+	}
+	ok, wait, err := s.sqlite.TakeWriteToken(stash.Name, agent, limit, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &model.ThrottleError{Stash: stash.Name, Agent: agent, Limit: limit, RetryAfter: wait}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteCache_TakeWriteToken(t *testing.T) {
+	cache, err := NewSQLiteCache(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+
+	base := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	take := func(agent string, at time.Time) (bool, time.Duration) {
+		t.Helper()
+		ok, wait, err := cache.TakeWriteToken("test-stash", agent, 6, at)
+		require.NoError(t, err)
+		return ok, wait
+	}
+
+	// A full bucket allows a minute's writes at once
+	for i := 0; i < 6; i++ {
+		ok, _ := take("bot", base)
+		require.True(t, ok, "write %d", i+1)
+	}
+	ok, wait := take("bot", base)
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, wait, "6 a minute refill one every 10s")
+
+	// Other agents have their own bucket
+	ok, _ = take("alice", base)
+	assert.True(t, ok)
+
+	// The bucket refills with time, and a refused write takes nothing
+	ok, wait = take("bot", base.Add(4*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 6*time.Second, wait)
+	ok, _ = take("bot", base.Add(10*time.Second))
+	assert.True(t, ok)
+	ok, _ = take("bot", base.Add(10*time.Second))
+	assert.False(t, ok)
+
+	// It refills no further than full
+	for i := 0; i < 6; i++ {
+		ok, _ := take("bot", base.Add(time.Hour))
+		require.True(t, ok, "write %d", i+1)
+	}
+	ok, _ = take("bot", base.Add(time.Hour))
+	assert.False(t, ok)
+
+	require.NoError(t, cache.ClearWriteRates("test-stash"))
+	ok, _ = take("bot", base.Add(time.Hour))
+	assert.True(t, ok, "cleared buckets start full")
+}
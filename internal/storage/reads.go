@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Read tracking keeps when each record was last read and how often, for
+// stashes with TrackReads set. It lives only in the SQLite cache, in its
+// own table so that record upserts and cache rebuilds keep it, and is
+// never written to JSONL: reads are not changes to share through git.
+
+// Fields that order records by read tracking, as in
+// "list --order-by _last_read".
+const (
+	FieldLastRead  = "_last_read"
+	FieldReadCount = "_read_count"
+)
+
+// ReadStat is the read tracking of one record.
+type ReadStat struct {
+	ID        string     `json:"id"`
+	LastRead  *time.Time `json:"last_read"`
+	ReadCount int        `json:"read_count"`
+}
+
+// initReadsTable creates the read tracking table if it doesn't exist.
+func (c *SQLiteCache) initReadsTable() error {
+	_, err := c.db.Exec(`
+		CREATE TABLE IF NOT EXISTS _record_reads (
+			stash_name TEXT NOT NULL,
+			id TEXT NOT NULL,
+			last_read_at TEXT NOT NULL,
+			read_count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (stash_name, id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create reads table: %w", err)
+	}
+	return nil
+}
+
+// RecordReads counts a read of each record at the given time.
+func (c *SQLiteCache) RecordReads(stashName string, ids []string, at time.Time) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, id := range ids {
+		_, err := tx.Exec(`
+			INSERT INTO _record_reads (stash_name, id, last_read_at, read_count) VALUES (?, ?, ?, 1)
+			ON CONFLICT (stash_name, id) DO UPDATE SET last_read_at = excluded.last_read_at, read_count = read_count + 1
+		`, stashName, id, at.UTC().Format(time.RFC3339))
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record read: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ReadStats returns the read tracking of a stash's live records, most read
+// first, or least read first (never-read records leading) when hot is
+// false. Ties go to the most recently read, or least recently with hot
+// false. A limit of 0 returns every record.
+func (c *SQLiteCache) ReadStats(stashName string, hot bool, limit int) ([]ReadStat, error) {
+	dir := "DESC"
+	if !hot {
+		dir = "ASC"
+	}
+	query := fmt.Sprintf(`
+		SELECT t.id, r.last_read_at, COALESCE(r.read_count, 0)
+		FROM "%s" t LEFT JOIN _record_reads r ON r.stash_name = ? AND r.id = t.id
+		WHERE t.deleted_at IS NULL
+		ORDER BY COALESCE(r.read_count, 0) %s, julianday(r.last_read_at) %s, t.id ASC
+	`, sanitizeTableName(stashName), dir, dir)
+	args := []interface{}{stashName}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := c.db.QueryContext(c.ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []ReadStat{}
+	for rows.Next() {
+		var stat ReadStat
+		var lastRead *string
+		if err := rows.Scan(&stat.ID, &lastRead, &stat.ReadCount); err != nil {
+			return nil, err
+		}
+		if lastRead != nil {
+			if t, err := time.Parse(time.RFC3339, *lastRead); err == nil {
+				stat.LastRead = &t
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// ClearReads forgets the read tracking of a stash.
+func (c *SQLiteCache) ClearReads(stashName string) error {
+	if _, err := c.db.Exec(`DELETE FROM _record_reads WHERE stash_name = ?`, stashName); err != nil {
+		return fmt.Errorf("failed to clear reads: %w", err)
+	}
+	return nil
+}
+
+// readOrderExpr returns the SQL expression ordering a stash table's rows
+// by a read tracking field, or "" if field is not one.
+func readOrderExpr(stashName, tableName, field string) string {
+	// Stash names are letters, digits, - and _, so they are safe to inline
+	lookup := func(column string) string {
+		return fmt.Sprintf(`(SELECT %s FROM _record_reads r WHERE r.stash_name = '%s' AND r.id = "%s".id)`,
+			column, stashName, tableName)
+	}
+	switch strings.ToLower(field) {
+	case FieldLastRead:
+		return "julianday(" + lookup("r.last_read_at") + ")"
+	case FieldReadCount:
+		return "COALESCE(" + lookup("r.read_count") + ", 0)"
+	}
+	return ""
+}
+
+// RecordReads counts a read of each record, if the stash tracks reads.
+func (s *Store) RecordReads(stashName string, ids ...string) error {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+	if !stash.TrackReads || len(ids) == 0 {
+		return nil
+	}
+	return s.sqlite.RecordReads(stashName, ids, time.Now())
+}
+
+// ReadStats returns the read tracking of a stash's live records. See
+// SQLiteCache.ReadStats.
+func (s *Store) ReadStats(stashName string, hot bool, limit int) ([]ReadStat, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
+	return s.sqlite.ReadStats(stashName, hot, limit)
+}
+
+// ClearReads forgets the read tracking of a stash.
+func (s *Store) ClearReads(stashName string) error {
+	if err := s.loadTxStash(stashName); err != nil {
+		return err
+	}
+	return s.sqlite.ClearReads(stashName)
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestSQLiteCache_RecordReads(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "test-user"}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, id := range []string{"ts-aaaa", "ts-bbbb", "ts-cccc"} {
+		record := &model.Record{ID: id, Hash: "h", CreatedAt: now, CreatedBy: "user", UpdatedAt: now, UpdatedBy: "user", Fields: map[string]interface{}{}}
+		require.NoError(t, cache.UpsertRecord("test-stash", record, nil))
+	}
+
+	require.NoError(t, cache.RecordReads("test-stash", []string{"ts-bbbb"}, now.Add(-time.Hour)))
+	require.NoError(t, cache.RecordReads("test-stash", []string{"ts-bbbb", "ts-cccc"}, now))
+
+	t.Run("hot lists the most read first", func(t *testing.T) {
+		stats, err := cache.ReadStats("test-stash", true, 2)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, "ts-bbbb", stats[0].ID)
+		assert.Equal(t, 2, stats[0].ReadCount)
+		require.NotNil(t, stats[0].LastRead)
+		assert.True(t, stats[0].LastRead.Equal(now))
+		assert.Equal(t, "ts-cccc", stats[1].ID)
+	})
+
+	t.Run("cold lists never-read records first", func(t *testing.T) {
+		stats, err := cache.ReadStats("test-stash", false, 0)
+		require.NoError(t, err)
+		require.Len(t, stats, 3)
+		assert.Equal(t, "ts-aaaa", stats[0].ID)
+		assert.Nil(t, stats[0].LastRead)
+		assert.Equal(t, 0, stats[0].ReadCount)
+	})
+
+	t.Run("reads survive record updates", func(t *testing.T) {
+		record := &model.Record{ID: "ts-bbbb", Hash: "h2", CreatedAt: now, CreatedBy: "user", UpdatedAt: now, UpdatedBy: "user", Fields: map[string]interface{}{}}
+		require.NoError(t, cache.UpsertRecord("test-stash", record, nil))
+		stats, err := cache.ReadStats("test-stash", true, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats[0].ReadCount)
+	})
+
+	t.Run("order by read tracking", func(t *testing.T) {
+		keys, err := ParseOrderBy("_read_count desc", false)
+		require.NoError(t, err)
+		records, err := cache.ListRecords("test-stash", nil, ListOptions{ParentID: "*", OrderBy: keys})
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"ts-bbbb", "ts-cccc", "ts-aaaa"}, []string{records[0].ID, records[1].ID, records[2].ID})
+
+		keys, err = ParseOrderBy("_last_read", false)
+		require.NoError(t, err)
+		records, err = cache.ListRecords("test-stash", nil, ListOptions{ParentID: "*", OrderBy: keys})
+		require.NoError(t, err)
+		assert.Equal(t, "ts-aaaa", records[0].ID, "never-read records sort first")
+	})
+
+	t.Run("clear forgets reads", func(t *testing.T) {
+		require.NoError(t, cache.ClearReads("test-stash"))
+		stats, err := cache.ReadStats("test-stash", true, 0)
+		require.NoError(t, err)
+		for _, stat := range stats {
+			assert.Zero(t, stat.ReadCount)
+		}
+	})
+}
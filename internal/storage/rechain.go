@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// rechainFile names the file beside records.jsonl that lists the rewrites
+// of its hash chain.
+const rechainFile = "records.jsonl.chain"
+
+// Rechain records a rewrite of a hash-chained log, by compaction, a prefix
+// migration, or enabling the chain. The rewritten log's first line links
+// to the head the log had before, so the chain runs on across the rewrite
+// and a copy of the old log can still be checked against it.
+type Rechain struct {
+	At       time.Time `json:"at"`
+	Reason   string    `json:"reason"`    // "compact", "prefix", or "enable"
+	PrevHead string    `json:"prev_head"` // hash of the last line of the log replaced
+}
+
+func (s *JSONLStore) getRechainPath(stashName string) string {
+	return filepath.Join(s.baseDir, stashName, rechainFile)
+}
+
+// chainHead returns the hash the next chained line of a stash's log links
+// to: that of its last line or, for an empty log, the head recorded by its
+// latest rewrite, or the chain genesis hash.
+func (s *JSONLStore) chainHead(stashName string) (string, error) {
+	line, err := s.readLastLine(s.getRecordsPath(stashName))
+	if err != nil {
+		return "", err
+	}
+	if len(line) > 0 {
+		return model.LineHash(line), nil
+	}
+	rechains, err := s.ReadRechains(stashName)
+	if err != nil {
+		return "", err
+	}
+	if len(rechains) > 0 {
+		return rechains[len(rechains)-1].PrevHead, nil
+	}
+	return model.ChainGenesis, nil
+}
+
+// recordRechain appends a rewrite of a stash's log from the head prevHead
+// to its rechain file. Nothing is recorded for an unchained rewrite, or
+// one that replaced a log never written to.
+func (s *JSONLStore) recordRechain(stashName, reason, prevHead string) error {
+	if reason == "" || prevHead == model.ChainGenesis {
+		return nil
+	}
+	return s.appendRechain(stashName, Rechain{At: time.Now().UTC(), Reason: reason, PrevHead: prevHead})
+}
+
+// appendRechain appends a rewrite to a stash's rechain file.
+func (s *JSONLStore) appendRechain(stashName string, rechain Rechain) error {
+	data, err := json.Marshal(rechain)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rechain: %w", err)
+	}
+	data = append(data, '\n')
+
+	path := s.getRechainPath(stashName)
+	if s.mem != nil {
+		s.mem.appendFile(path, data)
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to record rechain: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to record rechain: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadRechains returns the recorded rewrites of a stash's hash chain,
+// oldest first.
+func (s *JSONLStore) ReadRechains(stashName string) ([]Rechain, error) {
+	path := s.getRechainPath(stashName)
+	var data []byte
+	var err error
+	if s.mem != nil {
+		data, err = s.mem.readFile(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Rechain{}, nil
+		}
+		return nil, fmt.Errorf("failed to read rechains: %w", err)
+	}
+
+	rechains := []Rechain{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rechain Rechain
+		if err := json.Unmarshal(scanner.Bytes(), &rechain); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", rechainFile, lineNum, err)
+		}
+		rechains = append(rechains, rechain)
+	}
+	return rechains, scanner.Err()
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/logging"
+	"github.com/user/stash/internal/platform"
+)
+
+// tornPrefix starts the name of the file a torn final line is moved to.
+const tornPrefix = "records.jsonl.torn-"
+
+// TornWrite describes an incomplete final line that was cut off the end
+// of a stash's log.
+type TornWrite struct {
+	Stash      string `json:"stash"`
+	Bytes      int    `json:"bytes"`      // length of the incomplete line
+	Quarantine string `json:"quarantine"` // file the line was moved to
+}
+
+// RecoverTornWrite checks the end of a stash's log for a final line cut
+// off mid-write, as left by a crash or power loss while another program
+// (or an older stash) wrote the file in place. stash's own writes replace
+// the log whole, so a torn line is never one of them in progress.
+//
+// The torn line is moved to a records.jsonl.torn-<time> file beside the
+// log, and the log is cut back to its last complete line, so that it
+// parses again and new operations are not appended onto the fragment. A
+// last line that is complete JSON and lacks only its newline gets one.
+// Returns nil if the log did not need recovering.
+func (s *JSONLStore) RecoverTornWrite(stashName string) (*TornWrite, error) {
+	if s.mem != nil {
+		return nil, nil
+	}
+	path := s.getRecordsPath(stashName)
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat records file: %w", err)
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return nil, fmt.Errorf("failed to read records file: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil, nil
+	}
+
+	// Find where the last line starts
+	var fragment []byte
+	cut := int64(0)
+	for tail := int64(64 * 1024); ; tail *= 2 {
+		offset := max(size-tail, 0)
+		buf := make([]byte, size-offset)
+		if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read records file: %w", err)
+		}
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			cut, fragment = offset+int64(i)+1, buf[i+1:]
+			break
+		}
+		if offset == 0 || tail >= maxLineSize {
+			cut, fragment = offset, buf
+			break
+		}
+	}
+
+	if len(bytes.TrimSpace(fragment)) == 0 || json.Valid(fragment) {
+		if _, err := file.WriteAt([]byte{'\n'}, size); err != nil {
+			return nil, fmt.Errorf("failed to end records file: %w", err)
+		}
+		return nil, file.Sync()
+	}
+
+	dir := filepath.Dir(path)
+	quarantine := filepath.Join(dir, tornPrefix+time.Now().UTC().Format("20060102T150405.000000000Z"))
+	if err := writeFileSync(quarantine, fragment); err != nil {
+		return nil, fmt.Errorf("failed to quarantine torn write: %w", err)
+	}
+	if err := file.Truncate(cut); err != nil {
+		return nil, fmt.Errorf("failed to truncate records file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync records file: %w", err)
+	}
+	if err := platform.SyncDir(dir); err != nil {
+		return nil, fmt.Errorf("failed to sync stash directory: %w", err)
+	}
+
+	torn := &TornWrite{Stash: stashName, Bytes: len(fragment), Quarantine: quarantine}
+	logging.Debug("jsonl torn write", "stash", stashName, "bytes", torn.Bytes, "quarantine", quarantine)
+	s.mu.Lock()
+	s.torn = append(s.torn, *torn)
+	s.mu.Unlock()
+	return torn, nil
+}
+
+// TornWrites returns the torn writes recovered by this store.
+func (s *JSONLStore) TornWrites() []TornWrite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]TornWrite(nil), s.torn...)
+}
+
+// QuarantinedWrites returns the files torn lines of a stash's log have been
+// moved to, oldest first. They are kept until removed by hand.
+func (s *JSONLStore) QuarantinedWrites(stashName string) ([]string, error) {
+	if s.mem != nil {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(s.baseDir, stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), tornPrefix) {
+			paths = append(paths, filepath.Join(s.baseDir, stashName, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// syncDir flushes the stash directory, so a log just renamed into place
+// survives power loss.
+func (s *JSONLStore) syncDir(stashName string) error {
+	if s.mem != nil {
+		return nil
+	}
+	if err := platform.SyncDir(filepath.Join(s.baseDir, stashName)); err != nil {
+		return fmt.Errorf("failed to sync stash directory: %w", err)
+	}
+	return nil
+}
+
+// writeFileSync writes data to a new file and syncs it.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recoverTornWrites recovers the log of every stash in the directory.
+// Failures are logged rather than returned: a log that cannot be
+// recovered fails to parse when read, as it did before.
+func (s *JSONLStore) recoverTornWrites() {
+	if s.mem != nil {
+		return
+	}
+	entries, err := os.ReadDir(s.baseDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := s.RecoverTornWrite(entry.Name()); err != nil {
+			logging.Debug("jsonl torn write", "stash", entry.Name(), "error", err)
+		}
+	}
+}
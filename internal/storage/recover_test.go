@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_TornWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	stash := &model.Stash{
+		Name:       "test-stash",
+		Prefix:     "ts-",
+		Created:    time.Now(),
+		CreatedBy:  "test-user",
+		Durability: model.DurabilityFull,
+		Columns:    model.ColumnList{{Name: "name", Added: time.Now(), AddedBy: "test-user"}},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+	create := func(store *Store, id string) error {
+		now := time.Now()
+		fields := map[string]interface{}{"name": id}
+		return store.CreateRecord("test-stash", &model.Record{
+			ID: id, Fields: fields,
+			CreatedAt: now, CreatedBy: "test-user", UpdatedAt: now, UpdatedBy: "test-user",
+		})
+	}
+	require.NoError(t, create(store, "ts-aaaa"))
+	store.Close()
+
+	logPath := filepath.Join(tmpDir, "test-stash", "records.jsonl")
+	intact, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	appendRaw := func(data string) {
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString(data)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	t.Run("opening the store quarantines a torn last line", func(t *testing.T) {
+		appendRaw(`{"id":"ts-bbbb","_op":"cre`)
+
+		store, err := NewStore(tmpDir)
+		require.NoError(t, err)
+		defer store.Close()
+
+		torn := store.TornWrites()
+		require.Len(t, torn, 1)
+		assert.Equal(t, "test-stash", torn[0].Stash)
+		assert.Equal(t, len(`{"id":"ts-bbbb","_op":"cre`), torn[0].Bytes)
+
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, string(intact), string(data))
+		fragment, err := os.ReadFile(torn[0].Quarantine)
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":"ts-bbbb","_op":"cre`, string(fragment))
+
+		quarantined, err := store.QuarantinedWrites("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, []string{torn[0].Quarantine}, quarantined)
+
+		require.NoError(t, create(store, "ts-bbbb"))
+		records, err := store.jsonl.ReadAllRecords("test-stash")
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("appends recover a log torn while open", func(t *testing.T) {
+		store, err := NewStore(tmpDir)
+		require.NoError(t, err)
+		defer store.Close()
+		assert.Empty(t, store.TornWrites())
+
+		appendRaw(`{"id":"ts-cc`)
+		require.NoError(t, create(store, "ts-dddd"))
+		assert.Len(t, store.TornWrites(), 1)
+		records, err := store.jsonl.ReadAllRecords("test-stash")
+		require.NoError(t, err)
+		assert.Len(t, records, 3)
+	})
+
+	t.Run("a complete last line only gets its newline", func(t *testing.T) {
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(logPath, []byte(strings.TrimSuffix(string(data), "\n")), 0644))
+
+		store, err := NewStore(tmpDir)
+		require.NoError(t, err)
+		defer store.Close()
+		assert.Empty(t, store.TornWrites())
+
+		fixed, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, string(data), string(fixed))
+	})
+}
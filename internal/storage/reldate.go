@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeDateRegex splits a relative date into its base (now or today)
+// and a signed offset, as in "now+2w", "today-1d", or "-7d".
+var relativeDateRegex = regexp.MustCompile(`(?i)^(now|today)?\s*(?:([+-])\s*(\S+))?$`)
+
+// offsetStartRegex matches the start of a date offset: a number and a
+// unit, or an ISO-8601 duration.
+var offsetStartRegex = regexp.MustCompile(`(?i)^(?:\d+(?:y|mo|w|d|h|m|s)|PT?\d)`)
+
+// shortDurationRegex matches one or more number-unit pairs, as in "7d" or
+// "1d12h". Months (mo) and years (y) are calendar units.
+var shortDurationRegex = regexp.MustCompile(`^(?:\d+(?:y|mo|w|d|h|m|s))+$`)
+
+// shortDurationPartRegex matches one number-unit pair of a short duration.
+var shortDurationPartRegex = regexp.MustCompile(`(\d+)(y|mo|w|d|h|m|s)`)
+
+// isoDurationRegex matches an ISO-8601 duration such as P7D or PT12H.
+var isoDurationRegex = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseRelativeDate resolves a relative date against now. It accepts "now"
+// and "today" (the start of the current calendar day), optionally followed
+// by a signed offset, or a bare signed offset relative to now:
+//
+//	now, today, -7d, +2w, now+2w, today-1d, now-PT12H, -P1M
+//
+// Offsets are a number and a unit (y, mo, w, d, h, m, s), possibly
+// repeated as in 1d12h, or an ISO-8601 duration. ok is false if the value
+// is not a relative date, so it can be compared as written; err is set if
+// it looks like one but cannot be parsed.
+func ParseRelativeDate(value string, now time.Time) (t time.Time, ok bool, err error) {
+	value = strings.TrimSpace(value)
+	matches := relativeDateRegex.FindStringSubmatch(value)
+	if matches == nil || value == "" {
+		if lower := strings.ToLower(value); strings.HasPrefix(lower, "now") || strings.HasPrefix(lower, "today") {
+			return time.Time{}, false, fmt.Errorf("invalid relative date '%s' (e.g. now+2w, today-1d)", value)
+		}
+		return time.Time{}, false, nil
+	}
+	base, sign, offset := strings.ToLower(matches[1]), matches[2], matches[3]
+
+	// A bare signed value is a relative date only if it has a unit, so
+	// numbers such as -7 are left alone
+	if base == "" && !offsetStartRegex.MatchString(offset) {
+		return time.Time{}, false, nil
+	}
+
+	t = now.UTC()
+	if base == "today" {
+		// Date-only values are stored without a zone, so today is the
+		// local calendar date at midnight
+		y, m, d := now.Date()
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	if sign == "" {
+		return t, true, nil
+	}
+
+	years, months, days, clock, err := parseDateOffset(offset)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid relative date '%s': %w", value, err)
+	}
+	if sign == "-" {
+		years, months, days, clock = -years, -months, -days, -clock
+	}
+	return t.AddDate(years, months, days).Add(clock), true, nil
+}
+
+// parseDateOffset splits an offset into calendar parts and a clock
+// duration, so that months and days follow the calendar.
+func parseDateOffset(offset string) (years, months, days int, clock time.Duration, err error) {
+	if upper := strings.ToUpper(offset); strings.HasPrefix(upper, "P") {
+		matches := isoDurationRegex.FindStringSubmatch(upper)
+		if matches == nil || upper == "P" || strings.HasSuffix(upper, "T") {
+			return 0, 0, 0, 0, fmt.Errorf("'%s' is not an ISO-8601 duration (e.g. P7D, PT12H)", offset)
+		}
+		n := make([]int, len(matches))
+		for i := 1; i < len(matches); i++ {
+			if matches[i] != "" {
+				n[i], _ = strconv.Atoi(matches[i])
+			}
+		}
+		clock = time.Duration(n[5])*time.Hour + time.Duration(n[6])*time.Minute + time.Duration(n[7])*time.Second
+		return n[1], n[2], n[3]*7 + n[4], clock, nil
+	}
+
+	offset = strings.ToLower(offset)
+	if !shortDurationRegex.MatchString(offset) {
+		return 0, 0, 0, 0, fmt.Errorf("'%s' is not a duration (e.g. 7d, 2w, 12h, 3mo)", offset)
+	}
+	for _, part := range shortDurationPartRegex.FindAllStringSubmatch(offset, -1) {
+		n, _ := strconv.Atoi(part[1])
+		switch part[2] {
+		case "y":
+			years += n
+		case "mo":
+			months += n
+		case "w":
+			days += n * 7
+		case "d":
+			days += n
+		case "h":
+			clock += time.Duration(n) * time.Hour
+		case "m":
+			clock += time.Duration(n) * time.Minute
+		case "s":
+			clock += time.Duration(n) * time.Second
+		}
+	}
+	return years, months, days, clock, nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelativeDate(t *testing.T) {
+	now := time.Date(2025, 1, 31, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"now", now},
+		{"NOW", now},
+		{"today", today},
+		{"-7d", now.AddDate(0, 0, -7)},
+		{"+2w", now.AddDate(0, 0, 14)},
+		{"now+2w", now.AddDate(0, 0, 14)},
+		{"now - 12h", now.Add(-12 * time.Hour)},
+		{"today-1d", today.AddDate(0, 0, -1)},
+		{"-1d12h", now.AddDate(0, 0, -1).Add(-12 * time.Hour)},
+		{"now+1mo", now.AddDate(0, 1, 0)},
+		{"-1y", now.AddDate(-1, 0, 0)},
+		{"-P7D", now.AddDate(0, 0, -7)},
+		{"now-PT12H", now.Add(-12 * time.Hour)},
+		{"+P1Y2M3DT4H", now.AddDate(1, 2, 3).Add(4 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, ok, err := ParseRelativeDate(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, ok, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %s, want %s", tt.value, got, tt.want)
+	}
+
+	for _, value := range []string{"", "2025-01-08", "100", "-7", "-1e5", "abc"} {
+		_, ok, err := ParseRelativeDate(value, now)
+		assert.NoError(t, err, value)
+		assert.False(t, ok, "%q is not a relative date", value)
+	}
+
+	for _, value := range []string{"now+2q", "today-", "now 2w", "now+7dd", "-P1X"} {
+		_, _, err := ParseRelativeDate(value, now)
+		assert.Error(t, err, value)
+	}
+}
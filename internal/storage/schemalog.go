@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/stash/internal/model"
+)
+
+// getSchemaLogPath returns the path to schema.jsonl for a stash.
+func (s *ConfigStore) getSchemaLogPath(stashName string) string {
+	return filepath.Join(s.baseDir, stashName, "schema.jsonl")
+}
+
+// schemaLogExists returns true if the stash has a schema log.
+func (s *ConfigStore) schemaLogExists(stashName string) bool {
+	path := s.getSchemaLogPath(stashName)
+	if s.mem != nil {
+		return s.mem.exists(path)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// AppendSchemaLog appends schema changes to a stash's schema.jsonl.
+func (s *ConfigStore) AppendSchemaLog(stashName string, changes []model.SchemaChange) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			return fmt.Errorf("failed to marshal schema change: %w", err)
+		}
+	}
+
+	path := s.getSchemaLogPath(stashName)
+	if s.mem != nil {
+		s.mem.appendFile(path, buf.Bytes())
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open schema log: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write schema log: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadSchemaLog returns the schema changes logged for a stash, oldest
+// first. A stash without a schema log has none.
+func (s *ConfigStore) ReadSchemaLog(stashName string) ([]model.SchemaChange, error) {
+	path := s.getSchemaLogPath(stashName)
+	var r io.ReadCloser
+	var err error
+	if s.mem != nil {
+		r, err = s.mem.open(path)
+	} else {
+		r, err = os.Open(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open schema log: %w", err)
+	}
+	defer r.Close()
+
+	var changes []model.SchemaChange
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var change model.SchemaChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, fmt.Errorf("schema log line %d: %w", lineNum, err)
+		}
+		changes = append(changes, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema log: %w", err)
+	}
+	return changes, nil
+}
+
+// SetActor sets how the store names the actor making a schema change to
+// a stash, for the stash's schema log.
+func (s *Store) SetActor(actor func(stash string) string) {
+	s.actor = actor
+}
+
+// SchemaLog returns the schema changes of a stash, oldest first. A stash
+// created before schema changes were logged, and not changed since, has
+// its creation and columns described from its config.
+func (s *Store) SchemaLog(stashName string) ([]model.SchemaChange, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+	if !s.config.schemaLogExists(stashName) {
+		return model.DiffSchema(nil, stash, time.Time{}, ""), nil
+	}
+	return s.config.ReadSchemaLog(stashName)
+}
+
+// logSchema appends the changes from old to stash to the stash's schema
+// log. When a stash gets its first logged change, its state before the
+// change is logged first, so the log starts with the stash's creation.
+func (s *Store) logSchema(old, stash *model.Stash) error {
+	var actor string
+	if s.actor != nil {
+		actor = s.actor(stash.Name)
+	}
+	changes := model.DiffSchema(old, stash, time.Now().UTC(), actor)
+	if len(changes) == 0 {
+		return nil
+	}
+	if old != nil && !s.config.schemaLogExists(stash.Name) {
+		changes = append(model.DiffSchema(nil, old, time.Time{}, ""), changes...)
+	}
+	return s.config.AppendSchemaLog(stash.Name, changes)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_SchemaLog(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".stash")
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	store.SetActor(func(stash string) string { return "bob@" + stash })
+
+	now := time.Now().UTC()
+	stash := &model.Stash{Name: "inventory", Prefix: "inv-", Created: now, CreatedBy: "alice"}
+	require.NoError(t, store.CreateStash("inventory", "inv-", stash))
+	require.NoError(t, store.AddColumn("inventory", model.Column{Name: "Price", Added: now, AddedBy: "carol"}))
+
+	stash, err = store.GetStash("inventory")
+	require.NoError(t, err)
+	stash.Columns[0].Validate = "number"
+	require.NoError(t, store.UpdateStashConfig(stash))
+	// Unchanged configs are not logged
+	require.NoError(t, store.UpdateStashConfig(stash))
+
+	ops := func() []string {
+		t.Helper()
+		changes, err := store.SchemaLog("inventory")
+		require.NoError(t, err)
+		var ops []string
+		for _, c := range changes {
+			ops = append(ops, c.Op+":"+c.Column+":"+c.Actor)
+		}
+		return ops
+	}
+	assert.Equal(t, []string{
+		"stash_create::alice",
+		"column_add:Price:carol",
+		"column_change:Price:bob@inventory",
+	}, ops())
+
+	t.Run("stash without a log", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dir, "inventory", "schema.jsonl")))
+		assert.Equal(t, []string{"stash_create::alice", "column_add:Price:carol"}, ops())
+
+		// The first logged change starts the log with the stash's creation
+		stash.Prefix = "it-"
+		require.NoError(t, store.UpdateStashConfig(stash))
+		assert.Equal(t, []string{
+			"stash_create::alice",
+			"column_add:Price:carol",
+			"settings_change::bob@inventory",
+		}, ops())
+	})
+}
+
+func TestMemoryStore_SchemaLog(t *testing.T) {
+	store, err := NewMemoryStore()
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	stash := &model.Stash{Name: "notes", Prefix: "nt-", Created: time.Now()}
+	require.NoError(t, store.CreateStash("notes", "nt-", stash))
+	require.NoError(t, store.AddColumn("notes", model.Column{Name: "Body", Added: time.Now()}))
+
+	changes, err := store.SchemaLog("notes")
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, model.SchemaColumnAdd, changes[1].Op)
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Search modes for ListOptions.SearchMode.
+const (
+	// SearchExact matches the term as a case-sensitive substring.
+	SearchExact = "exact"
+	// SearchCI matches the term as a substring ignoring case and accents,
+	// so "laptop" matches "Laptop" and "láptop". It is the default.
+	SearchCI = "ci"
+	// SearchFuzzy matches values with a word similar to the term by
+	// trigram similarity, tolerating typos.
+	SearchFuzzy = "fuzzy"
+)
+
+// SearchModes lists the valid search modes.
+var SearchModes = []string{SearchExact, SearchCI, SearchFuzzy}
+
+// FuzzyThreshold is the minimum trigram similarity for a fuzzy match.
+const FuzzyThreshold = 0.3
+
+// IsValidSearchMode returns true if the mode is a known search mode.
+func IsValidSearchMode(mode string) bool {
+	for _, m := range SearchModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSearch reports whether a value matches a search term in the given
+// mode. It applies the same rules as ListOptions.Search, for callers that
+// filter records in memory.
+func MatchesSearch(value, term, mode string) bool {
+	switch mode {
+	case SearchExact:
+		return strings.Contains(value, term)
+	case SearchFuzzy:
+		return WordSimilarity(value, term) >= FuzzyThreshold
+	default:
+		return strings.Contains(FoldText(value), FoldText(term))
+	}
+}
+
+// searchSeparator separates the column values searched as one text, so a
+// match cannot span two values.
+const searchSeparator = "\x1f"
+
+// searchCondition returns the SQL condition matching any of the columns
+// against the search term, and the one argument it binds. The columns are
+// searched as a single text of their values, so the condition binds one
+// parameter however wide the stash is.
+func searchCondition(columns []string, term, mode string) (string, interface{}) {
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = fmt.Sprintf(`coalesce("%s", '')`, col)
+	}
+	text := joinBalanced(values, " || char(31) || ")
+	switch mode {
+	case SearchExact:
+		return fmt.Sprintf(`instr(%s, ?) > 0`, text), term
+	case SearchFuzzy:
+		return fmt.Sprintf(`stash_similarity(%s, ?) >= %g`, text, FuzzyThreshold), term
+	default:
+		return fmt.Sprintf(`instr(stash_fold(%s), ?) > 0`, text), FoldText(term)
+	}
+}
+
+// balancedJoinMax is the longest chain of terms joinBalanced joins flat.
+const balancedJoinMax = 64
+
+// joinBalanced joins SQL terms with an operator as strings.Join does, but
+// nests long chains in parentheses as a balanced tree. SQLite parses a
+// flat chain of n terms as an expression n deep, and rejects expressions
+// over 1000 deep.
+func joinBalanced(terms []string, op string) string {
+	if len(terms) <= balancedJoinMax {
+		return strings.Join(terms, op)
+	}
+	mid := len(terms) / 2
+	return "(" + joinBalanced(terms[:mid], op) + ")" + op + "(" + joinBalanced(terms[mid:], op) + ")"
+}
+
+// searchText converts a value passed to a search function to text.
+func searchText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// accentFolds maps accented Latin letters to their unaccented forms.
+var accentFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'ř': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+	'æ': "ae", 'œ': "oe",
+}
+
+// FoldText lowercases text and strips accents from Latin letters, for
+// case- and accent-insensitive comparison.
+func FoldText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		if fold, ok := accentFolds[r]; ok {
+			b.WriteString(fold)
+		} else if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// searchWords splits folded text into words of letters and digits.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(FoldText(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams returns the trigram set of a phrase, padding each word as
+// pg_trgm does so that word starts weigh more than word ends.
+func trigrams(words []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// similarity returns the Jaccard similarity of two trigram sets.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// fieldsSimilarity returns the highest WordSimilarity between the term and
+// any of the values joined by searchSeparator.
+func fieldsSimilarity(values, term string) float64 {
+	best := 0.0
+	for _, value := range strings.Split(values, searchSeparator) {
+		best = max(best, WordSimilarity(value, term))
+	}
+	return best
+}
+
+// WordSimilarity returns the highest trigram similarity between the term
+// and any run of as many consecutive words in the value, from 0 to 1.
+func WordSimilarity(value, term string) float64 {
+	termWords := searchWords(term)
+	valueWords := searchWords(value)
+	if len(termWords) == 0 || len(valueWords) == 0 {
+		return 0
+	}
+
+	termSet := trigrams(termWords)
+	span := min(len(termWords), len(valueWords))
+	best := 0.0
+	for i := 0; i+span <= len(valueWords); i++ {
+		if s := similarity(termSet, trigrams(valueWords[i:i+span])); s > best {
+			best = s
+		}
+	}
+	return best
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestFoldText(t *testing.T) {
+	assert.Equal(t, "laptop", FoldText("Láptop"))
+	assert.Equal(t, "creme brulee", FoldText("Crème Brûlée"))
+	assert.Equal(t, "strasse", FoldText("Straße"))
+	assert.Equal(t, "cafe", FoldText("café"), "combining accents are dropped")
+}
+
+func TestWordSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, WordSimilarity("Gaming Laptop", "laptop"))
+	assert.GreaterOrEqual(t, WordSimilarity("Gaming Laptop", "laptp"), FuzzyThreshold)
+	assert.GreaterOrEqual(t, WordSimilarity("Gaming Laptop", "gaming laptp"), FuzzyThreshold)
+	assert.Less(t, WordSimilarity("Gaming Laptop", "keyboard"), FuzzyThreshold)
+	assert.Equal(t, 0.0, WordSimilarity("", "laptop"))
+}
+
+func TestMatchesSearch(t *testing.T) {
+	assert.True(t, MatchesSearch("Láptop stand", "laptop", SearchCI))
+	assert.True(t, MatchesSearch("Láptop stand", "laptop", ""), "ci is the default")
+	assert.False(t, MatchesSearch("Laptop stand", "laptop", SearchExact))
+	assert.True(t, MatchesSearch("Laptop stand", "Laptop", SearchExact))
+	assert.True(t, MatchesSearch("Laptop stand", "laptp", SearchFuzzy))
+	assert.False(t, MatchesSearch("Laptop stand", "monitor", SearchFuzzy))
+}
+
+func TestSQLiteCache_SearchModes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	columns := []string{"name"}
+	now := time.Now()
+	for i, name := range []string{"Laptop", "láptop bag", "Monitor"} {
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: "ts-abc" + string(rune('1'+i)), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now.Add(time.Duration(i) * time.Second), UpdatedBy: "user",
+			Fields: map[string]interface{}{"name": name},
+		}, columns))
+	}
+
+	search := func(term, mode string) []string {
+		records, err := cache.ListRecords("test-stash", columns, ListOptions{ParentID: "*", Search: term, SearchMode: mode})
+		require.NoError(t, err)
+		var names []string
+		for _, r := range records {
+			names = append(names, r.Fields["name"].(string))
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"Laptop", "láptop bag"}, search("laptop", SearchCI))
+	assert.ElementsMatch(t, []string{"Laptop", "láptop bag"}, search("LAPTOP", ""))
+	assert.ElementsMatch(t, []string{"Laptop"}, search("Lap", SearchExact))
+	assert.ElementsMatch(t, []string{"Laptop", "láptop bag"}, search("laptp", SearchFuzzy))
+	assert.Empty(t, search("laptp", SearchCI))
+}
+
+func TestSQLiteCache_WideStash(t *testing.T) {
+	cache, err := NewSQLiteCache(t.TempDir())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	now := time.Now()
+	stash := &model.Stash{Name: "wide", Prefix: "wd-", Created: now}
+	var columns []string
+	for i := 1; i <= 200; i++ {
+		name := fmt.Sprintf("C%d", i)
+		stash.Columns = append(stash.Columns, model.Column{Name: name, Added: now})
+		columns = append(columns, name)
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+	for i, fields := range []map[string]interface{}{
+		{"C1": "first", "C150": "Blue Whale"},
+		{"C1": "second", "C149": "blue", "C150": "whale"},
+	} {
+		require.NoError(t, cache.UpsertRecord("wide", &model.Record{
+			ID: fmt.Sprintf("wd-000%d", i+1), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: fields,
+		}, columns))
+	}
+
+	ids := func(opts ListOptions) []string {
+		t.Helper()
+		opts.ParentID = "*"
+		records, err := cache.ListRecords("wide", columns, opts)
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	t.Run("search binds one parameter", func(t *testing.T) {
+		_, args := cache.buildWhere("wide", columns, ListOptions{ParentID: "*", Search: "whale"})
+		assert.Len(t, args, 1)
+
+		assert.ElementsMatch(t, []string{"wd-0001", "wd-0002"}, ids(ListOptions{Search: "WHALE"}))
+		assert.ElementsMatch(t, []string{"wd-0001"}, ids(ListOptions{Search: "Whale", SearchMode: SearchExact}))
+		assert.ElementsMatch(t, []string{"wd-0002"}, ids(ListOptions{Search: "wd-0002"}))
+	})
+
+	t.Run("matches do not span columns", func(t *testing.T) {
+		assert.Equal(t, []string{"wd-0001"}, ids(ListOptions{Search: "blue whale"}))
+		assert.Empty(t, ids(ListOptions{Search: "bluewhale", SearchMode: SearchExact}))
+	})
+
+	t.Run("many conditions", func(t *testing.T) {
+		var where []WhereCondition
+		for i := 0; i < 6; i++ {
+			for _, col := range columns[1:148] {
+				where = append(where, WhereCondition{Field: col, Operator: "IS EMPTY"})
+			}
+		}
+		where = append(where, WhereCondition{Field: "C1", Operator: "=", Value: "first"})
+		assert.Equal(t, []string{"wd-0001"}, ids(ListOptions{Where: where, Search: "whale"}))
+	})
+
+	t.Run("past SQLite's limits", func(t *testing.T) {
+		pattern := strings.Repeat("%a", 30000)
+		_, err := cache.ListRecords("wide", columns, ListOptions{ParentID: "*", Where: []WhereCondition{{Field: "C1", Operator: "LIKE", Value: pattern}}})
+		var tooLarge *QueryTooLargeError
+		require.ErrorAs(t, err, &tooLarge)
+		assert.Contains(t, tooLarge.Reason, "LIKE or GLOB pattern too complex")
+	})
+}
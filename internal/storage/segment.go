@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sealFile names the file beside records.jsonl that records its sealed
+// segment.
+const sealFile = "records.jsonl.sum"
+
+// Segment is the start of a stash's log as it was when stash last wrote
+// the log whole, by compaction or another rewrite: its size, record count,
+// and SHA-256 checksum. Operations appended later follow it. While the
+// segment's bytes are unchanged, its lines are known to be the valid
+// records stash wrote, so integrity checks only need to read the rest.
+type Segment struct {
+	Bytes    int64     `json:"bytes"`
+	Records  int       `json:"records"`
+	Checksum string    `json:"checksum"` // "sha256:<hex>"
+	SealedAt time.Time `json:"sealed_at"`
+}
+
+// SegmentReport is the result of checking a log against its sealed
+// segment.
+type SegmentReport struct {
+	Sealed  bool     `json:"sealed"` // a segment was recorded
+	Valid   bool     `json:"valid"`  // the log still starts with it
+	Segment *Segment `json:"segment,omitempty"`
+	Problem string   `json:"problem,omitempty"` // why it is not valid
+}
+
+// segmentWriter passes a log through as it is written, summing it up as a
+// segment.
+type segmentWriter struct {
+	w     io.Writer
+	hash  hash.Hash
+	bytes int64
+	lines int
+}
+
+func newSegmentWriter(w io.Writer) *segmentWriter {
+	return &segmentWriter{w: w, hash: sha256.New()}
+}
+
+func (sw *segmentWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	sw.hash.Write(p[:n])
+	sw.bytes += int64(n)
+	sw.lines += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
+
+func (sw *segmentWriter) segment() *Segment {
+	return &Segment{
+		Bytes:    sw.bytes,
+		Records:  sw.lines,
+		Checksum: "sha256:" + hex.EncodeToString(sw.hash.Sum(nil)),
+		SealedAt: time.Now().UTC(),
+	}
+}
+
+func (s *JSONLStore) getSealPath(stashName string) string {
+	return filepath.Join(s.baseDir, stashName, sealFile)
+}
+
+// writeSeal records the segment a stash's log was just rewritten as.
+func (s *JSONLStore) writeSeal(stashName string, segment *Segment) error {
+	data, err := json.MarshalIndent(segment, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal segment: %w", err)
+	}
+	data = append(data, '\n')
+
+	path := s.getSealPath(stashName)
+	if s.mem != nil {
+		s.mem.writeFile(path, data)
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write segment checksum: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write segment checksum: %w", err)
+	}
+	return nil
+}
+
+// ReadSegment returns the sealed segment of a stash's log, or nil if none
+// has been recorded.
+func (s *JSONLStore) ReadSegment(stashName string) (*Segment, error) {
+	path := s.getSealPath(stashName)
+	var data []byte
+	var err error
+	if s.mem != nil {
+		data, err = s.mem.readFile(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read segment checksum: %w", err)
+	}
+	var segment Segment
+	if err := json.Unmarshal(data, &segment); err != nil {
+		return nil, fmt.Errorf("failed to parse segment checksum: %w", err)
+	}
+	return &segment, nil
+}
+
+// VerifySegment checks that a stash's log still starts with its sealed
+// segment, reading only the segment's bytes and parsing none of them. A
+// log that was changed outside stash, such as by a git merge, no longer
+// matches, and must be checked in full.
+func (s *JSONLStore) VerifySegment(stashName string) (*SegmentReport, error) {
+	segment, err := s.ReadSegment(stashName)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return &SegmentReport{}, nil
+	}
+	report := &SegmentReport{Sealed: true, Segment: segment}
+
+	file, err := s.open(s.getRecordsPath(stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.Problem = "records.jsonl is missing"
+			return report, nil
+		}
+		return nil, fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+
+	sw := newSegmentWriter(io.Discard)
+	n, err := io.Copy(sw, io.LimitReader(file, segment.Bytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records file: %w", err)
+	}
+	switch summed := sw.segment(); {
+	case n < segment.Bytes:
+		report.Problem = fmt.Sprintf("records.jsonl is shorter (%d bytes) than its sealed segment (%d bytes)", n, segment.Bytes)
+	case summed.Checksum != segment.Checksum:
+		report.Problem = "records.jsonl changed within its sealed segment"
+	case summed.Records != segment.Records:
+		report.Problem = fmt.Sprintf("sealed segment has %d lines, expected %d", summed.Records, segment.Records)
+	default:
+		report.Valid = true
+	}
+	return report, nil
+}
+
+// ReadTail calls fn for each line of a stash's log after its first skip
+// bytes, with the line's number in the whole log counting from first.
+func (s *JSONLStore) ReadTail(stashName string, skip int64, first int, fn func(lineNum int, line []byte) error) error {
+	file, err := s.open(s.getRecordsPath(stashName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open records file: %w", err)
+	}
+	defer file.Close()
+	if seeker, ok := file.(io.Seeker); ok {
+		_, err = seeker.Seek(skip, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, file, skip)
+	}
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read records file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for lineNum := first; scanner.Scan(); lineNum++ {
+		if err := fn(lineNum, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading records file: %w", err)
+	}
+	return nil
+}
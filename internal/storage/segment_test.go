@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_SealedSegment(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns:   model.ColumnList{{Name: "name", Added: time.Now(), AddedBy: "test-user"}},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+	create := func(id string) {
+		now := time.Now()
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID: id, Fields: map[string]interface{}{"name": id},
+			CreatedAt: now, CreatedBy: "test-user", UpdatedAt: now, UpdatedBy: "test-user",
+		}))
+	}
+	create("ts-aaaa")
+	create("ts-bbbb")
+
+	report, err := store.jsonl.VerifySegment("test-stash")
+	require.NoError(t, err)
+	assert.False(t, report.Sealed, "appends alone do not seal the log")
+
+	require.NoError(t, store.FlushToJSONL("test-stash"))
+	create("ts-cccc")
+
+	t.Run("compaction seals the log", func(t *testing.T) {
+		report, err := store.jsonl.VerifySegment("test-stash")
+		require.NoError(t, err)
+		require.True(t, report.Valid, report.Problem)
+		assert.Equal(t, 2, report.Segment.Records)
+		assert.True(t, strings.HasPrefix(report.Segment.Checksum, "sha256:"))
+
+		var tail []int
+		require.NoError(t, store.jsonl.ReadTail("test-stash", report.Segment.Bytes, 3, func(lineNum int, line []byte) error {
+			assert.Contains(t, string(line), `"ts-cccc"`)
+			tail = append(tail, lineNum)
+			return nil
+		}))
+		assert.Equal(t, []int{3}, tail)
+	})
+
+	t.Run("changes within the segment are found", func(t *testing.T) {
+		logPath := filepath.Join(tmpDir, "test-stash", "records.jsonl")
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(logPath, []byte(strings.Replace(string(data), "ts-aaaa", "ts-zzzz", 1)), 0644))
+
+		report, err := store.jsonl.VerifySegment("test-stash")
+		require.NoError(t, err)
+		assert.True(t, report.Sealed)
+		assert.False(t, report.Valid)
+		assert.Contains(t, report.Problem, "changed within its sealed segment")
+
+		require.NoError(t, os.WriteFile(logPath, data[:10], 0644))
+		report, err = store.jsonl.VerifySegment("test-stash")
+		require.NoError(t, err)
+		assert.Contains(t, report.Problem, "shorter")
+	})
+}
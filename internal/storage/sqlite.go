@@ -1,29 +1,65 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/user/stash/internal/model"
 )
 
+// baseColumns are the system columns present in every stash table, in scan order.
+var baseColumns = []string{"id", "hash", "parent_id", "created_at", "created_by", "updated_at", "updated_by", "branch", "deleted_at", "deleted_by", "archived_at", "archived_by", "signature", "assigned_to"}
+
+// IsBaseColumn returns true if the name is a system column of every stash
+// table (case-insensitive, with or without the "_" prefix used in JSON
+// output), and so may be used in a --where condition.
+func IsBaseColumn(name string) bool {
+	for _, col := range baseColumns {
+		if strings.EqualFold(col, strings.TrimPrefix(name, "_")) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxCachedStatements bounds the prepared statement cache. Ad-hoc list
+// queries each get their own entry, so the cache is reset when it fills.
+const maxCachedStatements = 128
+
 // SQLiteCache provides SQLite-based caching for fast queries.
 type SQLiteCache struct {
 	db      *sql.DB
 	dbPath  string
-	baseDir string // .stash directory
+	baseDir string          // .stash directory
+	ctx     context.Context // queries fail once it is done (see Store.SetContext)
+
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt // prepared statements by table and column set
+
+	migrationErrs map[string]error // failed migrations by stash, from opening
 }
 
-// NewSQLiteCache creates a new SQLite cache.
+// NewSQLiteCache creates a new SQLite cache. With MemoryDir as baseDir the
+// database is held in memory and lives until the cache is closed.
 func NewSQLiteCache(baseDir string) (*SQLiteCache, error) {
 	dbPath := filepath.Join(baseDir, "cache.db")
+	dsn := sqliteFileDSN(dbPath)
+	if baseDir == MemoryDir {
+		// A named shared-cache database is seen by every pooled connection,
+		// unlike a plain :memory: database, which is private to one
+		dbPath = ""
+		dsn = sqliteMemoryDSN(fmt.Sprintf("stash-memory-%d", memoryDBs.Add(1)))
+	}
 
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000")
+	db, err := sql.Open(sqliteDriver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -32,6 +68,10 @@ func NewSQLiteCache(baseDir string) (*SQLiteCache, error) {
 		db:      db,
 		dbPath:  dbPath,
 		baseDir: baseDir,
+		ctx:     context.Background(),
+		stmts:   make(map[string]*sql.Stmt),
+
+		migrationErrs: make(map[string]error),
 	}
 
 	if err := cache.initMetaTable(); err != nil {
@@ -39,6 +79,21 @@ func NewSQLiteCache(baseDir string) (*SQLiteCache, error) {
 		return nil, err
 	}
 
+	if err := cache.initReadsTable(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := cache.initRateTable(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := cache.migrateStashTables(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return cache, nil
 }
 
@@ -49,23 +104,72 @@ func (c *SQLiteCache) initMetaTable() error {
 			stash_name TEXT PRIMARY KEY,
 			prefix TEXT,
 			config_json TEXT,
-			last_sync TEXT
+			last_sync TEXT,
+			schema_version INTEGER NOT NULL DEFAULT 0,
+			record_count INTEGER NOT NULL DEFAULT 0,
+			deleted_count INTEGER NOT NULL DEFAULT 0,
+			last_op_at TEXT,
+			root_seq_key TEXT,
+			root_seq INTEGER NOT NULL DEFAULT 0
 		)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to create meta table: %w", err)
 	}
-	return nil
+	return c.initMetaColumns()
 }
 
 // Close closes the database connection.
 func (c *SQLiteCache) Close() error {
+	c.resetStatements()
 	if c.db != nil {
 		return c.db.Close()
 	}
 	return nil
 }
 
+// prepared returns the cached statement for key, preparing the SQL from
+// build on first use. Keys identify the table and column set, so the SQL
+// text is only built once per schema.
+func (c *SQLiteCache) prepared(key string, build func() string) (*sql.Stmt, error) {
+	c.stmtMu.Lock()
+	defer c.stmtMu.Unlock()
+
+	if stmt, ok := c.stmts[key]; ok {
+		return stmt, nil
+	}
+	if len(c.stmts) >= maxCachedStatements {
+		c.closeStatementsLocked()
+	}
+
+	stmt, err := c.db.Prepare(build())
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[key] = stmt
+	return stmt, nil
+}
+
+// resetStatements closes all cached statements. It is called after schema
+// changes so no statement outlives the table shape it was prepared for.
+func (c *SQLiteCache) resetStatements() {
+	c.stmtMu.Lock()
+	defer c.stmtMu.Unlock()
+	c.closeStatementsLocked()
+}
+
+func (c *SQLiteCache) closeStatementsLocked() {
+	for key, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, key)
+	}
+}
+
+// statementKey builds a cache key from a statement kind, table, and columns.
+func statementKey(kind, tableName string, columns []string) string {
+	return kind + "\x00" + tableName + "\x00" + strings.Join(columns, "\x00")
+}
+
 // sanitizeTableName converts stash name to a safe table name.
 func sanitizeTableName(name string) string {
 	// Replace hyphens with underscores, SQLite identifiers can't have hyphens
@@ -76,6 +180,7 @@ func sanitizeTableName(name string) string {
 // CreateStashTable creates a table for a stash with the base schema.
 func (c *SQLiteCache) CreateStashTable(stash *model.Stash) error {
 	tableName := sanitizeTableName(stash.Name)
+	defer c.resetStatements()
 
 	// Create main table
 	createSQL := fmt.Sprintf(`
@@ -89,7 +194,11 @@ func (c *SQLiteCache) CreateStashTable(stash *model.Stash) error {
 			updated_by TEXT NOT NULL,
 			branch TEXT,
 			deleted_at TEXT,
-			deleted_by TEXT
+			deleted_by TEXT,
+			archived_at TEXT,
+			archived_by TEXT,
+			signature TEXT,
+			assigned_to TEXT
 		)
 	`, tableName)
 
@@ -104,6 +213,8 @@ func (c *SQLiteCache) CreateStashTable(stash *model.Stash) error {
 		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_hash" ON "%s"(hash)`, tableName, tableName),
 		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_branch" ON "%s"(branch)`, tableName, tableName),
 		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_updated" ON "%s"(updated_at)`, tableName, tableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_archived" ON "%s"(archived_at)`, tableName, tableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "idx_%s_assigned" ON "%s"(assigned_to)`, tableName, tableName),
 	}
 
 	for _, idx := range indexes {
@@ -114,6 +225,12 @@ func (c *SQLiteCache) CreateStashTable(stash *model.Stash) error {
 
 	// Add columns for existing schema
 	for _, col := range stash.Columns {
+		if col.IsComputed() {
+			if err := c.AddComputedColumn(stash.Name, col.Name, col.Computed); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := c.AddColumn(stash.Name, col.Name); err != nil {
 			return err
 		}
@@ -126,21 +243,28 @@ func (c *SQLiteCache) CreateStashTable(stash *model.Stash) error {
 	}
 
 	_, err = c.db.Exec(`
-		INSERT OR REPLACE INTO _stash_meta (stash_name, prefix, config_json, last_sync)
-		VALUES (?, ?, ?, ?)
-	`, stash.Name, stash.Prefix, string(configJSON), time.Now().Format(time.RFC3339))
+		INSERT OR REPLACE INTO _stash_meta (stash_name, prefix, config_json, last_sync, schema_version)
+		VALUES (?, ?, ?, ?, ?)
+	`, stash.Name, stash.Prefix, string(configJSON), time.Now().Format(time.RFC3339), CacheSchemaVersion)
 
 	if err != nil {
 		return fmt.Errorf("failed to store stash metadata: %w", err)
 	}
 
-	return nil
+	// Replacing the metadata zeroed the counts; the table may not be empty
+	if err := c.createCountTriggers(stash.Name); err != nil {
+		return err
+	}
+	return c.recount(stash.Name)
 }
 
 // DropStashTable drops the table for a stash.
 func (c *SQLiteCache) DropStashTable(stashName string) error {
 	tableName := sanitizeTableName(stashName)
 
+	// Finalize statements on the table before dropping it
+	c.resetStatements()
+
 	if _, err := c.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, tableName)); err != nil {
 		return fmt.Errorf("failed to drop stash table: %w", err)
 	}
@@ -170,13 +294,57 @@ func (c *SQLiteCache) AddColumn(stashName, columnName string) error {
 	if _, err := c.db.Exec(alterSQL); err != nil {
 		return fmt.Errorf("failed to add column %s: %w", columnName, err)
 	}
+	c.resetStatements()
 
 	return nil
 }
 
+// AddComputedColumn adds a virtual generated column to a stash table.
+// The expression is evaluated by SQLite whenever the column is read, so
+// computed values are available to list, show, and raw queries alike.
+func (c *SQLiteCache) AddComputedColumn(stashName, columnName, expr string) error {
+	tableName := sanitizeTableName(stashName)
+
+	exists, err := c.columnExists(tableName, columnName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil // Column already exists
+	}
+
+	alterSQL := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" TEXT GENERATED ALWAYS AS (%s) VIRTUAL`, tableName, columnName, expr)
+	if _, err := c.db.Exec(alterSQL); err != nil {
+		return fmt.Errorf("failed to add computed column %s: %w", columnName, err)
+	}
+	c.resetStatements()
+
+	return nil
+}
+
+// CheckComputedColumn returns the error AddComputedColumn would give for
+// the expression, without changing the table: the column is added in a
+// transaction that is rolled back.
+func (c *SQLiteCache) CheckComputedColumn(stashName, columnName, expr string) error {
+	tableName := sanitizeTableName(stashName)
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	alterSQL := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" TEXT GENERATED ALWAYS AS (%s) VIRTUAL`, tableName, columnName, expr)
+	if _, err := tx.Exec(alterSQL); err != nil {
+		return fmt.Errorf("failed to add computed column %s: %w", columnName, err)
+	}
+	return nil
+}
+
 // columnExists checks if a column exists in a table.
 func (c *SQLiteCache) columnExists(tableName, columnName string) (bool, error) {
-	rows, err := c.db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, tableName))
+	// table_xinfo includes generated columns, which table_info hides
+	rows, err := c.db.QueryContext(c.ctx, fmt.Sprintf(`PRAGMA table_xinfo("%s")`, tableName))
 	if err != nil {
 		return false, fmt.Errorf("failed to get table info: %w", err)
 	}
@@ -185,9 +353,9 @@ func (c *SQLiteCache) columnExists(tableName, columnName string) (bool, error) {
 	for rows.Next() {
 		var cid int
 		var name, colType string
-		var notNull, pk int
+		var notNull, pk, hidden int
 		var dfltValue interface{}
-		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk, &hidden); err != nil {
 			return false, err
 		}
 		if strings.EqualFold(name, columnName) {
@@ -201,7 +369,7 @@ func (c *SQLiteCache) columnExists(tableName, columnName string) (bool, error) {
 // GetStash retrieves stash configuration from metadata.
 func (c *SQLiteCache) GetStash(name string) (*model.Stash, error) {
 	var configJSON string
-	err := c.db.QueryRow(`SELECT config_json FROM _stash_meta WHERE stash_name = ?`, name).Scan(&configJSON)
+	err := c.db.QueryRowContext(c.ctx, `SELECT config_json FROM _stash_meta WHERE stash_name = ?`, name).Scan(&configJSON)
 	if err == sql.ErrNoRows {
 		return nil, model.ErrStashNotFound
 	}
@@ -225,8 +393,8 @@ func (c *SQLiteCache) UpdateStashConfig(stash *model.Stash) error {
 	}
 
 	result, err := c.db.Exec(`
-		UPDATE _stash_meta SET config_json = ?, last_sync = ? WHERE stash_name = ?
-	`, string(configJSON), time.Now().Format(time.RFC3339), stash.Name)
+		UPDATE _stash_meta SET prefix = ?, config_json = ?, last_sync = ? WHERE stash_name = ?
+	`, stash.Prefix, string(configJSON), time.Now().Format(time.RFC3339), stash.Name)
 	if err != nil {
 		return fmt.Errorf("failed to update stash config: %w", err)
 	}
@@ -244,7 +412,7 @@ func (c *SQLiteCache) UpdateStashConfig(stash *model.Stash) error {
 
 // ListStashes returns all stash configurations.
 func (c *SQLiteCache) ListStashes() ([]*model.Stash, error) {
-	rows, err := c.db.Query(`SELECT config_json FROM _stash_meta ORDER BY stash_name`)
+	rows, err := c.db.QueryContext(c.ctx, `SELECT config_json FROM _stash_meta ORDER BY stash_name`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list stashes: %w", err)
 	}
@@ -269,24 +437,85 @@ func (c *SQLiteCache) ListStashes() ([]*model.Stash, error) {
 
 // UpsertRecord inserts or updates a record in the cache.
 func (c *SQLiteCache) UpsertRecord(stashName string, record *model.Record, columns []string) error {
-	tableName := sanitizeTableName(stashName)
+	stmt, err := c.upsertStmt(stashName, columns)
+	if err != nil {
+		return fmt.Errorf("failed to upsert record: %w", err)
+	}
 
-	// Build column list
-	baseCols := []string{"id", "hash", "parent_id", "created_at", "created_by", "updated_at", "updated_by", "branch", "deleted_at", "deleted_by"}
-	allCols := append(baseCols, columns...)
+	if _, err := stmt.Exec(recordValues(record, columns)...); err != nil {
+		return fmt.Errorf("failed to upsert record: %w", err)
+	}
+
+	return nil
+}
 
-	// Build placeholders
-	placeholders := make([]string, len(allCols))
-	for i := range placeholders {
-		placeholders[i] = "?"
+// UpsertRecords inserts or updates many records in a single transaction,
+// reusing one prepared statement for every row.
+func (c *SQLiteCache) UpsertRecords(stashName string, records []*model.Record, columns []string) error {
+	stmt, err := c.upsertStmt(stashName, columns)
+	if err != nil {
+		return fmt.Errorf("failed to upsert records: %w", err)
 	}
 
-	// Build values
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	txStmt := tx.Stmt(stmt)
+	defer txStmt.Close()
+
+	for _, record := range records {
+		if _, err := txStmt.Exec(recordValues(record, columns)...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to upsert record %s: %w", record.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit records: %w", err)
+	}
+	return nil
+}
+
+// upsertStmt returns the prepared upsert statement for a stash table and
+// column set. An existing record is updated in place rather than replaced,
+// so the count triggers see it as an update.
+func (c *SQLiteCache) upsertStmt(stashName string, columns []string) (*sql.Stmt, error) {
+	tableName := sanitizeTableName(stashName)
+
+	return c.prepared(statementKey("upsert", tableName, columns), func() string {
+		allCols := append(append([]string{}, baseColumns...), columns...)
+
+		// Quote column names and build placeholders
+		quotedCols := make([]string, len(allCols))
+		placeholders := make([]string, len(allCols))
+		var updates []string
+		for i, col := range allCols {
+			quotedCols[i] = fmt.Sprintf(`"%s"`, col)
+			placeholders[i] = "?"
+			if col != "id" {
+				updates = append(updates, fmt.Sprintf(`"%s" = excluded."%s"`, col, col))
+			}
+		}
+
+		return fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) ON CONFLICT(id) DO UPDATE SET %s`,
+			tableName, strings.Join(quotedCols, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+	})
+}
+
+// recordValues returns the values to bind for a record, in the order of
+// baseColumns followed by columns.
+func recordValues(record *model.Record, columns []string) []interface{} {
 	var deletedAt, deletedBy interface{}
 	if record.DeletedAt != nil {
 		deletedAt = record.DeletedAt.Format(time.RFC3339)
 		deletedBy = record.DeletedBy
 	}
+	var archivedAt, archivedBy interface{}
+	if record.ArchivedAt != nil {
+		archivedAt = record.ArchivedAt.Format(time.RFC3339)
+		archivedBy = record.ArchivedBy
+	}
 
 	values := []interface{}{
 		record.ID,
@@ -299,61 +528,75 @@ func (c *SQLiteCache) UpsertRecord(stashName string, record *model.Record, colum
 		nullString(record.Branch),
 		deletedAt,
 		deletedBy,
+		archivedAt,
+		archivedBy,
+		nullString(record.Signature),
+		nullString(record.AssignedTo),
 	}
 
 	// Add user field values
 	for _, col := range columns {
-		if v, ok := record.Fields[col]; ok {
-			// Convert to string for storage
-			switch val := v.(type) {
-			case string:
-				values = append(values, val)
-			case nil:
-				values = append(values, nil)
-			default:
-				// JSON encode non-string values
-				jsonVal, _ := json.Marshal(val)
-				values = append(values, string(jsonVal))
-			}
-		} else {
-			values = append(values, nil)
-		}
+		values = append(values, encodeCacheValue(record.Fields[col]))
 	}
 
-	// Quote column names
-	quotedCols := make([]string, len(allCols))
-	for i, col := range allCols {
-		quotedCols[i] = fmt.Sprintf(`"%s"`, col)
-	}
+	return values
+}
 
-	sql := fmt.Sprintf(`
-		INSERT OR REPLACE INTO "%s" (%s) VALUES (%s)
-	`, tableName, strings.Join(quotedCols, ", "), strings.Join(placeholders, ", "))
+// encodeCacheValue converts a field value to the text the cache stores:
+// strings as they are, other values JSON encoded.
+func encodeCacheValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return nil
+	default:
+		jsonVal, _ := json.Marshal(val)
+		return string(jsonVal)
+	}
+}
 
-	_, err := c.db.Exec(sql, values...)
-	if err != nil {
-		return fmt.Errorf("failed to upsert record: %w", err)
+// decodeCacheValue converts the text the cache stores back to a field
+// value: JSON where it parses, else the text.
+func decodeCacheValue(text string) interface{} {
+	var val interface{}
+	if err := json.Unmarshal([]byte(text), &val); err != nil {
+		return text
 	}
+	return val
+}
 
-	return nil
+// CachedValue returns a field value as the cache gives it back once
+// stored, such as the number 2 for the string "2", so logged and cached
+// values can be compared.
+func CachedValue(v interface{}) interface{} {
+	text, ok := encodeCacheValue(v).(string)
+	if !ok {
+		return nil
+	}
+	return decodeCacheValue(text)
 }
 
 // GetRecord retrieves a record from the cache.
 func (c *SQLiteCache) GetRecord(stashName, id string, columns []string) (*model.Record, error) {
 	tableName := sanitizeTableName(stashName)
 
-	// Build column list
-	baseCols := []string{"id", "hash", "parent_id", "created_at", "created_by", "updated_at", "updated_by", "branch", "deleted_at", "deleted_by"}
-	allCols := append(baseCols, columns...)
+	stmt, err := c.prepared(statementKey("get", tableName, columns), func() string {
+		// Build column list
+		allCols := append(append([]string{}, baseColumns...), columns...)
 
-	quotedCols := make([]string, len(allCols))
-	for i, col := range allCols {
-		quotedCols[i] = fmt.Sprintf(`"%s"`, col)
-	}
+		quotedCols := make([]string, len(allCols))
+		for i, col := range allCols {
+			quotedCols[i] = fmt.Sprintf(`"%s"`, col)
+		}
 
-	query := fmt.Sprintf(`SELECT %s FROM "%s" WHERE id = ?`, strings.Join(quotedCols, ", "), tableName)
+		return fmt.Sprintf(`SELECT %s FROM "%s" WHERE id = ?`, strings.Join(quotedCols, ", "), tableName)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get record: %w", err)
+	}
 
-	row := c.db.QueryRow(query, id)
+	row := stmt.QueryRowContext(c.ctx, id)
 
 	record, err := c.scanRecord(row, columns)
 	if err == sql.ErrNoRows {
@@ -380,18 +623,191 @@ func (c *SQLiteCache) DeleteRecord(stashName, id string) error {
 
 // ListRecords lists records from the cache with filtering options.
 func (c *SQLiteCache) ListRecords(stashName string, columns []string, opts ListOptions) ([]*model.Record, error) {
+	var records []*model.Record
+	err := c.IterateRecords(stashName, columns, opts, func(record *model.Record) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// IterateRecords calls fn for each record matching opts, reading rows one at
+// a time so memory use does not grow with the size of the stash. Iteration
+// stops at the first error returned by fn.
+func (c *SQLiteCache) IterateRecords(stashName string, columns []string, opts ListOptions, fn func(*model.Record) error) error {
+	query, args := c.listQuery(stashName, columns, opts)
+	stmt, err := c.prepared(query, func() string { return query })
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", queryError(err))
+	}
+
+	rows, err := stmt.QueryContext(c.ctx, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", queryError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := c.scanRecordFromRows(rows, columns)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list records: %w", queryError(err))
+	}
+	return nil
+}
+
+// QueryTooLargeError is returned for a listing past SQLite's limits on the
+// size of a query, such as thousands of conditions or a LIKE pattern over
+// 50000 bytes.
+type QueryTooLargeError struct {
+	Reason string // SQLite's error
+}
+
+func (e *QueryTooLargeError) Error() string {
+	return fmt.Sprintf("query too large for SQLite (%s)", e.Reason)
+}
+
+// sqliteLimitErrors are the messages of SQLite's errors for queries past
+// its limits.
+var sqliteLimitErrors = []string{
+	"too many SQL variables",
+	"Expression tree is too large",
+	"LIKE or GLOB pattern too complex",
+	"string or blob too big",
+	"too many arguments on function",
+}
+
+// queryError returns the error of running a query, as a
+// QueryTooLargeError if the query is past SQLite's limits.
+func queryError(err error) error {
+	for _, msg := range sqliteLimitErrors {
+		if strings.Contains(err.Error(), msg) {
+			return &QueryTooLargeError{Reason: err.Error()}
+		}
+	}
+	return err
+}
+
+// listQuery returns the SELECT statement listing the records opts matches,
+// and the arguments it binds.
+func (c *SQLiteCache) listQuery(stashName string, columns []string, opts ListOptions) (string, []interface{}) {
 	tableName := sanitizeTableName(stashName)
 
 	// Build column list
-	baseCols := []string{"id", "hash", "parent_id", "created_at", "created_by", "updated_at", "updated_by", "branch", "deleted_at", "deleted_by"}
-	allCols := append(baseCols, columns...)
+	allCols := append(append([]string{}, baseColumns...), columns...)
 
 	quotedCols := make([]string, len(allCols))
 	for i, col := range allCols {
 		quotedCols[i] = fmt.Sprintf(`"%s"`, col)
 	}
 
-	// Build WHERE clause
+	whereClause, args := c.buildWhere(tableName, columns, opts)
+
+	orderTerms := c.orderTerms(stashName, tableName, columns, opts, false)
+
+	// Sampling and tailing pick the records in a subquery, so the outer
+	// query still lists them in the requested order
+	if pick, n := c.pickOrder(stashName, tableName, columns, opts); pick != "" {
+		cond := fmt.Sprintf(`id IN (SELECT id FROM "%s" %s ORDER BY %s LIMIT ?)`, tableName, whereClause, pick)
+		if whereClause == "" {
+			whereClause = "WHERE " + cond
+		} else {
+			whereClause += " AND " + cond
+		}
+		args = append(append(args, args...), n)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM "%s" %s ORDER BY %s`,
+		strings.Join(quotedCols, ", "), tableName, whereClause, strings.Join(orderTerms, ", "))
+
+	// Add LIMIT and OFFSET
+	// SQLite requires LIMIT before OFFSET, and OFFSET requires LIMIT
+	// (bound as parameters so the statement can be reused across pages)
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+		if opts.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, opts.Offset)
+		}
+	} else if opts.Offset > 0 {
+		// If only offset is specified, use -1 for unlimited
+		query += " LIMIT -1 OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+	return query, args
+}
+
+// orderTerms returns the ORDER BY terms of a listing, reversed for the
+// last records first. Ties are always broken by ID.
+func (c *SQLiteCache) orderTerms(stashName, tableName string, columns []string, opts ListOptions, reverse bool) []string {
+	keys := opts.OrderBy
+	if len(keys) == 0 {
+		keys = []OrderKey{{Field: "updated_at", Type: model.ValueTypeText}}
+	}
+	asc, desc := "ASC", "DESC"
+	if reverse {
+		asc, desc = desc, asc
+	}
+	var orderTerms []string
+	orderedByID := false
+	for _, key := range keys {
+		orderDir := asc
+		if key.Desc || opts.Descending {
+			orderDir = desc
+		}
+		if expr := readOrderExpr(stashName, tableName, key.Field); expr != "" {
+			orderTerms = append(orderTerms, expr+" "+orderDir)
+			continue
+		}
+
+		// Resolve order by field name case-insensitively
+		field := c.resolveColumnName(tableName, key.Field, columns)
+		if field == "" {
+			field = key.Field
+		}
+		valueType := key.Type
+		if valueType == "" {
+			valueType = columnValueType(field, opts.ColumnTypes)
+		}
+		orderTerms = append(orderTerms, collatedColumn(field, valueType, opts.Collation)+" "+orderDir)
+		orderedByID = orderedByID || field == "id"
+	}
+	// Break ties by ID so the order is deterministic
+	if !orderedByID {
+		orderTerms = append(orderTerms, `"id" `+asc)
+	}
+	return orderTerms
+}
+
+// pickOrder returns the ORDER BY clause that picks the records of a
+// sampled or tailed listing, and how many to pick. It returns "" for any
+// other listing.
+func (c *SQLiteCache) pickOrder(stashName, tableName string, columns []string, opts ListOptions) (string, int) {
+	switch {
+	case opts.Sample > 0 && opts.SampleSeed != 0:
+		return fmt.Sprintf(`stash_sample(id, %d)`, opts.SampleSeed), opts.Sample
+	case opts.Sample > 0:
+		return "RANDOM()", opts.Sample
+	case opts.Tail > 0:
+		return strings.Join(c.orderTerms(stashName, tableName, columns, opts, true), ", "), opts.Tail
+	}
+	return "", 0
+}
+
+// buildWhere returns the WHERE clause (empty if there are no conditions)
+// selecting the records opts matches, and the arguments it binds.
+func (c *SQLiteCache) buildWhere(tableName string, columns []string, opts ListOptions) (string, []interface{}) {
 	var conditions []string
 	var args []interface{}
 
@@ -402,10 +818,51 @@ func (c *SQLiteCache) ListRecords(stashName string, columns []string, opts ListO
 		conditions = append(conditions, "deleted_at IS NULL")
 	}
 
+	// Handle archived record filtering
+	if opts.ArchivedOnly {
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	} else if opts.ExcludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+
+	if opts.AssignedTo != "" {
+		conditions = append(conditions, "assigned_to = ?")
+		args = append(args, opts.AssignedTo)
+	}
+
+	// Audit filters on the system columns
+	for _, filter := range []struct{ column, value string }{
+		{"created_by", opts.CreatedBy},
+		{"deleted_by", opts.DeletedBy},
+		{"branch", opts.Branch},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = ?")
+			args = append(args, filter.value)
+		}
+	}
+
+	// Timestamps carry their zone offset, so compare them as UTC datetimes
+	if !opts.UpdatedSince.IsZero() {
+		conditions = append(conditions, "datetime(updated_at) >= datetime(?)")
+		args = append(args, opts.UpdatedSince.UTC().Format(time.RFC3339))
+	}
+
 	if opts.ParentID != "*" {
-		if opts.ParentID == "" {
+		switch {
+		case opts.ParentID == "":
 			conditions = append(conditions, "parent_id IS NULL")
-		} else {
+		case opts.Recursive:
+			// Descendant IDs extend their ancestor's ID (inv-ex4j.1.2), so
+			// match on the ID prefix and count dots for the depth
+			prefix := opts.ParentID + "."
+			conditions = append(conditions, "substr(id, 1, ?) = ?")
+			args = append(args, len(prefix), prefix)
+			if opts.MaxDepth > 0 {
+				conditions = append(conditions, "length(id) - length(replace(id, '.', '')) <= ?")
+				args = append(args, model.GetDepth(opts.ParentID)+opts.MaxDepth)
+			}
+		default:
 			conditions = append(conditions, "parent_id = ?")
 			args = append(args, opts.ParentID)
 		}
@@ -420,27 +877,43 @@ func (c *SQLiteCache) ListRecords(stashName string, columns []string, opts ListO
 		}
 
 		switch w.Operator {
-		case "=":
-			conditions = append(conditions, fmt.Sprintf(`"%s" = ?`, fieldName))
-			args = append(args, w.Value)
-		case "!=", "<>":
-			conditions = append(conditions, fmt.Sprintf(`"%s" != ?`, fieldName))
-			args = append(args, w.Value)
-		case "<":
-			conditions = append(conditions, fmt.Sprintf(`CAST("%s" AS REAL) < CAST(? AS REAL)`, fieldName))
-			args = append(args, w.Value)
-		case ">":
-			conditions = append(conditions, fmt.Sprintf(`CAST("%s" AS REAL) > CAST(? AS REAL)`, fieldName))
-			args = append(args, w.Value)
-		case "<=":
-			conditions = append(conditions, fmt.Sprintf(`CAST("%s" AS REAL) <= CAST(? AS REAL)`, fieldName))
+		case "=", "!=", "<>":
+			// User columns compare under the stash's collation
+			column, value := fmt.Sprintf(`"%s"`, fieldName), "?"
+			if !IsBaseColumn(fieldName) {
+				column, value = collated(column, opts.Collation), collated(value, opts.Collation)
+			}
+			op := w.Operator
+			if op == "<>" {
+				op = "!="
+			}
+			conditions = append(conditions, column+" "+op+" "+value)
 			args = append(args, w.Value)
-		case ">=":
-			conditions = append(conditions, fmt.Sprintf(`CAST("%s" AS REAL) >= CAST(? AS REAL)`, fieldName))
+		case "<", ">", "<=", ">=":
+			// Relative dates (-7d, now+2w) resolve against the current time
+			if t, ok, _ := ParseRelativeDate(w.Value, time.Now()); ok {
+				conditions = append(conditions, fmt.Sprintf(`julianday("%s") %s julianday(?)`, fieldName, w.Operator))
+				args = append(args, t.Format(time.RFC3339))
+				continue
+			}
+			// Dates compare chronologically; everything else numerically
+			if columnValueType(fieldName, opts.ColumnTypes) == model.ValueTypeDate {
+				conditions = append(conditions, fmt.Sprintf(`julianday("%s") %s julianday(?)`, fieldName, w.Operator))
+			} else {
+				conditions = append(conditions, fmt.Sprintf(`CAST("%s" AS REAL) %s CAST(? AS REAL)`, fieldName, w.Operator))
+			}
 			args = append(args, w.Value)
 		case "LIKE":
 			conditions = append(conditions, fmt.Sprintf(`"%s" LIKE ?`, fieldName))
 			args = append(args, w.Value)
+		case "CONTAINS":
+			// List values are stored as JSON arrays; any other value
+			// contains only itself
+			conditions = append(conditions, fmt.Sprintf(
+				`(CASE WHEN json_valid("%[1]s") AND json_type("%[1]s") = 'array' `+
+					`THEN EXISTS (SELECT 1 FROM json_each("%[1]s") WHERE CAST(value AS TEXT) = ?) `+
+					`ELSE "%[1]s" = ? END)`, fieldName))
+			args = append(args, w.Value, w.Value)
 		case "IS NULL":
 			conditions = append(conditions, fmt.Sprintf(`"%s" IS NULL`, fieldName))
 		case "IS NOT NULL":
@@ -452,85 +925,93 @@ func (c *SQLiteCache) ListRecords(stashName string, columns []string, opts ListO
 		}
 	}
 
-	// Add search condition (search across all user columns)
+	// Add search condition (search across all user columns, and the ID)
 	if opts.Search != "" {
-		var searchConds []string
-		searchPattern := "%" + opts.Search + "%"
-		for _, col := range columns {
-			searchConds = append(searchConds, fmt.Sprintf(`"%s" LIKE ?`, col))
-			args = append(args, searchPattern)
-		}
-		// Also search ID
-		searchConds = append(searchConds, `"id" LIKE ?`)
-		args = append(args, searchPattern)
-
-		if len(searchConds) > 0 {
-			conditions = append(conditions, "("+strings.Join(searchConds, " OR ")+")")
-		}
+		cond, arg := searchCondition(append(append([]string{}, columns...), "id"), opts.Search, opts.SearchMode)
+		conditions = append(conditions, cond)
+		args = append(args, arg)
 	}
 
 	whereClause := ""
 	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+		whereClause = "WHERE " + joinBalanced(conditions, " AND ")
 	}
 
-	// Build ORDER BY clause
-	orderBy := "updated_at"
-	if opts.OrderBy != "" {
-		// Resolve order by field name case-insensitively
-		resolvedOrderBy := c.resolveColumnName(tableName, opts.OrderBy, columns)
-		if resolvedOrderBy != "" {
-			orderBy = resolvedOrderBy
-		} else {
-			orderBy = opts.OrderBy
+	return whereClause, args
+}
+
+// ParseOrderBy parses a comma-separated list of sort keys, each a field
+// with an optional value type override and direction, as in
+// "Category, Price:numeric desc". Keys without a direction sort descending
+// when descending is set.
+func ParseOrderBy(spec string, descending bool) ([]OrderKey, error) {
+	var keys []OrderKey
+	for _, part := range strings.Split(spec, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > 2 {
+			return nil, fmt.Errorf("invalid sort key '%s' (expected 'Field[:type] [asc|desc]')", strings.TrimSpace(part))
 		}
-	}
-	orderDir := "ASC"
-	if opts.Descending {
-		orderDir = "DESC"
-	}
 
-	query := fmt.Sprintf(`SELECT %s FROM "%s" %s ORDER BY "%s" %s`,
-		strings.Join(quotedCols, ", "), tableName, whereClause, orderBy, orderDir)
+		key := OrderKey{Desc: descending}
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+				key.Desc = false
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction '%s' (valid: asc, desc)", words[1])
+			}
+		}
 
-	// Add LIMIT and OFFSET
-	// SQLite requires LIMIT before OFFSET, and OFFSET requires LIMIT
-	if opts.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", opts.Limit)
-		if opts.Offset > 0 {
-			query += fmt.Sprintf(" OFFSET %d", opts.Offset)
+		field, valueType, found := strings.Cut(words[0], ":")
+		key.Field = field
+		if found {
+			key.Type = strings.ToLower(valueType)
+			if !slices.Contains(model.ValueTypes, key.Type) {
+				return nil, fmt.Errorf("invalid sort type '%s' (valid: %s)", valueType, strings.Join(model.ValueTypes, ", "))
+			}
 		}
-	} else if opts.Offset > 0 {
-		// If only offset is specified, use -1 for unlimited
-		query += fmt.Sprintf(" LIMIT -1 OFFSET %d", opts.Offset)
+		keys = append(keys, key)
 	}
+	return keys, nil
+}
 
-	rows, err := c.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list records: %w", err)
+// columnValueType returns the value type of a resolved column. System
+// timestamps are dates; other columns default to text.
+func columnValueType(column string, types map[string]string) string {
+	if t, ok := types[column]; ok {
+		return t
 	}
-	defer rows.Close()
-
-	var records []*model.Record
-	for rows.Next() {
-		record, err := c.scanRecordFromRows(rows, columns)
-		if err != nil {
-			return nil, err
-		}
-		records = append(records, record)
+	if strings.HasSuffix(column, "_at") && IsBaseColumn(column) {
+		return model.ValueTypeDate
 	}
+	return model.ValueTypeText
+}
 
-	return records, rows.Err()
+// typedColumn returns the SQL expression that compares a column's values
+// by their value type.
+func typedColumn(column, valueType string) string {
+	switch valueType {
+	case model.ValueTypeNumeric:
+		return fmt.Sprintf(`CAST("%s" AS REAL)`, column)
+	case model.ValueTypeDate:
+		return fmt.Sprintf(`julianday("%s")`, column)
+	default:
+		return fmt.Sprintf(`"%s"`, column)
+	}
 }
 
 // resolveColumnName finds the actual column name case-insensitively.
 func (c *SQLiteCache) resolveColumnName(tableName, fieldName string, columns []string) string {
 	fieldLower := strings.ToLower(fieldName)
 
-	// Check system columns
-	systemCols := []string{"id", "hash", "parent_id", "created_at", "created_by", "updated_at", "updated_by", "branch", "deleted_at", "deleted_by"}
-	for _, col := range systemCols {
-		if strings.ToLower(col) == fieldLower {
+	// Check system columns, which may be named as in JSON output ("_id")
+	for _, col := range baseColumns {
+		if strings.ToLower(col) == strings.TrimPrefix(fieldLower, "_") {
 			return col
 		}
 	}
@@ -557,14 +1038,17 @@ func (c *SQLiteCache) GetChildren(stashName, parentID string, columns []string)
 func (c *SQLiteCache) GetNextChildSeq(stashName, parentID string) (int, error) {
 	tableName := sanitizeTableName(stashName)
 
+	// Compare with SUBSTR rather than LIKE so that '_' in templated IDs
+	// is not treated as a wildcard
 	var maxSeq sql.NullInt64
 	query := fmt.Sprintf(`
 		SELECT MAX(CAST(SUBSTR(id, LENGTH(?) + 2) AS INTEGER))
 		FROM "%s"
-		WHERE id LIKE ? || '.%%' AND id NOT LIKE ? || '.%%.%%'
+		WHERE SUBSTR(id, 1, LENGTH(?) + 1) = ? || '.'
+		  AND INSTR(SUBSTR(id, LENGTH(?) + 2), '.') = 0
 	`, tableName)
 
-	err := c.db.QueryRow(query, parentID, parentID, parentID).Scan(&maxSeq)
+	err := c.db.QueryRowContext(c.ctx, query, parentID, parentID, parentID, parentID).Scan(&maxSeq)
 	if err != nil {
 		return 1, fmt.Errorf("failed to get max child seq: %w", err)
 	}
@@ -576,13 +1060,106 @@ func (c *SQLiteCache) GetNextChildSeq(stashName, parentID string) (int, error) {
 	return int(maxSeq.Int64) + 1, nil
 }
 
-// ClearTable removes all records from a stash table.
+// NextRootSeq allocates the next sequence number for a root ID of the form
+// <head><seq><tail>. The last number allocated is kept in _stash_meta with
+// the head and tail it was for, so each allocation reads one row. When the
+// head or tail changes, as a template's date does, or the counter was lost
+// with the cache, the highest existing number is found from the IDs
+// starting with head, deleted records included so IDs are never reused.
+// Numbers whose IDs are taken anyway, as by a replayed log, are skipped.
+// Callers hold the stash's write lock.
+func (c *SQLiteCache) NextRootSeq(stashName, head, tail string) (int, error) {
+	tableName := sanitizeTableName(stashName)
+	key := head + "{seq}" + tail
+
+	var lastKey sql.NullString
+	var seq int
+	err := c.db.QueryRowContext(c.ctx,
+		`SELECT root_seq_key, root_seq FROM _stash_meta WHERE stash_name = ?`, stashName,
+	).Scan(&lastKey, &seq)
+	if err != nil && err != sql.ErrNoRows {
+		return 1, fmt.Errorf("failed to read root sequence: %w", err)
+	}
+	if !lastKey.Valid || lastKey.String != key {
+		if seq, err = c.maxRootSeq(tableName, head, tail); err != nil {
+			return 1, err
+		}
+	}
+
+	for {
+		seq++
+		var taken int
+		err := c.db.QueryRowContext(c.ctx,
+			fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE id = ?`, tableName), head+model.FormatSeq(seq)+tail,
+		).Scan(&taken)
+		if err != nil {
+			return 1, fmt.Errorf("failed to check record ID: %w", err)
+		}
+		if taken == 0 {
+			break
+		}
+	}
+
+	_, err = c.db.ExecContext(c.ctx,
+		`UPDATE _stash_meta SET root_seq_key = ?, root_seq = ? WHERE stash_name = ?`, key, seq, stashName)
+	if err != nil {
+		return 1, fmt.Errorf("failed to save root sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// maxRootSeq returns the highest sequence number of the root IDs of the
+// form <head><seq><tail>, or 0 if there are none. The IDs are selected by
+// a range on the primary key, since every one starts with head.
+func (c *SQLiteCache) maxRootSeq(tableName, head, tail string) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT id FROM "%s"
+		WHERE id >= ? AND id < ? AND INSTR(id, '.') = 0
+	`, tableName)
+
+	// IDs are ASCII, so every ID starting with head sorts below head+DEL
+	rows, err := c.db.QueryContext(c.ctx, query, head, head+"\x7f")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get max root seq: %w", err)
+	}
+	defer rows.Close()
+
+	maxSeq := 0
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("failed to scan record ID: %w", err)
+		}
+		rest := strings.TrimPrefix(id, head)
+		if !strings.HasSuffix(rest, tail) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(rest, tail))
+		if err != nil {
+			continue
+		}
+		if seq > maxSeq {
+			maxSeq = seq
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get max root seq: %w", err)
+	}
+	return maxSeq, nil
+}
+
+// ClearTable removes all records from a stash table. Its counts are reset
+// to zero outright, so a rebuild also repairs counts that went wrong.
 func (c *SQLiteCache) ClearTable(stashName string) error {
 	tableName := sanitizeTableName(stashName)
 	_, err := c.db.Exec(fmt.Sprintf(`DELETE FROM "%s"`, tableName))
 	if err != nil {
 		return fmt.Errorf("failed to clear table: %w", err)
 	}
+	_, err = c.db.Exec(`UPDATE _stash_meta SET record_count = 0, deleted_count = 0, last_op_at = NULL WHERE stash_name = ?`, stashName)
+	if err != nil {
+		return fmt.Errorf("failed to clear table: %w", err)
+	}
 	return nil
 }
 
@@ -593,6 +1170,8 @@ func (c *SQLiteCache) scanRecord(row *sql.Row, columns []string) (*model.Record,
 		parentID, branch               sql.NullString
 		createdAt, updatedAt           string
 		deletedAt, deletedBy           sql.NullString
+		archivedAt, archivedBy         sql.NullString
+		signature, assignedTo          sql.NullString
 	)
 
 	// Prepare slice for user columns
@@ -606,6 +1185,7 @@ func (c *SQLiteCache) scanRecord(row *sql.Row, columns []string) (*model.Record,
 	dests := []interface{}{
 		&id, &hash, &parentID, &createdAt, &createdBy,
 		&updatedAt, &updatedBy, &branch, &deletedAt, &deletedBy,
+		&archivedAt, &archivedBy, &signature, &assignedTo,
 	}
 	dests = append(dests, userPtrs...)
 
@@ -613,7 +1193,7 @@ func (c *SQLiteCache) scanRecord(row *sql.Row, columns []string) (*model.Record,
 		return nil, err
 	}
 
-	return c.buildRecord(id, hash, parentID, createdAt, createdBy, updatedAt, updatedBy, branch, deletedAt, deletedBy, columns, userVals)
+	return c.buildRecord(id, hash, parentID, createdAt, createdBy, updatedAt, updatedBy, branch, deletedAt, deletedBy, archivedAt, archivedBy, signature, assignedTo, columns, userVals)
 }
 
 // scanRecordFromRows scans a row from Rows into a Record.
@@ -623,6 +1203,8 @@ func (c *SQLiteCache) scanRecordFromRows(rows *sql.Rows, columns []string) (*mod
 		parentID, branch               sql.NullString
 		createdAt, updatedAt           string
 		deletedAt, deletedBy           sql.NullString
+		archivedAt, archivedBy         sql.NullString
+		signature, assignedTo          sql.NullString
 	)
 
 	// Prepare slice for user columns
@@ -636,6 +1218,7 @@ func (c *SQLiteCache) scanRecordFromRows(rows *sql.Rows, columns []string) (*mod
 	dests := []interface{}{
 		&id, &hash, &parentID, &createdAt, &createdBy,
 		&updatedAt, &updatedBy, &branch, &deletedAt, &deletedBy,
+		&archivedAt, &archivedBy, &signature, &assignedTo,
 	}
 	dests = append(dests, userPtrs...)
 
@@ -643,7 +1226,7 @@ func (c *SQLiteCache) scanRecordFromRows(rows *sql.Rows, columns []string) (*mod
 		return nil, err
 	}
 
-	return c.buildRecord(id, hash, parentID, createdAt, createdBy, updatedAt, updatedBy, branch, deletedAt, deletedBy, columns, userVals)
+	return c.buildRecord(id, hash, parentID, createdAt, createdBy, updatedAt, updatedBy, branch, deletedAt, deletedBy, archivedAt, archivedBy, signature, assignedTo, columns, userVals)
 }
 
 // buildRecord constructs a Record from scanned values.
@@ -654,6 +1237,8 @@ func (c *SQLiteCache) buildRecord(
 	updatedAt, updatedBy string,
 	branch sql.NullString,
 	deletedAt, deletedBy sql.NullString,
+	archivedAt, archivedBy sql.NullString,
+	signature, assignedTo sql.NullString,
 	columns []string,
 	userVals []sql.NullString,
 ) (*model.Record, error) {
@@ -671,6 +1256,12 @@ func (c *SQLiteCache) buildRecord(
 	if branch.Valid {
 		record.Branch = branch.String
 	}
+	if signature.Valid {
+		record.Signature = signature.String
+	}
+	if assignedTo.Valid {
+		record.AssignedTo = assignedTo.String
+	}
 
 	// Parse timestamps
 	if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
@@ -685,16 +1276,17 @@ func (c *SQLiteCache) buildRecord(
 		}
 		record.DeletedBy = deletedBy.String
 	}
+	if archivedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, archivedAt.String); err == nil {
+			record.ArchivedAt = &t
+		}
+		record.ArchivedBy = archivedBy.String
+	}
 
 	// Set user fields
 	for i, col := range columns {
 		if userVals[i].Valid {
-			// Try to unmarshal JSON, fall back to string
-			var val interface{}
-			if err := json.Unmarshal([]byte(userVals[i].String), &val); err != nil {
-				val = userVals[i].String
-			}
-			record.Fields[col] = val
+			record.Fields[col] = decodeCacheValue(userVals[i].String)
 		}
 	}
 
@@ -705,7 +1297,7 @@ func (c *SQLiteCache) buildRecord(
 func (c *SQLiteCache) TableExists(stashName string) (bool, error) {
 	tableName := sanitizeTableName(stashName)
 	var name string
-	err := c.db.QueryRow(`SELECT name FROM sqlite_master WHERE type='table' AND name=?`, tableName).Scan(&name)
+	err := c.db.QueryRowContext(c.ctx, `SELECT name FROM sqlite_master WHERE type='table' AND name=?`, tableName).Scan(&name)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -715,22 +1307,54 @@ func (c *SQLiteCache) TableExists(stashName string) (bool, error) {
 	return true, nil
 }
 
-// CountRecords returns the number of non-deleted records in a stash.
-func (c *SQLiteCache) CountRecords(stashName string) (int, error) {
+// DistinctValues returns each distinct value of a column among the records
+// opts matches, most common first. Ordering, limits, and search in opts are
+// ignored.
+func (c *SQLiteCache) DistinctValues(stashName, column string, columns []string, opts ListOptions) ([]ValueCount, error) {
 	tableName := sanitizeTableName(stashName)
 
-	var count int
-	err := c.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE deleted_at IS NULL`, tableName)).Scan(&count)
+	field := c.resolveColumnName(tableName, column, columns)
+	if field == "" {
+		return nil, fmt.Errorf("%w: %s", model.ErrColumnNotFound, column)
+	}
+
+	opts.Search = ""
+	whereClause, args := c.buildWhere(tableName, columns, opts)
+	// Values equal under the collation are one value, shown as the
+	// first of them in binary order
+	key := fmt.Sprintf(`"%s"`, field)
+	if !IsBaseColumn(field) {
+		key = collated(key, opts.Collation)
+	}
+	query := fmt.Sprintf(`SELECT MIN("%s"), COUNT(*) FROM "%s" %s GROUP BY %s ORDER BY COUNT(*) DESC, %s ASC`,
+		field, tableName, whereClause, key, key)
+
+	rows, err := c.db.QueryContext(c.ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count records: %w", err)
+		return nil, fmt.Errorf("failed to list distinct values: %w", queryError(err))
+	}
+	defer rows.Close()
+
+	values := []ValueCount{}
+	for rows.Next() {
+		var value sql.NullString
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan distinct value: %w", err)
+		}
+		vc := ValueCount{Count: count}
+		if value.Valid {
+			vc.Value = value.String
+		}
+		values = append(values, vc)
 	}
-	return count, nil
+	return values, rows.Err()
 }
 
 // GetLastSyncTime returns the most recent last_sync time from all stashes.
 func (c *SQLiteCache) GetLastSyncTime() (time.Time, error) {
 	var lastSyncStr sql.NullString
-	err := c.db.QueryRow(`SELECT MAX(last_sync) FROM _stash_meta`).Scan(&lastSyncStr)
+	err := c.db.QueryRowContext(c.ctx, `SELECT MAX(last_sync) FROM _stash_meta`).Scan(&lastSyncStr)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to get last sync time: %w", err)
 	}
@@ -753,7 +1377,7 @@ func nullString(s string) interface{} {
 // RawQuery executes a raw SQL SELECT query and returns results.
 // Only SELECT queries should be passed to this function.
 func (c *SQLiteCache) RawQuery(query string) ([]map[string]interface{}, []string, error) {
-	rows, err := c.db.Query(query)
+	rows, err := c.db.QueryContext(c.ctx, query)
 	if err != nil {
 		return nil, nil, fmt.Errorf("query failed: %w", err)
 	}
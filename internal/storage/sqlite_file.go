@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/telemetry"
+)
+
+// SQLiteExportVersion is the layout version of standalone SQLite exports.
+const SQLiteExportVersion = 1
+
+// A standalone SQLite export holds one table named after the stash, with a
+// column for each system field (_id, _parent, _created_at, ...) and one for
+// each stash column, typed so DB tools sort and sum them: number columns
+// are REAL, dates are ISO-8601 TEXT, and list columns TEXT holding a JSON
+// array. Two more tables describe it: _stash_export, key/value pairs
+// naming the stash and the export, and _stash_columns, the stash's schema.
+const (
+	sqliteExportMetaTable    = "_stash_export"
+	sqliteExportColumnsTable = "_stash_columns"
+)
+
+// sqliteExportSystemColumns are the system fields of every record in a
+// standalone export, in table order, with the record value of each.
+var sqliteExportSystemColumns = []struct {
+	name  string
+	value func(*model.Record) interface{}
+}{
+	{"_id", func(r *model.Record) interface{} { return r.ID }},
+	{"_hash", func(r *model.Record) interface{} { return nullString(r.Hash) }},
+	{"_parent", func(r *model.Record) interface{} { return nullString(r.ParentID) }},
+	{"_created_at", func(r *model.Record) interface{} { return r.CreatedAt.UTC().Format(time.RFC3339) }},
+	{"_created_by", func(r *model.Record) interface{} { return r.CreatedBy }},
+	{"_updated_at", func(r *model.Record) interface{} { return r.UpdatedAt.UTC().Format(time.RFC3339) }},
+	{"_updated_by", func(r *model.Record) interface{} { return r.UpdatedBy }},
+	{"_branch", func(r *model.Record) interface{} { return nullString(r.Branch) }},
+	{"_deleted_at", func(r *model.Record) interface{} { return nullTime(r.DeletedAt) }},
+	{"_deleted_by", func(r *model.Record) interface{} { return nullString(r.DeletedBy) }},
+	{"_archived_at", func(r *model.Record) interface{} { return nullTime(r.ArchivedAt) }},
+	{"_archived_by", func(r *model.Record) interface{} { return nullString(r.ArchivedBy) }},
+	{"_assigned_to", func(r *model.Record) interface{} { return nullString(r.AssignedTo) }},
+}
+
+// sqliteExportIndexes are the system columns a standalone export indexes.
+var sqliteExportIndexes = []string{"_parent", "_updated_at", "_deleted_at", "_assigned_to"}
+
+// nullTime converts a nil time to NULL.
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// quoteIdent quotes a SQLite identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqliteColumnType returns the SQLite type a stash column is exported as.
+func sqliteColumnType(col *model.Column) string {
+	if col != nil && !col.List && col.ValueType() == model.ValueTypeNumeric {
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+// sqliteExportValue converts a field value for a standalone export: text
+// and numbers as they are, anything else (lists) as JSON.
+func sqliteExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string, float64, int, int64, bool:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// ExportSQLite writes the records of a stash matching opts to a new
+// standalone SQLite database at path, with the named columns (all columns
+// when columns is empty), and returns the number of records written. The
+// database is built next to path and renamed into place, so a failed
+// export leaves no partial file; an existing file at path is replaced.
+func (s *Store) ExportSQLite(stashName, path, actor string, opts ListOptions, columns []string) (_ int, err error) {
+	defer telemetry.Start("Store.ExportSQLite", stashAttr(stashName)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		columns = stash.Columns.Names()
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	db, err := sql.Open(sqliteDriver, tmp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create database: %w", err)
+	}
+	defer func() {
+		db.Close()
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create database: %w", err)
+	}
+	defer tx.Rollback()
+
+	table := quoteIdent(stash.Name)
+	defs := make([]string, 0, len(sqliteExportSystemColumns)+len(columns))
+	for _, sys := range sqliteExportSystemColumns {
+		def := quoteIdent(sys.name) + " TEXT"
+		switch sys.name {
+		case "_id":
+			def += " PRIMARY KEY"
+		case "_created_at", "_created_by", "_updated_at", "_updated_by":
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+	for _, name := range columns {
+		defs = append(defs, quoteIdent(name)+" "+sqliteColumnType(stash.Columns.Find(name)))
+	}
+
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", table, strings.Join(defs, ",\n\t")),
+		fmt.Sprintf("CREATE TABLE %s (key TEXT PRIMARY KEY, value TEXT)", sqliteExportMetaTable),
+		fmt.Sprintf(`CREATE TABLE %s (
+			name TEXT PRIMARY KEY,
+			position INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			list INTEGER NOT NULL DEFAULT 0,
+			description TEXT
+		)`, sqliteExportColumnsTable),
+	}
+	for _, name := range sqliteExportIndexes {
+		statements = append(statements, fmt.Sprintf("CREATE INDEX %s ON %s(%s)",
+			quoteIdent("idx_"+sanitizeTableName(stash.Name)+name), table, quoteIdent(name)))
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return 0, fmt.Errorf("failed to create database: %w", err)
+		}
+	}
+
+	for i, name := range columns {
+		col := stash.Columns.Find(name)
+		valueType, list, desc := model.ValueTypeText, false, ""
+		if col != nil {
+			valueType, list, desc = col.ValueType(), col.List, col.Desc
+		}
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?)", sqliteExportColumnsTable),
+			name, i+1, valueType, list, nullString(desc)); err != nil {
+			return 0, fmt.Errorf("failed to write columns: %w", err)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(defs)), ", ")
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", table, placeholders))
+	if err != nil {
+		return 0, fmt.Errorf("failed to write records: %w", err)
+	}
+	defer insert.Close()
+
+	count := 0
+	err = s.IterateRecords(stashName, opts, func(record *model.Record) error {
+		args := make([]interface{}, 0, len(defs))
+		for _, sys := range sqliteExportSystemColumns {
+			args = append(args, sys.value(record))
+		}
+		for _, name := range columns {
+			args = append(args, sqliteExportValue(record.Fields[name]))
+		}
+		if _, err := insert.Exec(args...); err != nil {
+			return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	meta := map[string]string{
+		"format_version": fmt.Sprint(SQLiteExportVersion),
+		"stash":          stash.Name,
+		"prefix":         stash.Prefix,
+		"exported_at":    time.Now().UTC().Format(time.RFC3339),
+		"exported_by":    actor,
+		"records":        fmt.Sprint(count),
+	}
+	for key, value := range meta {
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s VALUES (?, ?)", sqliteExportMetaTable), key, value); err != nil {
+			return 0, fmt.Errorf("failed to write export metadata: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to write database: %w", err)
+	}
+	if err := db.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write database: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to write database: %w", err)
+	}
+	return count, nil
+}
+
+// SQLiteTable is the contents of a table read from a SQLite database by
+// ReadSQLiteTable.
+type SQLiteTable struct {
+	Name    string
+	Columns []string                 // In table order
+	Rows    []map[string]interface{} // NULL values are left out
+	// StashExport is set when the database is a standalone stash export;
+	// its system columns (_id, _created_at, ...) are then left out of
+	// Columns and Rows.
+	StashExport bool
+}
+
+// ErrNoTable is returned by ReadSQLiteTable when the table to read is
+// missing or, with no table named, cannot be chosen.
+var ErrNoTable = errors.New("no such table")
+
+// SQLiteTables returns the names of the data tables in the SQLite
+// database at path, leaving out SQLite's own tables and those describing
+// a stash export.
+func SQLiteTables(path string) ([]string, error) {
+	db, err := openSQLiteReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return sqliteTables(db)
+}
+
+func sqliteTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type IN ('table', 'view') ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read database: %w", err)
+		}
+		if strings.HasPrefix(name, "sqlite_") || name == sqliteExportMetaTable || name == sqliteExportColumnsTable {
+			continue
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// openSQLiteReadOnly opens the SQLite database at path for reading.
+func openSQLiteReadOnly(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(sqliteDriver, "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+// ReadSQLiteTable reads every row of a table or view in the SQLite
+// database at path. With no table named, the database must hold exactly
+// one. Text comes back as strings, numbers as int64 or float64, and blobs
+// as strings.
+func ReadSQLiteTable(path, table string) (*SQLiteTable, error) {
+	db, err := openSQLiteReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := sqliteTables(db)
+	if err != nil {
+		return nil, err
+	}
+	if table == "" {
+		switch len(tables) {
+		case 0:
+			return nil, fmt.Errorf("%w: database has no tables", ErrNoTable)
+		case 1:
+			table = tables[0]
+		default:
+			return nil, fmt.Errorf("%w: database has %d tables (%s); choose one with --table", ErrNoTable, len(tables), strings.Join(tables, ", "))
+		}
+	} else if !containsString(tables, table) {
+		return nil, fmt.Errorf("%w: '%s' (tables: %s)", ErrNoTable, table, strings.Join(tables, ", "))
+	}
+
+	result := &SQLiteTable{Name: table}
+	var exportTables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, sqliteExportMetaTable).Scan(&exportTables); err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	result.StashExport = exportTables > 0
+
+	rows, err := db.Query("SELECT * FROM " + quoteIdent(table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table '%s': %w", table, err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table '%s': %w", table, err)
+	}
+	for _, name := range names {
+		if result.StashExport && strings.HasPrefix(name, "_") {
+			continue
+		}
+		result.Columns = append(result.Columns, name)
+	}
+
+	values := make([]interface{}, len(names))
+	ptrs := make([]interface{}, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to read table '%s': %w", table, err)
+		}
+		row := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			if result.StashExport && strings.HasPrefix(name, "_") {
+				continue
+			}
+			switch v := values[i].(type) {
+			case nil:
+			case []byte:
+				row[name] = string(v)
+			case time.Time:
+				row[name] = v.UTC().Format(time.RFC3339)
+			default:
+				row[name] = v
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read table '%s': %w", table, err)
+	}
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
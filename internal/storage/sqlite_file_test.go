@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_ExportSQLite(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(filepath.Join(tmpDir, ".stash"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	stash := &model.Stash{
+		Name:    "test-stash",
+		Prefix:  "ts-",
+		Created: now,
+		Columns: model.ColumnList{
+			{Name: "Name", Added: now, Desc: "Product name"},
+			{Name: "Price", Added: now, Validate: "number"},
+			{Name: "Tags", Added: now, List: true},
+		},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+	for _, fields := range []map[string]interface{}{
+		{"Name": "Laptop", "Price": "999.5", "Tags": []interface{}{"new", "sale"}},
+		{"Name": "Mouse", "Price": "25"},
+	} {
+		id, err := store.NextRecordID("test-stash")
+		require.NoError(t, err)
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID: id, Hash: model.CalculateHash(fields), Fields: fields,
+			CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+		}))
+	}
+
+	path := filepath.Join(tmpDir, "out.db")
+	count, err := store.ExportSQLite("test-stash", path, "alice", ListOptions{ParentID: "*"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err), "temporary file removed")
+
+	t.Run("typed columns", func(t *testing.T) {
+		db, err := sql.Open(sqliteDriver, path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		var total float64
+		var priceType string
+		require.NoError(t, db.QueryRow(`SELECT SUM("Price"), typeof(MAX("Price")) FROM "test-stash"`).Scan(&total, &priceType))
+		assert.Equal(t, 1024.5, total)
+		assert.Equal(t, "real", priceType)
+
+		var tags string
+		require.NoError(t, db.QueryRow(`SELECT "Tags" FROM "test-stash" WHERE "Name" = 'Laptop'`).Scan(&tags))
+		assert.JSONEq(t, `["new", "sale"]`, tags)
+
+		var valueType string
+		require.NoError(t, db.QueryRow(`SELECT type FROM _stash_columns WHERE name = 'Price'`).Scan(&valueType))
+		assert.Equal(t, model.ValueTypeNumeric, valueType)
+		var desc string
+		require.NoError(t, db.QueryRow(`SELECT description FROM _stash_columns WHERE name = 'Name'`).Scan(&desc))
+		assert.Equal(t, "Product name", desc)
+
+		var stashName string
+		require.NoError(t, db.QueryRow(`SELECT value FROM _stash_export WHERE key = 'stash'`).Scan(&stashName))
+		assert.Equal(t, "test-stash", stashName)
+
+		var indexes int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'test-stash' AND sql IS NOT NULL`).Scan(&indexes))
+		assert.Equal(t, len(sqliteExportIndexes), indexes)
+	})
+
+	t.Run("read back", func(t *testing.T) {
+		tables, err := SQLiteTables(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test-stash"}, tables)
+
+		table, err := ReadSQLiteTable(path, "")
+		require.NoError(t, err)
+		assert.True(t, table.StashExport)
+		assert.Equal(t, "test-stash", table.Name)
+		assert.Equal(t, []string{"Name", "Price", "Tags"}, table.Columns)
+		require.Len(t, table.Rows, 2)
+
+		byName := map[string]map[string]interface{}{}
+		for _, row := range table.Rows {
+			byName[row["Name"].(string)] = row
+		}
+		assert.Equal(t, 999.5, byName["Laptop"]["Price"])
+		assert.NotContains(t, byName["Mouse"], "Tags", "NULL values are left out")
+		assert.NotContains(t, byName["Mouse"], "_id", "system columns are left out")
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		_, err := ReadSQLiteTable(path, "nope")
+		assert.ErrorIs(t, err, ErrNoTable)
+	})
+
+	t.Run("several tables need a name", func(t *testing.T) {
+		other := filepath.Join(tmpDir, "other.db")
+		db, err := sql.Open(sqliteDriver, other)
+		require.NoError(t, err)
+		_, err = db.Exec(`CREATE TABLE a (x TEXT); CREATE TABLE b (y INTEGER); INSERT INTO b VALUES (7)`)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		_, err = ReadSQLiteTable(other, "")
+		assert.ErrorIs(t, err, ErrNoTable)
+
+		table, err := ReadSQLiteTable(other, "b")
+		require.NoError(t, err)
+		assert.False(t, table.StashExport)
+		assert.Equal(t, []map[string]interface{}{{"y": int64(7)}}, table.Rows)
+	})
+}
@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -667,6 +671,77 @@ func TestSQLiteCache_WhereConditions(t *testing.T) {
 		assert.Len(t, result, 1)
 		assert.Equal(t, "Banana", result[0].Fields["name"])
 	})
+
+	t.Run("filter by system columns", func(t *testing.T) {
+		branched := &model.Record{
+			ID: "ts-abc5", Hash: "hash5", CreatedAt: now, CreatedBy: "alice",
+			UpdatedAt: now, UpdatedBy: "alice", Branch: "feature",
+			Fields: map[string]interface{}{"name": "Damson"},
+		}
+		require.NoError(t, cache.UpsertRecord("test-stash", branched, columns))
+		defer cache.DeleteRecord("test-stash", branched.ID)
+
+		result, err := cache.ListRecords("test-stash", columns, ListOptions{ParentID: "*", CreatedBy: "alice"})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "ts-abc5", result[0].ID)
+
+		result, err = cache.ListRecords("test-stash", columns, ListOptions{ParentID: "*", Branch: "feature", CreatedBy: "user"})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("filter by relative date", func(t *testing.T) {
+		result, err := cache.ListRecords("test-stash", columns, ListOptions{
+			ParentID: "*",
+			Where: []WhereCondition{
+				{Field: "created_at", Operator: ">", Value: "-1h"},
+				{Field: "created_at", Operator: "<", Value: "now+1d"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+
+		result, err = cache.ListRecords("test-stash", columns, ListOptions{
+			ParentID: "*",
+			Where:    []WhereCondition{{Field: "created_at", Operator: "<", Value: "-P1D"}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("filter by CONTAINS", func(t *testing.T) {
+		tagged := &model.Record{
+			ID: "ts-abc4", Hash: "hash4", CreatedAt: now.Add(3 * time.Second), CreatedBy: "user",
+			UpdatedAt: now.Add(3 * time.Second), UpdatedBy: "user",
+			Fields: map[string]interface{}{"name": []interface{}{"urgent", "home"}},
+		}
+		require.NoError(t, cache.UpsertRecord("test-stash", tagged, columns))
+		defer cache.DeleteRecord("test-stash", tagged.ID)
+
+		// Arrays match on any element
+		result, err := cache.ListRecords("test-stash", columns, ListOptions{
+			ParentID: "*",
+			Where: []WhereCondition{
+				{Field: "name", Operator: "CONTAINS", Value: "urgent"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "ts-abc4", result[0].ID)
+		assert.Equal(t, []interface{}{"urgent", "home"}, result[0].Fields["name"])
+
+		// Scalars match on the whole value
+		result, err = cache.ListRecords("test-stash", columns, ListOptions{
+			ParentID: "*",
+			Where: []WhereCondition{
+				{Field: "name", Operator: "CONTAINS", Value: "Apple"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "ts-abc1", result[0].ID)
+	})
 }
 
 func TestSQLiteCache_SearchFilter(t *testing.T) {
@@ -791,7 +866,7 @@ func TestSQLiteCache_OrderBy(t *testing.T) {
 	t.Run("order by user field ascending", func(t *testing.T) {
 		result, err := cache.ListRecords("test-stash", columns, ListOptions{
 			ParentID: "*",
-			OrderBy:  "name",
+			OrderBy:  []OrderKey{{Field: "name"}},
 		})
 		require.NoError(t, err)
 		require.Len(t, result, 3)
@@ -803,7 +878,7 @@ func TestSQLiteCache_OrderBy(t *testing.T) {
 	t.Run("order by user field descending", func(t *testing.T) {
 		result, err := cache.ListRecords("test-stash", columns, ListOptions{
 			ParentID:   "*",
-			OrderBy:    "name",
+			OrderBy:    []OrderKey{{Field: "name"}},
 			Descending: true,
 		})
 		require.NoError(t, err)
@@ -816,7 +891,7 @@ func TestSQLiteCache_OrderBy(t *testing.T) {
 	t.Run("order by system field", func(t *testing.T) {
 		result, err := cache.ListRecords("test-stash", columns, ListOptions{
 			ParentID: "*",
-			OrderBy:  "id",
+			OrderBy:  []OrderKey{{Field: "id"}},
 		})
 		require.NoError(t, err)
 		require.Len(t, result, 3)
@@ -824,6 +899,60 @@ func TestSQLiteCache_OrderBy(t *testing.T) {
 	})
 }
 
+func TestSQLiteCache_SampleAndTail(t *testing.T) {
+	cache, err := NewSQLiteCache(t.TempDir())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "test-user",
+		Columns: model.ColumnList{{Name: "n", Added: time.Now(), AddedBy: "test-user"}},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+	columns := []string{"n"}
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: fmt.Sprintf("ts-%04d", i), Hash: "hash", CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: map[string]interface{}{"n": fmt.Sprint(i)},
+		}, columns))
+	}
+	ids := func(opts ListOptions) []string {
+		t.Helper()
+		opts.ParentID = "*"
+		opts.OrderBy = []OrderKey{{Field: "id"}}
+		result, err := cache.ListRecords("test-stash", columns, opts)
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range result {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	t.Run("tail keeps the listing order", func(t *testing.T) {
+		assert.Equal(t, []string{"ts-0017", "ts-0018", "ts-0019"}, ids(ListOptions{Tail: 3}))
+		assert.Equal(t, []string{"ts-0002", "ts-0001", "ts-0000"}, ids(ListOptions{Tail: 3, Descending: true}))
+		assert.Equal(t, []string{"ts-0008", "ts-0009"}, ids(ListOptions{Tail: 2, Where: []WhereCondition{{Field: "n", Operator: "<", Value: "10"}}}))
+	})
+
+	t.Run("sample picks sorted matching records", func(t *testing.T) {
+		sample := ids(ListOptions{Sample: 5, Where: []WhereCondition{{Field: "n", Operator: ">=", Value: "10"}}})
+		require.Len(t, sample, 5)
+		assert.True(t, sort.StringsAreSorted(sample))
+		for _, id := range sample {
+			assert.GreaterOrEqual(t, id, "ts-0010")
+		}
+		assert.Len(t, ids(ListOptions{Sample: 50}), 20)
+	})
+
+	t.Run("seeded sample is repeatable", func(t *testing.T) {
+		first := ids(ListOptions{Sample: 5, SampleSeed: 42})
+		assert.Equal(t, first, ids(ListOptions{Sample: 5, SampleSeed: 42}))
+		assert.NotEqual(t, first, ids(ListOptions{Sample: 5, SampleSeed: 7}))
+	})
+}
+
 func TestSQLiteCache_DeletedOnlyFilter(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
 	require.NoError(t, err)
@@ -1115,3 +1244,424 @@ func TestSanitizeTableName(t *testing.T) {
 		})
 	}
 }
+
+func TestSQLiteCache_PreparedStatements(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	now := time.Now()
+	newRecord := func(id, name string) *model.Record {
+		return &model.Record{
+			ID:        id,
+			Hash:      "hash-" + id,
+			CreatedAt: now,
+			CreatedBy: "test-user",
+			UpdatedAt: now,
+			UpdatedBy: "test-user",
+			Fields:    map[string]interface{}{"name": name, "qty": "many"},
+		}
+	}
+
+	t.Run("batch upsert writes every record", func(t *testing.T) {
+		records := []*model.Record{newRecord("ts-abc1", "One"), newRecord("ts-abc2", "Two")}
+		require.NoError(t, cache.UpsertRecords("test-stash", records, []string{"name"}))
+
+		count, err := cache.CountRecords("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("statements are reused for the same column set", func(t *testing.T) {
+		_, err := cache.GetRecord("test-stash", "ts-abc1", []string{"name"})
+		require.NoError(t, err)
+		cached := len(cache.stmts)
+
+		_, err = cache.GetRecord("test-stash", "ts-abc2", []string{"name"})
+		require.NoError(t, err)
+		assert.Equal(t, cached, len(cache.stmts))
+	})
+
+	t.Run("schema changes invalidate cached statements", func(t *testing.T) {
+		require.NoError(t, cache.AddColumn("test-stash", "qty"))
+		assert.Empty(t, cache.stmts)
+
+		require.NoError(t, cache.UpsertRecord("test-stash", newRecord("ts-abc3", "Three"), []string{"name", "qty"}))
+		record, err := cache.GetRecord("test-stash", "ts-abc3", []string{"name", "qty"})
+		require.NoError(t, err)
+		assert.Equal(t, "many", record.Fields["qty"])
+	})
+}
+
+func TestSQLiteCache_TypedOrderAndCompare(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "price", Added: time.Now(), AddedBy: "test-user"},
+			{Name: "due", Validate: "date", Added: time.Now(), AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	columns := []string{"price", "due"}
+	now := time.Now()
+	for i, fields := range []map[string]interface{}{
+		{"price": "1000", "due": "2025-03-01"},
+		{"price": "999", "due": "2025-01-15T09:00:00Z"},
+		{"price": "50", "due": "2025-02-01"},
+	} {
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: "ts-abc" + string(rune('1'+i)), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: fields,
+		}, columns))
+	}
+
+	list := func(opts ListOptions) []string {
+		opts.ParentID = "*"
+		records, err := cache.ListRecords("test-stash", columns, opts)
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	t.Run("untyped columns sort as text", func(t *testing.T) {
+		assert.Equal(t, []string{"ts-abc1", "ts-abc3", "ts-abc2"}, list(ListOptions{OrderBy: []OrderKey{{Field: "price"}}}))
+	})
+
+	t.Run("numeric column type sorts by value", func(t *testing.T) {
+		types := map[string]string{"price": model.ValueTypeNumeric}
+		assert.Equal(t, []string{"ts-abc3", "ts-abc2", "ts-abc1"}, list(ListOptions{OrderBy: []OrderKey{{Field: "Price"}}, ColumnTypes: types}))
+	})
+
+	t.Run("explicit type overrides", func(t *testing.T) {
+		assert.Equal(t, []string{"ts-abc1", "ts-abc2", "ts-abc3"}, list(ListOptions{OrderBy: []OrderKey{{Field: "price", Type: model.ValueTypeNumeric, Desc: true}}}))
+	})
+
+	t.Run("date column sorts and compares chronologically", func(t *testing.T) {
+		types := map[string]string{"due": model.ValueTypeDate}
+		assert.Equal(t, []string{"ts-abc2", "ts-abc3", "ts-abc1"}, list(ListOptions{OrderBy: []OrderKey{{Field: "due"}}, ColumnTypes: types}))
+		assert.Equal(t, []string{"ts-abc3", "ts-abc1"}, list(ListOptions{
+			OrderBy:     []OrderKey{{Field: "due"}},
+			ColumnTypes: types,
+			Where:       []WhereCondition{{Field: "due", Operator: ">", Value: "2025-01-31"}},
+		}))
+	})
+
+}
+
+func TestParseOrderBy(t *testing.T) {
+	keys, err := ParseOrderBy("Category, Price:numeric desc, Name ASC", false)
+	require.NoError(t, err)
+	assert.Equal(t, []OrderKey{
+		{Field: "Category"},
+		{Field: "Price", Type: model.ValueTypeNumeric, Desc: true},
+		{Field: "Name"},
+	}, keys)
+
+	keys, err = ParseOrderBy("Category,Price asc", true)
+	require.NoError(t, err)
+	assert.Equal(t, []OrderKey{{Field: "Category", Desc: true}, {Field: "Price"}}, keys)
+
+	keys, err = ParseOrderBy("", false)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	for _, spec := range []string{"Price:money", "Price sideways", "Price desc extra"} {
+		_, err := ParseOrderBy(spec, false)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSQLiteCache_MultipleOrderKeys(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "category", Added: time.Now(), AddedBy: "test-user"},
+			{Name: "price", Added: time.Now(), AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	columns := []string{"category", "price"}
+	now := time.Now()
+	for _, r := range []struct{ id, category, price string }{
+		{"ts-abc4", "tools", "20"},
+		{"ts-abc2", "books", "5"},
+		{"ts-abc3", "tools", "20"},
+		{"ts-abc1", "books", "12"},
+	} {
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: r.id, CreatedAt: now, CreatedBy: "user", UpdatedAt: now, UpdatedBy: "user",
+			Fields: map[string]interface{}{"category": r.category, "price": r.price},
+		}, columns))
+	}
+
+	list := func(keys []OrderKey) []string {
+		records, err := cache.ListRecords("test-stash", columns, ListOptions{ParentID: "*", OrderBy: keys})
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	t.Run("sorts by each key in turn", func(t *testing.T) {
+		keys := []OrderKey{{Field: "category"}, {Field: "price", Type: model.ValueTypeNumeric, Desc: true}}
+		assert.Equal(t, []string{"ts-abc1", "ts-abc2", "ts-abc3", "ts-abc4"}, list(keys))
+	})
+
+	t.Run("ties are broken by id", func(t *testing.T) {
+		assert.Equal(t, []string{"ts-abc1", "ts-abc2", "ts-abc3", "ts-abc4"}, list(nil), "equal updated_at")
+		assert.Equal(t, []string{"ts-abc3", "ts-abc4", "ts-abc1", "ts-abc2"}, list([]OrderKey{{Field: "category", Desc: true}}))
+	})
+
+	t.Run("system columns accept the underscore prefix", func(t *testing.T) {
+		assert.Equal(t, []string{"ts-abc4", "ts-abc3", "ts-abc2", "ts-abc1"}, list([]OrderKey{{Field: "_id", Desc: true}}))
+	})
+}
+
+func TestSQLiteCache_DistinctValues(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "category", Added: time.Now(), AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	columns := []string{"category"}
+	now := time.Now()
+	for i, category := range []interface{}{"tools", "books", "tools", nil} {
+		fields := map[string]interface{}{}
+		if category != nil {
+			fields["category"] = category
+		}
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: "ts-abc" + string(rune('1'+i)), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: fields,
+		}, columns))
+	}
+
+	values, err := cache.DistinctValues("test-stash", "Category", columns, ListOptions{ParentID: "*"})
+	require.NoError(t, err)
+	assert.Equal(t, []ValueCount{
+		{Value: "tools", Count: 2},
+		{Value: nil, Count: 1},
+		{Value: "books", Count: 1},
+	}, values)
+
+	values, err = cache.DistinctValues("test-stash", "category", columns, ListOptions{
+		ParentID: "*",
+		Where:    []WhereCondition{{Field: "category", Operator: "!=", Value: "tools"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ValueCount{{Value: "books", Count: 1}}, values)
+
+	_, err = cache.DistinctValues("test-stash", "missing", columns, ListOptions{ParentID: "*"})
+	assert.ErrorIs(t, err, model.ErrColumnNotFound)
+}
+
+func TestSQLiteCache_RecursiveChildren(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-sqlite-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "test-user",
+	}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	now := time.Now()
+	// ts-abc1.10 must not be mistaken for a descendant of ts-abc1.1
+	for _, id := range []string{"ts-abc1", "ts-abc1.1", "ts-abc1.1.1", "ts-abc1.1.1.1", "ts-abc1.2", "ts-abc1.10", "ts-abc2"} {
+		require.NoError(t, cache.UpsertRecord("test-stash", &model.Record{
+			ID: id, ParentID: model.GetParentID(id), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: map[string]interface{}{},
+		}, nil))
+	}
+
+	ids := func(records []*model.Record) []string {
+		var result []string
+		for _, rec := range records {
+			result = append(result, rec.ID)
+		}
+		return result
+	}
+	byID := []OrderKey{{Field: "id"}}
+
+	result, err := cache.ListRecords("test-stash", nil, ListOptions{ParentID: "ts-abc1.1", Recursive: true, OrderBy: byID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ts-abc1.1.1", "ts-abc1.1.1.1"}, ids(result))
+
+	result, err = cache.ListRecords("test-stash", nil, ListOptions{ParentID: "ts-abc1", Recursive: true, MaxDepth: 2, OrderBy: byID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ts-abc1.1", "ts-abc1.1.1", "ts-abc1.10", "ts-abc1.2"}, ids(result))
+
+	result, err = cache.ListRecords("test-stash", nil, ListOptions{ParentID: "ts-abc1", Recursive: true})
+	require.NoError(t, err)
+	assert.Len(t, result, 5)
+}
+
+func TestSQLiteCache_SchemaMigrations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+
+	stash := &model.Stash{Name: "tasks", Prefix: "tk-", Created: time.Now(), CreatedBy: "test"}
+	require.NoError(t, cache.CreateStashTable(stash))
+
+	t.Run("new tables are at the current version", func(t *testing.T) {
+		version, err := cache.SchemaVersion("tasks")
+		require.NoError(t, err)
+		assert.Equal(t, CacheSchemaVersion, version)
+
+		pending, err := cache.PendingMigrations("tasks")
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	// Take the table back to a layout from before any migration
+	_, err = cache.db.Exec(`DROP INDEX "idx_tasks_archived"`)
+	require.NoError(t, err)
+	_, err = cache.db.Exec(`ALTER TABLE "tasks" DROP COLUMN "signature"`)
+	require.NoError(t, err)
+	_, err = cache.db.Exec(`DROP INDEX "idx_tasks_assigned"`)
+	require.NoError(t, err)
+	_, err = cache.db.Exec(`ALTER TABLE "tasks" DROP COLUMN "assigned_to"`)
+	require.NoError(t, err)
+	require.NoError(t, cache.setSchemaVersion("tasks", 0))
+	require.NoError(t, cache.Close())
+
+	t.Run("pending migrations are applied on open", func(t *testing.T) {
+		cache, err := NewSQLiteCache(tmpDir)
+		require.NoError(t, err)
+		defer cache.Close()
+
+		version, err := cache.SchemaVersion("tasks")
+		require.NoError(t, err)
+		assert.Equal(t, CacheSchemaVersion, version)
+		assert.NoError(t, cache.MigrationError("tasks"))
+
+		for _, col := range []string{"signature", "assigned_to"} {
+			exists, err := cache.columnExists("tasks", col)
+			require.NoError(t, err)
+			assert.True(t, exists, col)
+		}
+
+		var name string
+		err = cache.db.QueryRow(`SELECT name FROM sqlite_master WHERE type='index' AND name='idx_tasks_archived'`).Scan(&name)
+		assert.NoError(t, err)
+	})
+
+	t.Run("newer versions are left alone", func(t *testing.T) {
+		cache, err := NewSQLiteCache(tmpDir)
+		require.NoError(t, err)
+		require.NoError(t, cache.setSchemaVersion("tasks", CacheSchemaVersion+1))
+		require.NoError(t, cache.Close())
+
+		cache, err = NewSQLiteCache(tmpDir)
+		require.NoError(t, err)
+		defer cache.Close()
+
+		version, err := cache.SchemaVersion("tasks")
+		require.NoError(t, err)
+		assert.Equal(t, CacheSchemaVersion+1, version)
+
+		pending, err := cache.PendingMigrations("tasks")
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
+
+func TestSQLiteCache_UnversionedMetaTable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A cache written before schema versions were recorded
+	db, err := sql.Open(sqliteDriver, sqliteFileDSN(filepath.Join(tmpDir, "cache.db")))
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE _stash_meta (stash_name TEXT PRIMARY KEY, prefix TEXT, config_json TEXT, last_sync TEXT)`,
+		`CREATE TABLE "tasks" (id TEXT PRIMARY KEY, hash TEXT NOT NULL, parent_id TEXT,
+			created_at TEXT NOT NULL, created_by TEXT NOT NULL, updated_at TEXT NOT NULL,
+			updated_by TEXT NOT NULL, branch TEXT, deleted_at TEXT, deleted_by TEXT)`,
+		`INSERT INTO _stash_meta VALUES ('tasks', 'tk-', '{"name":"tasks","prefix":"tk-"}', '')`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	cache, err := NewSQLiteCache(tmpDir)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	version, err := cache.SchemaVersion("tasks")
+	require.NoError(t, err)
+	assert.Equal(t, CacheSchemaVersion, version)
+
+	for _, col := range []string{"archived_at", "archived_by", "signature"} {
+		exists, err := cache.columnExists("tasks", col)
+		require.NoError(t, err)
+		assert.True(t, exists, col)
+	}
+}
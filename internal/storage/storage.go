@@ -2,36 +2,100 @@
 package storage
 
 import (
+	"time"
+
 	"github.com/user/stash/internal/model"
 )
 
 // WhereCondition represents a single filter condition.
 type WhereCondition struct {
 	Field    string // Field name (column)
-	Operator string // =, !=, <, >, <=, >=, LIKE
+	Operator string // =, !=, <, >, <=, >=, LIKE, CONTAINS, IS [NOT] NULL/EMPTY
 	Value    string // Value to compare against
 }
 
+// OrderKey is one sort key of a listing.
+type OrderKey struct {
+	Field string // Field name (column)
+	Type  string // Value type override (model.ValueType*); empty = the column's own
+	Desc  bool   // Sort descending
+}
+
+// ValueCount is one distinct value of a column and the number of records
+// holding it. Value is nil for records where the column is unset.
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
 // ListOptions configures record listing behavior.
 type ListOptions struct {
 	// IncludeDeleted includes soft-deleted records in the result.
 	IncludeDeleted bool
 	// DeletedOnly shows only deleted records (when combined with IncludeDeleted).
 	DeletedOnly bool
+	// ExcludeArchived hides archived records from the result.
+	ExcludeArchived bool
+	// ArchivedOnly shows only archived records.
+	ArchivedOnly bool
+	// AssignedTo shows only records assigned to this agent (empty = no
+	// restriction).
+	AssignedTo string
+	// CreatedBy shows only records created by this actor (empty = no
+	// restriction).
+	CreatedBy string
+	// DeletedBy shows only records deleted by this actor (empty = no
+	// restriction). It matches deleted records only, so it needs
+	// IncludeDeleted.
+	DeletedBy string
+	// Branch shows only records created on this git branch (empty = no
+	// restriction).
+	Branch string
 	// ParentID filters records by parent (empty = root records only, "*" = all).
 	ParentID string
+	// Recursive includes every descendant of ParentID, not just its direct
+	// children. Ignored when ParentID is empty or "*".
+	Recursive bool
+	// MaxDepth limits a recursive listing to N levels below ParentID
+	// (0 = unlimited).
+	MaxDepth int
+	// UpdatedSince restricts the result to records updated at or after this
+	// time (zero = no restriction).
+	UpdatedSince time.Time
 	// Limit restricts the number of results (0 = no limit).
 	Limit int
 	// Offset skips the first N results.
 	Offset int
-	// OrderBy specifies the sort field.
-	OrderBy string
-	// Descending reverses the sort order.
+	// Sample restricts the result to N matching records picked at random,
+	// still listed in the OrderBy order (0 = no sampling).
+	Sample int
+	// SampleSeed makes Sample pick the same records every time for the
+	// same seed and data (0 = a different pick each time).
+	SampleSeed int64
+	// Tail restricts the result to the last N matching records in the
+	// OrderBy order, still listed in that order (0 = no restriction).
+	Tail int
+	// OrderBy specifies the sort keys, in priority order (empty = by
+	// updated_at). Ties are always broken by ID.
+	OrderBy []OrderKey
+	// ColumnTypes maps column names to their value type (model.ValueType*)
+	// for sorting and range comparisons. Store fills it from the stash's
+	// column metadata when nil; unlisted columns compare as text.
+	ColumnTypes map[string]string
+	// Collation compares the text values of user columns in OrderBy, =
+	// and != conditions, and distinct values (model.Collation*, or a
+	// language tag). Store fills it from the stash's config when empty.
+	Collation string
+	// Descending sorts the default updated_at order, and every key in
+	// OrderBy, descending.
 	Descending bool
 	// Where specifies filter conditions (ANDed together).
 	Where []WhereCondition
 	// Search specifies a full-text search term across all fields.
 	Search string
+	// SearchMode selects how Search matches: SearchExact, SearchCI, or
+	// SearchFuzzy (empty = SearchCI).
+	SearchMode string
 	// Columns specifies which columns to return (empty = all).
 	Columns []string
 }
@@ -43,6 +107,7 @@ type Storage interface {
 	DropStash(name string) error
 	GetStash(name string) (*model.Stash, error)
 	ListStashes() ([]*model.Stash, error)
+	ChangePrefix(stashName, newPrefix string, migrate bool) (*PrefixChange, error)
 
 	// Column management
 	AddColumn(stashName string, col model.Column) error
@@ -52,8 +117,14 @@ type Storage interface {
 	UpdateRecord(stashName string, record *model.Record) error
 	DeleteRecord(stashName string, id string, actor string) error
 	RestoreRecord(stashName string, id string, actor string) error
+	ArchiveRecord(stashName string, id string, actor string) error
+	UnarchiveRecord(stashName string, id string, actor string) error
 	GetRecord(stashName string, id string) (*model.Record, error)
 	ListRecords(stashName string, opts ListOptions) ([]*model.Record, error)
+	IterateRecords(stashName string, opts ListOptions, fn func(*model.Record) error) error
+
+	// ID generation
+	NextRecordID(stashName string) (string, error)
 
 	// Child record operations
 	GetChildren(stashName string, parentID string) ([]*model.Record, error)
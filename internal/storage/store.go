@@ -1,12 +1,24 @@
 package storage
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/user/stash/internal/logging"
 	"github.com/user/stash/internal/model"
+	"github.com/user/stash/internal/telemetry"
 )
 
 // Store implements the Storage interface using JSONL files and SQLite cache.
@@ -15,10 +27,33 @@ type Store struct {
 	jsonl   *JSONLStore
 	sqlite  *SQLiteCache
 	config  *ConfigStore
+
+	mu   sync.Mutex
+	refs int // open references; the cache is closed when this reaches zero
+
+	lockMu sync.Mutex
+	held   map[string]*heldLock // stash write locks this store holds (see lockStash)
+
+	signer Signer
+	source string                    // noted on each logged operation (see SetSource)
+	runID  string                    // noted on each logged operation (see SetRunID)
+	staged *JSONLStore               // pending area logged operations go to in a transaction (see OpenTx)
+	view   *txView                   // where a transaction's view loads stashes from (see OpenTx)
+	actor  func(stash string) string // names who changes a stash's schema
+	ctx    context.Context           // no write starts once it is done (see SetContext)
 }
 
+// Signer signs operations on behalf of an actor. It returns a nil key when
+// no signing key is available for the actor, in which case the operation
+// is written unsigned.
+type Signer func(actor string) (ed25519.PrivateKey, error)
+
 // NewStore creates a new storage instance.
 func NewStore(baseDir string) (*Store, error) {
+	if baseDir == MemoryDir {
+		return NewMemoryStore()
+	}
+
 	// Ensure base directory exists
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create stash directory: %w", err)
@@ -26,22 +61,79 @@ func NewStore(baseDir string) (*Store, error) {
 
 	jsonl := NewJSONLStore(baseDir)
 	config := NewConfigStore(baseDir)
+	jsonl.recoverTornWrites()
 
 	sqlite, err := NewSQLiteCache(baseDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize SQLite cache: %w", err)
 	}
+	logging.Debug("store open", "dir", baseDir, "driver", sqliteDriverName)
 
 	return &Store{
 		baseDir: baseDir,
 		jsonl:   jsonl,
 		sqlite:  sqlite,
 		config:  config,
+		refs:    1,
+		ctx:     context.Background(),
+	}, nil
+}
+
+// NewMemoryStore creates a store that keeps its config, JSONL log, and
+// cache in memory. Nothing is written to disk, and everything is lost when
+// the store is closed. Attachments and published keys are not supported.
+func NewMemoryStore() (*Store, error) {
+	sqlite, err := NewSQLiteCache(MemoryDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize SQLite cache: %w", err)
+	}
+
+	mem := newMemFS()
+	return &Store{
+		baseDir: MemoryDir,
+		jsonl:   &JSONLStore{baseDir: MemoryDir, mem: mem, ctx: context.Background()},
+		sqlite:  sqlite,
+		config:  &ConfigStore{baseDir: MemoryDir, mem: mem},
+		refs:    1,
+		ctx:     context.Background(),
 	}, nil
 }
 
-// Close releases resources.
+// TornWrites returns the incomplete final lines this store cut off the end
+// of stash logs and quarantined (see JSONLStore.RecoverTornWrite). Logs are
+// checked when the store is opened and before each append.
+func (s *Store) TornWrites() []TornWrite {
+	return s.jsonl.TornWrites()
+}
+
+// QuarantinedWrites returns the files torn writes to a stash's log were
+// moved to.
+func (s *Store) QuarantinedWrites(stashName string) ([]string, error) {
+	return s.jsonl.QuarantinedWrites(stashName)
+}
+
+// IsMemory returns true if the store is held in memory.
+func (s *Store) IsMemory() bool {
+	return s.baseDir == MemoryDir
+}
+
+// Retain adds a reference to the store so it can be shared between callers
+// that each Close it when done. Resources are released by the final Close.
+func (s *Store) Retain() *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs++
+	return s
+}
+
+// Close releases resources once every reference has been closed.
 func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs--
+	if s.refs > 0 {
+		return nil
+	}
 	return s.sqlite.Close()
 }
 
@@ -52,6 +144,9 @@ func (s *Store) BaseDir() string {
 
 // CreateStash creates a new stash with the given name and prefix.
 func (s *Store) CreateStash(name, prefix string, stash *model.Stash) error {
+	if err := s.inTx(); err != nil {
+		return err
+	}
 	// Check if stash already exists
 	if s.config.Exists(name) {
 		return model.ErrStashExists
@@ -69,11 +164,14 @@ func (s *Store) CreateStash(name, prefix string, stash *model.Stash) error {
 		return err
 	}
 
-	return nil
+	return s.logSchema(nil, stash)
 }
 
 // DropStash removes a stash and all its data.
 func (s *Store) DropStash(name string) error {
+	if err := s.inTx(); err != nil {
+		return err
+	}
 	// Check if stash exists
 	if !s.config.Exists(name) {
 		return model.ErrStashNotFound
@@ -83,6 +181,12 @@ func (s *Store) DropStash(name string) error {
 	if err := s.sqlite.DropStashTable(name); err != nil {
 		return err
 	}
+	if err := s.sqlite.ClearReads(name); err != nil {
+		return err
+	}
+	if err := s.sqlite.ClearWriteRates(name); err != nil {
+		return err
+	}
 
 	// Delete config directory (includes JSONL)
 	if err := s.config.DeleteConfig(name); err != nil {
@@ -94,6 +198,10 @@ func (s *Store) DropStash(name string) error {
 
 // GetStash retrieves stash configuration.
 func (s *Store) GetStash(name string) (*model.Stash, error) {
+	if err := s.loadTxStash(name); err != nil {
+		return nil, err
+	}
+
 	// Try SQLite cache first
 	stash, err := s.sqlite.GetStash(name)
 	if err == nil {
@@ -111,9 +219,10 @@ func (s *Store) GetStash(name string) (*model.Stash, error) {
 
 // ListStashes returns all stash configurations.
 func (s *Store) ListStashes() ([]*model.Stash, error) {
-	// Try SQLite cache first
+	// Try SQLite cache first; a transaction's view only caches the
+	// stashes it has used
 	stashes, err := s.sqlite.ListStashes()
-	if err == nil && len(stashes) > 0 {
+	if err == nil && len(stashes) > 0 && s.view == nil {
 		return stashes, nil
 	}
 
@@ -135,13 +244,168 @@ func (s *Store) ListStashes() ([]*model.Stash, error) {
 	return stashes, nil
 }
 
+// PrefixChange is the result of changing a stash's prefix.
+type PrefixChange struct {
+	Mapping map[string]string // old ID -> new ID of each migrated record
+	// Signatures cover the record ID, so the operations of a migrated
+	// record are signed again with their actors' keys where this machine
+	// has them. Resigned lists the migrated records whose signed
+	// operations were all signed again; Unsigned those with operations
+	// whose signatures were dropped.
+	Resigned []string
+	Unsigned []string
+}
+
+// ChangePrefix sets a new ID prefix for a stash. When migrate is true, every
+// record ID and parent reference using the old prefix is rewritten in the
+// JSONL log (history included) and attachment directories are renamed.
+// The config is restored if the log cannot be rewritten.
+func (s *Store) ChangePrefix(stashName, newPrefix string, migrate bool) (*PrefixChange, error) {
+	if err := s.inTx(); err != nil {
+		return nil, err
+	}
+	if err := model.ValidatePrefix(newPrefix); err != nil {
+		return nil, err
+	}
+
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := s.lockStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	oldPrefix := stash.Prefix
+
+	change := &PrefixChange{Mapping: make(map[string]string), Resigned: []string{}, Unsigned: []string{}}
+	mapping := change.Mapping
+	var records []*model.Record
+	if migrate && oldPrefix != newPrefix {
+		records, err = s.jsonl.ReadAllRecords(stashName)
+		if err != nil {
+			return nil, err
+		}
+
+		existing := make(map[string]bool)
+		for _, rec := range records {
+			existing[rec.ID] = true
+		}
+		for id := range existing {
+			if strings.HasPrefix(id, oldPrefix) {
+				mapping[id] = newPrefix + strings.TrimPrefix(id, oldPrefix)
+			}
+		}
+		for oldID, newID := range mapping {
+			if existing[newID] {
+				return nil, fmt.Errorf("%w: cannot migrate %s to %s", model.ErrRecordExists, oldID, newID)
+			}
+		}
+	}
+
+	// Rename the records and sign their operations again, before anything
+	// is written
+	unsigned := make(map[string]bool)
+	resigned := make(map[string]bool)
+	for _, rec := range records {
+		newID, renamed := mapping[rec.ID]
+		newParent, reparented := mapping[rec.ParentID]
+		if !renamed && !reparented {
+			continue
+		}
+		if renamed {
+			rec.ID = newID
+		}
+		if reparented {
+			rec.ParentID = newParent
+		}
+		if rec.Signature == "" {
+			continue
+		}
+		rec.Signature = ""
+		if s.signer != nil {
+			key, err := s.signer(rec.UpdatedBy)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load signing key for %s: %w", rec.UpdatedBy, err)
+			}
+			if key != nil {
+				model.SignRecord(rec, key)
+			}
+		}
+		if rec.Signature == "" {
+			unsigned[rec.ID] = true
+		} else {
+			resigned[rec.ID] = true
+		}
+	}
+	for id := range unsigned {
+		change.Unsigned = append(change.Unsigned, id)
+	}
+	for id := range resigned {
+		if !unsigned[id] {
+			change.Resigned = append(change.Resigned, id)
+		}
+	}
+	sort.Strings(change.Unsigned)
+	sort.Strings(change.Resigned)
+
+	// Update config first so a failed migration leaves old IDs valid
+	old := *stash
+	stash.Prefix = newPrefix
+	if err := s.UpdateStashConfig(stash); err != nil {
+		return nil, err
+	}
+
+	if len(mapping) == 0 {
+		return change, nil
+	}
+
+	// Rewrite the log in a single atomic write
+	err = s.writeLog(stash, "prefix", func(write func(*model.Record) error) error {
+		for _, rec := range records {
+			if err := write(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if rbErr := s.UpdateStashConfig(&old); rbErr != nil {
+			return nil, fmt.Errorf("%w; failed to roll back config: %w", err, rbErr)
+		}
+		return nil, err
+	}
+
+	// Move attachment directories
+	for oldID, newID := range mapping {
+		oldDir := s.GetFilesDir(stashName, oldID)
+		if _, err := os.Stat(oldDir); err != nil {
+			continue
+		}
+		if err := os.Rename(oldDir, s.GetFilesDir(stashName, newID)); err != nil {
+			return nil, fmt.Errorf("failed to move files for %s: %w", oldID, err)
+		}
+	}
+
+	if err := s.RebuildCache(stashName); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
 // AddColumn adds a new column to a stash.
 func (s *Store) AddColumn(stashName string, col model.Column) error {
+	if err := s.inTx(); err != nil {
+		return err
+	}
 	// Get current stash config
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
 	}
+	old := *stash
 
 	// Add column to stash
 	if err := stash.AddColumn(col); err != nil {
@@ -154,7 +418,16 @@ func (s *Store) AddColumn(stashName string, col model.Column) error {
 	}
 
 	// Add column to SQLite table
-	if err := s.sqlite.AddColumn(stashName, col.Name); err != nil {
+	if col.IsComputed() {
+		// SQLite validates the expression, so roll back the config if it is rejected
+		if err := s.sqlite.AddComputedColumn(stashName, col.Name, col.Computed); err != nil {
+			stash.Columns = stash.Columns[:len(stash.Columns)-1]
+			if rbErr := s.config.WriteConfig(stash); rbErr != nil {
+				return fmt.Errorf("%w; failed to roll back config: %w", err, rbErr)
+			}
+			return err
+		}
+	} else if err := s.sqlite.AddColumn(stashName, col.Name); err != nil {
 		return err
 	}
 
@@ -163,29 +436,359 @@ func (s *Store) AddColumn(stashName string, col model.Column) error {
 		return err
 	}
 
+	return s.logSchema(&old, stash)
+}
+
+// CheckColumn returns the error AddColumn would give for the column,
+// without adding it.
+func (s *Store) CheckColumn(stashName string, col model.Column) error {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+	if err := stash.AddColumn(col); err != nil {
+		return err
+	}
+	if col.IsComputed() {
+		return s.sqlite.CheckComputedColumn(stashName, col.Name, col.Computed)
+	}
+	return nil
+}
+
+// SetContext sets the context the store works under, such as a command's
+// deadline. Once it is done, cache queries and log reads fail with its
+// error and no new write to a log is started. A write already under way
+// is finished, so the log and the cache stay consistent with each other.
+func (s *Store) SetContext(ctx context.Context) {
+	s.ctx = ctx
+	s.sqlite.ctx = ctx
+	s.jsonl.ctx = ctx
+}
+
+// SetSigner sets the signer used to sign operations as they are logged.
+func (s *Store) SetSigner(signer Signer) {
+	s.signer = signer
+}
+
+// SetSource sets the source recorded on operations as they are logged:
+// where their values came from, such as a file or an agent run. An empty
+// source records none.
+func (s *Store) SetSource(source string) {
+	s.source = source
+}
+
+// SetRunID sets the run recorded on operations as they are logged, so
+// everything one agent run did can be reviewed or undone together. An
+// empty ID records none.
+func (s *Store) SetRunID(runID string) {
+	s.runID = runID
+}
+
+// appendLog appends an operation to a stash's JSONL log, noting the
+// store's source and run, signing it when the acting actor has a key, and
+// extending the hash chain when the stash has one. Operations on existing
+// records note the hash of the state they were made from, so concurrent
+// changes can be told apart after a merge. In a transaction the operation
+// is also staged in its pending area.
+func (s *Store) appendLog(stash *model.Stash, record *model.Record) error {
+	record.Source = s.source
+	record.RunID = s.runID
+	return s.appendLogs(stash, []*model.Record{record})
+}
+
+// appendLogs appends operations to a stash's JSONL log in one write, so
+// either all of them are logged or none are, under the stash's write lock.
+// Each keeps the source and run it has, and is signed and noted with its
+// base hash as by appendLog; an operation's base is the state the ones
+// before it left the record in.
+func (s *Store) appendLogs(stash *model.Stash, records []*model.Record) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := s.lockStash(stash.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	hashes := make(map[string]string, len(records))
+	for _, record := range records {
+		record.BaseHash = ""
+		if record.Operation != model.OpCreate {
+			if hash, ok := hashes[record.ID]; ok {
+				record.BaseHash = hash
+			} else if current, err := s.sqlite.GetRecord(stash.Name, record.ID, nil); err == nil {
+				record.BaseHash = current.Hash
+			}
+		}
+		hashes[record.ID] = record.Hash
+
+		record.Signature = ""
+		if s.signer != nil {
+			key, err := s.signer(record.UpdatedBy)
+			if err != nil {
+				return fmt.Errorf("failed to load signing key for %s: %w", record.UpdatedBy, err)
+			}
+			if key != nil {
+				model.SignRecord(record, key)
+			}
+		}
+	}
+
+	if err := s.jsonl.AppendRecords(stash.Name, records, stash.HashChain); err != nil {
+		return err
+	}
+	if s.staged != nil {
+		if err := s.staged.AppendRecords(stash.Name, records, false); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		telemetry.RecordWritten(stash.Name, record.Operation)
+	}
+	if stash.FullDurability() {
+		return s.jsonl.syncDir(stash.Name)
+	}
 	return nil
 }
 
-// CreateRecord creates a new record.
-func (s *Store) CreateRecord(stashName string, record *model.Record) error {
+// writeLog rewrites a stash's JSONL log under its write lock. When the
+// stash has a hash chain, the new log continues it and the rewrite is
+// recorded with the reason given (see Rechain).
+func (s *Store) writeLog(stash *model.Stash, reason string, produce func(write func(*model.Record) error) error) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.inTx(); err != nil {
+		return err
+	}
+	unlock, err := s.lockStash(stash.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if stash.HashChain {
+		err = s.jsonl.WriteChainedRecordsFrom(stash.Name, reason, produce)
+	} else {
+		err = s.jsonl.WriteRecordsFrom(stash.Name, produce)
+	}
+	if err != nil || !stash.FullDurability() {
+		return err
+	}
+	return s.jsonl.syncDir(stash.Name)
+}
+
+// EnableHashChain turns on hash chain mode for a stash and chains the
+// existing log from its first line.
+func (s *Store) EnableHashChain(stashName string) error {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+	unlock, err := s.lockStash(stashName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := s.jsonl.ReadAllRecords(stashName)
+	if err != nil {
+		return err
+	}
+
+	stash.HashChain = true
+	err = s.writeLog(stash, "enable", func(write func(*model.Record) error) error {
+		for _, rec := range records {
+			if err := write(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateStashConfig(stash)
+}
+
+// VerifyChain checks the hash chain of a stash's JSONL log.
+func (s *Store) VerifyChain(stashName string) (*ChainReport, error) {
+	if _, err := s.GetStash(stashName); err != nil {
+		return nil, err
+	}
+	return s.jsonl.VerifyChain(stashName)
+}
+
+// keysDir returns the meta directory holding actors' registered public keys.
+func (s *Store) keysDir() string {
+	return filepath.Join(s.baseDir, "_keys")
+}
+
+// PublishKey registers an actor's public key, replacing any previous key.
+func (s *Store) PublishKey(key *model.ActorKey) error {
+	if _, err := key.Key(); err != nil {
+		return err
+	}
+	if s.IsMemory() {
+		return ErrInMemory
+	}
+	if err := os.MkdirAll(s.keysDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create keys directory: %w", err)
+	}
+	data, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.keysDir(), key.Actor+".pub")
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ActorKeys returns the registered public keys, keyed by actor.
+func (s *Store) ActorKeys() (map[string]*model.ActorKey, error) {
+	keys := make(map[string]*model.ActorKey)
+	entries, err := os.ReadDir(s.keysDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pub" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.keysDir(), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var key model.ActorKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", model.ErrInvalidKey, entry.Name(), err)
+		}
+		keys[key.Actor] = &key
+	}
+	return keys, nil
+}
+
+// SignatureIssue describes an operation whose attribution cannot be trusted.
+type SignatureIssue struct {
+	Line    int    `json:"line"`
+	ID      string `json:"id"`
+	Actor   string `json:"actor"`
+	Problem string `json:"problem"`
+}
+
+// SignatureReport is the result of verifying the signatures in a stash's log.
+type SignatureReport struct {
+	Lines      int              `json:"lines"`
+	Signed     int              `json:"signed"`
+	Valid      int              `json:"valid"`
+	Unsigned   int              `json:"unsigned"`
+	Unverified int              `json:"unverified"`
+	Issues     []SignatureIssue `json:"issues"`
+}
+
+// VerifySignatures checks every operation in a stash's JSONL log against the
+// registered actor keys. A signature that does not verify is an issue, as is
+// an unsigned operation attributed to an actor who had registered a key by
+// then. Unsigned operations by actors without keys, and signatures by actors
+// whose keys are not registered, are only counted.
+func (s *Store) VerifySignatures(stashName string) (*SignatureReport, error) {
+	if _, err := s.GetStash(stashName); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.ActorKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.jsonl.ReadAllRecords(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SignatureReport{Lines: len(records), Issues: []SignatureIssue{}}
+	for i, rec := range records {
+		actor := rec.UpdatedBy
+		issue := func(problem string) {
+			report.Issues = append(report.Issues, SignatureIssue{Line: i + 1, ID: rec.ID, Actor: actor, Problem: problem})
+		}
+
+		key, hasKey := keys[actor]
+		if rec.Signature == "" {
+			report.Unsigned++
+			if hasKey && !rec.UpdatedAt.Before(key.Added) {
+				issue("unsigned operation by an actor with a registered key")
+			}
+			continue
+		}
+
+		report.Signed++
+		if !hasKey {
+			report.Unverified++
+			continue
+		}
+		pub, err := key.Key()
+		if err != nil {
+			issue(err.Error())
+			continue
+		}
+		if !model.VerifyRecordSignature(rec, pub) {
+			issue("signature does not match the actor's key")
+			continue
+		}
+		report.Valid++
+	}
+
+	return report, nil
+}
+
+// CreateRecord creates a new record. It fails with ErrRecordExists if a
+// record, deleted or not, already has the ID.
+func (s *Store) CreateRecord(stashName string, record *model.Record) (err error) {
+	defer telemetry.Start("Store.CreateRecord", stashAttr(stashName), recordAttr(record.ID)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
 	}
 
+	unlock, err := s.lockStash(stashName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := s.sqlite.GetRecord(stashName, record.ID, nil); err == nil {
+		return fmt.Errorf("%w: %s", model.ErrRecordExists, record.ID)
+	} else if !errors.Is(err, model.ErrRecordNotFound) {
+		return err
+	}
+
 	// Set operation type
 	record.Operation = model.OpCreate
+	stripComputedFields(stash, record)
+	if err := stash.CheckSize(record, nil); err != nil {
+		return err
+	}
+	if err := s.checkRecordQuota(stash); err != nil {
+		return err
+	}
+	if err := s.checkWriteRate(stash, record.UpdatedBy); err != nil {
+		return err
+	}
 
 	// Calculate hash
 	record.Hash = record.CalculateHash()
 
 	// Append to JSONL
-	if err := s.jsonl.AppendRecord(stashName, record); err != nil {
+	if err := s.appendLog(stash, record); err != nil {
 		return err
 	}
 
 	// Update SQLite cache
-	columns := stash.Columns.Names()
+	columns := stash.Columns.StoredNames()
 	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
 		return err
 	}
@@ -194,7 +797,8 @@ func (s *Store) CreateRecord(stashName string, record *model.Record) error {
 }
 
 // UpdateRecord updates an existing record.
-func (s *Store) UpdateRecord(stashName string, record *model.Record) error {
+func (s *Store) UpdateRecord(stashName string, record *model.Record) (err error) {
+	defer telemetry.Start("Store.UpdateRecord", stashAttr(stashName), recordAttr(record.ID)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
@@ -202,17 +806,53 @@ func (s *Store) UpdateRecord(stashName string, record *model.Record) error {
 
 	// Set operation type
 	record.Operation = model.OpUpdate
+	stripComputedFields(stash, record)
+	current, _ := s.sqlite.GetRecord(stashName, record.ID, stash.Columns.StoredNames())
+	if err := stash.CheckSize(record, current); err != nil {
+		return err
+	}
+	if err := s.checkWriteRate(stash, record.UpdatedBy); err != nil {
+		return err
+	}
 
 	// Calculate new hash
 	record.Hash = record.CalculateHash()
 
 	// Append to JSONL
-	if err := s.jsonl.AppendRecord(stashName, record); err != nil {
+	if err := s.appendLog(stash, record); err != nil {
 		return err
 	}
 
 	// Update SQLite cache
-	columns := stash.Columns.Names()
+	columns := stash.Columns.StoredNames()
+	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReplayRecord appends an operation taken from another log, keeping its
+// operation type, timestamps, and actors. The hash is recalculated and the
+// operation is signed afresh, since its ID may have been changed.
+func (s *Store) ReplayRecord(stashName string, record *model.Record) (err error) {
+	defer telemetry.Start("Store.ReplayRecord", stashAttr(stashName), recordAttr(record.ID)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+
+	stripComputedFields(stash, record)
+	record.Hash = record.CalculateHash()
+	record.PrevHash = ""
+
+	// Append to JSONL
+	if err := s.appendLog(stash, record); err != nil {
+		return err
+	}
+
+	// Update SQLite cache
+	columns := stash.Columns.StoredNames()
 	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
 		return err
 	}
@@ -220,8 +860,35 @@ func (s *Store) UpdateRecord(stashName string, record *model.Record) error {
 	return nil
 }
 
+// PreviewRecord returns the operation that writing the record with op
+// would append to the log, without writing it: a copy of the record with
+// the operation type set, computed fields removed, and, for creates and
+// updates, the new hash. The hash chain link and signature are added only
+// when an operation is written.
+func (s *Store) PreviewRecord(stashName string, record *model.Record, op string) (*model.Record, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := *record
+	preview.Fields = make(map[string]interface{}, len(record.Fields))
+	for name, value := range record.Fields {
+		preview.Fields[name] = value
+	}
+	preview.Operation = op
+	preview.PrevHash = ""
+	preview.Signature = ""
+	stripComputedFields(stash, &preview)
+	if op == model.OpCreate || op == model.OpUpdate {
+		preview.Hash = preview.CalculateHash()
+	}
+	return &preview, nil
+}
+
 // DeleteRecord soft-deletes a record.
-func (s *Store) DeleteRecord(stashName string, id string, actor string) error {
+func (s *Store) DeleteRecord(stashName string, id string, actor string) (err error) {
+	defer telemetry.Start("Store.DeleteRecord", stashAttr(stashName), recordAttr(id)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
@@ -245,14 +912,18 @@ func (s *Store) DeleteRecord(stashName string, id string, actor string) error {
 	record.UpdatedAt = now
 	record.UpdatedBy = actor
 	record.Operation = model.OpDelete
+	stripComputedFields(stash, record)
+	if err := s.checkWriteRate(stash, actor); err != nil {
+		return err
+	}
 
 	// Append to JSONL
-	if err := s.jsonl.AppendRecord(stashName, record); err != nil {
+	if err := s.appendLog(stash, record); err != nil {
 		return err
 	}
 
 	// Update SQLite cache
-	columns := stash.Columns.Names()
+	columns := stash.Columns.StoredNames()
 	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
 		return err
 	}
@@ -261,46 +932,203 @@ func (s *Store) DeleteRecord(stashName string, id string, actor string) error {
 }
 
 // RestoreRecord restores a soft-deleted record.
-func (s *Store) RestoreRecord(stashName string, id string, actor string) error {
+func (s *Store) RestoreRecord(stashName string, id string, actor string) (err error) {
+	defer telemetry.Start("Store.RestoreRecord", stashAttr(stashName), recordAttr(id)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+
+	// Get current record (including deleted)
+	record, err := s.GetRecordIncludeDeleted(stashName, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if not deleted
+	if !record.IsDeleted() {
+		return fmt.Errorf("record is not deleted")
+	}
+
+	// Clear deletion metadata
+	record.DeletedAt = nil
+	record.DeletedBy = ""
+	record.UpdatedAt = time.Now()
+	record.UpdatedBy = actor
+	record.Operation = model.OpRestore
+	stripComputedFields(stash, record)
+	if err := s.checkRecordQuota(stash); err != nil {
+		return err
+	}
+	if err := s.checkWriteRate(stash, actor); err != nil {
+		return err
+	}
+
+	// Append to JSONL
+	if err := s.appendLog(stash, record); err != nil {
+		return err
+	}
+
+	// Update SQLite cache
+	columns := stash.Columns.StoredNames()
+	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ArchiveRecord marks a record as archived. Archived records stay in the
+// cache and history but are hidden from default list output.
+func (s *Store) ArchiveRecord(stashName string, id string, actor string) (err error) {
+	defer telemetry.Start("Store.ArchiveRecord", stashAttr(stashName), recordAttr(id)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.GetRecord(stashName, id)
+	if err != nil {
+		return err
+	}
+
+	if record.IsArchived() {
+		return model.ErrRecordArchived
+	}
+
+	// Set archive metadata
+	now := time.Now()
+	record.ArchivedAt = &now
+	record.ArchivedBy = actor
+	record.UpdatedAt = now
+	record.UpdatedBy = actor
+	record.Operation = model.OpArchive
+	stripComputedFields(stash, record)
+	if err := s.checkWriteRate(stash, actor); err != nil {
+		return err
+	}
+
+	// Append to JSONL
+	if err := s.appendLog(stash, record); err != nil {
+		return err
+	}
+
+	// Update SQLite cache
+	columns := stash.Columns.StoredNames()
+	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnarchiveRecord returns an archived record to the active set.
+func (s *Store) UnarchiveRecord(stashName string, id string, actor string) (err error) {
+	defer telemetry.Start("Store.UnarchiveRecord", stashAttr(stashName), recordAttr(id)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.GetRecord(stashName, id)
+	if err != nil {
+		return err
+	}
+
+	if !record.IsArchived() {
+		return model.ErrRecordNotArchived
+	}
+
+	// Clear archive metadata
+	record.ArchivedAt = nil
+	record.ArchivedBy = ""
+	record.UpdatedAt = time.Now()
+	record.UpdatedBy = actor
+	record.Operation = model.OpUnarchive
+	stripComputedFields(stash, record)
+	if err := s.checkWriteRate(stash, actor); err != nil {
+		return err
+	}
+
+	// Append to JSONL
+	if err := s.appendLog(stash, record); err != nil {
+		return err
+	}
+
+	// Update SQLite cache
+	columns := stash.Columns.StoredNames()
+	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AssignRecord assigns a record to an agent, replacing any earlier
+// assignment. Assignment records who is meant to work on a record; unlike
+// a lock, it does not stop others from changing it.
+func (s *Store) AssignRecord(stashName string, id string, agent string, actor string) error {
+	return s.setAssignment(stashName, id, agent, actor)
+}
+
+// UnassignRecord clears a record's assignment.
+func (s *Store) UnassignRecord(stashName string, id string, actor string) error {
+	return s.setAssignment(stashName, id, "", actor)
+}
+
+// setAssignment records an assign operation, or an unassign operation when
+// agent is empty.
+func (s *Store) setAssignment(stashName string, id string, agent string, actor string) (err error) {
+	defer telemetry.Start("Store.AssignRecord", stashAttr(stashName), recordAttr(id)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
 	}
 
-	// Get current record (including deleted)
-	record, err := s.GetRecordIncludeDeleted(stashName, id)
+	record, err := s.GetRecord(stashName, id)
 	if err != nil {
 		return err
 	}
 
-	// Check if not deleted
-	if !record.IsDeleted() {
-		return fmt.Errorf("record is not deleted")
+	if agent == "" && record.AssignedTo == "" {
+		return model.ErrRecordNotAssigned
 	}
 
-	// Clear deletion metadata
-	record.DeletedAt = nil
-	record.DeletedBy = ""
+	record.AssignedTo = agent
 	record.UpdatedAt = time.Now()
 	record.UpdatedBy = actor
-	record.Operation = model.OpRestore
+	record.Operation = model.OpAssign
+	if agent == "" {
+		record.Operation = model.OpUnassign
+	}
+	stripComputedFields(stash, record)
+	if err := s.checkWriteRate(stash, actor); err != nil {
+		return err
+	}
 
 	// Append to JSONL
-	if err := s.jsonl.AppendRecord(stashName, record); err != nil {
+	if err := s.appendLog(stash, record); err != nil {
 		return err
 	}
 
 	// Update SQLite cache
-	columns := stash.Columns.Names()
-	if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
-		return err
-	}
+	columns := stash.Columns.StoredNames()
+	return s.sqlite.UpsertRecord(stashName, record, columns)
+}
 
-	return nil
+// stripComputedFields removes computed column values from a record before
+// it is written, since those values are derived at read time.
+func stripComputedFields(stash *model.Stash, record *model.Record) {
+	for _, col := range stash.Columns {
+		if col.IsComputed() {
+			delete(record.Fields, col.Name)
+		}
+	}
 }
 
 // GetRecord retrieves a record by ID.
-func (s *Store) GetRecord(stashName string, id string) (*model.Record, error) {
+func (s *Store) GetRecord(stashName string, id string) (_ *model.Record, err error) {
+	defer telemetry.Start("Store.GetRecord", stashAttr(stashName), recordAttr(id)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return nil, err
@@ -321,7 +1149,8 @@ func (s *Store) GetRecord(stashName string, id string) (*model.Record, error) {
 }
 
 // GetRecordIncludeDeleted retrieves a record including soft-deleted ones.
-func (s *Store) GetRecordIncludeDeleted(stashName string, id string) (*model.Record, error) {
+func (s *Store) GetRecordIncludeDeleted(stashName string, id string) (_ *model.Record, err error) {
+	defer telemetry.Start("Store.GetRecordIncludeDeleted", stashAttr(stashName), recordAttr(id)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return nil, err
@@ -332,16 +1161,61 @@ func (s *Store) GetRecordIncludeDeleted(stashName string, id string) (*model.Rec
 }
 
 // ListRecords lists records with filtering options.
-func (s *Store) ListRecords(stashName string, opts ListOptions) ([]*model.Record, error) {
+func (s *Store) ListRecords(stashName string, opts ListOptions) (_ []*model.Record, err error) {
+	defer telemetry.Start("Store.ListRecords", stashAttr(stashName)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return nil, err
 	}
 
 	columns := stash.Columns.Names()
+	if opts.ColumnTypes == nil {
+		opts.ColumnTypes = stash.Columns.ValueTypes()
+	}
+	if opts.Collation == "" {
+		opts.Collation = stash.Collation
+	}
 	return s.sqlite.ListRecords(stashName, columns, opts)
 }
 
+// IterateRecords calls fn for each record matching opts without loading the
+// whole result set into memory. Use it instead of ListRecords for exports and
+// other operations that may touch every record in a large stash.
+func (s *Store) IterateRecords(stashName string, opts ListOptions, fn func(*model.Record) error) (err error) {
+	defer telemetry.Start("Store.IterateRecords", stashAttr(stashName)).End(&err)
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return err
+	}
+
+	columns := stash.Columns.Names()
+	if opts.ColumnTypes == nil {
+		opts.ColumnTypes = stash.Columns.ValueTypes()
+	}
+	if opts.Collation == "" {
+		opts.Collation = stash.Collation
+	}
+	return s.sqlite.IterateRecords(stashName, columns, opts, fn)
+}
+
+// ExplainList returns the SQL ListRecords runs for opts and SQLite's plan
+// for it, without running it.
+func (s *Store) ExplainList(stashName string, opts ListOptions) (*QueryPlan, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := stash.Columns.Names()
+	if opts.ColumnTypes == nil {
+		opts.ColumnTypes = stash.Columns.ValueTypes()
+	}
+	if opts.Collation == "" {
+		opts.Collation = stash.Collation
+	}
+	return s.sqlite.ExplainList(stashName, columns, opts)
+}
+
 // GetChildren returns direct children of a parent record (excluding deleted).
 func (s *Store) GetChildren(stashName string, parentID string) ([]*model.Record, error) {
 	stash, err := s.GetStash(stashName)
@@ -367,13 +1241,59 @@ func (s *Store) GetChildrenIncludeDeleted(stashName string, parentID string) ([]
 	})
 }
 
+// NextRecordID generates a new root record ID using the stash's ID strategy.
+// Sequence numbers are allocated under the stash's write lock, so
+// concurrent writers get different IDs. A number is used up when it is
+// allocated, even if no record is then created with it, as by a dry run.
+func (s *Store) NextRecordID(stashName string) (string, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	switch stash.IDStrategy {
+	case model.IDStrategyULID:
+		return model.GenerateULID(stash.Prefix, now)
+	case model.IDStrategySequential, model.IDStrategyTemplate:
+		template := "{seq}"
+		if stash.IDStrategy == model.IDStrategyTemplate {
+			template = stash.IDTemplate
+		}
+		head, tail, hasSeq, err := model.ExpandIDTemplate(template, now)
+		if err != nil {
+			return "", err
+		}
+		head = stash.Prefix + head
+		if !hasSeq {
+			return head, nil
+		}
+		unlock, err := s.lockStash(stashName)
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+		seq, err := s.sqlite.NextRootSeq(stashName, head, tail)
+		if err != nil {
+			return "", err
+		}
+		return head + model.FormatSeq(seq) + tail, nil
+	default:
+		return model.GenerateID(stash.Prefix)
+	}
+}
+
 // GetNextChildSeq returns the next sequence number for a child record.
 func (s *Store) GetNextChildSeq(stashName string, parentID string) (int, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return 0, err
+	}
 	return s.sqlite.GetNextChildSeq(stashName, parentID)
 }
 
 // RebuildCache rebuilds the SQLite cache from JSONL files.
-func (s *Store) RebuildCache(stashName string) error {
+func (s *Store) RebuildCache(stashName string) (err error) {
+	defer telemetry.Start("Store.RebuildCache", stashAttr(stashName)).End(&err)
 	stash, err := s.config.ReadConfig(stashName)
 	if err != nil {
 		return err
@@ -394,12 +1314,28 @@ func (s *Store) RebuildCache(stashName string) error {
 	}
 
 	// Build current state by replaying operations
+	state := ReplayRecords(records)
+	logging.Debug("cache rebuild", "stash", stashName, "operations", len(records), "records", len(state))
+	telemetry.CacheRebuilt(stashName)
+
+	// Insert current state into SQLite in a single transaction
+	columns := stash.Columns.StoredNames()
+	current := make([]*model.Record, 0, len(state))
+	for _, record := range state {
+		current = append(current, record)
+	}
+	return s.sqlite.UpsertRecords(stashName, current, columns)
+}
+
+// ReplayRecords replays a JSONL log of operations and returns the current
+// state of each record by ID. Deleted records are kept, with DeletedAt set.
+func ReplayRecords(records []*model.Record) map[string]*model.Record {
 	state := make(map[string]*model.Record)
 	for _, record := range records {
-		switch record.Operation {
-		case model.OpCreate, model.OpUpdate, model.OpRestore:
+		switch {
+		case model.IsStateOp(record.Operation):
 			state[record.ID] = record
-		case model.OpDelete:
+		case record.Operation == model.OpDelete:
 			if existing, ok := state[record.ID]; ok {
 				existing.DeletedAt = record.DeletedAt
 				existing.DeletedBy = record.DeletedBy
@@ -408,60 +1344,123 @@ func (s *Store) RebuildCache(stashName string) error {
 			}
 		}
 	}
+	return state
+}
 
-	// Insert current state into SQLite
-	columns := stash.Columns.Names()
-	for _, record := range state {
-		if err := s.sqlite.UpsertRecord(stashName, record, columns); err != nil {
-			return err
+// CacheSchemaVersion returns the cache layout version of a stash table.
+func (s *Store) CacheSchemaVersion(stashName string) (int, error) {
+	return s.sqlite.SchemaVersion(stashName)
+}
+
+// PendingCacheMigrations returns the cache migrations not yet applied to
+// a stash, oldest first.
+func (s *Store) PendingCacheMigrations(stashName string) ([]CacheMigration, error) {
+	return s.sqlite.PendingMigrations(stashName)
+}
+
+// CacheMigrationError returns the error that stopped a stash's cache
+// migrations when the store was opened, or nil.
+func (s *Store) CacheMigrationError(stashName string) error {
+	return s.sqlite.MigrationError(stashName)
+}
+
+// MissingCacheColumns returns the columns of a stash's config that its
+// cache table lacks, in config order.
+func (s *Store) MissingCacheColumns(stashName string) ([]string, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, col := range stash.Columns {
+		exists, err := s.sqlite.columnExists(sanitizeTableName(stashName), col.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, col.Name)
 		}
 	}
+	return missing, nil
+}
 
-	return nil
+// MigrateCache applies pending cache migrations to a stash.
+func (s *Store) MigrateCache(stashName string) error {
+	return s.sqlite.Migrate(stashName)
+}
+
+// ResetCache drops a stash's cache table and rebuilds it from JSONL at the
+// latest layout, for caches left by a newer version of stash.
+func (s *Store) ResetCache(stashName string) error {
+	if err := s.sqlite.DropStashTable(stashName); err != nil {
+		return err
+	}
+	return s.RebuildCache(stashName)
 }
 
 // FlushToJSONL writes the current SQLite state to a new JSONL file.
 // This compacts the log by removing historical operations.
-func (s *Store) FlushToJSONL(stashName string) error {
+func (s *Store) FlushToJSONL(stashName string) (err error) {
+	defer telemetry.Start("Store.FlushToJSONL", stashAttr(stashName)).End(&err)
 	stash, err := s.GetStash(stashName)
 	if err != nil {
 		return err
 	}
 
-	// Get all records from SQLite (including deleted)
-	columns := stash.Columns.Names()
-	records, err := s.sqlite.ListRecords(stashName, columns, ListOptions{
+	// Stream all records from SQLite (including deleted) straight into the
+	// new log, so compaction uses constant memory regardless of stash size.
+	// Computed columns are derived at read time and never written to JSONL.
+	columns := stash.Columns.StoredNames()
+	opts := ListOptions{
 		IncludeDeleted: true,
 		ParentID:       "*", // All records
-	})
-	if err != nil {
-		return err
-	}
-
-	// Set operation to create for compacted log
-	for _, record := range records {
-		if record.IsDeleted() {
-			record.Operation = model.OpDelete
-		} else {
-			record.Operation = model.OpCreate
-		}
 	}
 
 	// Write all records atomically
-	if err := s.jsonl.WriteAllRecords(stashName, records); err != nil {
-		return err
-	}
-
-	return nil
+	return s.writeLog(stash, "compact", func(write func(*model.Record) error) error {
+		return s.sqlite.IterateRecords(stashName, columns, opts, func(record *model.Record) error {
+			// Set operation to create for compacted log
+			if record.IsDeleted() {
+				record.Operation = model.OpDelete
+			} else {
+				record.Operation = model.OpCreate
+			}
+			return write(record)
+		})
+	})
 }
 
 // CountRecords returns the number of records in a stash (excluding deleted).
 func (s *Store) CountRecords(stashName string) (int, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return 0, err
+	}
 	return s.sqlite.CountRecords(stashName)
 }
 
+// DistinctValues returns each distinct value of a column among the records
+// opts matches, with the number of records holding it, most common first.
+func (s *Store) DistinctValues(stashName, column string, opts ListOptions) ([]ValueCount, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ColumnTypes == nil {
+		opts.ColumnTypes = stash.Columns.ValueTypes()
+	}
+	if opts.Collation == "" {
+		opts.Collation = stash.Collation
+	}
+	return s.sqlite.DistinctValues(stashName, column, stash.Columns.Names(), opts)
+}
+
 // PurgeRecord permanently removes a soft-deleted record from both SQLite and JSONL.
-func (s *Store) PurgeRecord(stashName string, id string) error {
+func (s *Store) PurgeRecord(stashName string, id string) (err error) {
+	defer telemetry.Start("Store.PurgeRecord", stashAttr(stashName), recordAttr(id)).End(&err)
+	if err := s.inTx(); err != nil {
+		return err
+	}
 	// Get record (must be deleted)
 	record, err := s.GetRecordIncludeDeleted(stashName, id)
 	if err != nil {
@@ -472,6 +1471,15 @@ func (s *Store) PurgeRecord(stashName string, id string) error {
 		return fmt.Errorf("record '%s' is not deleted; cannot purge active records", id)
 	}
 
+	// Purging a parent would leave its live children orphaned
+	children, err := s.GetChildren(stashName, id)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return fmt.Errorf("%w: record '%s' has %d live child record(s)", model.ErrHasChildren, id, len(children))
+	}
+
 	// Delete from SQLite cache
 	if err := s.sqlite.DeleteRecord(stashName, id); err != nil {
 		return err
@@ -491,6 +1499,36 @@ func (s *Store) PurgeRecord(stashName string, id string) error {
 	return nil
 }
 
+// FindOrphans returns the live records whose parent no longer exists, such
+// as the children of a purged record.
+func (s *Store) FindOrphans(stashName string) ([]*model.Record, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.sqlite.ListRecords(stashName, stash.Columns.Names(), ListOptions{
+		ParentID:       "*",
+		IncludeDeleted: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(records))
+	for _, rec := range records {
+		exists[rec.ID] = true
+	}
+
+	var orphans []*model.Record
+	for _, rec := range records {
+		if !rec.IsDeleted() && rec.ParentID != "" && !exists[rec.ParentID] {
+			orphans = append(orphans, rec)
+		}
+	}
+	return orphans, nil
+}
+
 // ListDeletedRecords returns all soft-deleted records, optionally filtered by deletion time.
 func (s *Store) ListDeletedRecords(stashName string, before *time.Time) ([]*model.Record, error) {
 	stash, err := s.GetStash(stashName)
@@ -523,8 +1561,56 @@ func (s *Store) ListDeletedRecords(stashName string, before *time.Time) ([]*mode
 	return deleted, nil
 }
 
+// ListExpiredRecords returns deleted records older than the stash's retention
+// period. Returns nil if the stash has no retention policy.
+func (s *Store) ListExpiredRecords(stashName string, now time.Time) ([]*model.Record, error) {
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		return nil, err
+	}
+
+	retention, ok := stash.RetentionPeriod()
+	if !ok {
+		return nil, nil
+	}
+
+	cutoff := now.Add(-retention)
+	return s.ListDeletedRecords(stashName, &cutoff)
+}
+
+// PurgeExpired permanently removes deleted records older than the stash's
+// retention period. Returns the IDs of purged records.
+func (s *Store) PurgeExpired(stashName string, now time.Time) ([]string, error) {
+	expired, err := s.ListExpiredRecords(stashName, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	for _, rec := range expired {
+		if err := s.PurgeRecord(stashName, rec.ID); err != nil {
+			if errors.Is(err, model.ErrHasChildren) {
+				// Kept until its children are deleted or re-parented
+				continue
+			}
+			return purged, fmt.Errorf("failed to purge %s: %w", rec.ID, err)
+		}
+		purged = append(purged, rec.ID)
+	}
+	return purged, nil
+}
+
 // UpdateStashConfig updates the stash configuration in both config file and SQLite.
 func (s *Store) UpdateStashConfig(stash *model.Stash) error {
+	if err := s.inTx(); err != nil {
+		return err
+	}
+	// Read before writing, to log what changed
+	old, err := s.GetStash(stash.Name)
+	if err != nil {
+		return err
+	}
+
 	// Update config file
 	if err := s.config.WriteConfig(stash); err != nil {
 		return err
@@ -535,7 +1621,58 @@ func (s *Store) UpdateStashConfig(stash *model.Stash) error {
 		return err
 	}
 
-	return nil
+	return s.logSchema(old, stash)
+}
+
+// StashStats summarizes a stash from its cache.
+type StashStats struct {
+	Records      int
+	Deleted      int
+	LastModified time.Time // zero if the stash has no records
+}
+
+// StashStats counts a stash's active and deleted records and finds when
+// it last changed, from the cache alone.
+func (s *Store) StashStats(stashName string) (*StashStats, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
+	records, deleted, lastModified, err := s.sqlite.StashStats(stashName)
+	if err != nil {
+		return nil, err
+	}
+	return &StashStats{Records: records, Deleted: deleted, LastModified: lastModified}, nil
+}
+
+// CacheInSync reports whether the cache has caught up with the last
+// operation in a stash's log. It reads only the end of the log, so it is
+// cheap, but it does not detect every difference; 'stash doctor' compares
+// the whole log.
+func (s *Store) CacheInSync(stashName string) (bool, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return false, err
+	}
+	last, err := s.jsonl.LastRecord(stashName)
+	if err != nil {
+		return false, err
+	}
+	if last == nil {
+		records, deleted, _, err := s.sqlite.StashStats(stashName)
+		if err != nil {
+			return false, err
+		}
+		return records+deleted == 0, nil
+	}
+
+	cached, err := s.sqlite.GetRecord(stashName, last.ID, nil)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// The cache keeps timestamps to the second
+	return cached.UpdatedAt.Unix() == last.UpdatedAt.Unix() && cached.IsDeleted() == last.IsDeleted(), nil
 }
 
 // GetLastSyncTime returns the last sync time from metadata.
@@ -560,6 +1697,10 @@ func (s *Store) GetFilesDir(stashName, recordID string) string {
 // AttachFile attaches a file to a record.
 // If move is true, the source file is moved; otherwise it's copied.
 func (s *Store) AttachFile(stashName, recordID, srcPath string, move bool, actor string) (*model.Attachment, error) {
+	if s.IsMemory() {
+		return nil, ErrInMemory
+	}
+
 	// Verify record exists
 	_, err := s.GetRecord(stashName, recordID)
 	if err != nil {
@@ -593,6 +1734,11 @@ func (s *Store) AttachFile(stashName, recordID, srcPath string, move bool, actor
 		return nil, model.ErrAttachmentExists
 	}
 
+	// Enforce the stash's attachment policy
+	if err := s.checkAttachmentPolicy(stashName, recordID, srcInfo.Name(), srcPath, srcInfo.Size()); err != nil {
+		return nil, err
+	}
+
 	// Copy or move the file
 	if move {
 		if err := os.Rename(srcPath, destPath); err != nil {
@@ -622,6 +1768,98 @@ func (s *Store) AttachFile(stashName, recordID, srcPath string, move bool, actor
 	return attachment, nil
 }
 
+// AttachReader attaches the content read from r to a record under the
+// given name. The content is written to a temporary file first, so a read
+// that fails part way leaves no attachment behind.
+func (s *Store) AttachReader(stashName, recordID, name string, r io.Reader, actor string) (*model.Attachment, error) {
+	if s.IsMemory() {
+		return nil, ErrInMemory
+	}
+	if err := model.ValidateAttachmentName(name); err != nil {
+		return nil, err
+	}
+
+	// Verify record exists
+	if _, err := s.GetRecord(stashName, recordID); err != nil {
+		return nil, err
+	}
+
+	filesDir := s.GetFilesDir(stashName, recordID)
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create files directory: %w", err)
+	}
+	destPath := filepath.Join(filesDir, name)
+	if _, err := os.Stat(destPath); err == nil {
+		return nil, model.ErrAttachmentExists
+	}
+
+	tmp, err := os.CreateTemp(filesDir, ".attach-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// Stop reading past the size limit rather than buffer a huge input
+	stash, err := s.GetStash(stashName)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if policy := stash.AttachPolicy; policy != nil && policy.MaxSize > 0 {
+		r = io.LimitReader(r, policy.MaxSize+1)
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write attachment: %w", err)
+	}
+
+	// Enforce the stash's attachment policy
+	if err := s.checkAttachmentPolicy(stashName, recordID, name, tmp.Name(), size); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), destPath); err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	return &model.Attachment{
+		Name:       name,
+		Size:       size,
+		Hash:       hex.EncodeToString(h.Sum(nil)),
+		AttachedAt: time.Now(),
+		AttachedBy: actor,
+	}, nil
+}
+
+// OpenAttachment opens an attachment of a record for reading. The caller
+// must close it.
+func (s *Store) OpenAttachment(stashName, recordID, filename string) (*os.File, error) {
+	if err := model.ValidateAttachmentName(filename); err != nil {
+		return nil, err
+	}
+
+	// Verify record exists
+	if _, err := s.GetRecord(stashName, recordID); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(s.GetFilesDir(stashName, recordID), filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, model.ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return f, nil
+}
+
 // DetachFile removes an attachment from a record.
 func (s *Store) DetachFile(stashName, recordID, filename string) error {
 	// Verify record exists
@@ -670,7 +1908,8 @@ func (s *Store) ListAttachments(stashName, recordID string) ([]*model.Attachment
 
 	attachments := make([]*model.Attachment, 0, len(entries))
 	for _, entry := range entries {
-		if entry.IsDir() {
+		// Skip the temporary files of attachments being written
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".attach-") {
 			continue
 		}
 
@@ -725,11 +1964,26 @@ func (s *Store) GetAttachment(stashName, recordID, filename string) (*model.Atta
 // RawQuery executes a raw SQL SELECT query against the cache.
 // Returns rows as a slice of maps and the column names in order.
 func (s *Store) RawQuery(query string) ([]map[string]interface{}, []string, error) {
+	if err := s.loadAllTxStashes(); err != nil {
+		return nil, nil, err
+	}
 	return s.sqlite.RawQuery(query)
 }
 
+// ExplainQuery returns SQLite's plan for a raw SELECT query, without
+// running it.
+func (s *Store) ExplainQuery(query string) (*QueryPlan, error) {
+	if err := s.loadAllTxStashes(); err != nil {
+		return nil, err
+	}
+	return s.sqlite.ExplainQuery(query)
+}
+
 // GetRecordHistory retrieves all historical changes for a record from JSONL.
 func (s *Store) GetRecordHistory(stashName string, recordID string) ([]*model.Record, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
 	// Read all records from JSONL
 	records, err := s.jsonl.ReadAllRecords(stashName)
 	if err != nil {
@@ -749,6 +2003,9 @@ func (s *Store) GetRecordHistory(stashName string, recordID string) ([]*model.Re
 
 // GetAllHistory retrieves all historical changes from JSONL.
 func (s *Store) GetAllHistory(stashName string) ([]*model.Record, error) {
+	if err := s.loadTxStash(stashName); err != nil {
+		return nil, err
+	}
 	return s.jsonl.ReadAllRecords(stashName)
 }
 
@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -174,6 +178,30 @@ func TestStore_AddColumn(t *testing.T) {
 	})
 }
 
+func TestStore_MissingCacheColumns(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:    "test-stash",
+		Prefix:  "ts-",
+		Created: time.Now(),
+		Columns: model.ColumnList{{Name: "Name", Added: time.Now()}, {Name: "Price", Added: time.Now()}},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	missing, err := store.MissingCacheColumns("test-stash")
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	_, err = store.sqlite.db.Exec(`ALTER TABLE "test_stash" DROP COLUMN "Price"`)
+	require.NoError(t, err)
+	missing, err = store.MissingCacheColumns("test-stash")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Price"}, missing)
+}
+
 func TestStore_RecordCRUD(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
 	require.NoError(t, err)
@@ -230,6 +258,7 @@ func TestStore_RecordCRUD(t *testing.T) {
 	})
 
 	t.Run("update record", func(t *testing.T) {
+		createHash := record.Hash
 		record.UpdatedAt = time.Now()
 		record.UpdatedBy = "updater"
 		record.Fields["name"] = "Updated Item"
@@ -237,6 +266,7 @@ func TestStore_RecordCRUD(t *testing.T) {
 
 		err := store.UpdateRecord("test-stash", record)
 		require.NoError(t, err)
+		assert.Equal(t, createHash, record.BaseHash, "update should note the state it was made from")
 
 		retrieved, err := store.GetRecord("test-stash", "ts-abc1")
 		require.NoError(t, err)
@@ -413,6 +443,162 @@ func TestStore_GetNextChildSeq(t *testing.T) {
 	})
 }
 
+func TestStore_NextRecordID(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:       "orders",
+		Prefix:     "ord-",
+		Created:    time.Now(),
+		CreatedBy:  "user",
+		IDStrategy: model.IDStrategySequential,
+	}
+	require.NoError(t, store.CreateStash("orders", "ord-", stash))
+
+	create := func(store *Store, id string) error {
+		now := time.Now()
+		return store.CreateRecord("orders", &model.Record{
+			ID: id, CreatedAt: now, CreatedBy: "user", UpdatedAt: now, UpdatedBy: "user",
+			Fields: map[string]interface{}{},
+		})
+	}
+
+	t.Run("concurrent writers get different IDs", func(t *testing.T) {
+		const writers = 10
+		ids := make(chan string, writers)
+		errs := make(chan error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Each writer opens its own store, as separate processes do
+				s, err := NewStore(tmpDir)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer s.Close()
+				id, err := s.NextRecordID("orders")
+				if err == nil {
+					err = create(s, id)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+				ids <- id
+			}()
+		}
+		wg.Wait()
+		close(ids)
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		seen := map[string]bool{}
+		for id := range ids {
+			assert.False(t, seen[id], "duplicate ID %s", id)
+			seen[id] = true
+		}
+		assert.Len(t, seen, writers)
+		count, err := store.CountRecords("orders")
+		require.NoError(t, err)
+		assert.Equal(t, writers, count)
+	})
+
+	t.Run("create fails when the ID is taken", func(t *testing.T) {
+		err := create(store, "ord-0001")
+		assert.ErrorIs(t, err, model.ErrRecordExists)
+	})
+
+	t.Run("skips IDs taken outside the counter", func(t *testing.T) {
+		require.NoError(t, create(store, "ord-0011"))
+		id, err := store.NextRecordID("orders")
+		require.NoError(t, err)
+		assert.Equal(t, "ord-0012", id)
+	})
+
+	t.Run("continues from the records when the counter is lost", func(t *testing.T) {
+		require.NoError(t, store.ResetCache("orders"))
+		id, err := store.NextRecordID("orders")
+		require.NoError(t, err)
+		assert.Equal(t, "ord-0012", id)
+	})
+}
+
+func TestStore_HashChain(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "audit",
+		Prefix:    "aud-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		HashChain: true,
+	}
+	require.NoError(t, store.CreateStash("audit", "aud-", stash))
+
+	t.Run("concurrent writers extend one chain", func(t *testing.T) {
+		const writers = 10
+		errs := make(chan error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				s, err := NewStore(tmpDir)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer s.Close()
+				now := time.Now()
+				errs <- s.CreateRecord("audit", &model.Record{
+					ID: fmt.Sprintf("aud-%04d", i), CreatedAt: now, CreatedBy: "user", UpdatedAt: now, UpdatedBy: "user",
+					Fields: map[string]interface{}{},
+				})
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		report, err := store.VerifyChain("audit")
+		require.NoError(t, err)
+		assert.Equal(t, writers, report.Lines)
+		assert.Empty(t, report.Breaks)
+	})
+
+	t.Run("compaction continues the chain and is reported", func(t *testing.T) {
+		head, err := store.jsonl.chainHead("audit")
+		require.NoError(t, err)
+		require.NoError(t, store.FlushToJSONL("audit"))
+
+		report, err := store.VerifyChain("audit")
+		require.NoError(t, err)
+		assert.Empty(t, report.Breaks)
+		require.Len(t, report.Rechains, 1)
+		assert.Equal(t, "compact", report.Rechains[0].Reason)
+		assert.Equal(t, head, report.Rechains[0].PrevHead)
+	})
+}
+
 func TestStore_RebuildCache(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
 	require.NoError(t, err)
@@ -534,3 +720,766 @@ func TestStore_FlushToJSONL(t *testing.T) {
 	assert.Len(t, jsonlRecords, 1)
 	assert.Equal(t, "Updated", jsonlRecords[0].Fields["name"])
 }
+
+func TestStore_ComputedColumn(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "price", Added: time.Now(), AddedBy: "user"},
+			{Name: "quantity", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+
+	err = store.CreateStash("test-stash", "ts-", stash)
+	require.NoError(t, err)
+
+	err = store.AddColumn("test-stash", model.Column{
+		Name:     "total",
+		Added:    time.Now(),
+		AddedBy:  "user",
+		Computed: "price * quantity",
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	record := &model.Record{
+		ID:        "ts-abc1",
+		CreatedAt: now,
+		CreatedBy: "user",
+		UpdatedAt: now,
+		UpdatedBy: "user",
+		Fields:    map[string]interface{}{"price": "2.5", "quantity": "4", "total": "999"},
+	}
+	err = store.CreateRecord("test-stash", record)
+	require.NoError(t, err)
+
+	t.Run("computed value evaluated at read time", func(t *testing.T) {
+		got, err := store.GetRecord("test-stash", "ts-abc1")
+		require.NoError(t, err)
+		assert.Equal(t, float64(10), got.Fields["total"])
+	})
+
+	t.Run("computed value not written to JSONL", func(t *testing.T) {
+		history, err := store.GetRecordHistory("test-stash", "ts-abc1")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.NotContains(t, history[0].Fields, "total")
+	})
+
+	t.Run("computed value usable in filters and ordering", func(t *testing.T) {
+		records, err := store.ListRecords("test-stash", ListOptions{
+			ParentID: "*",
+			Where:    []WhereCondition{{Field: "total", Operator: ">", Value: "5"}},
+			OrderBy:  []OrderKey{{Field: "total"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+	})
+
+	t.Run("computed value survives cache rebuild", func(t *testing.T) {
+		err := store.RebuildCache("test-stash")
+		require.NoError(t, err)
+
+		got, err := store.GetRecord("test-stash", "ts-abc1")
+		require.NoError(t, err)
+		assert.Equal(t, float64(10), got.Fields["total"])
+	})
+
+	t.Run("invalid expression is rejected", func(t *testing.T) {
+		err := store.AddColumn("test-stash", model.Column{
+			Name:     "broken",
+			Added:    time.Now(),
+			AddedBy:  "user",
+			Computed: "missing_column + 1",
+		})
+		assert.Error(t, err)
+
+		retrieved, err := store.GetStash("test-stash")
+		require.NoError(t, err)
+		assert.False(t, retrieved.Columns.Exists("broken"))
+	})
+}
+
+func TestStore_ArchiveRecord(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+
+	err = store.CreateStash("test-stash", "ts-", stash)
+	require.NoError(t, err)
+
+	now := time.Now()
+	record := &model.Record{
+		ID:        "ts-abc1",
+		CreatedAt: now,
+		CreatedBy: "user",
+		UpdatedAt: now,
+		UpdatedBy: "user",
+		Fields:    map[string]interface{}{"name": "Widget"},
+	}
+	err = store.CreateRecord("test-stash", record)
+	require.NoError(t, err)
+
+	t.Run("archive hides record from active listing", func(t *testing.T) {
+		err := store.ArchiveRecord("test-stash", "ts-abc1", "archiver")
+		require.NoError(t, err)
+
+		active, err := store.ListRecords("test-stash", ListOptions{ParentID: "*", ExcludeArchived: true})
+		require.NoError(t, err)
+		assert.Len(t, active, 0)
+
+		archived, err := store.ListRecords("test-stash", ListOptions{ParentID: "*", ArchivedOnly: true})
+		require.NoError(t, err)
+		require.Len(t, archived, 1)
+		assert.Equal(t, "archiver", archived[0].ArchivedBy)
+		assert.True(t, archived[0].IsArchived())
+	})
+
+	t.Run("archiving twice fails", func(t *testing.T) {
+		err := store.ArchiveRecord("test-stash", "ts-abc1", "archiver")
+		assert.ErrorIs(t, err, model.ErrRecordArchived)
+	})
+
+	t.Run("archive state survives cache rebuild", func(t *testing.T) {
+		err := store.RebuildCache("test-stash")
+		require.NoError(t, err)
+
+		got, err := store.GetRecord("test-stash", "ts-abc1")
+		require.NoError(t, err)
+		assert.True(t, got.IsArchived())
+	})
+
+	t.Run("unarchive restores record", func(t *testing.T) {
+		err := store.UnarchiveRecord("test-stash", "ts-abc1", "user")
+		require.NoError(t, err)
+
+		got, err := store.GetRecord("test-stash", "ts-abc1")
+		require.NoError(t, err)
+		assert.False(t, got.IsArchived())
+
+		err = store.UnarchiveRecord("test-stash", "ts-abc1", "user")
+		assert.ErrorIs(t, err, model.ErrRecordNotArchived)
+	})
+}
+
+func TestStore_AssignRecord(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "user"}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+	require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+		ID:        "ts-aaa1",
+		CreatedAt: time.Now(),
+		CreatedBy: "user",
+		UpdatedAt: time.Now(),
+		UpdatedBy: "user",
+		Fields:    map[string]interface{}{},
+	}))
+
+	assert.ErrorIs(t, store.UnassignRecord("test-stash", "ts-aaa1", "user"), model.ErrRecordNotAssigned)
+
+	require.NoError(t, store.AssignRecord("test-stash", "ts-aaa1", "alice", "user"))
+	record, err := store.GetRecord("test-stash", "ts-aaa1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", record.AssignedTo)
+
+	assigned, err := store.ListRecords("test-stash", ListOptions{ParentID: "*", AssignedTo: "alice"})
+	require.NoError(t, err)
+	assert.Len(t, assigned, 1)
+
+	// The assignment is rebuilt from the log
+	require.NoError(t, store.RebuildCache("test-stash"))
+	record, err = store.GetRecord("test-stash", "ts-aaa1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", record.AssignedTo)
+
+	require.NoError(t, store.UnassignRecord("test-stash", "ts-aaa1", "user"))
+	assigned, err = store.ListRecords("test-stash", ListOptions{ParentID: "*", AssignedTo: "alice"})
+	require.NoError(t, err)
+	assert.Empty(t, assigned)
+
+	records, err := store.jsonl.ReadAllRecords("test-stash")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, model.OpAssign, records[1].Operation)
+	assert.Equal(t, model.OpUnassign, records[2].Operation)
+	assert.Empty(t, records[2].AssignedTo)
+}
+
+func TestStore_ReplayRecord(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	created := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	record := &model.Record{
+		ID:        "ts-abc1",
+		Hash:      "stale",
+		CreatedAt: created,
+		CreatedBy: "alice",
+		UpdatedAt: deleted,
+		UpdatedBy: "bob",
+		DeletedAt: &deleted,
+		DeletedBy: "bob",
+		Operation: model.OpDelete,
+		PrevHash:  "from-another-chain",
+		Fields:    map[string]interface{}{"name": "Widget"},
+	}
+	require.NoError(t, store.ReplayRecord("test-stash", record))
+
+	got, err := store.GetRecordIncludeDeleted("test-stash", "ts-abc1")
+	require.NoError(t, err)
+	assert.True(t, got.IsDeleted())
+	assert.Equal(t, "alice", got.CreatedBy)
+	assert.Equal(t, "bob", got.DeletedBy)
+	assert.True(t, created.Equal(got.CreatedAt))
+	assert.Equal(t, model.CalculateHash(record.Fields), got.Hash)
+
+	history, err := store.GetRecordHistory("test-stash", "ts-abc1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, model.OpDelete, history[0].Operation)
+	assert.Empty(t, history[0].PrevHash)
+}
+
+func TestStore_ChangePrefix(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	now := time.Now()
+	for _, r := range []struct{ id, parent string }{{"ts-abc1", ""}, {"ts-abc1.1", "ts-abc1"}, {"nw-abc2", ""}} {
+		err := store.CreateRecord("test-stash", &model.Record{
+			ID:        r.id,
+			ParentID:  r.parent,
+			CreatedAt: now,
+			CreatedBy: "user",
+			UpdatedAt: now,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{"name": r.id},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("rejects invalid prefix", func(t *testing.T) {
+		_, err := store.ChangePrefix("test-stash", "BAD", true)
+		assert.ErrorIs(t, err, model.ErrInvalidPrefix)
+	})
+
+	t.Run("migrates IDs and parent references", func(t *testing.T) {
+		change, err := store.ChangePrefix("test-stash", "mv-", true)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"ts-abc1": "mv-abc1", "ts-abc1.1": "mv-abc1.1"}, change.Mapping)
+		assert.Empty(t, change.Unsigned)
+
+		child, err := store.GetRecord("test-stash", "mv-abc1.1")
+		require.NoError(t, err)
+		assert.Equal(t, "mv-abc1", child.ParentID)
+
+		// Records with a different prefix are left alone
+		_, err = store.GetRecord("test-stash", "nw-abc2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("refuses to overwrite existing IDs", func(t *testing.T) {
+		err := store.CreateRecord("test-stash", &model.Record{
+			ID:        "mv-abc2",
+			CreatedAt: now,
+			CreatedBy: "user",
+			UpdatedAt: now,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{"name": "dup"},
+		})
+		require.NoError(t, err)
+
+		// Going back to nw- would turn mv-abc2 into the existing nw-abc2
+		_, err = store.ChangePrefix("test-stash", "nw-", true)
+		assert.ErrorIs(t, err, model.ErrRecordExists)
+
+		got, err := store.GetStash("test-stash")
+		require.NoError(t, err)
+		assert.Equal(t, "mv-", got.Prefix)
+	})
+}
+
+func TestStore_ChangePrefixSignatures(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, aliceKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, bobKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	keys := map[string]ed25519.PrivateKey{"alice": aliceKey, "bob": bobKey}
+	store.SetSigner(func(actor string) (ed25519.PrivateKey, error) { return keys[actor], nil })
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "alice"}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+	now := time.Now()
+	for id, actor := range map[string]string{"ts-abc1": "alice", "ts-abc2": "bob", "ts-abc3": "carol"} {
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID: id, CreatedAt: now, CreatedBy: actor, UpdatedAt: now, UpdatedBy: actor,
+			Fields: map[string]interface{}{},
+		}))
+	}
+
+	t.Run("cannot be changed in a transaction", func(t *testing.T) {
+		require.NoError(t, store.BeginTx("tx-a1b2"))
+		defer store.RollbackTx("tx-a1b2")
+		view, err := store.OpenTx("tx-a1b2")
+		require.NoError(t, err)
+		defer view.Close()
+		_, err = view.ChangePrefix("test-stash", "mv-", true)
+		assert.ErrorIs(t, err, ErrInTransaction)
+	})
+
+	t.Run("migrated operations are signed again where the key is here", func(t *testing.T) {
+		// Bob's key is not on this machine
+		delete(keys, "bob")
+		change, err := store.ChangePrefix("test-stash", "mv-", true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mv-abc1"}, change.Resigned)
+		assert.Equal(t, []string{"mv-abc2"}, change.Unsigned)
+
+		history, err := store.GetRecordHistory("test-stash", "mv-abc1")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.True(t, model.VerifyRecordSignature(history[0], aliceKey.Public().(ed25519.PublicKey)))
+		history, err = store.GetRecordHistory("test-stash", "mv-abc2")
+		require.NoError(t, err)
+		assert.Empty(t, history[0].Signature)
+	})
+}
+
+func TestStore_PurgeExpired(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	now := time.Now()
+	for _, id := range []string{"ts-abc1", "ts-abc2"} {
+		record := &model.Record{
+			ID:        id,
+			CreatedAt: now,
+			CreatedBy: "user",
+			UpdatedAt: now,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{"name": id},
+		}
+		require.NoError(t, store.CreateRecord("test-stash", record))
+	}
+	require.NoError(t, store.DeleteRecord("test-stash", "ts-abc1", "user"))
+
+	t.Run("no policy purges nothing", func(t *testing.T) {
+		purged, err := store.PurgeExpired("test-stash", now.Add(365*24*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+	})
+
+	stash.Retention = "1d"
+	require.NoError(t, store.UpdateStashConfig(stash))
+
+	t.Run("records within retention are kept", func(t *testing.T) {
+		purged, err := store.PurgeExpired("test-stash", now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+	})
+
+	t.Run("expired deleted records are purged", func(t *testing.T) {
+		purged, err := store.PurgeExpired("test-stash", now.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ts-abc1"}, purged)
+
+		_, err = store.GetRecord("test-stash", "ts-abc1")
+		assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+		active, err := store.GetRecord("test-stash", "ts-abc2")
+		require.NoError(t, err)
+		assert.Equal(t, "ts-abc2", active.ID)
+	})
+}
+
+func TestStore_PurgeKeepsParentsOfLiveChildren(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "user"}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	now := time.Now()
+	for _, id := range []string{"ts-abc1", "ts-abc1.1", "ts-gone.1"} {
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID: id, ParentID: model.GetParentID(id), CreatedAt: now, CreatedBy: "user",
+			UpdatedAt: now, UpdatedBy: "user", Fields: map[string]interface{}{},
+		}))
+	}
+	require.NoError(t, store.DeleteRecord("test-stash", "ts-abc1", "user"))
+
+	t.Run("purge refuses a parent with live children", func(t *testing.T) {
+		err := store.PurgeRecord("test-stash", "ts-abc1")
+		assert.ErrorIs(t, err, model.ErrHasChildren)
+
+		stash.Retention = "1d"
+		require.NoError(t, store.UpdateStashConfig(stash))
+		purged, err := store.PurgeExpired("test-stash", now.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+	})
+
+	t.Run("orphans are records whose parent no longer exists", func(t *testing.T) {
+		orphans, err := store.FindOrphans("test-stash")
+		require.NoError(t, err)
+		require.Len(t, orphans, 1)
+		assert.Equal(t, "ts-gone.1", orphans[0].ID)
+	})
+}
+
+func TestStore_IterateRecords(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{
+		Name:      "test-stash",
+		Prefix:    "ts-",
+		Created:   time.Now(),
+		CreatedBy: "user",
+		Columns: model.ColumnList{
+			{Name: "name", Added: time.Now(), AddedBy: "user"},
+		},
+	}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	now := time.Now()
+	for _, id := range []string{"ts-abc1", "ts-abc2", "ts-abc3"} {
+		record := &model.Record{
+			ID:        id,
+			CreatedAt: now,
+			CreatedBy: "user",
+			UpdatedAt: now,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{"name": id},
+		}
+		require.NoError(t, store.CreateRecord("test-stash", record))
+	}
+
+	t.Run("visits every matching record", func(t *testing.T) {
+		var ids []string
+		err := store.IterateRecords("test-stash", ListOptions{ParentID: "*", OrderBy: []OrderKey{{Field: "id"}}}, func(rec *model.Record) error {
+			ids = append(ids, rec.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ts-abc1", "ts-abc2", "ts-abc3"}, ids)
+	})
+
+	t.Run("stops at the first callback error", func(t *testing.T) {
+		visited := 0
+		err := store.IterateRecords("test-stash", ListOptions{ParentID: "*"}, func(rec *model.Record) error {
+			visited++
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, visited)
+	})
+}
+
+func TestStore_ListRecordsUpdatedSince(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "user"}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	old := time.Now().Add(-48 * time.Hour)
+	for id, updated := range map[string]time.Time{"ts-old1": old, "ts-new1": time.Now()} {
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID:        id,
+			CreatedAt: old,
+			CreatedBy: "user",
+			UpdatedAt: updated,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{},
+		}))
+	}
+
+	records, err := store.ListRecords("test-stash", ListOptions{ParentID: "*", UpdatedSince: time.Now().Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ts-new1", records[0].ID)
+
+	all, err := store.ListRecords("test-stash", ListOptions{ParentID: "*"})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestStore_StashStatsAndCacheInSync(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stash-store-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	stash := &model.Stash{Name: "test-stash", Prefix: "ts-", Created: time.Now(), CreatedBy: "user"}
+	require.NoError(t, store.CreateStash("test-stash", "ts-", stash))
+
+	stats, err := store.StashStats("test-stash")
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Records)
+	assert.True(t, stats.LastModified.IsZero())
+	inSync, err := store.CacheInSync("test-stash")
+	require.NoError(t, err)
+	assert.True(t, inSync, "an empty stash should be in sync")
+
+	updated := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, id := range []string{"ts-aaa1", "ts-bbb2"} {
+		require.NoError(t, store.CreateRecord("test-stash", &model.Record{
+			ID:        id,
+			CreatedAt: updated,
+			CreatedBy: "user",
+			UpdatedAt: updated,
+			UpdatedBy: "user",
+			Fields:    map[string]interface{}{},
+		}))
+	}
+	require.NoError(t, store.DeleteRecord("test-stash", "ts-bbb2", "user"))
+
+	stats, err = store.StashStats("test-stash")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Records)
+	assert.Equal(t, 1, stats.Deleted)
+	assert.True(t, stats.LastModified.After(updated), "the delete should be the last change")
+
+	last, err := store.jsonl.LastRecord("test-stash")
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, "ts-bbb2", last.ID)
+	assert.Equal(t, model.OpDelete, last.Operation)
+
+	inSync, err = store.CacheInSync("test-stash")
+	require.NoError(t, err)
+	assert.True(t, inSync)
+
+	// An operation written to the log alone leaves the cache stale
+	require.NoError(t, store.jsonl.AppendRecord("test-stash", &model.Record{
+		ID:        "ts-ccc3",
+		Operation: model.OpCreate,
+		CreatedAt: time.Now(),
+		CreatedBy: "user",
+		UpdatedAt: time.Now(),
+		UpdatedBy: "user",
+		Fields:    map[string]interface{}{},
+	}))
+	inSync, err = store.CacheInSync("test-stash")
+	require.NoError(t, err)
+	assert.False(t, inSync)
+
+	require.NoError(t, store.RebuildCache("test-stash"))
+	inSync, err = store.CacheInSync("test-stash")
+	require.NoError(t, err)
+	assert.True(t, inSync)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := NewStore(MemoryDir)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.True(t, store.IsMemory())
+
+	now := time.Now()
+	stash := &model.Stash{
+		Name:      "scratch",
+		Prefix:    "sc-",
+		Created:   now,
+		CreatedBy: "test-user",
+		Columns: model.ColumnList{
+			{Name: "Name", Added: now, AddedBy: "test-user"},
+		},
+	}
+	require.NoError(t, store.CreateStash("scratch", "sc-", stash))
+
+	for _, name := range []string{"Laptop", "Desk"} {
+		id, err := store.NextRecordID("scratch")
+		require.NoError(t, err)
+		require.NoError(t, store.CreateRecord("scratch", &model.Record{
+			ID:        id,
+			CreatedAt: now,
+			CreatedBy: "test-user",
+			UpdatedAt: now,
+			UpdatedBy: "test-user",
+			Fields:    map[string]interface{}{"Name": name},
+		}))
+	}
+
+	t.Run("lists stashes and records", func(t *testing.T) {
+		stashes, err := store.ListStashes()
+		require.NoError(t, err)
+		require.Len(t, stashes, 1)
+		assert.Equal(t, "scratch", stashes[0].Name)
+
+		records, err := store.ListRecords("scratch", ListOptions{ParentID: "*"})
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("keeps history and rebuilds the cache from memory", func(t *testing.T) {
+		history, err := store.GetAllHistory("scratch")
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+
+		require.NoError(t, store.RebuildCache("scratch"))
+		records, err := store.ListRecords("scratch", ListOptions{ParentID: "*"})
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("rejects attachments", func(t *testing.T) {
+		records, err := store.ListRecords("scratch", ListOptions{ParentID: "*"})
+		require.NoError(t, err)
+		_, err = store.AttachFile("scratch", records[0].ID, "notes.txt", false, "test-user")
+		assert.ErrorIs(t, err, ErrInMemory)
+	})
+
+	t.Run("each memory store is separate", func(t *testing.T) {
+		other, err := NewMemoryStore()
+		require.NoError(t, err)
+		defer other.Close()
+
+		stashes, err := other.ListStashes()
+		require.NoError(t, err)
+		assert.Empty(t, stashes)
+	})
+
+	t.Run("drops stashes", func(t *testing.T) {
+		require.NoError(t, store.DropStash("scratch"))
+		_, err := store.GetStash("scratch")
+		assert.ErrorIs(t, err, model.ErrStashNotFound)
+	})
+}
+
+func TestStore_SetContext(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Now()
+	stash := &model.Stash{Name: "inventory", Prefix: "inv-", Created: now, Columns: model.ColumnList{{Name: "Name", Added: now}}}
+	require.NoError(t, store.CreateStash("inventory", "inv-", stash))
+	record := &model.Record{
+		ID: "inv-0001", Fields: map[string]interface{}{"Name": "Laptop"},
+		CreatedAt: now, CreatedBy: "alice", UpdatedAt: now, UpdatedBy: "alice",
+	}
+	require.NoError(t, store.CreateRecord("inventory", record))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	store.SetContext(ctx)
+	cancel()
+
+	_, err = store.ListRecords("inventory", ListOptions{ParentID: "*"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = store.GetAllHistory("inventory")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	record.Fields["Name"] = "Desk"
+	assert.ErrorIs(t, store.UpdateRecord("inventory", record), context.Canceled)
+
+	// Nothing was written
+	store.SetContext(context.Background())
+	history, err := store.GetAllHistory("inventory")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "go.opentelemetry.io/otel/attribute"
+
+// stashAttr and recordAttr label telemetry spans with the stash and record
+// an operation is on.
+func stashAttr(name string) attribute.KeyValue {
+	return attribute.String("stash.name", name)
+}
+
+func recordAttr(id string) attribute.KeyValue {
+	return attribute.String("stash.record.id", id)
+}
@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/user/stash/internal/model"
+)
+
+// ErrInTransaction is returned by operations that cannot be staged in a
+// transaction, such as schema changes and log rewrites, when the store is
+// a transaction's view (see OpenTx).
+var ErrInTransaction = errors.New("not supported in a transaction")
+
+// ErrTxNotFound is returned when a transaction has no pending area.
+var ErrTxNotFound = errors.New("transaction not found")
+
+// TxConflictError is returned by CommitTx when records the transaction
+// changed were changed by others since, or records it created were
+// created by others with the same IDs. Nothing is committed.
+type TxConflictError struct {
+	IDs []string
+}
+
+func (e *TxConflictError) Error() string {
+	return fmt.Sprintf("%d record(s) changed since the transaction read them (%s)", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// TxStash is the operations a transaction has staged for one stash.
+type TxStash struct {
+	Stash      string
+	Operations []*model.Record
+}
+
+// txDir returns the pending area of a transaction.
+func (s *Store) txDir(token string) string {
+	return filepath.Join(s.baseDir, "_tx", token)
+}
+
+// BeginTx creates an empty pending area for a transaction.
+func (s *Store) BeginTx(token string) error {
+	if s.IsMemory() {
+		return ErrInMemory
+	}
+	if err := os.MkdirAll(s.txDir(token), 0755); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return nil
+}
+
+// TxOperations returns the operations staged in a transaction, by stash
+// in name order, each in the order they were written.
+func (s *Store) TxOperations(token string) ([]TxStash, error) {
+	dir := s.txDir(token)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrTxNotFound
+		}
+		return nil, err
+	}
+	staged := NewJSONLStore(dir)
+	staged.ctx = s.ctx
+	var stashes []TxStash
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ops, err := staged.ReadAllRecords(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		stashes = append(stashes, TxStash{Stash: entry.Name(), Operations: ops})
+	}
+	sort.Slice(stashes, func(i, j int) bool { return stashes[i].Stash < stashes[j].Stash })
+	return stashes, nil
+}
+
+// txView is what a transaction's view of a store loads stashes from (see
+// OpenTx).
+type txView struct {
+	disk    *JSONLStore                // the stash logs on disk
+	pending map[string][]*model.Record // staged operations by stash
+
+	mu     sync.Mutex
+	loaded map[string]bool
+}
+
+// OpenTx returns a view of the store as a transaction sees it: an
+// in-memory copy of the stashes with the transaction's staged operations
+// applied. Operations written to the view are staged in the transaction's
+// pending area instead of the stash logs. Schema changes and log rewrites
+// fail with ErrInTransaction.
+//
+// Every stash's config is copied, but a stash's log is only copied, and
+// its cache built, when the view first uses the stash, so a command reads
+// only the stashes it touches.
+func (s *Store) OpenTx(token string) (*Store, error) {
+	pending, err := s.TxOperations(token)
+	if err != nil {
+		return nil, err
+	}
+	byStash := make(map[string][]*model.Record, len(pending))
+	for _, p := range pending {
+		byStash[p.Stash] = p.Operations
+	}
+
+	view, err := NewMemoryStore()
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.config.ListStashDirs()
+	if err != nil {
+		view.Close()
+		return nil, err
+	}
+	for _, name := range names {
+		config, err := os.ReadFile(s.config.getConfigPath(name))
+		if err != nil {
+			view.Close()
+			return nil, fmt.Errorf("failed to read config of stash '%s': %w", name, err)
+		}
+		view.config.mem.writeFile(view.config.getConfigPath(name), config)
+	}
+
+	view.view = &txView{disk: s.jsonl, pending: byStash, loaded: make(map[string]bool)}
+	view.staged = NewJSONLStore(s.txDir(token))
+	return view, nil
+}
+
+// loadTxStash copies a stash's log into a transaction's view, applies the
+// operations staged for it, and builds its cache, the first time the view
+// uses the stash. It does nothing outside a view or for unknown stashes.
+func (s *Store) loadTxStash(name string) error {
+	if s.view == nil {
+		return nil
+	}
+	s.view.mu.Lock()
+	if s.view.loaded[name] || !s.config.Exists(name) {
+		s.view.mu.Unlock()
+		return nil
+	}
+	// Marked first: rebuilding the cache reads the stash through the view
+	s.view.loaded[name] = true
+	s.view.mu.Unlock()
+
+	records, err := os.ReadFile(s.view.disk.getRecordsPath(name))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read log of stash '%s': %w", name, err)
+	}
+	s.jsonl.mem.writeFile(s.jsonl.getRecordsPath(name), records)
+	if ops := s.view.pending[name]; len(ops) > 0 {
+		if err := s.jsonl.AppendRecords(name, ops, false); err != nil {
+			return err
+		}
+	}
+	if err := s.RebuildCache(name); err != nil {
+		return fmt.Errorf("failed to load stash '%s': %w", name, err)
+	}
+	return nil
+}
+
+// loadAllTxStashes loads every stash into a transaction's view, for
+// operations that may read any of them.
+func (s *Store) loadAllTxStashes() error {
+	if s.view == nil {
+		return nil
+	}
+	names, err := s.config.ListStashDirs()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := s.loadTxStash(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inTx returns ErrInTransaction if the store is a transaction's view.
+func (s *Store) inTx() error {
+	if s.staged != nil {
+		return ErrInTransaction
+	}
+	return nil
+}
+
+// CommitTx appends a transaction's staged operations to the stash logs and
+// removes its pending area. The write lock of every stash written to is
+// held from the check to the last write. Every operation is checked
+// before any is written: an operation on a record changed since the
+// transaction read it, or a create of an ID taken since, fails the commit
+// with a *TxConflictError and leaves the transaction pending.
+//
+// Each stash's operations are appended in one write. Once they are, they
+// are removed from the pending area, so if a later stash's write fails, a
+// retried commit resumes with the stashes not yet written.
+func (s *Store) CommitTx(token string) ([]TxStash, error) {
+	pending, err := s.TxOperations(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stashes are locked in name order, so commits cannot deadlock
+	for _, p := range pending {
+		unlock, err := s.lockStash(p.Stash)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	var conflicts []string
+	for _, p := range pending {
+		seen := make(map[string]bool)
+		for _, op := range p.Operations {
+			if seen[op.ID] {
+				continue
+			}
+			seen[op.ID] = true
+			current, err := s.sqlite.GetRecord(p.Stash, op.ID, nil)
+			switch {
+			case errors.Is(err, model.ErrRecordNotFound):
+				if op.Operation != model.OpCreate {
+					conflicts = append(conflicts, op.ID)
+				}
+			case err != nil:
+				return nil, err
+			case op.Operation == model.OpCreate || current.Hash != op.BaseHash:
+				conflicts = append(conflicts, op.ID)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &TxConflictError{IDs: conflicts}
+	}
+
+	// Operations keep the source and run they were staged with
+	for _, p := range pending {
+		stash, err := s.GetStash(p.Stash)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range p.Operations {
+			stripComputedFields(stash, op)
+			op.Hash = op.CalculateHash()
+			op.PrevHash = ""
+		}
+		if err := s.appendLogs(stash, p.Operations); err != nil {
+			return nil, fmt.Errorf("failed to commit to stash '%s': %w", p.Stash, err)
+		}
+		if err := os.RemoveAll(filepath.Join(s.txDir(token), p.Stash)); err != nil {
+			return nil, fmt.Errorf("failed to record commit to stash '%s': %w", p.Stash, err)
+		}
+		columns := stash.Columns.StoredNames()
+		for _, op := range p.Operations {
+			if err := s.sqlite.UpsertRecord(p.Stash, op, columns); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pending, s.RollbackTx(token)
+}
+
+// RollbackTx discards a transaction's staged operations.
+func (s *Store) RollbackTx(token string) error {
+	dir := s.txDir(token)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return ErrTxNotFound
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/stash/internal/model"
+)
+
+func TestStore_Transaction(t *testing.T) {
+	newStore := func(t *testing.T) *Store {
+		t.Helper()
+		store, err := NewStore(t.TempDir())
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+
+		stash := &model.Stash{
+			Name:    "inventory",
+			Prefix:  "inv-",
+			Created: time.Now(),
+			Columns: model.ColumnList{{Name: "Name", Added: time.Now()}},
+		}
+		require.NoError(t, store.CreateStash("inventory", "inv-", stash))
+		now := time.Now()
+		require.NoError(t, store.CreateRecord("inventory", &model.Record{
+			ID: "inv-ex4j", CreatedAt: now, UpdatedAt: now,
+			Fields: map[string]interface{}{"Name": "Laptop"},
+		}))
+		return store
+	}
+	stageRename := func(t *testing.T, store *Store, token string) {
+		t.Helper()
+		view, err := store.OpenTx(token)
+		require.NoError(t, err)
+		defer view.Close()
+		record, err := view.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		record.Fields["Name"] = "Laptop Pro"
+		require.NoError(t, view.UpdateRecord("inventory", record))
+		now := time.Now()
+		require.NoError(t, view.CreateRecord("inventory", &model.Record{
+			ID: "inv-8t2m", CreatedAt: now, UpdatedAt: now,
+			Fields: map[string]interface{}{"Name": "Mouse"},
+		}))
+	}
+
+	t.Run("staged operations are seen only in the transaction until commit", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.BeginTx("tx-a1b2"))
+		stageRename(t, store, "tx-a1b2")
+
+		record, err := store.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		assert.Equal(t, "Laptop", record.Fields["Name"])
+		_, err = store.GetRecord("inventory", "inv-8t2m")
+		assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+		view, err := store.OpenTx("tx-a1b2")
+		require.NoError(t, err)
+		record, err = view.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		assert.Equal(t, "Laptop Pro", record.Fields["Name"])
+		view.Close()
+
+		committed, err := store.CommitTx("tx-a1b2")
+		require.NoError(t, err)
+		require.Len(t, committed, 1)
+		assert.Len(t, committed[0].Operations, 2)
+
+		record, err = store.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		assert.Equal(t, "Laptop Pro", record.Fields["Name"])
+		_, err = store.GetRecord("inventory", "inv-8t2m")
+		assert.NoError(t, err)
+
+		_, err = store.TxOperations("tx-a1b2")
+		assert.ErrorIs(t, err, ErrTxNotFound)
+	})
+
+	t.Run("a record changed since it was staged fails the commit", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.BeginTx("tx-c3d4"))
+		stageRename(t, store, "tx-c3d4")
+
+		record, err := store.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		record.Fields["Name"] = "Laptop Air"
+		require.NoError(t, store.UpdateRecord("inventory", record))
+
+		_, err = store.CommitTx("tx-c3d4")
+		var conflict *TxConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{"inv-ex4j"}, conflict.IDs)
+
+		// Nothing was written, and the transaction is still open
+		_, err = store.GetRecord("inventory", "inv-8t2m")
+		assert.ErrorIs(t, err, model.ErrRecordNotFound)
+		pending, err := store.TxOperations("tx-c3d4")
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+
+		require.NoError(t, store.RollbackTx("tx-c3d4"))
+		_, err = store.TxOperations("tx-c3d4")
+		assert.ErrorIs(t, err, ErrTxNotFound)
+	})
+
+	t.Run("schema changes cannot be staged", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.BeginTx("tx-e5f6"))
+		view, err := store.OpenTx("tx-e5f6")
+		require.NoError(t, err)
+		defer view.Close()
+
+		err = view.AddColumn("inventory", model.Column{Name: "Price", Added: time.Now()})
+		assert.ErrorIs(t, err, ErrInTransaction)
+	})
+
+	addOrders := func(t *testing.T, store *Store) {
+		t.Helper()
+		stash := &model.Stash{
+			Name:    "orders",
+			Prefix:  "ord-",
+			Created: time.Now(),
+			Columns: model.ColumnList{{Name: "Item", Added: time.Now()}},
+		}
+		require.NoError(t, store.CreateStash("orders", "ord-", stash))
+	}
+
+	t.Run("a retried commit resumes with the stashes not yet written", func(t *testing.T) {
+		store := newStore(t)
+		addOrders(t, store)
+		require.NoError(t, store.BeginTx("tx-g7h8"))
+		stageRename(t, store, "tx-g7h8")
+		view, err := store.OpenTx("tx-g7h8")
+		require.NoError(t, err)
+		now := time.Now()
+		require.NoError(t, view.CreateRecord("orders", &model.Record{
+			ID: "ord-k9m2", CreatedAt: now, UpdatedAt: now,
+			Fields: map[string]interface{}{"Item": "inv-8t2m"},
+		}))
+		view.Close()
+
+		// The orders log cannot be written, so the commit stops after inventory
+		log := filepath.Join(store.baseDir, "orders", "records.jsonl")
+		require.NoError(t, os.RemoveAll(log))
+		require.NoError(t, os.Mkdir(log, 0755))
+		_, err = store.CommitTx("tx-g7h8")
+		require.Error(t, err)
+		_, err = store.GetRecord("inventory", "inv-8t2m")
+		require.NoError(t, err)
+		pending, err := store.TxOperations("tx-g7h8")
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "orders", pending[0].Stash)
+
+		require.NoError(t, os.Remove(log))
+		committed, err := store.CommitTx("tx-g7h8")
+		require.NoError(t, err)
+		require.Len(t, committed, 1)
+		_, err = store.GetRecord("orders", "ord-k9m2")
+		assert.NoError(t, err)
+		history, err := store.GetRecordHistory("inventory", "inv-8t2m")
+		require.NoError(t, err)
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("a view loads only the stashes it uses", func(t *testing.T) {
+		store := newStore(t)
+		addOrders(t, store)
+		require.NoError(t, store.BeginTx("tx-j1k2"))
+		view, err := store.OpenTx("tx-j1k2")
+		require.NoError(t, err)
+		defer view.Close()
+
+		stashes, err := view.ListStashes()
+		require.NoError(t, err)
+		assert.Len(t, stashes, 2)
+		assert.Empty(t, view.view.loaded)
+
+		_, err = view.GetRecord("inventory", "inv-ex4j")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"inventory": true}, view.view.loaded)
+	})
+}
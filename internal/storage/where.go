@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ParseWhereClause parses a WHERE clause string into a WhereCondition.
+// Supported formats:
+//   - field=value
+//   - field!=value
+//   - field>value, field<value, field>=value, field<=value, where value
+//     may be a relative date such as -7d or now+2w
+//   - field LIKE pattern
+//   - field IS NULL, field IS NOT NULL
+//   - field IS EMPTY, field IS NOT EMPTY
+//   - field CONTAINS value
+func ParseWhereClause(clause string) (WhereCondition, error) {
+	clause = strings.TrimSpace(clause)
+
+	// Check for IS NULL / IS NOT NULL / IS EMPTY / IS NOT EMPTY (case-insensitive)
+	isNullRegex := regexp.MustCompile(`(?i)^(\S+)\s+IS\s+(NOT\s+)?(NULL|EMPTY)$`)
+	if matches := isNullRegex.FindStringSubmatch(clause); len(matches) == 4 {
+		operator := strings.ToUpper(matches[3]) // NULL or EMPTY
+		if matches[2] != "" {
+			operator = "IS NOT " + operator
+		} else {
+			operator = "IS " + operator
+		}
+		return WhereCondition{
+			Field:    matches[1],
+			Operator: operator,
+			Value:    "",
+		}, nil
+	}
+
+	// Check for LIKE operator (case-insensitive)
+	likeRegex := regexp.MustCompile(`(?i)^(\S+)\s+LIKE\s+(.+)$`)
+	if matches := likeRegex.FindStringSubmatch(clause); len(matches) == 3 {
+		return WhereCondition{
+			Field:    matches[1],
+			Operator: "LIKE",
+			Value:    stripQuotes(matches[2]),
+		}, nil
+	}
+
+	// Check for CONTAINS operator (case-insensitive), for list columns
+	containsRegex := regexp.MustCompile(`(?i)^(\S+)\s+CONTAINS\s+(.+)$`)
+	if matches := containsRegex.FindStringSubmatch(clause); len(matches) == 3 {
+		return WhereCondition{
+			Field:    matches[1],
+			Operator: "CONTAINS",
+			Value:    stripQuotes(matches[2]),
+		}, nil
+	}
+
+	// Check for comparison operators (order matters: >= before >, <= before <, != before =)
+	operators := []string{"!=", ">=", "<=", "<>", ">", "<", "="}
+	for _, op := range operators {
+		if idx := strings.Index(clause, op); idx > 0 {
+			field := strings.TrimSpace(clause[:idx])
+			value := strings.TrimSpace(clause[idx+len(op):])
+			if op != "=" && op != "!=" && op != "<>" {
+				if _, _, err := ParseRelativeDate(stripQuotes(value), time.Now()); err != nil {
+					return WhereCondition{}, err
+				}
+			}
+			return WhereCondition{
+				Field:    field,
+				Operator: op,
+				Value:    stripQuotes(value),
+			}, nil
+		}
+	}
+
+	return WhereCondition{}, fmt.Errorf("invalid WHERE clause: %s (expected format: field=value, field>value, field LIKE pattern, field CONTAINS value, or field IS NULL/EMPTY)", clause)
+}
+
+// stripQuotes removes surrounding quotes from a string.
+func stripQuotes(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
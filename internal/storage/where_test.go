@@ -0,0 +1,71 @@
+package storage
+
+import "testing"
+
+// TestParseWhereClause tests the WHERE clause parser
+func TestParseWhereClause(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected WhereCondition
+		wantErr  bool
+	}{
+		// Existing operators
+		{"field=value", WhereCondition{Field: "field", Operator: "=", Value: "value"}, false},
+		{"field!=value", WhereCondition{Field: "field", Operator: "!=", Value: "value"}, false},
+		{"field>100", WhereCondition{Field: "field", Operator: ">", Value: "100"}, false},
+		{"field<100", WhereCondition{Field: "field", Operator: "<", Value: "100"}, false},
+		{"field>=100", WhereCondition{Field: "field", Operator: ">=", Value: "100"}, false},
+		{"field<=100", WhereCondition{Field: "field", Operator: "<=", Value: "100"}, false},
+		{"field LIKE %pattern%", WhereCondition{Field: "field", Operator: "LIKE", Value: "%pattern%"}, false},
+
+		// IS NULL / IS NOT NULL
+		{"field IS NULL", WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
+		{"field IS NOT NULL", WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
+
+		// IS EMPTY / IS NOT EMPTY
+		{"field IS EMPTY", WhereCondition{Field: "field", Operator: "IS EMPTY", Value: ""}, false},
+		{"field IS NOT EMPTY", WhereCondition{Field: "field", Operator: "IS NOT EMPTY", Value: ""}, false},
+
+		// Case insensitivity
+		{"field is null", WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
+		{"field is not null", WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
+		{"field is empty", WhereCondition{Field: "field", Operator: "IS EMPTY", Value: ""}, false},
+		{"field is not empty", WhereCondition{Field: "field", Operator: "IS NOT EMPTY", Value: ""}, false},
+		{"Field Is Empty", WhereCondition{Field: "Field", Operator: "IS EMPTY", Value: ""}, false},
+		{"Field IS Not NULL", WhereCondition{Field: "Field", Operator: "IS NOT NULL", Value: ""}, false},
+
+		// Whitespace handling
+		{"  field IS NULL  ", WhereCondition{Field: "field", Operator: "IS NULL", Value: ""}, false},
+		{"field   IS   NOT   NULL", WhereCondition{Field: "field", Operator: "IS NOT NULL", Value: ""}, false},
+
+		// Relative dates
+		{"created_at > -7d", WhereCondition{Field: "created_at", Operator: ">", Value: "-7d"}, false},
+		{"due < now+2w", WhereCondition{Field: "due", Operator: "<", Value: "now+2w"}, false},
+		{"due < now+2q", WhereCondition{}, true},
+
+		// Invalid
+		{"invalid", WhereCondition{}, true},
+		{"", WhereCondition{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseWhereClause(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseWhereClause(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if got.Field != tt.expected.Field {
+					t.Errorf("ParseWhereClause(%q) Field = %q, want %q", tt.input, got.Field, tt.expected.Field)
+				}
+				if got.Operator != tt.expected.Operator {
+					t.Errorf("ParseWhereClause(%q) Operator = %q, want %q", tt.input, got.Operator, tt.expected.Operator)
+				}
+				if got.Value != tt.expected.Value {
+					t.Errorf("ParseWhereClause(%q) Value = %q, want %q", tt.input, got.Value, tt.expected.Value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/user/stash/internal/platform"
+)
+
+// Writes to a stash are serialized between processes by an advisory lock
+// on records.jsonl.lock beside its log. It is held from reading the state a
+// write depends on (the next ID, whether an ID is taken, the chain head)
+// until the write is in the log and the cache, so two writers cannot act
+// on the same state. The lock is reentrant within a store, so an operation
+// holding it can call others that take it too.
+
+// heldLock is a stash write lock held by this store.
+type heldLock struct {
+	file  *platform.FileLock
+	depth int
+}
+
+// writeLockPath returns the path to a stash's write lock file.
+func (s *Store) writeLockPath(stashName string) string {
+	return filepath.Join(s.baseDir, stashName, "records.jsonl.lock")
+}
+
+// lockStash takes a stash's write lock and returns the function that
+// releases it. In-memory stores have a single writer and take no lock.
+func (s *Store) lockStash(stashName string) (func(), error) {
+	if s.IsMemory() {
+		return func() {}, nil
+	}
+
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+	held := s.held[stashName]
+	if held == nil {
+		if err := s.jsonl.ensureStashDir(stashName); err != nil {
+			return nil, err
+		}
+		file, err := platform.LockFile(s.writeLockPath(stashName))
+		if err != nil {
+			return nil, err
+		}
+		held = &heldLock{file: file}
+		if s.held == nil {
+			s.held = make(map[string]*heldLock)
+		}
+		s.held[stashName] = held
+	}
+	held.depth++
+
+	return func() {
+		s.lockMu.Lock()
+		defer s.lockMu.Unlock()
+		held.depth--
+		if held.depth == 0 {
+			held.file.Unlock()
+			delete(s.held, stashName)
+		}
+	}, nil
+}
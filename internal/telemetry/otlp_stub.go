@@ -0,0 +1,23 @@
+//go:build !otlp
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// errNoOTLP is returned when the otlp exporter is configured in a build
+// without the otlp tag.
+var errNoOTLP = errors.New("this stash was built without the OTLP exporters; rebuild with -tags otlp, or set OTEL_TRACES_EXPORTER and OTEL_METRICS_EXPORTER to console or none")
+
+func newOTLPTraceExporter(ctx context.Context, protocol string) (sdktrace.SpanExporter, error) {
+	return nil, errNoOTLP
+}
+
+func newOTLPMetricExporter(ctx context.Context, protocol string) (sdkmetric.Exporter, error) {
+	return nil, errNoOTLP
+}
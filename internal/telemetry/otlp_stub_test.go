@@ -0,0 +1,22 @@
+//go:build !otlp
+
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExportersNotBuilt(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_METRICS_EXPORTER", "")
+	defer Shutdown(context.Background())
+
+	_, err := Setup(context.Background(), "test")
+	assert.ErrorIs(t, err, errNoOTLP)
+	assert.False(t, Enabled())
+}
@@ -0,0 +1,39 @@
+//go:build otlp
+
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExporters(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_METRICS_EXPORTER", "")
+	// Nothing listens on the endpoint, so shut down without flushing
+	stopped, cancel := context.WithCancel(context.Background())
+	cancel()
+	defer Shutdown(stopped)
+
+	for _, protocol := range []string{"http/protobuf", "grpc"} {
+		t.Run(protocol, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", protocol)
+			_, err := Setup(context.Background(), "test")
+			require.NoError(t, err)
+			assert.True(t, Enabled())
+			Shutdown(stopped)
+		})
+	}
+
+	t.Run("unsupported protocol", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+		_, err := Setup(context.Background(), "test")
+		assert.ErrorContains(t, err, "unsupported OTLP protocol 'http/json'")
+		assert.False(t, Enabled())
+	})
+}
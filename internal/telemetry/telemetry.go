@@ -0,0 +1,296 @@
+// Package telemetry reports traces and metrics of stash commands to an
+// OpenTelemetry collector, so teams running stash inside agent fleets can
+// see its latency contribution alongside their other services.
+//
+// Telemetry is off unless it is configured with the standard OTEL_*
+// environment variables: an OTLP endpoint (OTEL_EXPORTER_OTLP_ENDPOINT) or
+// an exporter (OTEL_TRACES_EXPORTER, OTEL_METRICS_EXPORTER). While it is
+// off, Start and the counters do nothing.
+//
+// Each command is a root span. Store operations, JSONL reads and writes,
+// and cache queries are spans within it, nested in the order they run.
+//
+// The console exporter is in every build. The OTLP exporters pull in gRPC
+// and protobuf, so they are only built with the otlp tag:
+//
+//	go build -tags otlp ./cmd/stash
+//
+// Without it, configuring the otlp exporter is an error (see otlp.go).
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope names the instrumentation in exported traces and metrics.
+const scope = "github.com/user/stash"
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	tracer  trace.Tracer           // nil unless traces are exported
+	active  = context.Background() // context of the innermost open span
+
+	recordsWritten metric.Int64Counter
+	cacheRebuilds  metric.Int64Counter
+
+	shutdowns []func(context.Context) error
+)
+
+// Setup starts telemetry as configured by the OTEL_* environment variables,
+// reporting the stash version as service.version. It returns a function
+// that flushes and stops it; that function does nothing if telemetry is
+// off.
+func Setup(ctx context.Context, version string) (func(context.Context) error, error) {
+	Shutdown(ctx)
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return Shutdown, nil
+	}
+	tracesExporter := exporterName("TRACES")
+	metricsExporter := exporterName("METRICS")
+	if tracesExporter == "none" && metricsExporter == "none" {
+		return Shutdown, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "stash"),
+			attribute.String("service.version", version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe telemetry resource: %w", err)
+	}
+
+	var tp *sdktrace.TracerProvider
+	if tracesExporter != "none" {
+		exporter, err := newTraceExporter(ctx, tracesExporter)
+		if err != nil {
+			return nil, err
+		}
+		tp = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	}
+	var mp *sdkmetric.MeterProvider
+	if metricsExporter != "none" {
+		exporter, err := newMetricExporter(ctx, metricsExporter)
+		if err != nil {
+			if tp != nil {
+				tp.Shutdown(ctx)
+			}
+			return nil, err
+		}
+		mp = sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)), sdkmetric.WithResource(res))
+	}
+
+	start(tp, mp)
+	return Shutdown, nil
+}
+
+// exporterName returns the exporter configured for a signal (TRACES or
+// METRICS): the value of OTEL_<signal>_EXPORTER, or otlp when only an OTLP
+// endpoint is set, or none.
+func exporterName(signal string) string {
+	if name := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_" + signal + "_EXPORTER"))); name != "" {
+		return name
+	}
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_"+signal+"_ENDPOINT") != "" {
+		return "otlp"
+	}
+	return "none"
+}
+
+// otlpProtocol returns the OTLP protocol configured for a signal.
+func otlpProtocol(signal string) string {
+	if p := os.Getenv("OTEL_EXPORTER_OTLP_" + signal + "_PROTOCOL"); p != "" {
+		return p
+	}
+	if p := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); p != "" {
+		return p
+	}
+	return "http/protobuf"
+}
+
+// newTraceExporter creates the span exporter with the given name. The
+// exporters read the rest of their configuration (endpoint, headers,
+// timeout) from the environment themselves. Console output goes to stderr,
+// keeping stdout for the command's own output.
+func newTraceExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "otlp":
+		return newOTLPTraceExporter(ctx, otlpProtocol("TRACES"))
+	case "console":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("unsupported traces exporter '%s' (valid: otlp, console, none)", name)
+	}
+}
+
+// newMetricExporter creates the metric exporter with the given name.
+func newMetricExporter(ctx context.Context, name string) (sdkmetric.Exporter, error) {
+	switch name {
+	case "otlp":
+		return newOTLPMetricExporter(ctx, otlpProtocol("METRICS"))
+	case "console":
+		return stdoutmetric.New(stdoutmetric.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("unsupported metrics exporter '%s' (valid: otlp, console, none)", name)
+	}
+}
+
+// start reports spans to tp and metrics to mp; either may be nil.
+func start(tp *sdktrace.TracerProvider, mp *sdkmetric.MeterProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	enabled = true
+	active = context.Background()
+	tracer = nil
+	if tp != nil {
+		tracer = tp.Tracer(scope)
+		shutdowns = append(shutdowns, tp.Shutdown)
+	}
+	if mp != nil {
+		meter := mp.Meter(scope)
+		recordsWritten, _ = meter.Int64Counter("stash.records.written",
+			metric.WithDescription("Operations appended to records.jsonl"),
+			metric.WithUnit("{operation}"))
+		cacheRebuilds, _ = meter.Int64Counter("stash.cache.rebuilds",
+			metric.WithDescription("Rebuilds of the SQLite cache from records.jsonl"),
+			metric.WithUnit("{rebuild}"))
+		shutdowns = append(shutdowns, mp.Shutdown)
+	} else {
+		recordsWritten, cacheRebuilds = nil, nil
+	}
+}
+
+// Shutdown flushes pending spans and metrics and turns telemetry off.
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	pending := shutdowns
+	shutdowns = nil
+	enabled = false
+	tracer = nil
+	active = context.Background()
+	recordsWritten, cacheRebuilds = nil, nil
+	mu.Unlock()
+
+	var errs []error
+	for _, shutdown := range pending {
+		if err := shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Enabled reports whether telemetry is on, so callers can skip building
+// expensive attributes.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Span is an operation being timed. A nil Span, returned while traces are
+// not exported, does nothing.
+type Span struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// Start begins a span within the innermost open one. Spans must be ended
+// in the reverse order they were started.
+func Start(name string, attrs ...attribute.KeyValue) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	if tracer == nil {
+		return nil
+	}
+	parent := active
+	ctx, span := tracer.Start(parent, name, trace.WithAttributes(attrs...))
+	active = ctx
+	return &Span{span: span, parent: parent}
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...attribute.KeyValue) {
+	if s != nil {
+		s.span.SetAttributes(attrs...)
+	}
+}
+
+// End ends the span, marking it failed if *errp is an error. It takes a
+// pointer so it can be deferred with a named error result.
+func (s *Span) End(errp *error) {
+	if s == nil {
+		return
+	}
+	if errp != nil && *errp != nil {
+		s.span.RecordError(*errp)
+		s.span.SetStatus(codes.Error, (*errp).Error())
+	}
+	s.span.End()
+
+	mu.Lock()
+	active = s.parent
+	mu.Unlock()
+}
+
+// Record adds a span that has already finished, started at start and
+// ending now, within the innermost open span.
+func Record(name string, start time.Time, err error, attrs ...attribute.KeyValue) {
+	mu.Lock()
+	if tracer == nil {
+		mu.Unlock()
+		return
+	}
+	_, span := tracer.Start(active, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	mu.Unlock()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RecordWritten counts an operation appended to a stash's records.jsonl.
+func RecordWritten(stash, op string) {
+	mu.Lock()
+	counter := recordsWritten
+	mu.Unlock()
+	if counter != nil {
+		counter.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("stash.name", stash),
+			attribute.String("stash.operation", op),
+		))
+	}
+}
+
+// CacheRebuilt counts a rebuild of a stash's cache.
+func CacheRebuilt(stash string) {
+	mu.Lock()
+	counter := cacheRebuilds
+	mu.Unlock()
+	if counter != nil {
+		counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("stash.name", stash)))
+	}
+}
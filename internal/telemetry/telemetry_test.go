@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	for _, name := range []string{"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER", "OTEL_METRICS_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		t.Setenv(name, "")
+	}
+	defer Shutdown(context.Background())
+
+	t.Run("off without configuration", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), "test")
+		require.NoError(t, err)
+		assert.False(t, Enabled())
+		assert.Nil(t, Start("op"))
+		Start("op").End(nil)
+		RecordWritten("tasks", "create")
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("endpoint selects otlp", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+		assert.Equal(t, "otlp", exporterName("TRACES"))
+		assert.Equal(t, "otlp", exporterName("METRICS"))
+		t.Setenv("OTEL_METRICS_EXPORTER", "none")
+		assert.Equal(t, "none", exporterName("METRICS"))
+	})
+
+	t.Run("console exporter", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "console")
+		_, err := Setup(context.Background(), "test")
+		require.NoError(t, err)
+		assert.True(t, Enabled())
+		require.NoError(t, Shutdown(context.Background()))
+		assert.False(t, Enabled())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "console")
+		t.Setenv("OTEL_SDK_DISABLED", "true")
+		_, err := Setup(context.Background(), "test")
+		require.NoError(t, err)
+		assert.False(t, Enabled())
+	})
+
+	t.Run("unsupported settings", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+		_, err := Setup(context.Background(), "test")
+		assert.ErrorContains(t, err, "unsupported traces exporter 'zipkin'")
+		assert.False(t, Enabled())
+	})
+}
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	start(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), nil)
+	defer Shutdown(context.Background())
+
+	failed := errors.New("disk full")
+	root := Start("stash add")
+	op := Start("Store.CreateRecord", attribute.String("stash.name", "tasks"))
+	Record("sqlite.query", time.Now().Add(-time.Millisecond), nil)
+	op.End(&failed)
+	Record("sqlite.query", time.Now(), nil)
+	var ok error
+	root.End(&ok)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	byName := func(name string, n int) tracetest.SpanStub {
+		for _, s := range spans {
+			if s.Name == name {
+				if n == 0 {
+					return s
+				}
+				n--
+			}
+		}
+		t.Fatalf("no span %s", name)
+		return tracetest.SpanStub{}
+	}
+	rootSpan, opSpan := byName("stash add", 0), byName("Store.CreateRecord", 0)
+
+	assert.False(t, rootSpan.Parent.IsValid())
+	assert.Equal(t, rootSpan.SpanContext.SpanID(), opSpan.Parent.SpanID())
+	assert.Equal(t, opSpan.SpanContext.SpanID(), byName("sqlite.query", 0).Parent.SpanID())
+	assert.Equal(t, rootSpan.SpanContext.SpanID(), byName("sqlite.query", 1).Parent.SpanID())
+	assert.Equal(t, rootSpan.SpanContext.TraceID(), opSpan.SpanContext.TraceID())
+
+	assert.Equal(t, codes.Error, opSpan.Status.Code)
+	assert.Equal(t, "disk full", opSpan.Status.Description)
+	assert.Equal(t, codes.Unset, rootSpan.Status.Code)
+	assert.Contains(t, opSpan.Attributes, attribute.String("stash.name", "tasks"))
+}
+
+func TestCounters(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	start(nil, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer Shutdown(context.Background())
+
+	RecordWritten("tasks", "create")
+	RecordWritten("tasks", "create")
+	RecordWritten("tasks", "update")
+	CacheRebuilt("tasks")
+	assert.Nil(t, Start("op"), "spans are off without a tracer provider")
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+	sums := make(map[string]int64)
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				op, _ := dp.Attributes.Value("stash.operation")
+				sums[m.Name+" "+op.AsString()] += dp.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"stash.records.written create": 2,
+		"stash.records.written update": 1,
+		"stash.cache.rebuilds ":        1,
+	}, sums)
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/user/stash/tests/testutil"
+)
+
+// lockWorkers is how many stash processes each contention test runs at once.
+const lockWorkers = 8
+
+// runParallel runs one stash command per worker at the same time and
+// returns their results in worker order.
+func runParallel(t *testing.T, dir string, args func(i int) []string) []testutil.Result {
+	t.Helper()
+	results := make([]testutil.Result, lockWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < lockWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = testutil.RunStashInDir(t, dir, args(i)...)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// TestLockContention tests lock commands run by parallel agents
+func TestLockContention(t *testing.T) {
+	t.Run("one agent wins a contested record", func(t *testing.T) {
+		tmpDir := testutil.SetupStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		id := strings.TrimSpace(testutil.MustSucceedInDir(t, tmpDir, "add", "Laptop").Stdout)
+
+		results := runParallel(t, tmpDir, func(i int) []string {
+			return []string{"lock", id, "--agent", fmt.Sprintf("agent-%d", i)}
+		})
+
+		won := 0
+		for i, r := range results {
+			switch r.ExitCode {
+			case 0:
+				won++
+			case 5:
+			default:
+				t.Errorf("agent-%d: unexpected exit code %d: %s", i, r.ExitCode, r.Stderr)
+			}
+		}
+		if won != 1 {
+			t.Errorf("expected exactly 1 agent to take the lock, got %d", won)
+		}
+
+		locks := testutil.ParseJSONOutput(t, testutil.MustSucceedInDir(t, tmpDir, "locks", "--json").Stdout)
+		if len(locks) != 1 {
+			t.Errorf("expected 1 lock, got %d", len(locks))
+		}
+	})
+
+	t.Run("locks on different records are all kept", func(t *testing.T) {
+		tmpDir := testutil.SetupStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		ids := make([]string, lockWorkers)
+		for i := range ids {
+			ids[i] = strings.TrimSpace(testutil.MustSucceedInDir(t, tmpDir, "add", fmt.Sprintf("Item %d", i)).Stdout)
+		}
+
+		results := runParallel(t, tmpDir, func(i int) []string {
+			return []string{"lock", ids[i], "--agent", fmt.Sprintf("agent-%d", i)}
+		})
+		for i, r := range results {
+			testutil.AssertExitCode(t, r, 0)
+			if r.ExitCode != 0 {
+				t.Logf("agent-%d: %s", i, r.Stderr)
+			}
+		}
+
+		locks := testutil.ParseJSONOutput(t, testutil.MustSucceedInDir(t, tmpDir, "locks", "--json").Stdout)
+		if len(locks) != lockWorkers {
+			t.Errorf("expected %d locks, got %d", lockWorkers, len(locks))
+		}
+
+		// Unlocking in parallel loses no updates either
+		results = runParallel(t, tmpDir, func(i int) []string {
+			return []string{"unlock", ids[i]}
+		})
+		for _, r := range results {
+			testutil.AssertExitCode(t, r, 0)
+		}
+		result := testutil.MustSucceedInDir(t, tmpDir, "locks", "--json")
+		if locks := testutil.ParseJSONOutput(t, result.Stdout); len(locks) != 0 {
+			t.Errorf("expected no locks, got %d", len(locks))
+		}
+	})
+
+	t.Run("each stash keeps its own locks file", func(t *testing.T) {
+		tmpDir := testutil.SetupStashWithColumns(t, "inventory", "inv-", []string{"Name"})
+		testutil.MustSucceedInDir(t, tmpDir, "init", "contacts", "--prefix", "ct-")
+		testutil.MustSucceedInDir(t, tmpDir, "column", "add", "Name", "--stash", "contacts")
+		invID := strings.TrimSpace(testutil.MustSucceedInDir(t, tmpDir, "add", "Laptop", "--stash", "inventory").Stdout)
+		ctID := strings.TrimSpace(testutil.MustSucceedInDir(t, tmpDir, "add", "Alice", "--stash", "contacts").Stdout)
+
+		testutil.MustSucceedInDir(t, tmpDir, "lock", invID, "--stash", "inventory")
+		testutil.MustSucceedInDir(t, tmpDir, "lock", ctID, "--stash", "contacts")
+
+		for _, name := range []string{"inventory", "contacts"} {
+			testutil.AssertFileExists(t, filepath.Join(testutil.StashDir(tmpDir, name), "locks.json"))
+			result := testutil.MustSucceedInDir(t, tmpDir, "locks", "--json", "--stash", name)
+			if locks := testutil.ParseJSONOutput(t, result.Stdout); len(locks) != 1 {
+				t.Errorf("expected 1 lock in %s, got %d", name, len(locks))
+			}
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, ".stash", "locks.json")); err == nil {
+			t.Error("expected no shared locks file")
+		}
+	})
+}